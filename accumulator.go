@@ -54,6 +54,17 @@ type Accumulator interface {
 	WithTracking(maxTracked int) TrackingAccumulator
 }
 
+// BatchAccumulator is an Accumulator able to add a batch of metrics at once,
+// such as the metrics parsed from a request or packet, saving the per metric
+// overhead of AddMetric.
+type BatchAccumulator interface {
+	Accumulator
+
+	// AddMetrics adds the metrics to the accumulator.  The accumulator does
+	// not retain the slice, which may be reused by the caller.
+	AddMetrics(metrics []Metric)
+}
+
 // TrackingID uniquely identifies a tracked metric group
 type TrackingID uint64
 
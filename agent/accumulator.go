@@ -5,6 +5,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/models"
 )
 
 type MetricMaker interface {
@@ -83,6 +84,14 @@ func (ac *accumulator) AddMetric(m telegraf.Metric) {
 	}
 }
 
+// AddMetrics adds the batch of metrics, such as the metrics parsed from a
+// single request, to the accumulator.
+func (ac *accumulator) AddMetrics(metrics []telegraf.Metric) {
+	for _, m := range metrics {
+		ac.AddMetric(m)
+	}
+}
+
 func (ac *accumulator) addFields(
 	measurement string,
 	tags map[string]string,
@@ -96,7 +105,12 @@ func (ac *accumulator) addFields(
 	}
 	if m := ac.maker.MakeMetric(m); m != nil {
 		ac.metrics <- m
+		return
 	}
+
+	// The metric was created here and filtered before reaching any other
+	// plugin, so nothing references it anymore.
+	metric.Release(m)
 }
 
 // AddError passes a runtime error to the accumulator.
@@ -123,29 +137,47 @@ func (ac *accumulator) getTime(t []time.Time) time.Time {
 }
 
 func (ac *accumulator) WithTracking(maxTracked int) telegraf.TrackingAccumulator {
+	var outputs []string
+	if input, ok := ac.maker.(*models.RunningInput); ok {
+		outputs = input.Config.DeliveryOutputs
+	}
+
 	return &trackingAccumulator{
 		Accumulator: ac,
 		delivered:   make(chan telegraf.DeliveryInfo, maxTracked),
+		outputs:     outputs,
 	}
 }
 
 type trackingAccumulator struct {
 	telegraf.Accumulator
 	delivered chan telegraf.DeliveryInfo
+
+	// outputs are the only outputs whose rejections prevent the delivery,
+	// when empty all outputs are.
+	outputs []string
 }
 
 func (a *trackingAccumulator) AddTrackingMetric(m telegraf.Metric) telegraf.TrackingID {
-	dm, id := metric.WithTracking(m, a.onDelivery)
+	dm, id := metric.WithOutputTracking(m, a.outputs, a.onDelivery)
 	a.AddMetric(dm)
 	return id
 }
 
 func (a *trackingAccumulator) AddTrackingMetricGroup(group []telegraf.Metric) telegraf.TrackingID {
-	db, id := metric.WithGroupTracking(group, a.onDelivery)
-	for _, m := range db {
+	db, id := metric.WithOutputGroupTracking(group, a.outputs, a.onDelivery)
+	a.AddMetrics(db)
+	return id
+}
+
+func (a *trackingAccumulator) AddMetrics(metrics []telegraf.Metric) {
+	if acc, ok := a.Accumulator.(telegraf.BatchAccumulator); ok {
+		acc.AddMetrics(metrics)
+		return
+	}
+	for _, m := range metrics {
 		a.AddMetric(m)
 	}
-	return id
 }
 
 func (a *trackingAccumulator) Delivered() <-chan telegraf.DeliveryInfo {
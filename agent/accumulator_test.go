@@ -10,6 +10,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -158,3 +159,90 @@ func (tm *TestMetricMaker) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 func (tm *TestMetricMaker) Log() telegraf.Logger {
 	return models.NewLogger("TestPlugin", "test", "")
 }
+
+func TestAddMetrics(t *testing.T) {
+	ch := make(chan telegraf.Metric, 10)
+	acc := NewAccumulator(&TestMetricMaker{}, ch).(telegraf.BatchAccumulator)
+
+	now := time.Now()
+	acc.AddMetrics([]telegraf.Metric{
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"value": 1}, now),
+		testutil.MustMetric("mem", nil, map[string]interface{}{"value": 2}, now),
+	})
+
+	require.Equal(t, "cpu", (<-ch).Name())
+	require.Equal(t, "mem", (<-ch).Name())
+}
+
+func TestAddTrackingMetricGroup(t *testing.T) {
+	ch := make(chan telegraf.Metric, 10)
+	acc := NewAccumulator(&TestMetricMaker{}, ch).WithTracking(1)
+
+	now := time.Now()
+	id := acc.AddTrackingMetricGroup([]telegraf.Metric{
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"value": 1}, now),
+		testutil.MustMetric("mem", nil, map[string]interface{}{"value": 2}, now),
+	})
+	require.Len(t, ch, 2)
+	(<-ch).Accept()
+	(<-ch).Accept()
+
+	tracking := <-acc.Delivered()
+	require.Equal(t, id, tracking.ID())
+	require.True(t, tracking.Delivered())
+}
+
+// filterMaker drops the metrics named "drop".
+type filterMaker struct {
+	TestMetricMaker
+}
+
+func (fm *filterMaker) MakeMetric(metric telegraf.Metric) telegraf.Metric {
+	if metric.Name() == "drop" {
+		return nil
+	}
+	return metric
+}
+
+func TestAddFieldsFiltered(t *testing.T) {
+	ch := make(chan telegraf.Metric, 100)
+	acc := NewAccumulator(&filterMaker{}, ch)
+
+	for i := 0; i < 50; i++ {
+		acc.AddFields("drop",
+			map[string]interface{}{"a": 1, "b": 2, "c": 3},
+			map[string]string{"x": "1", "y": "2"})
+		acc.AddFields("keep",
+			map[string]interface{}{"value": i},
+			map[string]string{"host": "localhost"})
+	}
+
+	require.Len(t, ch, 50)
+	for i := 0; i < 50; i++ {
+		m := <-ch
+		require.Equal(t, "keep", m.Name())
+		require.Equal(t, map[string]string{"host": "localhost"}, m.Tags())
+		require.Equal(t, map[string]interface{}{"value": int64(i)}, m.Fields())
+	}
+}
+
+func BenchmarkAddFields(b *testing.B) {
+	ch := make(chan telegraf.Metric, 1)
+	acc := NewAccumulator(&filterMaker{}, ch)
+	tags := map[string]string{"host": "localhost", "cpu": "cpu0"}
+	fields := map[string]interface{}{"idle": 42.0, "user": 3.14, "system": 1.0}
+
+	b.Run("added", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			acc.AddFields("cpu", fields, tags)
+			<-ch
+		}
+	})
+	b.Run("filtered", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			acc.AddFields("drop", fields, tags)
+		}
+	})
+}
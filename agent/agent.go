@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"sync"
@@ -20,6 +21,15 @@ import (
 // Agent runs a set of plugins.
 type Agent struct {
 	Config *config.Config
+
+	// backpressure slows down the inputs while outputs are behind, it is nil
+	// unless collection_backpressure is enabled.
+	backpressure *backpressure
+
+	// running holds the units of the agent while Run is running, they are
+	// changed in place by Reload.  The reloadMutex is held during a reload.
+	running     *runningUnits
+	reloadMutex sync.Mutex
 }
 
 // NewAgent returns an Agent for the given Config.
@@ -30,6 +40,21 @@ func NewAgent(config *config.Config) (*Agent, error) {
 	return a, nil
 }
 
+// runningUnits are the units of a running agent.
+type runningUnits struct {
+	// ctx is the context of Run, inputCtx is done once the inputs must stop.
+	ctx      context.Context
+	inputCtx context.Context
+
+	// deadline is the shutdown deadline of the outputs.
+	deadline <-chan empty
+
+	inputs  *inputUnit
+	chain   *chainUnit
+	swap    chan<- *chainUnit
+	outputs *outputUnit
+}
+
 // inputUnit is a group of input plugins and the shared channel they write to.
 //
 // ┌───────┐
@@ -42,8 +67,20 @@ func NewAgent(config *config.Config) (*Agent, error) {
 // │ Input │───┘
 // └───────┘
 type inputUnit struct {
-	dst    chan<- telegraf.Metric
+	dst chan<- telegraf.Metric
+
+	// Mutex protects the inputs, schedules and stops, which are changed when
+	// the config is reloaded.
+	sync.Mutex
 	inputs []*models.RunningInput
+
+	// schedules holds the collection schedule of each input, by index.
+	schedules []*inputSchedule
+
+	// stops holds the function stopping the gather loop of each input, by
+	// index.
+	stops []func()
+	wg    sync.WaitGroup
 }
 
 //  ______     ┌───────────┐     ______
@@ -77,8 +114,31 @@ type aggregatorUnit struct {
 	aggregators []*models.RunningAggregator
 }
 
+// chainUnit is the processors and aggregators between the inputs and the
+// outputs.  When the config is reloaded and any of them changed, the chain is
+// replaced as a whole.
+//
+//  ______     ┌────────────┐    ┌─────────────┐    ┌────────────┐     ______
+// ()_____)──▶ │ Processors │──▶ │ Aggregators │──▶ │ Processors │──▶ ()_____)
+//             └────────────┘    └─────────────┘    └────────────┘
+type chainUnit struct {
+	// src is the channel the inputs write to, it is the destination channel
+	// when the chain has no processor or aggregator.
+	src       chan<- telegraf.Metric
+	startTime time.Time
+
+	apu []*processorUnit
+	au  *aggregatorUnit
+	pu  []*processorUnit
+
+	// out is the destination channel of a chain replaceable by a reload,
+	// forwarded to the outputs by runChains.
+	out <-chan telegraf.Metric
+}
+
 // outputUnit is a group of Outputs and their source channel.  Metrics on the
-// channel are written to all outputs.
+// channel are written to all outputs, or to the outputs selected by the routes
+// when the agent has routes.
 //
 //                            ┌────────┐
 //                       ┌──▶ │ Output │
@@ -89,9 +149,33 @@ type aggregatorUnit struct {
 //                       │    ┌────────┐
 //                       └──▶ │ Output │
 //                            └────────┘
+//
+// Metrics exceeding the agent size limits may be diverted to the dead letter
+// output, which receives no other metrics.  The metrics rejected by an output
+// are sent to its dead letter output.
 type outputUnit struct {
 	src     <-chan telegraf.Metric
-	outputs []*models.RunningOutput
+	limiter *sizeLimiter
+
+	// RWMutex protects the outputs, the dead letter outputs and the router,
+	// which are changed when the config is reloaded.
+	sync.RWMutex
+	outputs    []*models.RunningOutput
+	deadLetter *models.RunningOutput
+
+	// deadLetters are the outputs receiving rejected or diverted metrics,
+	// including the agent dead letter output.
+	deadLetters []*models.RunningOutput
+
+	// router selects the outputs of each metric when routes are configured.
+	router *router
+
+	// flushCtx is done once the source channel is closed, stopping the flush
+	// loops.  The stops stop the flush loop of a single output.
+	flushCtx  context.Context
+	stopFlush context.CancelFunc
+	stops     map[*models.RunningOutput]func(deadline <-chan empty)
+	wg        sync.WaitGroup
 }
 
 // Run starts and runs the Agent until the context is done.
@@ -107,100 +191,266 @@ func (a *Agent) Run(ctx context.Context) error {
 		return err
 	}
 
+	err = a.checkDeliveryOutputs()
+	if err != nil {
+		return err
+	}
+
 	startTime := time.Now()
 
 	log.Printf("D! [agent] Connecting outputs")
-	next, ou, err := a.startOutputs(ctx, a.Config.Outputs)
+	outputC, ou, err := a.startOutputs(ctx, a.Config.Outputs)
 	if err != nil {
 		return err
 	}
 
-	var apu []*processorUnit
-	var au *aggregatorUnit
-	if len(a.Config.Aggregators) != 0 {
-		aggC := next
-		if len(a.Config.AggProcessors) != 0 {
-			aggC, apu, err = a.startProcessors(next, a.Config.AggProcessors)
-			if err != nil {
-				return err
-			}
-		}
+	if a.Config.Agent.CollectionBackpressure {
+		a.backpressure = newBackpressure()
+	}
 
-		next, au, err = a.startAggregators(aggC, next, a.Config.Aggregators)
-		if err != nil {
-			return err
-		}
+	var deadline <-chan empty
+	if timeout := a.Config.Agent.ShutdownTimeout.Duration; timeout > 0 {
+		deadline = shutdownDeadline(ctx, timeout)
 	}
 
-	var pu []*processorUnit
-	if len(a.Config.Processors) != 0 {
-		next, pu, err = a.startProcessors(next, a.Config.Processors)
-		if err != nil {
-			return err
-		}
+	chain, err := a.startReloadableChain(startTime,
+		a.Config.Processors, a.Config.AggProcessors, a.Config.Aggregators)
+	if err != nil {
+		return err
 	}
 
-	iu, err := a.startInputs(next, a.Config.Inputs)
+	inputC := make(chan telegraf.Metric, 100)
+	iu, err := a.startInputs(inputC, a.Config.Inputs)
 	if err != nil {
 		return err
 	}
 
 	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		err = a.runOutputs(ou)
+	if a.Config.Agent.ControlSocket != "" {
+		cs, err := newControlServer(a.Config.Agent.ControlSocket, iu)
 		if err != nil {
-			log.Printf("E! [agent] Error running outputs: %v", err)
+			stopServiceInputs(iu.inputs)
+			return err
 		}
-	}()
 
-	if au != nil {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			err = a.runProcessors(apu)
-			if err != nil {
-				log.Printf("E! [agent] Error running processors: %v", err)
-			}
+			cs.serve(ctx)
 		}()
+	}
+
+	if a.Config.Agent.SelfTelemetryListen != "" {
+		ts, err := newTelemetryServer(a.Config.Agent.SelfTelemetryListen)
+		if err != nil {
+			stopServiceInputs(iu.inputs)
+			return err
+		}
 
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			err = a.runAggregators(startTime, au)
-			if err != nil {
-				log.Printf("E! [agent] Error running aggregators: %v", err)
-			}
+			ts.serve(ctx)
 		}()
 	}
 
-	if pu != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err = a.runProcessors(pu)
-			if err != nil {
-				log.Printf("E! [agent] Error running processors: %v", err)
-			}
-		}()
+	// The inputs are stopped once the context is done and a reload in
+	// progress is complete.
+	inputCtx, stopInputs := context.WithCancel(context.Background())
+	a.startFlushes(ou, deadline)
+	a.startGathers(inputCtx, startTime, iu)
+
+	swap := make(chan *chainUnit)
+	a.reloadMutex.Lock()
+	a.running = &runningUnits{
+		ctx:      ctx,
+		inputCtx: inputCtx,
+		deadline: deadline,
+		inputs:   iu,
+		chain:    chain,
+		swap:     swap,
+		outputs:  ou,
 	}
+	a.reloadMutex.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		a.reloadMutex.Lock()
+		a.running = nil
+		a.reloadMutex.Unlock()
+		stopInputs()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err := a.runOutputs(ou)
+		if err != nil {
+			log.Printf("E! [agent] Error running outputs: %v", err)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.runChains(inputC, outputC, chain, swap)
+	}()
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		err = a.runInputs(ctx, startTime, iu)
+		err := a.runInputs(inputCtx, iu)
 		if err != nil {
 			log.Printf("E! [agent] Error running inputs: %v", err)
 		}
 	}()
 
-	wg.Wait()
+	stopped := make(chan empty)
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-deadline:
+		var buffered int
+		ou.RLock()
+		for _, output := range ou.allOutputs() {
+			buffered += output.BufferLength()
+		}
+		ou.RUnlock()
+		return &ShutdownTimeoutError{
+			Timeout:  a.Config.Agent.ShutdownTimeout.Duration,
+			Buffered: buffered,
+			stopped:  stopped,
+		}
+	}
 
 	log.Printf("D! [agent] Stopped Successfully")
 	return err
 }
 
+// ShutdownTimeoutError is returned by Run when the shutdown did not complete
+// within the shutdown_timeout.  The plugins not stopped yet keep running in
+// the background.
+type ShutdownTimeoutError struct {
+	Timeout  time.Duration
+	Buffered int // the number of metrics buffered by the outputs
+
+	stopped <-chan empty
+}
+
+func (e *ShutdownTimeoutError) Error() string {
+	return fmt.Sprintf("shutdown did not complete within %s, %d metrics are still buffered",
+		e.Timeout, e.Buffered)
+}
+
+// Wait blocks until all plugins of the agent are stopped.
+func (e *ShutdownTimeoutError) Wait() {
+	<-e.stopped
+}
+
+// shutdownDeadline returns a channel closed once the timeout has elapsed
+// after the context is done.
+func shutdownDeadline(ctx context.Context, timeout time.Duration) <-chan empty {
+	deadline := make(chan empty)
+	go func() {
+		<-ctx.Done()
+		time.Sleep(timeout)
+		close(deadline)
+	}()
+	return deadline
+}
+
+// KeepBuffers moves the metrics buffered by the outputs of a previous run, ie.
+// before reloading the configuration, to the identically configured outputs
+// of this agent.  The metrics of removed or changed outputs are dropped.  It
+// must be called before Run.
+func (a *Agent) KeepBuffers(previous []*models.RunningOutput) {
+	kept := make(map[*models.RunningOutput]bool)
+	for _, output := range a.Config.Outputs {
+		for _, prev := range previous {
+			if kept[prev] || prev.Config.Fingerprint != output.Config.Fingerprint {
+				continue
+			}
+
+			kept[prev] = true
+			output.TakeBuffer(prev)
+			if n := output.BufferLength(); n > 0 {
+				log.Printf("I! [agent] Keeping %d buffered metrics for %s", n, output.LogName())
+			}
+			break
+		}
+	}
+
+	for _, prev := range previous {
+		if kept[prev] {
+			continue
+		}
+		if n := prev.DropBuffer(); n > 0 {
+			log.Printf("W! [agent] Dropping %d buffered metrics of removed or changed output %s",
+				n, prev.LogName())
+		}
+	}
+}
+
+// Validate initializes the plugins and checks the settings of the agent
+// without connecting or starting any plugin.  All errors found are returned.
+func (a *Agent) Validate() []error {
+	var errs []error
+	for _, input := range a.Config.Inputs {
+		if err := input.Init(); err != nil {
+			errs = append(errs, fmt.Errorf("could not initialize input %s: %v",
+				input.LogName(), err))
+		}
+	}
+	for _, processor := range a.Config.Processors {
+		if err := processor.Init(); err != nil {
+			errs = append(errs, fmt.Errorf("could not initialize processor %s: %v",
+				processor.LogName(), err))
+		}
+	}
+	for _, aggregator := range a.Config.Aggregators {
+		if err := aggregator.Init(); err != nil {
+			errs = append(errs, fmt.Errorf("could not initialize aggregator %s: %v",
+				aggregator.LogName(), err))
+		}
+	}
+	for _, output := range a.Config.Outputs {
+		if err := output.Init(); err != nil {
+			errs = append(errs, fmt.Errorf("could not initialize output %s: %v",
+				output.LogName(), err))
+		}
+	}
+
+	if err := a.checkDeliveryOutputs(); err != nil {
+		errs = append(errs, err)
+	}
+
+	_, err := newSizeLimiter(
+		a.Config.Agent.MetricMaxFields,
+		a.Config.Agent.MetricMaxStringLength,
+		a.Config.Agent.OversizedMetricAction)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := a.setBufferWatermarks(a.Config.Outputs); err != nil {
+		errs = append(errs, err)
+	}
+
+	deadLetters, err := a.routeDeadLetters(a.Config.Outputs)
+	if err != nil {
+		errs = append(errs, err)
+	} else if len(a.Config.Routes) > 0 {
+		if _, err := newRouter(a.Config.Routes, a.Config.Outputs, deadLetters); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
 // initPlugins runs the Init function on plugins.
 func (a *Agent) initPlugins() error {
 	for _, input := range a.Config.Inputs {
@@ -260,52 +510,54 @@ func (a *Agent) startInputs(
 			}
 		}
 		unit.inputs = append(unit.inputs, input)
+		unit.schedules = append(unit.schedules, a.initialSchedule(input))
 	}
 
 	return unit, nil
 }
 
-// runInputs starts and triggers the periodic gather for Inputs.
+// initialSchedule returns the initial collection schedule of the input.
+func (a *Agent) initialSchedule(input *models.RunningInput) *inputSchedule {
+	interval := a.Config.Agent.Interval.Duration
+	jitter := a.Config.Agent.CollectionJitter.Duration
+
+	// Overwrite agent interval if this plugin has its own.
+	if input.Config.Interval != 0 {
+		interval = input.Config.Interval
+	}
+
+	return newInputSchedule(interval, jitter)
+}
+
+// startGathers starts the periodic gather of the inputs, until the context is
+// done.
+func (a *Agent) startGathers(
+	ctx context.Context,
+	startTime time.Time,
+	unit *inputUnit,
+) {
+	unit.Lock()
+	defer unit.Unlock()
+	for i, input := range unit.inputs {
+		unit.stops = append(unit.stops, a.startGather(ctx, startTime, unit, input, unit.schedules[i]))
+	}
+}
+
+// runInputs waits for the gather of the inputs to stop.
 //
 // When the context is done the timers are stopped and this function returns
 // after all ongoing Gather calls complete.
 func (a *Agent) runInputs(
 	ctx context.Context,
-	startTime time.Time,
 	unit *inputUnit,
 ) error {
-	var wg sync.WaitGroup
-	for _, input := range unit.inputs {
-		interval := a.Config.Agent.Interval.Duration
-		jitter := a.Config.Agent.CollectionJitter.Duration
-
-		// Overwrite agent interval if this plugin has its own.
-		if input.Config.Interval != 0 {
-			interval = input.Config.Interval
-		}
-
-		var ticker Ticker
-		if a.Config.Agent.RoundInterval {
-			ticker = NewAlignedTicker(startTime, interval, jitter)
-		} else {
-			ticker = NewUnalignedTicker(interval, jitter)
-		}
-		defer ticker.Stop()
-
-		acc := NewAccumulator(input, unit.dst)
-		acc.SetPrecision(a.Precision())
-
-		wg.Add(1)
-		go func(input *models.RunningInput) {
-			defer wg.Done()
-			a.gatherLoop(ctx, acc, input, ticker)
-		}(input)
-	}
-
-	wg.Wait()
+	<-ctx.Done()
+	unit.wg.Wait()
 
 	log.Printf("D! [agent] Stopping service inputs")
+	unit.Lock()
 	stopServiceInputs(unit.inputs)
+	unit.Unlock()
 
 	close(unit.dst)
 	log.Printf("D! [agent] Input channel closed")
@@ -313,6 +565,46 @@ func (a *Agent) runInputs(
 	return nil
 }
 
+// startGather starts the gather loop of the input, which runs until the
+// context is done.  The returned function stops the loop, it returns after
+// the ongoing Gather call completes.
+func (a *Agent) startGather(
+	ctx context.Context,
+	startTime time.Time,
+	unit *inputUnit,
+	input *models.RunningInput,
+	schedule *inputSchedule,
+) func() {
+	roundInterval := a.roundInterval(input)
+
+	// newTicker creates the ticker for the current schedule, it is called
+	// again whenever the schedule is changed.
+	newTicker := func(start time.Time) Ticker {
+		interval, jitter := schedule.get()
+		if roundInterval {
+			return NewAlignedTicker(start, interval, jitter)
+		}
+		return NewUnalignedTicker(interval, jitter)
+	}
+
+	acc := NewAccumulator(input, unit.dst)
+	acc.SetPrecision(a.inputPrecision(input))
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan empty)
+	unit.wg.Add(1)
+	go func(ticker Ticker) {
+		defer unit.wg.Done()
+		defer close(done)
+		a.gatherLoop(ctx, acc, input, ticker, schedule, newTicker)
+	}(newTicker(startTime))
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
 // testStartInputs is a variation of startInputs for use in --test and --once
 // mode.  It differs by logging Start errors and returning only plugins
 // successfully started.
@@ -373,7 +665,7 @@ func (a *Agent) testRunInputs(
 			switch input.Config.Name {
 			case "cpu", "mongodb", "procstat":
 				nulAcc := NewAccumulator(input, nul)
-				nulAcc.SetPrecision(a.Precision())
+				nulAcc.SetPrecision(a.inputPrecision(input))
 				if err := input.Input.Gather(nulAcc); err != nil {
 					nulAcc.AddError(err)
 				}
@@ -382,7 +674,7 @@ func (a *Agent) testRunInputs(
 			}
 
 			acc := NewAccumulator(input, unit.dst)
-			acc.SetPrecision(a.Precision())
+			acc.SetPrecision(a.inputPrecision(input))
 
 			if err := input.Input.Gather(acc); err != nil {
 				acc.AddError(err)
@@ -411,22 +703,41 @@ func stopServiceInputs(inputs []*models.RunningInput) {
 }
 
 // gather runs an input's gather function periodically until the context is
-// done.
+// done.  The ticker is replaced using newTicker when the schedule changes.
 func (a *Agent) gatherLoop(
 	ctx context.Context,
 	acc telegraf.Accumulator,
 	input *models.RunningInput,
 	ticker Ticker,
+	schedule *inputSchedule,
+	newTicker func(start time.Time) Ticker,
 ) {
 	defer panicRecover(input)
+	defer func() {
+		ticker.Stop()
+	}()
 
+	skipped := false
 	for {
 		select {
 		case <-ticker.Elapsed():
+			if a.backpressure.active() && !skipped {
+				log.Printf("D! [agent] [%s] Skipping collection, outputs are behind", input.LogName())
+				skipped = true
+				continue
+			}
+			skipped = false
+
 			err := a.gatherOnce(acc, input, ticker)
 			if err != nil {
 				acc.AddError(err)
 			}
+			if input.IsDisabled() {
+				return
+			}
+		case <-schedule.changed:
+			ticker.Stop()
+			ticker = newTicker(time.Now())
 		case <-ctx.Done():
 			return
 		}
@@ -456,71 +767,225 @@ func (a *Agent) gatherOnce(
 	}
 }
 
-// startProcessors sets up the processor chain and calls Start on all
-// processors.  If an error occurs any started processors are Stopped.
-func (a *Agent) startProcessors(
+// startChain starts the processors and aggregators writing to dst.
+func (a *Agent) startChain(
+	startTime time.Time,
 	dst chan<- telegraf.Metric,
 	processors models.RunningProcessors,
-) (chan<- telegraf.Metric, []*processorUnit, error) {
-	var units []*processorUnit
-
-	// Sort from last to first
-	sort.SliceStable(processors, func(i, j int) bool {
-		return processors[i].Config.Order > processors[j].Config.Order
-	})
-
-	var src chan telegraf.Metric
-	for _, processor := range processors {
-		src = make(chan telegraf.Metric, 100)
-		acc := NewAccumulator(processor, dst)
+	aggProcessors models.RunningProcessors,
+	aggregators []*models.RunningAggregator,
+) (*chainUnit, error) {
+	chain := &chainUnit{src: dst, startTime: startTime}
+
+	var err error
+	if len(aggregators) != 0 {
+		aggC := chain.src
+		if len(aggProcessors) != 0 {
+			aggC, chain.apu, err = a.startProcessors(chain.src, aggProcessors)
+			if err != nil {
+				return nil, err
+			}
+		}
 
-		err := processor.Start(acc)
+		chain.src, chain.au, err = a.startAggregators(aggC, chain.src, aggregators)
 		if err != nil {
-			for _, u := range units {
-				u.processor.Stop()
-				close(u.dst)
-			}
-			return nil, nil, fmt.Errorf("starting processor %s: %w", processor.LogName(), err)
+			return nil, err
 		}
+	}
 
-		units = append(units, &processorUnit{
-			src:       src,
-			dst:       dst,
-			processor: processor,
-		})
+	if len(processors) != 0 {
+		chain.src, chain.pu, err = a.startProcessors(chain.src, processors)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return chain, nil
+}
 
-		dst = src
+// startReloadableChain starts the processors and aggregators writing to a
+// channel of their own, so the chain can be replaced while the agent runs.
+func (a *Agent) startReloadableChain(
+	startTime time.Time,
+	processors models.RunningProcessors,
+	aggProcessors models.RunningProcessors,
+	aggregators []*models.RunningAggregator,
+) (*chainUnit, error) {
+	out := make(chan telegraf.Metric, 100)
+	chain, err := a.startChain(startTime, out, processors, aggProcessors, aggregators)
+	if err != nil {
+		return nil, err
 	}
+	chain.out = out
+	return chain, nil
+}
 
-	return src, units, nil
+// empty returns true if the chain has no processor or aggregator.
+func (c *chainUnit) empty() bool {
+	return c.au == nil && len(c.pu) == 0
 }
 
-// runProcessors begins processing metrics and runs until the source channel is
-// closed and all metrics have been written.
-func (a *Agent) runProcessors(
-	units []*processorUnit,
-) error {
+// runChain runs the processors and aggregators of the chain until its source
+// channel is closed and all metrics have been written.
+func (a *Agent) runChain(chain *chainUnit) {
 	var wg sync.WaitGroup
-	for _, unit := range units {
+	if chain.au != nil {
 		wg.Add(1)
-		go func(unit *processorUnit) {
+		go func() {
 			defer wg.Done()
+			err := a.runProcessors(chain.apu)
+			if err != nil {
+				log.Printf("E! [agent] Error running processors: %v", err)
+			}
+		}()
 
-			acc := NewAccumulator(unit.processor, unit.dst)
-			for m := range unit.src {
-				unit.processor.Add(m, acc)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := a.runAggregators(chain.startTime, chain.au)
+			if err != nil {
+				log.Printf("E! [agent] Error running aggregators: %v", err)
 			}
-			unit.processor.Stop()
-			close(unit.dst)
-			log.Printf("D! [agent] Processor channel closed")
-		}(unit)
+		}()
 	}
-	wg.Wait()
 
-	return nil
+	if chain.pu != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := a.runProcessors(chain.pu)
+			if err != nil {
+				log.Printf("E! [agent] Error running processors: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
 }
 
-// startAggregators sets up the aggregator unit and returns the source channel.
+// runChains writes the metrics of the inputs to the chain until the source
+// channel is closed.  A chain received on swap replaces the running chain,
+// which is closed and drains to the destination channel in the background.
+// The destination channel is closed once all chains are drained.
+func (a *Agent) runChains(
+	src <-chan telegraf.Metric,
+	dst chan<- telegraf.Metric,
+	chain *chainUnit,
+	swap <-chan *chainUnit,
+) {
+	var wg sync.WaitGroup
+
+	// start runs the chain and returns the channel the metrics are written
+	// to, a chain without plugins is skipped.
+	start := func(chain *chainUnit) chan<- telegraf.Metric {
+		if chain.empty() {
+			return dst
+		}
+
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			a.runChain(chain)
+		}()
+		go func() {
+			defer wg.Done()
+			for metric := range chain.out {
+				dst <- metric
+			}
+		}()
+		return chain.src
+	}
+
+	stop := func(chain *chainUnit) {
+		if !chain.empty() {
+			close(chain.src)
+		}
+	}
+
+	next := start(chain)
+	for {
+		select {
+		case metric, ok := <-src:
+			if !ok {
+				stop(chain)
+				wg.Wait()
+				close(dst)
+				log.Printf("D! [agent] Output channel closed")
+				return
+			}
+			next <- metric
+		case replacement := <-swap:
+			stop(chain)
+			chain = replacement
+			next = start(chain)
+		}
+	}
+}
+
+// startProcessors sets up the processor chain and calls Start on all
+// processors.  If an error occurs any started processors are Stopped.
+func (a *Agent) startProcessors(
+	dst chan<- telegraf.Metric,
+	processors models.RunningProcessors,
+) (chan<- telegraf.Metric, []*processorUnit, error) {
+	var units []*processorUnit
+
+	// Sort from last to first, leaving the order of the config unchanged
+	processors = append(models.RunningProcessors(nil), processors...)
+	sort.SliceStable(processors, func(i, j int) bool {
+		return processors[i].Config.Order > processors[j].Config.Order
+	})
+
+	var src chan telegraf.Metric
+	for _, processor := range processors {
+		src = make(chan telegraf.Metric, 100)
+		acc := NewAccumulator(processor, dst)
+
+		err := processor.Start(acc)
+		if err != nil {
+			for _, u := range units {
+				u.processor.Stop()
+				close(u.dst)
+			}
+			return nil, nil, fmt.Errorf("starting processor %s: %w", processor.LogName(), err)
+		}
+
+		units = append(units, &processorUnit{
+			src:       src,
+			dst:       dst,
+			processor: processor,
+		})
+
+		dst = src
+	}
+
+	return src, units, nil
+}
+
+// runProcessors begins processing metrics and runs until the source channel is
+// closed and all metrics have been written.
+func (a *Agent) runProcessors(
+	units []*processorUnit,
+) error {
+	var wg sync.WaitGroup
+	for _, unit := range units {
+		wg.Add(1)
+		go func(unit *processorUnit) {
+			defer wg.Done()
+
+			acc := NewAccumulator(unit.processor, unit.dst)
+			for m := range unit.src {
+				unit.processor.Add(m, acc)
+			}
+			unit.processor.Stop()
+			close(unit.dst)
+			log.Printf("D! [agent] Processor channel closed")
+		}(unit)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// startAggregators sets up the aggregator unit and returns the source channel.
 func (a *Agent) startAggregators(
 	aggC chan<- telegraf.Metric,
 	outputC chan<- telegraf.Metric,
@@ -546,7 +1011,7 @@ func (a *Agent) runAggregators(
 
 	// Before calling Add, initialize the aggregation window.  This ensures
 	// that any metric created after start time will be aggregated.
-	for _, agg := range a.Config.Aggregators {
+	for _, agg := range unit.aggregators {
 		since, until := updateWindow(startTime, a.Config.Agent.RoundInterval, agg.Period())
 		agg.UpdateWindow(since, until)
 	}
@@ -557,7 +1022,7 @@ func (a *Agent) runAggregators(
 		defer wg.Done()
 		for metric := range unit.src {
 			var dropOriginal bool
-			for _, agg := range a.Config.Aggregators {
+			for _, agg := range unit.aggregators {
 				if ok := agg.Add(metric); ok {
 					dropOriginal = true
 				}
@@ -572,7 +1037,7 @@ func (a *Agent) runAggregators(
 		cancel()
 	}()
 
-	for _, agg := range a.Config.Aggregators {
+	for _, agg := range unit.aggregators {
 		wg.Add(1)
 		go func(agg *models.RunningAggregator) {
 			defer wg.Done()
@@ -628,7 +1093,7 @@ func (a *Agent) push(
 			aggregator.Push(acc)
 			break
 		case <-ctx.Done():
-			aggregator.Push(acc)
+			aggregator.Flush(acc)
 			return
 		}
 	}
@@ -640,21 +1105,277 @@ func (a *Agent) startOutputs(
 	ctx context.Context,
 	outputs []*models.RunningOutput,
 ) (chan<- telegraf.Metric, *outputUnit, error) {
-	src := make(chan telegraf.Metric, 100)
-	unit := &outputUnit{src: src}
-	for _, output := range outputs {
+	limiter, err := newSizeLimiter(
+		a.Config.Agent.MetricMaxFields,
+		a.Config.Agent.MetricMaxStringLength,
+		a.Config.Agent.OversizedMetricAction)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deadLetters, err := a.routeDeadLetters(outputs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := a.setBufferWatermarks(outputs); err != nil {
+		return nil, nil, err
+	}
+
+	var router *router
+	if len(a.Config.Routes) > 0 {
+		router, err = newRouter(a.Config.Routes, outputs, deadLetters)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := a.openDiskBuffers(outputs); err != nil {
+		return nil, nil, err
+	}
+
+	for i, output := range outputs {
 		err := a.connectOutput(ctx, output)
 		if err != nil {
-			for _, output := range unit.outputs {
+			for _, output := range outputs[:i] {
 				output.Close()
 			}
+			closeBuffers(outputs)
 			return nil, nil, fmt.Errorf("connecting output %s: %w", output.LogName(), err)
 		}
+	}
+
+	src := make(chan telegraf.Metric, 100)
+	unit := &outputUnit{
+		src:     src,
+		limiter: limiter,
+		router:  router,
+		stops:   make(map[*models.RunningOutput]func(<-chan empty)),
+	}
+	unit.flushCtx, unit.stopFlush = context.WithCancel(context.Background())
+	a.setOutputs(unit, outputs, deadLetters)
+	return src, unit, nil
+}
 
+// setOutputs sets the outputs of the unit, separating the outputs receiving
+// dead letters from the others.
+func (a *Agent) setOutputs(
+	unit *outputUnit,
+	outputs []*models.RunningOutput,
+	deadLetters map[*models.RunningOutput]bool,
+) {
+	unit.outputs = nil
+	unit.deadLetters = nil
+	unit.deadLetter = nil
+	for _, output := range outputs {
+		if deadLetters[output] {
+			if a.isDeadLetterOutput(output) {
+				unit.deadLetter = output
+			}
+			unit.deadLetters = append(unit.deadLetters, output)
+			continue
+		}
 		unit.outputs = append(unit.outputs, output)
 	}
+}
 
-	return src, unit, nil
+// routeDeadLetters sets the dead letter output of each output, receiving the
+// metrics it rejects, and returns the outputs receiving dead letters.  The
+// metrics rejected by dead letter outputs are dropped.
+func (a *Agent) routeDeadLetters(outputs []*models.RunningOutput) (map[*models.RunningOutput]bool, error) {
+	targets, deadLetters, err := a.deadLetterTargets(outputs)
+	if err != nil {
+		return nil, err
+	}
+	setDeadLetters(outputs, targets, deadLetters)
+	return deadLetters, nil
+}
+
+// deadLetterTargets returns the dead letter output of each output and the
+// outputs receiving dead letters.
+func (a *Agent) deadLetterTargets(outputs []*models.RunningOutput) (
+	map[*models.RunningOutput]*models.RunningOutput,
+	map[*models.RunningOutput]bool,
+	error,
+) {
+	find := func(name string) *models.RunningOutput {
+		for _, output := range outputs {
+			if outputSelected(output, name) {
+				return output
+			}
+		}
+		return nil
+	}
+
+	targets := make(map[*models.RunningOutput]*models.RunningOutput, len(outputs))
+	deadLetters := make(map[*models.RunningOutput]bool)
+
+	if name := a.Config.Agent.DeadLetterOutput; name != "" {
+		dl := find(name)
+		if dl == nil {
+			return nil, nil, fmt.Errorf("dead letter output %q not found", name)
+		}
+		deadLetters[dl] = true
+	} else if a.divertsOversized() {
+		return nil, nil, fmt.Errorf("oversized_metric_action %q requires dead_letter_output", oversizedDeadLetter)
+	}
+
+	for _, output := range outputs {
+		name := a.Config.Agent.DeadLetterOutput
+		if output.Config.DeadLetterOutput != nil {
+			name = *output.Config.DeadLetterOutput
+		}
+		if name == "" {
+			continue
+		}
+
+		dl := find(name)
+		if dl == nil {
+			return nil, nil, fmt.Errorf("dead letter output %q of %s not found", name, output.LogName())
+		}
+		targets[output] = dl
+		deadLetters[dl] = true
+	}
+	return targets, deadLetters, nil
+}
+
+// divertsOversized returns true if the oversized metrics are sent to the dead
+// letter output.
+func (a *Agent) divertsOversized() bool {
+	return a.Config.Agent.OversizedMetricAction == oversizedDeadLetter &&
+		(a.Config.Agent.MetricMaxFields > 0 || a.Config.Agent.MetricMaxStringLength > 0)
+}
+
+// setDeadLetters sets the dead letter output of each output, the outputs
+// receiving dead letters have none.
+func setDeadLetters(
+	outputs []*models.RunningOutput,
+	targets map[*models.RunningOutput]*models.RunningOutput,
+	deadLetters map[*models.RunningOutput]bool,
+) {
+	for _, output := range outputs {
+		if deadLetters[output] {
+			output.SetDeadLetter(nil)
+			continue
+		}
+		output.SetDeadLetter(targets[output])
+	}
+}
+
+// checkDeliveryOutputs verifies that the delivery outputs of the inputs exist.
+func (a *Agent) checkDeliveryOutputs() error {
+	for _, input := range a.Config.Inputs {
+		for _, name := range input.Config.DeliveryOutputs {
+			found := false
+			for _, output := range a.Config.Outputs {
+				if outputSelected(output, name) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("delivery output %q of input %s not found", name, input.LogName())
+			}
+		}
+	}
+	return nil
+}
+
+// setBufferWatermarks sets the buffer length at which each output is flushed
+// early, as a percentage of its buffer limit.
+func (a *Agent) setBufferWatermarks(outputs []*models.RunningOutput) error {
+	watermark := a.Config.Agent.FlushBufferWatermark
+	if watermark < 0 || watermark > 100 {
+		return fmt.Errorf("flush_buffer_watermark must be a percentage between 0 and 100, got %d", watermark)
+	}
+	if a.Config.Agent.CollectionBackpressure && watermark == 0 {
+		return fmt.Errorf("collection_backpressure requires flush_buffer_watermark")
+	}
+
+	for _, output := range outputs {
+		output.BufferWatermark = 0
+		if watermark > 0 {
+			output.BufferWatermark = output.MetricBufferLimit * watermark / 100
+			if output.BufferWatermark < 1 {
+				output.BufferWatermark = 1
+			}
+		}
+	}
+	return nil
+}
+
+// openDiskBuffers replaces the in-memory buffers of the outputs by buffers on
+// disk, when a buffer directory is configured.  Each output is buffered in a
+// directory named after the plugin and alias.
+func (a *Agent) openDiskBuffers(outputs []*models.RunningOutput) error {
+	dir := a.Config.Agent.MetricBufferDirectory
+	if dir == "" {
+		return nil
+	}
+
+	opened := make(map[string]*models.RunningOutput, len(outputs))
+	for _, output := range outputs {
+		name := diskBufferName(output)
+		if other, ok := opened[name]; ok {
+			closeBuffers(outputs)
+			return fmt.Errorf("outputs %s and %s use the same disk buffer, set an alias to distinguish them",
+				other.LogName(), output.LogName())
+		}
+
+		err := output.OpenDiskBuffer(filepath.Join(dir, name), a.Config.Agent.MetricBufferMaxDiskSize.Size)
+		if err != nil {
+			closeBuffers(outputs)
+			return fmt.Errorf("opening disk buffer of %s: %w", output.LogName(), err)
+		}
+		opened[name] = output
+	}
+	return nil
+}
+
+// diskBufferName returns the name of the disk buffer directory of the output.
+func diskBufferName(output *models.RunningOutput) string {
+	name := output.Config.Name
+	if output.Config.Alias != "" {
+		name += "-" + output.Config.Alias
+	}
+	return name
+}
+
+// closeBuffers closes the disk buffers of the outputs.
+func closeBuffers(outputs []*models.RunningOutput) {
+	for _, output := range outputs {
+		if err := output.CloseBuffer(); err != nil {
+			log.Printf("E! [agent] Closing buffer of %s: %v", output.LogName(), err)
+		}
+	}
+}
+
+// isDeadLetterOutput returns true if the output is selected, by alias or
+// name, as the dead letter output of the agent.
+func (a *Agent) isDeadLetterOutput(output *models.RunningOutput) bool {
+	name := a.Config.Agent.DeadLetterOutput
+	if name == "" {
+		return false
+	}
+	return outputSelected(output, name)
+}
+
+// outputSelected returns true if the output is selected by the name, which is
+// matched against the alias if the output has one.
+func outputSelected(output *models.RunningOutput, name string) bool {
+	if output.Config.Alias != "" {
+		return output.Config.Alias == name
+	}
+	return output.Config.Name == name
+}
+
+// allOutputs returns the outputs in the unit including the dead letter
+// outputs.
+func (u *outputUnit) allOutputs() []*models.RunningOutput {
+	if len(u.deadLetters) == 0 {
+		return u.outputs
+	}
+	return append(u.outputs[:len(u.outputs):len(u.outputs)], u.deadLetters...)
 }
 
 // connectOutputs connects to all outputs.
@@ -679,72 +1400,136 @@ func (a *Agent) connectOutput(ctx context.Context, output *models.RunningOutput)
 	return nil
 }
 
+// startFlushes starts the flush loop of the outputs.  On shutdown metrics will
+// be written one last time, retried until the deadline if one is set, and
+// dropped if unsuccessful.
+func (a *Agent) startFlushes(
+	unit *outputUnit,
+	deadline <-chan empty,
+) {
+	unit.Lock()
+	defer unit.Unlock()
+	for _, output := range unit.allOutputs() {
+		unit.stops[output] = a.startFlush(unit, output, deadline)
+	}
+}
+
 // runOutputs begins processing metrics and returns until the source channel is
-// closed and all metrics have been written.  On shutdown metrics will be
-// written one last time and dropped if unsuccessful.
+// closed and all metrics have been written.
 func (a *Agent) runOutputs(
 	unit *outputUnit,
 ) error {
-	var wg sync.WaitGroup
+	for metric := range unit.src {
+		unit.add(metric)
+	}
+
+	log.Println("I! [agent] Hang on, flushing any cached metrics before shutdown")
+	unit.stopFlush()
+	unit.wg.Wait()
 
-	// Start flush loop
+	closeBuffers(unit.allOutputs())
+
+	return nil
+}
+
+// startFlush starts the flush loop of the output, which flushes the output a
+// final time and returns when the flush context of the unit is done.  The
+// returned function stops the loop of this output only, the final write is
+// retried until the deadline passed to it.
+func (a *Agent) startFlush(
+	unit *outputUnit,
+	output *models.RunningOutput,
+	deadline <-chan empty,
+) func(deadline <-chan empty) {
 	interval := a.Config.Agent.FlushInterval.Duration
+	// Overwrite agent flush_interval if this plugin has its own.
+	if output.Config.FlushInterval != 0 {
+		interval = output.Config.FlushInterval
+	}
+
 	jitter := a.Config.Agent.FlushJitter.Duration
+	// Overwrite agent flush_jitter if this plugin has its own.
+	if output.Config.FlushJitter != nil {
+		jitter = *output.Config.FlushJitter
+	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	stop := make(chan (<-chan empty), 1)
+	done := make(chan empty)
+	unit.wg.Add(1)
+	go func() {
+		defer unit.wg.Done()
+		defer close(done)
 
-	for _, output := range unit.outputs {
-		interval := interval
-		// Overwrite agent flush_interval if this plugin has its own.
-		if output.Config.FlushInterval != 0 {
-			interval = output.Config.FlushInterval
-		}
+		ticker := NewRollingTicker(interval, jitter)
+		defer ticker.Stop()
 
-		jitter := jitter
-		// Overwrite agent flush_jitter if this plugin has its own.
-		if output.Config.FlushJitter != nil {
-			jitter = *output.Config.FlushJitter
-		}
+		a.flushLoop(unit.flushCtx, output, ticker, deadline, stop)
+	}()
 
-		wg.Add(1)
-		go func(output *models.RunningOutput) {
-			defer wg.Done()
+	return func(deadline <-chan empty) {
+		stop <- deadline
+		<-done
+	}
+}
 
-			ticker := NewRollingTicker(interval, jitter)
-			defer ticker.Stop()
+// add writes the metric to the outputs, diverting it to the dead letter
+// output when it exceeds the size limits.
+func (u *outputUnit) add(metric telegraf.Metric) {
+	u.RLock()
+	defer u.RUnlock()
 
-			a.flushLoop(ctx, output, ticker)
-		}(output)
+	if u.limiter == nil {
+		u.fanout(metric)
+		return
 	}
 
-	for metric := range unit.src {
-		for i, output := range unit.outputs {
-			if i == len(a.Config.Outputs)-1 {
-				output.AddMetric(metric)
-			} else {
-				output.AddMetric(metric.Copy())
-			}
-		}
+	metrics, diverted := u.limiter.apply(metric)
+	for _, metric := range metrics {
+		u.fanout(metric)
+	}
+	if diverted != nil {
+		diverted.AddTag("dead_letter_reason", "oversized")
+		u.deadLetter.AddMetric(diverted)
 	}
+}
 
-	log.Println("I! [agent] Hang on, flushing any cached metrics before shutdown")
-	cancel()
-	wg.Wait()
+// fanout writes the metric to all outputs selected by the routes, except the
+// dead letter outputs.
+func (u *outputUnit) fanout(metric telegraf.Metric) {
+	outputs := u.outputs
+	if u.router != nil {
+		outputs = u.router.route(metric)
+	}
 
-	return nil
+	if len(outputs) == 0 {
+		metric.Drop()
+		return
+	}
+
+	for i, output := range outputs {
+		if i == len(outputs)-1 {
+			output.AddMetric(metric)
+		} else {
+			output.AddMetric(metric.Copy())
+		}
+	}
 }
 
 // flushLoop runs an output's flush function periodically until the context is
-// done.
+// done or a deadline is received on stop, then flushes the output a final
+// time.
 func (a *Agent) flushLoop(
 	ctx context.Context,
 	output *models.RunningOutput,
 	ticker Ticker,
+	deadline <-chan empty,
+	stop <-chan (<-chan empty),
 ) {
 	logError := func(err error) {
 		if err != nil {
 			log.Printf("E! [agent] Error writing to %s: %v", output.LogName(), err)
 		}
+		a.backpressure.flushed(output)
 	}
 
 	// watch for flush requests
@@ -756,19 +1541,28 @@ func (a *Agent) flushLoop(
 		// Favor shutdown over other methods.
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, ticker, output.Write))
+			logError(a.finalFlush(output, ticker, deadline))
+			return
+		case deadline := <-stop:
+			logError(a.finalFlush(output, ticker, deadline))
 			return
 		default:
 		}
 
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, ticker, output.Write))
+			logError(a.finalFlush(output, ticker, deadline))
+			return
+		case deadline := <-stop:
+			logError(a.finalFlush(output, ticker, deadline))
 			return
 		case <-ticker.Elapsed():
 			logError(a.flushOnce(output, ticker, output.Write))
 		case <-flushRequested:
 			logError(a.flushOnce(output, ticker, output.Write))
+		case <-output.BufferHigh:
+			log.Printf("D! [agent] Buffer of %s reached its watermark, flushing", output.LogName())
+			logError(a.flushOnce(output, ticker, output.Write))
 		case <-output.BatchReady:
 			// Favor the ticker over batch ready
 			select {
@@ -806,6 +1600,33 @@ func (a *Agent) flushOnce(
 	}
 }
 
+// finalFlushRetryInterval is the delay between the retries of a failed final
+// write.
+const finalFlushRetryInterval = time.Second
+
+// finalFlush writes the buffered metrics of the output on shutdown.  When a
+// shutdown deadline is set failed writes are retried until the deadline,
+// otherwise the write is attempted once.
+func (a *Agent) finalFlush(
+	output *models.RunningOutput,
+	ticker Ticker,
+	deadline <-chan empty,
+) error {
+	for {
+		err := a.flushOnce(output, ticker, output.Write)
+		if err == nil || deadline == nil {
+			return err
+		}
+
+		log.Printf("W! [agent] Retrying final write to %s: %v", output.LogName(), err)
+		select {
+		case <-deadline:
+			return err
+		case <-time.After(finalFlushRetryInterval):
+		}
+	}
+}
+
 // Test runs the inputs, processors and aggregators for a single gather and
 // writes the metrics to stdout.
 func (a *Agent) Test(ctx context.Context, wait time.Duration) error {
@@ -852,70 +1673,24 @@ func (a *Agent) test(ctx context.Context, wait time.Duration, outputC chan<- tel
 
 	startTime := time.Now()
 
-	next := outputC
-
-	var apu []*processorUnit
-	var au *aggregatorUnit
-	if len(a.Config.Aggregators) != 0 {
-		procC := next
-		if len(a.Config.AggProcessors) != 0 {
-			procC, apu, err = a.startProcessors(next, a.Config.AggProcessors)
-			if err != nil {
-				return err
-			}
-		}
-
-		next, au, err = a.startAggregators(procC, next, a.Config.Aggregators)
-		if err != nil {
-			return err
-		}
-	}
-
-	var pu []*processorUnit
-	if len(a.Config.Processors) != 0 {
-		next, pu, err = a.startProcessors(next, a.Config.Processors)
-		if err != nil {
-			return err
-		}
+	chain, err := a.startChain(startTime, outputC,
+		a.Config.Processors, a.Config.AggProcessors, a.Config.Aggregators)
+	if err != nil {
+		return err
 	}
 
-	iu, err := a.testStartInputs(next, a.Config.Inputs)
+	iu, err := a.testStartInputs(chain.src, a.Config.Inputs)
 	if err != nil {
 		return err
 	}
 
 	var wg sync.WaitGroup
 
-	if au != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err = a.runProcessors(apu)
-			if err != nil {
-				log.Printf("E! [agent] Error running processors: %v", err)
-			}
-		}()
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err = a.runAggregators(startTime, au)
-			if err != nil {
-				log.Printf("E! [agent] Error running aggregators: %v", err)
-			}
-		}()
-	}
-
-	if pu != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err = a.runProcessors(pu)
-			if err != nil {
-				log.Printf("E! [agent] Error running processors: %v", err)
-			}
-		}()
-	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.runChain(chain)
+	}()
 
 	wg.Add(1)
 	go func() {
@@ -972,76 +1747,34 @@ func (a *Agent) once(ctx context.Context, wait time.Duration) error {
 		return err
 	}
 
-	var apu []*processorUnit
-	var au *aggregatorUnit
-	if len(a.Config.Aggregators) != 0 {
-		procC := next
-		if len(a.Config.AggProcessors) != 0 {
-			procC, apu, err = a.startProcessors(next, a.Config.AggProcessors)
-			if err != nil {
-				return err
-			}
-		}
-
-		next, au, err = a.startAggregators(procC, next, a.Config.Aggregators)
-		if err != nil {
-			return err
-		}
-	}
-
-	var pu []*processorUnit
-	if len(a.Config.Processors) != 0 {
-		next, pu, err = a.startProcessors(next, a.Config.Processors)
-		if err != nil {
-			return err
-		}
+	chain, err := a.startChain(startTime, next,
+		a.Config.Processors, a.Config.AggProcessors, a.Config.Aggregators)
+	if err != nil {
+		return err
 	}
 
-	iu, err := a.testStartInputs(next, a.Config.Inputs)
+	iu, err := a.testStartInputs(chain.src, a.Config.Inputs)
 	if err != nil {
 		return err
 	}
 
+	a.startFlushes(ou, nil)
+
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		err = a.runOutputs(ou)
+		err := a.runOutputs(ou)
 		if err != nil {
 			log.Printf("E! [agent] Error running outputs: %v", err)
 		}
 	}()
 
-	if au != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err = a.runProcessors(apu)
-			if err != nil {
-				log.Printf("E! [agent] Error running processors: %v", err)
-			}
-		}()
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err = a.runAggregators(startTime, au)
-			if err != nil {
-				log.Printf("E! [agent] Error running aggregators: %v", err)
-			}
-		}()
-	}
-
-	if pu != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err = a.runProcessors(pu)
-			if err != nil {
-				log.Printf("E! [agent] Error running processors: %v", err)
-			}
-		}()
-	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.runChain(chain)
+	}()
 
 	wg.Add(1)
 	go func() {
@@ -1059,6 +1792,24 @@ func (a *Agent) once(ctx context.Context, wait time.Duration) error {
 	return nil
 }
 
+// inputPrecision returns the timestamp precision of the input, the precision
+// set in the plugin configuration takes precedence over the agent precision.
+func (a *Agent) inputPrecision(input *models.RunningInput) time.Duration {
+	if input.Config.Precision > 0 {
+		return input.Config.Precision
+	}
+	return a.Precision()
+}
+
+// roundInterval returns true if the collection interval of the input should
+// be rounded to the interval.
+func (a *Agent) roundInterval(input *models.RunningInput) bool {
+	if input.Config.RoundInterval != nil {
+		return *input.Config.RoundInterval
+	}
+	return a.Config.Agent.RoundInterval
+}
+
 // Returns the rounding precision for metrics.
 func (a *Agent) Precision() time.Duration {
 	precision := a.Config.Agent.Precision.Duration
@@ -3,6 +3,7 @@
 package agent
 
 import (
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
@@ -17,3 +18,22 @@ func watchForFlushSignal(flushRequested chan os.Signal) {
 func stopListeningForFlushSignal(flushRequested chan os.Signal) {
 	defer signal.Stop(flushRequested)
 }
+
+// listenControl listens on the unix socket at path, replacing a socket left
+// behind by a previous run.  Only the owner and group may connect.
+func listenControl(path string) (net.Listener, error) {
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(path, 0660); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
@@ -1,12 +1,20 @@
 package agent
 
 import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
+	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -166,3 +174,399 @@ func TestWindow(t *testing.T) {
 		})
 	}
 }
+
+func TestAgent_InputPrecision(t *testing.T) {
+	c := config.NewConfig()
+	err := c.LoadConfigData([]byte(`
+[agent]
+  interval = "10s"
+  round_interval = true
+
+[[inputs.memcached]]
+
+[[inputs.redis]]
+  precision = "1ms"
+  round_interval = false
+`))
+	require.NoError(t, err)
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	require.Len(t, a.Config.Inputs, 2)
+
+	// The order of the inputs in the config is not preserved
+	inputs := make(map[string]*models.RunningInput)
+	for _, input := range a.Config.Inputs {
+		inputs[input.Config.Name] = input
+	}
+
+	require.Equal(t, time.Second, a.inputPrecision(inputs["memcached"]))
+	require.True(t, a.roundInterval(inputs["memcached"]))
+
+	require.Equal(t, time.Millisecond, a.inputPrecision(inputs["redis"]))
+	require.False(t, a.roundInterval(inputs["redis"]))
+}
+
+func TestAgent_KeepBuffers(t *testing.T) {
+	load := func(data string) *config.Config {
+		c := config.NewConfig()
+		require.NoError(t, c.LoadConfigData([]byte(data)))
+		return c
+	}
+
+	previous := load(`
+[[outputs.http]]
+  url = "http://localhost:8080/kept"
+
+[[outputs.http]]
+  url = "http://localhost:8080/changed"
+`)
+	for _, output := range previous.Outputs {
+		output.AddMetric(testutil.TestMetric(42))
+		output.AddMetric(testutil.TestMetric(43))
+	}
+
+	c := load(`
+[[outputs.http]]
+  url = "http://localhost:8080/changed"
+  timeout = "1s"
+
+[[outputs.http]]
+  url = "http://localhost:8080/kept"
+
+[[outputs.http]]
+  url = "http://localhost:8080/added"
+`)
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	a.KeepBuffers(previous.Outputs)
+
+	require.Equal(t, 0, c.Outputs[0].BufferLength())
+	require.Equal(t, 2, c.Outputs[1].BufferLength())
+	require.Equal(t, 0, c.Outputs[2].BufferLength())
+}
+
+func TestAgent_DiskBufferRequiresAlias(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfigData([]byte(`
+[[outputs.http]]
+  url = "http://localhost:8080/a"
+
+[[outputs.http]]
+  url = "http://localhost:8080/b"
+`)))
+	c.Agent.MetricBufferDirectory = dir
+
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	require.Error(t, a.openDiskBuffers(c.Outputs))
+
+	c.Outputs[1].Config.Alias = "b"
+	require.NoError(t, a.openDiskBuffers(c.Outputs))
+	closeBuffers(c.Outputs)
+
+	require.DirExists(t, filepath.Join(dir, "http"))
+	require.DirExists(t, filepath.Join(dir, "http-b"))
+}
+
+func TestAgent_RouteDeadLetters(t *testing.T) {
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfigData([]byte(`
+[agent]
+  dead_letter_output = "dead"
+
+[[outputs.http]]
+  alias = "a"
+  url = "http://localhost:8080/a"
+
+[[outputs.http]]
+  alias = "b"
+  url = "http://localhost:8080/b"
+  dead_letter_output = "b_rejected"
+
+[[outputs.http]]
+  alias = "c"
+  url = "http://localhost:8080/c"
+  dead_letter_output = ""
+
+[[outputs.file]]
+  alias = "dead"
+
+[[outputs.file]]
+  alias = "b_rejected"
+`)))
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	// The order of the outputs in the config is not preserved
+	outputs := make(map[string]*models.RunningOutput)
+	for _, output := range c.Outputs {
+		outputs[output.Config.Alias] = output
+	}
+
+	deadLetters, err := a.routeDeadLetters(c.Outputs)
+	require.NoError(t, err)
+	require.Equal(t, map[*models.RunningOutput]bool{
+		outputs["dead"]:       true,
+		outputs["b_rejected"]: true,
+	}, deadLetters)
+
+	outputs["b"].Config.DeadLetterOutput = nil
+	c.Agent.DeadLetterOutput = "missing"
+	_, err = a.routeDeadLetters(c.Outputs)
+	require.Error(t, err)
+}
+
+func TestAgent_CheckDeliveryOutputs(t *testing.T) {
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfigData([]byte(`
+[[inputs.kafka_consumer]]
+  delivery_outputs = ["primary", "file"]
+
+[[outputs.http]]
+  alias = "primary"
+  url = "http://localhost:8080/a"
+
+[[outputs.file]]
+`)))
+	require.Equal(t, []string{"primary", "file"}, c.Inputs[0].Config.DeliveryOutputs)
+
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	require.NoError(t, a.checkDeliveryOutputs())
+
+	c.Inputs[0].Config.DeliveryOutputs = []string{"http"}
+	require.Error(t, a.checkDeliveryOutputs())
+}
+
+func TestAgent_Validate(t *testing.T) {
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfigData([]byte(`
+[[inputs.kafka_consumer]]
+  delivery_mode = "exactly_once"
+  delivery_outputs = ["missing"]
+
+[[outputs.file]]
+  dead_letter_output = "missing"
+`)))
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	require.Len(t, a.Validate(), 3)
+
+	c = config.NewConfig()
+	require.NoError(t, c.LoadConfigData([]byte(`
+[[inputs.kafka_consumer]]
+
+[[outputs.file]]
+`)))
+	a, err = NewAgent(c)
+	require.NoError(t, err)
+	require.Empty(t, a.Validate())
+
+	c = config.NewConfig()
+	require.NoError(t, c.LoadConfigData([]byte(`
+[agent]
+  metric_max_fields = 10
+  oversized_metric_action = "dead_letter"
+
+[[inputs.kafka_consumer]]
+
+[[outputs.file]]
+`)))
+	a, err = NewAgent(c)
+	require.NoError(t, err)
+	errs := a.Validate()
+	require.Len(t, errs, 1)
+	require.EqualError(t, errs[0], `oversized_metric_action "dead_letter" requires dead_letter_output`)
+
+	c.Agent.DeadLetterOutput = "file"
+	require.Empty(t, a.Validate())
+}
+
+func TestAgent_Reload(t *testing.T) {
+	load := func(data string) *config.Config {
+		c := config.NewConfig()
+		require.NoError(t, c.LoadConfigData([]byte(data)))
+		return c
+	}
+
+	c := load(`
+[[inputs.mem]]
+
+[[inputs.swap]]
+
+[[outputs.discard]]
+  alias = "kept"
+
+[[outputs.discard]]
+  alias = "removed"
+`)
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	// The order of the plugins in the config is not preserved
+	inputs := make(map[string]*models.RunningInput)
+	for _, input := range c.Inputs {
+		inputs[input.Config.Name] = input
+	}
+	outputs := make(map[string]*models.RunningOutput)
+	for _, output := range c.Outputs {
+		outputs[output.Config.Alias] = output
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+	go func() {
+		stopped <- a.Run(ctx)
+	}()
+
+	// Reloading is possible once the agent is running
+	nc := load(`
+[[inputs.mem]]
+
+[[inputs.system]]
+
+[[outputs.discard]]
+  alias = "kept"
+
+[[outputs.discard]]
+  alias = "added"
+`)
+	require.Eventually(t, func() bool {
+		err = a.Reload(nc)
+		return err != ErrRestartRequired
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, err)
+
+	names := make(map[string]*models.RunningInput)
+	for _, input := range a.Config.Inputs {
+		names[input.Config.Name] = input
+	}
+	require.Len(t, names, 2)
+	require.Same(t, inputs["mem"], names["mem"])
+	require.Contains(t, names, "system")
+
+	aliases := make(map[string]*models.RunningOutput)
+	for _, output := range a.Config.Outputs {
+		aliases[output.Config.Alias] = output
+	}
+	require.Len(t, aliases, 2)
+	require.Same(t, outputs["kept"], aliases["kept"])
+	require.Contains(t, aliases, "added")
+
+	// A change of the agent settings requires a restart
+	require.Equal(t, ErrRestartRequired, a.Reload(load(`
+[agent]
+  interval = "1s"
+
+[[inputs.mem]]
+
+[[outputs.discard]]
+`)))
+
+	cancel()
+	select {
+	case err := <-stopped:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent did not stop")
+	}
+}
+
+// failingOutput fails the given number of writes.
+type failingOutput struct {
+	testOutput
+	failures int
+	written  int
+}
+
+func (o *failingOutput) Write(metrics []telegraf.Metric) error {
+	if o.failures > 0 {
+		o.failures--
+		return errors.New("write failed")
+	}
+	o.written += len(metrics)
+	return nil
+}
+
+func TestAgent_FinalFlush(t *testing.T) {
+	a, err := NewAgent(config.NewConfig())
+	require.NoError(t, err)
+
+	newOutput := func(failures int) (*models.RunningOutput, *failingOutput) {
+		output := &failingOutput{failures: failures}
+		ro := models.NewRunningOutput("test", output,
+			&models.OutputConfig{Name: "test"}, 1000, 10000)
+		ro.AddMetric(testutil.MustMetric("cpu", nil,
+			map[string]interface{}{"value": 42}, time.Unix(0, 0)))
+		return ro, output
+	}
+
+	ticker := NewUnalignedTicker(time.Hour, 0)
+	defer ticker.Stop()
+
+	// Without a deadline the final write is attempted once
+	ro, output := newOutput(1)
+	require.Error(t, a.finalFlush(ro, ticker, nil))
+	require.Equal(t, 0, output.written)
+	require.Equal(t, 1, ro.BufferLength())
+
+	// With a deadline failed writes are retried
+	ro, output = newOutput(1)
+	require.NoError(t, a.finalFlush(ro, ticker, make(chan empty)))
+	require.Equal(t, 1, output.written)
+	require.Equal(t, 0, ro.BufferLength())
+
+	// Until the deadline
+	deadline := make(chan empty)
+	close(deadline)
+	ro, output = newOutput(2)
+	require.Error(t, a.finalFlush(ro, ticker, deadline))
+	require.Equal(t, 0, output.written)
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	deadline := shutdownDeadline(ctx, 10*time.Millisecond)
+
+	select {
+	case <-deadline:
+		t.Fatal("deadline passed before the context is done")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case <-deadline:
+	case <-time.After(time.Second):
+		t.Fatal("deadline did not pass after the timeout")
+	}
+}
+
+func TestShutdownTimeoutError(t *testing.T) {
+	stopped := make(chan empty)
+	err := &ShutdownTimeoutError{Timeout: time.Second, Buffered: 3, stopped: stopped}
+	require.EqualError(t, err, "shutdown did not complete within 1s, 3 metrics are still buffered")
+
+	waited := make(chan empty)
+	go func() {
+		err.Wait()
+		close(waited)
+	}()
+
+	select {
+	case <-waited:
+		t.Fatal("wait returned before the agent stopped")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(stopped)
+	select {
+	case <-waited:
+	case <-time.After(time.Second):
+		t.Fatal("wait did not return after the agent stopped")
+	}
+}
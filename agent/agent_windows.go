@@ -2,7 +2,12 @@
 
 package agent
 
-import "os"
+import (
+	"net"
+	"os"
+
+	"github.com/Microsoft/go-winio"
+)
 
 func watchForFlushSignal(flushRequested chan os.Signal) {
 	// not supported
@@ -11,3 +16,8 @@ func watchForFlushSignal(flushRequested chan os.Signal) {
 func stopListeningForFlushSignal(flushRequested chan os.Signal) {
 	// not supported
 }
+
+// listenControl listens on the named pipe, ie. `\\.\pipe\telegraf`.
+func listenControl(path string) (net.Listener, error) {
+	return winio.ListenPipe(path, nil)
+}
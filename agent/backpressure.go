@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"log"
+	"sync"
+
+	"github.com/influxdata/telegraf/models"
+)
+
+// backpressureFlushes is the number of consecutive flushes after which an
+// output still above its buffer watermark is considered behind.
+const backpressureFlushes = 3
+
+// backpressure tracks the outputs that are persistently behind, so that the
+// collection of inputs can be slowed down until they catch up.  A nil
+// backpressure is never active.
+type backpressure struct {
+	sync.Mutex
+	flushes map[*models.RunningOutput]int
+	behind  int
+}
+
+func newBackpressure() *backpressure {
+	return &backpressure{
+		flushes: make(map[*models.RunningOutput]int),
+	}
+}
+
+// flushed updates the state of the output after a flush.
+func (b *backpressure) flushed(output *models.RunningOutput) {
+	if b == nil || output.BufferWatermark == 0 {
+		return
+	}
+
+	above := output.BufferLength() >= output.BufferWatermark
+
+	b.Lock()
+	defer b.Unlock()
+
+	n := b.flushes[output]
+	switch {
+	case above:
+		b.flushes[output] = n + 1
+		if n+1 == backpressureFlushes {
+			b.behind++
+			log.Printf("W! [agent] Output %s is behind, slowing down the collection of inputs",
+				output.LogName())
+		}
+	case n >= backpressureFlushes:
+		b.flushes[output] = 0
+		b.behind--
+		log.Printf("I! [agent] Output %s caught up", output.LogName())
+	default:
+		b.flushes[output] = 0
+	}
+}
+
+// remove stops tracking the output, removed by a reload.
+func (b *backpressure) remove(output *models.RunningOutput) {
+	if b == nil {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+	if b.flushes[output] >= backpressureFlushes {
+		b.behind--
+	}
+	delete(b.flushes, output)
+}
+
+// active returns true if any output is behind.
+func (b *backpressure) active() bool {
+	if b == nil {
+		return false
+	}
+
+	b.Lock()
+	defer b.Unlock()
+	return b.behind > 0
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackpressure(t *testing.T) {
+	output := newTestOutput("file", "")
+	output.BufferWatermark = 2
+	for i := 0; i < 3; i++ {
+		output.AddMetric(testutil.MustMetric("cpu", nil,
+			map[string]interface{}{"value": i}, time.Unix(int64(i), 0)))
+	}
+
+	var nilBackpressure *backpressure
+	nilBackpressure.flushed(output)
+	require.False(t, nilBackpressure.active())
+
+	b := newBackpressure()
+	for i := 0; i < backpressureFlushes-1; i++ {
+		b.flushed(output)
+		require.False(t, b.active())
+	}
+	b.flushed(output)
+	require.True(t, b.active())
+	b.flushed(output)
+	require.True(t, b.active())
+
+	require.NoError(t, output.Write())
+	b.flushed(output)
+	require.False(t, b.active())
+}
+
+func TestAgent_BufferWatermarks(t *testing.T) {
+	c := config.NewConfig()
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	outputs := []*models.RunningOutput{newTestOutput("file", "")}
+	outputs[0].MetricBufferLimit = 1000
+
+	c.Agent.FlushBufferWatermark = 80
+	require.NoError(t, a.setBufferWatermarks(outputs))
+	require.Equal(t, 800, outputs[0].BufferWatermark)
+
+	c.Agent.FlushBufferWatermark = 0
+	require.NoError(t, a.setBufferWatermarks(outputs))
+	require.Equal(t, 0, outputs[0].BufferWatermark)
+
+	c.Agent.CollectionBackpressure = true
+	require.Error(t, a.setBufferWatermarks(outputs))
+
+	c.Agent.FlushBufferWatermark = 101
+	require.Error(t, a.setBufferWatermarks(outputs))
+}
@@ -0,0 +1,212 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf/models"
+)
+
+// inputSchedule holds the collection interval and jitter of an input, which
+// can be changed through the control API while the agent is running.
+type inputSchedule struct {
+	sync.Mutex
+	interval time.Duration
+	jitter   time.Duration
+
+	// changed receives a value whenever the schedule is updated.
+	changed chan empty
+}
+
+func newInputSchedule(interval, jitter time.Duration) *inputSchedule {
+	return &inputSchedule{
+		interval: interval,
+		jitter:   jitter,
+		changed:  make(chan empty, 1),
+	}
+}
+
+func (s *inputSchedule) get() (time.Duration, time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+	return s.interval, s.jitter
+}
+
+func (s *inputSchedule) set(interval, jitter time.Duration) {
+	s.Lock()
+	s.interval = interval
+	s.jitter = jitter
+	s.Unlock()
+
+	select {
+	case s.changed <- empty{}:
+	default:
+	}
+}
+
+// controlInput is the representation of an input in the control API.
+type controlInput struct {
+	Index            int    `json:"index"`
+	Name             string `json:"name"`
+	Alias            string `json:"alias,omitempty"`
+	Interval         string `json:"interval"`
+	CollectionJitter string `json:"collection_jitter"`
+}
+
+// controlScheduleUpdate is the request body for changing the schedule of an
+// input, unset values are left unchanged.
+type controlScheduleUpdate struct {
+	Interval         *string `json:"interval"`
+	CollectionJitter *string `json:"collection_jitter"`
+}
+
+// controlServer serves the control API on a local socket.
+//
+//   GET  /inputs          lists the inputs with their schedule
+//   POST /inputs/<index>  changes the schedule of an input
+type controlServer struct {
+	// unit holds the inputs, which change when the config is reloaded.
+	unit *inputUnit
+
+	listener net.Listener
+	server   *http.Server
+}
+
+func newControlServer(address string, unit *inputUnit) (*controlServer, error) {
+	listener, err := listenControl(address)
+	if err != nil {
+		return nil, fmt.Errorf("listening on control socket %s: %w", address, err)
+	}
+
+	c := &controlServer{
+		unit:     unit,
+		listener: listener,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/inputs", c.listInputs)
+	mux.HandleFunc("/inputs/", c.updateInput)
+	c.server = &http.Server{Handler: mux}
+	return c, nil
+}
+
+// serve handles requests until the context is done.
+func (c *controlServer) serve(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		c.server.Close()
+	}()
+
+	err := c.server.Serve(c.listener)
+	if err != nil && err != http.ErrServerClosed {
+		log.Printf("E! [agent] Serving control API: %v", err)
+	}
+}
+
+// input returns the input and its schedule by index, false if there is no
+// input with the index.
+func (c *controlServer) input(i int) (*models.RunningInput, *inputSchedule, bool) {
+	c.unit.Lock()
+	defer c.unit.Unlock()
+
+	if i < 0 || i >= len(c.unit.inputs) {
+		return nil, nil, false
+	}
+	return c.unit.inputs[i], c.unit.schedules[i], true
+}
+
+func describe(i int, input *models.RunningInput, schedule *inputSchedule) controlInput {
+	interval, jitter := schedule.get()
+	return controlInput{
+		Index:            i,
+		Name:             input.Config.Name,
+		Alias:            input.Config.Alias,
+		Interval:         interval.String(),
+		CollectionJitter: jitter.String(),
+	}
+}
+
+func (c *controlServer) listInputs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	c.unit.Lock()
+	inputs := make([]controlInput, 0, len(c.unit.inputs))
+	for i, input := range c.unit.inputs {
+		inputs = append(inputs, describe(i, input, c.unit.schedules[i]))
+	}
+	c.unit.Unlock()
+	writeJSON(w, inputs)
+}
+
+func (c *controlServer) updateInput(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	i, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/inputs/"))
+	if err != nil {
+		http.Error(w, "input not found", http.StatusNotFound)
+		return
+	}
+	input, schedule, ok := c.input(i)
+	if !ok {
+		http.Error(w, "input not found", http.StatusNotFound)
+		return
+	}
+
+	var update controlScheduleUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	interval, jitter := schedule.get()
+	if update.Interval != nil {
+		interval, err = time.ParseDuration(*update.Interval)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid interval: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if update.CollectionJitter != nil {
+		jitter, err = time.ParseDuration(*update.CollectionJitter)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid collection_jitter: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if interval <= 0 {
+		http.Error(w, "interval must be greater than 0", http.StatusBadRequest)
+		return
+	}
+	if jitter < 0 {
+		http.Error(w, "collection_jitter must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	schedule.set(interval, jitter)
+	log.Printf("I! [agent] [%s] Schedule changed: interval %s, collection jitter %s",
+		input.LogName(), interval, jitter)
+
+	writeJSON(w, describe(i, input, schedule))
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("E! [agent] Writing control API response: %v", err)
+	}
+}
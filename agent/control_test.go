@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
+	"github.com/stretchr/testify/require"
+)
+
+type testInput struct {
+	gathered chan empty
+}
+
+func (t *testInput) Description() string  { return "" }
+func (t *testInput) SampleConfig() string { return "" }
+func (t *testInput) Gather(acc telegraf.Accumulator) error {
+	if t.gathered != nil {
+		t.gathered <- empty{}
+	}
+	return nil
+}
+
+func newTestControlServer() *controlServer {
+	return &controlServer{
+		unit: &inputUnit{
+			inputs: []*models.RunningInput{
+				models.NewRunningInput(&testInput{}, &models.InputConfig{Name: "cpu"}),
+				models.NewRunningInput(&testInput{}, &models.InputConfig{Name: "mem", Alias: "slow"}),
+			},
+			schedules: []*inputSchedule{
+				newInputSchedule(10*time.Second, 0),
+				newInputSchedule(time.Minute, time.Second),
+			},
+		},
+	}
+}
+
+func TestControlListInputs(t *testing.T) {
+	c := newTestControlServer()
+
+	w := httptest.NewRecorder()
+	c.listInputs(w, httptest.NewRequest("GET", "/inputs", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var inputs []controlInput
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&inputs))
+	require.Equal(t, []controlInput{
+		{Index: 0, Name: "cpu", Interval: "10s", CollectionJitter: "0s"},
+		{Index: 1, Name: "mem", Alias: "slow", Interval: "1m0s", CollectionJitter: "1s"},
+	}, inputs)
+}
+
+func TestControlUpdateInput(t *testing.T) {
+	c := newTestControlServer()
+
+	w := httptest.NewRecorder()
+	c.updateInput(w, httptest.NewRequest("POST", "/inputs/1", strings.NewReader(`{"interval": "5m"}`)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	interval, jitter := c.unit.schedules[1].get()
+	require.Equal(t, 5*time.Minute, interval)
+	require.Equal(t, time.Second, jitter)
+
+	select {
+	case <-c.unit.schedules[1].changed:
+	default:
+		t.Fatal("schedule change not signaled")
+	}
+
+	w = httptest.NewRecorder()
+	c.updateInput(w, httptest.NewRequest("POST", "/inputs/1", strings.NewReader(`{"collection_jitter": "0s"}`)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	interval, jitter = c.unit.schedules[1].get()
+	require.Equal(t, 5*time.Minute, interval)
+	require.Equal(t, time.Duration(0), jitter)
+}
+
+func TestControlUpdateInputInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		body string
+		code int
+	}{
+		{name: "unknown input", path: "/inputs/2", body: `{}`, code: http.StatusNotFound},
+		{name: "invalid index", path: "/inputs/cpu", body: `{}`, code: http.StatusNotFound},
+		{name: "invalid json", path: "/inputs/0", body: `{`, code: http.StatusBadRequest},
+		{name: "invalid duration", path: "/inputs/0", body: `{"interval": "often"}`, code: http.StatusBadRequest},
+		{name: "zero interval", path: "/inputs/0", body: `{"interval": "0s"}`, code: http.StatusBadRequest},
+		{name: "negative jitter", path: "/inputs/0", body: `{"collection_jitter": "-1s"}`, code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestControlServer()
+
+			w := httptest.NewRecorder()
+			c.updateInput(w, httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body)))
+			require.Equal(t, tt.code, w.Code)
+
+			interval, jitter := c.unit.schedules[0].get()
+			require.Equal(t, 10*time.Second, interval)
+			require.Equal(t, time.Duration(0), jitter)
+		})
+	}
+}
+
+func TestGatherLoopScheduleChange(t *testing.T) {
+	input := &testInput{gathered: make(chan empty)}
+	ri := models.NewRunningInput(input, &models.InputConfig{Name: "test"})
+	acc := NewAccumulator(ri, make(chan telegraf.Metric, 10))
+
+	schedule := newInputSchedule(time.Hour, 0)
+	newTicker := func(start time.Time) Ticker {
+		interval, jitter := schedule.get()
+		return NewUnalignedTicker(interval, jitter)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan empty)
+	go func() {
+		a := &Agent{}
+		a.gatherLoop(ctx, acc, ri, newTicker(time.Now()), schedule, newTicker)
+		close(done)
+	}()
+
+	schedule.set(10*time.Millisecond, 0)
+	select {
+	case <-input.gathered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("input not gathered after the interval was changed")
+	}
+
+	cancel()
+	go func() {
+		// unblock a gather in progress
+		for range input.gathered {
+		}
+	}()
+	<-done
+	close(input.gathered)
+}
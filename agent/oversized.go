@@ -0,0 +1,181 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+const (
+	oversizedTruncate   = "truncate"
+	oversizedSplit      = "split"
+	oversizedDrop       = "drop"
+	oversizedDeadLetter = "dead_letter"
+)
+
+var (
+	oversizedMetrics = selfstat.Register("agent", "metrics_oversized", map[string]string{})
+)
+
+// sizeLimiter enforces the agent limits on the size of a single metric.
+type sizeLimiter struct {
+	maxFields       int
+	maxStringLength int
+	action          string
+}
+
+func newSizeLimiter(maxFields, maxStringLength int, action string) (*sizeLimiter, error) {
+	switch action {
+	case "":
+		action = oversizedTruncate
+	case oversizedTruncate, oversizedSplit, oversizedDrop, oversizedDeadLetter:
+	default:
+		return nil, fmt.Errorf("invalid oversized_metric_action %q", action)
+	}
+
+	if maxFields <= 0 && maxStringLength <= 0 {
+		return nil, nil
+	}
+
+	return &sizeLimiter{
+		maxFields:       maxFields,
+		maxStringLength: maxStringLength,
+		action:          action,
+	}, nil
+}
+
+// check returns a description of why the metric exceeds the limits, or an
+// empty string if it does not.
+func (l *sizeLimiter) check(m telegraf.Metric) string {
+	if l.maxFields > 0 && len(m.FieldList()) > l.maxFields {
+		return fmt.Sprintf("%d fields exceeds limit of %d", len(m.FieldList()), l.maxFields)
+	}
+
+	if l.maxStringLength > 0 {
+		for _, tag := range m.TagList() {
+			if len(tag.Value) > l.maxStringLength {
+				return fmt.Sprintf("tag %q length %d exceeds limit of %d",
+					tag.Key, len(tag.Value), l.maxStringLength)
+			}
+		}
+		for _, field := range m.FieldList() {
+			if s, ok := field.Value.(string); ok && len(s) > l.maxStringLength {
+				return fmt.Sprintf("field %q length %d exceeds limit of %d",
+					field.Key, len(s), l.maxStringLength)
+			}
+		}
+	}
+	return ""
+}
+
+// apply returns the metrics that should be written to the outputs.  If the
+// metric must be diverted to the dead letter output it is returned as
+// diverted instead.
+func (l *sizeLimiter) apply(m telegraf.Metric) (metrics []telegraf.Metric, diverted telegraf.Metric) {
+	reason := l.check(m)
+	if reason == "" {
+		return []telegraf.Metric{m}, nil
+	}
+
+	oversizedMetrics.Incr(1)
+
+	switch l.action {
+	case oversizedDrop:
+		log.Printf("W! [agent] Dropping oversized metric %q: %s", m.Name(), reason)
+		m.Drop()
+		return nil, nil
+	case oversizedDeadLetter:
+		log.Printf("W! [agent] Diverting oversized metric %q to dead letter output: %s", m.Name(), reason)
+		return nil, m
+	case oversizedSplit:
+		log.Printf("D! [agent] Splitting oversized metric %q: %s", m.Name(), reason)
+		metrics = l.split(m)
+	default:
+		log.Printf("D! [agent] Truncating oversized metric %q: %s", m.Name(), reason)
+		metrics = []telegraf.Metric{m}
+		l.truncateFields(m)
+	}
+
+	for _, metric := range metrics {
+		l.truncateStrings(metric)
+	}
+	return metrics, nil
+}
+
+// split divides the fields of the metric into multiple metrics with the same
+// name, tags and time.
+func (l *sizeLimiter) split(m telegraf.Metric) []telegraf.Metric {
+	if l.maxFields <= 0 || len(m.FieldList()) <= l.maxFields {
+		return []telegraf.Metric{m}
+	}
+
+	keys := fieldKeys(m)
+	var metrics []telegraf.Metric
+	for start := 0; start < len(keys); start += l.maxFields {
+		end := start + l.maxFields
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		part := m.Copy()
+		for i, key := range keys {
+			if i < start || i >= end {
+				part.RemoveField(key)
+			}
+		}
+		metrics = append(metrics, part)
+	}
+	m.Drop()
+	return metrics
+}
+
+// truncateFields removes fields in excess of the limit, keeping the fields
+// that sort first.
+func (l *sizeLimiter) truncateFields(m telegraf.Metric) {
+	if l.maxFields <= 0 || len(m.FieldList()) <= l.maxFields {
+		return
+	}
+
+	for _, key := range fieldKeys(m)[l.maxFields:] {
+		m.RemoveField(key)
+	}
+}
+
+func (l *sizeLimiter) truncateStrings(m telegraf.Metric) {
+	if l.maxStringLength <= 0 {
+		return
+	}
+
+	for _, tag := range m.TagList() {
+		if len(tag.Value) > l.maxStringLength {
+			tag.Value = truncate(tag.Value, l.maxStringLength)
+		}
+	}
+	for _, field := range m.FieldList() {
+		if s, ok := field.Value.(string); ok && len(s) > l.maxStringLength {
+			field.Value = truncate(s, l.maxStringLength)
+		}
+	}
+}
+
+// truncate shortens the string to at most n bytes without splitting a
+// multi-byte character.
+func truncate(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func fieldKeys(m telegraf.Metric) []string {
+	keys := make([]string, 0, len(m.FieldList()))
+	for _, field := range m.FieldList() {
+		keys = append(keys, field.Key)
+	}
+	sort.Strings(keys)
+	return keys
+}
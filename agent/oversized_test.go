@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func oversizedMetric() telegraf.Metric {
+	return testutil.MustMetric("cpu",
+		map[string]string{"host": strings.Repeat("a", 12)},
+		map[string]interface{}{
+			"a": 1,
+			"b": 2,
+			"c": 3,
+			"d": strings.Repeat("x", 12),
+			"e": 5,
+		},
+		time.Unix(0, 0))
+}
+
+func TestSizeLimiterDisabled(t *testing.T) {
+	limiter, err := newSizeLimiter(0, 0, "")
+	require.NoError(t, err)
+	require.Nil(t, limiter)
+
+	_, err = newSizeLimiter(10, 0, "discard")
+	require.Error(t, err)
+}
+
+func TestSizeLimiterTruncate(t *testing.T) {
+	limiter, err := newSizeLimiter(3, 10, "")
+	require.NoError(t, err)
+
+	metrics, diverted := limiter.apply(oversizedMetric())
+	require.Nil(t, diverted)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": strings.Repeat("a", 10)},
+			map[string]interface{}{"a": 1, "b": 2, "c": 3},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestSizeLimiterTruncateMultiByte(t *testing.T) {
+	limiter, err := newSizeLimiter(0, 10, "")
+	require.NoError(t, err)
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{"host": strings.Repeat("é", 6)},
+		map[string]interface{}{"value": "abcdefghi€"},
+		time.Unix(0, 0))
+	metrics, diverted := limiter.apply(m)
+	require.Nil(t, diverted)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": strings.Repeat("é", 5)},
+			map[string]interface{}{"value": "abcdefghi"},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestSizeLimiterSplit(t *testing.T) {
+	limiter, err := newSizeLimiter(2, 10, oversizedSplit)
+	require.NoError(t, err)
+
+	metrics, diverted := limiter.apply(oversizedMetric())
+	require.Nil(t, diverted)
+
+	tags := map[string]string{"host": strings.Repeat("a", 10)}
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu", tags, map[string]interface{}{"a": 1, "b": 2}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", tags, map[string]interface{}{"c": 3, "d": strings.Repeat("x", 10)}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", tags, map[string]interface{}{"e": 5}, time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestSizeLimiterDrop(t *testing.T) {
+	limiter, err := newSizeLimiter(0, 100, oversizedDrop)
+	require.NoError(t, err)
+
+	m := oversizedMetric()
+	metrics, diverted := limiter.apply(m)
+	require.Len(t, metrics, 1)
+	require.Nil(t, diverted)
+
+	limiter.maxStringLength = 5
+	metrics, diverted = limiter.apply(m)
+	require.Len(t, metrics, 0)
+	require.Nil(t, diverted)
+}
+
+func TestSizeLimiterDeadLetter(t *testing.T) {
+	limiter, err := newSizeLimiter(4, 0, oversizedDeadLetter)
+	require.NoError(t, err)
+
+	m := oversizedMetric()
+	metrics, diverted := limiter.apply(m)
+	require.Len(t, metrics, 0)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{oversizedMetric()}, []telegraf.Metric{diverted})
+}
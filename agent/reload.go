@@ -0,0 +1,407 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
+)
+
+// ErrRestartRequired is returned by Reload when the config cannot be applied
+// to the running agent, such as when the agent settings or the global tags
+// changed.  The agent must be restarted with the new config instead.
+var ErrRestartRequired = errors.New("restart required to apply the config")
+
+// Reload applies the config to the running agent.  The plugins configured
+// identically in both configs, according to their fingerprint, keep running
+// and the plugins of the new config replacing them are discarded.  The
+// removed plugins are stopped and the added plugins started.  The processors
+// and aggregators are replaced together when any of them changed.
+//
+// An error is returned, and the running agent left unchanged, when a plugin
+// of the new config cannot be initialized or connected.
+func (a *Agent) Reload(c *config.Config) error {
+	a.reloadMutex.Lock()
+	defer a.reloadMutex.Unlock()
+
+	// The agent is not reloaded while it is starting or stopping.
+	r := a.running
+	if r == nil {
+		return ErrRestartRequired
+	}
+	if c.AgentFingerprint != a.Config.AgentFingerprint {
+		return ErrRestartRequired
+	}
+
+	inputs, addedInputs, removedInputs := matchInputs(a.Config.Inputs, c.Inputs)
+	outputs, addedOutputs, removedOutputs := matchOutputs(a.Config.Outputs, c.Outputs)
+	chainChanged := !sameChain(a.Config, c)
+
+	// The plugins are checked against the agent settings, which are
+	// unchanged, before the running agent is changed.
+	next := *a.Config
+	next.Inputs = inputs
+	next.Outputs = outputs
+	next.Routes = c.Routes
+	if chainChanged {
+		next.Processors = c.Processors
+		next.AggProcessors = c.AggProcessors
+		next.Aggregators = c.Aggregators
+	}
+	na := &Agent{Config: &next}
+
+	if err := initAdded(addedInputs, addedOutputs, chainChanged, c); err != nil {
+		return err
+	}
+	if err := na.checkDeliveryOutputs(); err != nil {
+		return err
+	}
+
+	targets, deadLetters, err := na.deadLetterTargets(outputs)
+	if err != nil {
+		return err
+	}
+
+	var router *router
+	if len(c.Routes) > 0 {
+		router, err = newRouter(c.Routes, outputs, deadLetters)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := na.setBufferWatermarks(addedOutputs); err != nil {
+		return err
+	}
+
+	if err := a.reloadDiskBuffers(outputs, addedOutputs, removedOutputs); err != nil {
+		return err
+	}
+
+	for i, output := range addedOutputs {
+		err := a.connectOutput(r.ctx, output)
+		if err != nil {
+			for _, output := range addedOutputs[:i] {
+				output.Close()
+			}
+			closeBuffers(addedOutputs)
+			return fmt.Errorf("connecting output %s: %w", output.LogName(), err)
+		}
+	}
+
+	var chain *chainUnit
+	if chainChanged {
+		chain, err = a.startReloadableChain(time.Now(), c.Processors, c.AggProcessors, c.Aggregators)
+		if err != nil {
+			for _, output := range addedOutputs {
+				output.Close()
+			}
+			closeBuffers(addedOutputs)
+			return err
+		}
+	}
+
+	a.reloadOutputs(r, outputs, addedOutputs, removedOutputs, targets, deadLetters, router)
+	if chain != nil {
+		r.swap <- chain
+		r.chain = chain
+	}
+	inputs = a.reloadInputs(r, inputs, removedInputs)
+
+	a.Config.Inputs = inputs
+	a.Config.Outputs = outputs
+	a.Config.Routes = c.Routes
+	if chainChanged {
+		a.Config.Processors = c.Processors
+		a.Config.AggProcessors = c.AggProcessors
+		a.Config.Aggregators = c.Aggregators
+	}
+
+	log.Printf("I! [agent] Reloaded config: started %d inputs and %d outputs, stopped %d inputs and %d outputs",
+		len(addedInputs), len(addedOutputs), len(removedInputs), len(removedOutputs))
+	if chainChanged {
+		log.Printf("I! [agent] Reloaded config: restarted the processors and aggregators")
+	}
+	return nil
+}
+
+// initAdded runs the Init function on the plugins added by a reload.
+func initAdded(
+	inputs []*models.RunningInput,
+	outputs []*models.RunningOutput,
+	chainChanged bool,
+	c *config.Config,
+) error {
+	for _, input := range inputs {
+		if err := input.Init(); err != nil {
+			return fmt.Errorf("could not initialize input %s: %v", input.LogName(), err)
+		}
+	}
+	for _, output := range outputs {
+		if err := output.Init(); err != nil {
+			return fmt.Errorf("could not initialize output %s: %v", output.LogName(), err)
+		}
+	}
+	if !chainChanged {
+		return nil
+	}
+	for _, processor := range c.Processors {
+		if err := processor.Init(); err != nil {
+			return fmt.Errorf("could not initialize processor %s: %v", processor.LogName(), err)
+		}
+	}
+	for _, aggregator := range c.Aggregators {
+		if err := aggregator.Init(); err != nil {
+			return fmt.Errorf("could not initialize aggregator %s: %v", aggregator.LogName(), err)
+		}
+	}
+	return nil
+}
+
+// reloadDiskBuffers opens the disk buffers of the outputs added by a reload.
+// An added output cannot take over the buffer directory of a removed output
+// while the removed output is running, the agent must be restarted instead.
+func (a *Agent) reloadDiskBuffers(outputs, added, removed []*models.RunningOutput) error {
+	if a.Config.Agent.MetricBufferDirectory == "" {
+		return nil
+	}
+
+	names := make(map[string]*models.RunningOutput, len(outputs))
+	for _, output := range outputs {
+		name := diskBufferName(output)
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("outputs %s and %s use the same disk buffer, set an alias to distinguish them",
+				other.LogName(), output.LogName())
+		}
+		names[name] = output
+	}
+
+	for _, output := range removed {
+		for _, other := range added {
+			if diskBufferName(output) == diskBufferName(other) {
+				return ErrRestartRequired
+			}
+		}
+	}
+	return a.openDiskBuffers(added)
+}
+
+// reloadOutputs replaces the outputs of the running agent.  The removed
+// outputs are flushed a final time and closed in the background.
+func (a *Agent) reloadOutputs(
+	r *runningUnits,
+	outputs []*models.RunningOutput,
+	added []*models.RunningOutput,
+	removed []*models.RunningOutput,
+	targets map[*models.RunningOutput]*models.RunningOutput,
+	deadLetters map[*models.RunningOutput]bool,
+	router *router,
+) {
+	unit := r.outputs
+	unit.Lock()
+	defer unit.Unlock()
+
+	a.setOutputs(unit, outputs, deadLetters)
+	unit.router = router
+	setDeadLetters(outputs, targets, deadLetters)
+
+	for _, output := range added {
+		unit.stops[output] = a.startFlush(unit, output, r.deadline)
+	}
+
+	for _, output := range removed {
+		stop := unit.stops[output]
+		delete(unit.stops, output)
+
+		unit.wg.Add(1)
+		go func(output *models.RunningOutput) {
+			defer unit.wg.Done()
+
+			stop(a.stopDeadline())
+			output.Close()
+			closeBuffers([]*models.RunningOutput{output})
+			a.backpressure.remove(output)
+		}(output)
+	}
+}
+
+// reloadInputs stops the removed inputs of the running agent and starts the
+// inputs added.  The inputs failing to start are left out of the returned
+// inputs.
+func (a *Agent) reloadInputs(
+	r *runningUnits,
+	inputs []*models.RunningInput,
+	removed []*models.RunningInput,
+) []*models.RunningInput {
+	unit := r.inputs
+	unit.Lock()
+	defer unit.Unlock()
+
+	schedules := make(map[*models.RunningInput]*inputSchedule, len(unit.inputs))
+	stops := make(map[*models.RunningInput]func(), len(unit.inputs))
+	for i, input := range unit.inputs {
+		schedules[input] = unit.schedules[i]
+		stops[input] = unit.stops[i]
+	}
+
+	// The removed inputs are stopped first, so that a changed input can use
+	// the same resources.
+	for _, input := range removed {
+		stops[input]()
+	}
+	stopServiceInputs(removed)
+
+	unit.inputs, unit.schedules, unit.stops = nil, nil, nil
+	for _, input := range inputs {
+		schedule, ok := schedules[input]
+		stop := stops[input]
+		if !ok {
+			if si, ok := input.Input.(telegraf.ServiceInput); ok {
+				// Service input plugins are not subject to timestamp
+				// rounding, as in startInputs.
+				acc := NewAccumulator(input, unit.dst)
+				acc.SetPrecision(time.Nanosecond)
+
+				if err := si.Start(acc); err != nil {
+					log.Printf("E! [agent] Starting input %s: %v", input.LogName(), err)
+					continue
+				}
+			}
+			schedule = a.initialSchedule(input)
+			stop = a.startGather(r.inputCtx, time.Now(), unit, input, schedule)
+		}
+
+		unit.inputs = append(unit.inputs, input)
+		unit.schedules = append(unit.schedules, schedule)
+		unit.stops = append(unit.stops, stop)
+	}
+	return append([]*models.RunningInput(nil), unit.inputs...)
+}
+
+// stopDeadline returns a channel closed once the shutdown timeout elapsed,
+// it is nil when no timeout is set.
+func (a *Agent) stopDeadline() <-chan empty {
+	timeout := a.Config.Agent.ShutdownTimeout.Duration
+	if timeout <= 0 {
+		return nil
+	}
+
+	deadline := make(chan empty)
+	time.AfterFunc(timeout, func() {
+		close(deadline)
+	})
+	return deadline
+}
+
+// matchInputs returns the inputs of the next config, with the inputs
+// configured identically in the previous config in their place, and the
+// inputs added and removed.
+func matchInputs(previous, next []*models.RunningInput) (
+	inputs []*models.RunningInput,
+	added []*models.RunningInput,
+	removed []*models.RunningInput,
+) {
+	prev := make([]string, 0, len(previous))
+	for _, input := range previous {
+		prev = append(prev, input.Config.Fingerprint)
+	}
+	fingerprints := make([]string, 0, len(next))
+	for _, input := range next {
+		fingerprints = append(fingerprints, input.Config.Fingerprint)
+	}
+
+	kept := make(map[int]bool, len(previous))
+	for i, j := range matchFingerprints(prev, fingerprints) {
+		if j < 0 {
+			inputs = append(inputs, next[i])
+			added = append(added, next[i])
+			continue
+		}
+		kept[j] = true
+		inputs = append(inputs, previous[j])
+	}
+	for j, input := range previous {
+		if !kept[j] {
+			removed = append(removed, input)
+		}
+	}
+	return inputs, added, removed
+}
+
+// matchOutputs returns the outputs of the next config, with the outputs
+// configured identically in the previous config in their place, and the
+// outputs added and removed.
+func matchOutputs(previous, next []*models.RunningOutput) (
+	outputs []*models.RunningOutput,
+	added []*models.RunningOutput,
+	removed []*models.RunningOutput,
+) {
+	prev := make([]string, 0, len(previous))
+	for _, output := range previous {
+		prev = append(prev, output.Config.Fingerprint)
+	}
+	fingerprints := make([]string, 0, len(next))
+	for _, output := range next {
+		fingerprints = append(fingerprints, output.Config.Fingerprint)
+	}
+
+	kept := make(map[int]bool, len(previous))
+	for i, j := range matchFingerprints(prev, fingerprints) {
+		if j < 0 {
+			outputs = append(outputs, next[i])
+			added = append(added, next[i])
+			continue
+		}
+		kept[j] = true
+		outputs = append(outputs, previous[j])
+	}
+	for j, output := range previous {
+		if !kept[j] {
+			removed = append(removed, output)
+		}
+	}
+	return outputs, added, removed
+}
+
+// sameChain returns true if the processors and aggregators of the configs are
+// configured identically and in the same order.
+func sameChain(previous, next *config.Config) bool {
+	if len(previous.Processors) != len(next.Processors) ||
+		len(previous.Aggregators) != len(next.Aggregators) {
+		return false
+	}
+	for i, processor := range previous.Processors {
+		if processor.Config.Fingerprint != next.Processors[i].Config.Fingerprint {
+			return false
+		}
+	}
+	for i, aggregator := range previous.Aggregators {
+		if aggregator.Config.Fingerprint != next.Aggregators[i].Config.Fingerprint {
+			return false
+		}
+	}
+	return true
+}
+
+// matchFingerprints returns for each of the next fingerprints the index of the
+// same previous fingerprint, or -1 if there is none.  Each previous
+// fingerprint is matched once.
+func matchFingerprints(previous, next []string) []int {
+	matched := make([]bool, len(previous))
+	indexes := make([]int, len(next))
+	for i, fingerprint := range next {
+		indexes[i] = -1
+		for j, prev := range previous {
+			if !matched[j] && prev == fingerprint {
+				matched[j] = true
+				indexes[i] = j
+				break
+			}
+		}
+	}
+	return indexes
+}
@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
+)
+
+// router selects the outputs receiving each metric according to the routes of
+// the agent.  Each route is evaluated once per metric, regardless of the number
+// of outputs it names.  Outputs not named by any route receive all metrics.
+type router struct {
+	outputs []*models.RunningOutput
+	routes  []*models.Route
+
+	// targets are the indexes of the outputs named by each route.
+	targets [][]int
+
+	// routed is true for the outputs receiving only routed metrics.
+	routed []bool
+
+	selected []bool
+	dst      []*models.RunningOutput
+}
+
+// newRouter creates a router for the outputs.  The dead letter outputs cannot
+// be named by a route.
+func newRouter(
+	routes []*models.Route,
+	outputs []*models.RunningOutput,
+	deadLetters map[*models.RunningOutput]bool,
+) (*router, error) {
+	r := &router{routes: routes}
+	for _, output := range outputs {
+		if !deadLetters[output] {
+			r.outputs = append(r.outputs, output)
+		}
+	}
+	r.routed = make([]bool, len(r.outputs))
+	r.selected = make([]bool, len(r.outputs))
+
+	for _, route := range routes {
+		var targets []int
+		for _, name := range route.Outputs {
+			found := false
+			for i, output := range r.outputs {
+				if outputSelected(output, name) {
+					targets = append(targets, i)
+					r.routed[i] = true
+					found = true
+				}
+			}
+			if found {
+				continue
+			}
+
+			for output := range deadLetters {
+				if outputSelected(output, name) {
+					return nil, fmt.Errorf("route output %q receives dead letters and cannot be routed", name)
+				}
+			}
+			return nil, fmt.Errorf("route output %q not found", name)
+		}
+		r.targets = append(r.targets, targets)
+	}
+	return r, nil
+}
+
+// route returns the outputs receiving the metric.  The returned slice is only
+// valid until the next call.
+func (r *router) route(metric telegraf.Metric) []*models.RunningOutput {
+	for i := range r.selected {
+		r.selected[i] = !r.routed[i]
+	}
+
+	for i, route := range r.routes {
+		if !route.Filter.Select(metric) {
+			continue
+		}
+		for _, target := range r.targets[i] {
+			r.selected[target] = true
+		}
+	}
+
+	r.dst = r.dst[:0]
+	for i, output := range r.outputs {
+		if r.selected[i] {
+			r.dst = append(r.dst, output)
+		}
+	}
+	return r.dst
+}
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRoute(t *testing.T, outputs []string, filter models.Filter) *models.Route {
+	require.NoError(t, filter.Compile())
+	return &models.Route{Outputs: outputs, Filter: filter}
+}
+
+type testOutput struct{}
+
+func (o *testOutput) Connect() error                  { return nil }
+func (o *testOutput) Close() error                    { return nil }
+func (o *testOutput) Description() string             { return "" }
+func (o *testOutput) SampleConfig() string            { return "" }
+func (o *testOutput) Write(_ []telegraf.Metric) error { return nil }
+
+func newTestOutput(name, alias string) *models.RunningOutput {
+	return models.NewRunningOutput(name, &testOutput{},
+		&models.OutputConfig{Name: name, Alias: alias}, 1000, 10000)
+}
+
+func TestRouterFanout(t *testing.T) {
+	all := newTestOutput("file", "")
+	prod := newTestOutput("http", "prod")
+	cpu := newTestOutput("http", "cpu")
+	outputs := []*models.RunningOutput{all, prod, cpu}
+
+	r, err := newRouter([]*models.Route{
+		newTestRoute(t, []string{"prod"}, models.Filter{
+			TagPass: []models.TagFilter{{Name: "env", Filter: []string{"prod"}}},
+		}),
+		newTestRoute(t, []string{"prod", "cpu"}, models.Filter{
+			NamePass: []string{"cpu"},
+		}),
+	}, outputs, nil)
+	require.NoError(t, err)
+
+	unit := &outputUnit{outputs: outputs, router: r}
+	metric := func(name string, tags map[string]string) telegraf.Metric {
+		return testutil.MustMetric(name, tags, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	}
+	unit.fanout(metric("mem", map[string]string{"env": "dev"}))
+	unit.fanout(metric("mem", map[string]string{"env": "prod"}))
+	unit.fanout(metric("cpu", map[string]string{"env": "dev"}))
+
+	require.Equal(t, 3, all.BufferLength())
+	require.Equal(t, 2, prod.BufferLength())
+	require.Equal(t, 1, cpu.BufferLength())
+}
+
+func TestRouterInvalidOutput(t *testing.T) {
+	outputs := []*models.RunningOutput{
+		newTestOutput("http", "prod"),
+		newTestOutput("file", "dead"),
+	}
+	deadLetters := map[*models.RunningOutput]bool{outputs[1]: true}
+
+	_, err := newRouter([]*models.Route{
+		newTestRoute(t, []string{"missing"}, models.Filter{}),
+	}, outputs, deadLetters)
+	require.Error(t, err)
+
+	_, err = newRouter([]*models.Route{
+		newTestRoute(t, []string{"dead"}, models.Filter{}),
+	}, outputs, deadLetters)
+	require.Error(t, err)
+}
@@ -0,0 +1,229 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	serializer "github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+// Simulate reads metrics in line protocol from r, runs them through the
+// processors and aggregators and writes the metrics each output would
+// receive to w.  No input is started and no output is connected.
+//
+// The metrics are aggregated in a single period spanning their timestamps,
+// or in the windows of their timestamps for event time aggregators.
+func (a *Agent) Simulate(r io.Reader, w io.Writer) error {
+	processors := append(models.RunningProcessors{}, a.Config.Processors...)
+	processors = append(processors, a.Config.AggProcessors...)
+	for _, processor := range processors {
+		if err := processor.Init(); err != nil {
+			return fmt.Errorf("could not initialize processor %s: %v",
+				processor.LogName(), err)
+		}
+	}
+	for _, aggregator := range a.Config.Aggregators {
+		if err := aggregator.Init(); err != nil {
+			return fmt.Errorf("could not initialize aggregator %s: %v",
+				aggregator.LogName(), err)
+		}
+	}
+
+	metrics, err := readMetrics(r)
+	if err != nil {
+		return err
+	}
+
+	// The sample metrics are tagged like the metrics of an input
+	for _, m := range metrics {
+		for k, v := range a.Config.Tags {
+			if !m.HasTag(k) {
+				m.AddTag(k, v)
+			}
+		}
+	}
+
+	metrics, err = a.simulateProcessors(a.Config.Processors, metrics)
+	if err != nil {
+		return err
+	}
+
+	if len(a.Config.Aggregators) != 0 {
+		var aggregated []telegraf.Metric
+		metrics, aggregated = a.simulateAggregators(metrics)
+
+		aggregated, err = a.simulateProcessors(a.Config.AggProcessors, aggregated)
+		if err != nil {
+			return err
+		}
+		metrics = append(metrics, aggregated...)
+	}
+
+	return a.simulateOutputs(metrics, w)
+}
+
+// readMetrics parses the metrics in line protocol.
+func readMetrics(r io.Reader) ([]telegraf.Metric, error) {
+	var metrics []telegraf.Metric
+	parser := influx.NewStreamParser(r)
+	for {
+		m, err := parser.Next()
+		if err == influx.EOF {
+			return metrics, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading metrics: %v", err)
+		}
+		metrics = append(metrics, m)
+	}
+}
+
+// simulateProcessors passes the metrics through the processors and returns
+// the metrics they emit.
+func (a *Agent) simulateProcessors(
+	processors models.RunningProcessors,
+	metrics []telegraf.Metric,
+) ([]telegraf.Metric, error) {
+	if len(processors) == 0 {
+		return metrics, nil
+	}
+
+	dst := make(chan telegraf.Metric, 100)
+	src, units, err := a.startProcessors(dst, processors)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for _, m := range metrics {
+			src <- m
+		}
+		close(src)
+	}()
+	go a.runProcessors(units)
+
+	var result []telegraf.Metric
+	for m := range dst {
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// simulateAggregators adds the metrics to the aggregators and pushes them.
+// It returns the original metrics kept and the aggregated metrics.
+func (a *Agent) simulateAggregators(metrics []telegraf.Metric) ([]telegraf.Metric, []telegraf.Metric) {
+	if len(metrics) != 0 {
+		since, until := metrics[0].Time(), metrics[0].Time()
+		for _, m := range metrics {
+			if m.Time().Before(since) {
+				since = m.Time()
+			}
+			if m.Time().After(until) {
+				until = m.Time()
+			}
+		}
+		for _, agg := range a.Config.Aggregators {
+			agg.UpdateWindow(since, until)
+		}
+	}
+
+	var kept []telegraf.Metric
+	for _, m := range metrics {
+		var dropOriginal bool
+		for _, agg := range a.Config.Aggregators {
+			if ok := agg.Add(m); ok {
+				dropOriginal = true
+			}
+		}
+
+		if !dropOriginal {
+			kept = append(kept, m)
+		} else {
+			m.Drop()
+		}
+	}
+
+	aggC := make(chan telegraf.Metric, 100)
+	done := make(chan []telegraf.Metric)
+	go func() {
+		var aggregated []telegraf.Metric
+		for m := range aggC {
+			aggregated = append(aggregated, m)
+		}
+		done <- aggregated
+	}()
+
+	for _, agg := range a.Config.Aggregators {
+		acc := NewAccumulator(agg, aggC)
+		acc.SetPrecision(a.Precision())
+		agg.Flush(acc)
+	}
+	close(aggC)
+
+	return kept, <-done
+}
+
+// simulateOutputs writes the metrics each output would receive, the outputs
+// are sorted by name.
+func (a *Agent) simulateOutputs(metrics []telegraf.Metric, w io.Writer) error {
+	deadLetters, err := a.routeDeadLetters(a.Config.Outputs)
+	if err != nil {
+		return err
+	}
+
+	var r *router
+	if len(a.Config.Routes) > 0 {
+		r, err = newRouter(a.Config.Routes, a.Config.Outputs, deadLetters)
+		if err != nil {
+			return err
+		}
+	}
+
+	received := make(map[*models.RunningOutput][]telegraf.Metric)
+	for _, m := range metrics {
+		var outputs []*models.RunningOutput
+		if r != nil {
+			outputs = r.route(m)
+		} else {
+			outputs = a.Config.Outputs
+		}
+
+		for _, output := range outputs {
+			if deadLetters[output] {
+				continue
+			}
+			if m := output.Simulate(m.Copy()); m != nil {
+				received[output] = append(received[output], m)
+			}
+		}
+	}
+
+	outputs := make([]*models.RunningOutput, 0, len(a.Config.Outputs))
+	for _, output := range a.Config.Outputs {
+		if !deadLetters[output] {
+			outputs = append(outputs, output)
+		}
+	}
+	sort.SliceStable(outputs, func(i, j int) bool {
+		return outputs[i].LogName() < outputs[j].LogName()
+	})
+
+	s := serializer.NewSerializer()
+	s.SetFieldSortOrder(serializer.SortFields)
+	for _, output := range outputs {
+		fmt.Fprintf(w, "%s:\n", output.LogName())
+		for _, m := range received[output] {
+			octets, err := s.Serialize(m)
+			if err != nil {
+				fmt.Fprintf(w, "! %v\n", err)
+				continue
+			}
+			fmt.Fprintf(w, "> %s", octets)
+		}
+	}
+	return nil
+}
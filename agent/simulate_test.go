@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/telegraf/config"
+	_ "github.com/influxdata/telegraf/plugins/processors/all"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgent_Simulate(t *testing.T) {
+	c := config.NewConfig()
+	err := c.LoadConfigData([]byte(`
+[agent]
+  omit_hostname = true
+
+[global_tags]
+  dc = "eu"
+
+[[processors.override]]
+  [processors.override.tags]
+    seen = "yes"
+
+[[outputs.file]]
+  alias = "all"
+
+[[outputs.file]]
+  alias = "only_cpu"
+  namepass = ["cpu"]
+  fieldpass = ["value"]
+`))
+	require.NoError(t, err)
+
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	input := "cpu value=1,other=2 0\nmem value=3 1000000000\n"
+	var buf bytes.Buffer
+	err = a.Simulate(strings.NewReader(input), &buf)
+	require.NoError(t, err)
+
+	expected := `outputs.file::all:
+> cpu,dc=eu,seen=yes other=2,value=1 0
+> mem,dc=eu,seen=yes value=3 1000000000
+outputs.file::only_cpu:
+> cpu,dc=eu,seen=yes value=1 0
+`
+	require.Equal(t, expected, buf.String())
+}
+
+func TestAgent_SimulateInvalidMetrics(t *testing.T) {
+	c := config.NewConfig()
+	err := c.LoadConfigData([]byte(`
+[[outputs.file]]
+`))
+	require.NoError(t, err)
+
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = a.Simulate(strings.NewReader("cpu value=\n"), &buf)
+	require.Error(t, err)
+}
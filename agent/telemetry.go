@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/prometheus/common/expfmt"
+)
+
+// telemetryServer serves the internal statistics of the agent, as collected
+// by the internal input, in the Prometheus text format.
+//
+//   GET /metrics  returns the statistics
+type telemetryServer struct {
+	serializer *prometheus.Serializer
+
+	listener net.Listener
+	server   *http.Server
+}
+
+func newTelemetryServer(address string) (*telemetryServer, error) {
+	serializer, err := prometheus.NewSerializer(prometheus.FormatConfig{
+		MetricSortOrder: prometheus.SortMetrics,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("listening on self telemetry address %s: %w", address, err)
+	}
+
+	t := &telemetryServer{
+		serializer: serializer,
+		listener:   listener,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", t.metrics)
+	t.server = &http.Server{Handler: mux}
+	return t, nil
+}
+
+// serve handles requests until the context is done.
+func (t *telemetryServer) serve(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		t.server.Close()
+	}()
+
+	log.Printf("I! [agent] Serving self telemetry on http://%s/metrics", t.listener.Addr())
+	err := t.server.Serve(t.listener)
+	if err != nil && err != http.ErrServerClosed {
+		log.Printf("E! [agent] Serving self telemetry: %v", err)
+	}
+}
+
+func (t *telemetryServer) metrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var metrics []telegraf.Metric
+	for _, m := range selfstat.Metrics() {
+		if m != nil {
+			metrics = append(metrics, m)
+		}
+	}
+
+	body, err := t.serializer.SerializeBatch(metrics)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", string(expfmt.FmtText))
+	w.Write(body)
+}
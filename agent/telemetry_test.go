@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelemetryMetrics(t *testing.T) {
+	stat := selfstat.Register("telemetry_test", "errors", map[string]string{"output": "file"})
+	stat.Set(42)
+
+	ts, err := newTelemetryServer("localhost:0")
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	ts.metrics(w, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	require.Contains(t, w.Body.String(), `internal_telemetry_test_errors{output="file"} 42`)
+
+	w = httptest.NewRecorder()
+	ts.metrics(w, httptest.NewRequest("POST", "/metrics", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan empty)
+	go func() {
+		ts.serve(ctx)
+		close(done)
+	}()
+
+	resp, err := http.Get("http://" + ts.listener.Addr().String() + "/metrics")
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Contains(t, string(body), "internal_telemetry_test_errors")
+
+	cancel()
+	<-done
+}
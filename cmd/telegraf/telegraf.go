@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	_ "net/http/pprof" // Comment this line to disable pprof endpoint.
@@ -20,12 +22,15 @@ import (
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/goplugin"
 	"github.com/influxdata/telegraf/logger"
+	"github.com/influxdata/telegraf/models"
 	_ "github.com/influxdata/telegraf/plugins/aggregators/all"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
 	_ "github.com/influxdata/telegraf/plugins/processors/all"
+	_ "github.com/influxdata/telegraf/plugins/secretstores/all"
+	"github.com/influxdata/telegraf/plugins/secretstores/file"
 )
 
 // If you update these, update usage.go and usage_windows.go
@@ -40,6 +45,8 @@ var fTestWait = flag.Int("test-wait", 0, "wait up to this many seconds for servi
 var fConfig = flag.String("config", "", "configuration file to load")
 var fConfigDirectory = flag.String("config-directory", "",
 	"directory containing additional *.conf files")
+var fConfigSignatureKey = flag.String("config-signature-key", "",
+	"public key verifying the signatures of the config files loaded from a URL")
 var fVersion = flag.Bool("version", false, "display the version and exit")
 var fSampleConfig = flag.Bool("sample-config", false,
 	"print out full sample configuration")
@@ -83,79 +90,298 @@ func reloadLoop(
 	aggregatorFilters []string,
 	processorFilters []string,
 ) {
+	c, err := loadConfig(inputFilters, outputFilters)
+	if err != nil {
+		log.Fatalf("E! [telegraf] Error running agent: %v", err)
+	}
+
+	// outputs of the previous run, their buffered metrics are kept by the
+	// unchanged outputs of the reloaded config.
+	var previous []*models.RunningOutput
+
 	reload := make(chan bool, 1)
 	reload <- true
 	for <-reload {
 		reload <- false
 
+		ag, err := agent.NewAgent(c)
+		if err != nil {
+			log.Fatalf("E! [telegraf] Error running agent: %v", err)
+		}
+
 		ctx, cancel := context.WithCancel(context.Background())
 
+		// The new config is loaded before stopping the agent, so an invalid
+		// config does not interrupt the running agent.
+		next := make(chan *config.Config, 1)
 		signals := make(chan os.Signal, 1)
 		signal.Notify(signals, os.Interrupt, syscall.SIGHUP,
 			syscall.SIGTERM, syscall.SIGINT)
-		go func() {
-			select {
-			case sig := <-signals:
-				if sig == syscall.SIGHUP {
-					log.Printf("I! Reloading Telegraf config")
-					<-reload
-					reload <- true
+		go func(c *config.Config) {
+			defer signal.Stop(signals)
+
+			// The secrets referenced by the config are retrieved again
+			// periodically, and the config reloaded when one changed.
+			var refresh <-chan time.Time
+			if c.Agent.SecretRefreshInterval.Duration > 0 {
+				ticker := time.NewTicker(c.Agent.SecretRefreshInterval.Duration)
+				defer ticker.Stop()
+				refresh = ticker.C
+			}
+
+			// The config files loaded from a URL are fetched again
+			// periodically, and the config reloaded when one changed.
+			var poll <-chan time.Time
+			if c.Agent.ConfigPollInterval.Duration > 0 {
+				ticker := time.NewTicker(c.Agent.ConfigPollInterval.Duration)
+				defer ticker.Stop()
+				poll = ticker.C
+			}
+
+			// reloadConfig loads the config again and applies it to the
+			// running agent, it returns true if the agent must be restarted
+			// with the new config instead.
+			reloadConfig := func() bool {
+				nc, err := loadConfig(inputFilters, outputFilters)
+				if err != nil {
+					log.Printf("E! Error reloading config, keeping the running config: %v", err)
+					return false
+				}
+
+				err = ag.Reload(nc)
+				if err == nil {
+					c = nc
+					return false
+				}
+				if err != agent.ErrRestartRequired {
+					log.Printf("E! Error reloading config, keeping the running config: %v", err)
+					return false
 				}
-				cancel()
-			case <-stop:
-				cancel()
+
+				log.Printf("I! Restarting Telegraf to apply the config")
+				next <- nc
+				<-reload
+				reload <- true
+				return true
 			}
-		}()
 
-		err := runAgent(ctx, inputFilters, outputFilters)
+			for {
+				select {
+				case sig := <-signals:
+					if sig == syscall.SIGHUP {
+						log.Printf("I! Reloading Telegraf config")
+						if !reloadConfig() {
+							continue
+						}
+					}
+					cancel()
+					return
+				case <-refresh:
+					changed, err := c.SecretsChanged()
+					if err != nil {
+						log.Printf("E! Error refreshing secrets, keeping the running config: %v", err)
+						continue
+					}
+					if !changed {
+						continue
+					}
+					log.Printf("I! Secrets changed, reloading Telegraf config")
+					if !reloadConfig() {
+						continue
+					}
+					cancel()
+					return
+				case <-poll:
+					changed, err := c.RemoteConfigChanged()
+					if err != nil {
+						log.Printf("E! Error polling config, keeping the running config: %v", err)
+						continue
+					}
+					if !changed {
+						continue
+					}
+					log.Printf("I! Remote config changed, reloading Telegraf config")
+					if !reloadConfig() {
+						continue
+					}
+					cancel()
+					return
+				case <-stop:
+					cancel()
+					return
+				}
+			}
+		}(c)
+
+		err = runAgent(ctx, ag, previous)
+
+		var nc *config.Config
+		select {
+		case nc = <-next:
+		default:
+		}
+
 		if err != nil && err != context.Canceled {
-			log.Fatalf("E! [telegraf] Error running agent: %v", err)
+			timeout, ok := err.(*agent.ShutdownTimeoutError)
+			if !ok || nc == nil {
+				log.Fatalf("E! [telegraf] Error running agent: %v", err)
+			}
+
+			// A reload continues, but the outputs still flushing must
+			// stop before the reloaded outputs take over their buffers.
+			log.Printf("E! [telegraf] Error reloading agent, waiting for the outputs to stop: %v", err)
+			timeout.Wait()
+		}
+
+		previous = ag.Config.Outputs
+		if nc != nil {
+			c = nc
 		}
 	}
 }
 
-func runAgent(ctx context.Context,
+// newConfig returns an empty configuration with the settings of the command
+// line.
+func newConfig(
 	inputFilters []string,
 	outputFilters []string,
-) error {
-	log.Printf("I! Starting Telegraf %s", version)
-
-	// If no other options are specified, load the config file and run.
+) (*config.Config, error) {
 	c := config.NewConfig()
 	c.OutputFilters = outputFilters
 	c.InputFilters = inputFilters
-	err := c.LoadConfig(*fConfig)
+	if *fConfigSignatureKey != "" {
+		key, err := config.LoadSignatureKey(*fConfigSignatureKey)
+		if err != nil {
+			return nil, err
+		}
+		c.SignatureKey = key
+	}
+	return c, nil
+}
+
+// loadConfig loads and validates the configuration files.
+func loadConfig(
+	inputFilters []string,
+	outputFilters []string,
+) (*config.Config, error) {
+	c, err := newConfig(inputFilters, outputFilters)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	err = c.LoadConfig(*fConfig)
+	if err != nil {
+		return nil, err
 	}
 
 	if *fConfigDirectory != "" {
 		err = c.LoadDirectory(*fConfigDirectory)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
+
+	if errs := checkConfig(c); len(errs) != 0 {
+		return nil, errs[0]
+	}
+	return c, nil
+}
+
+// checkConfig returns the errors of the agent settings of the loaded
+// configuration.
+func checkConfig(c *config.Config) []error {
+	var errs []error
 	if !*fTest && len(c.Outputs) == 0 {
-		return errors.New("Error: no outputs found, did you provide a valid config file?")
+		errs = append(errs, errors.New("Error: no outputs found, did you provide a valid config file?"))
 	}
 	if *fPlugins == "" && len(c.Inputs) == 0 {
-		return errors.New("Error: no inputs found, did you provide a valid config file?")
+		errs = append(errs, errors.New("Error: no inputs found, did you provide a valid config file?"))
 	}
 
 	if int64(c.Agent.Interval.Duration) <= 0 {
-		return fmt.Errorf("Agent interval must be positive, found %s",
-			c.Agent.Interval.Duration)
+		errs = append(errs, fmt.Errorf("Agent interval must be positive, found %s",
+			c.Agent.Interval.Duration))
 	}
 
 	if int64(c.Agent.FlushInterval.Duration) <= 0 {
-		return fmt.Errorf("Agent flush_interval must be positive; found %s",
-			c.Agent.Interval.Duration)
+		errs = append(errs, fmt.Errorf("Agent flush_interval must be positive; found %s",
+			c.Agent.Interval.Duration))
+	}
+	return errs
+}
+
+// validateConfig loads the configuration and initializes the plugins without
+// starting them, all errors found are returned.
+func validateConfig(
+	inputFilters []string,
+	outputFilters []string,
+) []error {
+	c, err := newConfig(inputFilters, outputFilters)
+	if err != nil {
+		return []error{err}
+	}
+	c.CollectErrors = true
+
+	if err := c.LoadConfig(*fConfig); err != nil {
+		return append(c.Errors, err)
+	}
+	if *fConfigDirectory != "" {
+		if err := c.LoadDirectory(*fConfigDirectory); err != nil {
+			return append(c.Errors, err)
+		}
+	}
+	errs := append(c.Errors, checkConfig(c)...)
+
+	ag, err := agent.NewAgent(c)
+	if err != nil {
+		return append(errs, err)
+	}
+	return append(errs, ag.Validate()...)
+}
+
+// simulate runs the metrics in line protocol read from the file at path, or
+// stdin if empty or "-", through the processors and aggregators of the
+// configuration and prints the metrics each output would receive.
+func simulate(
+	inputFilters []string,
+	outputFilters []string,
+	path string,
+) error {
+	c, err := newConfig(inputFilters, outputFilters)
+	if err != nil {
+		return err
+	}
+	if err := c.LoadConfig(*fConfig); err != nil {
+		return err
+	}
+	if *fConfigDirectory != "" {
+		if err := c.LoadDirectory(*fConfigDirectory); err != nil {
+			return err
+		}
+	}
+
+	r := os.Stdin
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
 	}
 
 	ag, err := agent.NewAgent(c)
 	if err != nil {
 		return err
 	}
+	return ag.Simulate(r, os.Stdout)
+}
+
+func runAgent(ctx context.Context,
+	ag *agent.Agent,
+	previous []*models.RunningOutput,
+) error {
+	log.Printf("I! Starting Telegraf %s", version)
+	c := ag.Config
 
 	// Setup logging as configured.
 	logConfig := logger.LogConfig{
@@ -166,6 +392,7 @@ func runAgent(ctx context.Context,
 		RotationInterval:    ag.Config.Agent.LogfileRotationInterval,
 		RotationMaxSize:     ag.Config.Agent.LogfileRotationMaxSize,
 		RotationMaxArchives: ag.Config.Agent.LogfileRotationMaxArchives,
+		LogFormat:           ag.Config.Agent.LogFormat,
 	}
 
 	logger.SetupLogging(logConfig)
@@ -204,6 +431,8 @@ func runAgent(ctx context.Context,
 		}
 	}
 
+	ag.KeepBuffers(previous)
+
 	return ag.Run(ctx)
 }
 
@@ -212,6 +441,42 @@ func usageExit(rc int) {
 	os.Exit(rc)
 }
 
+// encryptSecrets encrypts the JSON object of secrets read from the file at
+// path, or stdin if empty or "-", with the key of keyFile for the file secret
+// store and writes the encrypted file to stdout.
+func encryptSecrets(keyFile string, path string) error {
+	key, err := file.ReadKey(keyFile)
+	if err != nil {
+		return err
+	}
+
+	r := os.Stdin
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	plaintext, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var secrets map[string]string
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return fmt.Errorf("secrets must be a JSON object of strings: %v", err)
+	}
+
+	ciphertext, err := file.Encrypt(key, plaintext)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(ciphertext)
+	return err
+}
+
 func formatFullVersion() string {
 	var parts = []string{"Telegraf"}
 
@@ -291,7 +556,40 @@ func main() {
 		case "version":
 			fmt.Println(formatFullVersion())
 			return
+		case "simulate":
+			var path string
+			if len(args) > 1 {
+				path = args[1]
+			}
+			if err := simulate(inputFilters, outputFilters, path); err != nil {
+				log.Fatalf("E! %v", err)
+			}
+			return
+		case "secrets":
+			if len(args) < 3 || args[1] != "encrypt" {
+				usageExit(1)
+			}
+			var path string
+			if len(args) > 3 {
+				path = args[3]
+			}
+			if err := encryptSecrets(args[2], path); err != nil {
+				log.Fatalf("E! %v", err)
+			}
+			return
 		case "config":
+			if len(args) > 1 && args[1] == "validate" {
+				errs := validateConfig(inputFilters, outputFilters)
+				for _, err := range errs {
+					fmt.Fprintf(os.Stderr, "E! %v\n", err)
+				}
+				if len(errs) != 0 {
+					fmt.Fprintf(os.Stderr, "Configuration is invalid, found %d errors\n", len(errs))
+					os.Exit(1)
+				}
+				fmt.Println("Configuration is valid")
+				return
+			}
 			config.PrintSampleConfig(
 				sectionFilters,
 				inputFilters,
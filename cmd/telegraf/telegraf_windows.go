@@ -35,14 +35,19 @@ type program struct {
 	outputFilters     []string
 	aggregatorFilters []string
 	processorFilters  []string
+
+	// done is closed once the agent has stopped.
+	done chan struct{}
 }
 
 func (p *program) Start(s service.Service) error {
+	stop = make(chan struct{})
+	p.done = make(chan struct{})
 	go p.run()
 	return nil
 }
 func (p *program) run() {
-	stop = make(chan struct{})
+	defer close(p.done)
 	reloadLoop(
 		p.inputFilters,
 		p.outputFilters,
@@ -52,6 +57,9 @@ func (p *program) run() {
 }
 func (p *program) Stop(s service.Service) error {
 	close(stop)
+	// Wait for the final flush of the outputs, the service is considered
+	// stopped once Stop returns.
+	<-p.done
 	return nil
 }
 
@@ -2,12 +2,12 @@ package config
 
 import (
 	"bytes"
+	"crypto"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"math"
-	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -19,12 +19,14 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/models"
 	"github.com/influxdata/telegraf/plugins/aggregators"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/binary"
 	"github.com/influxdata/telegraf/plugins/processors"
 	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/toml"
@@ -67,6 +69,43 @@ type Config struct {
 	// Processors have a slice wrapper type because they need to be sorted
 	Processors    models.RunningProcessors
 	AggProcessors models.RunningProcessors
+
+	// Routes select the metrics sent to the outputs they name
+	Routes []*models.Route
+
+	// AgentFingerprint identifies the agent settings and global tags, the
+	// plugins of a running agent can only be reloaded while it is unchanged.
+	AgentFingerprint string
+
+	// CollectErrors continues loading the config after an invalid plugin or
+	// route, the errors are collected in Errors with their file and line.
+	CollectErrors bool
+	Errors        []error
+
+	// loaded holds the absolute paths of the loaded config files, so that
+	// each file is included only once.
+	loaded map[string]bool
+
+	// file is the config file being loaded.
+	file string
+
+	// outOfRange holds the options of the config data being loaded with a
+	// value out of their declared range, reported together.
+	outOfRange []error
+
+	// secretStores holds the secret stores by id.
+	secretStores map[string]telegraf.SecretStore
+
+	// secrets holds the values of the secrets referenced by the config.
+	secrets map[string]string
+
+	// SignatureKey is the public key verifying the detached signatures of
+	// the config files loaded from a URL.  When nil the signatures are not
+	// verified.
+	SignatureKey crypto.PublicKey
+
+	// remote holds the state of the config files loaded from a URL by URL.
+	remote map[string]*remoteConfig
 }
 
 func NewConfig() *Config {
@@ -78,6 +117,7 @@ func NewConfig() *Config {
 			FlushInterval:              internal.Duration{Duration: 10 * time.Second},
 			LogTarget:                  "file",
 			LogfileRotationMaxArchives: 5,
+			SecretRefreshInterval:      internal.Duration{Duration: 5 * time.Minute},
 		},
 
 		Tags:          make(map[string]string),
@@ -87,13 +127,17 @@ func NewConfig() *Config {
 		AggProcessors: make([]*models.RunningProcessor, 0),
 		InputFilters:  make([]string, 0),
 		OutputFilters: make([]string, 0),
+		loaded:        make(map[string]bool),
+		secretStores:  make(map[string]telegraf.SecretStore),
+		secrets:       make(map[string]string),
+		remote:        make(map[string]*remoteConfig),
 	}
 	return c
 }
 
 type AgentConfig struct {
 	// Interval at which to gather information
-	Interval internal.Duration
+	Interval internal.Duration `range:"1ms,"`
 
 	// RoundInterval rounds collection interval to 'interval'.
 	//     ie, if Interval=10s then always collect on :00, :10, :20, etc.
@@ -105,39 +149,118 @@ type AgentConfig struct {
 	//       when interval = "250ms", precision will be "1ms"
 	// Precision will NOT be used for service inputs. It is up to each individual
 	// service input to set the timestamp at the appropriate precision.
-	Precision internal.Duration
+	Precision internal.Duration `range:"0s,"`
 
 	// CollectionJitter is used to jitter the collection by a random amount.
 	// Each plugin will sleep for a random time within jitter before collecting.
 	// This can be used to avoid many plugins querying things like sysfs at the
 	// same time, which can have a measurable effect on the system.
-	CollectionJitter internal.Duration
+	CollectionJitter internal.Duration `range:"0s,"`
+
+	// ControlSocket is the path of the unix socket, or on Windows the name of
+	// the pipe, serving the control API.  The API allows changing the
+	// interval and collection jitter of running inputs.  When empty the API
+	// is disabled.
+	ControlSocket string `toml:"control_socket"`
+
+	// SelfTelemetryListen is the address serving the internal statistics of
+	// the agent in the Prometheus format on the /metrics path.  When empty
+	// the endpoint is disabled.
+	SelfTelemetryListen string `toml:"self_telemetry_listen"`
+
+	// ShutdownTimeout is the maximum duration of a shutdown.  During this
+	// time the final write of the outputs is retried until it succeeds.
+	// When set to 0 the shutdown is not limited and the final write is
+	// attempted once.
+	ShutdownTimeout internal.Duration `toml:"shutdown_timeout" range:"0s,"`
+
+	// SecretRefreshInterval is the interval at which the secrets referenced
+	// by the config are retrieved again, the config is reloaded when one of
+	// them changed.  When set to 0 the secrets are only retrieved when the
+	// config is loaded.
+	SecretRefreshInterval internal.Duration `toml:"secret_refresh_interval" range:"0s,"`
+
+	// ConfigPollInterval is the interval at which the config files loaded
+	// from a URL are fetched again, the config is reloaded when one of them
+	// changed.  When set to 0 they are only fetched when the config is
+	// loaded.
+	ConfigPollInterval internal.Duration `toml:"config_poll_interval" range:"0s,"`
 
 	// FlushInterval is the Interval at which to flush data
-	FlushInterval internal.Duration
+	FlushInterval internal.Duration `range:"1ms,"`
 
 	// FlushJitter Jitters the flush interval by a random amount.
 	// This is primarily to avoid large write spikes for users running a large
 	// number of telegraf instances.
 	// ie, a jitter of 5s and interval 10s means flushes will happen every 10-15s
-	FlushJitter internal.Duration
+	FlushJitter internal.Duration `range:"0s,"`
+
+	// FlushBufferWatermark is the percentage of MetricBufferLimit at which an
+	// output is flushed without waiting for FlushInterval.  When set to 0
+	// outputs are only flushed early when a full batch is ready.
+	FlushBufferWatermark int `toml:"flush_buffer_watermark" range:"0,100"`
+
+	// CollectionBackpressure skips every other collection of the inputs while
+	// an output remains above FlushBufferWatermark after consecutive flushes.
+	CollectionBackpressure bool `toml:"collection_backpressure"`
 
 	// MetricBatchSize is the maximum number of metrics that is wrote to an
 	// output plugin in one call.
-	MetricBatchSize int
+	MetricBatchSize int `range:"1,"`
 
 	// MetricBufferLimit is the max number of metrics that each output plugin
 	// will cache. The buffer is cleared when a successful write occurs. When
 	// full, the oldest metrics will be overwritten. This number should be a
 	// multiple of MetricBatchSize. Due to current implementation, this could
 	// not be less than 2 times MetricBatchSize.
-	MetricBufferLimit int
+	MetricBufferLimit int `range:"1,"`
+
+	// MetricBufferDirectory is the directory the output buffers are stored
+	// in.  When set, metrics are buffered on disk instead of in memory, so
+	// they are kept when Telegraf restarts.
+	MetricBufferDirectory string `toml:"metric_buffer_directory"`
+
+	// MetricBufferMaxDiskSize is the maximum size of the disk buffer of each
+	// output.  When exceeded the oldest metrics are dropped.  When set to 0
+	// the size is not limited.
+	MetricBufferMaxDiskSize internal.Size `toml:"metric_buffer_max_disk_size" range:"0B,"`
 
 	// FlushBufferWhenFull tells Telegraf to flush the metric buffer whenever
 	// it fills up, regardless of FlushInterval. Setting this option to true
 	// does _not_ deactivate FlushInterval.
 	FlushBufferWhenFull bool // deprecated in 0.13; has no effect
 
+	// MetricMaxFields is the maximum number of fields allowed in a single
+	// metric.  When set to 0 the number of fields is not limited.
+	MetricMaxFields int `toml:"metric_max_fields" range:"0,"`
+
+	// MetricMaxStringLength is the maximum length of tag values and string
+	// field values.  When set to 0 the length is not limited.
+	MetricMaxStringLength int `toml:"metric_max_string_length" range:"0,"`
+
+	// OversizedMetricAction controls the handling of metrics exceeding the
+	// size limits and can be one of "truncate", "split", "drop" or
+	// "dead_letter".
+	OversizedMetricAction string `toml:"oversized_metric_action"`
+
+	// DeadLetterOutput is the name, or alias, of the output receiving
+	// diverted metrics and the metrics rejected by the other outputs.  This
+	// output does not receive any other metrics.
+	DeadLetterOutput string `toml:"dead_letter_output"`
+
+	// MetricMaxPast is the maximum age of a metric timestamp relative to the
+	// collection time.  When set to 0 the age is not limited.
+	MetricMaxPast internal.Duration `toml:"metric_max_past" range:"0s,"`
+
+	// MetricMaxFuture is the maximum amount a metric timestamp may be ahead
+	// of the collection time.  When set to 0 it is not limited.
+	MetricMaxFuture internal.Duration `toml:"metric_max_future" range:"0s,"`
+
+	// InvalidTimestampAction controls the handling of metrics with timestamps
+	// outside of the allowed range and can be one of "drop", "clamp" or
+	// "rewrite".
+	InvalidTimestampAction string `toml:"invalid_timestamp_action"`
+
 	// TODO(cam): Remove UTC and parameter, they are no longer
 	// valid for the agent config. Leaving them here for now for backwards-
 	// compatibility
@@ -170,6 +293,9 @@ type AgentConfig struct {
 	// If set to -1, no archives are removed.
 	LogfileRotationMaxArchives int `toml:"logfile_rotation_max_archives"`
 
+	// Format of the log messages, "text" or "json".
+	LogFormat string `toml:"logformat"`
+
 	Hostname     string
 	OmitHostname bool
 }
@@ -268,12 +394,77 @@ var agentConfig = `
   ## cost of higher maximum memory usage.
   metric_buffer_limit = 10000
 
+  ## Directory the output buffers are stored in.  When set, metrics are
+  ## buffered on disk instead of in memory, so they are kept when Telegraf
+  ## restarts or an output is down for a long time.  Each output is buffered
+  ## in a sub-directory named after the plugin and its alias.
+  # metric_buffer_directory = "/var/lib/telegraf/buffer"
+  ## Maximum size of the disk buffer of each output, when exceeded the oldest
+  ## metrics are dropped.  Set to 0 for no limit.
+  # metric_buffer_max_disk_size = "1GB"
+
+  ## Limits on the size of a single metric, protecting outputs from metrics
+  ## that would cause the whole batch to fail.  Set to 0 for no limit.
+  ## metric_max_string_length applies to tag values and string fields.
+  # metric_max_fields = 0
+  # metric_max_string_length = 0
+
+  ## Handling of metrics exceeding the size limits, one of:
+  ##   "truncate": remove excess fields and shorten long values
+  ##   "split": split the fields over multiple metrics, then truncate
+  ##   "drop": discard the metric
+  ##   "dead_letter": write the metric only to the dead_letter_output
+  # oversized_metric_action = "truncate"
+
+  ## Name or alias of an output receiving diverted metrics and the metrics
+  ## rejected by the other outputs, this output does not receive any other
+  ## metrics.  Outputs can override it with their own dead_letter_output.
+  # dead_letter_output = ""
+
+  ## Limits on the metric timestamp relative to the collection time,
+  ## protecting outputs from devices with a bad clock.  Set to "0s" for no
+  ## limit.  These options can be overridden in each input.
+  # metric_max_past = "0s"
+  # metric_max_future = "0s"
+
+  ## Handling of metrics with a timestamp outside of the limits, one of:
+  ##   "drop": discard the metric
+  ##   "clamp": set the timestamp to the nearest allowed time
+  ##   "rewrite": set the timestamp to the collection time
+  # invalid_timestamp_action = "drop"
+
   ## Collection jitter is used to jitter the collection by a random amount.
   ## Each plugin will sleep for a random time within jitter before collecting.
   ## This can be used to avoid many plugins querying things like sysfs at the
   ## same time, which can have a measurable effect on the system.
   collection_jitter = "0s"
 
+  ## Path of the unix socket, or on Windows the name of the pipe, serving the
+  ## control API.  The API allows changing the interval and collection_jitter
+  ## of a running input without restarting Telegraf.
+  # control_socket = "/var/run/telegraf/control.sock"
+
+  ## Address serving the internal statistics of the agent, such as the gather
+  ## durations, buffer sizes and write errors, in the Prometheus format on the
+  ## /metrics path.
+  # self_telemetry_listen = ":9273"
+
+  ## Maximum time to stop Telegraf, during which the metrics collected so far
+  ## are processed, the aggregators are pushed and the final write of each
+  ## output is retried until it succeeds.  By default the shutdown is not
+  ## limited and the final write is attempted once.
+  # shutdown_timeout = "30s"
+
+  ## Interval at which the secrets referenced by the config are retrieved
+  ## again from their secret store.  The config is reloaded when one of them
+  ## changed.  Set to 0 to only retrieve them when the config is loaded.
+  # secret_refresh_interval = "5m"
+
+  ## Interval at which the config files loaded from a URL are fetched again.
+  ## The config is reloaded when one of them changed.  By default they are
+  ## only fetched when the config is loaded.
+  # config_poll_interval = "1m"
+
   ## Default flushing interval for all outputs. Maximum flush_interval will be
   ## flush_interval + flush_jitter
   flush_interval = "10s"
@@ -281,6 +472,14 @@ var agentConfig = `
   ## large write spikes for users running a large number of telegraf instances.
   ## ie, a jitter of 5s and interval 10s means flushes will happen every 10-15s
   flush_jitter = "0s"
+  ## Flush an output as soon as its buffer holds this percentage of
+  ## metric_buffer_limit, without waiting for flush_interval.  Set to 0 to
+  ## disable.
+  # flush_buffer_watermark = 0
+  ## Slow down the collection of inputs while an output stays above the
+  ## flush_buffer_watermark after consecutive flushes, by skipping every
+  ## other collection.  Service inputs are not slowed down.
+  # collection_backpressure = false
 
   ## By default or when set to "0s", precision will be set to the same
   ## timestamp order as the collection interval, with the maximum being 1s.
@@ -318,6 +517,12 @@ var agentConfig = `
   ## If set to -1, no archives are removed.
   # logfile_rotation_max_archives = 5
 
+  ## Log format controls the format of the log messages and can be one of
+  ## "text" or "json".  With "json", each message is written as a JSON object
+  ## with the plugin type, name, alias and instance ID of the plugin logging
+  ## it.  Not supported with the "eventlog" logtarget.
+  # logformat = "text"
+
   ## Override default hostname, if empty use os.Hostname()
   hostname = ""
   ## If set to true, do no set the "host" tag in the telegraf agent.
@@ -586,6 +791,41 @@ func printConfig(name string, p telegraf.PluginDescriber, op string, commented b
 	}
 }
 
+// tableFingerprint returns a string identifying the settings of the table,
+// independent of formatting, comments and the order of the settings.
+func tableFingerprint(tbl *ast.Table) string {
+	var b strings.Builder
+	writeTableFingerprint(&b, tbl)
+	return b.String()
+}
+
+func writeTableFingerprint(b *strings.Builder, tbl *ast.Table) {
+	keys := make([]string, 0, len(tbl.Fields))
+	for key := range tbl.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch v := tbl.Fields[key].(type) {
+		case *ast.KeyValue:
+			fmt.Fprintf(b, "%q=%s;", key, v.Value.Source())
+		case *ast.Table:
+			fmt.Fprintf(b, "%q={", key)
+			writeTableFingerprint(b, v)
+			b.WriteString("};")
+		case []*ast.Table:
+			fmt.Fprintf(b, "%q=[", key)
+			for _, t := range v {
+				b.WriteString("{")
+				writeTableFingerprint(b, t)
+				b.WriteString("}")
+			}
+			b.WriteString("];")
+		}
+	}
+}
+
 func sliceContains(name string, list []string) bool {
 	for _, b := range list {
 		if b == name {
@@ -634,6 +874,10 @@ func (c *Config) LoadDirectory(path string) error {
 		if len(name) < 6 || name[len(name)-5:] != ".conf" {
 			return nil
 		}
+		if abs, err := filepath.Abs(thispath); err == nil && c.loaded[abs] {
+			log.Printf("D! Config file %s already loaded, skipping", thispath)
+			return nil
+		}
 		err := c.LoadConfig(thispath)
 		if err != nil {
 			return err
@@ -679,12 +923,25 @@ func (c *Config) LoadConfig(path string) error {
 			return err
 		}
 	}
-	data, err := loadConfig(path)
+	data, err := c.loadConfig(path)
 	if err != nil {
 		return fmt.Errorf("Error loading config file %s: %w", path, err)
 	}
 
-	if err = c.LoadConfigData(data); err != nil {
+	prev := c.file
+	c.file = path
+	defer func() { c.file = prev }()
+
+	// Included files are relative to the directory of local config files
+	var dir string
+	if !isURL(path) {
+		dir = filepath.Dir(path)
+		if abs, err := filepath.Abs(path); err == nil {
+			c.loaded[abs] = true
+		}
+	}
+
+	if err = c.loadConfigData(data, dir); err != nil {
 		return fmt.Errorf("Error loading config file %s: %w", path, err)
 	}
 	return nil
@@ -692,11 +949,29 @@ func (c *Config) LoadConfig(path string) error {
 
 // LoadConfigData loads TOML-formatted config data
 func (c *Config) LoadConfigData(data []byte) error {
+	return c.loadConfigData(data, "")
+}
+
+// loadConfigData loads TOML-formatted config data, included files are
+// relative to dir.
+func (c *Config) loadConfigData(data []byte, dir string) error {
 	tbl, err := parseConfig(data)
 	if err != nil {
 		return fmt.Errorf("Error parsing data: %s", err)
 	}
 
+	// Add the secret stores and resolve the secrets first, so the secrets
+	// can be referenced anywhere else:
+	if val, ok := tbl.Fields["secretstores"]; ok {
+		if err = c.addSecretStores(val); err != nil {
+			return err
+		}
+		delete(tbl.Fields, "secretstores")
+	}
+	if err = c.resolveSecrets(tbl); err != nil {
+		return err
+	}
+
 	// Parse tags tables first:
 	for _, tableName := range []string{"tags", "global_tags"} {
 		if val, ok := tbl.Fields[tableName]; ok {
@@ -704,6 +979,7 @@ func (c *Config) LoadConfigData(data []byte) error {
 			if !ok {
 				return fmt.Errorf("invalid configuration, bad table name %q", tableName)
 			}
+			c.AgentFingerprint += fmt.Sprintf("%s={%s};", tableName, tableFingerprint(subTable))
 			if err = toml.UnmarshalTable(subTable, c.Tags); err != nil {
 				return fmt.Errorf("error parsing table name %q: %w", tableName, err)
 			}
@@ -716,9 +992,13 @@ func (c *Config) LoadConfigData(data []byte) error {
 		if !ok {
 			return fmt.Errorf("invalid configuration, error parsing agent table")
 		}
+		c.AgentFingerprint += fmt.Sprintf("agent={%s};", tableFingerprint(subTable))
 		if err = toml.UnmarshalTable(subTable, c.Agent); err != nil {
 			return fmt.Errorf("error parsing agent table: %w", err)
 		}
+		if err = c.checkRanges("agent", subTable, c.Agent); err != nil {
+			return err
+		}
 	}
 
 	if !c.Agent.OmitHostname {
@@ -734,8 +1014,68 @@ func (c *Config) LoadConfigData(data []byte) error {
 		c.Tags["host"] = c.Agent.Hostname
 	}
 
-	// Parse all the rest of the plugins:
+	// Parse routes table:
+	if val, ok := tbl.Fields["routes"]; ok {
+		routeTables, ok := val.([]*ast.Table)
+		if !ok {
+			return fmt.Errorf("invalid configuration, routes must be an array of tables")
+		}
+		for _, t := range routeTables {
+			if err = c.addRoute(t); err != nil {
+				if err = c.collect(t, fmt.Errorf("Error parsing routes, %s", err)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Parse the conditional sections:
+	if val, ok := tbl.Fields["if_env"]; ok {
+		conditionTables, ok := val.([]*ast.Table)
+		if !ok {
+			return fmt.Errorf("invalid configuration, if_env must be an array of tables")
+		}
+		for _, t := range conditionTables {
+			if err = c.addConditional(t); err != nil {
+				if err = c.collect(t, fmt.Errorf("Error parsing if_env, %s", err)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if err = c.addPlugins(tbl); err != nil {
+		c.outOfRange = nil
+		return err
+	}
+
+	if err = c.reportRanges(); err != nil {
+		return err
+	}
+
+	if len(c.Processors) > 1 {
+		sort.Sort(c.Processors)
+	}
+
+	// Load the included files last:
+	if val, ok := tbl.Fields["include"]; ok {
+		if err = c.include(val, dir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addPlugins adds the plugins of the table.
+func (c *Config) addPlugins(tbl *ast.Table) error {
+	var err error
 	for name, val := range tbl.Fields {
+		switch name {
+		case "routes", "if_env", "include":
+			continue
+		}
+
 		subTable, ok := val.(*ast.Table)
 		if !ok {
 			return fmt.Errorf("invalid configuration, error parsing field %q as table", name)
@@ -749,12 +1089,16 @@ func (c *Config) LoadConfigData(data []byte) error {
 				// legacy [outputs.influxdb] support
 				case *ast.Table:
 					if err = c.addOutput(pluginName, pluginSubTable); err != nil {
-						return fmt.Errorf("Error parsing %s, %s", pluginName, err)
+						if err = c.collect(pluginSubTable, fmt.Errorf("Error parsing %s, %s", pluginName, err)); err != nil {
+							return err
+						}
 					}
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
 						if err = c.addOutput(pluginName, t); err != nil {
-							return fmt.Errorf("Error parsing %s array, %s", pluginName, err)
+							if err = c.collect(t, fmt.Errorf("Error parsing %s array, %s", pluginName, err)); err != nil {
+								return err
+							}
 						}
 					}
 				default:
@@ -768,12 +1112,16 @@ func (c *Config) LoadConfigData(data []byte) error {
 				// legacy [inputs.cpu] support
 				case *ast.Table:
 					if err = c.addInput(pluginName, pluginSubTable); err != nil {
-						return fmt.Errorf("Error parsing %s, %s", pluginName, err)
+						if err = c.collect(pluginSubTable, fmt.Errorf("Error parsing %s, %s", pluginName, err)); err != nil {
+							return err
+						}
 					}
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
 						if err = c.addInput(pluginName, t); err != nil {
-							return fmt.Errorf("Error parsing %s, %s", pluginName, err)
+							if err = c.collect(t, fmt.Errorf("Error parsing %s, %s", pluginName, err)); err != nil {
+								return err
+							}
 						}
 					}
 				default:
@@ -787,7 +1135,9 @@ func (c *Config) LoadConfigData(data []byte) error {
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
 						if err = c.addProcessor(pluginName, t); err != nil {
-							return fmt.Errorf("Error parsing %s, %s", pluginName, err)
+							if err = c.collect(t, fmt.Errorf("Error parsing %s, %s", pluginName, err)); err != nil {
+								return err
+							}
 						}
 					}
 				default:
@@ -801,7 +1151,9 @@ func (c *Config) LoadConfigData(data []byte) error {
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
 						if err = c.addAggregator(pluginName, t); err != nil {
-							return fmt.Errorf("Error parsing %s, %s", pluginName, err)
+							if err = c.collect(t, fmt.Errorf("Error parsing %s, %s", pluginName, err)); err != nil {
+								return err
+							}
 						}
 					}
 				default:
@@ -813,15 +1165,167 @@ func (c *Config) LoadConfigData(data []byte) error {
 		// identifiers are present
 		default:
 			if err = c.addInput(name, subTable); err != nil {
-				return fmt.Errorf("Error parsing %s, %s", name, err)
+				if err = c.collect(subTable, fmt.Errorf("Error parsing %s, %s", name, err)); err != nil {
+					return err
+				}
 			}
 		}
 	}
+	return nil
+}
 
-	if len(c.Processors) > 1 {
-		sort.Sort(c.Processors)
+// collect records the error of the table when collecting errors, otherwise
+// it returns the error.
+func (c *Config) collect(tbl *ast.Table, err error) error {
+	if !c.CollectErrors {
+		return err
+	}
+
+	c.Errors = append(c.Errors, c.locate(tbl, err))
+	return nil
+}
+
+// locate prefixes the error with the file and line of the table.
+func (c *Config) locate(tbl *ast.Table, err error) error {
+	return c.locateLine(tbl.Line, err)
+}
+
+// locateLine prefixes the error with the file and line.
+func (c *Config) locateLine(line int, err error) error {
+	if c.file != "" {
+		return fmt.Errorf("%s:%d: %v", c.file, line, err)
+	}
+	return fmt.Errorf("line %d: %v", line, err)
+}
+
+// checkRanges records the options of the plugin, or the agent, set in tbl
+// with a value out of their declared range.
+func (c *Config) checkRanges(name string, tbl *ast.Table, v interface{}) error {
+	problems, err := checkRanges(tbl, v)
+	if err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		c.outOfRange = append(c.outOfRange,
+			c.locate(tbl, fmt.Errorf("%s: %s", name, problem)))
+	}
+	return nil
+}
+
+// reportRanges returns the options out of range as a single error, or adds
+// them to the collected errors.
+func (c *Config) reportRanges() error {
+	outOfRange := c.outOfRange
+	c.outOfRange = nil
+	if len(outOfRange) == 0 {
+		return nil
+	}
+
+	if c.CollectErrors {
+		c.Errors = append(c.Errors, outOfRange...)
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString("options out of range:")
+	for _, err := range outOfRange {
+		b.WriteString("\n  ")
+		b.WriteString(err.Error())
+	}
+	return errors.New(b.String())
+}
+
+// addConditional adds the plugins of an if_env section if the environment
+// variable named by the section matches its value.  Without a value the
+// variable must be set to a non-empty value.
+func (c *Config) addConditional(tbl *ast.Table) error {
+	var name, pattern string
+	if node, ok := tbl.Fields["name"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				name = str.Value
+			}
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("name of the environment variable is required")
+	}
+	if node, ok := tbl.Fields["value"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				pattern = str.Value
+			}
+		}
+	}
+
+	delete(tbl.Fields, "name")
+	delete(tbl.Fields, "value")
+	for key := range tbl.Fields {
+		switch key {
+		case "inputs", "outputs", "processors", "aggregators":
+		default:
+			return fmt.Errorf("%q is not supported in if_env", key)
+		}
+	}
+
+	value := os.Getenv(name)
+	if pattern == "" {
+		if value == "" {
+			return nil
+		}
+	} else {
+		f, err := filter.Compile([]string{pattern})
+		if err != nil {
+			return err
+		}
+		if !f.Match(value) {
+			return nil
+		}
 	}
 
+	return c.addPlugins(tbl)
+}
+
+// include loads the config files matching the glob patterns of the include
+// directive.  Relative patterns are relative to dir.
+func (c *Config) include(node interface{}, dir string) error {
+	kv, ok := node.(*ast.KeyValue)
+	if !ok {
+		return fmt.Errorf("invalid configuration, include must be placed before the first table")
+	}
+	ary, ok := kv.Value.(*ast.Array)
+	if !ok {
+		return fmt.Errorf("invalid configuration, include must be an array of strings")
+	}
+
+	for _, elem := range ary.Value {
+		str, ok := elem.(*ast.String)
+		if !ok {
+			return fmt.Errorf("invalid configuration, include must be an array of strings")
+		}
+
+		pattern := str.Value
+		if dir != "" && !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid include pattern %q: %w", str.Value, err)
+		}
+
+		for _, path := range paths {
+			if abs, err := filepath.Abs(path); err == nil && c.loaded[abs] {
+				log.Printf("D! Config file %s already loaded, skipping include", path)
+				continue
+			}
+			if err := c.LoadConfig(path); err != nil {
+				if !c.CollectErrors {
+					return err
+				}
+				c.Errors = append(c.Errors, err)
+			}
+		}
+	}
 	return nil
 }
 
@@ -837,7 +1341,13 @@ func escapeEnv(value string) string {
 	return envVarEscaper.Replace(value)
 }
 
-func loadConfig(config string) ([]byte, error) {
+// isURL returns true if the config is loaded from a http or https URL.
+func isURL(config string) bool {
+	u, err := url.Parse(config)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+func (c *Config) loadConfig(config string) ([]byte, error) {
 	u, err := url.Parse(config)
 	if err != nil {
 		return nil, err
@@ -845,7 +1355,7 @@ func loadConfig(config string) ([]byte, error) {
 
 	switch u.Scheme {
 	case "https", "http":
-		return fetchConfig(u)
+		return c.loadRemoteConfig(u)
 	default:
 		// If it isn't a https scheme, try it as a file.
 	}
@@ -853,29 +1363,6 @@ func loadConfig(config string) ([]byte, error) {
 
 }
 
-func fetchConfig(u *url.URL) ([]byte, error) {
-	req, err := http.NewRequest("GET", u.String(), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	if v, exists := os.LookupEnv("INFLUX_TOKEN"); exists {
-		req.Header.Add("Authorization", "Token "+v)
-	}
-	req.Header.Add("Accept", "application/toml")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to retrieve remote config: %s", resp.Status)
-	}
-
-	defer resp.Body.Close()
-	return ioutil.ReadAll(resp.Body)
-}
-
 // parseConfig loads a TOML configuration from a provided path and
 // returns the AST produced from the TOML parser. When loading the file, it
 // will find environment variables and replace them.
@@ -914,16 +1401,34 @@ func (c *Config) addAggregator(name string, table *ast.Table) error {
 	}
 	aggregator := creator()
 
+	// The fingerprint is taken before the table is consumed by the builders.
+	fingerprint := name + " " + tableFingerprint(table)
+
+	ranges := &aggregatorRanges{}
+	if err := c.checkRanges("aggregators."+name, decodeRanges(table, ranges), ranges); err != nil {
+		return err
+	}
+
 	conf, err := buildAggregator(name, table)
 	if err != nil {
 		return err
 	}
+	conf.Fingerprint = fingerprint
 
 	if err := toml.UnmarshalTable(table, aggregator); err != nil {
 		return err
 	}
+	if err := c.checkRanges("aggregators."+name, table, aggregator); err != nil {
+		return err
+	}
 
-	c.Aggregators = append(c.Aggregators, models.NewRunningAggregator(aggregator, conf))
+	ra := models.NewRunningAggregator(aggregator, conf)
+	ra.Creator = func() (telegraf.Aggregator, error) {
+		aggregator := creator()
+		err := toml.UnmarshalTable(table, aggregator)
+		return aggregator, err
+	}
+	c.Aggregators = append(c.Aggregators, ra)
 	return nil
 }
 
@@ -933,15 +1438,26 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested processor: %s", name)
 	}
 
+	// The fingerprint is taken before the table is consumed by the builders.
+	fingerprint := name + " " + tableFingerprint(table)
+
 	processorConfig, err := buildProcessor(name, table)
 	if err != nil {
 		return err
 	}
+	processorConfig.Fingerprint = fingerprint
 
 	rf, err := c.newRunningProcessor(creator, processorConfig, name, table)
 	if err != nil {
 		return err
 	}
+	var processor interface{} = rf.Processor
+	if p, ok := rf.Processor.(unwrappable); ok {
+		processor = p.Unwrap()
+	}
+	if err := c.checkRanges("processors."+name, table, processor); err != nil {
+		return err
+	}
 	c.Processors = append(c.Processors, rf)
 
 	// save a copy for the aggregator
@@ -986,25 +1502,50 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 	}
 	output := creator()
 
+	// The fingerprint is taken before the table is consumed by the builders.
+	fingerprint := fmt.Sprintf("%s %d %d %s", name,
+		c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit, tableFingerprint(table))
+
+	ranges := &outputRanges{}
+	if err := c.checkRanges("outputs."+name, decodeRanges(table, ranges), ranges); err != nil {
+		return err
+	}
+
 	// If the output has a SetSerializer function, then this means it can write
 	// arbitrary types of output, so build the serializer and set it.
-	switch t := output.(type) {
-	case serializers.SerializerOutput:
-		serializer, err := buildSerializer(name, table)
+	switch output.(type) {
+	case serializers.SerializerOutput, serializers.SerializerFuncOutput:
+		config, err := getSerializerConfig(name, table)
 		if err != nil {
 			return err
 		}
-		t.SetSerializer(serializer)
+
+		if t, ok := output.(serializers.SerializerOutput); ok {
+			serializer, err := serializers.NewSerializer(config)
+			if err != nil {
+				return err
+			}
+			t.SetSerializer(serializer)
+		}
+		if t, ok := output.(serializers.SerializerFuncOutput); ok {
+			t.SetSerializerFunc(func() (serializers.Serializer, error) {
+				return serializers.NewSerializer(config)
+			})
+		}
 	}
 
 	outputConfig, err := buildOutput(name, table)
 	if err != nil {
 		return err
 	}
+	outputConfig.Fingerprint = fingerprint
 
 	if err := toml.UnmarshalTable(table, output); err != nil {
 		return err
 	}
+	if err := c.checkRanges("outputs."+name, table, output); err != nil {
+		return err
+	}
 
 	ro := models.NewRunningOutput(name, output, outputConfig,
 		c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit)
@@ -1012,6 +1553,35 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 	return nil
 }
 
+// addRoute parses a route, which selects metrics using only the selector
+// filters and sends them to the outputs named in the route.
+func (c *Config) addRoute(table *ast.Table) error {
+	filter, err := buildFilter(table)
+	if err != nil {
+		return err
+	}
+	if len(filter.FieldPass) > 0 || len(filter.FieldDrop) > 0 ||
+		len(filter.TagInclude) > 0 || len(filter.TagExclude) > 0 {
+		return fmt.Errorf("routes only support namepass, namedrop, tagpass and tagdrop")
+	}
+
+	var route struct {
+		Outputs []string `toml:"outputs"`
+	}
+	if err := toml.UnmarshalTable(table, &route); err != nil {
+		return err
+	}
+	if len(route.Outputs) == 0 {
+		return fmt.Errorf("route has no outputs")
+	}
+
+	c.Routes = append(c.Routes, &models.Route{
+		Outputs: route.Outputs,
+		Filter:  filter,
+	})
+	return nil
+}
+
 func (c *Config) addInput(name string, table *ast.Table) error {
 	if len(c.InputFilters) > 0 && !sliceContains(name, c.InputFilters) {
 		return nil
@@ -1027,6 +1597,9 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 	}
 	input := creator()
 
+	// The fingerprint is taken before the table is consumed by the builders.
+	fingerprint := name + " " + tableFingerprint(table)
+
 	// If the input has a SetParser function, then this means it can accept
 	// arbitrary types of input, so build the parser and set it.
 	switch t := input.(type) {
@@ -1049,17 +1622,28 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 		})
 	}
 
+	ranges := &inputRanges{}
+	if err := c.checkRanges("inputs."+name, decodeRanges(table, ranges), ranges); err != nil {
+		return err
+	}
+
 	pluginConfig, err := buildInput(name, table)
 	if err != nil {
 		return err
 	}
+	pluginConfig.Fingerprint = fingerprint
 
 	if err := toml.UnmarshalTable(table, input); err != nil {
 		return err
 	}
+	if err := c.checkRanges("inputs."+name, table, input); err != nil {
+		return err
+	}
 
 	rp := models.NewRunningInput(input, pluginConfig)
 	rp.SetDefaultTags(c.Tags)
+	rp.SetTimestampLimits(c.Agent.MetricMaxPast.Duration,
+		c.Agent.MetricMaxFuture.Duration, c.Agent.InvalidTimestampAction)
 	c.Inputs = append(c.Inputs, rp)
 	return nil
 }
@@ -1069,10 +1653,12 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 // models.AggregatorConfig to be inserted into models.RunningAggregator
 func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, error) {
 	conf := &models.AggregatorConfig{
-		Name:   name,
-		Delay:  time.Millisecond * 100,
-		Period: time.Second * 30,
-		Grace:  time.Second * 0,
+		Name:       name,
+		Delay:      time.Millisecond * 100,
+		Period:     time.Second * 30,
+		Grace:      time.Second * 0,
+		Window:     models.WindowProcessingTime,
+		MaxWindows: 100,
 	}
 
 	if node, ok := tbl.Fields["period"]; ok {
@@ -1113,35 +1699,74 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 			}
 		}
 	}
-	if node, ok := tbl.Fields["drop_original"]; ok {
+
+	if node, ok := tbl.Fields["window"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
-			if b, ok := kv.Value.(*ast.Boolean); ok {
-				var err error
-				conf.DropOriginal, err = strconv.ParseBool(b.Value)
-				if err != nil {
-					log.Printf("Error parsing boolean value for %s: %s\n", name, err)
+			if str, ok := kv.Value.(*ast.String); ok {
+				switch str.Value {
+				case models.WindowProcessingTime, models.WindowEventTime:
+					conf.Window = str.Value
+				default:
+					return nil, fmt.Errorf("invalid window %q", str.Value)
 				}
 			}
 		}
 	}
 
-	if node, ok := tbl.Fields["name_prefix"]; ok {
+	if node, ok := tbl.Fields["allowed_lateness"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
-				conf.MeasurementPrefix = str.Value
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				conf.AllowedLateness = dur
 			}
 		}
 	}
 
-	if node, ok := tbl.Fields["name_suffix"]; ok {
+	if node, ok := tbl.Fields["max_windows"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
-			if str, ok := kv.Value.(*ast.String); ok {
-				conf.MeasurementSuffix = str.Value
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				conf.MaxWindows = int(v)
 			}
 		}
 	}
 
-	if node, ok := tbl.Fields["name_override"]; ok {
+	if node, ok := tbl.Fields["drop_original"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				conf.DropOriginal, err = strconv.ParseBool(b.Value)
+				if err != nil {
+					log.Printf("Error parsing boolean value for %s: %s\n", name, err)
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["name_prefix"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				conf.MeasurementPrefix = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["name_suffix"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				conf.MeasurementSuffix = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["name_override"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
 				conf.NameOverride = str.Value
@@ -1169,6 +1794,9 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 	delete(tbl.Fields, "period")
 	delete(tbl.Fields, "delay")
 	delete(tbl.Fields, "grace")
+	delete(tbl.Fields, "window")
+	delete(tbl.Fields, "allowed_lateness")
+	delete(tbl.Fields, "max_windows")
 	delete(tbl.Fields, "drop_original")
 	delete(tbl.Fields, "name_prefix")
 	delete(tbl.Fields, "name_suffix")
@@ -1374,6 +2002,102 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["precision"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.Precision = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["round_interval"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				roundInterval, err := b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+
+				cp.RoundInterval = &roundInterval
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["metric_max_past"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.MetricMaxPast = &dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["metric_max_future"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.MetricMaxFuture = &dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["invalid_timestamp_action"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cp.InvalidTimestampAction = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["max_gather_cpu_time"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.MaxGatherCPUTime = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["delivery_outputs"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						cp.DeliveryOutputs = append(cp.DeliveryOutputs, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["max_gather_metric_bytes"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			var size internal.Size
+			if err := size.UnmarshalTOML([]byte(kv.Value.Source())); err != nil {
+				return nil, fmt.Errorf("invalid max_gather_metric_bytes: %v", err)
+			}
+
+			cp.MaxGatherMetricBytes = size.Size
+		}
+	}
+
 	if node, ok := tbl.Fields["name_prefix"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1420,6 +2144,14 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "alias")
 	delete(tbl.Fields, "interval")
+	delete(tbl.Fields, "precision")
+	delete(tbl.Fields, "round_interval")
+	delete(tbl.Fields, "metric_max_past")
+	delete(tbl.Fields, "metric_max_future")
+	delete(tbl.Fields, "invalid_timestamp_action")
+	delete(tbl.Fields, "max_gather_cpu_time")
+	delete(tbl.Fields, "max_gather_metric_bytes")
+	delete(tbl.Fields, "delivery_outputs")
 	delete(tbl.Fields, "tags")
 	var err error
 	cp.Filter, err = buildFilter(tbl)
@@ -1504,6 +2236,18 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["json_structured_fields"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.JSONStructuredFields = append(c.JSONStructuredFields, str.Value)
+					}
+				}
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["json_name_key"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1702,6 +2446,62 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["grok_pattern_sets"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.GrokPatternSets = append(c.GrokPatternSets, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["grok_reload_interval"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, fmt.Errorf("Unable to parse grok_reload_interval as a duration, %s", err)
+				}
+				c.GrokReloadInterval = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["grok_timestamp_locale"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.GrokTimestampLocale = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["grok_timestamp_fallbacks"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.GrokTimestampFallbacks = append(c.GrokTimestampFallbacks, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["grok_inherit_timestamp"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.GrokInheritTimestamp, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
 	//for csv parser
 	if node, ok := tbl.Fields["csv_column_names"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
@@ -1836,6 +2636,19 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["csv_skip_errors"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.Boolean); ok {
+				//for config with no quotes
+				val, err := strconv.ParseBool(str.Value)
+				c.CSVSkipErrors = val
+				if err != nil {
+					return nil, fmt.Errorf("E! parsing to bool: %v", err)
+				}
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["form_urlencoded_tag_keys"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if ary, ok := kv.Value.(*ast.Array); ok {
@@ -1848,6 +2661,126 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["syslog_rfc"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.SyslogRFC = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["syslog_best_effort"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				val, err := b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+				c.SyslogBestEffort = val
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["syslog_sdparam_separator"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.SyslogSeparator = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["binary_endianness"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.BinaryEndianness = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["binary_field"]; ok {
+		if subtbls, ok := node.([]*ast.Table); ok {
+			for _, subtbl := range subtbls {
+				var field binary.Field
+				if err := toml.UnmarshalTable(subtbl, &field); err != nil {
+					return nil, fmt.Errorf("Error parsing binary_field, %s", err)
+				}
+				c.BinaryFields = append(c.BinaryFields, field)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["avro_schema_registry"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.AvroSchemaRegistry = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["avro_schema"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.AvroSchema = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["avro_measurement"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.AvroMeasurement = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["avro_timestamp"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.AvroTimestamp = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["avro_timestamp_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.AvroTimestampFormat = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["avro_field_separator"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.AvroFieldSeparator = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["avro_tags"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.AvroTags = append(c.AvroTags, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["avro_fields"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.AvroFields = append(c.AvroFields, str.Value)
+					}
+				}
+			}
+		}
+	}
+
 	c.MetricName = name
 
 	delete(tbl.Fields, "data_format")
@@ -1857,6 +2790,7 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 	delete(tbl.Fields, "json_name_key")
 	delete(tbl.Fields, "json_query")
 	delete(tbl.Fields, "json_string_fields")
+	delete(tbl.Fields, "json_structured_fields")
 	delete(tbl.Fields, "json_time_format")
 	delete(tbl.Fields, "json_time_key")
 	delete(tbl.Fields, "json_timezone")
@@ -1877,6 +2811,11 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 	delete(tbl.Fields, "grok_custom_pattern_files")
 	delete(tbl.Fields, "grok_timezone")
 	delete(tbl.Fields, "grok_unique_timestamp")
+	delete(tbl.Fields, "grok_pattern_sets")
+	delete(tbl.Fields, "grok_reload_interval")
+	delete(tbl.Fields, "grok_timestamp_locale")
+	delete(tbl.Fields, "grok_timestamp_fallbacks")
+	delete(tbl.Fields, "grok_inherit_timestamp")
 	delete(tbl.Fields, "csv_column_names")
 	delete(tbl.Fields, "csv_column_types")
 	delete(tbl.Fields, "csv_comment")
@@ -1891,15 +2830,29 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 	delete(tbl.Fields, "csv_timestamp_format")
 	delete(tbl.Fields, "csv_timezone")
 	delete(tbl.Fields, "csv_trim_space")
+	delete(tbl.Fields, "csv_skip_errors")
 	delete(tbl.Fields, "form_urlencoded_tag_keys")
+	delete(tbl.Fields, "syslog_rfc")
+	delete(tbl.Fields, "syslog_best_effort")
+	delete(tbl.Fields, "syslog_sdparam_separator")
+	delete(tbl.Fields, "binary_endianness")
+	delete(tbl.Fields, "binary_field")
+	delete(tbl.Fields, "avro_schema_registry")
+	delete(tbl.Fields, "avro_schema")
+	delete(tbl.Fields, "avro_measurement")
+	delete(tbl.Fields, "avro_timestamp")
+	delete(tbl.Fields, "avro_timestamp_format")
+	delete(tbl.Fields, "avro_field_separator")
+	delete(tbl.Fields, "avro_tags")
+	delete(tbl.Fields, "avro_fields")
 
 	return c, nil
 }
 
-// buildSerializer grabs the necessary entries from the ast.Table for creating
-// a serializers.Serializer object, and creates it, which can then be added onto
-// an Output object.
-func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error) {
+// getSerializerConfig grabs the necessary entries from the ast.Table for
+// creating a serializers.Serializer object, which can then be added onto an
+// Output object.
+func getSerializerConfig(name string, tbl *ast.Table) (*serializers.Config, error) {
 	c := &serializers.Config{TimestampUnits: time.Duration(1 * time.Second)}
 
 	if node, ok := tbl.Fields["data_format"]; ok {
@@ -1998,6 +2951,18 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	if node, ok := tbl.Fields["graphite_strict_sanitize"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.GraphiteStrictSanitize, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["json_timestamp_units"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -2014,6 +2979,72 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	if node, ok := tbl.Fields["json_timestamp_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONTimestampFormat = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_flatten_tags"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.JSONFlattenTags, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_flatten_fields"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.JSONFlattenFields, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_rename"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			c.JSONRename = make(map[string]string)
+			if err := toml.UnmarshalTable(subtbl, c.JSONRename); err != nil {
+				return nil, fmt.Errorf("Unable to parse json_rename, %s", err)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_batch_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONBatchFormat = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_batch_key"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONBatchKey = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_batch_envelope"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			c.JSONBatchEnvelope = make(map[string]string)
+			if err := toml.UnmarshalTable(subtbl, c.JSONBatchEnvelope); err != nil {
+				return nil, fmt.Errorf("Unable to parse json_batch_envelope, %s", err)
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["splunkmetric_hec_routing"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if b, ok := kv.Value.(*ast.Boolean); ok {
@@ -2103,11 +3134,19 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 	delete(tbl.Fields, "influx_uint_support")
 	delete(tbl.Fields, "graphite_tag_support")
 	delete(tbl.Fields, "graphite_separator")
+	delete(tbl.Fields, "graphite_strict_sanitize")
 	delete(tbl.Fields, "data_format")
 	delete(tbl.Fields, "prefix")
 	delete(tbl.Fields, "template")
 	delete(tbl.Fields, "templates")
 	delete(tbl.Fields, "json_timestamp_units")
+	delete(tbl.Fields, "json_timestamp_format")
+	delete(tbl.Fields, "json_flatten_tags")
+	delete(tbl.Fields, "json_flatten_fields")
+	delete(tbl.Fields, "json_rename")
+	delete(tbl.Fields, "json_batch_format")
+	delete(tbl.Fields, "json_batch_key")
+	delete(tbl.Fields, "json_batch_envelope")
 	delete(tbl.Fields, "splunkmetric_hec_routing")
 	delete(tbl.Fields, "splunkmetric_multimetric")
 	delete(tbl.Fields, "wavefront_source_override")
@@ -2115,7 +3154,7 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 	delete(tbl.Fields, "prometheus_export_timestamp")
 	delete(tbl.Fields, "prometheus_sort_metrics")
 	delete(tbl.Fields, "prometheus_string_as_label")
-	return serializers.NewSerializer(c)
+	return c, nil
 }
 
 // buildOutput parses output specific items from the ast.Table,
@@ -2223,6 +3262,27 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["dead_letter_output"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				deadLetter := str.Value
+				oc.DeadLetterOutput = &deadLetter
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["tags"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			oc.Tags = make(map[string]string)
+			if err := toml.UnmarshalTable(subtbl, oc.Tags); err != nil {
+				return nil, fmt.Errorf("could not parse tags for output %s: %v", name, err)
+			}
+			// Some outputs have a tags option, only the table is required
+			// from the metrics.
+			delete(tbl.Fields, "tags")
+		}
+	}
+
 	delete(tbl.Fields, "flush_interval")
 	delete(tbl.Fields, "flush_jitter")
 	delete(tbl.Fields, "metric_buffer_limit")
@@ -2231,6 +3291,7 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_prefix")
+	delete(tbl.Fields, "dead_letter_output")
 
 	return oc, nil
 }
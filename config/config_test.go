@@ -2,18 +2,23 @@ package config
 
 import (
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/aggregators/basicstats"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/inputs/exec"
 	"github.com/influxdata/telegraf/plugins/inputs/http_listener_v2"
 	"github.com/influxdata/telegraf/plugins/inputs/memcached"
+	_ "github.com/influxdata/telegraf/plugins/inputs/ping"
 	"github.com/influxdata/telegraf/plugins/inputs/procstat"
 	httpOut "github.com/influxdata/telegraf/plugins/outputs/http"
+	"github.com/influxdata/telegraf/plugins/outputs/riemann"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	_ "github.com/influxdata/telegraf/plugins/processors/rename"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -57,6 +62,7 @@ func TestConfig_LoadSingleInputWithEnvVars(t *testing.T) {
 
 	assert.Equal(t, memcached, c.Inputs[0].Input,
 		"Testdata did not produce a correct memcached struct.")
+	mConfig.Fingerprint = c.Inputs[0].Config.Fingerprint
 	assert.Equal(t, mConfig, c.Inputs[0].Config,
 		"Testdata did not produce correct memcached metadata.")
 }
@@ -96,6 +102,7 @@ func TestConfig_LoadSingleInput(t *testing.T) {
 
 	assert.Equal(t, memcached, c.Inputs[0].Input,
 		"Testdata did not produce a correct memcached struct.")
+	mConfig.Fingerprint = c.Inputs[0].Config.Fingerprint
 	assert.Equal(t, mConfig, c.Inputs[0].Config,
 		"Testdata did not produce correct memcached metadata.")
 }
@@ -142,6 +149,7 @@ func TestConfig_LoadDirectory(t *testing.T) {
 
 	assert.Equal(t, memcached, c.Inputs[0].Input,
 		"Testdata did not produce a correct memcached struct.")
+	mConfig.Fingerprint = c.Inputs[0].Config.Fingerprint
 	assert.Equal(t, mConfig, c.Inputs[0].Config,
 		"Testdata did not produce correct memcached metadata.")
 
@@ -166,12 +174,14 @@ func TestConfig_LoadDirectory(t *testing.T) {
 
 	assert.Equal(t, ex, c.Inputs[1].Input,
 		"Merged Testdata did not produce a correct exec struct.")
+	eConfig.Fingerprint = c.Inputs[1].Config.Fingerprint
 	assert.Equal(t, eConfig, c.Inputs[1].Config,
 		"Merged Testdata did not produce correct exec metadata.")
 
 	memcached.Servers = []string{"192.168.1.1"}
 	assert.Equal(t, memcached, c.Inputs[2].Input,
 		"Testdata did not produce a correct memcached struct.")
+	mConfig.Fingerprint = c.Inputs[2].Config.Fingerprint
 	assert.Equal(t, mConfig, c.Inputs[2].Config,
 		"Testdata did not produce correct memcached metadata.")
 
@@ -183,6 +193,7 @@ func TestConfig_LoadDirectory(t *testing.T) {
 
 	assert.Equal(t, pstat, c.Inputs[3].Input,
 		"Merged Testdata did not produce a correct procstat struct.")
+	pConfig.Fingerprint = c.Inputs[3].Config.Fingerprint
 	assert.Equal(t, pConfig, c.Inputs[3].Config,
 		"Merged Testdata did not produce correct procstat metadata.")
 }
@@ -257,3 +268,430 @@ func TestConfig_BadOrdering(t *testing.T) {
 	require.Error(t, err, "bad ordering")
 	assert.Equal(t, "Error loading config file ./testdata/non_slice_slice.toml: Error parsing http array, line 4: cannot unmarshal TOML array into string (need slice)", err.Error())
 }
+
+func TestConfig_InputTimestampLimits(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(`
+[agent]
+  metric_max_past = "1h"
+  metric_max_future = "1m"
+
+[[inputs.memcached]]
+  metric_max_past = "0s"
+  invalid_timestamp_action = "clamp"
+`))
+	require.NoError(t, err)
+	require.Len(t, c.Inputs, 1)
+
+	cfg := c.Inputs[0].Config
+	require.NotNil(t, cfg.MetricMaxPast)
+	require.Equal(t, time.Duration(0), *cfg.MetricMaxPast)
+	require.Nil(t, cfg.MetricMaxFuture)
+	require.Equal(t, "clamp", cfg.InvalidTimestampAction)
+	require.Equal(t, time.Hour, c.Agent.MetricMaxPast.Duration)
+	require.Equal(t, time.Minute, c.Agent.MetricMaxFuture.Duration)
+}
+
+func TestConfig_InputResourceLimits(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(`
+[[inputs.memcached]]
+  max_gather_cpu_time = "2s"
+  max_gather_metric_bytes = "10MB"
+
+[[inputs.memcached]]
+  alias = "bytes"
+  max_gather_metric_bytes = 1024
+`))
+	require.NoError(t, err)
+	require.Len(t, c.Inputs, 2)
+
+	for _, input := range c.Inputs {
+		if input.Config.Alias == "bytes" {
+			require.Equal(t, time.Duration(0), input.Config.MaxGatherCPUTime)
+			require.Equal(t, int64(1024), input.Config.MaxGatherMetricBytes)
+		} else {
+			require.Equal(t, 2*time.Second, input.Config.MaxGatherCPUTime)
+			require.Equal(t, int64(10*1000*1000), input.Config.MaxGatherMetricBytes)
+		}
+	}
+
+	c = NewConfig()
+	err = c.LoadConfigData([]byte(`
+[[inputs.memcached]]
+  max_gather_metric_bytes = "lots"
+`))
+	require.Error(t, err)
+}
+
+func TestConfig_AggregatorEventTime(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(`
+[[aggregators.basicstats]]
+  period = "1m"
+  window = "event_time"
+  allowed_lateness = "5m"
+  stats = ["mean"]
+`))
+	require.NoError(t, err)
+	require.Len(t, c.Aggregators, 1)
+
+	conf := c.Aggregators[0].Config
+	require.Equal(t, models.WindowEventTime, conf.Window)
+	require.Equal(t, 5*time.Minute, conf.AllowedLateness)
+
+	// Each window is aggregated by a new instance with the same settings
+	aggregator, err := c.Aggregators[0].Creator()
+	require.NoError(t, err)
+	require.Equal(t, []string{"mean"}, aggregator.(*basicstats.BasicStats).Stats)
+
+	c = NewConfig()
+	err = c.LoadConfigData([]byte(`
+[[aggregators.basicstats]]
+  window = "ingest_time"
+`))
+	require.Error(t, err)
+}
+
+func TestConfig_Include(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/include.toml"))
+
+	// The included file itself is skipped
+	require.Len(t, c.Inputs, 4)
+	require.ElementsMatch(t,
+		[]string{"exec", "memcached", "memcached", "procstat"},
+		c.InputNames())
+
+	c = NewConfig()
+	require.Error(t, c.LoadConfigData([]byte(`
+include = "subconfig/*.conf"
+`)))
+}
+
+func TestConfig_IncludeLoadDirectory(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/include.toml"))
+
+	// The files already included are not loaded again from the directory
+	require.NoError(t, c.LoadDirectory("./testdata/subconfig"))
+	require.ElementsMatch(t,
+		[]string{"exec", "memcached", "memcached", "procstat"},
+		c.InputNames())
+}
+
+func TestConfig_IfEnv(t *testing.T) {
+	os.Setenv("TELEGRAF_TEST_ENV", "production-eu")
+	defer os.Unsetenv("TELEGRAF_TEST_ENV")
+
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(`
+[[inputs.memcached]]
+  alias = "always"
+
+[[if_env]]
+  name = "TELEGRAF_TEST_ENV"
+  value = "production-*"
+  [[if_env.inputs.memcached]]
+    alias = "production"
+
+[[if_env]]
+  name = "TELEGRAF_TEST_ENV"
+  value = "staging"
+  [[if_env.inputs.memcached]]
+    alias = "staging"
+
+[[if_env]]
+  name = "TELEGRAF_TEST_ENV"
+  [[if_env.inputs.memcached]]
+    alias = "set"
+
+[[if_env]]
+  name = "TELEGRAF_TEST_UNSET"
+  [[if_env.inputs.memcached]]
+    alias = "unset"
+`))
+	require.NoError(t, err)
+
+	var aliases []string
+	for _, input := range c.Inputs {
+		aliases = append(aliases, input.Config.Alias)
+	}
+	require.ElementsMatch(t, []string{"always", "production", "set"}, aliases)
+
+	c = NewConfig()
+	err = c.LoadConfigData([]byte(`
+[[if_env]]
+  name = "TELEGRAF_TEST_ENV"
+  [if_env.agent]
+    interval = "1s"
+`))
+	require.Error(t, err)
+}
+
+func TestConfig_CollectErrors(t *testing.T) {
+	c := NewConfig()
+	require.Error(t, c.LoadConfig("./testdata/invalid_plugins.toml"))
+
+	c = NewConfig()
+	c.CollectErrors = true
+	require.NoError(t, c.LoadConfig("./testdata/invalid_plugins.toml"))
+	require.Len(t, c.Inputs, 1)
+
+	var errs []string
+	for _, err := range c.Errors {
+		errs = append(errs, strings.SplitN(err.Error(), ",", 2)[0])
+	}
+	require.ElementsMatch(t, []string{
+		"./testdata/invalid_plugins.toml:4: Error parsing nonexistent",
+		"./testdata/invalid_plugins.toml:6: Error parsing nonexistent array",
+		"./testdata/invalid_plugins.toml:8: Error parsing memcached",
+	}, errs)
+}
+
+func TestConfig_OutputFingerprint(t *testing.T) {
+	load := func(data string) *Config {
+		c := NewConfig()
+		require.NoError(t, c.LoadConfigData([]byte(data)))
+		return c
+	}
+
+	c := load(`
+[[outputs.http]]
+  url = "http://localhost:8080/a"
+  data_format = "influx"
+  [outputs.http.tagpass]
+    cpu = ["cpu0"]
+
+[[outputs.http]]
+  url = "http://localhost:8080/b"
+`)
+	require.Len(t, c.Outputs, 2)
+	require.NotEqual(t, c.Outputs[0].Config.Fingerprint, c.Outputs[1].Config.Fingerprint)
+
+	// Formatting, comments and order do not change the fingerprint
+	reformatted := load(`
+[[outputs.http]]
+  data_format="influx" # line protocol
+  url="http://localhost:8080/a"
+  [outputs.http.tagpass]
+  cpu = ["cpu0"]
+`)
+	require.Equal(t, c.Outputs[0].Config.Fingerprint, reformatted.Outputs[0].Config.Fingerprint)
+
+	changed := load(`
+[[outputs.http]]
+  url = "http://localhost:8080/a"
+  data_format = "influx"
+  [outputs.http.tagpass]
+    cpu = ["cpu1"]
+`)
+	require.NotEqual(t, c.Outputs[0].Config.Fingerprint, changed.Outputs[0].Config.Fingerprint)
+
+	buffer := load(`
+[agent]
+  metric_buffer_limit = 100
+
+[[outputs.http]]
+  url = "http://localhost:8080/a"
+  data_format = "influx"
+  [outputs.http.tagpass]
+    cpu = ["cpu0"]
+`)
+	require.NotEqual(t, c.Outputs[0].Config.Fingerprint, buffer.Outputs[0].Config.Fingerprint)
+}
+
+func TestConfig_PluginFingerprint(t *testing.T) {
+	load := func(data string) *Config {
+		c := NewConfig()
+		require.NoError(t, c.LoadConfigData([]byte(data)))
+		return c
+	}
+
+	c := load(`
+[agent]
+  interval = "10s"
+
+[[inputs.memcached]]
+  servers = ["localhost"]
+
+[[processors.rename]]
+  order = 1
+
+[[aggregators.basicstats]]
+  period = "30s"
+`)
+	reformatted := load(`
+[agent]
+  interval="10s"
+
+[[aggregators.basicstats]]
+  period="30s" # window
+
+[[processors.rename]]
+  order=1
+
+[[inputs.memcached]]
+  servers=["localhost"]
+`)
+	require.Equal(t, c.AgentFingerprint, reformatted.AgentFingerprint)
+	require.Equal(t, c.Inputs[0].Config.Fingerprint, reformatted.Inputs[0].Config.Fingerprint)
+	require.Equal(t, c.Processors[0].Config.Fingerprint, reformatted.Processors[0].Config.Fingerprint)
+	require.Equal(t, c.AggProcessors[0].Config.Fingerprint, reformatted.AggProcessors[0].Config.Fingerprint)
+	require.Equal(t, c.Aggregators[0].Config.Fingerprint, reformatted.Aggregators[0].Config.Fingerprint)
+
+	changed := load(`
+[agent]
+  interval = "20s"
+
+[[inputs.memcached]]
+  servers = ["remote"]
+
+[[processors.rename]]
+  order = 2
+
+[[aggregators.basicstats]]
+  period = "1m"
+`)
+	require.NotEqual(t, c.AgentFingerprint, changed.AgentFingerprint)
+	require.NotEqual(t, c.Inputs[0].Config.Fingerprint, changed.Inputs[0].Config.Fingerprint)
+	require.NotEqual(t, c.Processors[0].Config.Fingerprint, changed.Processors[0].Config.Fingerprint)
+	require.NotEqual(t, c.Aggregators[0].Config.Fingerprint, changed.Aggregators[0].Config.Fingerprint)
+}
+
+func TestConfig_OutputTags(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfigData([]byte(`
+[[outputs.http]]
+  url = "http://localhost:8080"
+  [outputs.http.tags]
+    team = "a"
+
+[[outputs.riemann]]
+  tags = ["telegraf"]
+`))
+	require.NoError(t, err)
+	require.Len(t, c.Outputs, 2)
+
+	for _, output := range c.Outputs {
+		switch output.Config.Name {
+		case "http":
+			require.Equal(t, map[string]string{"team": "a"}, output.Config.Tags)
+		case "riemann":
+			// The tags option of the output is not a table
+			require.Nil(t, output.Config.Tags)
+			require.Equal(t, []string{"telegraf"}, output.Output.(*riemann.Riemann).Tags)
+		}
+	}
+}
+
+func TestConfig_OutOfRange(t *testing.T) {
+	data := []byte(`
+[agent]
+  interval = "0s"
+  flush_buffer_watermark = 120
+
+[[inputs.ping]]
+  urls = ["localhost"]
+  count = 0
+  interval = "500us"
+
+[[inputs.exec]]
+  commands = ["true"]
+  timeout = "1h"
+
+[[outputs.http]]
+  url = "http://localhost:8080"
+  metric_batch_size = 0
+`)
+
+	c := NewConfig()
+	err := c.LoadConfigData(data)
+	require.Error(t, err)
+	for _, problem := range []string{
+		"agent: interval = 0s is out of range, must be at least 1ms",
+		"agent: flush_buffer_watermark = 120 is out of range, must be between 0 and 100",
+		"inputs.ping: interval = 500µs is out of range, must be at least 1ms",
+		"inputs.ping: count = 0 is out of range, must be at least 1",
+		"outputs.http: metric_batch_size = 0 is out of range, must be at least 1",
+	} {
+		require.Contains(t, err.Error(), problem)
+	}
+	require.NotContains(t, err.Error(), "inputs.exec")
+
+	c = NewConfig()
+	c.CollectErrors = true
+	require.NoError(t, c.LoadConfigData(data))
+	require.Len(t, c.Errors, 5)
+	require.Contains(t, c.Errors[0].Error(), "line 2: agent: interval")
+}
+
+func TestCheckRanges(t *testing.T) {
+	type embedded struct {
+		Size internal.Size `toml:"size" range:"1KB,1MB"`
+	}
+	type plugin struct {
+		embedded
+		Timeout internal.Duration `range:",1m"`
+		Ratio   float64           `toml:"ratio" range:"0,1"`
+		Unset   int               `toml:"unset" range:"1,"`
+	}
+
+	tbl, err := parseConfig([]byte(`
+size = "2MB"
+timeout = "2m"
+ratio = 0.5
+`))
+	require.NoError(t, err)
+
+	v := &plugin{
+		embedded: embedded{Size: internal.Size{Size: 2 * 1024 * 1024}},
+		Timeout:  internal.Duration{Duration: 2 * time.Minute},
+		Ratio:    0.5,
+	}
+	problems, err := checkRanges(tbl, v)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"size = 2097152B is out of range, must be between 1KB and 1MB",
+		"timeout = 2m0s is out of range, must be at most 1m",
+	}, problems)
+
+	type invalid struct {
+		Name string `toml:"name" range:"1,"`
+	}
+	tbl, err = parseConfig([]byte(`name = "a"`))
+	require.NoError(t, err)
+	_, err = checkRanges(tbl, &invalid{Name: "a"})
+	require.Error(t, err)
+}
+
+func TestConfig_Routes(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfigData([]byte(`
+[[routes]]
+  outputs = ["prod", "archive"]
+  namepass = ["cpu*"]
+  [routes.tagpass]
+    env = ["prod"]
+
+[[routes]]
+  outputs = ["debug"]
+`)))
+	require.Len(t, c.Routes, 2)
+	require.Equal(t, []string{"prod", "archive"}, c.Routes[0].Outputs)
+	require.Equal(t, []string{"cpu*"}, c.Routes[0].Filter.NamePass)
+	require.Len(t, c.Routes[0].Filter.TagPass, 1)
+	require.Equal(t, "env", c.Routes[0].Filter.TagPass[0].Name)
+	require.Equal(t, []string{"prod"}, c.Routes[0].Filter.TagPass[0].Filter)
+	require.Equal(t, []string{"debug"}, c.Routes[1].Outputs)
+
+	invalid := []string{
+		"[routes]\n  outputs = [\"prod\"]\n",
+		"[[routes]]\n  namepass = [\"cpu\"]\n",
+		"[[routes]]\n  outputs = [\"prod\"]\n  fieldpass = [\"usage\"]\n",
+		"[[routes]]\n  outputs = [\"prod\"]\n  unknown = true\n",
+	}
+	for _, data := range invalid {
+		require.Error(t, NewConfig().LoadConfigData([]byte(data)), data)
+	}
+}
@@ -0,0 +1,257 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/toml"
+	"github.com/influxdata/toml/ast"
+)
+
+// Plugins declare the sane range of an option with the range struct tag,
+// holding the minimum and the maximum separated by a comma.  Either bound
+// can be omitted:
+//
+//	Timeout internal.Duration `toml:"timeout" range:"1ms,1h"`
+//	Count   int               `toml:"count" range:"1,"`
+//
+// The bounds of internal.Duration and time.Duration options are durations,
+// those of internal.Size options are sizes such as "10MB".  Only the options
+// set in the configuration are checked.
+
+// inputRanges are the ranges of the options available on every input.
+type inputRanges struct {
+	Interval             internal.Duration `toml:"interval" range:"1ms,"`
+	Precision            internal.Duration `toml:"precision" range:"0s,"`
+	MetricMaxPast        internal.Duration `toml:"metric_max_past" range:"0s,"`
+	MetricMaxFuture      internal.Duration `toml:"metric_max_future" range:"0s,"`
+	MaxGatherCPUTime     internal.Duration `toml:"max_gather_cpu_time" range:"0s,"`
+	MaxGatherMetricBytes internal.Size     `toml:"max_gather_metric_bytes" range:"0B,"`
+}
+
+// outputRanges are the ranges of the options available on every output.
+type outputRanges struct {
+	FlushInterval     internal.Duration `toml:"flush_interval" range:"1ms,"`
+	FlushJitter       internal.Duration `toml:"flush_jitter" range:"0s,"`
+	MetricBatchSize   int               `toml:"metric_batch_size" range:"1,"`
+	MetricBufferLimit int               `toml:"metric_buffer_limit" range:"1,"`
+}
+
+// aggregatorRanges are the ranges of the options available on every
+// aggregator.
+type aggregatorRanges struct {
+	Period          internal.Duration `toml:"period" range:"1ms,"`
+	Delay           internal.Duration `toml:"delay" range:"0s,"`
+	Grace           internal.Duration `toml:"grace" range:"0s,"`
+	AllowedLateness internal.Duration `toml:"allowed_lateness" range:"0s,"`
+}
+
+// decodeRanges decodes the options of tbl declared by v, a pointer to one of
+// the range structs above, and returns the table of the decoded options.
+// Options failing to decode are left for the plugin configuration to report.
+func decodeRanges(tbl *ast.Table, v interface{}) *ast.Table {
+	decoded := &ast.Table{Line: tbl.Line, Fields: make(map[string]interface{})}
+	typ := reflect.TypeOf(v).Elem()
+	for i := 0; i < typ.NumField(); i++ {
+		key := typ.Field(i).Tag.Get("toml")
+		node, ok := tbl.Fields[key]
+		if !ok {
+			continue
+		}
+
+		sub := &ast.Table{Fields: map[string]interface{}{key: node}}
+		if err := toml.UnmarshalTable(sub, v); err == nil {
+			decoded.Fields[key] = node
+		}
+	}
+	return decoded
+}
+
+// checkRanges returns a description of each option of v, a pointer to a
+// struct, set in tbl with a value out of its declared range.
+func checkRanges(tbl *ast.Table, v interface{}) ([]string, error) {
+	return checkStructRanges(tbl, reflect.ValueOf(v))
+}
+
+func checkStructRanges(tbl *ast.Table, rv reflect.Value) ([]string, error) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	var problems []string
+	typ := rv.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		// Options of embedded structs are set in the same table
+		if field.Anonymous {
+			embedded, err := checkStructRanges(tbl, rv.Field(i))
+			if err != nil {
+				return nil, err
+			}
+			problems = append(problems, embedded...)
+			continue
+		}
+
+		spec, ok := field.Tag.Lookup("range")
+		if !ok || field.PkgPath != "" {
+			continue
+		}
+		key, ok := tableKey(tbl, field)
+		if !ok {
+			continue
+		}
+
+		problem, err := checkRange(key, rv.Field(i), spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid range of %s.%s: %v", typ, field.Name, err)
+		}
+		if problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	return problems, nil
+}
+
+// tableKey returns the key of the table setting the struct field, matched
+// like the TOML decoder does.
+func tableKey(tbl *ast.Table, field reflect.StructField) (string, bool) {
+	if name := strings.Split(field.Tag.Get("toml"), ",")[0]; name != "" {
+		if name == "-" {
+			return "", false
+		}
+		_, ok := tbl.Fields[name]
+		return name, ok
+	}
+
+	norm := toml.DefaultConfig.NormFieldName
+	for key := range tbl.Fields {
+		if norm(nil, key) == norm(nil, field.Name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// rangeKind is the kind of the values of an option with a range.
+type rangeKind int
+
+const (
+	rangeNumber rangeKind = iota
+	rangeDuration
+	rangeSize
+)
+
+var (
+	durationType         = reflect.TypeOf(time.Duration(0))
+	internalDurationType = reflect.TypeOf(internal.Duration{})
+	internalSizeType     = reflect.TypeOf(internal.Size{})
+	internalNumberType   = reflect.TypeOf(internal.Number{})
+)
+
+// rangeValue returns the kind and the value of an option as a float.
+func rangeValue(v reflect.Value) (rangeKind, float64, error) {
+	switch v.Type() {
+	case durationType:
+		return rangeDuration, float64(v.Int()), nil
+	case internalDurationType:
+		return rangeDuration, float64(v.FieldByName("Duration").Int()), nil
+	case internalSizeType:
+		return rangeSize, float64(v.FieldByName("Size").Int()), nil
+	case internalNumberType:
+		return rangeNumber, v.FieldByName("Value").Float(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rangeNumber, float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rangeNumber, float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rangeNumber, v.Float(), nil
+	}
+	return 0, 0, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// formatValue formats the value of an option like in the configuration.
+func formatValue(kind rangeKind, value float64) string {
+	switch kind {
+	case rangeDuration:
+		return time.Duration(value).String()
+	case rangeSize:
+		return strconv.FormatInt(int64(value), 10) + "B"
+	default:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+}
+
+// parseBound parses a bound of a range.
+func parseBound(kind rangeKind, s string) (float64, error) {
+	switch kind {
+	case rangeDuration:
+		d, err := time.ParseDuration(s)
+		return float64(d), err
+	case rangeSize:
+		size, err := units.ParseStrictBytes(s)
+		return float64(size), err
+	default:
+		return strconv.ParseFloat(s, 64)
+	}
+}
+
+// checkRange returns a description of the problem if the value of the option
+// named key is out of the range spec, and an empty string otherwise.
+func checkRange(key string, v reflect.Value, spec string) (string, error) {
+	kind, value, err := rangeValue(v)
+	if err != nil {
+		return "", err
+	}
+
+	bounds := strings.Split(spec, ",")
+	if len(bounds) != 2 {
+		return "", fmt.Errorf("expected \"min,max\", got %q", spec)
+	}
+	min, max := strings.TrimSpace(bounds[0]), strings.TrimSpace(bounds[1])
+
+	var below, above bool
+	if min != "" {
+		bound, err := parseBound(kind, min)
+		if err != nil {
+			return "", err
+		}
+		below = value < bound
+	}
+	if max != "" {
+		bound, err := parseBound(kind, max)
+		if err != nil {
+			return "", err
+		}
+		above = value > bound
+	}
+	if !below && !above {
+		return "", nil
+	}
+
+	var want string
+	switch {
+	case min != "" && max != "":
+		want = fmt.Sprintf("between %s and %s", min, max)
+	case min != "":
+		want = "at least " + min
+	default:
+		want = "at most " + max
+	}
+	return fmt.Sprintf("%s = %s is out of range, must be %s",
+		key, formatValue(kind, value), want), nil
+}
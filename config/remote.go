@@ -0,0 +1,202 @@
+package config
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// remoteTimeout is the timeout of the requests fetching the config files.
+const remoteTimeout = 30 * time.Second
+
+// remoteClient fetches the config files loaded from a URL.
+var remoteClient = &http.Client{Timeout: remoteTimeout}
+
+// remoteConfig is the state of a config file loaded from a URL, used to
+// detect its changes.
+type remoteConfig struct {
+	etag string
+	hash [sha256.Size]byte
+}
+
+// LoadSignatureKey reads the PEM encoded public key verifying the signatures
+// of the config files loaded from a URL.  RSA, ECDSA and Ed25519 keys are
+// supported.
+func LoadSignatureKey(path string) (crypto.PublicKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM encoded public key found in %s", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key in %s: %v", path, err)
+	}
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T in %s", key, path)
+	}
+}
+
+// loadRemoteConfig fetches the config file at u and records its state.
+func (c *Config) loadRemoteConfig(u *url.URL) ([]byte, error) {
+	data, etag, err := c.fetchConfig(u, "")
+	if err != nil {
+		return nil, err
+	}
+	c.remote[u.String()] = &remoteConfig{
+		etag: etag,
+		hash: sha256.Sum256(data),
+	}
+	return data, nil
+}
+
+// RemoteConfigChanged fetches the config files loaded from a URL again and
+// returns true if one of them changed since the config was loaded.
+func (c *Config) RemoteConfigChanged() (bool, error) {
+	for rawurl, state := range c.remote {
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return false, err
+		}
+		data, _, err := c.fetchConfig(u, state.etag)
+		if err != nil {
+			return false, fmt.Errorf("error fetching config file %s: %w", rawurl, err)
+		}
+		if data != nil && sha256.Sum256(data) != state.hash {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fetchConfig fetches the config file at u and verifies its signature when a
+// signature key is set.  With the ETag of the previous fetch, nil data is
+// returned if the file was not modified.
+func (c *Config) fetchConfig(u *url.URL, etag string) ([]byte, string, error) {
+	headers := map[string]string{"Accept": "application/toml"}
+	if etag != "" {
+		headers["If-None-Match"] = etag
+	}
+	resp, err := fetch(u, headers)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		if etag != "" {
+			return nil, etag, nil
+		}
+		fallthrough
+	default:
+		return nil, "", fmt.Errorf("failed to retrieve remote config: %s", resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if c.SignatureKey != nil {
+		if err := c.verifyConfig(u, data); err != nil {
+			return nil, "", err
+		}
+	}
+	return data, resp.Header.Get("ETag"), nil
+}
+
+// verifyConfig verifies the config file fetched from u with its detached
+// signature, fetched from the same URL with the ".sig" suffix.
+func (c *Config) verifyConfig(u *url.URL, data []byte) error {
+	sigURL := *u
+	sigURL.Path += ".sig"
+	resp, err := fetch(&sigURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve signature: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to retrieve signature %s: %s", sigURL.String(), resp.Status)
+	}
+	sig, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// The signature is either binary or base64 encoded.
+	if verifySignature(c.SignatureKey, data, sig) {
+		return nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig)))
+	if err == nil && verifySignature(c.SignatureKey, data, decoded) {
+		return nil
+	}
+	return errors.New("invalid signature")
+}
+
+// ecdsaSignature is the ASN.1 encoding of an ECDSA signature.
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// verifySignature returns true if sig is a valid signature of data.  The RSA
+// and ECDSA signatures are made of the SHA-256 digest of the data.
+func verifySignature(key crypto.PublicKey, data, sig []byte) bool {
+	digest := sha256.Sum256(data)
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil
+	case *ecdsa.PublicKey:
+		var esig ecdsaSignature
+		if rest, err := asn1.Unmarshal(sig, &esig); err != nil || len(rest) != 0 {
+			return false
+		}
+		if esig.R == nil || esig.S == nil {
+			return false
+		}
+		return ecdsa.Verify(key, digest[:], esig.R, esig.S)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, data, sig)
+	}
+	return false
+}
+
+// fetch sends a GET request to u, authenticated with the INFLUX_TOKEN
+// environment variable when set.
+func fetch(u *url.URL, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if v, exists := os.LookupEnv("INFLUX_TOKEN"); exists {
+		req.Header.Add("Authorization", "Token "+v)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return remoteClient.Do(req)
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// configServer serves a config file with its ETag and signature.
+type configServer struct {
+	sync.Mutex
+	data     []byte
+	sig      []byte
+	requests int
+}
+
+func (s *configServer) set(data []byte, sig []byte) {
+	s.Lock()
+	defer s.Unlock()
+	s.data = data
+	s.sig = sig
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	switch r.URL.Path {
+	case "/telegraf.conf":
+		s.requests++
+		etag := fmt.Sprintf(`"%x"`, sha256.Sum256(s.data))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write(s.data)
+	case "/telegraf.conf.sig":
+		if s.sig == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(s.sig)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+const remoteConfigData = `
+[[inputs.memcached]]
+  servers = ["localhost"]
+`
+
+func TestConfig_RemoteConfigChanged(t *testing.T) {
+	s := &configServer{data: []byte(remoteConfigData)}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig(ts.URL+"/telegraf.conf"))
+	require.Len(t, c.Inputs, 1)
+
+	changed, err := c.RemoteConfigChanged()
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Equal(t, 2, s.requests)
+
+	s.set([]byte(remoteConfigData+"  timeout = \"5s\"\n"), nil)
+	changed, err = c.RemoteConfigChanged()
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	ts.Close()
+	_, err = c.RemoteConfigChanged()
+	require.Error(t, err)
+}
+
+func TestConfig_RemoteConfigSignature(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	data := []byte(remoteConfigData)
+	digest := sha256.Sum256(data)
+	edSig := ed25519.Sign(edKey, data)
+	rsaSig, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+	require.NoError(t, err)
+	ecSig, err := asn1.Marshal(ecdsaSignature{R: r, S: s})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		key  crypto.PublicKey
+		sig  []byte
+		err  bool
+	}{
+		{name: "ed25519", key: edKey.Public(), sig: edSig},
+		{name: "ed25519 base64", key: edKey.Public(), sig: []byte(base64.StdEncoding.EncodeToString(edSig) + "\n")},
+		{name: "rsa", key: rsaKey.Public(), sig: rsaSig},
+		{name: "ecdsa", key: ecKey.Public(), sig: ecSig},
+		{name: "wrong key", key: rsaKey.Public(), sig: edSig, err: true},
+		{name: "missing signature", key: edKey.Public(), err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &configServer{data: data, sig: tt.sig}
+			ts := httptest.NewServer(s)
+			defer ts.Close()
+
+			c := NewConfig()
+			c.SignatureKey = tt.key
+			err := c.LoadConfig(ts.URL + "/telegraf.conf")
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			// A changed config with the previous signature is rejected.
+			s.set([]byte(remoteConfigData+"  timeout = \"5s\"\n"), tt.sig)
+			_, err = c.RemoteConfigChanged()
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLoadSignatureKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signature")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	path := filepath.Join(dir, "key.pem")
+	require.NoError(t, ioutil.WriteFile(path,
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+
+	key, err := LoadSignatureKey(path)
+	require.NoError(t, err)
+	require.Equal(t, pub, key)
+
+	invalid := filepath.Join(dir, "invalid.pem")
+	require.NoError(t, ioutil.WriteFile(invalid, []byte("not a key"), 0600))
+	_, err = LoadSignatureKey(invalid)
+	require.Error(t, err)
+
+	_, err = LoadSignatureKey(filepath.Join(dir, "missing.pem"))
+	require.Error(t, err)
+}
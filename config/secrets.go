@@ -0,0 +1,174 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+	"github.com/influxdata/toml"
+	"github.com/influxdata/toml/ast"
+)
+
+// secretRe is a regex to find the secrets referenced in the config values,
+// such as "@{vault:kv/telegraf:password}".  The first part is the id of the
+// store and the rest the key of the secret in the store.
+var secretRe = regexp.MustCompile(`@\{(\w+):([^}]+)\}`)
+
+// secretStoreIDRe matches the valid ids of the secret stores.
+var secretStoreIDRe = regexp.MustCompile(`^\w+$`)
+
+// addSecretStores adds the secret stores of the secretstores table.
+func (c *Config) addSecretStores(node interface{}) error {
+	tbl, ok := node.(*ast.Table)
+	if !ok {
+		return fmt.Errorf("invalid configuration, error parsing field %q as table", "secretstores")
+	}
+
+	for name, val := range tbl.Fields {
+		tables, ok := val.([]*ast.Table)
+		if !ok {
+			return fmt.Errorf("Unsupported config format: %s", name)
+		}
+		for _, t := range tables {
+			if err := c.addSecretStore(name, t); err != nil {
+				if err = c.collect(t, fmt.Errorf("Error parsing %s, %s", name, err)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// addSecretStore creates and initializes the secret store of the table.  The
+// store is referenced by its id, which defaults to the name of the plugin.
+func (c *Config) addSecretStore(name string, tbl *ast.Table) error {
+	creator, ok := secretstores.SecretStores[name]
+	if !ok {
+		return fmt.Errorf("Undefined but requested secret store: %s", name)
+	}
+
+	id := name
+	if node, ok := tbl.Fields["id"]; ok {
+		kv, ok := node.(*ast.KeyValue)
+		if !ok {
+			return fmt.Errorf("id must be a string")
+		}
+		str, ok := kv.Value.(*ast.String)
+		if !ok || !secretStoreIDRe.MatchString(str.Value) {
+			return fmt.Errorf("id must be a string of letters, digits and underscores")
+		}
+		id = str.Value
+		delete(tbl.Fields, "id")
+	}
+	if _, ok := c.secretStores[id]; ok {
+		return fmt.Errorf("duplicate secret store id %q", id)
+	}
+
+	store := creator()
+	if err := toml.UnmarshalTable(tbl, store); err != nil {
+		return err
+	}
+	if init, ok := store.(telegraf.Initializer); ok {
+		if err := init.Init(); err != nil {
+			return err
+		}
+	}
+
+	c.secretStores[id] = store
+	return nil
+}
+
+// resolveSecrets replaces the secrets referenced in the string values of the
+// node with their value.
+func (c *Config) resolveSecrets(node interface{}) error {
+	switch node := node.(type) {
+	case *ast.Table:
+		for _, field := range node.Fields {
+			if err := c.resolveSecrets(field); err != nil {
+				return err
+			}
+		}
+	case []*ast.Table:
+		for _, tbl := range node {
+			if err := c.resolveSecrets(tbl); err != nil {
+				return err
+			}
+		}
+	case *ast.KeyValue:
+		if err := c.resolveSecrets(node.Value); err != nil {
+			return c.locateLine(node.Line, fmt.Errorf("option %q: %v", node.Key, err))
+		}
+	case *ast.Array:
+		for _, value := range node.Value {
+			if err := c.resolveSecrets(value); err != nil {
+				return err
+			}
+		}
+	case *ast.String:
+		var err error
+		value := secretRe.ReplaceAllStringFunc(node.Value, func(ref string) string {
+			if err != nil {
+				return ""
+			}
+			var secret string
+			secret, err = c.secret(ref)
+			return secret
+		})
+		if err != nil {
+			return err
+		}
+		node.Value = value
+	}
+	return nil
+}
+
+// secret returns the value of the referenced secret, which is retrieved from
+// its store once per config.
+func (c *Config) secret(ref string) (string, error) {
+	if value, ok := c.secrets[ref]; ok {
+		return value, nil
+	}
+
+	value, err := c.getSecret(ref)
+	if err != nil {
+		return "", err
+	}
+	c.secrets[ref] = value
+	return value, nil
+}
+
+// getSecret retrieves the value of the referenced secret from its store.
+func (c *Config) getSecret(ref string) (string, error) {
+	match := secretRe.FindStringSubmatch(ref)
+	store, ok := c.secretStores[match[1]]
+	if !ok {
+		return "", fmt.Errorf("unknown secret store %q in %s", match[1], ref)
+	}
+	value, err := store.Get(match[2])
+	if err != nil {
+		return "", fmt.Errorf("error getting secret %s: %v", ref, err)
+	}
+	return value, nil
+}
+
+// HasSecrets returns true if the config references secrets.
+func (c *Config) HasSecrets() bool {
+	return len(c.secrets) != 0
+}
+
+// SecretsChanged retrieves the secrets referenced by the config again and
+// returns true if a value changed since the config was loaded.
+func (c *Config) SecretsChanged() (bool, error) {
+	for ref, value := range c.secrets {
+		current, err := c.getSecret(ref)
+		if err != nil {
+			return false, err
+		}
+		if current != value {
+			return true, nil
+		}
+	}
+	return false, nil
+}
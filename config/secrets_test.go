@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	httpOut "github.com/influxdata/telegraf/plugins/outputs/http"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+	"github.com/stretchr/testify/require"
+)
+
+// mockStore is a secret store returning the secrets of its map.
+type mockStore struct {
+	Prefix string `toml:"prefix"`
+
+	secrets map[string]string
+}
+
+func (s *mockStore) SampleConfig() string { return "" }
+func (s *mockStore) Description() string  { return "" }
+
+func (s *mockStore) Get(key string) (string, error) {
+	value, ok := s.secrets[s.Prefix+key]
+	if !ok {
+		return "", fmt.Errorf("secret %q not found", key)
+	}
+	return value, nil
+}
+
+// mockSecrets holds the secrets of the mock stores.
+var mockSecrets = map[string]string{}
+
+func init() {
+	secretstores.Add("mock", func() telegraf.SecretStore {
+		return &mockStore{secrets: mockSecrets}
+	})
+}
+
+func TestConfig_Secrets(t *testing.T) {
+	mockSecrets["db_password"] = `pa"ss`
+	mockSecrets["host"] = "example.org"
+	mockSecrets["team/token"] = "abc"
+	defer func() {
+		for k := range mockSecrets {
+			delete(mockSecrets, k)
+		}
+	}()
+
+	c := NewConfig()
+	require.NoError(t, c.LoadConfigData([]byte(`
+[[secretstores.mock]]
+
+[[secretstores.mock]]
+  id = "team"
+  prefix = "team/"
+
+[[outputs.http]]
+  url = "https://@{mock:host}/write"
+  username = "telegraf"
+  password = "@{mock:db_password}"
+  [outputs.http.headers]
+    Authorization = "Bearer @{team:token}"
+`)))
+	require.Len(t, c.Outputs, 1)
+	output := c.Outputs[0].Output.(*httpOut.HTTP)
+	require.Equal(t, "https://example.org/write", output.URL)
+	require.Equal(t, `pa"ss`, output.Password)
+	require.Equal(t, map[string]string{"Authorization": "Bearer abc"}, output.Headers)
+	require.True(t, c.HasSecrets())
+
+	changed, err := c.SecretsChanged()
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	mockSecrets["team/token"] = "def"
+	changed, err = c.SecretsChanged()
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	delete(mockSecrets, "team/token")
+	_, err = c.SecretsChanged()
+	require.Error(t, err)
+}
+
+func TestConfig_SecretsErrors(t *testing.T) {
+	mockSecrets["db_password"] = "secret"
+	defer delete(mockSecrets, "db_password")
+
+	invalid := []string{
+		// unknown store
+		"[[outputs.http]]\n  url = \"http://localhost\"\n  password = \"@{vault:kv/telegraf:password}\"\n",
+		// missing secret
+		"[[secretstores.mock]]\n[[outputs.http]]\n  url = \"http://localhost\"\n  password = \"@{mock:missing}\"\n",
+		// duplicate id
+		"[[secretstores.mock]]\n[[secretstores.mock]]\n",
+		// invalid id
+		"[[secretstores.mock]]\n  id = \"my-store\"\n",
+		// unknown plugin
+		"[[secretstores.unknown]]\n",
+		// unknown option
+		"[[secretstores.mock]]\n  unknown = true\n",
+	}
+	for _, data := range invalid {
+		require.Error(t, NewConfig().LoadConfigData([]byte(data)), data)
+	}
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"fmt"
+)
+
+// PartialWriteError can be returned by the Write function of an output to
+// report that some of the metrics were rejected by the destination.
+//
+// The metrics at the indexes in MetricsReject are discarded and will not be
+// retried.  If Err is nil the remaining metrics were written successfully,
+// otherwise they are returned to the buffer and retried on the next write.
+type PartialWriteError struct {
+	Err error
+
+	// Indexes into the batch of the rejected metrics.
+	MetricsReject []int
+
+	// Optional reason for each rejected metric, in the same order as
+	// MetricsReject.
+	MetricsRejectErrors []error
+}
+
+func (e *PartialWriteError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%d metrics rejected: %v", len(e.MetricsReject), e.Err)
+	}
+	return fmt.Sprintf("%d metrics rejected", len(e.MetricsReject))
+}
+
+func (e *PartialWriteError) Unwrap() error {
+	return e.Err
+}
+
+// RejectAll returns a PartialWriteError rejecting all n metrics of a batch,
+// for writes refused permanently by the destination.  The reason is reported
+// for each metric.
+func RejectAll(n int, reason error) *PartialWriteError {
+	e := &PartialWriteError{
+		MetricsReject:       make([]int, 0, n),
+		MetricsRejectErrors: make([]error, 0, n),
+	}
+	for i := 0; i < n; i++ {
+		e.MetricsReject = append(e.MetricsReject, i)
+		e.MetricsRejectErrors = append(e.MetricsRejectErrors, reason)
+	}
+	return e
+}
+
+// AddRejected adds the metrics rejected by the write of a part of a batch,
+// indexes maps the indexes into the part to the indexes into the batch.
+func (e *PartialWriteError) AddRejected(partial *PartialWriteError, indexes []int) {
+	for i, index := range partial.MetricsReject {
+		if index < 0 || index >= len(indexes) {
+			continue
+		}
+
+		var reason error
+		if i < len(partial.MetricsRejectErrors) {
+			reason = partial.MetricsRejectErrors[i]
+		}
+		e.MetricsReject = append(e.MetricsReject, indexes[index])
+		e.MetricsRejectErrors = append(e.MetricsRejectErrors, reason)
+	}
+}
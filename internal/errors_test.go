@@ -0,0 +1,21 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartialWriteError_AddRejected(t *testing.T) {
+	reason := errors.New("invalid field format")
+	partial := RejectAll(2, reason)
+	require.Equal(t, []int{0, 1}, partial.MetricsReject)
+	require.Equal(t, []error{reason, reason}, partial.MetricsRejectErrors)
+
+	rejected := &PartialWriteError{}
+	rejected.AddRejected(partial, []int{1, 3})
+	rejected.AddRejected(&PartialWriteError{MetricsReject: []int{0, 5}}, []int{4})
+	require.Equal(t, []int{1, 3, 4}, rejected.MetricsReject)
+	require.Equal(t, []error{reason, reason, nil}, rejected.MetricsRejectErrors)
+}
@@ -0,0 +1,111 @@
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+var (
+	// healthPing is written to stdin of the process, it must answer with
+	// healthPong on stdout.  Both are line protocol comments.
+	healthPing = []byte("# ping\n")
+	healthPong = []byte("# pong")
+)
+
+// HealthCheck tracks the outstanding ping sent to the process.
+type HealthCheck struct {
+	mu      sync.Mutex
+	pending *time.Timer
+}
+
+// Ping writes a ping to the process, calling onTimeout if it is not answered
+// within the timeout.  No ping is sent while the previous one is pending.
+func (h *HealthCheck) Ping(write func([]byte) error, timeout time.Duration, onTimeout func()) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.pending != nil {
+		return nil
+	}
+
+	if err := write(healthPing); err != nil {
+		return err
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(timeout, func() {
+		h.mu.Lock()
+		expired := h.pending == timer
+		if expired {
+			h.pending = nil
+		}
+		h.mu.Unlock()
+
+		if expired {
+			onTimeout()
+		}
+	})
+	h.pending = timer
+	return nil
+}
+
+// Pong marks the pending ping as answered.
+func (h *HealthCheck) Pong() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.pending != nil {
+		h.pending.Stop()
+		h.pending = nil
+	}
+}
+
+// Reset discards the pending ping, e.g. when the process terminated.
+func (h *HealthCheck) Reset() {
+	h.Pong()
+}
+
+// Pending returns true while a ping is not answered.
+func (h *HealthCheck) Pending() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.pending != nil
+}
+
+// pongReader passes the output of the process through, except for the pong
+// lines answering health pings.
+type pongReader struct {
+	r      *bufio.Reader
+	onPong func()
+	buf    []byte
+	err    error
+}
+
+// NewPongReader returns a reader of the output of the process calling onPong
+// for each pong line instead of passing it through.
+func NewPongReader(r io.Reader, onPong func()) io.Reader {
+	return &pongReader{r: bufio.NewReader(r), onPong: onPong}
+}
+
+func (p *pongReader) Read(b []byte) (int, error) {
+	for len(p.buf) == 0 {
+		if p.err != nil {
+			return 0, p.err
+		}
+
+		line, err := p.r.ReadBytes('\n')
+		p.err = err
+		if bytes.Equal(bytes.TrimRight(line, "\r\n"), healthPong) {
+			p.onPong()
+			continue
+		}
+		p.buf = line
+	}
+
+	n := copy(b, p.buf)
+	p.buf = p.buf[n:]
+	return n, nil
+}
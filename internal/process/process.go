@@ -0,0 +1,170 @@
+// Package process supervises the long-running processes of the execd plugins,
+// restarting them after an unexpected termination and checking their health.
+package process
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Supervisor restarts a process after an unexpected termination.  The restart
+// delay doubles on each consecutive restart up to RestartDelayMax, and is
+// reset once the process ran for at least RestartDelayMax.  After MaxRestarts
+// consecutive restarts, unless 0, the supervisor gives up.
+//
+// With a HealthCheckInterval a ping is written to the process at this
+// interval, and the process is killed, and so restarted, when the ping is not
+// answered within HealthCheckTimeout.
+type Supervisor struct {
+	Command             []string
+	RestartDelay        time.Duration
+	RestartDelayMax     time.Duration
+	MaxRestarts         int
+	HealthCheckInterval time.Duration
+	HealthCheckTimeout  time.Duration
+
+	Log telegraf.Logger
+
+	// Start starts the process again once it terminated.
+	Start func() error
+	// Wait waits for the running process to terminate.
+	Wait func() error
+	// Terminated is called after an unexpected termination, if set.
+	Terminated func(err error)
+	// Stop stops the running process once the context is done, if set.
+	// Otherwise the process must terminate by itself.
+	Stop func()
+
+	// Write writes the health pings to the stdin of the running process,
+	// Kill kills the process when a ping is not answered.  The answers are
+	// passed to Health.Pong, ie. with a NewPongReader.
+	Write  func(b []byte) error
+	Kill   func()
+	Health *HealthCheck
+}
+
+// Run supervises the running process until the context is done and the
+// process terminated.  An error is returned when the supervisor gives up.
+func (s *Supervisor) Run(ctx context.Context) error {
+	var healthTick <-chan time.Time
+	if s.HealthCheckInterval > 0 {
+		ticker := time.NewTicker(s.HealthCheckInterval)
+		defer ticker.Stop()
+		healthTick = ticker.C
+	}
+
+	delay := s.RestartDelay
+	restarts := 0
+	for {
+		started := time.Now()
+
+		// Use a buffered channel to ensure goroutine below can exit
+		// if `ctx.Done` is selected and nothing reads on `done` anymore
+		done := make(chan error, 1)
+		go func() {
+			done <- s.Wait()
+		}()
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				s.resetHealth()
+				if s.Stop != nil {
+					s.Stop()
+				}
+				<-done
+				return nil
+			case <-healthTick:
+				s.ping()
+			case err := <-done:
+				s.resetHealth()
+				if isQuitting(ctx) {
+					return nil
+				}
+				s.Log.Errorf("Process %s terminated: %v", s.Command, err)
+				if s.Terminated != nil {
+					s.Terminated(err)
+				}
+				break wait
+			}
+		}
+
+		for {
+			// A process running longer than the maximum delay is
+			// considered healthy, so start over with the initial delay.
+			if time.Since(started) >= s.restartDelayMax() {
+				delay = s.RestartDelay
+				restarts = 0
+			}
+
+			if s.MaxRestarts > 0 && restarts >= s.MaxRestarts {
+				return fmt.Errorf("process %s restarted %d times, giving up", s.Command, restarts)
+			}
+			restarts++
+
+			s.Log.Infof("Restarting in %s...", delay)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(delay):
+			}
+			delay = nextDelay(delay, s.restartDelayMax())
+
+			started = time.Now()
+			err := s.Start()
+			if err == nil {
+				break
+			}
+			s.Log.Error(err)
+		}
+	}
+}
+
+// restartDelayMax returns the upper bound of the restart delay.
+func (s *Supervisor) restartDelayMax() time.Duration {
+	if s.RestartDelayMax < s.RestartDelay {
+		return s.RestartDelay
+	}
+	return s.RestartDelayMax
+}
+
+// nextDelay doubles the delay, limited to max.
+func nextDelay(delay, max time.Duration) time.Duration {
+	delay *= 2
+	if delay > max || delay <= 0 {
+		return max
+	}
+	return delay
+}
+
+// ping pings the running process, killing it if the ping is not answered in
+// time.  The process is then restarted by Run.
+func (s *Supervisor) ping() {
+	timeout := s.HealthCheckTimeout
+	err := s.Health.Ping(s.Write, timeout, func() {
+		s.Log.Errorf("Process %s did not answer health check within %s, killing it", s.Command, timeout)
+		s.Kill()
+	})
+	if err != nil {
+		s.Log.Errorf("Error writing health check to stdin: %v", err)
+	}
+}
+
+func (s *Supervisor) resetHealth() {
+	if s.Health != nil {
+		s.Health.Reset()
+	}
+}
+
+func isQuitting(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,99 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartBackoff(t *testing.T) {
+	require.Equal(t, 20*time.Second, nextDelay(10*time.Second, time.Minute))
+	require.Equal(t, 40*time.Second, nextDelay(20*time.Second, time.Minute))
+	require.Equal(t, time.Minute, nextDelay(40*time.Second, time.Minute))
+	require.Equal(t, time.Minute, nextDelay(time.Minute, time.Minute))
+}
+
+func TestSupervisor_HealthCheck(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	exited := make(chan error)
+	pings := make(chan string, 10)
+	var starts int
+	s := &Supervisor{
+		Command:             []string{"test"},
+		RestartDelay:        time.Millisecond,
+		HealthCheckInterval: 10 * time.Millisecond,
+		HealthCheckTimeout:  10 * time.Millisecond,
+		Log:                 testutil.Logger{},
+		Start: func() error {
+			// Stop once the process is restarted
+			starts++
+			cancel()
+			return nil
+		},
+		Wait: func() error {
+			return <-exited
+		},
+		Stop: func() {
+			exited <- nil
+		},
+		Write: func(b []byte) error {
+			pings <- string(b)
+			return nil
+		},
+		Kill: func() {
+			exited <- errors.New("killed")
+		},
+		Health: &HealthCheck{},
+	}
+
+	// The unanswered ping kills the process, which is restarted
+	require.NoError(t, s.Run(ctx))
+	require.Equal(t, 1, starts)
+	require.Equal(t, "# ping\n", <-pings)
+}
+
+func TestSupervisor_MaxRestarts(t *testing.T) {
+	var starts int
+	s := &Supervisor{
+		Command:         []string{"test"},
+		RestartDelay:    time.Millisecond,
+		RestartDelayMax: 10 * time.Millisecond,
+		MaxRestarts:     2,
+		Log:             testutil.Logger{},
+		Start: func() error {
+			starts++
+			return nil
+		},
+		Wait: func() error {
+			return errors.New("exit status 1")
+		},
+	}
+
+	require.EqualError(t, s.Run(context.Background()), "process [test] restarted 2 times, giving up")
+	require.Equal(t, 2, starts)
+}
+
+func TestPongReader(t *testing.T) {
+	var h HealthCheck
+	timedOut := make(chan bool, 1)
+	write := func(b []byte) error { return nil }
+	require.NoError(t, h.Ping(write, time.Second, func() {
+		timedOut <- true
+	}))
+	require.True(t, h.Pending())
+
+	r := NewPongReader(strings.NewReader("# pong\ncpu value=42\n"), h.Pong)
+	out, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "cpu value=42\n", string(out))
+	require.False(t, h.Pending())
+	require.Len(t, timedOut, 0)
+}
@@ -2,6 +2,7 @@ package rotate
 
 // Rotating things
 import (
+	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
@@ -11,6 +12,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/klauspost/compress/zstd"
 )
 
 // FilePerm defines the permissions that Writer will use for all
@@ -34,11 +37,36 @@ type FileWriter struct {
 	maxArchives              int
 	expireTime               time.Time
 	bytesWritten             int64
+	options                  Options
 	sync.Mutex
 }
 
+// Options are the optional settings of a FileWriter.
+type Options struct {
+	// Compression of the rotated files, "gzip" or "zstd".  The rotated files
+	// are not compressed when empty.
+	Compression string
+	// MaxAge is the age after which the rotated files are deleted, in
+	// addition to the maximum number of archives.  The rotated files are not
+	// deleted by age when 0.
+	MaxAge time.Duration
+	// KeepOnClose prevents the rotation of the file on Close.
+	KeepOnClose bool
+}
+
 // NewFileWriter creates a new file writer.
 func NewFileWriter(filename string, interval time.Duration, maxSizeInBytes int64, maxArchives int) (io.WriteCloser, error) {
+	return NewFileWriterWithOptions(filename, interval, maxSizeInBytes, maxArchives, Options{})
+}
+
+// NewFileWriterWithOptions creates a new file writer with optional settings.
+func NewFileWriterWithOptions(filename string, interval time.Duration, maxSizeInBytes int64, maxArchives int, options Options) (io.WriteCloser, error) {
+	switch options.Compression {
+	case "", "gzip", "zstd":
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", options.Compression)
+	}
+
 	if interval == 0 && maxSizeInBytes <= 0 {
 		// No rotation needed so a basic io.Writer will do the trick
 		return openFile(filename)
@@ -50,6 +78,7 @@ func NewFileWriter(filename string, interval time.Duration, maxSizeInBytes int64
 		maxSizeInBytes:           maxSizeInBytes,
 		maxArchives:              maxArchives,
 		filenameRotationTemplate: getFilenameRotationTemplate(filename),
+		options:                  options,
 	}
 
 	if err := w.openCurrent(); err != nil {
@@ -94,6 +123,12 @@ func (w *FileWriter) Close() (err error) {
 	w.Lock()
 	defer w.Unlock()
 
+	if w.options.KeepOnClose {
+		err = w.current.Close()
+		w.current = nil
+		return err
+	}
+
 	// Rotate before closing
 	if err = w.rotate(); err != nil {
 		return err
@@ -153,6 +188,12 @@ func (w *FileWriter) rotate() (err error) {
 		return err
 	}
 
+	if w.options.Compression != "" {
+		if err = compressFile(rotatedFilename, w.options.Compression); err != nil {
+			return err
+		}
+	}
+
 	if err = w.purgeArchivesIfNeeded(); err != nil {
 		return err
 	}
@@ -161,25 +202,91 @@ func (w *FileWriter) rotate() (err error) {
 }
 
 func (w *FileWriter) purgeArchivesIfNeeded() (err error) {
-	if w.maxArchives == -1 {
+	if w.maxArchives == -1 && w.options.MaxAge == 0 {
 		//Skip archiving
 		return nil
 	}
 
+	pattern := fmt.Sprintf(w.filenameRotationTemplate, "*", "*")
+	if w.options.Compression != "" {
+		// Match the archives with the extension of the compression
+		pattern += "*"
+	}
+
 	var matches []string
-	if matches, err = filepath.Glob(fmt.Sprintf(w.filenameRotationTemplate, "*", "*")); err != nil {
+	if matches, err = filepath.Glob(pattern); err != nil {
 		return err
 	}
 
+	//sort files alphanumerically to delete older files first
+	sort.Strings(matches)
+
 	//if there are more archives than the configured maximum, then purge older files
-	if len(matches) > w.maxArchives {
-		//sort files alphanumerically to delete older files first
-		sort.Strings(matches)
+	if w.maxArchives != -1 && len(matches) > w.maxArchives {
 		for _, filename := range matches[:len(matches)-w.maxArchives] {
 			if err = os.Remove(filename); err != nil {
 				return err
 			}
 		}
+		matches = matches[len(matches)-w.maxArchives:]
+	}
+
+	if w.options.MaxAge > 0 {
+		expired := time.Now().Add(-w.options.MaxAge)
+		for _, filename := range matches {
+			if info, err := os.Stat(filename); err == nil && info.ModTime().Before(expired) {
+				if err = os.Remove(filename); err != nil {
+					return err
+				}
+			}
+		}
 	}
 	return nil
 }
+
+// compressFile replaces the file by its compressed copy, with the extension
+// of the compression appended to its name.
+func compressFile(filename, compression string) error {
+	in, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if info, err := in.Stat(); err == nil && info.Size() == 0 {
+		return nil
+	}
+
+	ext := ".gz"
+	if compression == "zstd" {
+		ext = ".zst"
+	}
+	out, err := os.OpenFile(filename+ext, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, FilePerm)
+	if err != nil {
+		return err
+	}
+
+	var zw io.WriteCloser
+	if compression == "zstd" {
+		zw, err = zstd.NewWriter(out)
+		if err != nil {
+			out.Close()
+			return err
+		}
+	} else {
+		zw = gzip.NewWriter(out)
+	}
+
+	if _, err = io.Copy(zw, in); err == nil {
+		err = zw.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filename + ext)
+		return err
+	}
+	in.Close()
+	return os.Remove(filename)
+}
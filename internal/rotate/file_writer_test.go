@@ -1,12 +1,15 @@
 package rotate
 
 import (
+	"compress/gzip"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -146,3 +149,82 @@ func TestFileWriter_CloseRotates(t *testing.T) {
 	assert.Equal(t, 1, len(files))
 	assert.Regexp(t, "^test\\.[^\\.]+\\.log$", files[0].Name())
 }
+
+func TestFileWriter_Compression(t *testing.T) {
+	for _, compression := range []string{"gzip", "zstd"} {
+		t.Run(compression, func(t *testing.T) {
+			tempDir, err := ioutil.TempDir("", "RotationCompression")
+			require.NoError(t, err)
+			defer os.RemoveAll(tempDir)
+			writer, err := NewFileWriterWithOptions(filepath.Join(tempDir, "test.log"), 0, 5, -1,
+				Options{Compression: compression})
+			require.NoError(t, err)
+
+			_, err = writer.Write([]byte("Hello World"))
+			require.NoError(t, err)
+			require.NoError(t, writer.Close())
+
+			matches, err := filepath.Glob(filepath.Join(tempDir, "test.*.log.*"))
+			require.NoError(t, err)
+			require.Len(t, matches, 1)
+
+			f, err := os.Open(matches[0])
+			require.NoError(t, err)
+			defer f.Close()
+			var r io.Reader
+			if compression == "gzip" {
+				require.Equal(t, ".gz", filepath.Ext(matches[0]))
+				r, err = gzip.NewReader(f)
+			} else {
+				require.Equal(t, ".zst", filepath.Ext(matches[0]))
+				r, err = zstd.NewReader(f)
+			}
+			require.NoError(t, err)
+			content, err := ioutil.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, "Hello World", string(content))
+		})
+	}
+
+	_, err := NewFileWriterWithOptions("test.log", 0, 0, 0, Options{Compression: "lz4"})
+	require.Error(t, err)
+}
+
+func TestFileWriter_MaxAge(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "RotationMaxAge")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	old := filepath.Join(tempDir, "test.2020-01-01-1577836800.log")
+	require.NoError(t, ioutil.WriteFile(old, []byte("Old file"), 0644))
+	past := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(old, past, past))
+
+	writer, err := NewFileWriterWithOptions(filepath.Join(tempDir, "test.log"), 0, 5, -1,
+		Options{MaxAge: 24 * time.Hour})
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("Hello World"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	_, err = os.Stat(old)
+	require.True(t, os.IsNotExist(err))
+	files, _ := ioutil.ReadDir(tempDir)
+	require.NotEmpty(t, files)
+}
+
+func TestFileWriter_KeepOnClose(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "RotationKeepOnClose")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	writer, err := NewFileWriterWithOptions(filepath.Join(tempDir, "test.log"), 0, 100, -1,
+		Options{KeepOnClose: true})
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("Hello World"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	files, _ := ioutil.ReadDir(tempDir)
+	require.Equal(t, 1, len(files))
+	require.Equal(t, "test.log", files[0].Name())
+}
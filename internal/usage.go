@@ -11,11 +11,21 @@ Usage:
 The commands & flags are:
 
   config              print out full sample configuration to stdout
+  config validate     validate the configuration and initialize the plugins
+                      without starting them
+  simulate [file]     run the metrics in line protocol from the file or stdin
+                      through the processors and aggregators and print the
+                      metrics each output would receive
+  secrets encrypt <key file> [file]
+                      encrypt the JSON object of secrets from the file or
+                      stdin for the file secret store and print the result
   version             print the version to stdout
 
   --aggregator-filter <filter>   filter the aggregators to enable, separator is :
   --config <file>                configuration file to load
   --config-directory <directory> directory containing additional *.conf files
+  --config-signature-key <file>  PEM encoded public key verifying the detached
+                                 signatures of the config files loaded from a URL
   --plugin-directory             directory containing *.so files, this directory will be
                                  searched recursively. Any Plugin found will be loaded
                                  and namespaced.
@@ -47,6 +57,15 @@ Examples:
   # generate config with only cpu input & influxdb output plugins defined
   telegraf --input-filter cpu --output-filter influxdb config
 
+  # validate the configuration file, reporting all errors found
+  telegraf --config telegraf.conf config validate
+
+  # test the processors with sample metrics
+  telegraf --config telegraf.conf simulate metrics.txt
+
+  # create the encrypted file of the file secret store
+  telegraf secrets encrypt secrets.key secrets.json > secrets.enc
+
   # run a single telegraf collection, outputting metrics to stdout
   telegraf --config telegraf.conf --test
 
@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/wlog"
+)
+
+// sourceRegex matches the source of the message written after the level, such
+// as "[agent]" or "[inputs.cpu::alias]".
+var sourceRegex = regexp.MustCompile(`^\[([^\] ]+)\] ?`)
+
+var levelNames = map[byte]string{
+	'D': "debug",
+	'I': "info",
+	'W': "warn",
+	'E': "error",
+}
+
+var pluginTypes = map[string]bool{
+	"inputs":      true,
+	"outputs":     true,
+	"processors":  true,
+	"aggregators": true,
+}
+
+// Plugin identifies the plugin instance logging a message.
+type Plugin struct {
+	Type       string
+	Name       string
+	Alias      string
+	InstanceID int
+}
+
+// LogName returns the name of the plugin written in the text log format.
+func (p *Plugin) LogName() string {
+	if p.Alias == "" {
+		return p.Type + "." + p.Name
+	}
+	return p.Type + "." + p.Name + "::" + p.Alias
+}
+
+// entry is a log message in the JSON log format.
+type entry struct {
+	Time       string `json:"time"`
+	Level      string `json:"level"`
+	Source     string `json:"source,omitempty"`
+	PluginType string `json:"plugin_type,omitempty"`
+	PluginName string `json:"plugin_name,omitempty"`
+	Alias      string `json:"alias,omitempty"`
+	InstanceID int    `json:"instance_id,omitempty"`
+	Message    string `json:"msg"`
+}
+
+type jsonLog struct {
+	sync.Mutex
+	writer         io.Writer
+	internalWriter io.Writer
+}
+
+// newJSONWriter returns a writer writing each log message as a JSON object.
+func newJSONWriter(w io.Writer) io.Writer {
+	return &jsonLog{
+		writer:         w,
+		internalWriter: w,
+	}
+}
+
+// Write writes a message of the standard logger.  The plugin is read from the
+// source of the message when it is the name of a plugin.
+func (j *jsonLog) Write(b []byte) (int, error) {
+	msg := strings.TrimRight(string(b), "\n")
+
+	level := byte('I')
+	if prefixRegex.MatchString(msg) {
+		level = msg[0]
+		msg = strings.TrimPrefix(msg[2:], " ")
+	}
+
+	e := &entry{}
+	if match := sourceRegex.FindStringSubmatch(msg); match != nil {
+		msg = msg[len(match[0]):]
+		if !setPlugin(e, match[1]) {
+			e.Source = match[1]
+		}
+	}
+	e.Message = msg
+
+	if err := j.writeEntry(level, e); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// setPlugin sets the plugin of the entry from its log name, and returns false
+// if the name is not the name of a plugin.
+func setPlugin(e *entry, name string) bool {
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) != 2 || !pluginTypes[parts[0]] {
+		return false
+	}
+
+	e.PluginType = parts[0]
+	e.PluginName = parts[1]
+	if i := strings.Index(parts[1], "::"); i >= 0 {
+		e.PluginName = parts[1][:i]
+		e.Alias = parts[1][i+2:]
+	}
+	return true
+}
+
+func (j *jsonLog) writePlugin(level byte, plugin *Plugin, msg string) error {
+	return j.writeEntry(level, &entry{
+		PluginType: plugin.Type,
+		PluginName: plugin.Name,
+		Alias:      plugin.Alias,
+		InstanceID: plugin.InstanceID,
+		Message:    msg,
+	})
+}
+
+func (j *jsonLog) writeEntry(level byte, e *entry) error {
+	if wlog.Levels[level] < wlog.LogLevel() {
+		return nil
+	}
+
+	e.Time = time.Now().UTC().Format(time.RFC3339)
+	e.Level = levelNames[level]
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	j.Lock()
+	defer j.Unlock()
+	_, err = j.writer.Write(line)
+	return err
+}
+
+func (j *jsonLog) Close() error {
+	var stdErrWriter io.Writer
+	stdErrWriter = os.Stderr
+	// avoid closing stderr
+	if j.internalWriter != stdErrWriter {
+		closer, isCloser := j.internalWriter.(io.Closer)
+		if !isCloser {
+			return errors.New("the underlying writer cannot be closed")
+		}
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func readEntries(t *testing.T, filename string) []map[string]interface{} {
+	f, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(f)), "\n") {
+		var e map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &e))
+		require.NotEmpty(t, e["time"])
+		delete(e, "time")
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestWriteJSONLogToFile(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer func() { os.Remove(tmpfile.Name()) }()
+
+	config := createBasicLogConfig(tmpfile.Name())
+	config.LogFormat = LogFormatJSON
+	SetupLogging(config)
+	defer SetupLogging(LogConfig{})
+
+	log.Printf("I! [agent] Starting")
+	log.Printf("D! TEST") // <- should be ignored
+	log.Printf("E! [inputs.cpu::host] failed")
+	log.Printf("TEST")
+	PrintPlugin('W', &Plugin{Type: "outputs", Name: "file", InstanceID: 2}, "slow")
+	PrintPlugin('D', &Plugin{Type: "outputs", Name: "file", InstanceID: 2}, "ignored")
+
+	require.Equal(t, []map[string]interface{}{
+		{"level": "info", "source": "agent", "msg": "Starting"},
+		{"level": "error", "plugin_type": "inputs", "plugin_name": "cpu", "alias": "host", "msg": "failed"},
+		{"level": "info", "msg": "TEST"},
+		{"level": "warn", "plugin_type": "outputs", "plugin_name": "file", "instance_id": 2.0, "msg": "slow"},
+	}, readEntries(t, tmpfile.Name()))
+}
+
+func TestPrintPluginText(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer SetupLogging(LogConfig{})
+
+	PrintPlugin('E', &Plugin{Type: "inputs", Name: "cpu", Alias: "host", InstanceID: 1}, "failed")
+	require.Equal(t, "E! [inputs.cpu::host] failed\n", buf.String())
+}
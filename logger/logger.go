@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf/internal"
@@ -18,6 +19,9 @@ var prefixRegex = regexp.MustCompile("^[DIWE]!")
 const (
 	LogTargetFile   = "file"
 	LogTargetStderr = "stderr"
+
+	LogFormatText = "text"
+	LogFormatJSON = "json"
 )
 
 // LogConfig contains the log configuration settings
@@ -38,6 +42,9 @@ type LogConfig struct {
 	RotationMaxSize internal.Size
 	// maximum rotated files to keep (older ones will be deleted)
 	RotationMaxArchives int
+	// text or json, json writes each message as an object carrying the
+	// plugin that logged it
+	LogFormat string
 }
 
 type LoggerCreator interface {
@@ -120,12 +127,20 @@ func (t *telegrafLogCreator) CreateLogger(config LogConfig) (io.Writer, error) {
 		writer = defaultWriter
 	}
 
+	switch config.LogFormat {
+	case LogFormatJSON:
+		return newJSONWriter(writer), nil
+	case LogFormatText, "":
+	default:
+		log.Printf("E! Unsupported logformat: %s, using text", config.LogFormat)
+	}
 	return newTelegrafWriter(writer), nil
 }
 
 // Keep track what is actually set as a log output, because log package doesn't provide a getter.
 // It allows closing previous writer if re-set and have possibility to test what is actually set
 var actualLogger io.Writer
+var actualLoggerMu sync.RWMutex
 
 func newLogWriter(config LogConfig) io.Writer {
 	log.SetFlags(0)
@@ -146,6 +161,8 @@ func newLogWriter(config LogConfig) io.Writer {
 		logWriter, _ = (&telegrafLogCreator{}).CreateLogger(config)
 	}
 
+	actualLoggerMu.Lock()
+	defer actualLoggerMu.Unlock()
 	if closer, isCloser := actualLogger.(io.Closer); isCloser {
 		closer.Close()
 	}
@@ -155,6 +172,19 @@ func newLogWriter(config LogConfig) io.Writer {
 	return logWriter
 }
 
+// PrintPlugin logs the message of the plugin at the level, one of 'D', 'I',
+// 'W' or 'E'.  The JSON log format writes the plugin as fields of the message.
+func PrintPlugin(level byte, plugin *Plugin, msg string) {
+	actualLoggerMu.RLock()
+	w, ok := actualLogger.(*jsonLog)
+	actualLoggerMu.RUnlock()
+	if ok {
+		w.writePlugin(level, plugin, msg)
+		return
+	}
+	log.Print(string(level) + "! [" + plugin.LogName() + "] " + msg)
+}
+
 func init() {
 	tlc := &telegrafLogCreator{}
 	registerLogger("", tlc)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"hash/fnv"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -19,6 +20,18 @@ type metric struct {
 	aggregate bool
 }
 
+// maxPooledLength is the maximum number of tags or fields of the metrics
+// kept in the pool, larger metrics are left to the garbage collector.
+const maxPooledLength = 64
+
+// pool holds the released metrics reused by New, along with their tags and
+// fields.
+var pool = sync.Pool{
+	New: func() interface{} {
+		return &metric{}
+	},
+}
+
 func New(
 	name string,
 	tags map[string]string,
@@ -33,35 +46,132 @@ func New(
 		vtype = telegraf.Untyped
 	}
 
-	m := &metric{
-		name:   name,
-		tags:   nil,
-		fields: nil,
-		tm:     tm,
-		tp:     vtype,
+	m := pool.Get().(*metric)
+	m.name = name
+	m.tm = tm
+	m.tp = vtype
+
+	m.tags = makeTags(m.tags, len(tags))
+	i := 0
+	for k, v := range tags {
+		m.tags[i].Key = k
+		m.tags[i].Value = v
+		i++
+	}
+	sortTags(m.tags)
+
+	// The keys of the map are unique, so the fields are set without looking
+	// for existing ones as AddField does.
+	m.fields = makeFields(m.fields, len(fields))
+	i = 0
+	for k, v := range fields {
+		v := convertField(v)
+		if v == nil {
+			continue
+		}
+		m.fields[i].Key = k
+		m.fields[i].Value = v
+		i++
+	}
+	m.fields = m.fields[:i]
+
+	return m, nil
+}
+
+// Release returns a metric created by New to the pool reused by New.  It is
+// only safe for a metric dropped before being passed to any other plugin, as
+// neither the metric nor its tags and fields may be referenced afterwards.
+// Metrics of other types are ignored.
+func Release(m telegraf.Metric) {
+	mm, ok := m.(*metric)
+	if !ok || cap(mm.tags) > maxPooledLength || cap(mm.fields) > maxPooledLength {
+		return
 	}
 
-	if len(tags) > 0 {
-		m.tags = make([]*telegraf.Tag, 0, len(tags))
-		for k, v := range tags {
-			m.tags = append(m.tags,
-				&telegraf.Tag{Key: k, Value: v})
+	// Clear the tags and fields kept past the length of the lists too, so the
+	// pool does not retain their values.
+	tags := mm.tags[:cap(mm.tags)]
+	for _, tag := range tags {
+		if tag != nil {
+			*tag = telegraf.Tag{}
+		}
+	}
+	fields := mm.fields[:cap(mm.fields)]
+	for _, field := range fields {
+		if field != nil {
+			*field = telegraf.Field{}
 		}
-		sort.Slice(m.tags, func(i, j int) bool { return m.tags[i].Key < m.tags[j].Key })
 	}
 
-	if len(fields) > 0 {
-		m.fields = make([]*telegraf.Field, 0, len(fields))
-		for k, v := range fields {
-			v := convertField(v)
-			if v == nil {
-				continue
-			}
-			m.AddField(k, v)
+	*mm = metric{
+		tags:   tags[:0],
+		fields: fields[:0],
+	}
+	pool.Put(mm)
+}
+
+// sortTags sorts the tags by key.  Metrics have few tags, which an insertion
+// sort orders without the allocations of sort.Slice.
+func sortTags(tags []*telegraf.Tag) {
+	if len(tags) > 32 {
+		sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+		return
+	}
+	for i := 1; i < len(tags); i++ {
+		for j := i; j > 0 && tags[j].Key < tags[j-1].Key; j-- {
+			tags[j], tags[j-1] = tags[j-1], tags[j]
 		}
 	}
+}
 
-	return m, nil
+// makeTags returns a list of n tags, reusing the tags allocated in list and
+// allocating the missing ones together rather than one at a time.
+func makeTags(list []*telegraf.Tag, n int) []*telegraf.Tag {
+	if n == 0 {
+		return list[:0]
+	}
+	if cap(list) < n {
+		list = append(list[:cap(list)], make([]*telegraf.Tag, n-cap(list))...)
+	}
+	list = list[:n]
+
+	var tags []telegraf.Tag
+	for i := range list {
+		if list[i] != nil {
+			continue
+		}
+		if len(tags) == 0 {
+			tags = make([]telegraf.Tag, n-i)
+		}
+		list[i] = &tags[0]
+		tags = tags[1:]
+	}
+	return list
+}
+
+// makeFields returns a list of n fields, reusing the fields allocated in list
+// and allocating the missing ones together rather than one at a time.
+func makeFields(list []*telegraf.Field, n int) []*telegraf.Field {
+	if n == 0 {
+		return list[:0]
+	}
+	if cap(list) < n {
+		list = append(list[:cap(list)], make([]*telegraf.Field, n-cap(list))...)
+	}
+	list = list[:n]
+
+	var fields []telegraf.Field
+	for i := range list {
+		if list[i] != nil {
+			continue
+		}
+		if len(fields) == 0 {
+			fields = make([]telegraf.Field, n-i)
+		}
+		list[i] = &fields[0]
+		fields = fields[1:]
+	}
+	return list
 }
 
 // FromMetric returns a deep copy of the metric with any tracking information
@@ -69,19 +179,19 @@ func New(
 func FromMetric(other telegraf.Metric) telegraf.Metric {
 	m := &metric{
 		name:      other.Name(),
-		tags:      make([]*telegraf.Tag, len(other.TagList())),
-		fields:    make([]*telegraf.Field, len(other.FieldList())),
+		tags:      makeTags(nil, len(other.TagList())),
+		fields:    makeFields(nil, len(other.FieldList())),
 		tm:        other.Time(),
 		tp:        other.Type(),
 		aggregate: other.IsAggregate(),
 	}
 
 	for i, tag := range other.TagList() {
-		m.tags[i] = &telegraf.Tag{Key: tag.Key, Value: tag.Value}
+		*m.tags[i] = *tag
 	}
 
 	for i, field := range other.FieldList() {
-		m.fields[i] = &telegraf.Field{Key: field.Key, Value: field.Value}
+		*m.fields[i] = *field
 	}
 	return m
 }
@@ -234,19 +344,19 @@ func (m *metric) SetTime(t time.Time) {
 func (m *metric) Copy() telegraf.Metric {
 	m2 := &metric{
 		name:      m.name,
-		tags:      make([]*telegraf.Tag, len(m.tags)),
-		fields:    make([]*telegraf.Field, len(m.fields)),
+		tags:      makeTags(nil, len(m.tags)),
+		fields:    makeFields(nil, len(m.fields)),
 		tm:        m.tm,
 		tp:        m.tp,
 		aggregate: m.aggregate,
 	}
 
 	for i, tag := range m.tags {
-		m2.tags[i] = &telegraf.Tag{Key: tag.Key, Value: tag.Value}
+		*m2.tags[i] = *tag
 	}
 
 	for i, field := range m.fields {
-		m2.fields[i] = &telegraf.Field{Key: field.Key, Value: field.Value}
+		*m2.fields[i] = *field
 	}
 	return m2
 }
@@ -286,6 +396,10 @@ func convertField(v interface{}) interface{} {
 	switch v := v.(type) {
 	case float64:
 		return v
+	case map[string]interface{}:
+		return convertMap(v)
+	case []interface{}:
+		return convertSlice(v)
 	case int64:
 		return v
 	case string:
@@ -340,3 +340,70 @@ func TestCopyAggregate(t *testing.T) {
 	m2 := m1.Copy()
 	assert.True(t, m2.IsAggregate())
 }
+
+func TestCopyIndependent(t *testing.T) {
+	m1 := baseMetric()
+	m2 := m1.Copy()
+	m2.AddTag("host", "localhost")
+	m2.AddField("value", int64(43))
+
+	assert.False(t, m1.HasTag("host"))
+	f, _ := m1.GetField("value")
+	assert.Equal(t, float64(1), f)
+	v, _ := m2.GetTag("host")
+	assert.Equal(t, "localhost", v)
+	f, _ = m2.GetField("value")
+	assert.Equal(t, int64(43), f)
+}
+
+func TestRelease(t *testing.T) {
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		m, err := New("cpu",
+			map[string]string{"host": "localhost", "cpu": "cpu0", "dc": "us-east-1"},
+			map[string]interface{}{"idle": 42, "user": 3.14, "nil": (*int64)(nil)},
+			now)
+		assert.NoError(t, err)
+		m.RemoveTag("cpu")
+		m.AddTag("rack", "1")
+		Release(m)
+
+		m, err = New("mem",
+			map[string]string{"host": "localhost"},
+			map[string]interface{}{"free": uint64(1)},
+			now, telegraf.Gauge)
+		assert.NoError(t, err)
+		assert.Equal(t, "mem", m.Name())
+		assert.Equal(t, map[string]string{"host": "localhost"}, m.Tags())
+		assert.Equal(t, map[string]interface{}{"free": uint64(1)}, m.Fields())
+		assert.Equal(t, telegraf.Gauge, m.Type())
+		assert.False(t, m.IsAggregate())
+		Release(m)
+
+		m, err = New("empty", nil, nil, now)
+		assert.NoError(t, err)
+		assert.Empty(t, m.TagList())
+		assert.Empty(t, m.FieldList())
+		Release(m)
+	}
+}
+
+func BenchmarkNew(b *testing.B) {
+	tags := map[string]string{"host": "localhost", "cpu": "cpu0", "dc": "us-east-1"}
+	fields := map[string]interface{}{"idle": 42.0, "user": 3.14, "system": 1.0}
+	now := time.Now()
+
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			New("cpu", tags, fields, now)
+		}
+	})
+	b.Run("release", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m, _ := New("cpu", tags, fields, now)
+			Release(m)
+		}
+	})
+}
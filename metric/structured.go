@@ -0,0 +1,96 @@
+package metric
+
+import (
+	"strconv"
+
+	"github.com/influxdata/telegraf"
+)
+
+// FlattenSeparator joins the keys of the path of the values flattened from
+// the structured fields, as the JSON parser flattens the documents.
+const FlattenSeparator = "_"
+
+// convertMap converts the values of a map field, dropping the values that
+// cannot be converted.
+func convertMap(in map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(in))
+	for k, v := range in {
+		if v := convertField(v); v != nil {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// convertSlice converts the items of an array field, the items that cannot be
+// converted are nil to keep the position of the others.
+func convertSlice(in []interface{}) []interface{} {
+	out := make([]interface{}, len(in))
+	for i, v := range in {
+		out[i] = convertField(v)
+	}
+	return out
+}
+
+// IsStructured returns true if the field value is an array or a map.
+func IsStructured(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// HasStructuredFields returns true if a field of the metric holds an array
+// or a map.
+func HasStructuredFields(m telegraf.Metric) bool {
+	for _, field := range m.FieldList() {
+		if IsStructured(field.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// FlattenFields replaces the fields holding arrays and maps by a field per
+// value, named after the path of the value joined by the separator.  The
+// structured values are shared between the copies of a metric, they are
+// replaced and never modified.
+func FlattenFields(m telegraf.Metric, separator string) {
+	if !HasStructuredFields(m) {
+		return
+	}
+
+	flat := make(map[string]interface{})
+	var keys []string
+	for _, field := range m.FieldList() {
+		if IsStructured(field.Value) {
+			keys = append(keys, field.Key)
+			flattenValue(field.Key, field.Value, separator, flat)
+		}
+	}
+
+	for _, key := range keys {
+		m.RemoveField(key)
+	}
+	for key, value := range flat {
+		m.AddField(key, value)
+	}
+}
+
+func flattenValue(key string, value interface{}, separator string, flat map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			flattenValue(key+separator+k, item, separator, flat)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flattenValue(key+separator+strconv.Itoa(i), item, separator, flat)
+		}
+	case nil:
+	default:
+		flat[key] = v
+	}
+}
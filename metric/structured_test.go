@@ -0,0 +1,56 @@
+package metric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructuredFieldsConverted(t *testing.T) {
+	m, err := New("test", nil, map[string]interface{}{
+		"labels": []interface{}{"a", 1, nil, struct{}{}},
+		"owner": map[string]interface{}{
+			"id":    uint8(3),
+			"name":  "x",
+			"extra": struct{}{},
+			"none":  nil,
+		},
+	}, time.Unix(0, 0))
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]interface{}{
+		"labels": []interface{}{"a", int64(1), nil, nil},
+		"owner": map[string]interface{}{
+			"id":   uint64(3),
+			"name": "x",
+		},
+	}, m.Fields())
+	require.True(t, HasStructuredFields(m))
+}
+
+func TestFlattenFields(t *testing.T) {
+	m, err := New("test", nil, map[string]interface{}{
+		"value": 1.0,
+		"labels": []interface{}{
+			"a",
+			nil,
+			map[string]interface{}{"b": true},
+		},
+		"owner": map[string]interface{}{
+			"name":  "x",
+			"teams": []interface{}{"y"},
+		},
+	}, time.Unix(0, 0))
+	require.NoError(t, err)
+
+	FlattenFields(m, FlattenSeparator)
+	require.Equal(t, map[string]interface{}{
+		"value":         1.0,
+		"labels_0":      "a",
+		"labels_2_b":    true,
+		"owner_name":    "x",
+		"owner_teams_0": "y",
+	}, m.Fields())
+	require.False(t, HasStructuredFields(m))
+}
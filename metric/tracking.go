@@ -15,13 +15,36 @@ type NotifyFunc = func(track telegraf.DeliveryInfo)
 // WithTracking adds tracking to the metric and registers the notify function
 // to be called when processing is complete.
 func WithTracking(metric telegraf.Metric, fn NotifyFunc) (telegraf.Metric, telegraf.TrackingID) {
-	return newTrackingMetric(metric, fn)
+	return newTrackingMetric(metric, nil, fn)
 }
 
 // WithBatchTracking adds tracking to the metrics and registers the notify
 // function to be called when processing is complete.
 func WithGroupTracking(metric []telegraf.Metric, fn NotifyFunc) ([]telegraf.Metric, telegraf.TrackingID) {
-	return newTrackingMetricGroup(metric, fn)
+	return newTrackingMetricGroup(metric, nil, fn)
+}
+
+// WithOutputTracking is like WithTracking, but only rejections by the
+// outputs, identified by their alias or name, prevent the delivery.  The
+// rejections by other outputs are ignored.
+func WithOutputTracking(metric telegraf.Metric, outputs []string, fn NotifyFunc) (telegraf.Metric, telegraf.TrackingID) {
+	return newTrackingMetric(metric, outputs, fn)
+}
+
+// WithOutputGroupTracking is like WithGroupTracking, but only rejections by
+// the outputs, identified by their alias or name, prevent the delivery.  The
+// rejections by other outputs are ignored.
+func WithOutputGroupTracking(metric []telegraf.Metric, outputs []string, fn NotifyFunc) ([]telegraf.Metric, telegraf.TrackingID) {
+	return newTrackingMetricGroup(metric, outputs, fn)
+}
+
+// RejectBy marks the metric as rejected by the output, identified by its
+// alias or name.
+func RejectBy(metric telegraf.Metric, output string) {
+	if m, ok := metric.(*trackingMetric); ok {
+		m.d.rejectBy(output)
+	}
+	metric.Reject()
 }
 
 func EnableDebugFinalizer() {
@@ -50,6 +73,24 @@ type trackingData struct {
 	acceptCount int32
 	rejectCount int32
 	notifyFunc  NotifyFunc
+
+	// outputs holds the number of metrics rejected by each of the outputs
+	// preventing the delivery, when nil a rejection by any output does.
+	outputs map[string]*int32
+}
+
+func newTrackingData(outputs []string, fn NotifyFunc) *trackingData {
+	d := &trackingData{
+		id:         newTrackingID(),
+		notifyFunc: fn,
+	}
+	if len(outputs) > 0 {
+		d.outputs = make(map[string]*int32, len(outputs))
+		for _, output := range outputs {
+			d.outputs[output] = new(int32)
+		}
+	}
+	return d
 }
 
 func (d *trackingData) incr() {
@@ -68,32 +109,49 @@ func (d *trackingData) reject() {
 	atomic.AddInt32(&d.rejectCount, 1)
 }
 
+func (d *trackingData) rejectBy(output string) {
+	if rejected, ok := d.outputs[output]; ok {
+		atomic.AddInt32(rejected, 1)
+	}
+}
+
 func (d *trackingData) notify() {
 	d.notifyFunc(
 		&deliveryInfo{
-			id:       d.id,
-			accepted: int(d.acceptCount),
-			rejected: int(d.rejectCount),
+			id:        d.id,
+			accepted:  int(atomic.LoadInt32(&d.acceptCount)),
+			rejected:  int(atomic.LoadInt32(&d.rejectCount)),
+			delivered: d.delivered(),
 		},
 	)
 }
 
+// delivered returns true if none of the metrics were rejected by the outputs
+// preventing the delivery.
+func (d *trackingData) delivered() bool {
+	if d.outputs == nil {
+		return atomic.LoadInt32(&d.rejectCount) == 0
+	}
+
+	for _, rejected := range d.outputs {
+		if atomic.LoadInt32(rejected) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
 type trackingMetric struct {
 	telegraf.Metric
 	d *trackingData
 }
 
-func newTrackingMetric(metric telegraf.Metric, fn NotifyFunc) (telegraf.Metric, telegraf.TrackingID) {
+func newTrackingMetric(metric telegraf.Metric, outputs []string, fn NotifyFunc) (telegraf.Metric, telegraf.TrackingID) {
 	m := &trackingMetric{
 		Metric: metric,
-		d: &trackingData{
-			id:          newTrackingID(),
-			rc:          1,
-			acceptCount: 0,
-			rejectCount: 0,
-			notifyFunc:  fn,
-		},
+		d:      newTrackingData(outputs, fn),
 	}
+	m.d.rc = 1
 
 	if finalizer != nil {
 		runtime.SetFinalizer(m.d, finalizer)
@@ -101,14 +159,8 @@ func newTrackingMetric(metric telegraf.Metric, fn NotifyFunc) (telegraf.Metric,
 	return m, m.d.id
 }
 
-func newTrackingMetricGroup(group []telegraf.Metric, fn NotifyFunc) ([]telegraf.Metric, telegraf.TrackingID) {
-	d := &trackingData{
-		id:          newTrackingID(),
-		rc:          0,
-		acceptCount: 0,
-		rejectCount: 0,
-		notifyFunc:  fn,
-	}
+func newTrackingMetricGroup(group []telegraf.Metric, outputs []string, fn NotifyFunc) ([]telegraf.Metric, telegraf.TrackingID) {
+	d := newTrackingData(outputs, fn)
 
 	for i, m := range group {
 		d.incr()
@@ -130,6 +182,11 @@ func newTrackingMetricGroup(group []telegraf.Metric, fn NotifyFunc) ([]telegraf.
 	return group, d.id
 }
 
+// TrackingID returns the ID of the tracked metric group.
+func (m *trackingMetric) TrackingID() telegraf.TrackingID {
+	return m.d.id
+}
+
 func (m *trackingMetric) Copy() telegraf.Metric {
 	m.d.incr()
 	return &trackingMetric{
@@ -164,9 +221,10 @@ func (m *trackingMetric) decr() {
 }
 
 type deliveryInfo struct {
-	id       telegraf.TrackingID
-	accepted int
-	rejected int
+	id        telegraf.TrackingID
+	accepted  int
+	rejected  int
+	delivered bool
 }
 
 func (r *deliveryInfo) ID() telegraf.TrackingID {
@@ -174,5 +232,5 @@ func (r *deliveryInfo) ID() telegraf.TrackingID {
 }
 
 func (r *deliveryInfo) Delivered() bool {
-	return r.rejected == 0
+	return r.delivered
 }
@@ -296,3 +296,66 @@ func TestGroupTracking(t *testing.T) {
 		})
 	}
 }
+
+func TestOutputGroupTracking(t *testing.T) {
+	tests := []struct {
+		name      string
+		actions   func(metrics []telegraf.Metric)
+		delivered bool
+	}{
+		{
+			name: "accept",
+			actions: func(metrics []telegraf.Metric) {
+				metrics[0].Accept()
+				metrics[1].Accept()
+			},
+			delivered: true,
+		},
+		{
+			name: "reject by other output",
+			actions: func(metrics []telegraf.Metric) {
+				metrics[0].Accept()
+				RejectBy(metrics[1], "other")
+			},
+			delivered: true,
+		},
+		{
+			name: "reject by required output",
+			actions: func(metrics []telegraf.Metric) {
+				metrics[0].Accept()
+				RejectBy(metrics[1], "primary")
+			},
+			delivered: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &deliveries{
+				Info: make(map[telegraf.TrackingID]telegraf.DeliveryInfo),
+			}
+			group := []telegraf.Metric{
+				mustMetric(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 42,
+					},
+					time.Unix(0, 0),
+				),
+				mustMetric(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 42,
+					},
+					time.Unix(0, 0),
+				),
+			}
+			metrics, id := WithOutputGroupTracking(group, []string{"primary"}, d.onDelivery)
+			tt.actions(metrics)
+
+			info := d.Info[id]
+			require.Equal(t, tt.delivered, info.Delivered())
+		})
+	}
+}
@@ -1,20 +1,43 @@
 package models
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
 var (
-	AgentMetricsWritten = selfstat.Register("agent", "metrics_written", map[string]string{})
-	AgentMetricsDropped = selfstat.Register("agent", "metrics_dropped", map[string]string{})
+	AgentMetricsWritten  = selfstat.Register("agent", "metrics_written", map[string]string{})
+	AgentMetricsDropped  = selfstat.Register("agent", "metrics_dropped", map[string]string{})
+	AgentMetricsRejected = selfstat.Register("agent", "metrics_rejected", map[string]string{})
 )
 
-// Buffer stores metrics in a circular buffer.
+// Buffer stores metrics in a circular buffer per priority level.  The levels
+// share the capacity of the buffer; when it is full the oldest of the lowest
+// priority metrics is dropped.
 type Buffer struct {
 	sync.Mutex
+	output string // alias, or name, of the output
+
+	levels []*ring // the rings of the priority levels, lowest first
+	cap    int     // the capacity of the buffer
+
+	MetricsAdded    selfstat.Stat
+	MetricsWritten  selfstat.Stat
+	MetricsDropped  selfstat.Stat
+	MetricsRejected selfstat.Stat
+	BufferSize      selfstat.Stat
+	BufferLimit     selfstat.Stat
+}
+
+// ring is the circular buffer of the metrics of one priority level.
+type ring struct {
+	priority int
+	dropped  func(telegraf.Metric)
+
 	buf   []telegraf.Metric
 	first int // index of the first/oldest metric
 	last  int // one after the index of the last/newest metric
@@ -23,12 +46,6 @@ type Buffer struct {
 
 	batchFirst int // index of the first metric in the batch
 	batchSize  int // number of metrics currently in the batch
-
-	MetricsAdded   selfstat.Stat
-	MetricsWritten selfstat.Stat
-	MetricsDropped selfstat.Stat
-	BufferSize     selfstat.Stat
-	BufferLimit    selfstat.Stat
 }
 
 // NewBuffer returns a new empty Buffer with the given capacity.
@@ -39,11 +56,9 @@ func NewBuffer(name string, alias string, capacity int) *Buffer {
 	}
 
 	b := &Buffer{
-		buf:   make([]telegraf.Metric, capacity),
-		first: 0,
-		last:  0,
-		size:  0,
-		cap:   capacity,
+		output: outputID(name, alias),
+
+		cap: capacity,
 
 		MetricsAdded: selfstat.Register(
 			"write",
@@ -60,6 +75,11 @@ func NewBuffer(name string, alias string, capacity int) *Buffer {
 			"metrics_dropped",
 			tags,
 		),
+		MetricsRejected: selfstat.Register(
+			"write",
+			"metrics_rejected",
+			tags,
+		),
 		BufferSize: selfstat.Register(
 			"write",
 			"buffer_size",
@@ -71,11 +91,62 @@ func NewBuffer(name string, alias string, capacity int) *Buffer {
 			tags,
 		),
 	}
+	b.levels = []*ring{b.newRing(0)}
 	b.BufferSize.Set(int64(0))
 	b.BufferLimit.Set(int64(capacity))
 	return b
 }
 
+// outputID returns the identifier of an output in the tracking of metrics,
+// its alias if set and otherwise its name.
+func outputID(name, alias string) string {
+	if alias != "" {
+		return alias
+	}
+	return name
+}
+
+func (b *Buffer) newRing(priority int) *ring {
+	return &ring{
+		priority: priority,
+		dropped:  b.metricDropped,
+
+		buf:   make([]telegraf.Metric, b.cap),
+		first: 0,
+		last:  0,
+		size:  0,
+		cap:   b.cap,
+	}
+}
+
+// level returns the ring of the priority, the ring is created when the first
+// metric of the priority is added.
+func (b *Buffer) level(priority int) *ring {
+	i := sort.Search(len(b.levels), func(i int) bool {
+		return b.levels[i].priority >= priority
+	})
+	if i < len(b.levels) && b.levels[i].priority == priority {
+		return b.levels[i]
+	}
+
+	r := b.newRing(priority)
+	b.levels = append(b.levels, nil)
+	copy(b.levels[i+1:], b.levels[i:])
+	b.levels[i] = r
+	return r
+}
+
+// lowest returns the ring of the lowest priority holding metrics, or nil if
+// the buffer is empty.
+func (b *Buffer) lowest() *ring {
+	for _, r := range b.levels {
+		if r.size > 0 {
+			return r
+		}
+	}
+	return nil
+}
+
 // Len returns the number of metrics currently in the buffer.
 func (b *Buffer) Len() int {
 	b.Lock()
@@ -85,7 +156,20 @@ func (b *Buffer) Len() int {
 }
 
 func (b *Buffer) length() int {
-	return min(b.size+b.batchSize, b.cap)
+	n := 0
+	for _, r := range b.levels {
+		n += r.size + r.batchSize
+	}
+	return min(n, b.cap)
+}
+
+// size returns the number of metrics in the buffer, not counting the batch.
+func (b *Buffer) size() int {
+	n := 0
+	for _, r := range b.levels {
+		n += r.size
+	}
+	return n
 }
 
 func (b *Buffer) metricAdded() {
@@ -98,35 +182,38 @@ func (b *Buffer) metricWritten(metric telegraf.Metric) {
 	metric.Accept()
 }
 
-func (b *Buffer) metricDropped(metric telegraf.Metric) {
+func (b *Buffer) metricDropped(m telegraf.Metric) {
 	AgentMetricsDropped.Incr(1)
 	b.MetricsDropped.Incr(1)
-	metric.Reject()
+	metric.RejectBy(UnwrapPriority(m), b.output)
+}
+
+func (b *Buffer) metricRejected(m telegraf.Metric) {
+	AgentMetricsRejected.Incr(1)
+	b.MetricsRejected.Incr(1)
+	metric.RejectBy(UnwrapPriority(m), b.output)
 }
 
 func (b *Buffer) add(m telegraf.Metric) int {
-	dropped := 0
-	// Check if Buffer is full
-	if b.size == b.cap {
-		b.metricDropped(b.buf[b.last])
-		dropped++
+	r := b.level(Priority(m))
 
-		if b.last == b.batchFirst && b.batchSize > 0 {
-			b.batchSize--
-			b.batchFirst = b.next(b.batchFirst)
+	dropped := 0
+	if b.size() == b.cap {
+		// Drop the oldest of the lowest priority metrics, which may be the
+		// new metric itself.
+		victim := b.lowest()
+		if r.priority < victim.priority {
+			b.metricAdded()
+			b.metricDropped(m)
+			return 1
 		}
-	}
-
-	b.metricAdded()
 
-	b.buf[b.last] = m
-	b.last = b.next(b.last)
-
-	if b.size == b.cap {
-		b.first = b.next(b.first)
+		victim.dropOldest()
+		dropped++
 	}
 
-	b.size = min(b.size+1, b.cap)
+	b.metricAdded()
+	r.add(m)
 	return dropped
 }
 
@@ -147,31 +234,17 @@ func (b *Buffer) Add(metrics ...telegraf.Metric) int {
 }
 
 // Batch returns a slice containing up to batchSize of the most recently added
-// metrics.  Metrics are ordered from newest to oldest in the batch.  The
-// batch must not be modified by the client.
+// metrics, taking the metrics of the highest priority first.  Metrics of the
+// same priority are ordered from newest to oldest in the batch.  The batch
+// must not be modified by the client.
 func (b *Buffer) Batch(batchSize int) []telegraf.Metric {
 	b.Lock()
 	defer b.Unlock()
 
-	outLen := min(b.size, batchSize)
-	out := make([]telegraf.Metric, outLen)
-	if outLen == 0 {
-		return out
-	}
-
-	b.batchFirst = b.cap + b.last - outLen
-	b.batchFirst %= b.cap
-	b.batchSize = outLen
-
-	batchIndex := b.batchFirst
-	for i := range out {
-		out[len(out)-1-i] = b.buf[batchIndex]
-		b.buf[batchIndex] = nil
-		batchIndex = b.next(batchIndex)
+	out := make([]telegraf.Metric, 0, min(b.size(), batchSize))
+	for i := len(b.levels) - 1; i >= 0 && len(out) < batchSize; i-- {
+		out = b.levels[i].batch(out, batchSize-len(out))
 	}
-
-	b.last = b.batchFirst
-	b.size -= outLen
 	return out
 }
 
@@ -184,7 +257,9 @@ func (b *Buffer) Accept(batch []telegraf.Metric) {
 		b.metricWritten(m)
 	}
 
-	b.resetBatch()
+	for _, r := range b.levels {
+		r.resetBatch()
+	}
 	b.BufferSize.Set(int64(b.length()))
 }
 
@@ -195,87 +270,180 @@ func (b *Buffer) Reject(batch []telegraf.Metric) {
 	defer b.Unlock()
 
 	if len(batch) == 0 {
+		for _, r := range b.levels {
+			r.resetBatch()
+		}
 		return
 	}
 
-	older := b.dist(b.first, b.batchFirst)
-	free := b.cap - b.size
+	if len(b.levels) == 1 {
+		b.levels[0].reject(batch)
+	} else {
+		for _, r := range b.levels {
+			part := make([]telegraf.Metric, 0, r.batchSize)
+			for _, m := range batch {
+				if Priority(m) == r.priority {
+					part = append(part, m)
+				}
+			}
+			r.reject(part)
+		}
+	}
+
+	// The levels share the capacity, the returned metrics of a level may only
+	// fit by dropping metrics of lower priority.
+	for excess := b.size() - b.cap; excess > 0; excess-- {
+		b.lowest().dropOldest()
+	}
+
+	b.BufferSize.Set(int64(b.length()))
+}
+
+// Discard marks metrics from the batch, acquired from Batch(), as rejected by
+// the output.  The metrics are not returned to the buffer, the remainder of
+// the batch must still be passed to Accept or Reject.
+func (b *Buffer) Discard(metrics []telegraf.Metric) {
+	b.Lock()
+	defer b.Unlock()
+
+	for _, m := range metrics {
+		b.metricRejected(m)
+	}
+}
+
+// add adds the metric as the newest of the ring, the buffer makes room for it
+// beforehand.
+func (r *ring) add(m telegraf.Metric) {
+	r.buf[r.last] = m
+	r.last = r.next(r.last)
+	r.size++
+}
+
+// dropOldest drops the oldest metric of the ring.
+func (r *ring) dropOldest() {
+	r.dropped(r.buf[r.first])
+
+	if r.first == r.batchFirst && r.batchSize > 0 {
+		r.batchSize--
+		r.batchFirst = r.next(r.batchFirst)
+	}
+
+	r.buf[r.first] = nil
+	r.first = r.next(r.first)
+	r.size--
+}
+
+// batch appends up to batchSize of the newest metrics of the ring to out,
+// ordered from newest to oldest.
+func (r *ring) batch(out []telegraf.Metric, batchSize int) []telegraf.Metric {
+	outLen := min(r.size, batchSize)
+	if outLen == 0 {
+		return out
+	}
+
+	r.batchFirst = r.cap + r.last - outLen
+	r.batchFirst %= r.cap
+	r.batchSize = outLen
+
+	n := len(out)
+	out = append(out, make([]telegraf.Metric, outLen)...)
+	batchIndex := r.batchFirst
+	for i := 0; i < outLen; i++ {
+		out[n+outLen-1-i] = r.buf[batchIndex]
+		r.buf[batchIndex] = nil
+		batchIndex = r.next(batchIndex)
+	}
+
+	r.last = r.batchFirst
+	r.size -= outLen
+	return out
+}
+
+// reject returns the metrics of the ring from a rejected batch to their
+// place in the ring.
+func (r *ring) reject(batch []telegraf.Metric) {
+	if len(batch) == 0 {
+		r.resetBatch()
+		return
+	}
+
+	older := r.dist(r.first, r.batchFirst)
+	free := r.cap - r.size
 	restore := min(len(batch), free+older)
 
 	// Rotate newer metrics forward the number of metrics that we can restore.
-	rb := b.batchFirst
-	rp := b.last
-	re := b.nextby(rp, restore)
-	b.last = re
+	rb := r.batchFirst
+	rp := r.last
+	re := r.nextby(rp, restore)
+	r.last = re
 
 	for rb != rp && rp != re {
-		rp = b.prev(rp)
-		re = b.prev(re)
+		rp = r.prev(rp)
+		re = r.prev(re)
 
-		if b.buf[re] != nil {
-			b.metricDropped(b.buf[re])
-			b.first = b.next(b.first)
+		if r.buf[re] != nil {
+			r.dropped(r.buf[re])
+			r.first = r.next(r.first)
 		}
 
-		b.buf[re] = b.buf[rp]
-		b.buf[rp] = nil
+		r.buf[re] = r.buf[rp]
+		r.buf[rp] = nil
 	}
 
 	// Copy metrics from the batch back into the buffer; recall that the
-	// batch is in reverse order compared to b.buf
+	// batch is in reverse order compared to r.buf
 	for i := range batch {
 		if i < restore {
-			re = b.prev(re)
-			b.buf[re] = batch[i]
-			b.size = min(b.size+1, b.cap)
+			re = r.prev(re)
+			r.buf[re] = batch[i]
+			r.size = min(r.size+1, r.cap)
 		} else {
-			b.metricDropped(batch[i])
+			r.dropped(batch[i])
 		}
 	}
 
-	b.resetBatch()
-	b.BufferSize.Set(int64(b.length()))
+	r.resetBatch()
 }
 
 // dist returns the distance between two indexes.  Because this data structure
 // uses a half open range the arguments must both either left side or right
 // side pairs.
-func (b *Buffer) dist(begin, end int) int {
+func (r *ring) dist(begin, end int) int {
 	if begin <= end {
 		return end - begin
 	} else {
-		return b.cap - begin + end
+		return r.cap - begin + end
 	}
 }
 
 // next returns the next index with wrapping.
-func (b *Buffer) next(index int) int {
+func (r *ring) next(index int) int {
 	index++
-	if index == b.cap {
+	if index == r.cap {
 		return 0
 	}
 	return index
 }
 
 // next returns the index that is count newer with wrapping.
-func (b *Buffer) nextby(index, count int) int {
+func (r *ring) nextby(index, count int) int {
 	index += count
-	index %= b.cap
+	index %= r.cap
 	return index
 }
 
 // next returns the prev index with wrapping.
-func (b *Buffer) prev(index int) int {
+func (r *ring) prev(index int) int {
 	index--
 	if index < 0 {
-		return b.cap - 1
+		return r.cap - 1
 	}
 	return index
 }
 
-func (b *Buffer) resetBatch() {
-	b.batchFirst = 0
-	b.batchSize = 0
+func (r *ring) resetBatch() {
+	r.batchFirst = 0
+	r.batchSize = 0
 }
 
 func min(a, b int) int {
@@ -726,3 +726,134 @@ func TestBuffer_RejectEmptyBatch(t *testing.T) {
 		require.NotNil(t, m)
 	}
 }
+
+func MetricPriority(sec int64, priority int) telegraf.Metric {
+	return WithPriority(MetricTime(sec), priority)
+}
+
+func TestBuffer_PriorityDropsLowestFirst(t *testing.T) {
+	b := setup(NewBuffer("test", "", 3))
+	b.Add(MetricTime(1), MetricPriority(2, 10), MetricTime(3))
+
+	require.Equal(t, 1, b.Add(MetricTime(4)))
+	require.Equal(t, 1, b.Add(MetricTime(5)))
+	require.Equal(t, 1, b.Add(MetricPriority(6, -1)))
+	require.Equal(t, int64(3), b.MetricsDropped.Get())
+
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricPriority(2, 10),
+			MetricTime(5),
+			MetricTime(4),
+		}, b.Batch(3))
+}
+
+func TestBuffer_PriorityBatchHighestFirst(t *testing.T) {
+	b := setup(NewBuffer("test", "", 5))
+	b.Add(MetricTime(1), MetricPriority(2, 10), MetricPriority(3, -1), MetricTime(4), MetricPriority(5, 10))
+
+	batch := b.Batch(3)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricPriority(5, 10),
+			MetricPriority(2, 10),
+			MetricTime(4),
+		}, batch)
+	require.Equal(t, 10, Priority(batch[0]))
+	require.Equal(t, 0, Priority(batch[2]))
+	require.Equal(t, 5, b.Len())
+
+	b.Accept(batch)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(1),
+			MetricPriority(3, -1),
+		}, b.Batch(3))
+}
+
+func TestBuffer_PriorityReject(t *testing.T) {
+	b := setup(NewBuffer("test", "", 3))
+	b.Add(MetricPriority(1, 10), MetricTime(2))
+	batch := b.Batch(1)
+
+	b.Add(MetricTime(3), MetricTime(4))
+	b.Add(MetricTime(5))
+	require.Equal(t, int64(1), b.MetricsDropped.Get())
+
+	b.Reject(batch)
+	require.Equal(t, int64(2), b.MetricsDropped.Get())
+	require.Equal(t, 3, b.Len())
+
+	batch = b.Batch(3)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricPriority(1, 10),
+			MetricTime(5),
+			MetricTime(4),
+		}, batch)
+	require.Equal(t, 10, Priority(batch[0]))
+}
+
+func TestBuffer_PriorityRejectKeepsOrder(t *testing.T) {
+	b := setup(NewBuffer("test", "", 5))
+	b.Add(MetricPriority(1, 10), MetricTime(2), MetricTime(3))
+	batch := b.Batch(2)
+
+	b.Add(MetricTime(4))
+	b.Reject(batch)
+	require.Equal(t, int64(0), b.MetricsDropped.Get())
+
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricPriority(1, 10),
+			MetricTime(4),
+			MetricTime(3),
+			MetricTime(2),
+		}, b.Batch(5))
+}
+
+func TestBuffer_PriorityRejectDropsLowerLevels(t *testing.T) {
+	b := setup(NewBuffer("test", "", 3))
+	b.Add(MetricPriority(1, 10), MetricPriority(2, 10))
+	batch := b.Batch(2)
+
+	b.Add(MetricTime(3), MetricTime(4), MetricTime(5))
+	b.Reject(batch)
+	require.Equal(t, int64(2), b.MetricsDropped.Get())
+
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricPriority(2, 10),
+			MetricPriority(1, 10),
+			MetricTime(5),
+		}, b.Batch(3))
+}
+
+func TestWithPriority(t *testing.T) {
+	m := MetricTime(1)
+	require.Equal(t, 0, Priority(m))
+	require.True(t, WithPriority(m, 0) == m)
+
+	pm := WithPriority(m, 10)
+	require.Equal(t, 10, Priority(pm))
+	require.Equal(t, 10, Priority(pm.Copy()))
+	require.True(t, UnwrapPriority(pm) == m)
+	require.True(t, WithPriority(pm, 5) == pm)
+	require.Equal(t, 5, Priority(pm))
+	require.True(t, WithPriority(pm, 0) == m)
+}
+
+func TestBuffer_PriorityDropRejectsByOutput(t *testing.T) {
+	var delivered telegraf.DeliveryInfo
+	m, _ := metric.WithOutputTracking(MetricTime(1), []string{"test"},
+		func(info telegraf.DeliveryInfo) {
+			delivered = info
+		})
+
+	b := setup(NewBuffer("test", "", 1))
+	b.Add(MetricPriority(2, 10))
+	require.Equal(t, 1, b.Add(WithPriority(m, 5)))
+
+	require.NotNil(t, delivered)
+	require.False(t, delivered.Delivered())
+}
@@ -0,0 +1,21 @@
+// +build linux
+
+package models
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// threadCPUTimeSupported is true if the CPU time of a thread can be measured.
+const threadCPUTimeSupported = true
+
+// threadCPUTime returns the CPU time consumed by the calling thread.
+func threadCPUTime() time.Duration {
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_THREAD_CPUTIME_ID, &ts); err != nil {
+		return 0
+	}
+	return time.Duration(ts.Nano())
+}
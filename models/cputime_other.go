@@ -0,0 +1,13 @@
+// +build !linux
+
+package models
+
+import "time"
+
+// threadCPUTimeSupported is true if the CPU time of a thread can be measured.
+const threadCPUTimeSupported = false
+
+// threadCPUTime returns the CPU time consumed by the calling thread.
+func threadCPUTime() time.Duration {
+	return 0
+}
@@ -0,0 +1,706 @@
+package models
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	serializer "github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+const (
+	segmentExt  = ".seg"
+	ackFilename = "ack"
+
+	// Size of the segments when the size of the buffer is not limited.
+	defaultSegmentSize = 32 * 1024 * 1024
+	minSegmentSize     = 64 * 1024
+
+	// Records larger than this are considered corrupt.
+	maxRecordSize = 64 * 1024 * 1024
+
+	recordHeaderSize = 8
+)
+
+var errCorruptRecord = errors.New("corrupt record")
+
+// DiskBuffer stores metrics in segment files on disk, so they are not lost
+// when Telegraf restarts or an output is unavailable for a long time.
+// Metrics are written to the output in the order they were added.
+//
+// Each record in a segment is prefixed with its length and checksum, a corrupt
+// record ends the segment and the remainder of the segment is skipped.  The
+// position of the oldest metric not yet written is stored in the ack file.
+type DiskBuffer struct {
+	sync.Mutex
+	output      string // alias, or name, of the output
+	dir         string
+	maxSize     int64
+	segmentSize int64
+	log         telegraf.Logger
+
+	segments []*segment // ordered oldest first, the last one is appended to
+	file     *os.File   // the last segment, opened for appending
+
+	// position of the oldest metric not yet written, within segments[0]
+	ackOffset int64
+	ackCount  int
+
+	// end position of the batch returned by Batch()
+	batch        bool
+	batchSegment uint64
+	batchOffset  int64
+	batchCount   int
+	batchTracked []recordPos
+	discarded    bool
+
+	// tracking metrics added since the buffer was opened; they are returned
+	// instead of the decoded metric, so their delivery is reported.
+	tracked map[recordPos]telegraf.Metric
+
+	serializer *serializer.Serializer
+	parser     *influx.Parser
+
+	MetricsAdded    selfstat.Stat
+	MetricsWritten  selfstat.Stat
+	MetricsDropped  selfstat.Stat
+	MetricsRejected selfstat.Stat
+	BufferSize      selfstat.Stat
+	BufferDiskSize  selfstat.Stat
+}
+
+type segment struct {
+	id    uint64
+	size  int64 // size of the valid records
+	count int   // number of valid records
+}
+
+type recordPos struct {
+	segment uint64
+	offset  int64
+}
+
+// NewDiskBuffer opens the buffer stored in dir, replaying the metrics not
+// yet written.  When maxSize is greater than 0, the oldest metrics are
+// dropped once the size of the segments exceeds it.
+func NewDiskBuffer(name, alias, dir string, maxSize int64, log telegraf.Logger) (*DiskBuffer, error) {
+	tags := map[string]string{"output": name}
+	if alias != "" {
+		tags["alias"] = alias
+	}
+
+	segmentSize := int64(defaultSegmentSize)
+	if maxSize > 0 {
+		segmentSize = maxSize / 8
+		if segmentSize > defaultSegmentSize {
+			segmentSize = defaultSegmentSize
+		}
+		if segmentSize < minSegmentSize {
+			segmentSize = minSegmentSize
+		}
+	}
+
+	s := serializer.NewSerializer()
+	s.SetFieldTypeSupport(serializer.UintSupport)
+
+	b := &DiskBuffer{
+		output:      outputID(name, alias),
+		dir:         dir,
+		maxSize:     maxSize,
+		segmentSize: segmentSize,
+		log:         log,
+		tracked:     make(map[recordPos]telegraf.Metric),
+		serializer:  s,
+		parser:      influx.NewParser(influx.NewMetricHandler()),
+
+		MetricsAdded:    selfstat.Register("write", "metrics_added", tags),
+		MetricsWritten:  selfstat.Register("write", "metrics_written", tags),
+		MetricsDropped:  selfstat.Register("write", "metrics_dropped", tags),
+		MetricsRejected: selfstat.Register("write", "metrics_rejected", tags),
+		BufferSize:      selfstat.Register("write", "buffer_size", tags),
+		BufferDiskSize:  selfstat.Register("write", "buffer_disk_size", tags),
+	}
+
+	if err := b.open(); err != nil {
+		b.Close()
+		return nil, err
+	}
+
+	if n := b.length(); n > 0 {
+		b.log.Infof("Replaying %d metrics buffered on disk", n)
+	}
+	b.updateStats()
+	return b, nil
+}
+
+// open loads the existing segments and starts a new segment for appending.
+func (b *DiskBuffer) open() error {
+	if err := os.MkdirAll(b.dir, 0750); err != nil {
+		return err
+	}
+
+	files, err := ioutil.ReadDir(b.dir)
+	if err != nil {
+		return err
+	}
+
+	var lastID uint64
+	for _, fi := range files {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), segmentExt) {
+			continue
+		}
+
+		id, err := strconv.ParseUint(strings.TrimSuffix(fi.Name(), segmentExt), 16, 64)
+		if err != nil {
+			continue
+		}
+		if id > lastID {
+			lastID = id
+		}
+
+		seg, err := b.scanSegment(id)
+		if err != nil {
+			return err
+		}
+		b.segments = append(b.segments, seg)
+	}
+	sort.Slice(b.segments, func(i, j int) bool {
+		return b.segments[i].id < b.segments[j].id
+	})
+
+	if err := b.loadAck(); err != nil {
+		return err
+	}
+
+	// Remove the segments that are already completely written.
+	for len(b.segments) > 0 && b.ackCount >= b.segments[0].count {
+		if err := b.removeSegment(); err != nil {
+			return err
+		}
+	}
+
+	return b.newSegment(lastID + 1)
+}
+
+// scanSegment counts the valid records of the segment.
+func (b *DiskBuffer) scanSegment(id uint64) (*segment, error) {
+	f, err := os.Open(b.segmentPath(id))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	seg := &segment{id: id}
+	r := bufio.NewReader(f)
+	for {
+		n, _, err := readRecord(r)
+		if err == io.EOF {
+			return seg, nil
+		}
+		if err != nil {
+			b.log.Warnf("Segment %s is corrupt after %d metrics, skipping the remainder: %v",
+				b.segmentPath(id), seg.count, err)
+			return seg, nil
+		}
+		seg.size += n
+		seg.count++
+	}
+}
+
+// loadAck reads the position of the oldest metric not yet written.
+func (b *DiskBuffer) loadAck() error {
+	data, err := ioutil.ReadFile(filepath.Join(b.dir, ackFilename))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var id uint64
+	var offset int64
+	var count int
+	if _, err := fmt.Sscanf(string(data), "%d %d %d", &id, &offset, &count); err != nil {
+		b.log.Warnf("Ignoring invalid ack file, metrics may be written again: %v", err)
+		return nil
+	}
+
+	for len(b.segments) > 0 && b.segments[0].id < id {
+		if err := b.removeSegment(); err != nil {
+			return err
+		}
+	}
+	if len(b.segments) > 0 && b.segments[0].id == id {
+		b.ackOffset = offset
+		b.ackCount = count
+	}
+	return nil
+}
+
+// storeAck persists the position of the oldest metric not yet written.
+func (b *DiskBuffer) storeAck() error {
+	var id uint64
+	if len(b.segments) > 0 {
+		id = b.segments[0].id
+	}
+
+	tmp := filepath.Join(b.dir, ackFilename+".tmp")
+	data := fmt.Sprintf("%d %d %d\n", id, b.ackOffset, b.ackCount)
+	if err := ioutil.WriteFile(tmp, []byte(data), 0640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(b.dir, ackFilename))
+}
+
+func (b *DiskBuffer) segmentPath(id uint64) string {
+	return filepath.Join(b.dir, fmt.Sprintf("%016x%s", id, segmentExt))
+}
+
+// newSegment starts appending to a new segment.
+func (b *DiskBuffer) newSegment(id uint64) error {
+	if b.file != nil {
+		if err := b.file.Close(); err != nil {
+			return err
+		}
+		b.file = nil
+	}
+
+	f, err := os.OpenFile(b.segmentPath(id), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0640)
+	if err != nil {
+		return err
+	}
+	b.file = f
+	b.segments = append(b.segments, &segment{id: id})
+	return nil
+}
+
+// removeSegment deletes the oldest segment.
+func (b *DiskBuffer) removeSegment() error {
+	seg := b.segments[0]
+	b.segments = b.segments[1:]
+	b.ackOffset = 0
+	b.ackCount = 0
+
+	for pos, m := range b.tracked {
+		if pos.segment == seg.id {
+			b.metricDropped(m)
+			delete(b.tracked, pos)
+		}
+	}
+
+	err := os.Remove(b.segmentPath(seg.id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Len returns the number of metrics currently in the buffer.
+func (b *DiskBuffer) Len() int {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.length()
+}
+
+func (b *DiskBuffer) length() int {
+	n := -b.ackCount
+	for _, seg := range b.segments {
+		n += seg.count
+	}
+	return n
+}
+
+func (b *DiskBuffer) diskSize() int64 {
+	var size int64
+	for _, seg := range b.segments {
+		size += seg.size
+	}
+	return size
+}
+
+func (b *DiskBuffer) updateStats() {
+	b.BufferSize.Set(int64(b.length()))
+	b.BufferDiskSize.Set(b.diskSize())
+}
+
+func (b *DiskBuffer) metricAdded() {
+	b.MetricsAdded.Incr(1)
+}
+
+func (b *DiskBuffer) metricWritten(metric telegraf.Metric) {
+	AgentMetricsWritten.Incr(1)
+	b.MetricsWritten.Incr(1)
+	metric.Accept()
+}
+
+func (b *DiskBuffer) metricDropped(m telegraf.Metric) {
+	AgentMetricsDropped.Incr(1)
+	b.MetricsDropped.Incr(1)
+	metric.RejectBy(UnwrapPriority(m), b.output)
+}
+
+func (b *DiskBuffer) metricRejected(m telegraf.Metric) {
+	AgentMetricsRejected.Incr(1)
+	b.MetricsRejected.Incr(1)
+	metric.RejectBy(UnwrapPriority(m), b.output)
+}
+
+// Add appends the metrics to the buffer and returns the number of dropped
+// metrics.
+func (b *DiskBuffer) Add(metrics ...telegraf.Metric) int {
+	b.Lock()
+	defer b.Unlock()
+
+	dropped := 0
+	for _, m := range metrics {
+		if err := b.add(m); err != nil {
+			b.log.Errorf("Could not buffer metric %q on disk: %v", m.Name(), err)
+			b.metricDropped(m)
+			dropped++
+		}
+	}
+
+	dropped += b.enforceMaxSize()
+	b.updateStats()
+	return dropped
+}
+
+func (b *DiskBuffer) add(m telegraf.Metric) error {
+	// Line protocol has no arrays or maps, the structured fields are stored
+	// flattened and are read back as such.
+	stored := m
+	if metric.HasStructuredFields(m) {
+		stored = metric.FromMetric(m)
+		metric.FlattenFields(stored, metric.FlattenSeparator)
+	}
+
+	line, err := b.serializer.Serialize(stored)
+	if err != nil {
+		return err
+	}
+
+	payload := make([]byte, 0, len(line)+1)
+	payload = append(payload, byte(m.Type()))
+	payload = append(payload, line...)
+
+	record := make([]byte, recordHeaderSize, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	record = append(record, payload...)
+
+	seg := b.segments[len(b.segments)-1]
+	if seg.size > 0 && seg.size+int64(len(record)) > b.segmentSize {
+		if err := b.newSegment(seg.id + 1); err != nil {
+			return err
+		}
+		seg = b.segments[len(b.segments)-1]
+	}
+
+	if _, err := b.file.Write(record); err != nil {
+		return err
+	}
+
+	if _, ok := m.(interface{ TrackingID() telegraf.TrackingID }); ok {
+		b.tracked[recordPos{segment: seg.id, offset: seg.size}] = m
+	}
+	seg.size += int64(len(record))
+	seg.count++
+	b.metricAdded()
+	return nil
+}
+
+// enforceMaxSize drops the oldest segments while the buffer is too large.
+// Segments with metrics of the current batch are kept until the batch is
+// done.
+func (b *DiskBuffer) enforceMaxSize() int {
+	if b.maxSize <= 0 {
+		return 0
+	}
+
+	dropped := 0
+	for len(b.segments) > 1 && b.diskSize() > b.maxSize {
+		if b.batch && b.batchSegment >= b.segments[0].id {
+			break
+		}
+
+		n := b.segments[0].count - b.ackCount
+		untracked := n
+		for pos := range b.tracked {
+			if pos.segment == b.segments[0].id {
+				untracked--
+			}
+		}
+		// Tracked metrics are counted when removing the segment.
+		AgentMetricsDropped.Incr(int64(untracked))
+		b.MetricsDropped.Incr(int64(untracked))
+		dropped += n
+
+		if err := b.removeSegment(); err != nil {
+			b.log.Errorf("Could not remove segment: %v", err)
+			break
+		}
+	}
+	if dropped > 0 {
+		if err := b.storeAck(); err != nil {
+			b.log.Errorf("Could not store buffer position: %v", err)
+		}
+	}
+	return dropped
+}
+
+// Batch returns a slice containing up to batchSize of the oldest metrics.
+// The metrics stay in the buffer until the batch is passed to Accept.
+func (b *DiskBuffer) Batch(batchSize int) []telegraf.Metric {
+	b.Lock()
+	defer b.Unlock()
+
+	out := make([]telegraf.Metric, 0, min(b.length(), batchSize))
+	b.batch = false
+	b.batchTracked = b.batchTracked[:0]
+	b.discarded = false
+
+	offset := b.ackOffset
+	count := b.ackCount
+	for i := 0; i < len(b.segments) && len(out) < batchSize; i++ {
+		seg := b.segments[i]
+		if i > 0 {
+			offset = 0
+			count = 0
+		}
+		if count >= seg.count {
+			continue
+		}
+
+		var err error
+		out, offset, count, err = b.readSegment(seg, offset, count, batchSize, out)
+		if err != nil {
+			b.log.Errorf("Segment %s is corrupt after %d metrics, skipping the remainder: %v",
+				b.segmentPath(seg.id), count, err)
+			seg.count = count
+			seg.size = offset
+
+			// Records appended to a corrupt segment would be unreadable.
+			if i == len(b.segments)-1 {
+				if err := b.newSegment(seg.id + 1); err != nil {
+					b.log.Errorf("Could not create segment: %v", err)
+				}
+			}
+		}
+
+		if len(out) > 0 {
+			b.batch = true
+			b.batchSegment = seg.id
+			b.batchOffset = offset
+			b.batchCount = count
+		}
+	}
+
+	b.updateStats()
+	return out
+}
+
+// readSegment appends the metrics of the segment starting at offset, until
+// the batch is full.  It returns the position after the last metric read.
+func (b *DiskBuffer) readSegment(
+	seg *segment,
+	offset int64,
+	count int,
+	batchSize int,
+	out []telegraf.Metric,
+) ([]telegraf.Metric, int64, int, error) {
+	f, err := os.Open(b.segmentPath(seg.id))
+	if err != nil {
+		return out, offset, count, err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return out, offset, count, err
+	}
+
+	r := bufio.NewReader(f)
+	for count < seg.count && len(out) < batchSize {
+		pos := recordPos{segment: seg.id, offset: offset}
+		n, payload, err := readRecord(r)
+		if err != nil {
+			return out, offset, count, err
+		}
+
+		m, ok := b.tracked[pos]
+		if ok {
+			b.batchTracked = append(b.batchTracked, pos)
+		} else {
+			m, err = b.decode(payload)
+			if err != nil {
+				return out, offset, count, err
+			}
+		}
+
+		out = append(out, m)
+		offset += n
+		count++
+	}
+	return out, offset, count, nil
+}
+
+func (b *DiskBuffer) decode(payload []byte) (telegraf.Metric, error) {
+	if len(payload) < 2 {
+		return nil, errCorruptRecord
+	}
+
+	metrics, err := b.parser.Parse(payload[1:])
+	if err != nil {
+		return nil, err
+	}
+	if len(metrics) != 1 {
+		return nil, errCorruptRecord
+	}
+
+	m := metrics[0]
+	return metric.New(m.Name(), m.Tags(), m.Fields(), m.Time(), telegraf.ValueType(payload[0]))
+}
+
+// Accept marks the batch, acquired from Batch(), as successfully written.
+func (b *DiskBuffer) Accept(batch []telegraf.Metric) {
+	b.Lock()
+	defer b.Unlock()
+
+	for _, m := range batch {
+		b.metricWritten(m)
+	}
+	b.acceptBatch()
+
+	b.enforceMaxSize()
+	b.updateStats()
+}
+
+// acceptBatch removes the metrics of the batch from the buffer.
+func (b *DiskBuffer) acceptBatch() {
+	if !b.batch {
+		return
+	}
+	b.batch = false
+
+	for _, pos := range b.batchTracked {
+		delete(b.tracked, pos)
+	}
+	b.batchTracked = b.batchTracked[:0]
+
+	for len(b.segments) > 0 && b.segments[0].id < b.batchSegment {
+		if err := b.removeSegment(); err != nil {
+			b.log.Errorf("Could not remove segment: %v", err)
+		}
+	}
+	if len(b.segments) > 0 && b.segments[0].id == b.batchSegment {
+		b.ackOffset = b.batchOffset
+		b.ackCount = b.batchCount
+	}
+
+	// Remove completely written segments, except the one appended to.
+	for len(b.segments) > 1 && b.ackCount >= b.segments[0].count {
+		if err := b.removeSegment(); err != nil {
+			b.log.Errorf("Could not remove segment: %v", err)
+		}
+	}
+
+	if err := b.storeAck(); err != nil {
+		b.log.Errorf("Could not store buffer position: %v", err)
+	}
+}
+
+// Reject returns the batch, acquired from Batch(), to the buffer.  When
+// metrics of the batch were discarded, the remaining metrics are appended to
+// the buffer again.
+func (b *DiskBuffer) Reject(batch []telegraf.Metric) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.discarded {
+		b.acceptBatch()
+		for _, m := range batch {
+			if err := b.add(m); err != nil {
+				b.log.Errorf("Could not buffer metric %q on disk: %v", m.Name(), err)
+				b.metricDropped(m)
+			}
+		}
+	}
+
+	b.batch = false
+	b.batchTracked = b.batchTracked[:0]
+	b.discarded = false
+
+	b.enforceMaxSize()
+	b.updateStats()
+}
+
+// Discard marks metrics from the batch, acquired from Batch(), as rejected by
+// the output.  The metrics are not returned to the buffer, the remainder of
+// the batch must still be passed to Accept or Reject.
+func (b *DiskBuffer) Discard(metrics []telegraf.Metric) {
+	b.Lock()
+	defer b.Unlock()
+
+	for _, m := range metrics {
+		b.metricRejected(m)
+	}
+	b.discarded = true
+}
+
+// Close closes the segment appended to and stores the position of the
+// oldest metric not yet written.
+func (b *DiskBuffer) Close() error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.file == nil {
+		return nil
+	}
+
+	err := b.file.Close()
+	b.file = nil
+	if err != nil {
+		return err
+	}
+	return b.storeAck()
+}
+
+// readRecord reads the next record, returning its size on disk and payload.
+func readRecord(r io.Reader) (int64, []byte, error) {
+	var header [recordHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, nil, errCorruptRecord
+		}
+		return 0, nil, err
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length == 0 || length > maxRecordSize {
+		return 0, nil, errCorruptRecord
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, errCorruptRecord
+	}
+
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return 0, nil, errCorruptRecord
+	}
+	return recordHeaderSize + int64(length), payload, nil
+}
@@ -0,0 +1,227 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDiskBuffer(t *testing.T, dir string, maxSize int64) *DiskBuffer {
+	b, err := NewDiskBuffer("test", "", dir, maxSize, testutil.Logger{})
+	require.NoError(t, err)
+	b.MetricsAdded.Set(0)
+	b.MetricsWritten.Set(0)
+	b.MetricsDropped.Set(0)
+	return b
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	return dir
+}
+
+func TestDiskBuffer_BatchAccept(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 0)
+	defer b.Close()
+
+	for i := int64(0); i < 5; i++ {
+		require.Equal(t, 0, b.Add(MetricTime(i)))
+	}
+	require.Equal(t, 5, b.Len())
+	require.Equal(t, int64(5), b.MetricsAdded.Get())
+
+	batch := b.Batch(3)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{
+		MetricTime(0),
+		MetricTime(1),
+		MetricTime(2),
+	}, batch)
+	require.Equal(t, 5, b.Len())
+
+	b.Accept(batch)
+	require.Equal(t, 2, b.Len())
+	require.Equal(t, int64(3), b.MetricsWritten.Get())
+
+	batch = b.Batch(3)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{
+		MetricTime(3),
+		MetricTime(4),
+	}, batch)
+}
+
+func TestDiskBuffer_RejectKeepsMetrics(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 0)
+	defer b.Close()
+
+	b.Add(MetricTime(0), MetricTime(1))
+	b.Reject(b.Batch(2))
+	require.Equal(t, 2, b.Len())
+
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{
+		MetricTime(0),
+		MetricTime(1),
+	}, b.Batch(2))
+}
+
+func TestDiskBuffer_RejectAfterDiscard(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 0)
+	defer b.Close()
+
+	b.Add(MetricTime(0), MetricTime(1), MetricTime(2))
+	batch := b.Batch(3)
+	b.Discard(batch[1:2])
+	b.Reject([]telegraf.Metric{batch[0], batch[2]})
+	require.Equal(t, 2, b.Len())
+
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{
+		MetricTime(0),
+		MetricTime(2),
+	}, b.Batch(3))
+}
+
+func TestDiskBuffer_Replay(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	m, err := metric.New("cpu",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{"count": uint64(42), "state": "idle\nbusy", "ok": true},
+		time.Unix(0, 42),
+		telegraf.Counter,
+	)
+	require.NoError(t, err)
+
+	b := newTestDiskBuffer(t, dir, 0)
+	b.Add(MetricTime(0), MetricTime(1), m)
+	b.Accept(b.Batch(1))
+	require.NoError(t, b.Close())
+
+	b = newTestDiskBuffer(t, dir, 0)
+	defer b.Close()
+	require.Equal(t, 2, b.Len())
+
+	batch := b.Batch(2)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{MetricTime(1), m}, batch)
+	require.Equal(t, telegraf.Counter, batch[1].Type())
+}
+
+func TestDiskBuffer_StructuredFields(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	m := testutil.MustMetric("deploy",
+		map[string]string{},
+		map[string]interface{}{
+			"labels": []interface{}{"web"},
+			"owner":  map[string]interface{}{"name": "ops"},
+		},
+		time.Unix(0, 42),
+	)
+
+	b := newTestDiskBuffer(t, dir, 0)
+	defer b.Close()
+	b.Add(m)
+
+	// The metric added is not modified, the stored copy is flattened.
+	require.True(t, metric.HasStructuredFields(m))
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{
+		testutil.MustMetric("deploy",
+			map[string]string{},
+			map[string]interface{}{"labels_0": "web", "owner_name": "ops"},
+			time.Unix(0, 42),
+		),
+	}, b.Batch(1))
+}
+
+func TestDiskBuffer_CorruptSegment(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 0)
+	b.Add(MetricTime(0), MetricTime(1), MetricTime(2))
+	require.NoError(t, b.Close())
+
+	segments, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+
+	// Damage the second record
+	data, err := ioutil.ReadFile(segments[0])
+	require.NoError(t, err)
+	data[len(data)/3+len(data)/6] ^= 0xff
+	require.NoError(t, ioutil.WriteFile(segments[0], data, 0640))
+
+	b = newTestDiskBuffer(t, dir, 0)
+	defer b.Close()
+	require.Equal(t, 1, b.Len())
+
+	b.Add(MetricTime(3))
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{
+		MetricTime(0),
+		MetricTime(3),
+	}, b.Batch(3))
+}
+
+func TestDiskBuffer_MaxSize(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 2*minSegmentSize)
+	defer b.Close()
+
+	added := 0
+	for b.MetricsDropped.Get() == 0 {
+		require.True(t, added < 100000, "buffer not limited")
+		b.Add(MetricTime(int64(added)))
+		added++
+	}
+
+	require.True(t, b.MetricsDropped.Get() > 0)
+	require.True(t, b.diskSize() <= 2*minSegmentSize)
+	require.Equal(t, added-int(b.MetricsDropped.Get()), b.Len())
+
+	// The oldest metrics are dropped
+	batch := b.Batch(1)
+	require.Equal(t, time.Unix(b.MetricsDropped.Get(), 0), batch[0].Time())
+}
+
+func TestDiskBuffer_TrackingMetric(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 0)
+	defer b.Close()
+
+	var delivered []telegraf.DeliveryInfo
+	tm, _ := metric.WithTracking(MetricTime(0), func(info telegraf.DeliveryInfo) {
+		delivered = append(delivered, info)
+	})
+	b.Add(tm)
+
+	batch := b.Batch(1)
+	b.Reject(batch)
+	require.Len(t, delivered, 0)
+
+	batch = b.Batch(1)
+	require.Equal(t, tm, batch[0])
+	b.Accept(batch)
+	require.Len(t, delivered, 1)
+	require.True(t, delivered[0].Delivered())
+}
@@ -1,22 +1,44 @@
 package models
 
 import (
+	"fmt"
 	"log"
 	"reflect"
+	"sync"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/logger"
 )
 
 // Logger defines a logging structure for plugins.
 type Logger struct {
 	OnErrs []func()
 	Name   string // Name is the plugin name, will be printed in the `[]`.
+
+	plugin *logger.Plugin
 }
 
+var (
+	instanceMu  sync.Mutex
+	instanceIDs = make(map[string]int)
+)
+
 // NewLogger creates a new logger instance
 func NewLogger(pluginType, name, alias string) *Logger {
+	// Instances of the same plugin are numbered in the order they are created.
+	instanceMu.Lock()
+	instanceIDs[pluginType+"."+name]++
+	id := instanceIDs[pluginType+"."+name]
+	instanceMu.Unlock()
+
 	return &Logger{
 		Name: logName(pluginType, name, alias),
+		plugin: &logger.Plugin{
+			Type:       pluginType,
+			Name:       name,
+			Alias:      alias,
+			InstanceID: id,
+		},
 	}
 }
 
@@ -30,7 +52,7 @@ func (l *Logger) Errorf(format string, args ...interface{}) {
 	for _, f := range l.OnErrs {
 		f()
 	}
-	log.Printf("E! ["+l.Name+"] "+format, args...)
+	l.print('E', fmt.Sprintf(format, args...))
 }
 
 // Error logs an error message, patterned after log.Print.
@@ -38,37 +60,47 @@ func (l *Logger) Error(args ...interface{}) {
 	for _, f := range l.OnErrs {
 		f()
 	}
-	log.Print(append([]interface{}{"E! [" + l.Name + "] "}, args...)...)
+	l.print('E', fmt.Sprint(args...))
 }
 
 // Debugf logs a debug message, patterned after log.Printf.
 func (l *Logger) Debugf(format string, args ...interface{}) {
-	log.Printf("D! ["+l.Name+"] "+format, args...)
+	l.print('D', fmt.Sprintf(format, args...))
 }
 
 // Debug logs a debug message, patterned after log.Print.
 func (l *Logger) Debug(args ...interface{}) {
-	log.Print(append([]interface{}{"D! [" + l.Name + "] "}, args...)...)
+	l.print('D', fmt.Sprint(args...))
 }
 
 // Warnf logs a warning message, patterned after log.Printf.
 func (l *Logger) Warnf(format string, args ...interface{}) {
-	log.Printf("W! ["+l.Name+"] "+format, args...)
+	l.print('W', fmt.Sprintf(format, args...))
 }
 
 // Warn logs a warning message, patterned after log.Print.
 func (l *Logger) Warn(args ...interface{}) {
-	log.Print(append([]interface{}{"W! [" + l.Name + "] "}, args...)...)
+	l.print('W', fmt.Sprint(args...))
 }
 
 // Infof logs an information message, patterned after log.Printf.
 func (l *Logger) Infof(format string, args ...interface{}) {
-	log.Printf("I! ["+l.Name+"] "+format, args...)
+	l.print('I', fmt.Sprintf(format, args...))
 }
 
 // Info logs an information message, patterned after log.Print.
 func (l *Logger) Info(args ...interface{}) {
-	log.Print(append([]interface{}{"I! [" + l.Name + "] "}, args...)...)
+	l.print('I', fmt.Sprint(args...))
+}
+
+// print logs the message at the level, with the plugin as fields of the
+// message when the logs are JSON.
+func (l *Logger) print(level byte, msg string) {
+	if l.plugin == nil {
+		log.Print(string(level) + "! [" + l.Name + "] " + msg)
+		return
+	}
+	logger.PrintPlugin(level, l.plugin, msg)
 }
 
 // logName returns the log-friendly name/type.
@@ -22,3 +22,15 @@ func TestErrorCounting(t *testing.T) {
 
 	require.Equal(t, int64(2), reg.Get())
 }
+
+func TestLoggerInstanceID(t *testing.T) {
+	first := NewLogger("inputs", "instance_id_test", "")
+	second := NewLogger("inputs", "instance_id_test", "b")
+	other := NewLogger("outputs", "instance_id_test", "")
+
+	require.Equal(t, "inputs.instance_id_test", first.Name)
+	require.Equal(t, 1, first.plugin.InstanceID)
+	require.Equal(t, 2, second.plugin.InstanceID)
+	require.Equal(t, "b", second.plugin.Alias)
+	require.Equal(t, 1, other.plugin.InstanceID)
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"github.com/influxdata/telegraf"
+)
+
+// prioritizedMetric is a metric with a priority in the output buffers other
+// than the default of 0.
+type prioritizedMetric struct {
+	telegraf.Metric
+	priority int
+}
+
+// Copy returns a copy of the metric with the same priority.
+func (m *prioritizedMetric) Copy() telegraf.Metric {
+	return &prioritizedMetric{
+		Metric:   m.Metric.Copy(),
+		priority: m.priority,
+	}
+}
+
+// WithPriority sets the priority of the metric in the output buffers.  When a
+// buffer is full the metrics with the lowest priority are dropped first.  The
+// returned metric must be used in place of the metric passed in.
+func WithPriority(m telegraf.Metric, priority int) telegraf.Metric {
+	if pm, ok := m.(*prioritizedMetric); ok {
+		if priority == 0 {
+			return pm.Metric
+		}
+		pm.priority = priority
+		return pm
+	}
+
+	if priority == 0 {
+		return m
+	}
+	return &prioritizedMetric{Metric: m, priority: priority}
+}
+
+// Priority returns the priority of the metric in the output buffers, 0 unless
+// set with WithPriority.
+func Priority(m telegraf.Metric) int {
+	if pm, ok := m.(*prioritizedMetric); ok {
+		return pm.priority
+	}
+	return 0
+}
+
+// UnwrapPriority returns the metric passed to WithPriority, for comparing
+// metrics by identity.
+func UnwrapPriority(m telegraf.Metric) telegraf.Metric {
+	if pm, ok := m.(*prioritizedMetric); ok {
+		return pm.Metric
+	}
+	return m
+}
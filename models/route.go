@@ -0,0 +1,13 @@
+package models
+
+// Route selects the metrics sent to a set of outputs.  An output named by a
+// route only receives the metrics selected by one of its routes.
+type Route struct {
+	// Outputs are the aliases, or names if no alias is set, of the outputs
+	// receiving the selected metrics.
+	Outputs []string
+
+	// Filter selects the metrics using the namepass/namedrop and
+	// tagpass/tagdrop rules.
+	Filter Filter
+}
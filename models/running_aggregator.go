@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -9,6 +11,16 @@ import (
 	"github.com/influxdata/telegraf/selfstat"
 )
 
+const (
+	// WindowProcessingTime aggregates the metrics received during each
+	// period of the agent clock.
+	WindowProcessingTime = "processing_time"
+
+	// WindowEventTime aggregates the metrics by the period their timestamp
+	// falls into.
+	WindowEventTime = "event_time"
+)
+
 type RunningAggregator struct {
 	sync.Mutex
 	Aggregator  telegraf.Aggregator
@@ -17,6 +29,18 @@ type RunningAggregator struct {
 	periodEnd   time.Time
 	log         telegraf.Logger
 
+	// Creator returns a new configured instance of the aggregator, event
+	// time aggregation uses an instance for each open window.
+	Creator func() (telegraf.Aggregator, error)
+
+	// windows holds the aggregators of the open event time windows by the
+	// start of the window in Unix nanoseconds.
+	windows map[int64]telegraf.Aggregator
+
+	// maxTime is the latest timestamp of the aggregated metrics, up to the
+	// current time plus the allowed lateness.
+	maxTime time.Time
+
 	MetricsPushed   selfstat.Stat
 	MetricsFiltered selfstat.Stat
 	MetricsDropped  selfstat.Stat
@@ -40,6 +64,7 @@ func NewRunningAggregator(aggregator telegraf.Aggregator, config *AggregatorConf
 	return &RunningAggregator{
 		Aggregator: aggregator,
 		Config:     config,
+		windows:    make(map[int64]telegraf.Aggregator),
 		MetricsPushed: selfstat.Register(
 			"aggregate",
 			"metrics_pushed",
@@ -73,11 +98,19 @@ type AggregatorConfig struct {
 	Delay        time.Duration
 	Grace        time.Duration
 
+	Window          string
+	AllowedLateness time.Duration
+	MaxWindows      int
+
 	NameOverride      string
 	MeasurementPrefix string
 	MeasurementSuffix string
 	Tags              map[string]string
 	Filter            Filter
+
+	// Fingerprint identifies the configuration of the aggregator, aggregators
+	// with the same fingerprint are configured identically.
+	Fingerprint string
 }
 
 func (r *RunningAggregator) LogName() string {
@@ -85,6 +118,10 @@ func (r *RunningAggregator) LogName() string {
 }
 
 func (r *RunningAggregator) Init() error {
+	if r.Config.Window == WindowEventTime && r.Creator == nil {
+		return fmt.Errorf("event time windows are not supported")
+	}
+
 	if p, ok := r.Aggregator.(telegraf.Initializer); ok {
 		err := p.Init()
 		if err != nil {
@@ -148,6 +185,11 @@ func (r *RunningAggregator) Add(m telegraf.Metric) bool {
 	r.Lock()
 	defer r.Unlock()
 
+	if r.Config.Window == WindowEventTime {
+		r.addEventTime(m)
+		return r.Config.DropOriginal
+	}
+
 	if m.Time().Before(r.periodStart.Add(-r.Config.Grace)) || m.Time().After(r.periodEnd.Add(r.Config.Delay)) {
 		r.log.Debugf("Metric is outside aggregation window; discarding. %s: m: %s e: %s g: %s",
 			m.Time(), r.periodStart, r.periodEnd, r.Config.Grace)
@@ -159,6 +201,71 @@ func (r *RunningAggregator) Add(m telegraf.Metric) bool {
 	return r.Config.DropOriginal
 }
 
+// addEventTime adds the metric to the window of its timestamp.  Metrics of
+// windows already closed by the watermark, or opening a window beyond the
+// maximum number of open windows, are discarded.
+func (r *RunningAggregator) addEventTime(m telegraf.Metric) {
+	start := m.Time().Truncate(r.Config.Period)
+	if !start.Add(r.Config.Period).After(r.watermark()) {
+		r.log.Debugf("Metric is older than the watermark; discarding. %s: w: %s",
+			m.Time(), r.watermark())
+		r.MetricsDropped.Incr(1)
+		return
+	}
+
+	aggregator, ok := r.windows[start.UnixNano()]
+	if !ok {
+		if r.Config.MaxWindows > 0 && len(r.windows) >= r.Config.MaxWindows {
+			r.log.Debugf("Metric would exceed %d open windows; discarding. %s",
+				r.Config.MaxWindows, m.Time())
+			r.MetricsDropped.Incr(1)
+			return
+		}
+
+		var err error
+		aggregator, err = r.newWindow()
+		if err != nil {
+			r.log.Errorf("Could not create aggregation window: %v", err)
+			r.MetricsDropped.Incr(1)
+			return
+		}
+		r.windows[start.UnixNano()] = aggregator
+	}
+	aggregator.Add(m)
+
+	// A timestamp in the future must not move the watermark past the
+	// windows of metrics with a correct timestamp.
+	latest := m.Time()
+	if limit := time.Now().Add(r.Config.AllowedLateness); latest.After(limit) {
+		latest = limit
+	}
+	if latest.After(r.maxTime) {
+		r.maxTime = latest
+	}
+}
+
+// newWindow creates the aggregator of an event time window.
+func (r *RunningAggregator) newWindow() (telegraf.Aggregator, error) {
+	aggregator, err := r.Creator()
+	if err != nil {
+		return nil, err
+	}
+	setLogIfExist(aggregator, r.log)
+
+	if p, ok := aggregator.(telegraf.Initializer); ok {
+		if err := p.Init(); err != nil {
+			return nil, err
+		}
+	}
+	return aggregator, nil
+}
+
+// watermark returns the time up to which all metrics are expected to have
+// arrived, the latest timestamp seen minus the allowed lateness.
+func (r *RunningAggregator) watermark() time.Time {
+	return r.maxTime.Add(-r.Config.AllowedLateness)
+}
+
 func (r *RunningAggregator) Push(acc telegraf.Accumulator) {
 	r.Lock()
 	defer r.Unlock()
@@ -167,13 +274,52 @@ func (r *RunningAggregator) Push(acc telegraf.Accumulator) {
 	until := r.periodEnd.Add(r.Config.Period)
 	r.UpdateWindow(since, until)
 
-	r.push(acc)
+	if r.Config.Window == WindowEventTime {
+		r.pushWindows(acc, false)
+		return
+	}
+
+	r.push(r.Aggregator, acc)
 	r.Aggregator.Reset()
 }
 
-func (r *RunningAggregator) push(acc telegraf.Accumulator) {
+// Flush pushes the remaining aggregations when the aggregator stops,
+// including the event time windows not yet closed by the watermark.
+func (r *RunningAggregator) Flush(acc telegraf.Accumulator) {
+	if r.Config.Window != WindowEventTime {
+		r.Push(acc)
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	r.pushWindows(acc, true)
+}
+
+// pushWindows pushes and closes the event time windows ending before the
+// watermark, or all windows if all is true, in order.
+func (r *RunningAggregator) pushWindows(acc telegraf.Accumulator, all bool) {
+	watermark := r.watermark()
+
+	var starts []int64
+	for start := range r.windows {
+		end := time.Unix(0, start).Add(r.Config.Period)
+		if all || !end.After(watermark) {
+			starts = append(starts, start)
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+
+	for _, start := range starts {
+		end := time.Unix(0, start).Add(r.Config.Period)
+		r.push(r.windows[start], &windowAccumulator{Accumulator: acc, end: end})
+		delete(r.windows, start)
+	}
+}
+
+func (r *RunningAggregator) push(aggregator telegraf.Aggregator, acc telegraf.Accumulator) {
 	start := time.Now()
-	r.Aggregator.Push(acc)
+	aggregator.Push(acc)
 	elapsed := time.Since(start)
 	r.PushTime.Incr(elapsed.Nanoseconds())
 }
@@ -181,3 +327,35 @@ func (r *RunningAggregator) push(acc telegraf.Accumulator) {
 func (r *RunningAggregator) Log() telegraf.Logger {
 	return r.log
 }
+
+// windowAccumulator timestamps the aggregates of an event time window with
+// the end of the window.
+type windowAccumulator struct {
+	telegraf.Accumulator
+	end time.Time
+}
+
+func (a *windowAccumulator) AddFields(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.Accumulator.AddFields(measurement, fields, tags, a.end)
+}
+
+func (a *windowAccumulator) AddGauge(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.Accumulator.AddGauge(measurement, fields, tags, a.end)
+}
+
+func (a *windowAccumulator) AddCounter(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.Accumulator.AddCounter(measurement, fields, tags, a.end)
+}
+
+func (a *windowAccumulator) AddSummary(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.Accumulator.AddSummary(measurement, fields, tags, a.end)
+}
+
+func (a *windowAccumulator) AddHistogram(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.Accumulator.AddHistogram(measurement, fields, tags, a.end)
+}
+
+func (a *windowAccumulator) AddMetric(m telegraf.Metric) {
+	m.SetTime(a.end)
+	a.Accumulator.AddMetric(m)
+}
@@ -238,6 +238,109 @@ func TestAddDoesNotModifyMetric(t *testing.T) {
 	testutil.RequireMetricEqual(t, expected, m)
 }
 
+func TestAddEventTime(t *testing.T) {
+	ra := NewRunningAggregator(&TestAggregator{}, &AggregatorConfig{
+		Name:            "TestRunningAggregator",
+		Period:          time.Minute,
+		Window:          WindowEventTime,
+		AllowedLateness: 2 * time.Minute,
+	})
+	ra.Creator = func() (telegraf.Aggregator, error) {
+		return &TestAggregator{}, nil
+	}
+	require.NoError(t, ra.Init())
+	require.NoError(t, ra.Config.Filter.Compile())
+	acc := testutil.Accumulator{}
+
+	add := func(tm time.Time, value int64) {
+		ra.Add(testutil.MustMetric("RITest",
+			map[string]string{},
+			map[string]interface{}{
+				"value": value,
+			},
+			tm))
+	}
+
+	start := time.Unix(600, 0)
+	add(start.Add(10*time.Second), 1)
+	add(start.Add(70*time.Second), 2)
+	add(start.Add(130*time.Second), 4)
+
+	// A late metric is aggregated into the window of its timestamp
+	add(start.Add(20*time.Second), 8)
+
+	// No window has passed the watermark yet
+	ra.Push(&acc)
+	require.Len(t, acc.Metrics, 0)
+
+	// The watermark at 3m10s closes the first window
+	add(start.Add(190*time.Second), 16)
+	ra.Push(&acc)
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, int64(9), acc.Metrics[0].Fields["sum"])
+	require.Equal(t, start.Add(time.Minute), acc.Metrics[0].Time)
+
+	// Metrics of a pushed window are discarded
+	dropped := ra.MetricsDropped.Get()
+	add(start.Add(30*time.Second), 32)
+	require.Equal(t, dropped+1, ra.MetricsDropped.Get())
+
+	// Flushing pushes the remaining windows in order
+	ra.Flush(&acc)
+	require.Len(t, acc.Metrics, 4)
+	for i, sum := range []int64{2, 4, 16} {
+		require.Equal(t, sum, acc.Metrics[i+1].Fields["sum"])
+		require.Equal(t, start.Add(time.Duration(i+2)*time.Minute), acc.Metrics[i+1].Time)
+	}
+}
+
+func TestAddEventTimeLimits(t *testing.T) {
+	ra := NewRunningAggregator(&TestAggregator{}, &AggregatorConfig{
+		Name:            "TestRunningAggregator",
+		Period:          time.Minute,
+		Window:          WindowEventTime,
+		AllowedLateness: time.Minute,
+		MaxWindows:      3,
+	})
+	ra.Creator = func() (telegraf.Aggregator, error) {
+		return &TestAggregator{}, nil
+	}
+	require.NoError(t, ra.Init())
+	require.NoError(t, ra.Config.Filter.Compile())
+	acc := testutil.Accumulator{}
+
+	add := func(tm time.Time, value int64) {
+		ra.Add(testutil.MustMetric("RITest",
+			map[string]string{},
+			map[string]interface{}{
+				"value": value,
+			},
+			tm))
+	}
+
+	now := time.Now()
+	add(now, 1)
+
+	// A metric far in the future does not close the window of the current
+	// time
+	add(now.Add(24*time.Hour), 2)
+	ra.Push(&acc)
+	require.Len(t, acc.Metrics, 0)
+
+	// No window is opened beyond the maximum
+	add(now.Add(time.Hour), 4)
+	dropped := ra.MetricsDropped.Get()
+	add(now.Add(2*time.Hour), 8)
+	require.Equal(t, dropped+1, ra.MetricsDropped.Get())
+	require.Len(t, ra.windows, 3)
+
+	ra.Flush(&acc)
+	require.Len(t, acc.Metrics, 3)
+	for i, sum := range []int64{1, 4, 2} {
+		require.Equal(t, sum, acc.Metrics[i].Fields["sum"])
+	}
+}
+
 type TestAggregator struct {
 	sum int64
 }
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -10,17 +13,38 @@ import (
 var (
 	GlobalMetricsGathered = selfstat.Register("agent", "metrics_gathered", map[string]string{})
 	GlobalGatherErrors    = selfstat.Register("agent", "gather_errors", map[string]string{})
+	GlobalInvalidTimes    = selfstat.Register("agent", "metrics_invalid_timestamp", map[string]string{})
+)
+
+const (
+	InvalidTimestampDrop    = "drop"
+	InvalidTimestampClamp   = "clamp"
+	InvalidTimestampRewrite = "rewrite"
 )
 
 type RunningInput struct {
+	// Must be 64-bit aligned
+	gatherMetricBytes int64
+	disabled          int32
+
 	Input  telegraf.Input
 	Config *InputConfig
 
 	log         telegraf.Logger
 	defaultTags map[string]string
 
+	maxPast                time.Duration
+	maxFuture              time.Duration
+	invalidTimestampAction string
+
 	MetricsGathered selfstat.Stat
 	GatherTime      selfstat.Stat
+
+	// GatherCPUTime, GatherMetricBytes and Disabled are only registered when the
+	// resources of the input are limited.
+	GatherCPUTime     selfstat.Stat
+	GatherMetricBytes selfstat.Stat
+	Disabled          selfstat.Stat
 }
 
 func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
@@ -37,7 +61,7 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 	})
 	setLogIfExist(input, logger)
 
-	return &RunningInput{
+	r := &RunningInput{
 		Input:  input,
 		Config: config,
 		MetricsGathered: selfstat.Register(
@@ -52,6 +76,13 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 		),
 		log: logger,
 	}
+
+	if r.limited() {
+		r.GatherCPUTime = selfstat.RegisterTiming("gather", "gather_cpu_time_ns", tags)
+		r.GatherMetricBytes = selfstat.RegisterTiming("gather", "gather_metric_bytes", tags)
+		r.Disabled = selfstat.Register("gather", "disabled", tags)
+	}
+	return r
 }
 
 // InputConfig is the common config for all inputs.
@@ -60,11 +91,37 @@ type InputConfig struct {
 	Alias    string
 	Interval time.Duration
 
+	// Precision and RoundInterval override the agent settings when set.
+	Precision     time.Duration
+	RoundInterval *bool
+
 	NameOverride      string
 	MeasurementPrefix string
 	MeasurementSuffix string
 	Tags              map[string]string
 	Filter            Filter
+
+	// MetricMaxPast and MetricMaxFuture override the agent limits on the
+	// metric timestamp when set.
+	MetricMaxPast          *time.Duration
+	MetricMaxFuture        *time.Duration
+	InvalidTimestampAction string
+
+	// MaxGatherCPUTime and MaxGatherMetricBytes limit the CPU time of the
+	// thread running a single gather and the size of the metrics it emits.
+	// The input is disabled when a gather exceeds them.  They do not limit
+	// the resources of the whole input, such as its other goroutines.
+	MaxGatherCPUTime     time.Duration
+	MaxGatherMetricBytes int64
+
+	// DeliveryOutputs are the outputs, by alias or name, whose rejections
+	// prevent the delivery of tracking metrics.  When empty the rejection
+	// by any output does.
+	DeliveryOutputs []string
+
+	// Fingerprint identifies the configuration of the input, inputs with
+	// the same fingerprint are configured identically.
+	Fingerprint string
 }
 
 func (r *RunningInput) metricFiltered(metric telegraf.Metric) {
@@ -76,6 +133,18 @@ func (r *RunningInput) LogName() string {
 }
 
 func (r *RunningInput) Init() error {
+	switch r.invalidTimestampAction {
+	case "":
+		r.invalidTimestampAction = InvalidTimestampDrop
+	case InvalidTimestampDrop, InvalidTimestampClamp, InvalidTimestampRewrite:
+	default:
+		return fmt.Errorf("invalid invalid_timestamp_action %q", r.invalidTimestampAction)
+	}
+
+	if r.Config.MaxGatherCPUTime > 0 && !threadCPUTimeSupported {
+		r.log.Warnf("max_gather_cpu_time is not supported on %s and is ignored", runtime.GOOS)
+	}
+
 	if p, ok := r.Input.(telegraf.Initializer); ok {
 		err := p.Init()
 		if err != nil {
@@ -86,6 +155,11 @@ func (r *RunningInput) Init() error {
 }
 
 func (r *RunningInput) MakeMetric(metric telegraf.Metric) telegraf.Metric {
+	if r.IsDisabled() {
+		r.metricFiltered(metric)
+		return nil
+	}
+
 	if ok := r.Config.Filter.Select(metric); !ok {
 		r.metricFiltered(metric)
 		return nil
@@ -105,12 +179,25 @@ func (r *RunningInput) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 		return nil
 	}
 
+	if !r.checkTime(m) {
+		r.metricFiltered(m)
+		return nil
+	}
+
+	if r.Config.MaxGatherMetricBytes > 0 {
+		atomic.AddInt64(&r.gatherMetricBytes, metricSize(m))
+	}
+
 	r.MetricsGathered.Incr(1)
 	GlobalMetricsGathered.Incr(1)
 	return m
 }
 
 func (r *RunningInput) Gather(acc telegraf.Accumulator) error {
+	if r.limited() {
+		return r.gatherLimited(acc)
+	}
+
 	start := time.Now()
 	err := r.Input.Gather(acc)
 	elapsed := time.Since(start)
@@ -118,10 +205,132 @@ func (r *RunningInput) Gather(acc telegraf.Accumulator) error {
 	return err
 }
 
+// limited returns true if the resources of a gather are limited.
+func (r *RunningInput) limited() bool {
+	return r.Config.MaxGatherCPUTime > 0 || r.Config.MaxGatherMetricBytes > 0
+}
+
+// gatherLimited runs Gather on a locked thread, measuring the CPU time of the
+// thread and the size of the metrics emitted, and disables the input when
+// they exceed the limits.  The CPU time of other goroutines of the input, and
+// the memory it allocates, are not measured.
+func (r *RunningInput) gatherLimited(acc telegraf.Accumulator) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	atomic.StoreInt64(&r.gatherMetricBytes, 0)
+	start := time.Now()
+	startCPU := threadCPUTime()
+	err := r.Input.Gather(acc)
+	cpuTime := threadCPUTime() - startCPU
+	elapsed := time.Since(start)
+	metricBytes := atomic.LoadInt64(&r.gatherMetricBytes)
+
+	r.GatherTime.Incr(elapsed.Nanoseconds())
+	r.GatherCPUTime.Incr(cpuTime.Nanoseconds())
+	r.GatherMetricBytes.Incr(metricBytes)
+
+	switch {
+	case r.Config.MaxGatherCPUTime > 0 && cpuTime > r.Config.MaxGatherCPUTime:
+		r.disable(fmt.Sprintf("gather thread used %s of CPU time, exceeding max_gather_cpu_time of %s",
+			cpuTime, r.Config.MaxGatherCPUTime))
+	case r.Config.MaxGatherMetricBytes > 0 && metricBytes > r.Config.MaxGatherMetricBytes:
+		r.disable(fmt.Sprintf("gather emitted %d bytes of metrics, exceeding max_gather_metric_bytes of %d bytes",
+			metricBytes, r.Config.MaxGatherMetricBytes))
+	}
+	return err
+}
+
+// disable stops the input from collecting metrics, the metrics it adds are
+// dropped from now on.
+func (r *RunningInput) disable(reason string) {
+	if !atomic.CompareAndSwapInt32(&r.disabled, 0, 1) {
+		return
+	}
+	r.Disabled.Set(1)
+	r.log.Errorf("Disabling input: %s", reason)
+}
+
+// IsDisabled returns true if the input was disabled for exceeding its
+// resource limits.
+func (r *RunningInput) IsDisabled() bool {
+	return atomic.LoadInt32(&r.disabled) == 1
+}
+
+// metricSize returns an estimate of the memory used by the metric.
+func metricSize(m telegraf.Metric) int64 {
+	size := len(m.Name()) + 8
+	for _, tag := range m.TagList() {
+		size += len(tag.Key) + len(tag.Value)
+	}
+	for _, field := range m.FieldList() {
+		size += len(field.Key)
+		if s, ok := field.Value.(string); ok {
+			size += len(s)
+		} else {
+			size += 8
+		}
+	}
+	return int64(size)
+}
+
+// checkTime enforces the limits on the metric timestamp, returning false if
+// the metric should be dropped.
+func (r *RunningInput) checkTime(m telegraf.Metric) bool {
+	if r.maxPast <= 0 && r.maxFuture <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	var limit time.Time
+	switch {
+	case r.maxPast > 0 && m.Time().Before(now.Add(-r.maxPast)):
+		limit = now.Add(-r.maxPast)
+	case r.maxFuture > 0 && m.Time().After(now.Add(r.maxFuture)):
+		limit = now.Add(r.maxFuture)
+	default:
+		return true
+	}
+
+	GlobalInvalidTimes.Incr(1)
+
+	switch r.invalidTimestampAction {
+	case InvalidTimestampClamp:
+		r.log.Debugf("Clamping timestamp %s of metric %q to %s", m.Time(), m.Name(), limit)
+		m.SetTime(limit)
+	case InvalidTimestampRewrite:
+		r.log.Debugf("Rewriting timestamp %s of metric %q to collection time", m.Time(), m.Name())
+		m.SetTime(now)
+	default:
+		r.log.Warnf("Dropping metric %q with timestamp %s outside of the allowed range", m.Name(), m.Time())
+		return false
+	}
+	return true
+}
+
 func (r *RunningInput) SetDefaultTags(tags map[string]string) {
 	r.defaultTags = tags
 }
 
+// SetTimestampLimits sets the agent limits on the metric timestamp, any
+// limits set in the plugin configuration take precedence.
+func (r *RunningInput) SetTimestampLimits(maxPast, maxFuture time.Duration, action string) {
+	r.maxPast = maxPast
+	if r.Config.MetricMaxPast != nil {
+		r.maxPast = *r.Config.MetricMaxPast
+	}
+
+	r.maxFuture = maxFuture
+	if r.Config.MetricMaxFuture != nil {
+		r.maxFuture = *r.Config.MetricMaxFuture
+	}
+
+	r.invalidTimestampAction = action
+	if r.Config.InvalidTimestampAction != "" {
+		r.invalidTimestampAction = r.Config.InvalidTimestampAction
+	}
+}
+
 func (r *RunningInput) Log() telegraf.Logger {
 	return r.log
 }
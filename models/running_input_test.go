@@ -287,8 +287,203 @@ func TestMetricErrorCounters(t *testing.T) {
 	require.GreaterOrEqual(t, int64(1), GlobalGatherErrors.Get())
 }
 
+func TestMakeMetricInvalidTimestamp(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		action   string
+		time     time.Time
+		expected func(t *testing.T, m telegraf.Metric)
+	}{
+		{
+			name:   "valid time is kept",
+			action: InvalidTimestampDrop,
+			time:   now.Add(-time.Minute),
+			expected: func(t *testing.T, m telegraf.Metric) {
+				require.NotNil(t, m)
+				require.Equal(t, now.Add(-time.Minute), m.Time())
+			},
+		},
+		{
+			name:   "drop past",
+			action: InvalidTimestampDrop,
+			time:   now.Add(-2 * time.Hour),
+			expected: func(t *testing.T, m telegraf.Metric) {
+				require.Nil(t, m)
+			},
+		},
+		{
+			name:   "drop future",
+			action: "",
+			time:   now.Add(time.Hour),
+			expected: func(t *testing.T, m telegraf.Metric) {
+				require.Nil(t, m)
+			},
+		},
+		{
+			name:   "clamp past",
+			action: InvalidTimestampClamp,
+			time:   now.Add(-2 * time.Hour),
+			expected: func(t *testing.T, m telegraf.Metric) {
+				require.NotNil(t, m)
+				require.WithinDuration(t, now.Add(-time.Hour), m.Time(), time.Second)
+			},
+		},
+		{
+			name:   "clamp future",
+			action: InvalidTimestampClamp,
+			time:   now.Add(time.Hour),
+			expected: func(t *testing.T, m telegraf.Metric) {
+				require.NotNil(t, m)
+				require.WithinDuration(t, now.Add(time.Minute), m.Time(), time.Second)
+			},
+		},
+		{
+			name:   "rewrite",
+			action: InvalidTimestampRewrite,
+			time:   now.Add(-2 * time.Hour),
+			expected: func(t *testing.T, m telegraf.Metric) {
+				require.NotNil(t, m)
+				require.WithinDuration(t, now, m.Time(), time.Second)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ri := NewRunningInput(&testInput{}, &InputConfig{
+				Name: "TestMakeMetricInvalidTimestamp",
+			})
+			ri.SetTimestampLimits(time.Hour, time.Minute, tt.action)
+			require.NoError(t, ri.Init())
+
+			m, err := metric.New("cpu",
+				map[string]string{},
+				map[string]interface{}{
+					"value": 42,
+				},
+				tt.time)
+			require.NoError(t, err)
+
+			tt.expected(t, ri.MakeMetric(m))
+		})
+	}
+}
+
+func TestMakeMetricInvalidTimestampPluginOverride(t *testing.T) {
+	now := time.Now()
+	maxPast := time.Duration(0)
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name:                   "TestMakeMetricInvalidTimestampPluginOverride",
+		MetricMaxPast:          &maxPast,
+		InvalidTimestampAction: InvalidTimestampRewrite,
+	})
+	ri.SetTimestampLimits(time.Hour, time.Minute, InvalidTimestampDrop)
+	require.NoError(t, ri.Init())
+
+	// The plugin has no limit on the age of the metric.
+	m, err := metric.New("cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"value": 42,
+		},
+		now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	actual := ri.MakeMetric(m)
+	require.NotNil(t, actual)
+	require.Equal(t, now.Add(-24*time.Hour), actual.Time())
+
+	// The agent limit on future timestamps still applies.
+	m, err = metric.New("cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"value": 42,
+		},
+		now.Add(time.Hour))
+	require.NoError(t, err)
+	actual = ri.MakeMetric(m)
+	require.NotNil(t, actual)
+	require.WithinDuration(t, now, actual.Time(), time.Second)
+}
+
+func TestInvalidTimestampActionError(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name: "TestInvalidTimestampActionError",
+	})
+	ri.SetTimestampLimits(time.Hour, 0, "foo")
+	require.Error(t, ri.Init())
+}
+
 type testInput struct{}
 
 func (t *testInput) Description() string                   { return "" }
 func (t *testInput) SampleConfig() string                  { return "" }
 func (t *testInput) Gather(acc telegraf.Accumulator) error { return nil }
+
+type gatherFuncInput struct {
+	testInput
+	gather func()
+}
+
+func (t *gatherFuncInput) Gather(acc telegraf.Accumulator) error {
+	t.gather()
+	return nil
+}
+
+func TestGatherMetricBytesLimit(t *testing.T) {
+	input := &gatherFuncInput{}
+	ri := NewRunningInput(input, &InputConfig{
+		Name:                 "TestGatherMetricBytesLimit",
+		MaxGatherMetricBytes: 100,
+	})
+	require.NoError(t, ri.Init())
+
+	newMetric := func() telegraf.Metric {
+		return testutil.MustMetric("cpu",
+			map[string]string{"host": "localhost"},
+			map[string]interface{}{"usage_idle": 99.0},
+			time.Unix(0, 0))
+	}
+
+	input.gather = func() {
+		require.NotNil(t, ri.MakeMetric(newMetric()))
+	}
+	require.NoError(t, ri.Gather(nil))
+	require.False(t, ri.IsDisabled())
+	require.Equal(t, int64(0), ri.Disabled.Get())
+
+	input.gather = func() {
+		for i := 0; i < 10; i++ {
+			require.NotNil(t, ri.MakeMetric(newMetric()))
+		}
+	}
+	require.NoError(t, ri.Gather(nil))
+	require.True(t, ri.IsDisabled())
+	require.Equal(t, int64(1), ri.Disabled.Get())
+
+	require.Nil(t, ri.MakeMetric(newMetric()))
+}
+
+func TestGatherCPUTimeLimit(t *testing.T) {
+	if !threadCPUTimeSupported {
+		t.Skip("CPU time of threads is not supported")
+	}
+
+	input := &gatherFuncInput{}
+	ri := NewRunningInput(input, &InputConfig{
+		Name:             "TestGatherCPUTimeLimit",
+		MaxGatherCPUTime: 10 * time.Millisecond,
+	})
+	require.NoError(t, ri.Init())
+
+	input.gather = func() {}
+	require.NoError(t, ri.Gather(nil))
+	require.False(t, ri.IsDisabled())
+
+	input.gather = func() {
+		start := threadCPUTime()
+		for threadCPUTime()-start < 20*time.Millisecond {
+		}
+	}
+	require.NoError(t, ri.Gather(nil))
+	require.True(t, ri.IsDisabled())
+}
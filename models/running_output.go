@@ -1,11 +1,14 @@
 package models
 
 import (
+	"errors"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
@@ -31,6 +34,29 @@ type OutputConfig struct {
 	NameOverride string
 	NamePrefix   string
 	NameSuffix   string
+
+	// Tags are required on the metrics of the output, metrics without all
+	// of the tags and values are filtered.
+	Tags map[string]string
+
+	// DeadLetterOutput is the name, or alias, of the output receiving the
+	// metrics rejected by this output, overriding the agent setting.  An
+	// empty name drops the rejected metrics.
+	DeadLetterOutput *string
+
+	// Fingerprint identifies the configuration of the output, outputs with
+	// the same fingerprint are configured identically.
+	Fingerprint string
+}
+
+// metricBuffer holds the metrics waiting to be written by an output.
+type metricBuffer interface {
+	Len() int
+	Add(metrics ...telegraf.Metric) int
+	Batch(batchSize int) []telegraf.Metric
+	Accept(batch []telegraf.Metric)
+	Reject(batch []telegraf.Metric)
+	Discard(metrics []telegraf.Metric)
 }
 
 // RunningOutput contains the output configuration
@@ -49,9 +75,22 @@ type RunningOutput struct {
 
 	BatchReady chan time.Time
 
-	buffer *Buffer
+	// BufferHigh receives a value when the buffer length reaches the
+	// BufferWatermark, which is disabled when set to 0.
+	BufferHigh      chan time.Time
+	BufferWatermark int
+
+	// watermarkCrossed is set to 1 once BufferHigh is signaled, and reset when
+	// a write drains the buffer below the watermark.
+	watermarkCrossed int32
+
+	buffer metricBuffer
 	log    telegraf.Logger
 
+	// deadLetter may be replaced by a reload while the output is running.
+	deadLetter      *RunningOutput
+	deadLetterMutex sync.Mutex
+
 	aggMutex sync.Mutex
 }
 
@@ -90,6 +129,7 @@ func NewRunningOutput(
 	ro := &RunningOutput{
 		buffer:            NewBuffer(config.Name, config.Alias, bufferLimit),
 		BatchReady:        make(chan time.Time, 1),
+		BufferHigh:        make(chan time.Time, 1),
 		Output:            output,
 		Config:            config,
 		MetricBufferLimit: bufferLimit,
@@ -134,16 +174,12 @@ func (r *RunningOutput) Init() error {
 //
 // Takes ownership of metric
 func (ro *RunningOutput) AddMetric(metric telegraf.Metric) {
-	if ok := ro.Config.Filter.Select(metric); !ok {
+	if !ro.filter(metric) {
 		ro.metricFiltered(metric)
 		return
 	}
 
-	ro.Config.Filter.Modify(metric)
-	if len(metric.FieldList()) == 0 {
-		ro.metricFiltered(metric)
-		return
-	}
+	ro.flatten(metric)
 
 	if output, ok := ro.Output.(telegraf.AggregatingOutput); ok {
 		ro.aggMutex.Lock()
@@ -152,21 +188,21 @@ func (ro *RunningOutput) AddMetric(metric telegraf.Metric) {
 		return
 	}
 
-	if len(ro.Config.NameOverride) > 0 {
-		metric.SetName(ro.Config.NameOverride)
-	}
-
-	if len(ro.Config.NamePrefix) > 0 {
-		metric.AddPrefix(ro.Config.NamePrefix)
-	}
-
-	if len(ro.Config.NameSuffix) > 0 {
-		metric.AddSuffix(ro.Config.NameSuffix)
-	}
+	ro.rename(metric)
 
 	dropped := ro.buffer.Add(metric)
 	atomic.AddInt64(&ro.droppedMetrics, int64(dropped))
 
+	// Only signal when the watermark is crossed, not while the buffer stays
+	// above it, so that a failing output is not flushed continuously.
+	if ro.BufferWatermark > 0 && ro.buffer.Len() >= ro.BufferWatermark &&
+		atomic.CompareAndSwapInt32(&ro.watermarkCrossed, 0, 1) {
+		select {
+		case ro.BufferHigh <- time.Now():
+		default:
+		}
+	}
+
 	count := atomic.AddInt64(&ro.newMetricsCount, 1)
 	if count == int64(ro.MetricBatchSize) {
 		atomic.StoreInt64(&ro.newMetricsCount, 0)
@@ -177,9 +213,67 @@ func (ro *RunningOutput) AddMetric(metric telegraf.Metric) {
 	}
 }
 
+// Simulate returns the metric as it would be added to the buffer of the
+// output, or nil if the output filters it.  The metric is modified.
+func (ro *RunningOutput) Simulate(metric telegraf.Metric) telegraf.Metric {
+	if !ro.filter(metric) {
+		return nil
+	}
+
+	ro.flatten(metric)
+
+	if _, ok := ro.Output.(telegraf.AggregatingOutput); !ok {
+		ro.rename(metric)
+	}
+	return metric
+}
+
+// flatten replaces the arrays and maps of the fields by their values unless
+// the output writes them as such.
+func (ro *RunningOutput) flatten(m telegraf.Metric) {
+	if output, ok := ro.Output.(telegraf.StructuredOutput); ok && output.StructuredFields() {
+		return
+	}
+	metric.FlattenFields(m, metric.FlattenSeparator)
+}
+
+// filter applies the filter of the output to the metric and returns false if
+// the metric is filtered.
+func (ro *RunningOutput) filter(metric telegraf.Metric) bool {
+	for k, v := range ro.Config.Tags {
+		if value, ok := metric.GetTag(k); !ok || value != v {
+			return false
+		}
+	}
+
+	if ok := ro.Config.Filter.Select(metric); !ok {
+		return false
+	}
+
+	ro.Config.Filter.Modify(metric)
+	return len(metric.FieldList()) != 0
+}
+
+// rename applies the name modifiers of the output to the metric.
+func (ro *RunningOutput) rename(metric telegraf.Metric) {
+	if len(ro.Config.NameOverride) > 0 {
+		metric.SetName(ro.Config.NameOverride)
+	}
+
+	if len(ro.Config.NamePrefix) > 0 {
+		metric.AddPrefix(ro.Config.NamePrefix)
+	}
+
+	if len(ro.Config.NameSuffix) > 0 {
+		metric.AddSuffix(ro.Config.NameSuffix)
+	}
+}
+
 // Write writes all metrics to the output, stopping when all have been sent on
 // or error.
 func (ro *RunningOutput) Write() error {
+	defer ro.resetWatermark()
+
 	if output, ok := ro.Output.(telegraf.AggregatingOutput); ok {
 		ro.aggMutex.Lock()
 		metrics := output.Push()
@@ -200,30 +294,84 @@ func (ro *RunningOutput) Write() error {
 			break
 		}
 
-		err := ro.write(batch)
+		err := ro.updateBuffer(batch, ro.write(batch))
 		if err != nil {
-			ro.buffer.Reject(batch)
 			return err
 		}
-		ro.buffer.Accept(batch)
 	}
 	return nil
 }
 
 // WriteBatch writes a single batch of metrics to the output.
 func (ro *RunningOutput) WriteBatch() error {
+	defer ro.resetWatermark()
+
 	batch := ro.buffer.Batch(ro.MetricBatchSize)
 	if len(batch) == 0 {
 		return nil
 	}
 
-	err := ro.write(batch)
-	if err != nil {
-		ro.buffer.Reject(batch)
-		return err
+	return ro.updateBuffer(batch, ro.write(batch))
+}
+
+// resetWatermark rearms the BufferHigh signal once the buffer is below the
+// watermark.
+func (ro *RunningOutput) resetWatermark() {
+	if ro.buffer.Len() < ro.BufferWatermark {
+		atomic.StoreInt32(&ro.watermarkCrossed, 0)
 	}
-	ro.buffer.Accept(batch)
+}
 
+// updateBuffer accepts or rejects the batch based on the result of the
+// write.  When the output reports a partial write only the metrics rejected
+// by the output are discarded, the rest of the batch is accepted or retried.
+func (ro *RunningOutput) updateBuffer(batch []telegraf.Metric, err error) error {
+	var partial *internal.PartialWriteError
+	if !errors.As(err, &partial) {
+		if err != nil {
+			ro.buffer.Reject(batch)
+			return err
+		}
+		ro.buffer.Accept(batch)
+		return nil
+	}
+
+	rejected := make(map[int]bool, len(partial.MetricsReject))
+	discard := make([]telegraf.Metric, 0, len(partial.MetricsReject))
+	for i, index := range partial.MetricsReject {
+		if index < 0 || index >= len(batch) || rejected[index] {
+			continue
+		}
+		rejected[index] = true
+		discard = append(discard, batch[index])
+
+		if i < len(partial.MetricsRejectErrors) && partial.MetricsRejectErrors[i] != nil {
+			ro.log.Debugf("Metric %q rejected: %v", batch[index].Name(), partial.MetricsRejectErrors[i])
+		}
+	}
+
+	keep := make([]telegraf.Metric, 0, len(batch)-len(discard))
+	for i, metric := range batch {
+		if !rejected[i] {
+			keep = append(keep, metric)
+		}
+	}
+
+	if len(discard) > 0 {
+		if deadLetter := ro.getDeadLetter(); deadLetter != nil {
+			ro.log.Errorf("Output rejected %d metrics; sending them to %s", len(discard), deadLetter.LogName())
+			ro.sendDeadLetters(deadLetter, discard)
+		} else {
+			ro.log.Errorf("Output rejected %d metrics; discarding them", len(discard))
+		}
+		ro.buffer.Discard(discard)
+	}
+
+	if partial.Err != nil {
+		ro.buffer.Reject(keep)
+		return partial.Err
+	}
+	ro.buffer.Accept(keep)
 	return nil
 }
 
@@ -262,6 +410,92 @@ func (r *RunningOutput) Log() telegraf.Logger {
 	return r.log
 }
 
+// SetDeadLetter sets the output receiving the metrics rejected by this output.
+func (r *RunningOutput) SetDeadLetter(output *RunningOutput) {
+	r.deadLetterMutex.Lock()
+	r.deadLetter = output
+	r.deadLetterMutex.Unlock()
+}
+
+func (r *RunningOutput) getDeadLetter() *RunningOutput {
+	r.deadLetterMutex.Lock()
+	defer r.deadLetterMutex.Unlock()
+	return r.deadLetter
+}
+
+// sendDeadLetters adds a copy of the rejected metrics to the dead letter
+// output, tagged with the reason and the rejecting output.
+func (r *RunningOutput) sendDeadLetters(deadLetter *RunningOutput, metrics []telegraf.Metric) {
+	source := r.Config.Name
+	if r.Config.Alias != "" {
+		source = r.Config.Alias
+	}
+
+	for _, m := range metrics {
+		m = m.Copy()
+		m.AddTag("dead_letter_reason", "rejected")
+		m.AddTag("dead_letter_source", source)
+		deadLetter.AddMetric(m)
+	}
+}
+
+// TakeBuffer takes over the buffered metrics of the previous output, when
+// reloading the configuration.  The previous output must be stopped, configured
+// identically and must not be used afterwards.  Disk buffers are not taken
+// over, their metrics are replayed when the buffer is opened again.
+func (r *RunningOutput) TakeBuffer(previous *RunningOutput) {
+	if _, ok := previous.buffer.(*DiskBuffer); ok {
+		return
+	}
+	r.buffer = previous.buffer
+}
+
+// DropBuffer drops the metrics buffered in memory and returns their number.
+// The output must be stopped.
+func (r *RunningOutput) DropBuffer() int {
+	b, ok := r.buffer.(*Buffer)
+	if !ok {
+		return 0
+	}
+
+	batch := b.Batch(b.Len())
+	for _, m := range batch {
+		b.metricDropped(m)
+	}
+	b.Accept(nil)
+	return len(batch)
+}
+
+// OpenDiskBuffer replaces the in-memory buffer by a buffer stored in dir, the
+// metrics already in memory are moved to it.  When maxSize is greater than 0
+// the oldest metrics are dropped once the buffer exceeds this size in bytes.
+func (r *RunningOutput) OpenDiskBuffer(dir string, maxSize int64) error {
+	db, err := NewDiskBuffer(r.Config.Name, r.Config.Alias, dir, maxSize, r.log)
+	if err != nil {
+		return err
+	}
+
+	if b, ok := r.buffer.(*Buffer); ok {
+		// The batch is ordered from newest to oldest.
+		batch := b.Batch(b.Len())
+		for i := len(batch) - 1; i >= 0; i-- {
+			db.Add(batch[i])
+		}
+		b.Accept(nil)
+	}
+
+	r.buffer = db
+	return nil
+}
+
+// CloseBuffer closes the disk buffer of the output, if any.
+func (r *RunningOutput) CloseBuffer() error {
+	if db, ok := r.buffer.(*DiskBuffer); ok {
+		return db.Close()
+	}
+	return nil
+}
+
 func (r *RunningOutput) BufferLength() int {
 	return r.buffer.Len()
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/selfstat"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
@@ -155,6 +156,32 @@ func TestRunningOutput_TagIncludeNoMatch(t *testing.T) {
 	assert.Empty(t, m.Metrics()[0].Tags())
 }
 
+// Test that metrics without the tags of the output are dropped
+func TestRunningOutput_Tags(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+		Tags:   map[string]string{"tag1": "value1"},
+	}
+	assert.NoError(t, conf.Filter.Compile())
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	other := testutil.TestMetric(101, "metric2")
+	other.AddTag("tag1", "value2")
+	untagged := testutil.TestMetric(101, "metric3")
+	untagged.RemoveTag("tag1")
+
+	ro.AddMetric(testutil.TestMetric(101, "metric1"))
+	ro.AddMetric(other)
+	ro.AddMetric(untagged)
+
+	err := ro.Write()
+	assert.NoError(t, err)
+	require.Len(t, m.Metrics(), 1)
+	assert.Equal(t, "metric1", m.Metrics()[0].Name())
+}
+
 // Test that tags are properly excluded
 func TestRunningOutput_TagExcludeMatch(t *testing.T) {
 	conf := &OutputConfig{
@@ -294,6 +321,37 @@ func TestRunningOutputDefault(t *testing.T) {
 	assert.Len(t, m.Metrics(), 10)
 }
 
+func TestRunningOutputStructuredFields(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+	}
+
+	fields := map[string]interface{}{
+		"value":  int64(1),
+		"labels": []interface{}{"a", "b"},
+		"owner":  map[string]interface{}{"name": "x"},
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+	ro.AddMetric(testutil.MustMetric("test", nil, fields, time.Unix(0, 0)))
+	require.NoError(t, ro.Write())
+	require.Len(t, m.Metrics(), 1)
+	require.Equal(t, map[string]interface{}{
+		"value":      int64(1),
+		"labels_0":   "a",
+		"labels_1":   "b",
+		"owner_name": "x",
+	}, m.Metrics()[0].Fields())
+
+	s := &structuredOutput{}
+	ro = NewRunningOutput("test", s, conf, 1000, 10000)
+	ro.AddMetric(testutil.MustMetric("test", nil, fields, time.Unix(0, 0)))
+	require.NoError(t, ro.Write())
+	require.Len(t, s.Metrics(), 1)
+	require.Equal(t, fields, s.Metrics()[0].Fields())
+}
+
 func TestRunningOutputWriteFail(t *testing.T) {
 	conf := &OutputConfig{
 		Filter: Filter{},
@@ -468,6 +526,150 @@ func TestRunningOutputWriteFailOrder3(t *testing.T) {
 	assert.Equal(t, expected, m.Metrics())
 }
 
+// Verify that only the metrics rejected by the output are discarded on a
+// partial write, and that the rest of the batch is accepted.
+func TestRunningOutputPartialWrite(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+	}
+
+	m := &mockOutput{}
+	m.rejectNames = map[string]bool{"metric2": true, "metric4": true}
+	ro := NewRunningOutput("test", m, conf, 10, 20)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	err := ro.Write()
+	require.NoError(t, err)
+	require.Equal(t, 0, ro.BufferLength())
+
+	expected := []telegraf.Metric{first5[0], first5[2], first5[4]}
+	testutil.RequireMetricsEqual(t, expected, reverse(m.Metrics()))
+}
+
+// Verify that the rejected metrics are sent to the dead letter output.
+func TestRunningOutputPartialWriteDeadLetter(t *testing.T) {
+	m := &mockOutput{}
+	m.rejectNames = map[string]bool{"metric2": true}
+	ro := NewRunningOutput("test", m, &OutputConfig{Name: "test", Alias: "primary"}, 10, 20)
+
+	dm := &mockOutput{}
+	dl := NewRunningOutput("dead", dm, &OutputConfig{Name: "dead"}, 10, 20)
+	ro.SetDeadLetter(dl)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	require.NoError(t, ro.Write())
+	require.Equal(t, 0, ro.BufferLength())
+	require.Len(t, m.Metrics(), 4)
+
+	require.NoError(t, dl.Write())
+	expected := first5[1].Copy()
+	expected.AddTag("dead_letter_reason", "rejected")
+	expected.AddTag("dead_letter_source", "primary")
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{expected}, dm.Metrics())
+}
+
+// Verify that on a partial write with an error the rejected metrics are
+// discarded, and the rest of the batch is retried.
+func TestRunningOutputPartialWriteRetry(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	m.rejectNames = map[string]bool{"metric2": true}
+	ro := NewRunningOutput("test", m, conf, 10, 20)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	err := ro.Write()
+	require.Error(t, err)
+	require.Equal(t, 4, ro.BufferLength())
+	require.Len(t, m.Metrics(), 0)
+
+	m.failWrite = false
+	err = ro.Write()
+	require.NoError(t, err)
+	require.Equal(t, 0, ro.BufferLength())
+
+	expected := []telegraf.Metric{first5[0], first5[2], first5[3], first5[4]}
+	testutil.RequireMetricsEqual(t, expected, reverse(m.Metrics()))
+}
+
+// Verify that reaching the buffer watermark is signaled once until the buffer
+// drops below it.
+func TestRunningOutputBufferHigh(t *testing.T) {
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, &OutputConfig{Name: "test"}, 10, 20)
+	ro.BufferWatermark = 3
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	select {
+	case <-ro.BufferHigh:
+	default:
+		t.Fatal("buffer watermark not signaled")
+	}
+
+	require.Error(t, ro.Write())
+	ro.AddMetric(next5[0])
+	select {
+	case <-ro.BufferHigh:
+		t.Fatal("buffer watermark signaled while above it")
+	default:
+	}
+
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	for _, metric := range next5[1:] {
+		ro.AddMetric(metric)
+	}
+	select {
+	case <-ro.BufferHigh:
+	default:
+		t.Fatal("buffer watermark not signaled after write")
+	}
+}
+
+// Verify that a full buffer at the watermark is signaled once, while the
+// buffer length stays at the watermark.
+func TestRunningOutputBufferHighFull(t *testing.T) {
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, &OutputConfig{Name: "test"}, 10, 3)
+	ro.BufferWatermark = 3
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	select {
+	case <-ro.BufferHigh:
+	default:
+		t.Fatal("buffer watermark not signaled")
+	}
+
+	require.Error(t, ro.Write())
+	for _, metric := range next5 {
+		ro.AddMetric(metric)
+	}
+	select {
+	case <-ro.BufferHigh:
+		t.Fatal("buffer watermark signaled while full")
+	default:
+	}
+}
+
 func TestInternalMetrics(t *testing.T) {
 	_ = NewRunningOutput(
 		"test_internal",
@@ -494,6 +696,7 @@ func TestInternalMetrics(t *testing.T) {
 				"metrics_added":    0,
 				"metrics_dropped":  0,
 				"metrics_filtered": 0,
+				"metrics_rejected": 0,
 				"metrics_written":  0,
 				"write_time_ns":    0,
 			},
@@ -519,6 +722,9 @@ type mockOutput struct {
 
 	// if true, mock a write failure
 	failWrite bool
+
+	// metrics with these names are rejected by the output
+	rejectNames map[string]bool
 }
 
 func (m *mockOutput) Connect() error {
@@ -540,7 +746,19 @@ func (m *mockOutput) SampleConfig() string {
 func (m *mockOutput) Write(metrics []telegraf.Metric) error {
 	m.Lock()
 	defer m.Unlock()
+
+	partial := &internal.PartialWriteError{}
+	for i, metric := range metrics {
+		if m.rejectNames[metric.Name()] {
+			partial.MetricsReject = append(partial.MetricsReject, i)
+		}
+	}
+
 	if m.failWrite {
+		if len(partial.MetricsReject) > 0 {
+			partial.Err = fmt.Errorf("Failed Write!")
+			return partial
+		}
 		return fmt.Errorf("Failed Write!")
 	}
 
@@ -549,7 +767,13 @@ func (m *mockOutput) Write(metrics []telegraf.Metric) error {
 	}
 
 	for _, metric := range metrics {
-		m.metrics = append(m.metrics, metric)
+		if !m.rejectNames[metric.Name()] {
+			m.metrics = append(m.metrics, metric)
+		}
+	}
+
+	if len(partial.MetricsReject) > 0 {
+		return partial
 	}
 	return nil
 }
@@ -560,9 +784,20 @@ func (m *mockOutput) Metrics() []telegraf.Metric {
 	return m.metrics
 }
 
+type structuredOutput struct {
+	mockOutput
+}
+
+func (s *structuredOutput) StructuredFields() bool {
+	return true
+}
+
 type perfOutput struct {
 	// if true, mock a write failure
 	failWrite bool
+
+	// metrics with these names are rejected by the output
+	rejectNames map[string]bool
 }
 
 func (m *perfOutput) Connect() error {
@@ -26,6 +26,10 @@ type ProcessorConfig struct {
 	Alias  string
 	Order  int64
 	Filter Filter
+
+	// Fingerprint identifies the configuration of the processor, processors
+	// with the same fingerprint are configured identically.
+	Fingerprint string
 }
 
 func NewRunningProcessor(processor telegraf.StreamingProcessor, config *ProcessorConfig) *RunningProcessor {
@@ -24,3 +24,12 @@ type AggregatingOutput interface {
 	// Reset signals the the aggregator period is completed.
 	Reset()
 }
+
+// StructuredOutput is implemented by the outputs writing documents, which can
+// store arrays and maps as field values.  The structured fields of the metrics
+// are flattened for the other outputs.
+type StructuredOutput interface {
+	// StructuredFields returns true if the output writes the arrays and maps
+	// of the fields as such.
+	StructuredFields() bool
+}
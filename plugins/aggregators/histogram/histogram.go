@@ -1,10 +1,14 @@
 package histogram
 
 import (
+	"fmt"
+	"math"
 	"sort"
 	"strconv"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/aggregators"
 )
 
@@ -20,21 +24,49 @@ const bucketLeftTag = "gt"
 // bucketNegInf is the left bucket border for infinite values
 const bucketNegInf = "-Inf"
 
+// bucketScaleLinear spaces derived buckets evenly between the observed bounds
+const bucketScaleLinear = "linear"
+
+// bucketScaleExponential spaces derived buckets by a constant factor between the observed bounds
+const bucketScaleExponential = "exponential"
+
+// defaultWarmup is the default duration values are observed to derive buckets
+var defaultWarmup = internal.Duration{Duration: time.Minute}
+
 // HistogramAggregator is aggregator with histogram configs and particular histograms for defined metrics
 type HistogramAggregator struct {
-	Configs      []config `toml:"config"`
-	ResetBuckets bool     `toml:"reset"`
-	Cumulative   bool     `toml:"cumulative"`
+	Configs       []config          `toml:"config"`
+	ResetBuckets  bool              `toml:"reset"`
+	ResetInterval internal.Duration `toml:"reset_interval"`
+	Cumulative    bool              `toml:"cumulative"`
 
 	buckets bucketsByMetrics
 	cache   map[uint64]metricHistogramCollection
+
+	// derived contains the buckets derived from observed values, which are
+	// kept when the histogram is reset
+	derived bucketsByMetrics
+	warmups map[string]map[string]*warmup
+
+	nextReset time.Time
+	now       func() time.Time
 }
 
 // config is the config, which contains name, field of metric and histogram buckets.
 type config struct {
-	Metric  string   `toml:"measurement_name"`
-	Fields  []string `toml:"fields"`
-	Buckets buckets  `toml:"buckets"`
+	Metric      string            `toml:"measurement_name"`
+	Fields      []string          `toml:"fields"`
+	Buckets     buckets           `toml:"buckets"`
+	BucketCount int               `toml:"bucket_count"`
+	BucketScale string            `toml:"bucket_scale"`
+	Warmup      internal.Duration `toml:"warmup"`
+}
+
+// warmup tracks the range of the values observed to derive buckets
+type warmup struct {
+	start time.Time
+	min   float64
+	max   float64
 }
 
 // bucketsByMetrics contains the buckets grouped by metric and field name
@@ -67,8 +99,11 @@ type groupedByCountFields struct {
 func NewHistogramAggregator() *HistogramAggregator {
 	h := &HistogramAggregator{
 		Cumulative: true,
+		now:        time.Now,
 	}
 	h.buckets = make(bucketsByMetrics)
+	h.derived = make(bucketsByMetrics)
+	h.warmups = make(map[string]map[string]*warmup)
 	h.resetCache()
 
 	return h
@@ -86,6 +121,11 @@ var sampleConfig = `
   ## of accumulating the results.
   reset = false
 
+  ## If set and reset is false, the histogram accumulates the results and is
+  ## reset on the first flush after each interval, aligned to the clock.  For
+  ## example with "1h" the histogram is reset at the start of every hour.
+  # reset_interval = "0s"
+
   ## Whether bucket values should be accumulated. If set to false, "gt" tag will be added.
   ## Defaults to true.
   cumulative = true
@@ -105,6 +145,19 @@ var sampleConfig = `
   #   measurement_name = "diskio"
   #   ## The concrete fields of metric
   #   fields = ["io_time", "read_time", "write_time"]
+
+  ## Example config that derives the buckets from the observed values.
+  # [[aggregators.histogram.config]]
+  #   ## Number of buckets to derive from the range of values observed
+  #   ## during the warmup, used when no buckets are set.
+  #   bucket_count = 10
+  #   ## Spacing of the derived buckets, "linear" or "exponential".
+  #   bucket_scale = "linear"
+  #   ## Duration values are observed before the buckets are derived.
+  #   warmup = "1m"
+  #   ## The name of metric.
+  #   measurement_name = "http_response"
+  #   fields = ["response_time"]
 `
 
 // SampleConfig returns sample of config
@@ -117,11 +170,47 @@ func (h *HistogramAggregator) Description() string {
 	return "Create aggregate histograms."
 }
 
+// Init validates the histogram configs
+func (h *HistogramAggregator) Init() error {
+	for i := range h.Configs {
+		cfg := &h.Configs[i]
+		if len(cfg.Buckets) > 0 || cfg.BucketCount == 0 {
+			continue
+		}
+
+		if cfg.BucketCount < 0 {
+			return fmt.Errorf("invalid bucket_count %d for %q", cfg.BucketCount, cfg.Metric)
+		}
+
+		switch cfg.BucketScale {
+		case "":
+			cfg.BucketScale = bucketScaleLinear
+		case bucketScaleLinear, bucketScaleExponential:
+		default:
+			return fmt.Errorf("invalid bucket_scale %q for %q", cfg.BucketScale, cfg.Metric)
+		}
+
+		if cfg.Warmup.Duration <= 0 {
+			cfg.Warmup = defaultWarmup
+		}
+	}
+	return nil
+}
+
 // Add adds new hit to the buckets
 func (h *HistogramAggregator) Add(in telegraf.Metric) {
 	bucketsByField := make(map[string][]float64)
-	for field := range in.Fields() {
+	for field, value := range in.Fields() {
 		buckets := h.getBuckets(in.Name(), field)
+		if buckets == nil {
+			// The value contributes to the range of derived buckets, once
+			// the warmup is over the buckets are used for this value too.
+			if cfg := h.getDerivedConfig(in.Name(), field); cfg != nil {
+				if value, ok := convert(value); ok {
+					buckets = h.observe(cfg, in.Name(), field, value)
+				}
+			}
+		}
 		if buckets != nil {
 			bucketsByField[field] = buckets
 		}
@@ -159,6 +248,8 @@ func (h *HistogramAggregator) Add(in telegraf.Metric) {
 
 // Push returns histogram values for metrics
 func (h *HistogramAggregator) Push(acc telegraf.Accumulator) {
+	h.finishWarmups()
+
 	metricsWithGroupedFields := []groupedByCountFields{}
 
 	for _, aggregate := range h.cache {
@@ -235,6 +326,18 @@ func (h *HistogramAggregator) Reset() {
 	if h.ResetBuckets {
 		h.resetCache()
 		h.buckets = make(bucketsByMetrics)
+		return
+	}
+
+	if h.ResetInterval.Duration > 0 {
+		now := h.now()
+		if h.nextReset.IsZero() {
+			h.nextReset = now.Truncate(h.ResetInterval.Duration).Add(h.ResetInterval.Duration)
+		}
+		if !now.Before(h.nextReset) {
+			h.resetCache()
+			h.nextReset = now.Truncate(h.ResetInterval.Duration).Add(h.ResetInterval.Duration)
+		}
 	}
 }
 
@@ -255,17 +358,119 @@ func (h *HistogramAggregator) getBuckets(metric string, field string) []float64
 				continue
 			}
 
+			buckets := config.Buckets
+			if len(buckets) == 0 && config.BucketCount > 0 {
+				derived, ok := h.derived[metric][field]
+				if !ok {
+					continue
+				}
+				buckets = derived
+			}
+
 			if _, ok := h.buckets[metric]; !ok {
 				h.buckets[metric] = make(bucketsByFields)
 			}
 
-			h.buckets[metric][field] = sortBuckets(config.Buckets)
+			h.buckets[metric][field] = sortBuckets(buckets)
 		}
 	}
 
 	return h.buckets[metric][field]
 }
 
+// getDerivedConfig finds the config deriving the buckets for the field
+func (h *HistogramAggregator) getDerivedConfig(metric string, field string) *config {
+	for i, config := range h.Configs {
+		if config.Metric == metric && len(config.Buckets) == 0 && config.BucketCount > 0 && isBucketExists(field, config) {
+			return &h.Configs[i]
+		}
+	}
+	return nil
+}
+
+// observe adds the value to the range observed during the warmup and returns
+// the derived buckets once the warmup is over
+func (h *HistogramAggregator) observe(cfg *config, metric string, field string, value float64) []float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil
+	}
+
+	now := h.now()
+	if _, ok := h.warmups[metric]; !ok {
+		h.warmups[metric] = make(map[string]*warmup)
+	}
+
+	w, ok := h.warmups[metric][field]
+	if !ok {
+		h.warmups[metric][field] = &warmup{start: now, min: value, max: value}
+		return nil
+	}
+
+	w.min = math.Min(w.min, value)
+	w.max = math.Max(w.max, value)
+	if now.Sub(w.start) < cfg.Warmup.Duration {
+		return nil
+	}
+
+	h.derive(cfg, metric, field, w)
+	return h.getBuckets(metric, field)
+}
+
+// finishWarmups derives the buckets of the fields with a finished warmup
+func (h *HistogramAggregator) finishWarmups() {
+	now := h.now()
+	for metric, fields := range h.warmups {
+		for field, w := range fields {
+			cfg := h.getDerivedConfig(metric, field)
+			if cfg == nil || now.Sub(w.start) < cfg.Warmup.Duration {
+				continue
+			}
+			h.derive(cfg, metric, field, w)
+		}
+	}
+}
+
+// derive sets the derived buckets of the field and ends the warmup
+func (h *HistogramAggregator) derive(cfg *config, metric string, field string, w *warmup) {
+	if _, ok := h.derived[metric]; !ok {
+		h.derived[metric] = make(bucketsByFields)
+	}
+	h.derived[metric][field] = deriveBuckets(cfg.BucketCount, cfg.BucketScale, w.min, w.max)
+
+	delete(h.warmups[metric], field)
+	if len(h.warmups[metric]) == 0 {
+		delete(h.warmups, metric)
+	}
+}
+
+// deriveBuckets returns count right borders of buckets spanning the range
+// between min and max, rounded to six significant digits.  The exponential
+// scale falls back to the linear scale if the range is not positive.
+func deriveBuckets(count int, scale string, min float64, max float64) []float64 {
+	if count == 1 || min == max {
+		return []float64{max}
+	}
+
+	result := make([]float64, 0, count)
+	for i := 0; i < count; i++ {
+		ratio := float64(i) / float64(count-1)
+
+		var bound float64
+		if scale == bucketScaleExponential && min > 0 {
+			bound = min * math.Pow(max/min, ratio)
+		} else {
+			bound = min + (max-min)*ratio
+		}
+
+		bound, _ = strconv.ParseFloat(strconv.FormatFloat(bound, 'g', 6, 64), 64)
+		if len(result) > 0 && result[len(result)-1] == bound {
+			continue
+		}
+		result = append(result, bound)
+	}
+	return result
+}
+
 // isBucketExists checks if buckets exists for the passed field
 func isBucketExists(field string, cfg config) bool {
 	if len(cfg.Fields) == 0 {
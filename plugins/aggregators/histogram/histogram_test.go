@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
@@ -238,6 +239,93 @@ func TestHistogramWithTwoPeriodsAndAllFields(t *testing.T) {
 	assertContainsTaggedField(t, acc, "first_metric_name", fields{"a_bucket": int64(2), "b_bucket": int64(1), "c_bucket": int64(1)}, tags{bucketRightTag: bucketPosInf})
 }
 
+// TestHistogramWithResetInterval tests that the histogram is reset on the first flush after the aligned interval
+func TestHistogramWithResetInterval(t *testing.T) {
+	var cfg []config
+	cfg = append(cfg, config{Metric: "first_metric_name", Fields: []string{"a"}, Buckets: []float64{0.0, 10.0, 20.0, 30.0, 40.0}})
+	histogram := NewHistogramAggregator()
+	histogram.Configs = cfg
+	histogram.ResetInterval = internal.Duration{Duration: time.Hour}
+
+	now := time.Date(2020, 1, 1, 10, 30, 0, 0, time.UTC)
+	histogram.now = func() time.Time { return now }
+
+	histogram.Add(firstMetric1)
+	histogram.Reset()
+	now = now.Add(20 * time.Minute)
+	histogram.Add(firstMetric2)
+	histogram.Reset()
+
+	acc := &testutil.Accumulator{}
+	histogram.Push(acc)
+	assertContainsTaggedField(t, acc, "first_metric_name", fields{"a_bucket": int64(2)}, tags{bucketRightTag: bucketPosInf})
+
+	// The histogram is reset at 11:00, the first flush afterwards is at 11:10.
+	now = now.Add(20 * time.Minute)
+	histogram.Reset()
+	histogram.Add(firstMetric2)
+
+	acc.ClearMetrics()
+	histogram.Push(acc)
+	assertContainsTaggedField(t, acc, "first_metric_name", fields{"a_bucket": int64(1)}, tags{bucketRightTag: bucketPosInf})
+}
+
+// TestHistogramDerivedBuckets tests buckets derived from the values observed during the warmup
+func TestHistogramDerivedBuckets(t *testing.T) {
+	histogram := NewHistogramAggregator()
+	histogram.Configs = []config{
+		{Metric: "latency", Fields: []string{"value"}, BucketCount: 3, Warmup: internal.Duration{Duration: time.Minute}},
+	}
+	histogram.ResetBuckets = true
+	assert.NoError(t, histogram.Init())
+
+	now := time.Unix(0, 0)
+	histogram.now = func() time.Time { return now }
+
+	add := func(value float64) {
+		m, _ := metric.New("latency", tags{}, fields{"value": value}, now)
+		histogram.Add(m)
+	}
+
+	// Values during the warmup are not counted.
+	add(10)
+	add(30)
+	acc := &testutil.Accumulator{}
+	histogram.Push(acc)
+	assert.Len(t, acc.Metrics, 0)
+	histogram.Reset()
+
+	now = now.Add(time.Minute)
+	add(20)
+	add(50)
+	histogram.Push(acc)
+	assert.Len(t, acc.Metrics, 4)
+	assertContainsTaggedField(t, acc, "latency", fields{"value_bucket": int64(0)}, tags{bucketRightTag: "10"})
+	assertContainsTaggedField(t, acc, "latency", fields{"value_bucket": int64(1)}, tags{bucketRightTag: "20"})
+	assertContainsTaggedField(t, acc, "latency", fields{"value_bucket": int64(1)}, tags{bucketRightTag: "30"})
+	assertContainsTaggedField(t, acc, "latency", fields{"value_bucket": int64(2)}, tags{bucketRightTag: bucketPosInf})
+
+	// The derived buckets are kept on reset.
+	histogram.Reset()
+	add(5)
+	acc.ClearMetrics()
+	histogram.Push(acc)
+	assertContainsTaggedField(t, acc, "latency", fields{"value_bucket": int64(1)}, tags{bucketRightTag: "10"})
+}
+
+func TestDeriveBuckets(t *testing.T) {
+	assert.Equal(t, []float64{0, 25, 50, 75, 100}, deriveBuckets(5, bucketScaleLinear, 0, 100))
+	assert.Equal(t, []float64{1, 10, 100, 1000}, deriveBuckets(4, bucketScaleExponential, 1, 1000))
+	assert.Equal(t, []float64{-10, 0, 10}, deriveBuckets(3, bucketScaleExponential, -10, 10))
+	assert.Equal(t, []float64{42}, deriveBuckets(3, bucketScaleLinear, 42, 42))
+}
+
+func TestInitInvalidBucketScale(t *testing.T) {
+	histogram := NewHistogramAggregator()
+	histogram.Configs = []config{{Metric: "latency", BucketCount: 3, BucketScale: "log"}}
+	assert.Error(t, histogram.Init())
+}
+
 // TestWrongBucketsOrder tests the calling panic with incorrect order of buckets
 func TestWrongBucketsOrder(t *testing.T) {
 	defer func() {
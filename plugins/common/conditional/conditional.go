@@ -0,0 +1,130 @@
+// Package conditional contains helpers for processors that apply rules only
+// to metrics matching a set of conditions, with values that may be computed
+// from the metric using Go templates.
+package conditional
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+)
+
+// Condition matches metrics by the value of their tags and the absence of
+// fields.  All configured conditions must match.
+type Condition struct {
+	tags    map[string]filter.Filter
+	missing []string
+}
+
+// NewCondition creates a condition matching metrics where each tag in
+// whenTag has a value matching one of its glob patterns, and none of the
+// fields in whenFieldMissing is set.
+func NewCondition(whenTag map[string][]string, whenFieldMissing []string) (*Condition, error) {
+	c := &Condition{
+		tags:    make(map[string]filter.Filter, len(whenTag)),
+		missing: whenFieldMissing,
+	}
+	for key, patterns := range whenTag {
+		if len(patterns) == 0 {
+			return nil, fmt.Errorf("no patterns for tag %q", key)
+		}
+		f, err := filter.Compile(patterns)
+		if err != nil {
+			return nil, fmt.Errorf("invalid patterns for tag %q: %v", key, err)
+		}
+		c.tags[key] = f
+	}
+	return c, nil
+}
+
+// Match reports whether the metric matches the condition.
+func (c *Condition) Match(metric telegraf.Metric) bool {
+	for key, f := range c.tags {
+		value, ok := metric.GetTag(key)
+		if !ok || !f.Match(value) {
+			return false
+		}
+	}
+	for _, key := range c.missing {
+		if metric.HasField(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// Value is a configured value.  String values containing template actions
+// are executed as Go templates with the metric, providing the Name, Tag,
+// Field and Time methods.
+type Value struct {
+	value interface{}
+	tmpl  *template.Template
+}
+
+// NewValue creates a value, parsing it as a template if needed.
+func NewValue(value interface{}) (*Value, error) {
+	v := &Value{value: value}
+
+	s, ok := value.(string)
+	if !ok || !strings.Contains(s, "{{") {
+		return v, nil
+	}
+
+	tmpl, err := template.New("value").Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	v.tmpl = tmpl
+	return v, nil
+}
+
+// Eval returns the value for the metric.
+func (v *Value) Eval(metric telegraf.Metric) (interface{}, error) {
+	if v.tmpl == nil {
+		return v.value, nil
+	}
+
+	var b strings.Builder
+	if err := v.tmpl.Execute(&b, &templateMetric{metric}); err != nil {
+		return nil, err
+	}
+	return b.String(), nil
+}
+
+// String returns the value for the metric as a string.
+func (v *Value) String(metric telegraf.Metric) (string, error) {
+	value, err := v.Eval(metric)
+	if err != nil {
+		return "", err
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+type templateMetric struct {
+	metric telegraf.Metric
+}
+
+func (m *templateMetric) Name() string {
+	return m.metric.Name()
+}
+
+func (m *templateMetric) Tag(key string) string {
+	value, _ := m.metric.GetTag(key)
+	return value
+}
+
+func (m *templateMetric) Field(key string) interface{} {
+	value, _ := m.metric.GetField(key)
+	return value
+}
+
+func (m *templateMetric) Time() time.Time {
+	return m.metric.Time()
+}
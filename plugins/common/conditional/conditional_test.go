@@ -0,0 +1,90 @@
+package conditional
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCondition(t *testing.T) {
+	m := testutil.MustMetric("cpu",
+		map[string]string{"env": "production", "host": "a"},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0),
+	)
+
+	tests := []struct {
+		name             string
+		whenTag          map[string][]string
+		whenFieldMissing []string
+		expected         bool
+	}{
+		{
+			name:     "no conditions",
+			expected: true,
+		},
+		{
+			name:     "tag matches glob",
+			whenTag:  map[string][]string{"env": {"prod*"}},
+			expected: true,
+		},
+		{
+			name:     "tag does not match",
+			whenTag:  map[string][]string{"env": {"staging", "dev*"}},
+			expected: false,
+		},
+		{
+			name:     "tag missing",
+			whenTag:  map[string][]string{"region": {"*"}},
+			expected: false,
+		},
+		{
+			name:             "field missing",
+			whenFieldMissing: []string{"status"},
+			expected:         true,
+		},
+		{
+			name:             "field present",
+			whenTag:          map[string][]string{"env": {"prod*"}},
+			whenFieldMissing: []string{"status", "value"},
+			expected:         false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewCondition(tt.whenTag, tt.whenFieldMissing)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, c.Match(m))
+		})
+	}
+}
+
+func TestConditionNoPatterns(t *testing.T) {
+	_, err := NewCondition(map[string][]string{"env": {}}, nil)
+	require.Error(t, err)
+}
+
+func TestValue(t *testing.T) {
+	m := testutil.MustMetric("cpu",
+		map[string]string{"host": "a"},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0),
+	)
+
+	v, err := NewValue(int64(3))
+	require.NoError(t, err)
+	value, err := v.Eval(m)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), value)
+
+	v, err = NewValue(`{{ .Name }}-{{ .Tag "host" }}-{{ .Field "value" }}`)
+	require.NoError(t, err)
+	s, err := v.String(m)
+	require.NoError(t, err)
+	require.Equal(t, "cpu-a-42", s)
+
+	_, err = NewValue(`{{ .Tag "host" `)
+	require.Error(t, err)
+}
@@ -0,0 +1,71 @@
+package encoding
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// CheckCompression returns an error if the compression is not supported.
+func CheckCompression(compression string) error {
+	switch compression {
+	case "", "none", "auto", "gzip", "zstd":
+		return nil
+	}
+	return fmt.Errorf("unsupported compression %q", compression)
+}
+
+// NewDecompressor returns a reader decompressing the content read from r.
+// The compression is one of none, gzip, zstd, or auto to detect gzip and
+// zstd content from its first bytes.
+func NewDecompressor(compression string, r io.Reader) (io.ReadCloser, error) {
+	if compression == "auto" {
+		br := bufio.NewReader(r)
+		// Peek fails on content shorter than the magic number, which is
+		// then not compressed.
+		magic, _ := br.Peek(len(zstdMagic))
+		switch {
+		case bytes.HasPrefix(magic, gzipMagic):
+			compression = "gzip"
+		case bytes.HasPrefix(magic, zstdMagic):
+			compression = "zstd"
+		default:
+			compression = "none"
+		}
+		r = br
+	}
+
+	switch compression {
+	case "", "none":
+		return ioutil.NopCloser(r), nil
+	case "gzip":
+		return gzip.NewReader(r)
+	case "zstd":
+		z, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return &zstdReader{z}, nil
+	}
+	return nil, fmt.Errorf("unsupported compression %q", compression)
+}
+
+// zstdReader releases the resources of the decoder on Close.
+type zstdReader struct {
+	*zstd.Decoder
+}
+
+func (r *zstdReader) Close() error {
+	r.Decoder.Close()
+	return nil
+}
@@ -0,0 +1,180 @@
+// Package encoding decodes the content of files read by the inputs: the
+// decompression of the file and the decoding of its character set to UTF-8.
+package encoding
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Decoder decodes text in a character encoding to UTF-8.
+type Decoder struct {
+	name string
+	enc  encoding.Encoding
+	// utf16 is set for the UTF-16 encodings, which cannot be decoded line by
+	// line after splitting on the '\n' byte.
+	utf16     bool
+	bigEndian bool
+	detectBOM bool
+}
+
+// NewDecoder returns a decoder of the character encoding.  The supported
+// encodings are utf-8, utf-16le, utf-16be, utf-16 which detects the byte
+// order mark and defaults to little endian, iso-8859-1 and windows-1252.
+// An empty name, or a nil decoder, decodes nothing.
+func NewDecoder(name string) (*Decoder, error) {
+	d := &Decoder{name: strings.ToLower(name)}
+	switch d.name {
+	case "":
+		d.enc = encoding.Nop
+	case "utf-8", "utf8":
+		d.enc = encoding.Nop
+		d.detectBOM = true
+	case "utf-16le":
+		d.enc = unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+		d.utf16 = true
+	case "utf-16be":
+		d.enc = unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+		d.utf16 = true
+		d.bigEndian = true
+	case "utf-16":
+		d.enc = unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+		d.utf16 = true
+		d.detectBOM = true
+	case "iso-8859-1", "latin1":
+		d.enc = charmap.ISO8859_1
+	case "windows-1252", "cp1252":
+		d.enc = charmap.Windows1252
+	default:
+		return nil, fmt.Errorf("unsupported character encoding %q", name)
+	}
+	return d, nil
+}
+
+// Reader returns a reader decoding the content read from r.  A byte order
+// mark at the start of the content is removed.
+func (d *Decoder) Reader(r io.Reader) io.Reader {
+	if d == nil || d.name == "" {
+		return r
+	}
+	return transform.NewReader(r, d.transformer())
+}
+
+// Bytes decodes the buffer.
+func (d *Decoder) Bytes(b []byte) ([]byte, error) {
+	if d == nil || d.name == "" {
+		return b, nil
+	}
+	out, _, err := transform.Bytes(d.transformer(), b)
+	return out, err
+}
+
+func (d *Decoder) transformer() transform.Transformer {
+	if d.detectBOM && !d.utf16 {
+		return unicode.BOMOverride(d.enc.NewDecoder())
+	}
+	return d.enc.NewDecoder()
+}
+
+// NewLineDecoder returns a decoder of the lines of a file that are split on
+// the '\n' byte, as read by the tail input.
+func (d *Decoder) NewLineDecoder() *LineDecoder {
+	return &LineDecoder{
+		decoder:   d,
+		first:     true,
+		bigEndian: d.bigEndian,
+	}
+}
+
+// LineDecoder decodes the lines of a file read by splitting its content on
+// the '\n' byte.  The UTF-16 lines are reassembled since both bytes of a
+// character can be '\n'.
+type LineDecoder struct {
+	decoder   *Decoder
+	first     bool
+	bigEndian bool
+	pending   []byte
+	// skipHigh is set when the next line starts with the high byte of the
+	// newline ending the previous line.
+	skipHigh bool
+}
+
+var errSplitCharacter = errors.New("character split as a newline")
+
+// Decode decodes the bytes read up to a '\n' byte, which is not part of the
+// line.  With UTF-16 the line is complete only once the following bytes are
+// read: ok is false until then.
+func (l *LineDecoder) Decode(line string) (text string, ok bool, err error) {
+	b := []byte(line)
+	if !l.decoder.utf16 {
+		var out []byte
+		if l.first {
+			l.first = false
+			out, err = l.decoder.Bytes(b)
+		} else {
+			out, err = l.decoder.enc.NewDecoder().Bytes(b)
+		}
+		return string(out), err == nil, err
+	}
+
+	if l.first {
+		l.first = false
+		if len(b) >= 2 {
+			switch {
+			case b[0] == 0xfe && b[1] == 0xff:
+				l.bigEndian = l.bigEndian || l.decoder.detectBOM
+				b = b[2:]
+			case b[0] == 0xff && b[1] == 0xfe:
+				b = b[2:]
+			}
+		}
+	}
+
+	if l.skipHigh {
+		l.skipHigh = false
+		if len(b) > 0 {
+			// Otherwise the previous line ended with a character whose low
+			// byte is '\n' but which is not a newline.  It is lost.
+			if b[0] != 0 {
+				err = errSplitCharacter
+			}
+			b = b[1:]
+		}
+	}
+	l.pending = append(l.pending, b...)
+
+	// The '\n' byte removed by the split completes a code unit when the
+	// pending bytes have an odd length, otherwise it starts one.
+	n := len(l.pending)
+	if l.bigEndian {
+		if n%2 == 1 && l.pending[n-1] == 0 {
+			return l.flush(l.pending[:n-1], err)
+		}
+	} else if n%2 == 0 {
+		// The high byte of the newline starts the next line.
+		l.skipHigh = true
+		return l.flush(l.pending, err)
+	}
+	l.pending = append(l.pending, '\n')
+	return "", false, err
+}
+
+func (l *LineDecoder) flush(b []byte, err error) (string, bool, error) {
+	enc := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	if l.bigEndian {
+		enc = unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+	}
+	out, decodeErr := enc.NewDecoder().Bytes(b)
+	l.pending = l.pending[:0]
+	if decodeErr != nil {
+		return "", false, decodeErr
+	}
+	return string(out), true, err
+}
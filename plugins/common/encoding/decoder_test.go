@@ -0,0 +1,183 @@
+package encoding
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestDecoderReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		input    []byte
+		expected string
+	}{
+		{
+			name:     "empty",
+			input:    []byte("\xef\xbb\xbfcafé"),
+			expected: "\ufeffcafé",
+		},
+		{
+			name:     "utf-8 byte order mark",
+			encoding: "utf-8",
+			input:    []byte("\xef\xbb\xbfcafé"),
+			expected: "café",
+		},
+		{
+			name:     "iso-8859-1",
+			encoding: "iso-8859-1",
+			input:    []byte("caf\xe9"),
+			expected: "café",
+		},
+		{
+			name:     "utf-16le",
+			encoding: "utf-16le",
+			input:    []byte("c\x00a\x00f\x00\xe9\x00"),
+			expected: "café",
+		},
+		{
+			name:     "utf-16 big endian byte order mark",
+			encoding: "utf-16",
+			input:    []byte("\xfe\xff\x00c\x00a\x00f\x00\xe9"),
+			expected: "café",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDecoder(tt.encoding)
+			require.NoError(t, err)
+			actual, err := ioutil.ReadAll(d.Reader(bytes.NewReader(tt.input)))
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(actual))
+		})
+	}
+
+	_, err := NewDecoder("ebcdic")
+	require.Error(t, err)
+}
+
+func TestLineDecoder(t *testing.T) {
+	// The high byte of U+0A41 and the low byte of U+010A are '\n'
+	text := "a,b=ੁ\r\ncĊd\r\n"
+	tests := []struct {
+		name     string
+		encoding string
+		bom      unicode.BOMPolicy
+		endian   unicode.Endianness
+	}{
+		{
+			name:     "utf-16le",
+			encoding: "utf-16le",
+			bom:      unicode.IgnoreBOM,
+			endian:   unicode.LittleEndian,
+		},
+		{
+			name:     "utf-16be",
+			encoding: "utf-16be",
+			bom:      unicode.IgnoreBOM,
+			endian:   unicode.BigEndian,
+		},
+		{
+			name:     "utf-16 big endian byte order mark",
+			encoding: "utf-16",
+			bom:      unicode.UseBOM,
+			endian:   unicode.BigEndian,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := unicode.UTF16(tt.endian, tt.bom).NewEncoder().String(text)
+			require.NoError(t, err)
+
+			d, err := NewDecoder(tt.encoding)
+			require.NoError(t, err)
+			ld := d.NewLineDecoder()
+
+			// Split as the tail input does
+			var lines []string
+			var errs int
+			chunks := strings.Split(input, "\n")
+			for _, chunk := range chunks[:len(chunks)-1] {
+				line, ok, err := ld.Decode(chunk)
+				if err != nil {
+					errs++
+				}
+				if ok {
+					lines = append(lines, line)
+				}
+			}
+
+			if tt.endian == unicode.BigEndian {
+				require.Equal(t, []string{"a,b=ੁ\r", "cĊd\r"}, lines)
+				require.Zero(t, errs)
+			} else {
+				// The U+010A character is taken for a newline
+				require.Equal(t, []string{"a,b=ੁ\r", "c", "d\r"}, lines)
+				require.Equal(t, 1, errs)
+			}
+		})
+	}
+}
+
+func TestLineDecoderSingleByte(t *testing.T) {
+	d, err := NewDecoder("windows-1252")
+	require.NoError(t, err)
+	ld := d.NewLineDecoder()
+
+	line, ok, err := ld.Decode("\x80 5")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "€ 5", line)
+}
+
+func TestDecompressor(t *testing.T) {
+	content := "cpu usage_idle=100\n"
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	_, err := w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	var zst bytes.Buffer
+	zw, err := zstd.NewWriter(&zst)
+	require.NoError(t, err)
+	_, err = zw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	tests := []struct {
+		name        string
+		compression string
+		input       []byte
+		expected    string
+	}{
+		{name: "none", compression: "none", input: []byte(content), expected: content},
+		{name: "gzip", compression: "gzip", input: gz.Bytes(), expected: content},
+		{name: "zstd", compression: "zstd", input: zst.Bytes(), expected: content},
+		{name: "auto none", compression: "auto", input: []byte(content), expected: content},
+		{name: "auto short", compression: "auto", input: []byte("x"), expected: "x"},
+		{name: "auto gzip", compression: "auto", input: gz.Bytes(), expected: content},
+		{name: "auto zstd", compression: "auto", input: zst.Bytes(), expected: content},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewDecompressor(tt.compression, bytes.NewReader(tt.input))
+			require.NoError(t, err)
+			defer r.Close()
+
+			actual, err := ioutil.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(actual))
+		})
+	}
+
+	require.Error(t, CheckCompression("lz4"))
+}
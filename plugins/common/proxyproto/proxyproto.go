@@ -0,0 +1,231 @@
+// Package proxyproto reads the PROXY protocol header, versions 1 and 2, sent
+// by load balancers and proxies to pass the address of the client they
+// forward to a listener.
+//
+// See https://www.haproxy.org/download/2.2/doc/proxy-protocol.txt
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// signature starts the headers of the version 2.
+var signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+const (
+	// maxV1Length is the maximum length of a version 1 header.
+	maxV1Length = 107
+	// v2HeaderLength is the length of a version 2 header without addresses.
+	v2HeaderLength = 16
+)
+
+// Address families and protocols of the version 2.
+const (
+	familyUnspec = 0x0
+	familyInet   = 0x1
+	familyInet6  = 0x2
+	familyUnix   = 0x3
+
+	protocolDgram = 0x2
+)
+
+// Conn is a connection starting with a PROXY protocol header.  The header
+// must be read with ReadHeader before reading the connection.
+type Conn struct {
+	net.Conn
+	reader *bufio.Reader
+	source net.Addr
+}
+
+// NewConn returns the Conn reading the header from c.
+func NewConn(c net.Conn) *Conn {
+	return &Conn{
+		Conn:   c,
+		reader: bufio.NewReader(c),
+	}
+}
+
+// ReadHeader reads the header of the connection.  The connections without
+// header are rejected.
+func (c *Conn) ReadHeader() error {
+	source, err := readHeader(c.reader)
+	if err != nil {
+		return err
+	}
+	c.source = source
+	return nil
+}
+
+// Read reads the data following the header.
+func (c *Conn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// Source returns the address of the client sent in the header, or the address
+// of the connection if the header carries no address, such as for the health
+// checks of the proxy.
+func (c *Conn) Source() net.Addr {
+	if c.source == nil {
+		return c.Conn.RemoteAddr()
+	}
+	return c.source
+}
+
+func readHeader(r *bufio.Reader) (net.Addr, error) {
+	start, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch start[0] {
+	case 'P':
+		return readV1(r)
+	case signature[0]:
+		header, err := r.Peek(v2HeaderLength)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.HasPrefix(header, signature) {
+			return nil, fmt.Errorf("invalid PROXY protocol signature")
+		}
+		b := make([]byte, v2HeaderLength+int(binary.BigEndian.Uint16(header[14:16])))
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		source, _, err := parseV2(b)
+		return source, err
+	default:
+		return nil, fmt.Errorf("missing PROXY protocol header")
+	}
+}
+
+// readV1 reads a header of the version 1, such as
+// "PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n".
+func readV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) <= maxV1Length {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if line[len(line)-1] != '\n' {
+		return nil, fmt.Errorf("PROXY protocol header too long")
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, fmt.Errorf("invalid PROXY protocol header %q", line)
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if fields[0] != "PROXY" || len(fields) < 2 {
+		return nil, fmt.Errorf("invalid PROXY protocol header %q", line)
+	}
+
+	switch fields[1] {
+	case "UNKNOWN":
+		return nil, nil
+	case "TCP4", "TCP6":
+	default:
+		return nil, fmt.Errorf("unknown PROXY protocol %q", fields[1])
+	}
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("invalid PROXY protocol header %q", line)
+	}
+
+	ip := net.ParseIP(fields[2])
+	if ip == nil || (ip.To4() != nil) != (fields[1] == "TCP4") {
+		return nil, fmt.Errorf("invalid PROXY protocol source address %q", fields[2])
+	}
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PROXY protocol source port %q", fields[4])
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// ParseDatagram parses the header of the version 2 starting a datagram, and
+// returns the address of the client and the payload following the header.
+// The address is nil if the header carries no address.
+func ParseDatagram(b []byte) (net.Addr, []byte, error) {
+	if !bytes.HasPrefix(b, signature) {
+		return nil, nil, fmt.Errorf("missing PROXY protocol header")
+	}
+	source, n, err := parseV2(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	return source, b[n:], nil
+}
+
+// parseV2 parses a header of the version 2 and returns the address of the
+// client and the length of the header.
+func parseV2(b []byte) (net.Addr, int, error) {
+	if len(b) < v2HeaderLength {
+		return nil, 0, fmt.Errorf("PROXY protocol header too short")
+	}
+	length := v2HeaderLength + int(binary.BigEndian.Uint16(b[14:16]))
+	if len(b) < length {
+		return nil, 0, fmt.Errorf("PROXY protocol header too short")
+	}
+
+	if b[12]>>4 != 2 {
+		return nil, 0, fmt.Errorf("unsupported PROXY protocol version %d", b[12]>>4)
+	}
+	switch b[12] & 0xf {
+	case 0x0:
+		// LOCAL command, the connection is made by the proxy itself.
+		return nil, length, nil
+	case 0x1:
+	default:
+		return nil, 0, fmt.Errorf("unknown PROXY protocol command %d", b[12]&0xf)
+	}
+
+	family, protocol := b[13]>>4, b[13]&0xf
+	addrs := b[v2HeaderLength:length]
+	var ip net.IP
+	var port int
+	switch family {
+	case familyUnspec:
+		return nil, length, nil
+	case familyInet:
+		if len(addrs) < 12 {
+			return nil, 0, fmt.Errorf("PROXY protocol addresses too short")
+		}
+		ip = net.IPv4(addrs[0], addrs[1], addrs[2], addrs[3])
+		port = int(binary.BigEndian.Uint16(addrs[8:10]))
+	case familyInet6:
+		if len(addrs) < 36 {
+			return nil, 0, fmt.Errorf("PROXY protocol addresses too short")
+		}
+		ip = make(net.IP, net.IPv6len)
+		copy(ip, addrs[0:16])
+		port = int(binary.BigEndian.Uint16(addrs[32:34]))
+	case familyUnix:
+		if len(addrs) < 216 {
+			return nil, 0, fmt.Errorf("PROXY protocol addresses too short")
+		}
+		name := string(bytes.TrimRight(addrs[0:108], "\x00"))
+		if protocol == protocolDgram {
+			return &net.UnixAddr{Name: name, Net: "unixgram"}, length, nil
+		}
+		return &net.UnixAddr{Name: name, Net: "unix"}, length, nil
+	default:
+		return nil, 0, fmt.Errorf("unknown PROXY protocol address family %d", family)
+	}
+
+	if protocol == protocolDgram {
+		return &net.UDPAddr{IP: ip, Port: port}, length, nil
+	}
+	return &net.TCPAddr{IP: ip, Port: port}, length, nil
+}
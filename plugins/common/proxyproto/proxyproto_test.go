@@ -0,0 +1,132 @@
+package proxyproto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// v2Header returns a PROXY protocol version 2 header.
+func v2Header(command, family byte, addrs []byte) []byte {
+	b := append([]byte(nil), signature...)
+	b = append(b, 0x20|command, family, 0, 0)
+	binary.BigEndian.PutUint16(b[14:16], uint16(len(addrs)))
+	return append(b, addrs...)
+}
+
+func TestReadHeader(t *testing.T) {
+	inet := []byte{10, 0, 0, 1, 10, 0, 0, 2, 0x1f, 0x90, 0x00, 0x50}
+	inet6 := make([]byte, 36)
+	copy(inet6, net.ParseIP("2001:db8::1"))
+	binary.BigEndian.PutUint16(inet6[32:34], 8080)
+
+	tests := []struct {
+		name   string
+		input  []byte
+		source net.Addr
+		err    bool
+	}{
+		{
+			name:   "v1 tcp4",
+			input:  []byte("PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n"),
+			source: &net.TCPAddr{IP: net.ParseIP("192.168.0.1"), Port: 56324},
+		},
+		{
+			name:   "v1 tcp6",
+			input:  []byte("PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n"),
+			source: &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 56324},
+		},
+		{
+			name:  "v1 unknown",
+			input: []byte("PROXY UNKNOWN\r\n"),
+		},
+		{
+			name:  "v1 mismatched family",
+			input: []byte("PROXY TCP4 2001:db8::1 2001:db8::2 56324 443\r\n"),
+			err:   true,
+		},
+		{
+			name:  "v1 missing CR",
+			input: []byte("PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\n"),
+			err:   true,
+		},
+		{
+			name:  "v1 too long",
+			input: append([]byte("PROXY UNKNOWN "), bytes.Repeat([]byte("x"), 200)...),
+			err:   true,
+		},
+		{
+			name:   "v2 inet",
+			input:  v2Header(0x1, 0x11, inet),
+			source: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 8080},
+		},
+		{
+			name:   "v2 inet6",
+			input:  v2Header(0x1, 0x21, inet6),
+			source: &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 8080},
+		},
+		{
+			name:  "v2 local",
+			input: v2Header(0x0, 0x00, nil),
+		},
+		{
+			name:  "v2 short addresses",
+			input: v2Header(0x1, 0x11, inet[:8]),
+			err:   true,
+		},
+		{
+			name:  "missing header",
+			input: []byte("cpu value=1\n"),
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := net.Pipe()
+			defer server.Close()
+			go func() {
+				client.Write(append(tt.input, "cpu value=1\n"...))
+				client.Close()
+			}()
+
+			c := NewConn(server)
+			err := c.ReadHeader()
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			if tt.source == nil {
+				require.Equal(t, server.RemoteAddr(), c.Source())
+			} else {
+				require.Equal(t, tt.source.String(), c.Source().String())
+			}
+
+			body, err := ioutil.ReadAll(c)
+			require.NoError(t, err)
+			require.Equal(t, "cpu value=1\n", string(body))
+		})
+	}
+}
+
+func TestParseDatagram(t *testing.T) {
+	inet := []byte{10, 0, 0, 1, 10, 0, 0, 2, 0x1f, 0x90, 0x00, 0x50, 0xff}
+	b := append(v2Header(0x1, 0x12, inet), "cpu value=1"...)
+
+	source, payload, err := ParseDatagram(b)
+	require.NoError(t, err)
+	require.Equal(t, &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 8080}, source)
+	require.Equal(t, "cpu value=1", string(payload))
+
+	_, _, err = ParseDatagram([]byte("cpu value=1"))
+	require.Error(t, err)
+
+	_, _, err = ParseDatagram(b[:20])
+	require.Error(t, err)
+}
@@ -0,0 +1,197 @@
+// Package ratelimit contains a token bucket rate limiter for listener inputs,
+// limiting each source, either the client address or the tenant, separately.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf/plugins/common/tenant"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+const (
+	keyIP     = "ip"
+	keyTenant = "tenant"
+)
+
+// sweepInterval is the interval at which the sources that are not limited
+// anymore are forgotten.
+const sweepInterval = time.Minute
+
+// LimiterConfig is the rate limit of each source, it is embedded in the
+// listener plugin configs.
+type LimiterConfig struct {
+	RateLimit      float64 `toml:"rate_limit"`
+	RateLimitBurst int     `toml:"rate_limit_burst"`
+	RateLimitKey   string  `toml:"rate_limit_key"`
+}
+
+// Limiter limits the rate of each source.  A nil Limiter accepts everything.
+type Limiter struct {
+	rate   float64
+	burst  float64
+	key    string
+	now    func() time.Time
+	denied selfstat.Stat
+
+	sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter returns the Limiter for the config, or nil if no rate limit is
+// configured.  The events denied are counted in the field of the internal
+// measurement.
+func (c *LimiterConfig) Limiter(measurement, field string, tags map[string]string) (*Limiter, error) {
+	if c.RateLimit < 0 {
+		return nil, fmt.Errorf("rate_limit must not be negative")
+	}
+	if c.RateLimitBurst < 0 {
+		return nil, fmt.Errorf("rate_limit_burst must not be negative")
+	}
+	switch c.RateLimitKey {
+	case "", keyIP, keyTenant:
+	default:
+		return nil, fmt.Errorf("invalid rate_limit_key %q", c.RateLimitKey)
+	}
+	if c.RateLimit == 0 {
+		return nil, nil
+	}
+
+	l := &Limiter{
+		rate:    c.RateLimit,
+		burst:   float64(c.RateLimitBurst),
+		key:     c.RateLimitKey,
+		now:     time.Now,
+		denied:  selfstat.Register(measurement, field, tags),
+		buckets: make(map[string]*bucket),
+	}
+	if l.burst == 0 {
+		// One second worth of the rate.
+		l.burst = c.RateLimit
+		if l.burst < 1 {
+			l.burst = 1
+		}
+	}
+	if l.key == "" {
+		l.key = keyIP
+	}
+	l.lastSweep = l.now()
+	return l, nil
+}
+
+// Allow takes n tokens from the bucket of the source and returns false if the
+// bucket is empty.  Events costing more than the burst are allowed when the
+// bucket is not empty, the source is then denied until the bucket refills.
+func (l *Limiter) Allow(source string, n int) bool {
+	if l == nil {
+		return true
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[source]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[source] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		l.denied.Incr(int64(n))
+		return false
+	}
+	b.tokens -= float64(n)
+	return true
+}
+
+// sweep forgets the sources whose bucket is full, they are not limited.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for source, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, source)
+		}
+	}
+}
+
+// RetryAfter returns the number of seconds until a token is available.
+func (l *Limiter) RetryAfter(source string) int {
+	l.Lock()
+	defer l.Unlock()
+
+	b, ok := l.buckets[source]
+	if !ok || b.tokens >= 1 {
+		return 1
+	}
+	return int(math.Ceil((1 - b.tokens) / l.rate))
+}
+
+// Handler returns a handler calling next for the requests allowed.  The
+// requests of a source over its rate are passed to limited with the
+// Retry-After header set, or answered with 429 Too Many Requests if limited is
+// nil.  The tenant key requires the handler to be wrapped by the
+// tenant.Tenants handler, the requests without a tenant are limited by
+// address.
+func (l *Limiter) Handler(next http.Handler, limited http.HandlerFunc) http.Handler {
+	if l == nil {
+		return next
+	}
+	if limited == nil {
+		limited = func(res http.ResponseWriter, req *http.Request) {
+			http.Error(res, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		}
+	}
+
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		source := l.Source(req)
+		if !l.Allow(source, 1) {
+			res.Header().Set("Retry-After", strconv.Itoa(l.RetryAfter(source)))
+			limited(res, req)
+			return
+		}
+		next.ServeHTTP(res, req)
+	})
+}
+
+// Source returns the source the request is limited as.
+func (l *Limiter) Source(req *http.Request) string {
+	if l.key == keyTenant {
+		if name, ok := tenant.FromContext(req.Context()); ok {
+			return keyTenant + ":" + name
+		}
+	}
+	return AddrSource(req.RemoteAddr)
+}
+
+// AddrSource returns the source of a client address, its IP.
+func AddrSource(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/plugins/common/tenant"
+	"github.com/stretchr/testify/require"
+)
+
+func newLimiter(t *testing.T, config LimiterConfig, now *time.Time) *Limiter {
+	l, err := config.Limiter("ratelimit_test", "denied", map[string]string{"test": t.Name()})
+	require.NoError(t, err)
+	require.NotNil(t, l)
+	l.now = func() time.Time { return *now }
+	l.lastSweep = *now
+	return l
+}
+
+func TestConfig(t *testing.T) {
+	l, err := (&LimiterConfig{}).Limiter("ratelimit_test", "denied", nil)
+	require.NoError(t, err)
+	require.Nil(t, l)
+	require.True(t, l.Allow("a", 10))
+
+	_, err = (&LimiterConfig{RateLimit: -1}).Limiter("ratelimit_test", "denied", nil)
+	require.Error(t, err)
+	_, err = (&LimiterConfig{RateLimit: 1, RateLimitKey: "user"}).Limiter("ratelimit_test", "denied", nil)
+	require.Error(t, err)
+}
+
+func TestAllow(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newLimiter(t, LimiterConfig{RateLimit: 2}, &now)
+
+	// The burst is one second worth of the rate.
+	require.True(t, l.Allow("a", 1))
+	require.True(t, l.Allow("a", 1))
+	require.False(t, l.Allow("a", 1))
+	require.True(t, l.Allow("b", 1))
+
+	now = now.Add(500 * time.Millisecond)
+	require.True(t, l.Allow("a", 1))
+	require.False(t, l.Allow("a", 1))
+
+	// Larger events are allowed once, the source owes the tokens.
+	now = now.Add(time.Second)
+	require.True(t, l.Allow("a", 5))
+	now = now.Add(time.Second)
+	require.False(t, l.Allow("a", 1))
+	require.Equal(t, 1, l.RetryAfter("a"))
+	require.Equal(t, int64(3), l.denied.Get())
+
+	// Sources with a full bucket are forgotten.
+	now = now.Add(sweepInterval)
+	require.True(t, l.Allow("a", 1))
+	require.Len(t, l.buckets, 1)
+}
+
+func TestHandler(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newLimiter(t, LimiterConfig{RateLimit: 1, RateLimitKey: "tenant"}, &now)
+
+	tenants, err := (&tenant.Config{
+		TenantSource: "basic_username",
+		TenantUsers:  map[string]string{"acme": "secret"},
+	}).Tenants()
+	require.NoError(t, err)
+
+	ok := http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {})
+	handler := tenants.Handler(l.Handler(ok, nil), func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusUnauthorized)
+	})
+
+	request := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/write", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.SetBasicAuth(user, "secret")
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	require.Equal(t, http.StatusOK, request("acme").Code)
+	res := request("acme")
+	require.Equal(t, http.StatusTooManyRequests, res.Code)
+	require.Equal(t, "1", res.Header().Get("Retry-After"))
+
+	// The tenant is limited, not its address.
+	require.Equal(t, []string{"tenant:acme"}, keys(l))
+}
+
+func TestSource(t *testing.T) {
+	l := &Limiter{key: keyIP}
+	req := httptest.NewRequest("POST", "/write", nil)
+	req.RemoteAddr = "[::1]:8080"
+	require.Equal(t, "::1", l.Source(req))
+	require.Equal(t, "10.0.0.1", AddrSource("10.0.0.1:80"))
+	require.Equal(t, "/tmp/sock", AddrSource("/tmp/sock"))
+}
+
+func keys(l *Limiter) []string {
+	var sources []string
+	for source := range l.buckets {
+		sources = append(sources, source)
+	}
+	return sources
+}
@@ -0,0 +1,46 @@
+// Package reuseport opens several UDP sockets on the same address with
+// SO_REUSEPORT, letting the kernel spread the datagrams received across the
+// sockets so that each can be read by its own goroutine.
+package reuseport
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// ListenPacket opens n sockets listening on the address of the network, or a
+// single socket without SO_REUSEPORT if n is 1.  The sockets listen on the
+// port chosen for the first socket when the port of the address is 0.
+func ListenPacket(network, address string, n int) ([]net.PacketConn, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid number of sockets %d", n)
+	}
+	if n == 1 {
+		conn, err := net.ListenPacket(network, address)
+		if err != nil {
+			return nil, err
+		}
+		return []net.PacketConn{conn}, nil
+	}
+	if !supported {
+		return nil, fmt.Errorf("SO_REUSEPORT is not supported on this platform")
+	}
+
+	lc := net.ListenConfig{Control: control}
+	conns := make([]net.PacketConn, 0, n)
+	for i := 0; i < n; i++ {
+		conn, err := lc.ListenPacket(context.Background(), network, address)
+		if err != nil {
+			for _, c := range conns {
+				c.Close()
+			}
+			return nil, err
+		}
+		if i == 0 {
+			address = conn.LocalAddr().String()
+		}
+		conns = append(conns, conn)
+	}
+	return conns, nil
+}
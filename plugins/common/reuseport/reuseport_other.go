@@ -0,0 +1,11 @@
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package reuseport
+
+import "syscall"
+
+const supported = false
+
+func control(network, address string, c syscall.RawConn) error {
+	return nil
+}
@@ -0,0 +1,41 @@
+package reuseport
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenPacket(t *testing.T) {
+	conns, err := ListenPacket("udp", "127.0.0.1:0", 1)
+	require.NoError(t, err)
+	require.Len(t, conns, 1)
+	conns[0].Close()
+
+	_, err = ListenPacket("udp", "127.0.0.1:0", 0)
+	require.Error(t, err)
+}
+
+func TestListenPacketReusePort(t *testing.T) {
+	if !supported {
+		t.Skip("SO_REUSEPORT is not supported")
+	}
+
+	conns, err := ListenPacket("udp", "127.0.0.1:0", 4)
+	require.NoError(t, err)
+	require.Len(t, conns, 4)
+	defer func() {
+		for _, c := range conns {
+			c.Close()
+		}
+	}()
+
+	for _, c := range conns {
+		require.Equal(t, conns[0].LocalAddr().String(), c.LocalAddr().String())
+	}
+
+	// Without SO_REUSEPORT the address is in use.
+	_, err = net.ListenPacket("udp", conns[0].LocalAddr().String())
+	require.Error(t, err)
+}
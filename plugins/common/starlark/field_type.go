@@ -0,0 +1,176 @@
+package starlark
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"go.starlark.net/starlark"
+)
+
+// Uint64 is an unsigned integer created by the uint64 builtin.  It is stored
+// as an unsigned field, other integers are stored as signed fields.
+type Uint64 uint64
+
+func (u Uint64) String() string {
+	return strconv.FormatUint(uint64(u), 10)
+}
+
+func (u Uint64) Type() string {
+	return "uint64"
+}
+
+func (u Uint64) Freeze() {
+}
+
+func (u Uint64) Truth() starlark.Bool {
+	return u != 0
+}
+
+func (u Uint64) Hash() (uint32, error) {
+	return uint32(u ^ u>>32), nil
+}
+
+// toInt64 converts x to an int64, truncating floats towards zero.
+func toInt64(b *starlark.Builtin, x starlark.Value) (int64, error) {
+	switch v := x.(type) {
+	case starlark.Int:
+		if n, ok := v.Int64(); ok {
+			return n, nil
+		}
+	case starlark.Float:
+		f := math.Trunc(float64(v))
+		if f >= math.MinInt64 && f < math.MaxInt64 {
+			return int64(f), nil
+		}
+	case Uint64:
+		if v <= math.MaxInt64 {
+			return int64(v), nil
+		}
+	case starlark.String:
+		n, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return 0, nameErr(b, err)
+		}
+		return n, nil
+	case starlark.Bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, nameErr(b, fmt.Sprintf("got %s, want int, float, string or bool", x.Type()))
+	}
+	return 0, nameErr(b, fmt.Sprintf("%s out of range", x.String()))
+}
+
+// toUint64 converts x to an uint64, truncating floats towards zero.
+func toUint64(b *starlark.Builtin, x starlark.Value) (uint64, error) {
+	switch v := x.(type) {
+	case starlark.Int:
+		if n, ok := v.Uint64(); ok {
+			return n, nil
+		}
+	case starlark.Float:
+		f := math.Trunc(float64(v))
+		if f >= 0 && f < math.MaxUint64 {
+			return uint64(f), nil
+		}
+	case Uint64:
+		return uint64(v), nil
+	case starlark.String:
+		n, err := strconv.ParseUint(string(v), 10, 64)
+		if err != nil {
+			return 0, nameErr(b, err)
+		}
+		return n, nil
+	case starlark.Bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, nameErr(b, fmt.Sprintf("got %s, want int, float, string or bool", x.Type()))
+	}
+	return 0, nameErr(b, fmt.Sprintf("%s out of range", x.String()))
+}
+
+func int64Builtin(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var x starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &x); err != nil {
+		return nil, err
+	}
+	n, err := toInt64(b, x)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.MakeInt64(n), nil
+}
+
+func uint64Builtin(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var x starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &x); err != nil {
+		return nil, err
+	}
+	n, err := toUint64(b, x)
+	if err != nil {
+		return nil, err
+	}
+	return Uint64(n), nil
+}
+
+func float64Builtin(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var x starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &x); err != nil {
+		return nil, err
+	}
+	switch v := x.(type) {
+	case Uint64:
+		return starlark.Float(v), nil
+	case starlark.String:
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return nil, nameErr(b, err)
+		}
+		return starlark.Float(f), nil
+	case starlark.Bool:
+		if v {
+			return starlark.Float(1), nil
+		}
+		return starlark.Float(0), nil
+	}
+	f, err := toFloat(b, x)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.Float(f), nil
+}
+
+// fieldType returns the type of a metric field, or None if the field does not
+// exist.
+func fieldType(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var sm *Metric
+	var key starlark.String
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 2, &sm, &key); err != nil {
+		return nil, err
+	}
+
+	value, ok := sm.metric.GetField(key.GoString())
+	if !ok {
+		return starlark.None, nil
+	}
+
+	switch value.(type) {
+	case int64:
+		return starlark.String("int64"), nil
+	case uint64:
+		return starlark.String("uint64"), nil
+	case float64:
+		return starlark.String("float64"), nil
+	case string:
+		return starlark.String("string"), nil
+	case bool:
+		return starlark.String("bool"), nil
+	}
+	return starlark.None, nil
+}
@@ -0,0 +1,198 @@
+package starlark
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// jsonModule is the json module available to scripts.
+var jsonModule = &starlarkstruct.Module{
+	Name: "json",
+	Members: starlark.StringDict{
+		"decode": starlark.NewBuiltin("decode", jsonDecode),
+		"encode": starlark.NewBuiltin("encode", jsonEncode),
+	},
+}
+
+// jsonDecode parses a JSON document.  Objects are decoded to dicts, arrays to
+// lists and numbers to ints when they are integral, or floats.  The keys of
+// objects are sorted.
+func jsonDecode(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var x starlark.String
+	var def starlark.Value
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "x", &x, "default?", &def); err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(strings.NewReader(string(x)))
+	dec.UseNumber()
+	var v interface{}
+	err := dec.Decode(&v)
+	if err == nil && dec.More() {
+		err = fmt.Errorf("invalid character after top-level value")
+	}
+	if err != nil {
+		if def != nil {
+			return def, nil
+		}
+		return nil, nameErr(b, err)
+	}
+	return fromJSON(v)
+}
+
+func fromJSON(v interface{}) (starlark.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(v), nil
+	case string:
+		return starlark.String(v), nil
+	case json.Number:
+		if i, ok := new(big.Int).SetString(string(v), 10); ok {
+			return starlark.MakeBigInt(i), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return starlark.Float(f), nil
+	case []interface{}:
+		items := make([]starlark.Value, 0, len(v))
+		for _, item := range v {
+			sv, err := fromJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, sv)
+		}
+		return starlark.NewList(items), nil
+	case map[string]interface{}:
+		// Keys are sorted, Go maps do not keep the order of the document
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		dict := starlark.NewDict(len(v))
+		for _, key := range keys {
+			sv, err := fromJSON(v[key])
+			if err != nil {
+				return nil, err
+			}
+			if err := dict.SetKey(starlark.String(key), sv); err != nil {
+				return nil, err
+			}
+		}
+		return dict, nil
+	}
+	return nil, fmt.Errorf("unexpected JSON value %T", v)
+}
+
+// jsonEncode serializes a value to a JSON string.  Dicts must have string
+// keys, the tags and fields of a metric can be encoded directly.
+func jsonEncode(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var x starlark.Value
+	var indent starlark.String
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "x", &x, "indent?", &indent); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, x, 0); err != nil {
+		return nil, nameErr(b, err)
+	}
+	if indent == "" {
+		return starlark.String(buf.String()), nil
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", string(indent)); err != nil {
+		return nil, nameErr(b, err)
+	}
+	return starlark.String(out.String()), nil
+}
+
+// maxJSONDepth guards against cyclic values, such as a list containing
+// itself.
+const maxJSONDepth = 100
+
+func encodeJSON(buf *bytes.Buffer, x starlark.Value, depth int) error {
+	if depth > maxJSONDepth {
+		return fmt.Errorf("value is nested too deeply")
+	}
+
+	switch v := x.(type) {
+	case starlark.NoneType:
+		buf.WriteString("null")
+	case starlark.Bool:
+		if v {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	case starlark.Int:
+		buf.WriteString(v.String())
+	case Uint64:
+		buf.WriteString(v.String())
+	case starlark.Float:
+		f := float64(v)
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return fmt.Errorf("cannot encode %s", v)
+		}
+		b, err := json.Marshal(f)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+	case starlark.String:
+		b, err := json.Marshal(string(v))
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+	case starlark.IterableMapping:
+		buf.WriteByte('{')
+		for i, item := range v.Items() {
+			key, ok := item[0].(starlark.String)
+			if !ok {
+				return fmt.Errorf("dict key must be a string, got %s", item[0].Type())
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeJSON(buf, key, depth+1); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := encodeJSON(buf, item[1], depth+1); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case starlark.Indexable:
+		// Lists and tuples
+		buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeJSON(buf, v.Index(i), depth+1); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		return fmt.Errorf("cannot encode %s", x.Type())
+	}
+	return nil
+}
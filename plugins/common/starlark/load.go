@@ -0,0 +1,78 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"go.starlark.net/starlark"
+)
+
+// loader implements the load statement for scripts.  Modules are files
+// relative to the directory of the file loading them, each is executed once
+// and its globals are shared by all the modules loading it.
+type loader struct {
+	builtins starlark.StringDict
+	state    *starlark.Dict
+	modules  map[string]*loadEntry
+}
+
+type loadEntry struct {
+	globals starlark.StringDict
+	err     error
+}
+
+func newLoader(builtins starlark.StringDict, state *starlark.Dict) *loader {
+	return &loader{
+		builtins: builtins,
+		state:    state,
+		modules:  make(map[string]*loadEntry),
+	}
+}
+
+func (l *loader) load(thread *starlark.Thread, module string) (starlark.StringDict, error) {
+	path := module
+	if !filepath.IsAbs(path) {
+		dir := filepath.Dir(thread.CallFrame(0).Pos.Filename())
+		path = filepath.Join(dir, path)
+	}
+
+	e, ok := l.modules[path]
+	if ok {
+		if e == nil {
+			return nil, fmt.Errorf("cycle in load graph")
+		}
+		return e.globals, e.err
+	}
+
+	// A nil entry marks the module as being loaded to detect cycles
+	l.modules[path] = nil
+	_, program, err := starlark.SourceProgram(path, nil, l.builtins.Has)
+	if err == nil {
+		var globals starlark.StringDict
+		globals, err = program.Init(thread, l.builtins)
+		freezeGlobals(globals, l.state)
+		e = &loadEntry{globals: globals, err: err}
+	} else {
+		e = &loadEntry{err: err}
+	}
+	l.modules[path] = e
+	return e.globals, e.err
+}
+
+// noLoad is used for inline sources, which have no directory to load modules
+// from.
+func noLoad(_ *starlark.Thread, _ string) (starlark.StringDict, error) {
+	return nil, errors.New("load is only supported in scripts read from a file")
+}
+
+// freezeGlobals freezes the global scope, except for the state dict which is
+// kept between calls.
+func freezeGlobals(globals starlark.StringDict, state *starlark.Dict) {
+	for _, v := range globals {
+		if d, ok := v.(*starlark.Dict); ok && d == state {
+			continue
+		}
+		v.Freeze()
+	}
+}
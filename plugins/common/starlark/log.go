@@ -0,0 +1,38 @@
+package starlark
+
+import (
+	"github.com/influxdata/telegraf"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// newLogModule returns the log module available to scripts.  The messages
+// are logged by the processor, prefixed with the name of the script.
+func newLogModule(log telegraf.Logger, script string) *starlarkstruct.Module {
+	prefix := "[" + script + "] "
+	logFunc := func(name string, fn func(args ...interface{})) *starlark.Builtin {
+		return starlark.NewBuiltin(name, func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var msg starlark.Value
+			if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &msg); err != nil {
+				return nil, err
+			}
+			// Strings are logged without quotes
+			if s, ok := starlark.AsString(msg); ok {
+				fn(prefix + s)
+			} else {
+				fn(prefix + msg.String())
+			}
+			return starlark.None, nil
+		})
+	}
+
+	return &starlarkstruct.Module{
+		Name: "log",
+		Members: starlark.StringDict{
+			"debug": logFunc("debug", log.Debug),
+			"info":  logFunc("info", log.Info),
+			"warn":  logFunc("warn", log.Warn),
+			"error": logFunc("error", log.Error),
+		},
+	}
+}
@@ -0,0 +1,146 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// mathModule is the math module available to scripts.
+var mathModule = &starlarkstruct.Module{
+	Name: "math",
+	Members: starlark.StringDict{
+		"ceil":  starlark.NewBuiltin("ceil", mathCeil),
+		"exp":   newUnaryBuiltin("exp", math.Exp),
+		"fabs":  newUnaryBuiltin("fabs", math.Abs),
+		"floor": starlark.NewBuiltin("floor", mathFloor),
+		"isinf": starlark.NewBuiltin("isinf", mathIsinf),
+		"isnan": starlark.NewBuiltin("isnan", mathIsnan),
+		"log":   starlark.NewBuiltin("log", mathLog),
+		"pow":   starlark.NewBuiltin("pow", mathPow),
+		"sqrt":  newUnaryBuiltin("sqrt", math.Sqrt),
+
+		"e":   starlark.Float(math.E),
+		"inf": starlark.Float(math.Inf(1)),
+		"nan": starlark.Float(math.NaN()),
+		"pi":  starlark.Float(math.Pi),
+	},
+}
+
+// newUnaryBuiltin returns a builtin calling fn with its single numeric
+// argument.
+func newUnaryBuiltin(name string, fn func(float64) float64) *starlark.Builtin {
+	return starlark.NewBuiltin(name, func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		x, err := unpackFloat(b, args, kwargs)
+		if err != nil {
+			return nil, err
+		}
+		return starlark.Float(fn(x)), nil
+	})
+}
+
+// unpackFloat unpacks a single int or float argument.
+func unpackFloat(b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (float64, error) {
+	var x starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &x); err != nil {
+		return 0, err
+	}
+	return toFloat(b, x)
+}
+
+func toFloat(b *starlark.Builtin, x starlark.Value) (float64, error) {
+	f, ok := starlark.AsFloat(x)
+	if !ok {
+		return 0, nameErr(b, fmt.Sprintf("got %s, want float or int", x.Type()))
+	}
+	return f, nil
+}
+
+func mathCeil(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	x, err := unpackFloat(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	return floatToInt(b, math.Ceil(x))
+}
+
+func mathFloor(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	x, err := unpackFloat(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	return floatToInt(b, math.Floor(x))
+}
+
+// floatToInt converts an integral float to an int, as the Python floor and
+// ceil functions do.
+func floatToInt(b *starlark.Builtin, f float64) (starlark.Value, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, nameErr(b, fmt.Sprintf("cannot convert %v to int", f))
+	}
+	return starlark.NumberToInt(starlark.Float(f))
+}
+
+func mathIsinf(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	x, err := unpackFloat(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.Bool(math.IsInf(x, 0)), nil
+}
+
+func mathIsnan(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	x, err := unpackFloat(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.Bool(math.IsNaN(x)), nil
+}
+
+func mathLog(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var xv, basev starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &xv, &basev); err != nil {
+		return nil, err
+	}
+
+	x, err := toFloat(b, xv)
+	if err != nil {
+		return nil, err
+	}
+	if x <= 0 {
+		return nil, nameErr(b, errors.New("math domain error"))
+	}
+
+	if basev == nil {
+		return starlark.Float(math.Log(x)), nil
+	}
+
+	base, err := toFloat(b, basev)
+	if err != nil {
+		return nil, err
+	}
+	if base <= 0 || base == 1 {
+		return nil, nameErr(b, errors.New("math domain error"))
+	}
+	return starlark.Float(math.Log(x) / math.Log(base)), nil
+}
+
+func mathPow(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var xv, yv starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 2, &xv, &yv); err != nil {
+		return nil, err
+	}
+
+	x, err := toFloat(b, xv)
+	if err != nil {
+		return nil, err
+	}
+	y, err := toFloat(b, yv)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.Float(math.Pow(x, y)), nil
+}
@@ -0,0 +1,36 @@
+package starlark
+
+import (
+	"os"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// newOSModule returns the os module available to scripts.  Reading the
+// environment is an error unless allowEnv is set.
+func newOSModule(allowEnv bool) *starlarkstruct.Module {
+	getenv := func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var name starlark.String
+		var def starlark.Value = starlark.None
+		if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name, "default?", &def); err != nil {
+			return nil, err
+		}
+
+		if !allowEnv {
+			return nil, nameErr(b, "access to environment variables is disabled, set allow_env = true to enable it")
+		}
+
+		if value, ok := os.LookupEnv(name.GoString()); ok {
+			return starlark.String(value), nil
+		}
+		return def, nil
+	}
+
+	return &starlarkstruct.Module{
+		Name: "os",
+		Members: starlark.StringDict{
+			"getenv": starlark.NewBuiltin("getenv", getenv),
+		},
+	}
+}
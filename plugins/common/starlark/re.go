@@ -0,0 +1,361 @@
+package starlark
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// reModule is the re module available to scripts.  Patterns use the RE2
+// syntax of the Go regexp package.
+var reModule = &starlarkstruct.Module{
+	Name: "re",
+	Members: starlark.StringDict{
+		"findall": starlark.NewBuiltin("findall", reFindall),
+		"match":   starlark.NewBuiltin("match", reMatch),
+		"search":  starlark.NewBuiltin("search", reSearch),
+		"split":   starlark.NewBuiltin("split", reSplit),
+		"sub":     starlark.NewBuiltin("sub", reSub),
+	},
+}
+
+// patterns caches the compiled patterns, scripts usually call the functions
+// with the same few patterns for every metric.
+var patterns = struct {
+	sync.Mutex
+	cache map[string]*regexp.Regexp
+}{cache: make(map[string]*regexp.Regexp)}
+
+// maxCachedPatterns bounds the cache for scripts building their patterns
+// dynamically.
+const maxCachedPatterns = 1000
+
+func compilePattern(b *starlark.Builtin, pattern string) (*regexp.Regexp, error) {
+	patterns.Lock()
+	defer patterns.Unlock()
+
+	if re, ok := patterns.cache[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, nameErr(b, err)
+	}
+	if len(patterns.cache) >= maxCachedPatterns {
+		patterns.cache = make(map[string]*regexp.Regexp)
+	}
+	patterns.cache[pattern] = re
+	return re, nil
+}
+
+func unpackPattern(b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (*regexp.Regexp, string, error) {
+	var pattern, s string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "pattern", &pattern, "string", &s); err != nil {
+		return nil, "", err
+	}
+	re, err := compilePattern(b, pattern)
+	return re, s, err
+}
+
+func reMatch(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	re, s, err := unpackPattern(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	loc := re.FindStringSubmatchIndex(s)
+	if loc == nil || loc[0] != 0 {
+		return starlark.None, nil
+	}
+	return newMatch(re, s, loc), nil
+}
+
+func reSearch(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	re, s, err := unpackPattern(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	loc := re.FindStringSubmatchIndex(s)
+	if loc == nil {
+		return starlark.None, nil
+	}
+	return newMatch(re, s, loc), nil
+}
+
+// reFindall returns the matches as a list of strings if the pattern has no
+// group, of the group if it has one, or of tuples of the groups otherwise.
+func reFindall(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	re, s, err := unpackPattern(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []starlark.Value
+	for _, m := range re.FindAllStringSubmatch(s, -1) {
+		switch len(m) {
+		case 1:
+			items = append(items, starlark.String(m[0]))
+		case 2:
+			items = append(items, starlark.String(m[1]))
+		default:
+			groups := make(starlark.Tuple, 0, len(m)-1)
+			for _, g := range m[1:] {
+				groups = append(groups, starlark.String(g))
+			}
+			items = append(items, groups)
+		}
+	}
+	return starlark.NewList(items), nil
+}
+
+func reSplit(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var pattern, s string
+	var maxsplit int
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "pattern", &pattern, "string", &s, "maxsplit?", &maxsplit); err != nil {
+		return nil, err
+	}
+	re, err := compilePattern(b, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	n := -1
+	if maxsplit > 0 {
+		n = maxsplit + 1
+	}
+	parts := re.Split(s, n)
+	items := make([]starlark.Value, 0, len(parts))
+	for _, p := range parts {
+		items = append(items, starlark.String(p))
+	}
+	return starlark.NewList(items), nil
+}
+
+// reSub replaces the matches of the pattern.  The replacement refers to the
+// groups with \1 or \g<name> as in Python.
+func reSub(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var pattern, repl, s string
+	var count int
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "pattern", &pattern, "repl", &repl, "string", &s, "count?", &count); err != nil {
+		return nil, err
+	}
+	re, err := compilePattern(b, pattern)
+	if err != nil {
+		return nil, err
+	}
+	template, err := expandTemplate(repl)
+	if err != nil {
+		return nil, nameErr(b, err)
+	}
+
+	var out strings.Builder
+	last := 0
+	for i, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+		if count > 0 && i >= count {
+			break
+		}
+		out.WriteString(s[last:loc[0]])
+		out.Write(re.ExpandString(nil, template, s, loc))
+		last = loc[1]
+	}
+	out.WriteString(s[last:])
+	return starlark.String(out.String()), nil
+}
+
+// expandTemplate converts a Python replacement string to the template syntax
+// of the regexp package.
+func expandTemplate(repl string) (string, error) {
+	var out strings.Builder
+	for i := 0; i < len(repl); i++ {
+		c := repl[i]
+		switch {
+		case c == '$':
+			out.WriteString("$$")
+		case c == '\\' && i+1 < len(repl):
+			i++
+			switch n := repl[i]; {
+			case n >= '0' && n <= '9':
+				j := i
+				for j < len(repl) && j < i+2 && repl[j] >= '0' && repl[j] <= '9' {
+					j++
+				}
+				out.WriteString("${" + repl[i:j] + "}")
+				i = j - 1
+			case n == 'g':
+				end := strings.IndexByte(repl[i:], '>')
+				if i+1 >= len(repl) || repl[i+1] != '<' || end < 0 {
+					return "", fmt.Errorf("invalid group reference at position %d", i-1)
+				}
+				out.WriteString("${" + repl[i+2:i+end] + "}")
+				i += end
+			case n == 'n':
+				out.WriteByte('\n')
+			case n == 't':
+				out.WriteByte('\t')
+			case n == '\\':
+				out.WriteByte('\\')
+			default:
+				out.WriteByte('\\')
+				out.WriteByte(n)
+			}
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String(), nil
+}
+
+// Match is the result of re.match and re.search.
+type Match struct {
+	re  *regexp.Regexp
+	s   string
+	loc []int
+}
+
+func newMatch(re *regexp.Regexp, s string, loc []int) *Match {
+	return &Match{re: re, s: s, loc: loc}
+}
+
+func (m *Match) String() string {
+	return fmt.Sprintf("<re.Match span=(%d, %d), match=%q>", m.loc[0], m.loc[1], m.group(0))
+}
+
+func (m *Match) Type() string {
+	return "re.Match"
+}
+
+func (m *Match) Freeze() {}
+
+func (m *Match) Truth() starlark.Bool {
+	return true
+}
+
+func (m *Match) Hash() (uint32, error) {
+	return 0, fmt.Errorf("unhashable type: re.Match")
+}
+
+// AttrNames implements the starlark.HasAttrs interface.
+func (m *Match) AttrNames() []string {
+	return []string{"end", "group", "groupdict", "groups", "start"}
+}
+
+// Attr implements the starlark.HasAttrs interface.
+func (m *Match) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "group":
+		return starlark.NewBuiltin(name, matchGroup).BindReceiver(m), nil
+	case "groups":
+		return starlark.NewBuiltin(name, matchGroups).BindReceiver(m), nil
+	case "groupdict":
+		return starlark.NewBuiltin(name, matchGroupdict).BindReceiver(m), nil
+	case "start":
+		return starlark.NewBuiltin(name, matchStart).BindReceiver(m), nil
+	case "end":
+		return starlark.NewBuiltin(name, matchEnd).BindReceiver(m), nil
+	default:
+		// Returning nil, nil indicates "no such field or method"
+		return nil, nil
+	}
+}
+
+// group returns the value of the group, None if it did not participate in
+// the match.
+func (m *Match) group(i int) starlark.Value {
+	if m.loc[2*i] < 0 {
+		return starlark.None
+	}
+	return starlark.String(m.s[m.loc[2*i]:m.loc[2*i+1]])
+}
+
+// groupIndex returns the index of a group given by number or name.
+func (m *Match) groupIndex(b *starlark.Builtin, v starlark.Value) (int, error) {
+	switch v := v.(type) {
+	case starlark.Int:
+		if i, ok := v.Int64(); ok && i >= 0 && int(i) <= m.re.NumSubexp() {
+			return int(i), nil
+		}
+	case starlark.String:
+		for i, name := range m.re.SubexpNames() {
+			if name != "" && name == string(v) {
+				return i, nil
+			}
+		}
+	}
+	return 0, nameErr(b, fmt.Sprintf("no such group %s", v))
+}
+
+func matchGroup(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(kwargs) > 0 {
+		return nil, nameErr(b, "unexpected keyword arguments")
+	}
+	m := b.Receiver().(*Match)
+	if len(args) == 0 {
+		return m.group(0), nil
+	}
+
+	groups := make(starlark.Tuple, 0, len(args))
+	for _, arg := range args {
+		i, err := m.groupIndex(b, arg)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, m.group(i))
+	}
+	if len(groups) == 1 {
+		return groups[0], nil
+	}
+	return groups, nil
+}
+
+func matchGroups(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	m := b.Receiver().(*Match)
+	groups := make(starlark.Tuple, 0, m.re.NumSubexp())
+	for i := 1; i <= m.re.NumSubexp(); i++ {
+		groups = append(groups, m.group(i))
+	}
+	return groups, nil
+}
+
+func matchGroupdict(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	m := b.Receiver().(*Match)
+	dict := starlark.NewDict(m.re.NumSubexp())
+	for i, name := range m.re.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		if err := dict.SetKey(starlark.String(name), m.group(i)); err != nil {
+			return nil, err
+		}
+	}
+	return dict, nil
+}
+
+func matchStart(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return matchPosition(b, args, kwargs, 0)
+}
+
+func matchEnd(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	return matchPosition(b, args, kwargs, 1)
+}
+
+func matchPosition(b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple, offset int) (starlark.Value, error) {
+	var group starlark.Value = starlark.MakeInt(0)
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0, &group); err != nil {
+		return nil, err
+	}
+	m := b.Receiver().(*Match)
+	i, err := m.groupIndex(b, group)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.MakeInt(m.loc[2*i+offset]), nil
+}
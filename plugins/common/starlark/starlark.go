@@ -0,0 +1,168 @@
+// Package starlark contains the Starlark runtime shared by the plugins
+// running Starlark scripts: the metric type, the builtins and modules
+// available to the scripts, and the loading of the script.
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+)
+
+// Common holds the options of the plugins running a Starlark script, and
+// the script once loaded.
+type Common struct {
+	Source    string                 `toml:"source"`
+	Script    string                 `toml:"script"`
+	AllowEnv  bool                   `toml:"allow_env"`
+	Constants map[string]interface{} `toml:"constants"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	name    string
+	thread  *starlark.Thread
+	globals starlark.StringDict
+	state   *starlark.Dict
+}
+
+// Init loads the script with the builtins common to all plugins and the
+// builtins of the plugin.  The name is used for an inline source in log
+// messages and backtraces.
+func (c *Common) Init(name string, builtins starlark.StringDict) error {
+	if c.Source == "" && c.Script == "" {
+		return errors.New("one of source or script must be set")
+	}
+	if c.Source != "" && c.Script != "" {
+		return errors.New("both source or script cannot be set")
+	}
+
+	c.name = name
+	if c.Script != "" {
+		c.name = filepath.Base(c.Script)
+	}
+
+	c.thread = &starlark.Thread{
+		Print: func(_ *starlark.Thread, msg string) { c.Log.Debug(msg) },
+	}
+
+	// The state dict is shared by all calls of the script functions.
+	c.state = starlark.NewDict(0)
+
+	constants, err := toStarlarkValue(c.Constants)
+	if err != nil {
+		return fmt.Errorf("constants: %v", err)
+	}
+	constants.Freeze()
+
+	predeclared := starlark.StringDict{
+		"Metric":     starlark.NewBuiltin("Metric", newMetric),
+		"deepcopy":   starlark.NewBuiltin("deepcopy", deepcopy),
+		"int64":      starlark.NewBuiltin("int64", int64Builtin),
+		"uint64":     starlark.NewBuiltin("uint64", uint64Builtin),
+		"float64":    starlark.NewBuiltin("float64", float64Builtin),
+		"field_type": starlark.NewBuiltin("field_type", fieldType),
+		"state":      c.state,
+		"constants":  constants,
+		"json":       jsonModule,
+		"math":       mathModule,
+		"time":       timeModule,
+		"re":         reModule,
+		"os":         newOSModule(c.AllowEnv),
+		"log":        newLogModule(c.Log, c.name),
+	}
+	for k, v := range builtins {
+		predeclared[k] = v
+	}
+
+	if c.Script != "" {
+		c.thread.Load = newLoader(predeclared, c.state).load
+	} else {
+		c.thread.Load = noLoad
+	}
+
+	var program *starlark.Program
+	if c.Source != "" {
+		_, program, err = starlark.SourceProgram(c.name, c.Source, predeclared.Has)
+	} else {
+		_, program, err = starlark.SourceProgram(c.Script, nil, predeclared.Has)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Execute source
+	globals, err := program.Init(c.thread, predeclared)
+	if err != nil {
+		c.LogError(err)
+		return err
+	}
+
+	// Freeze the global scope.  This prevents modifications to the plugin
+	// configuration and makes it explicit that only the state dict is kept
+	// between calls.
+	freezeGlobals(globals, c.state)
+	c.globals = globals
+	return nil
+}
+
+// IsDefined returns true if the script defines the global.
+func (c *Common) IsDefined(name string) bool {
+	_, ok := c.globals[name]
+	return ok
+}
+
+// Function returns the function of the script, which must take the number
+// of parameters.
+func (c *Common) Function(name string, params int) (*starlark.Function, error) {
+	v, ok := c.globals[name]
+	if !ok {
+		return nil, fmt.Errorf("%s is not defined", name)
+	}
+
+	fn, ok := v.(*starlark.Function)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a function", name)
+	}
+
+	if fn.NumParams() != params {
+		switch params {
+		case 0:
+			return nil, fmt.Errorf("%s function must take no parameter", name)
+		case 1:
+			return nil, fmt.Errorf("%s function must take one parameter", name)
+		default:
+			return nil, fmt.Errorf("%s function must take %d parameters", name, params)
+		}
+	}
+	return fn, nil
+}
+
+// Call calls a function of the script.
+func (c *Common) Call(fn *starlark.Function, args ...starlark.Value) (starlark.Value, error) {
+	return starlark.Call(c.thread, fn, starlark.Tuple(args), nil)
+}
+
+// LogError logs an error of the script with its backtrace.
+func (c *Common) LogError(err error) {
+	if err, ok := err.(*starlark.EvalError); ok {
+		for _, line := range strings.Split(err.Backtrace(), "\n") {
+			c.Log.Error(line)
+		}
+		return
+	}
+	c.Log.Error(err)
+}
+
+func init() {
+	// Enable the optional language features that are commonly needed when
+	// processing metrics.
+	resolve.AllowFloat = true
+	resolve.AllowLambda = true
+	resolve.AllowNestedDef = true
+	resolve.AllowSet = true
+}
@@ -0,0 +1,104 @@
+package starlark
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+	"go.starlark.net/starlark"
+)
+
+func TestFunction(t *testing.T) {
+	c := &Common{
+		Source: `
+limit = 10
+
+def apply(metric):
+	return metric
+
+def gather():
+	return []
+`,
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, c.Init("test.starlark", nil))
+
+	_, err := c.Function("apply", 1)
+	require.NoError(t, err)
+	_, err = c.Function("gather", 0)
+	require.NoError(t, err)
+
+	_, err = c.Function("apply", 0)
+	require.EqualError(t, err, "apply function must take no parameter")
+	_, err = c.Function("limit", 0)
+	require.EqualError(t, err, "limit is not a function")
+	_, err = c.Function("flush", 0)
+	require.EqualError(t, err, "flush is not defined")
+	require.True(t, c.IsDefined("limit"))
+	require.False(t, c.IsDefined("flush"))
+}
+
+func TestInitBuiltins(t *testing.T) {
+	c := &Common{
+		Source: `
+def apply(metric):
+	metric.fields["value"] = double(metric.fields["value"])
+	return metric
+`,
+		Log: testutil.Logger{},
+	}
+	err := c.Init("test.starlark", nil)
+	require.Error(t, err)
+
+	double := starlark.NewBuiltin("double", func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var x int
+		if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &x); err != nil {
+			return nil, err
+		}
+		return starlark.MakeInt(2 * x), nil
+	})
+	err = c.Init("test.starlark", starlark.StringDict{"double": double})
+	require.NoError(t, err)
+}
+
+func TestTimeNow(t *testing.T) {
+	defer func(f func() time.Time) { nowFunc = f }(nowFunc)
+	nowFunc = func() time.Time {
+		return time.Unix(42, 0)
+	}
+
+	c := &Common{
+		Source: `
+def apply(metric):
+	metric.time = time.now()
+	return metric
+`,
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, c.Init("test.starlark", nil))
+	fn, err := c.Function("apply", 1)
+	require.NoError(t, err)
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"time_idle": 42,
+		},
+		time.Unix(0, 0),
+	)
+	rv, err := c.Call(fn, NewMetric(m))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{
+				"time_idle": 42,
+			},
+			time.Unix(42, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, []telegraf.Metric{rv.(*Metric).Unwrap()})
+}
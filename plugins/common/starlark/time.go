@@ -0,0 +1,368 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// timeModule is the time module available to scripts.
+var timeModule = &starlarkstruct.Module{
+	Name: "time",
+	Members: starlark.StringDict{
+		"from_timestamp":    starlark.NewBuiltin("from_timestamp", fromTimestamp),
+		"is_valid_timezone": starlark.NewBuiltin("is_valid_timezone", isValidTimezone),
+		"now":               starlark.NewBuiltin("now", now),
+		"parse_duration":    starlark.NewBuiltin("parse_duration", parseDuration),
+		"parse_time":        starlark.NewBuiltin("parse_time", parseTime),
+
+		"nanosecond":  Duration(time.Nanosecond),
+		"microsecond": Duration(time.Microsecond),
+		"millisecond": Duration(time.Millisecond),
+		"second":      Duration(time.Second),
+		"minute":      Duration(time.Minute),
+		"hour":        Duration(time.Hour),
+	},
+}
+
+// nowFunc returns the current time, it can be replaced in tests.
+var nowFunc = time.Now
+
+func now(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	return Time(nowFunc()), nil
+}
+
+func parseTime(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		x        string
+		format   = time.RFC3339
+		location = "UTC"
+	)
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "x", &x, "format?", &format, "location?", &location); err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(location)
+	if err != nil {
+		return nil, nameErr(b, err)
+	}
+
+	t, err := time.ParseInLocation(format, x, loc)
+	if err != nil {
+		return nil, nameErr(b, err)
+	}
+	return Time(t), nil
+}
+
+func parseDuration(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var x starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &x); err != nil {
+		return nil, err
+	}
+
+	switch x := x.(type) {
+	case Duration:
+		return x, nil
+	case starlark.String:
+		d, err := time.ParseDuration(string(x))
+		if err != nil {
+			return nil, nameErr(b, err)
+		}
+		return Duration(d), nil
+	default:
+		return nil, nameErr(b, fmt.Sprintf("got %s, want string or duration", x.Type()))
+	}
+}
+
+func fromTimestamp(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var sec, nsec starlark.Int
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &sec, &nsec); err != nil {
+		return nil, err
+	}
+
+	s, ok := sec.Int64()
+	if !ok {
+		return nil, nameErr(b, "sec out of range")
+	}
+	ns, ok := nsec.Int64()
+	if !ok {
+		return nil, nameErr(b, "nsec out of range")
+	}
+	return Time(time.Unix(s, ns)), nil
+}
+
+func isValidTimezone(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var location string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &location); err != nil {
+		return nil, err
+	}
+	_, err := time.LoadLocation(location)
+	return starlark.Bool(err == nil), nil
+}
+
+// Time is a starlark.Value for an instant in time.
+type Time time.Time
+
+func (t Time) String() string {
+	return time.Time(t).String()
+}
+
+func (t Time) Type() string {
+	return "time.time"
+}
+
+func (t Time) Freeze() {}
+
+func (t Time) Truth() starlark.Bool {
+	return starlark.Bool(!time.Time(t).IsZero())
+}
+
+func (t Time) Hash() (uint32, error) {
+	return uint32(time.Time(t).UnixNano()) ^ uint32(time.Time(t).UnixNano()>>32), nil
+}
+
+// CompareSameType implements the starlark.Comparable interface.
+func (t Time) CompareSameType(op syntax.Token, y starlark.Value, depth int) (bool, error) {
+	x := time.Time(t)
+	u := time.Time(y.(Time))
+	switch op {
+	case syntax.EQL:
+		return x.Equal(u), nil
+	case syntax.NEQ:
+		return !x.Equal(u), nil
+	case syntax.LT:
+		return x.Before(u), nil
+	case syntax.LE:
+		return !x.After(u), nil
+	case syntax.GT:
+		return x.After(u), nil
+	case syntax.GE:
+		return !x.Before(u), nil
+	}
+	return false, fmt.Errorf("invalid comparison operator %s", op)
+}
+
+// Binary implements the starlark.HasBinary interface.
+func (t Time) Binary(op syntax.Token, y starlark.Value, side starlark.Side) (starlark.Value, error) {
+	x := time.Time(t)
+	switch y := y.(type) {
+	case Duration:
+		switch {
+		case op == syntax.PLUS:
+			return Time(x.Add(time.Duration(y))), nil
+		case op == syntax.MINUS && side == starlark.Left:
+			return Time(x.Add(-time.Duration(y))), nil
+		}
+	case Time:
+		if op == syntax.MINUS {
+			if side == starlark.Left {
+				return Duration(x.Sub(time.Time(y))), nil
+			}
+			return Duration(time.Time(y).Sub(x)), nil
+		}
+	}
+
+	// Returning nil, nil indicates the operation is not supported
+	return nil, nil
+}
+
+// AttrNames implements the starlark.HasAttrs interface.
+func (t Time) AttrNames() []string {
+	return []string{
+		"day", "format", "hour", "in_location", "minute", "month",
+		"nanosecond", "second", "unix", "unix_nano", "weekday", "year",
+	}
+}
+
+// Attr implements the starlark.HasAttrs interface.
+func (t Time) Attr(name string) (starlark.Value, error) {
+	x := time.Time(t)
+	switch name {
+	case "year":
+		return starlark.MakeInt(x.Year()), nil
+	case "month":
+		return starlark.MakeInt(int(x.Month())), nil
+	case "day":
+		return starlark.MakeInt(x.Day()), nil
+	case "hour":
+		return starlark.MakeInt(x.Hour()), nil
+	case "minute":
+		return starlark.MakeInt(x.Minute()), nil
+	case "second":
+		return starlark.MakeInt(x.Second()), nil
+	case "nanosecond":
+		return starlark.MakeInt(x.Nanosecond()), nil
+	case "weekday":
+		return starlark.MakeInt(int(x.Weekday())), nil
+	case "unix":
+		return starlark.MakeInt64(x.Unix()), nil
+	case "unix_nano":
+		return starlark.MakeInt64(x.UnixNano()), nil
+	case "format":
+		return starlark.NewBuiltin(name, timeFormat).BindReceiver(t), nil
+	case "in_location":
+		return starlark.NewBuiltin(name, timeInLocation).BindReceiver(t), nil
+	default:
+		// Returning nil, nil indicates "no such field or method"
+		return nil, nil
+	}
+}
+
+func timeFormat(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var layout string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &layout); err != nil {
+		return nil, err
+	}
+	t := time.Time(b.Receiver().(Time))
+	return starlark.String(t.Format(layout)), nil
+}
+
+func timeInLocation(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var location string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &location); err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(location)
+	if err != nil {
+		return nil, nameErr(b, err)
+	}
+	t := time.Time(b.Receiver().(Time))
+	return Time(t.In(loc)), nil
+}
+
+// Duration is a starlark.Value for the elapsed time between two instants.
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) Type() string {
+	return "time.duration"
+}
+
+func (d Duration) Freeze() {}
+
+func (d Duration) Truth() starlark.Bool {
+	return d != 0
+}
+
+func (d Duration) Hash() (uint32, error) {
+	return uint32(d) ^ uint32(int64(d)>>32), nil
+}
+
+// CompareSameType implements the starlark.Comparable interface.
+func (d Duration) CompareSameType(op syntax.Token, y starlark.Value, depth int) (bool, error) {
+	x, u := d, y.(Duration)
+	switch op {
+	case syntax.EQL:
+		return x == u, nil
+	case syntax.NEQ:
+		return x != u, nil
+	case syntax.LT:
+		return x < u, nil
+	case syntax.LE:
+		return x <= u, nil
+	case syntax.GT:
+		return x > u, nil
+	case syntax.GE:
+		return x >= u, nil
+	}
+	return false, fmt.Errorf("invalid comparison operator %s", op)
+}
+
+// Unary implements the starlark.HasUnary interface.
+func (d Duration) Unary(op syntax.Token) (starlark.Value, error) {
+	switch op {
+	case syntax.PLUS:
+		return d, nil
+	case syntax.MINUS:
+		return -d, nil
+	}
+
+	// Returning nil, nil indicates the operation is not supported
+	return nil, nil
+}
+
+// Binary implements the starlark.HasBinary interface.
+func (d Duration) Binary(op syntax.Token, y starlark.Value, side starlark.Side) (starlark.Value, error) {
+	x := time.Duration(d)
+	switch y := y.(type) {
+	case Duration:
+		u := time.Duration(y)
+		if side == starlark.Right {
+			x, u = u, x
+		}
+		switch op {
+		case syntax.PLUS:
+			return Duration(x + u), nil
+		case syntax.MINUS:
+			return Duration(x - u), nil
+		case syntax.SLASH:
+			if u == 0 {
+				return nil, errors.New("division by zero")
+			}
+			return starlark.Float(float64(x) / float64(u)), nil
+		case syntax.SLASHSLASH:
+			if u == 0 {
+				return nil, errors.New("floored division by zero")
+			}
+			return starlark.MakeInt64(int64(x / u)), nil
+		}
+	case starlark.Int:
+		n, ok := y.Int64()
+		if !ok {
+			return nil, errors.New("integer out of range")
+		}
+		switch {
+		case op == syntax.STAR:
+			return Duration(x * time.Duration(n)), nil
+		case (op == syntax.SLASH || op == syntax.SLASHSLASH) && side == starlark.Left:
+			if n == 0 {
+				return nil, errors.New("division by zero")
+			}
+			return Duration(x / time.Duration(n)), nil
+		}
+	case Time:
+		if op == syntax.PLUS {
+			return Time(time.Time(y).Add(x)), nil
+		}
+	}
+
+	// Returning nil, nil indicates the operation is not supported
+	return nil, nil
+}
+
+// AttrNames implements the starlark.HasAttrs interface.
+func (d Duration) AttrNames() []string {
+	return []string{"hours", "microseconds", "milliseconds", "minutes", "nanoseconds", "seconds"}
+}
+
+// Attr implements the starlark.HasAttrs interface.
+func (d Duration) Attr(name string) (starlark.Value, error) {
+	x := time.Duration(d)
+	switch name {
+	case "hours":
+		return starlark.Float(x.Hours()), nil
+	case "minutes":
+		return starlark.Float(x.Minutes()), nil
+	case "seconds":
+		return starlark.Float(x.Seconds()), nil
+	case "milliseconds":
+		return starlark.MakeInt64(int64(x / time.Millisecond)), nil
+	case "microseconds":
+		return starlark.MakeInt64(int64(x / time.Microsecond)), nil
+	case "nanoseconds":
+		return starlark.MakeInt64(int64(x)), nil
+	default:
+		// Returning nil, nil indicates "no such field or method"
+		return nil, nil
+	}
+}
@@ -0,0 +1,227 @@
+// Package targets loads the list of targets for probing inputs from a file
+// or an HTTP endpoint, so that the assignment of checks to agents can be
+// managed centrally.
+package targets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+)
+
+const (
+	defaultRefreshInterval = 5 * time.Minute
+	defaultTimeout         = 5 * time.Second
+
+	// maxResponseSize limits the size of a target list.
+	maxResponseSize = 16 * 1024 * 1024
+)
+
+// Config contains the options for loading targets, to be embedded into the
+// configuration of a plugin.
+type Config struct {
+	TargetsURL             string            `toml:"targets_url"`
+	TargetsFile            string            `toml:"targets_file"`
+	TargetsRefreshInterval internal.Duration `toml:"targets_refresh_interval"`
+	TargetsTimeout         internal.Duration `toml:"targets_timeout"`
+	TargetsHeaders         map[string]string `toml:"targets_headers"`
+
+	mu       sync.Mutex
+	client   *http.Client
+	targets  []Target
+	loaded   bool
+	loadedAt time.Time
+}
+
+// Target is a single target with the labels to add as tags to its metrics.
+type Target struct {
+	Target string            `json:"target"`
+	Labels map[string]string `json:"labels"`
+}
+
+// UnmarshalJSON allows targets to be given as plain strings as well as
+// objects.
+func (t *Target) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		t.Target = s
+		t.Labels = nil
+		return nil
+	}
+
+	type target Target
+	var v target
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*t = Target(v)
+	return nil
+}
+
+// Enabled reports whether targets are loaded from a file or URL.
+func (c *Config) Enabled() bool {
+	return c.TargetsURL != "" || c.TargetsFile != ""
+}
+
+// Targets returns the static targets followed by the loaded ones.  The
+// loaded targets are refreshed once the refresh interval has elapsed.  If
+// refreshing fails the previous targets are kept and the error is returned
+// along with them.
+func (c *Config) Targets(static []string) ([]Target, error) {
+	result := make([]Target, 0, len(static))
+	for _, s := range static {
+		result = append(result, Target{Target: s})
+	}
+	if !c.Enabled() {
+		return result, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var err error
+	interval := c.TargetsRefreshInterval.Duration
+	if interval == 0 {
+		interval = defaultRefreshInterval
+	}
+	if !c.loaded || time.Since(c.loadedAt) >= interval {
+		var targets []Target
+		targets, err = c.load()
+		if err == nil {
+			c.targets = targets
+			c.loaded = true
+		}
+		// Retry failed loads only at the next refresh interval, to avoid
+		// overloading the source.
+		c.loadedAt = time.Now()
+	}
+	return append(result, c.targets...), err
+}
+
+func (c *Config) load() ([]Target, error) {
+	if c.TargetsURL != "" && c.TargetsFile != "" {
+		return nil, errors.New("only one of targets_url and targets_file may be set")
+	}
+
+	var data []byte
+	var err error
+	if c.TargetsFile != "" {
+		data, err = ioutil.ReadFile(c.TargetsFile)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		data, err = c.fetch()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var targets []Target
+	if err := json.Unmarshal(data, &targets); err != nil {
+		return nil, fmt.Errorf("parsing targets: %v", err)
+	}
+	for i, t := range targets {
+		if t.Target == "" {
+			return nil, fmt.Errorf("target %d is empty", i)
+		}
+	}
+	return targets, nil
+}
+
+func (c *Config) fetch() ([]byte, error) {
+	if c.client == nil {
+		timeout := c.TargetsTimeout.Duration
+		if timeout == 0 {
+			timeout = defaultTimeout
+		}
+		c.client = &http.Client{Timeout: timeout}
+	}
+
+	req, err := http.NewRequest("GET", c.TargetsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", internal.ProductToken())
+	for k, v := range c.TargetsHeaders {
+		if k == "Host" {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("loading targets from %s: %s", c.TargetsURL, resp.Status)
+	}
+	return ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+}
+
+// Accumulator returns an accumulator adding the labels of the target as
+// tags to all metrics.  Tags set by the plugin take precedence.
+func Accumulator(acc telegraf.Accumulator, target Target) telegraf.Accumulator {
+	if len(target.Labels) == 0 {
+		return acc
+	}
+	return &labelAccumulator{Accumulator: acc, labels: target.Labels}
+}
+
+type labelAccumulator struct {
+	telegraf.Accumulator
+	labels map[string]string
+}
+
+func (a *labelAccumulator) tags(tags map[string]string) map[string]string {
+	result := make(map[string]string, len(tags)+len(a.labels))
+	for k, v := range a.labels {
+		result[k] = v
+	}
+	for k, v := range tags {
+		result[k] = v
+	}
+	return result
+}
+
+func (a *labelAccumulator) AddFields(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.Accumulator.AddFields(measurement, fields, a.tags(tags), t...)
+}
+
+func (a *labelAccumulator) AddGauge(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.Accumulator.AddGauge(measurement, fields, a.tags(tags), t...)
+}
+
+func (a *labelAccumulator) AddCounter(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.Accumulator.AddCounter(measurement, fields, a.tags(tags), t...)
+}
+
+func (a *labelAccumulator) AddSummary(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.Accumulator.AddSummary(measurement, fields, a.tags(tags), t...)
+}
+
+func (a *labelAccumulator) AddHistogram(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.Accumulator.AddHistogram(measurement, fields, a.tags(tags), t...)
+}
+
+func (a *labelAccumulator) AddMetric(m telegraf.Metric) {
+	for k, v := range a.labels {
+		if !m.HasTag(k) {
+			m.AddTag(k, v)
+		}
+	}
+	a.Accumulator.AddMetric(m)
+}
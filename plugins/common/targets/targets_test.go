@@ -0,0 +1,127 @@
+package targets
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargetsDisabled(t *testing.T) {
+	c := &Config{}
+	require.False(t, c.Enabled())
+
+	list, err := c.Targets([]string{"a", "b"})
+	require.NoError(t, err)
+	require.Equal(t, []Target{{Target: "a"}, {Target: "b"}}, list)
+}
+
+func TestTargetsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "targets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "targets.json")
+	err = ioutil.WriteFile(filename, []byte(`[
+		"example.org",
+		{"target": "example.com", "labels": {"region": "eu"}}
+	]`), 0644)
+	require.NoError(t, err)
+
+	c := &Config{TargetsFile: filename}
+	list, err := c.Targets([]string{"static.example.org"})
+	require.NoError(t, err)
+	require.Equal(t, []Target{
+		{Target: "static.example.org"},
+		{Target: "example.org"},
+		{Target: "example.com", Labels: map[string]string{"region": "eu"}},
+	}, list)
+}
+
+func TestTargetsURL(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		switch n {
+		case 1:
+			w.Write([]byte(`[{"target": "a", "labels": {"check": "1"}}]`))
+		case 2:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte(`["b"]`))
+		}
+	}))
+	defer ts.Close()
+
+	c := &Config{
+		TargetsURL:             ts.URL,
+		TargetsRefreshInterval: internal.Duration{Duration: time.Hour},
+		TargetsHeaders:         map[string]string{"Authorization": "Bearer token"},
+	}
+
+	list, err := c.Targets(nil)
+	require.NoError(t, err)
+	require.Equal(t, []Target{{Target: "a", Labels: map[string]string{"check": "1"}}}, list)
+
+	// Not refreshed before the interval elapsed
+	list, err = c.Targets(nil)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// Failed refreshes keep the previous targets
+	c.loadedAt = time.Now().Add(-time.Hour)
+	list, err = c.Targets(nil)
+	require.Error(t, err)
+	require.Equal(t, []Target{{Target: "a", Labels: map[string]string{"check": "1"}}}, list)
+
+	c.loadedAt = time.Now().Add(-time.Hour)
+	list, err = c.Targets(nil)
+	require.NoError(t, err)
+	require.Equal(t, []Target{{Target: "b"}}, list)
+}
+
+func TestTargetsInvalid(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"labels": {"check": "1"}}]`))
+	}))
+	defer ts.Close()
+
+	c := &Config{TargetsURL: ts.URL}
+	_, err := c.Targets(nil)
+	require.Error(t, err)
+}
+
+func TestAccumulator(t *testing.T) {
+	var acc testutil.Accumulator
+
+	target := Target{
+		Target: "example.org",
+		Labels: map[string]string{"region": "eu", "url": "ignored"},
+	}
+	a := Accumulator(&acc, target)
+	a.AddFields("test",
+		map[string]interface{}{"value": 42},
+		map[string]string{"url": "example.org"},
+		time.Unix(0, 0),
+	)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("test",
+			map[string]string{"region": "eu", "url": "example.org"},
+			map[string]interface{}{"value": 42},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
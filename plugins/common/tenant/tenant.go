@@ -0,0 +1,357 @@
+// Package tenant contains helpers for listener inputs serving multiple
+// tenants.  The tenant of a request is derived from the identity of the
+// client, its metrics are tagged with the tenant and limited per tenant.
+package tenant
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+)
+
+const (
+	sourceBasicUsername = "basic_username"
+	sourceTokenClaim    = "token_claim"
+	sourceTLSCN         = "tls_cn"
+
+	defaultTag        = "tenant"
+	defaultTokenClaim = "sub"
+	defaultSeriesTTL  = time.Hour
+
+	// maxSweepInterval is the longest interval between two removals of the
+	// expired series.
+	maxSweepInterval = time.Minute
+)
+
+var (
+	// ErrRateLimited is returned for metrics exceeding the rate limit of
+	// their tenant.
+	ErrRateLimited = errors.New("tenant rate limit exceeded")
+
+	// ErrSeriesLimited is returned for metrics of new series exceeding the
+	// series quota of their tenant.
+	ErrSeriesLimited = errors.New("tenant series limit exceeded")
+)
+
+// Config selects how the tenant of a request is identified and the limits
+// applied to each tenant.  It is embedded in the listener plugin configs.
+type Config struct {
+	TenantSource     string            `toml:"tenant_source"`
+	TenantTag        string            `toml:"tenant_tag"`
+	TenantUsers      map[string]string `toml:"tenant_users"`
+	TenantTokenKey   string            `toml:"tenant_token_key"`
+	TenantTokenClaim string            `toml:"tenant_token_claim"`
+	TenantRateLimit  float64           `toml:"tenant_rate_limit"`
+	TenantMaxSeries  int               `toml:"tenant_max_series"`
+	TenantSeriesTTL  internal.Duration `toml:"tenant_series_ttl"`
+}
+
+// Tenants identifies the tenants of requests and enforces their limits.  A
+// nil Tenants accepts all requests and metrics unchanged.
+type Tenants struct {
+	source string
+	tag    string
+	users  map[string]string
+	key    interface{}
+	claim  string
+
+	rateLimit float64
+	maxSeries int
+	seriesTTL time.Duration
+	now       func() time.Time
+
+	sync.Mutex
+	states map[string]*state
+	swept  time.Time
+}
+
+// state holds the rate limit and series of a tenant.
+type state struct {
+	tokens float64
+	last   time.Time
+	// series holds the time each series was last seen.
+	series map[uint64]time.Time
+}
+
+type contextKey struct{}
+
+// Tenants returns the Tenants for the config, or nil if no tenant source is
+// configured.
+func (c *Config) Tenants() (*Tenants, error) {
+	if c.TenantSource == "" {
+		return nil, nil
+	}
+
+	t := &Tenants{
+		source:    c.TenantSource,
+		tag:       c.TenantTag,
+		users:     c.TenantUsers,
+		claim:     c.TenantTokenClaim,
+		rateLimit: c.TenantRateLimit,
+		maxSeries: c.TenantMaxSeries,
+		seriesTTL: c.TenantSeriesTTL.Duration,
+		now:       time.Now,
+		states:    make(map[string]*state),
+	}
+	if t.tag == "" {
+		t.tag = defaultTag
+	}
+	if t.claim == "" {
+		t.claim = defaultTokenClaim
+	}
+	if t.seriesTTL == 0 {
+		t.seriesTTL = defaultSeriesTTL
+	}
+
+	switch c.TenantSource {
+	case sourceBasicUsername:
+		if len(c.TenantUsers) == 0 {
+			return nil, fmt.Errorf("tenant_source %q requires tenant_users", sourceBasicUsername)
+		}
+	case sourceTokenClaim:
+		if c.TenantTokenKey == "" {
+			return nil, fmt.Errorf("tenant_source %q requires tenant_token_key", sourceTokenClaim)
+		}
+		key, err := loadKey(c.TenantTokenKey)
+		if err != nil {
+			return nil, err
+		}
+		t.key = key
+	case sourceTLSCN:
+	default:
+		return nil, fmt.Errorf("invalid tenant_source %q", c.TenantSource)
+	}
+
+	if c.TenantRateLimit < 0 {
+		return nil, fmt.Errorf("tenant_rate_limit must not be negative")
+	}
+	if c.TenantMaxSeries < 0 {
+		return nil, fmt.Errorf("tenant_max_series must not be negative")
+	}
+	if c.TenantSeriesTTL.Duration < 0 {
+		return nil, fmt.Errorf("tenant_series_ttl must not be negative")
+	}
+	return t, nil
+}
+
+// UsesBasicAuth returns true if the tenants authenticate the requests using
+// HTTP basic authentication.
+func (t *Tenants) UsesBasicAuth() bool {
+	return t != nil && t.source == sourceBasicUsername
+}
+
+// UsesTokens returns true if the tenants authenticate the requests using
+// tokens in the Authorization header.
+func (t *Tenants) UsesTokens() bool {
+	return t != nil && t.source == sourceTokenClaim
+}
+
+// loadKey loads the key verifying the tokens, a PEM encoded RSA or ECDSA
+// public key or otherwise a secret for HMAC signatures.
+func loadKey(path string) (interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading tenant_token_key: %v", err)
+	}
+
+	if strings.Contains(string(data), "-----BEGIN") {
+		if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+			return key, nil
+		}
+		if key, err := jwt.ParseECPublicKeyFromPEM(data); err == nil {
+			return key, nil
+		}
+		return nil, fmt.Errorf("tenant_token_key is not a RSA or ECDSA public key")
+	}
+
+	secret := []byte(strings.TrimSpace(string(data)))
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("tenant_token_key is empty")
+	}
+	return secret, nil
+}
+
+// Handler returns a handler identifying the tenant of each request before
+// calling next.  Requests without a valid identity are passed to
+// unauthorized instead.
+func (t *Tenants) Handler(next http.Handler, unauthorized http.HandlerFunc) http.Handler {
+	if t == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		tenant, ok := t.identify(req)
+		if !ok {
+			unauthorized(res, req)
+			return
+		}
+		ctx := context.WithValue(req.Context(), contextKey{}, tenant)
+		next.ServeHTTP(res, req.WithContext(ctx))
+	})
+}
+
+// FromContext returns the tenant identified for the request of the context.
+func FromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(contextKey{}).(string)
+	return tenant, ok
+}
+
+// identify returns the tenant of the request.
+func (t *Tenants) identify(req *http.Request) (string, bool) {
+	switch t.source {
+	case sourceBasicUsername:
+		username, password, ok := req.BasicAuth()
+		if !ok {
+			return "", false
+		}
+		expected, found := t.users[username]
+		valid := subtle.ConstantTimeCompare([]byte(password), []byte(expected)) == 1
+		return username, found && valid
+	case sourceTokenClaim:
+		return t.tokenClaim(req.Header.Get("Authorization"))
+	case sourceTLSCN:
+		if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 {
+			return "", false
+		}
+		cn := req.TLS.VerifiedChains[0][0].Subject.CommonName
+		return cn, cn != ""
+	}
+	return "", false
+}
+
+// tokenClaim verifies the token of an Authorization header, using either the
+// "Bearer" or "Token" scheme, and returns the tenant claim.
+func (t *Tenants) tokenClaim(auth string) (string, bool) {
+	i := strings.IndexByte(auth, ' ')
+	if i < 0 {
+		return "", false
+	}
+	if scheme := auth[:i]; scheme != "Bearer" && scheme != "Token" {
+		return "", false
+	}
+
+	token, err := jwt.Parse(strings.TrimSpace(auth[i+1:]), func(token *jwt.Token) (interface{}, error) {
+		// Only accept the signing method matching the key, a public key must
+		// not be usable as a HMAC secret.
+		var ok bool
+		switch t.key.(type) {
+		case *rsa.PublicKey:
+			_, ok = token.Method.(*jwt.SigningMethodRSA)
+		case *ecdsa.PublicKey:
+			_, ok = token.Method.(*jwt.SigningMethodECDSA)
+		case []byte:
+			_, ok = token.Method.(*jwt.SigningMethodHMAC)
+		}
+		if !ok {
+			return nil, fmt.Errorf("unexpected signing method %q", token.Header["alg"])
+		}
+		return t.key, nil
+	})
+	if err != nil || !token.Valid {
+		return "", false
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", false
+	}
+	tenant, ok := claims[t.claim].(string)
+	return tenant, ok && tenant != ""
+}
+
+// Apply tags the metric with the tenant of the request context and checks the
+// limits of the tenant.  A metric exceeding the limits must be dropped.
+func (t *Tenants) Apply(ctx context.Context, m telegraf.Metric) error {
+	if t == nil {
+		return nil
+	}
+
+	tenant, ok := FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	m.AddTag(t.tag, tenant)
+
+	t.Lock()
+	defer t.Unlock()
+
+	now := t.now()
+	if t.maxSeries > 0 {
+		t.sweep(now)
+	}
+
+	s, ok := t.states[tenant]
+	if !ok {
+		s = &state{tokens: t.burst(), last: now, series: make(map[uint64]time.Time)}
+		t.states[tenant] = s
+	}
+
+	var id uint64
+	if t.maxSeries > 0 {
+		id = m.HashID()
+		if _, ok := s.series[id]; !ok && len(s.series) >= t.maxSeries {
+			return ErrSeriesLimited
+		}
+	}
+
+	if t.rateLimit > 0 {
+		s.tokens += now.Sub(s.last).Seconds() * t.rateLimit
+		if burst := t.burst(); s.tokens > burst {
+			s.tokens = burst
+		}
+		s.last = now
+
+		if s.tokens < 1 {
+			return ErrRateLimited
+		}
+		s.tokens--
+	}
+
+	if t.maxSeries > 0 {
+		s.series[id] = now
+	}
+	return nil
+}
+
+// sweep removes the series not seen for the series TTL, so that they no
+// longer count against the quota of their tenant.
+func (t *Tenants) sweep(now time.Time) {
+	interval := t.seriesTTL
+	if interval > maxSweepInterval {
+		interval = maxSweepInterval
+	}
+	if now.Sub(t.swept) < interval {
+		return
+	}
+	t.swept = now
+
+	expired := now.Add(-t.seriesTTL)
+	for _, s := range t.states {
+		for id, seen := range s.series {
+			if !seen.After(expired) {
+				delete(s.series, id)
+			}
+		}
+	}
+}
+
+// burst returns the number of metrics a tenant can send at once, one second
+// worth of the rate limit.
+func (t *Tenants) burst() float64 {
+	if t.rateLimit < 1 {
+		return 1
+	}
+	return t.rateLimit
+}
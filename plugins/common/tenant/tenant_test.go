@@ -0,0 +1,219 @@
+package tenant
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func metric(name string) telegraf.Metric {
+	return testutil.MustMetric(name,
+		map[string]string{},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0),
+	)
+}
+
+// identify returns the tenant identified by the handler, or "" if the request
+// is unauthorized.
+func identify(t *testing.T, tenants *Tenants, req *http.Request) string {
+	var tenant string
+	handler := tenants.Handler(
+		http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			m := metric("cpu")
+			require.NoError(t, tenants.Apply(req.Context(), m))
+			tenant, _ = m.GetTag("tenant")
+		}),
+		func(res http.ResponseWriter, req *http.Request) {
+			res.WriteHeader(http.StatusUnauthorized)
+		},
+	)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code == http.StatusUnauthorized {
+		return ""
+	}
+	return tenant
+}
+
+func TestConfigInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "unknown source", config: Config{TenantSource: "header"}},
+		{name: "basic without users", config: Config{TenantSource: "basic_username"}},
+		{name: "token without key", config: Config{TenantSource: "token_claim"}},
+		{name: "missing key", config: Config{TenantSource: "token_claim", TenantTokenKey: "/nonexistent"}},
+		{name: "negative rate", config: Config{TenantSource: "tls_cn", TenantRateLimit: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.config.Tenants()
+			require.Error(t, err)
+		})
+	}
+
+	tenants, err := (&Config{}).Tenants()
+	require.NoError(t, err)
+	require.Nil(t, tenants)
+}
+
+func TestBasicUsername(t *testing.T) {
+	c := Config{
+		TenantSource: "basic_username",
+		TenantUsers:  map[string]string{"acme": "secret"},
+	}
+	tenants, err := c.Tenants()
+	require.NoError(t, err)
+	require.True(t, tenants.UsesBasicAuth())
+
+	req := httptest.NewRequest("POST", "/write", nil)
+	req.SetBasicAuth("acme", "secret")
+	require.Equal(t, "acme", identify(t, tenants, req))
+
+	req.SetBasicAuth("acme", "wrong")
+	require.Equal(t, "", identify(t, tenants, req))
+
+	req.SetBasicAuth("other", "")
+	require.Equal(t, "", identify(t, tenants, req))
+}
+
+func TestTokenClaim(t *testing.T) {
+	key, err := ioutil.TempFile("", "tenant-key")
+	require.NoError(t, err)
+	defer os.Remove(key.Name())
+	_, err = key.WriteString("secret\n")
+	require.NoError(t, err)
+	require.NoError(t, key.Close())
+
+	c := Config{
+		TenantSource:     "token_claim",
+		TenantTokenKey:   key.Name(),
+		TenantTokenClaim: "org",
+	}
+	tenants, err := c.Tenants()
+	require.NoError(t, err)
+
+	sign := func(method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		require.NoError(t, err)
+		return token
+	}
+
+	req := httptest.NewRequest("POST", "/write", nil)
+	req.Header.Set("Authorization", "Bearer "+sign(jwt.SigningMethodHS256, []byte("secret"), jwt.MapClaims{"org": "acme"}))
+	require.Equal(t, "acme", identify(t, tenants, req))
+
+	req.Header.Set("Authorization", "Token "+sign(jwt.SigningMethodHS256, []byte("secret"), jwt.MapClaims{"org": "acme"}))
+	require.Equal(t, "acme", identify(t, tenants, req))
+
+	req.Header.Set("Authorization", "Bearer "+sign(jwt.SigningMethodHS256, []byte("other"), jwt.MapClaims{"org": "acme"}))
+	require.Equal(t, "", identify(t, tenants, req))
+
+	req.Header.Set("Authorization", "Bearer "+sign(jwt.SigningMethodHS256, []byte("secret"), jwt.MapClaims{"sub": "acme"}))
+	require.Equal(t, "", identify(t, tenants, req))
+
+	expired := jwt.MapClaims{"org": "acme", "exp": time.Now().Add(-time.Hour).Unix()}
+	req.Header.Set("Authorization", "Bearer "+sign(jwt.SigningMethodHS256, []byte("secret"), expired))
+	require.Equal(t, "", identify(t, tenants, req))
+
+	req.Header.Set("Authorization", "Bearer "+sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, jwt.MapClaims{"org": "acme"}))
+	require.Equal(t, "", identify(t, tenants, req))
+}
+
+func TestTLSCN(t *testing.T) {
+	tenants, err := (&Config{TenantSource: "tls_cn", TenantTag: "tenant"}).Tenants()
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/write", nil)
+	require.Equal(t, "", identify(t, tenants, req))
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "acme"}}
+	req.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{cert},
+	}
+	require.Equal(t, "", identify(t, tenants, req), "unverified certificate")
+
+	req.TLS.VerifiedChains = [][]*x509.Certificate{{cert}}
+	require.Equal(t, "acme", identify(t, tenants, req))
+}
+
+func TestApplyLimits(t *testing.T) {
+	c := Config{
+		TenantSource:    "tls_cn",
+		TenantTag:       "customer",
+		TenantRateLimit: 2,
+		TenantMaxSeries: 2,
+	}
+	tenants, err := c.Tenants()
+	require.NoError(t, err)
+
+	now := time.Unix(0, 0)
+	tenants.now = func() time.Time { return now }
+
+	acme := context.WithValue(context.Background(), contextKey{}, "acme")
+	other := context.WithValue(context.Background(), contextKey{}, "other")
+
+	m := metric("cpu")
+	require.NoError(t, tenants.Apply(acme, m))
+	require.Equal(t, "acme", m.Tags()["customer"])
+
+	require.NoError(t, tenants.Apply(acme, metric("cpu")))
+	require.Equal(t, ErrRateLimited, tenants.Apply(acme, metric("cpu")))
+	require.NoError(t, tenants.Apply(other, metric("cpu")))
+
+	now = now.Add(time.Second)
+	require.NoError(t, tenants.Apply(acme, metric("mem")))
+	require.Equal(t, ErrSeriesLimited, tenants.Apply(acme, metric("disk")))
+	require.NoError(t, tenants.Apply(acme, metric("cpu")))
+
+	// Metrics without a tenant are not limited
+	require.NoError(t, tenants.Apply(context.Background(), metric("disk")))
+
+	var nilTenants *Tenants
+	require.NoError(t, nilTenants.Apply(acme, metric("cpu")))
+}
+
+func TestApplySeriesExpiry(t *testing.T) {
+	c := Config{
+		TenantSource:    "tls_cn",
+		TenantMaxSeries: 1,
+		TenantSeriesTTL: internal.Duration{Duration: 10 * time.Minute},
+	}
+	tenants, err := c.Tenants()
+	require.NoError(t, err)
+
+	now := time.Unix(0, 0)
+	tenants.now = func() time.Time { return now }
+
+	acme := context.WithValue(context.Background(), contextKey{}, "acme")
+
+	require.NoError(t, tenants.Apply(acme, metric("cpu")))
+	require.Equal(t, ErrSeriesLimited, tenants.Apply(acme, metric("mem")))
+
+	// A series seen again is kept
+	now = now.Add(5 * time.Minute)
+	require.NoError(t, tenants.Apply(acme, metric("cpu")))
+	now = now.Add(9 * time.Minute)
+	require.Equal(t, ErrSeriesLimited, tenants.Apply(acme, metric("mem")))
+
+	// A series not seen for the TTL no longer counts against the quota
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, tenants.Apply(acme, metric("mem")))
+	require.Equal(t, ErrSeriesLimited, tenants.Apply(acme, metric("cpu")))
+}
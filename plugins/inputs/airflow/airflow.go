@@ -0,0 +1,474 @@
+package airflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const sampleConfig = `
+  ## The Airflow webserver URL, the stable REST API of Airflow 2 is used.
+  url = "http://localhost:8080"
+
+  ## Credentials for the basic authentication backend of the API.
+  # username = "admin"
+  # password = "admin"
+
+  ## Amount of time allowed to complete the HTTP requests.
+  # response_timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## Period over which the finished dag runs and task instances are counted
+  ## and the scheduling latencies are computed.
+  # lookback = "1h"
+
+  ## Filter dags, default is no filtering.
+  ## Values can be specified as glob patterns.
+  # dag_include = []
+  # dag_exclude = []
+`
+
+// pageLimit is the number of entries requested per page, the default
+// maximum of the API.
+const pageLimit = 100
+
+// Airflow gathers the state of the dag runs and task instances from the
+// REST API of Airflow.
+type Airflow struct {
+	URL             string            `toml:"url"`
+	Username        string            `toml:"username"`
+	Password        string            `toml:"password"`
+	ResponseTimeout internal.Duration `toml:"response_timeout"`
+	Lookback        internal.Duration `toml:"lookback"`
+	DagInclude      []string          `toml:"dag_include"`
+	DagExclude      []string          `toml:"dag_exclude"`
+	tls.ClientConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	client    *http.Client
+	dagFilter filter.Filter
+}
+
+type healthResponse struct {
+	Metadatabase struct {
+		Status string `json:"status"`
+	} `json:"metadatabase"`
+	Scheduler struct {
+		Status                   string     `json:"status"`
+		LatestSchedulerHeartbeat *time.Time `json:"latest_scheduler_heartbeat"`
+	} `json:"scheduler"`
+}
+
+type poolsResponse struct {
+	Pools []struct {
+		Name          string `json:"name"`
+		Slots         int64  `json:"slots"`
+		OccupiedSlots int64  `json:"occupied_slots"`
+		UsedSlots     int64  `json:"used_slots"`
+		QueuedSlots   int64  `json:"queued_slots"`
+		OpenSlots     int64  `json:"open_slots"`
+	} `json:"pools"`
+	TotalEntries int `json:"total_entries"`
+}
+
+type dag struct {
+	DagID    string `json:"dag_id"`
+	IsPaused bool   `json:"is_paused"`
+}
+
+type dagsResponse struct {
+	Dags         []dag `json:"dags"`
+	TotalEntries int   `json:"total_entries"`
+}
+
+type dagRun struct {
+	DagID           string     `json:"dag_id"`
+	DagRunID        string     `json:"dag_run_id"`
+	RunType         string     `json:"run_type"`
+	State           string     `json:"state"`
+	DataIntervalEnd *time.Time `json:"data_interval_end"`
+	StartDate       *time.Time `json:"start_date"`
+}
+
+type dagRunsResponse struct {
+	DagRuns      []dagRun `json:"dag_runs"`
+	TotalEntries int      `json:"total_entries"`
+}
+
+type taskInstance struct {
+	DagID      string     `json:"dag_id"`
+	DagRunID   string     `json:"dag_run_id"`
+	TaskID     string     `json:"task_id"`
+	State      *string    `json:"state"`
+	QueuedWhen *time.Time `json:"queued_when"`
+	StartDate  *time.Time `json:"start_date"`
+}
+
+type taskInstancesResponse struct {
+	TaskInstances []taskInstance `json:"task_instances"`
+	TotalEntries  int            `json:"total_entries"`
+}
+
+// The states for which a count is always reported, so that the series are
+// continuous.
+var (
+	runStates  = []string{"queued", "running", "success", "failed"}
+	taskStates = []string{"scheduled", "queued", "running", "success", "failed", "up_for_retry", "upstream_failed", "skipped"}
+)
+
+func (a *Airflow) SampleConfig() string {
+	return sampleConfig
+}
+
+func (a *Airflow) Description() string {
+	return "Read dag runs and task instances metrics from Airflow"
+}
+
+func (a *Airflow) Init() error {
+	if a.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	a.URL = strings.TrimRight(a.URL, "/")
+
+	var err error
+	a.dagFilter, err = filter.NewIncludeExcludeFilter(a.DagInclude, a.DagExclude)
+	if err != nil {
+		return fmt.Errorf("dag filter: %v", err)
+	}
+
+	tlsCfg, err := a.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	a.client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: a.ResponseTimeout.Duration,
+	}
+	return nil
+}
+
+func (a *Airflow) Gather(acc telegraf.Accumulator) error {
+	now := time.Now()
+	if err := a.gatherHealth(acc, now); err != nil {
+		acc.AddError(fmt.Errorf("health: %v", err))
+	}
+	if err := a.gatherPools(acc); err != nil {
+		acc.AddError(fmt.Errorf("pools: %v", err))
+	}
+	if err := a.gatherDags(acc, now); err != nil {
+		acc.AddError(fmt.Errorf("dags: %v", err))
+	}
+	return nil
+}
+
+func (a *Airflow) gatherHealth(acc telegraf.Accumulator, now time.Time) error {
+	var health healthResponse
+	err := a.get("/api/v1/health", nil, func(body io.Reader) error {
+		return json.NewDecoder(body).Decode(&health)
+	})
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]interface{}{
+		"metadatabase_healthy": health.Metadatabase.Status == "healthy",
+		"scheduler_healthy":    health.Scheduler.Status == "healthy",
+	}
+	if health.Scheduler.LatestSchedulerHeartbeat != nil {
+		fields["scheduler_heartbeat_age"] = now.Sub(*health.Scheduler.LatestSchedulerHeartbeat).Seconds()
+	}
+	acc.AddFields("airflow", fields, map[string]string{"url": a.URL}, now)
+	return nil
+}
+
+func (a *Airflow) gatherPools(acc telegraf.Accumulator) error {
+	return a.getPages("/api/v1/pools", url.Values{}, func(body io.Reader) (int, int, error) {
+		var resp poolsResponse
+		if err := json.NewDecoder(body).Decode(&resp); err != nil {
+			return 0, 0, err
+		}
+		for _, pool := range resp.Pools {
+			acc.AddFields("airflow_pool",
+				map[string]interface{}{
+					"slots":          pool.Slots,
+					"occupied_slots": pool.OccupiedSlots,
+					"used_slots":     pool.UsedSlots,
+					"queued_slots":   pool.QueuedSlots,
+					"open_slots":     pool.OpenSlots,
+				},
+				map[string]string{
+					"url":  a.URL,
+					"pool": pool.Name,
+				})
+		}
+		return len(resp.Pools), resp.TotalEntries, nil
+	})
+}
+
+// dagStats accumulates the metrics of a dag.
+type dagStats struct {
+	paused     bool
+	runs       map[string]int64
+	tasks      map[string]int64
+	runDelay   latency
+	queueDelay latency
+}
+
+// latency accumulates durations to report their mean and maximum.
+type latency struct {
+	count int
+	sum   float64
+	max   float64
+}
+
+func (l *latency) add(d time.Duration) {
+	s := d.Seconds()
+	if l.count == 0 || s > l.max {
+		l.max = s
+	}
+	l.sum += s
+	l.count++
+}
+
+func (l *latency) addFields(fields map[string]interface{}, prefix string) {
+	if l.count == 0 {
+		return
+	}
+	fields[prefix+"_mean"] = l.sum / float64(l.count)
+	fields[prefix+"_max"] = l.max
+}
+
+func (a *Airflow) gatherDags(acc telegraf.Accumulator, now time.Time) error {
+	stats := make(map[string]*dagStats)
+	err := a.getPages("/api/v1/dags", url.Values{}, func(body io.Reader) (int, int, error) {
+		var resp dagsResponse
+		if err := json.NewDecoder(body).Decode(&resp); err != nil {
+			return 0, 0, err
+		}
+		for _, d := range resp.Dags {
+			if !a.dagFilter.Match(d.DagID) {
+				continue
+			}
+			s := &dagStats{
+				paused: d.IsPaused,
+				runs:   make(map[string]int64),
+				tasks:  make(map[string]int64),
+			}
+			for _, state := range runStates {
+				s.runs[state] = 0
+			}
+			for _, state := range taskStates {
+				s.tasks[state] = 0
+			}
+			stats[d.DagID] = s
+		}
+		return len(resp.Dags), resp.TotalEntries, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	since := now.Add(-a.Lookback.Duration)
+	runs, err := a.dagRuns(since)
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		s, ok := stats[run.DagID]
+		if !ok {
+			continue
+		}
+		s.runs[run.State]++
+
+		// The scheduling delay of a scheduled run is the time from the end
+		// of its data interval to its start.
+		if run.RunType == "scheduled" && run.DataIntervalEnd != nil &&
+			run.StartDate != nil && run.StartDate.After(since) {
+			s.runDelay.add(run.StartDate.Sub(*run.DataIntervalEnd))
+		}
+	}
+
+	tasks, err := a.taskInstances(since)
+	if err != nil {
+		return err
+	}
+	for _, ti := range tasks {
+		s, ok := stats[ti.DagID]
+		if !ok || ti.State == nil {
+			continue
+		}
+		s.tasks[*ti.State]++
+
+		if ti.QueuedWhen != nil && ti.StartDate != nil && ti.StartDate.After(since) {
+			s.queueDelay.add(ti.StartDate.Sub(*ti.QueuedWhen))
+		}
+	}
+
+	for dagID, s := range stats {
+		fields := map[string]interface{}{
+			"paused": s.paused,
+		}
+		for state, count := range s.runs {
+			fields["runs_"+state] = count
+		}
+		for state, count := range s.tasks {
+			fields["tasks_"+state] = count
+		}
+		s.runDelay.addFields(fields, "run_schedule_delay")
+		s.queueDelay.addFields(fields, "task_queue_delay")
+
+		acc.AddFields("airflow_dag", fields,
+			map[string]string{
+				"url":    a.URL,
+				"dag_id": dagID,
+			}, now)
+	}
+	return nil
+}
+
+// dagRuns returns the active dag runs and the runs finished since the given
+// time.  A run finishing between the requests is counted once, in its final
+// state.
+func (a *Airflow) dagRuns(since time.Time) ([]dagRun, error) {
+	runs := make(map[string]dagRun)
+	collect := func(body io.Reader) (int, int, error) {
+		var resp dagRunsResponse
+		if err := json.NewDecoder(body).Decode(&resp); err != nil {
+			return 0, 0, err
+		}
+		for _, run := range resp.DagRuns {
+			runs[run.DagID+"\x00"+run.DagRunID] = run
+		}
+		return len(resp.DagRuns), resp.TotalEntries, nil
+	}
+
+	path := "/api/v1/dags/~/dagRuns"
+	if err := a.getPages(path, url.Values{"state": {"queued", "running"}}, collect); err != nil {
+		return nil, err
+	}
+	if err := a.getPages(path, url.Values{"end_date_gte": {formatTime(since)}}, collect); err != nil {
+		return nil, err
+	}
+
+	list := make([]dagRun, 0, len(runs))
+	for _, run := range runs {
+		list = append(list, run)
+	}
+	return list, nil
+}
+
+// taskInstances returns the pending task instances and the instances
+// finished since the given time.
+func (a *Airflow) taskInstances(since time.Time) ([]taskInstance, error) {
+	tasks := make(map[string]taskInstance)
+	collect := func(body io.Reader) (int, int, error) {
+		var resp taskInstancesResponse
+		if err := json.NewDecoder(body).Decode(&resp); err != nil {
+			return 0, 0, err
+		}
+		for _, ti := range resp.TaskInstances {
+			tasks[ti.DagID+"\x00"+ti.DagRunID+"\x00"+ti.TaskID] = ti
+		}
+		return len(resp.TaskInstances), resp.TotalEntries, nil
+	}
+
+	path := "/api/v1/dags/~/dagRuns/~/taskInstances"
+	if err := a.getPages(path, url.Values{"state": {"scheduled", "queued", "running"}}, collect); err != nil {
+		return nil, err
+	}
+	if err := a.getPages(path, url.Values{"end_date_gte": {formatTime(since)}}, collect); err != nil {
+		return nil, err
+	}
+
+	list := make([]taskInstance, 0, len(tasks))
+	for _, ti := range tasks {
+		list = append(list, ti)
+	}
+	return list, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// getPages requests all the pages of a collection, the collect function
+// decodes a page and returns its number of entries and the total number of
+// entries of the collection.
+func (a *Airflow) getPages(path string, params url.Values, collect func(io.Reader) (int, int, error)) error {
+	offset := 0
+	for {
+		params.Set("limit", strconv.Itoa(pageLimit))
+		params.Set("offset", strconv.Itoa(offset))
+
+		var entries, total int
+		err := a.get(path, params, func(body io.Reader) error {
+			var err error
+			entries, total, err = collect(body)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		offset += entries
+		if entries == 0 || offset >= total {
+			return nil
+		}
+	}
+}
+
+func (a *Airflow) get(path string, params url.Values, decode func(io.Reader) error) error {
+	addr := a.URL + path
+	if len(params) > 0 {
+		addr += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest("GET", addr, nil)
+	if err != nil {
+		return err
+	}
+	if a.Username != "" || a.Password != "" {
+		req.SetBasicAuth(a.Username, a.Password)
+	}
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP status %s", path, resp.Status)
+	}
+	return decode(resp.Body)
+}
+
+func init() {
+	inputs.Add("airflow", func() telegraf.Input {
+		return &Airflow{
+			ResponseTimeout: internal.Duration{Duration: 5 * time.Second},
+			Lookback:        internal.Duration{Duration: time.Hour},
+		}
+	})
+}
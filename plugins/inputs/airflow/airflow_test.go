@@ -0,0 +1,185 @@
+package airflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGather(t *testing.T) {
+	now := time.Now().UTC()
+	ts := func(d time.Duration) string {
+		return now.Add(-d).Format(time.RFC3339)
+	}
+
+	responses := map[string]string{
+		"/api/v1/health": fmt.Sprintf(`{
+			"metadatabase": {"status": "healthy"},
+			"scheduler": {"status": "unhealthy", "latest_scheduler_heartbeat": %q}
+		}`, ts(time.Minute)),
+		"/api/v1/pools": `{"pools": [{"name": "default_pool", "slots": 128, "occupied_slots": 3,
+			"used_slots": 2, "queued_slots": 1, "open_slots": 125}], "total_entries": 1}`,
+		"/api/v1/dags": `{"dags": [{"dag_id": "etl", "is_paused": false},
+			{"dag_id": "tmp_test", "is_paused": true}], "total_entries": 2}`,
+		"/api/v1/dags/~/dagRuns?state=queued&state=running": fmt.Sprintf(`{"dag_runs": [
+			{"dag_id": "etl", "dag_run_id": "r1", "run_type": "scheduled", "state": "running",
+				"data_interval_end": %q, "start_date": %q},
+			{"dag_id": "etl", "dag_run_id": "r2", "run_type": "manual", "state": "queued"}
+		], "total_entries": 2}`, ts(10*time.Minute), ts(9*time.Minute)),
+		"/api/v1/dags/~/dagRuns?end_date_gte": fmt.Sprintf(`{"dag_runs": [
+			{"dag_id": "etl", "dag_run_id": "r0", "run_type": "scheduled", "state": "failed",
+				"data_interval_end": %q, "start_date": %q}
+		], "total_entries": 1}`, ts(40*time.Minute), ts(37*time.Minute)),
+		"/api/v1/dags/~/dagRuns/~/taskInstances?state=scheduled&state=queued&state=running": fmt.Sprintf(`{"task_instances": [
+			{"dag_id": "etl", "dag_run_id": "r1", "task_id": "extract", "state": "running",
+				"queued_when": %q, "start_date": %q},
+			{"dag_id": "etl", "dag_run_id": "r1", "task_id": "load", "state": "queued",
+				"queued_when": %q, "start_date": null}
+		], "total_entries": 2}`, ts(9*time.Minute), ts(8*time.Minute), ts(time.Minute)),
+		"/api/v1/dags/~/dagRuns/~/taskInstances?end_date_gte": fmt.Sprintf(`{"task_instances": [
+			{"dag_id": "etl", "dag_run_id": "r1", "task_id": "extract", "state": "success",
+				"queued_when": %q, "start_date": %q},
+			{"dag_id": "etl", "dag_run_id": "r0", "task_id": "extract", "state": "failed",
+				"queued_when": %q, "start_date": %q}
+		], "total_entries": 2}`, ts(9*time.Minute), ts(8*time.Minute), ts(37*time.Minute), ts(34*time.Minute)),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "admin" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/api/v1/health" {
+			require.Equal(t, "100", r.URL.Query().Get("limit"))
+		}
+
+		key := r.URL.Path
+		q := r.URL.Query()
+		if states, ok := q["state"]; ok {
+			key += "?state=" + states[0]
+			for _, s := range states[1:] {
+				key += "&state=" + s
+			}
+		} else if q.Get("end_date_gte") != "" {
+			key += "?end_date_gte"
+		}
+		body, ok := responses[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	plugin := &Airflow{
+		URL:        server.URL + "/",
+		Username:   "admin",
+		Password:   "secret",
+		DagExclude: []string{"tmp_*"},
+		Log:        testutil.Logger{},
+	}
+	plugin.Lookback.Duration = time.Hour
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(plugin.Gather))
+
+	pool := map[string]interface{}{
+		"slots":          int64(128),
+		"occupied_slots": int64(3),
+		"used_slots":     int64(2),
+		"queued_slots":   int64(1),
+		"open_slots":     int64(125),
+	}
+	acc.AssertContainsTaggedFields(t, "airflow_pool", pool,
+		map[string]string{"url": server.URL, "pool": "default_pool"})
+
+	healthy, ok := acc.BoolField("airflow", "metadatabase_healthy")
+	require.True(t, ok)
+	require.True(t, healthy)
+	healthy, ok = acc.BoolField("airflow", "scheduler_healthy")
+	require.True(t, ok)
+	require.False(t, healthy)
+	age, ok := acc.FloatField("airflow", "scheduler_heartbeat_age")
+	require.True(t, ok)
+	require.InDelta(t, 60, age, 5)
+
+	require.Equal(t, 1, countMeasurement(&acc, "airflow_dag"))
+	acc.AssertContainsTaggedFields(t, "airflow_dag",
+		map[string]interface{}{
+			"paused":                  false,
+			"runs_queued":             int64(1),
+			"runs_running":            int64(1),
+			"runs_success":            int64(0),
+			"runs_failed":             int64(1),
+			"tasks_scheduled":         int64(0),
+			"tasks_queued":            int64(1),
+			"tasks_running":           int64(0),
+			"tasks_success":           int64(1),
+			"tasks_failed":            int64(1),
+			"tasks_up_for_retry":      int64(0),
+			"tasks_upstream_failed":   int64(0),
+			"tasks_skipped":           int64(0),
+			"run_schedule_delay_mean": float64(120),
+			"run_schedule_delay_max":  float64(180),
+			"task_queue_delay_mean":   float64(120),
+			"task_queue_delay_max":    float64(180),
+		},
+		map[string]string{"url": server.URL, "dag_id": "etl"})
+}
+
+func TestGetPages(t *testing.T) {
+	var offsets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset := r.URL.Query().Get("offset")
+		offsets = append(offsets, offset)
+		if offset == "0" {
+			fmt.Fprint(w, `{"dags": [{"dag_id": "a"}, {"dag_id": "b"}], "total_entries": 3}`)
+			return
+		}
+		fmt.Fprint(w, `{"dags": [{"dag_id": "c"}], "total_entries": 3}`)
+	}))
+	defer server.Close()
+
+	plugin := &Airflow{URL: server.URL}
+	require.NoError(t, plugin.Init())
+
+	var ids []string
+	err := plugin.getPages("/api/v1/dags", url.Values{}, func(body io.Reader) (int, int, error) {
+		var resp dagsResponse
+		if err := json.NewDecoder(body).Decode(&resp); err != nil {
+			return 0, 0, err
+		}
+		for _, d := range resp.Dags {
+			ids = append(ids, d.DagID)
+		}
+		return len(resp.Dags), resp.TotalEntries, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"0", "2"}, offsets)
+	require.Equal(t, []string{"a", "b", "c"}, ids)
+}
+
+func TestInitRequiresURL(t *testing.T) {
+	require.Error(t, (&Airflow{}).Init())
+}
+
+func countMeasurement(acc *testutil.Accumulator, name string) int {
+	count := 0
+	for _, m := range acc.Metrics {
+		if m.Measurement == name {
+			count++
+		}
+	}
+	return count
+}
@@ -19,6 +19,9 @@ import (
 
 const (
 	defaultMaxUndeliveredMessages = 1000
+
+	deliveryBestEffort  = "best_effort"
+	deliveryAtLeastOnce = "at_least_once"
 )
 
 type empty struct{}
@@ -36,6 +39,7 @@ type AMQPConsumer struct {
 	ExchangePassive        bool              `toml:"exchange_passive"`
 	ExchangeArguments      map[string]string `toml:"exchange_arguments"`
 	MaxUndeliveredMessages int               `toml:"max_undelivered_messages"`
+	DeliveryMode           string            `toml:"delivery_mode"`
 
 	// Queue Name
 	Queue           string `toml:"queue"`
@@ -144,6 +148,11 @@ func (a *AMQPConsumer) SampleConfig() string {
   ## waiting until the next flush_interval.
   # max_undelivered_messages = 1000
 
+  ## Delivery guarantee of the messages; one of "best_effort" or
+  ## "at_least_once".  With "at_least_once" messages not written by the
+  ## outputs are requeued instead of rejected.
+  # delivery_mode = "best_effort"
+
   ## Auth method. PLAIN and EXTERNAL are supported
   ## Using EXTERNAL requires enabling the rabbitmq_auth_mechanism_ssl plugin as
   ## described here: https://www.rabbitmq.com/plugins.html
@@ -209,6 +218,14 @@ func (a *AMQPConsumer) createConfig() (*amqp.Config, error) {
 
 // Start satisfies the telegraf.ServiceInput interface
 func (a *AMQPConsumer) Start(acc telegraf.Accumulator) error {
+	switch a.DeliveryMode {
+	case "":
+		a.DeliveryMode = deliveryBestEffort
+	case deliveryBestEffort, deliveryAtLeastOnce:
+	default:
+		return fmt.Errorf("invalid delivery_mode %q", a.DeliveryMode)
+	}
+
 	amqpConf, err := a.createConfig()
 	if err != nil {
 		return err
@@ -522,7 +539,9 @@ func (a *AMQPConsumer) onDelivery(track telegraf.DeliveryInfo) bool {
 			a.conn.Close()
 		}
 	} else {
-		err := delivery.Reject(false)
+		// Requeue the message to be consumed again, otherwise the broker
+		// discards it or passes it to the dead letter exchange of the queue.
+		err := delivery.Reject(a.DeliveryMode == deliveryAtLeastOnce)
 		if err != nil {
 			a.Log.Errorf("Unable to reject failed delivery: %d: %v", delivery.DeliveryTag, err)
 			a.conn.Close()
@@ -0,0 +1,127 @@
+package celery
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBroker struct {
+	lists   map[string]int64
+	unacked []string
+	oldest  float64
+	results []string
+}
+
+func (b *fakeBroker) LLen(key string) (int64, error) {
+	return b.lists[key], nil
+}
+
+func (b *fakeBroker) HVals(key string) ([]string, error) {
+	if key != unackedKey {
+		return nil, fmt.Errorf("unexpected key %q", key)
+	}
+	return b.unacked, nil
+}
+
+func (b *fakeBroker) Oldest(key string) (float64, bool, error) {
+	if key != unackedIndexKey {
+		return 0, false, fmt.Errorf("unexpected key %q", key)
+	}
+	return b.oldest, b.oldest != 0, nil
+}
+
+func (b *fakeBroker) Values(pattern string) ([]string, error) {
+	if pattern != "celery-task-meta-*" {
+		return nil, fmt.Errorf("unexpected pattern %q", pattern)
+	}
+	return b.results, nil
+}
+
+func (b *fakeBroker) Tags() map[string]string {
+	return map[string]string{"server": "localhost", "port": "6379", "db": "0"}
+}
+
+func TestGather(t *testing.T) {
+	delivered := time.Now().Add(-30 * time.Second)
+	client := &fakeBroker{
+		lists: map[string]int64{
+			"celery":             5,
+			"celery\x06\x163":    2,
+			"celery\x06\x169":    1,
+			"reports":            0,
+			"reports\x06\x166":   4,
+			"not_configured":     100,
+			"not_configured\x06": 100,
+		},
+		unacked: []string{
+			`[{"body": "e30="}, "", "celery"]`,
+			`[{"body": "e30="}, "", "celery"]`,
+			`[{"body": "e30="}, "", "other"]`,
+			`invalid`,
+		},
+		oldest: float64(delivered.UnixNano()) / float64(time.Second),
+		results: []string{
+			`{"status": "SUCCESS", "result": 4, "task_id": "a"}`,
+			`{"status": "SUCCESS", "result": 2, "task_id": "b"}`,
+			`{"status": "FAILURE", "result": {}, "task_id": "c"}`,
+			`{"status": "STARTED", "task_id": "d"}`,
+		},
+	}
+
+	plugin := &Celery{
+		Queues:        []string{"celery", "reports"},
+		ResultBackend: true,
+		Log:           testutil.Logger{},
+		clients:       []broker{client},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(plugin.Gather))
+
+	acc.AssertContainsTaggedFields(t, "celery_queue",
+		map[string]interface{}{"messages": int64(8), "unacked": int64(2)},
+		map[string]string{"server": "localhost", "port": "6379", "db": "0", "queue": "celery"})
+	acc.AssertContainsTaggedFields(t, "celery_queue",
+		map[string]interface{}{"messages": int64(4), "unacked": int64(0)},
+		map[string]string{"server": "localhost", "port": "6379", "db": "0", "queue": "reports"})
+
+	age, ok := acc.FloatField("celery", "oldest_unacked_age")
+	require.True(t, ok)
+	require.InDelta(t, 30, age, 5)
+
+	for field, expected := range map[string]int64{
+		"unacked":       3,
+		"tasks_pending": 0,
+		"tasks_started": 1,
+		"tasks_retry":   0,
+		"tasks_failure": 1,
+		"tasks_success": 2,
+		"tasks_revoked": 0,
+	} {
+		value, ok := acc.Int64Field("celery", field)
+		require.True(t, ok, field)
+		require.Equal(t, expected, value, field)
+	}
+}
+
+func TestInit(t *testing.T) {
+	plugin := &Celery{
+		Servers: []string{"tcp://:secret@broker:6380/2", "unix:///var/run/redis.sock"},
+	}
+	require.NoError(t, plugin.Init())
+	require.Equal(t, []string{"celery"}, plugin.Queues)
+	require.Len(t, plugin.clients, 2)
+
+	client := plugin.clients[0].(*redisBroker)
+	require.Equal(t, "broker:6380", client.client.Options().Addr)
+	require.Equal(t, 2, client.client.Options().DB)
+	require.Equal(t, "secret", client.client.Options().Password)
+	require.Equal(t, map[string]string{"server": "broker", "port": "6380", "db": "2"}, client.Tags())
+
+	require.Error(t, (&Celery{Servers: []string{"tcp://broker:6379/db"}}).Init())
+	require.Error(t, (&Celery{Servers: []string{"broker:6379"}}).Init())
+}
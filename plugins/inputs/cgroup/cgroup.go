@@ -6,8 +6,11 @@ import (
 )
 
 type CGroup struct {
-	Paths []string `toml:"paths"`
-	Files []string `toml:"files"`
+	Paths    []string `toml:"paths"`
+	Files    []string `toml:"files"`
+	MaxDepth int      `toml:"max_depth"`
+
+	unifiedRoot string
 }
 
 var sampleConfig = `
@@ -15,6 +18,8 @@ var sampleConfig = `
   ## Consider restricting paths to the set of cgroups you really
   ## want to monitor if you have a large number of cgroups, to avoid
   ## any cardinality issues.
+  ## When empty, the root of the cgroup v2 unified hierarchy is discovered
+  ## from the mounted file systems.
   # paths = [
   #   "/cgroup/memory",
   #   "/cgroup/memory/child1",
@@ -22,7 +27,14 @@ var sampleConfig = `
   # ]
   ## cgroup stat fields, as file names, globs are supported.
   ## these file names are appended to each path from above.
+  ## When empty, the files of the cgroup v2 controllers are gathered:
+  ## cpu.stat, cpu.max, memory.current, memory.max, memory.stat, io.stat,
+  ## pids.current, pids.max and the pressure files.
   # files = ["memory.*usage*", "memory.limit_in_bytes"]
+
+  ## Number of levels of child cgroups to gather below each path, 0 gathers
+  ## the paths only.
+  # max_depth = 0
 `
 
 func (g *CGroup) SampleConfig() string {
@@ -3,20 +3,52 @@
 package cgroup
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/influxdata/telegraf"
 )
 
 const metricName = "cgroup"
 
+// unifiedFiles are the files gathered when none are configured, the
+// interface files of the cgroup v2 controllers.
+var unifiedFiles = []string{
+	"cpu.stat",
+	"cpu.max",
+	"cpu.pressure",
+	"memory.current",
+	"memory.max",
+	"memory.stat",
+	"memory.events",
+	"memory.pressure",
+	"io.stat",
+	"io.pressure",
+	"pids.current",
+	"pids.max",
+}
+
+// procMounts lists the mounted file systems, in the format of fstab.
+var procMounts = "/proc/self/mounts"
+
 func (g *CGroup) Gather(acc telegraf.Accumulator) error {
+	if len(g.Paths) == 0 && g.unifiedRoot == "" {
+		root, err := findUnifiedRoot()
+		if err != nil {
+			return err
+		}
+		g.unifiedRoot = root
+	}
+
 	list := make(chan pathInfo)
 	go g.generateDirs(list)
 
@@ -80,9 +112,38 @@ func isDir(path string) (bool, error) {
 	return result.IsDir(), nil
 }
 
+// findUnifiedRoot returns the mount point of the cgroup v2 hierarchy.
+func findUnifiedRoot() (string, error) {
+	f, err := os.Open(procMounts)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 3 && fields[2] == "cgroup2" {
+			return fields[1], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("no cgroup v2 hierarchy is mounted, paths must be set")
+}
+
 func (g *CGroup) generateDirs(list chan<- pathInfo) {
 	defer close(list)
-	for _, dir := range g.Paths {
+
+	paths := g.Paths
+	if len(paths) == 0 {
+		paths = []string{g.unifiedRoot}
+	}
+
+	// overlapping paths and children are only gathered once
+	seen := make(map[string]bool)
+	for _, dir := range paths {
 		// getting all dirs that match the pattern 'dir'
 		items, err := filepath.Glob(dir)
 		if err != nil {
@@ -98,15 +159,48 @@ func (g *CGroup) generateDirs(list chan<- pathInfo) {
 			}
 			// supply only dirs
 			if ok {
-				list <- pathInfo{path: item}
+				if err := g.generateTree(item, g.MaxDepth, seen, list); err != nil {
+					list <- pathInfo{err: err}
+					return
+				}
 			}
 		}
 	}
 }
 
+// generateTree supplies the dir and its children dirs down to depth levels.
+func (g *CGroup) generateTree(dir string, depth int, seen map[string]bool, list chan<- pathInfo) error {
+	if !seen[dir] {
+		seen[dir] = true
+		list <- pathInfo{path: dir}
+	}
+	if depth <= 0 {
+		return nil
+	}
+
+	items, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if !item.IsDir() {
+			continue
+		}
+		if err := g.generateTree(filepath.Join(dir, item.Name()), depth-1, seen, list); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (g *CGroup) generateFiles(dir string, list chan<- pathInfo) {
 	defer close(list)
-	for _, file := range g.Files {
+
+	files := g.Files
+	if len(files) == 0 {
+		files = unifiedFiles
+	}
+	for _, file := range files {
 		// getting all file paths that match the pattern 'dir + file'
 		// path.Base make sure that file variable does not contains part of path
 		items, err := filepath.Glob(path.Join(dir, path.Base(file)))
@@ -168,8 +262,8 @@ type fileFormat struct {
 	parser  func(measurement string, fields map[string]interface{}, b []byte)
 }
 
-const keyPattern = "[[:alpha:]_]+"
-const valuePattern = "[\\d-]+"
+const keyPattern = "[[:alnum:]:_.]+"
+const valuePattern = "(?:max|[\\d.-]+)"
 
 var fileFormats = [...]fileFormat{
 	// 	VAL\n
@@ -199,9 +293,9 @@ var fileFormats = [...]fileFormat{
 	// 	VAL0 VAL1 ...\n
 	{
 		name:    "Space separated values",
-		pattern: "^(" + valuePattern + " )+\n$",
+		pattern: "^(" + valuePattern + " )+(" + valuePattern + ")?\n$",
 		parser: func(measurement string, fields map[string]interface{}, b []byte) {
-			re := regexp.MustCompile("(" + valuePattern + ") ")
+			re := regexp.MustCompile("(" + valuePattern + ")[ \n]")
 			matches := re.FindAllStringSubmatch(string(b), -1)
 			for i, v := range matches {
 				fields[measurement+"."+strconv.Itoa(i)] = numberOrString(v[1])
@@ -222,13 +316,43 @@ var fileFormats = [...]fileFormat{
 			}
 		},
 	},
+	// 	NAME0 KEY0=VAL0 KEY1=VAL1 ...\n
+	// 	NAME1 KEY0=VAL0 KEY1=VAL1 ...\n
+	// 	...
+	{
+		name:    "New line separated nested keyed values",
+		pattern: "^(" + keyPattern + "( " + keyPattern + "=" + valuePattern + ")+\n)+$",
+		parser: func(measurement string, fields map[string]interface{}, b []byte) {
+			for _, line := range strings.Split(string(b), "\n") {
+				items := strings.Fields(line)
+				if len(items) == 0 {
+					continue
+				}
+				for _, item := range items[1:] {
+					kv := strings.SplitN(item, "=", 2)
+					fields[measurement+"."+items[0]+"."+kv[0]] = numberOrString(kv[1])
+				}
+			}
+		},
+	},
 }
 
+// numberOrString types the value as an integer, or as a float for the values
+// with a fractional part such as the averages of the pressure files, which
+// always have one.  The "max" limits of cgroup v2 are the largest integer.
 func numberOrString(s string) interface{} {
 	i, err := strconv.ParseInt(s, 10, 64)
 	if err == nil {
 		return i
 	}
+	if s == "max" {
+		return int64(math.MaxInt64)
+	}
+	if strings.Contains(s, ".") {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
 
 	return s
 }
@@ -3,6 +3,7 @@
 package cgroup
 
 import (
+	"math"
 	"testing"
 
 	"github.com/influxdata/telegraf/testutil"
@@ -180,3 +181,106 @@ func TestCgroupStatistics_6(t *testing.T) {
 	}
 	acc.AssertContainsTaggedFields(t, "cgroup", fields, tags)
 }
+
+// ======================================================================
+
+func TestCgroupUnifiedDiscovery(t *testing.T) {
+	defer func(mounts string) { procMounts = mounts }(procMounts)
+	procMounts = "testdata/mounts"
+
+	cg := &CGroup{MaxDepth: 2}
+
+	var acc testutil.Accumulator
+	err := acc.GatherError(cg.Gather)
+	require.NoError(t, err)
+
+	acc.AssertContainsTaggedFields(t, "cgroup",
+		map[string]interface{}{
+			"cpu.stat.usage_usec":         int64(5126341000),
+			"cpu.stat.user_usec":          int64(3296452000),
+			"cpu.stat.system_usec":        int64(1829889000),
+			"io.stat.8:0.rbytes":          int64(1459200),
+			"io.stat.8:0.wbytes":          int64(314773504),
+			"io.stat.8:0.rios":            int64(192),
+			"io.stat.8:0.wios":            int64(353),
+			"io.stat.8:0.dbytes":          int64(0),
+			"io.stat.8:0.dios":            int64(0),
+			"io.stat.253:0.rbytes":        int64(1024),
+			"io.stat.253:0.wbytes":        int64(0),
+			"io.stat.253:0.rios":          int64(1),
+			"io.stat.253:0.wios":          int64(0),
+			"io.stat.253:0.dbytes":        int64(0),
+			"io.stat.253:0.dios":          int64(0),
+			"memory.pressure.some.avg10":  float64(0),
+			"memory.pressure.some.avg60":  float64(0.12),
+			"memory.pressure.some.avg300": float64(0.05),
+			"memory.pressure.some.total":  int64(2134000),
+			"memory.pressure.full.avg10":  float64(0),
+			"memory.pressure.full.avg60":  float64(0),
+			"memory.pressure.full.avg300": float64(0),
+			"memory.pressure.full.total":  int64(1088000),
+		},
+		map[string]string{"path": "testdata/v2"})
+
+	acc.AssertContainsTaggedFields(t, "cgroup",
+		map[string]interface{}{
+			"cpu.max.0":                           int64(math.MaxInt64),
+			"cpu.max.1":                           int64(100000),
+			"memory.current":                      int64(61812736),
+			"memory.max":                          int64(math.MaxInt64),
+			"memory.events.low":                   int64(0),
+			"memory.events.high":                  int64(0),
+			"memory.events.max":                   int64(0),
+			"memory.events.oom":                   int64(0),
+			"memory.events.oom_kill":              int64(0),
+			"memory.stat.anon":                    int64(12288000),
+			"memory.stat.file":                    int64(48234496),
+			"memory.stat.kernel_stack":            int64(442368),
+			"memory.stat.file_thp":                int64(0),
+			"memory.stat.workingset_refault_anon": int64(0),
+			"pids.current":                        int64(4),
+			"pids.max":                            int64(4915),
+		},
+		map[string]string{"path": "testdata/v2/system.slice"})
+
+	acc.AssertContainsTaggedFields(t, "cgroup",
+		map[string]interface{}{
+			"cpu.max.0":      int64(20000),
+			"cpu.max.1":      int64(100000),
+			"memory.current": int64(1048576),
+		},
+		map[string]string{"path": "testdata/v2/system.slice/docker.service"})
+
+	// The child cgroup is deeper than max_depth
+	require.Len(t, acc.Metrics, 3)
+}
+
+func TestCgroupUnifiedNotMounted(t *testing.T) {
+	defer func(mounts string) { procMounts = mounts }(procMounts)
+	procMounts = "testdata/blkio/blkio.io_serviced"
+
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError((&CGroup{}).Gather))
+}
+
+func TestCgroupDepthDeduplicated(t *testing.T) {
+	cg := &CGroup{
+		Paths:    []string{"testdata/v2/system.slice", "testdata/v2/system.slice/*"},
+		Files:    []string{"memory.current"},
+		MaxDepth: 1,
+	}
+
+	var acc testutil.Accumulator
+	err := acc.GatherError(cg.Gather)
+	require.NoError(t, err)
+
+	paths := make(map[string]int)
+	for _, m := range acc.Metrics {
+		paths[m.Tags["path"]]++
+	}
+	require.Equal(t, map[string]int{
+		"testdata/v2/system.slice":                      1,
+		"testdata/v2/system.slice/docker.service":       1,
+		"testdata/v2/system.slice/docker.service/child": 1,
+	}, paths)
+}
@@ -0,0 +1,191 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	// watchWaitTime is the maximum duration of a blocking query on the
+	// services of the catalog.
+	watchWaitTime = 5 * time.Minute
+
+	// watchRetryInterval is the interval between the queries on the services
+	// after a failure.
+	watchRetryInterval = 10 * time.Second
+)
+
+// raftPrefix is the prefix of the raft telemetry of the Consul servers.
+const raftPrefix = "consul.raft."
+
+// gatherRaft adds the raft leader and peers, along with the raft telemetry of
+// the agent.  The telemetry is only available on the servers.
+func (c *Consul) gatherRaft(acc telegraf.Accumulator) error {
+	leader, err := c.client.Status().Leader()
+	if err != nil {
+		return fmt.Errorf("getting raft leader: %v", err)
+	}
+	peers, err := c.client.Status().Peers()
+	if err != nil {
+		return fmt.Errorf("getting raft peers: %v", err)
+	}
+
+	fields := map[string]interface{}{
+		"leader":     leader,
+		"has_leader": leader != "",
+		"peers":      len(peers),
+	}
+
+	info, err := c.client.Agent().Metrics()
+	if err != nil {
+		return fmt.Errorf("getting agent metrics: %v", err)
+	}
+	addRaftTelemetry(fields, info)
+
+	acc.AddFields("consul_raft", fields, nil)
+	return nil
+}
+
+// addRaftTelemetry adds the raft metrics of the agent telemetry to the fields,
+// named after the metric without the "consul.raft." prefix.  The statistics of
+// the counters and samples are suffixed by their name.
+func addRaftTelemetry(fields map[string]interface{}, info *api.MetricsInfo) {
+	add := func(name string, value interface{}) {
+		if _, ok := fields[name]; !ok {
+			fields[name] = value
+		}
+	}
+
+	for _, gauge := range info.Gauges {
+		if name, ok := raftFieldName(gauge.Name); ok {
+			add(name, float64(gauge.Value))
+		}
+	}
+	for _, counter := range info.Counters {
+		if name, ok := raftFieldName(counter.Name); ok {
+			add(name+"_count", counter.Count)
+			add(name+"_sum", counter.Sum)
+		}
+	}
+	for _, sample := range info.Samples {
+		if name, ok := raftFieldName(sample.Name); ok {
+			add(name+"_count", sample.Count)
+			add(name+"_mean", sample.Mean)
+			add(name+"_max", sample.Max)
+		}
+	}
+}
+
+func raftFieldName(name string) (string, bool) {
+	if !strings.HasPrefix(name, raftPrefix) {
+		return "", false
+	}
+	return strings.Replace(strings.TrimPrefix(name, raftPrefix), ".", "_", -1), true
+}
+
+// gatherKV adds the number of keys under the prefix.
+func (c *Consul) gatherKV(acc telegraf.Accumulator, prefix string) error {
+	keys, _, err := c.client.KV().Keys(prefix, "", nil)
+	if err != nil {
+		return fmt.Errorf("listing keys of %q: %v", prefix, err)
+	}
+
+	fields := map[string]interface{}{
+		"keys": len(keys),
+	}
+	tags := map[string]string{
+		"prefix": prefix,
+	}
+	acc.AddFields("consul_kv", fields, tags)
+	return nil
+}
+
+// watchServices queries the services of the catalog with blocking queries
+// until the context is done, adding a metric for each change.
+func (c *Consul) watchServices(ctx context.Context, acc telegraf.Accumulator) {
+	var index uint64
+	var services map[string][]string
+	for {
+		q := &api.QueryOptions{WaitIndex: index, WaitTime: watchWaitTime}
+		current, meta, err := c.client.Catalog().Services(q.WithContext(ctx))
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			acc.AddError(fmt.Errorf("watching services: %v", err))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(watchRetryInterval):
+			}
+			continue
+		}
+
+		// The index going backwards means that it was reset, the next query
+		// must not block.
+		if meta.LastIndex < index {
+			index = 0
+		} else {
+			index = meta.LastIndex
+		}
+
+		// The first query returns the services to compare the changes to.
+		if services != nil {
+			addServiceChanges(acc, services, current)
+		}
+		services = current
+	}
+}
+
+// addServiceChanges adds a consul_service_change metric for each service
+// registered, deregistered or whose tags changed.
+func addServiceChanges(acc telegraf.Accumulator, previous, current map[string][]string) {
+	now := time.Now()
+	add := func(name, action string, tags []string) {
+		acc.AddFields("consul_service_change",
+			map[string]interface{}{"tags": strings.Join(tags, ",")},
+			map[string]string{"service_name": name, "action": action},
+			now)
+	}
+
+	names := make([]string, 0, len(previous)+len(current))
+	for name := range previous {
+		names = append(names, name)
+	}
+	for name := range current {
+		if _, ok := previous[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		before, registered := previous[name]
+		after, ok := current[name]
+		switch {
+		case !registered:
+			add(name, "registered", sortedTags(after))
+		case !ok:
+			add(name, "deregistered", sortedTags(before))
+		default:
+			b, a := sortedTags(before), sortedTags(after)
+			if strings.Join(b, ",") != strings.Join(a, ",") {
+				add(name, "updated", a)
+			}
+		}
+	}
+}
+
+func sortedTags(tags []string) []string {
+	sorted := make([]string, len(tags))
+	copy(sorted, tags)
+	sort.Strings(sorted)
+	return sorted
+}
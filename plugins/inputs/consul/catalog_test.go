@@ -0,0 +1,128 @@
+package consul
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// consulServer serves the health checks, status, agent metrics, KV keys and
+// catalog services of a fake Consul agent.  Each query of the services
+// returns the next version of the catalog, the last one blocks until the
+// server is closed.
+func consulServer(t *testing.T, catalogs ...string) *httptest.Server {
+	var mu sync.Mutex
+	var queries int
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/health/state/any":
+			fmt.Fprint(w, `[]`)
+		case "/v1/status/leader":
+			fmt.Fprint(w, `"10.0.0.1:8300"`)
+		case "/v1/status/peers":
+			fmt.Fprint(w, `["10.0.0.1:8300","10.0.0.2:8300","10.0.0.3:8300"]`)
+		case "/v1/agent/metrics":
+			fmt.Fprint(w, `{
+				"Gauges": [{"Name": "consul.runtime.num_goroutines", "Value": 80}],
+				"Counters": [{"Name": "consul.raft.apply", "Count": 3, "Sum": 5}],
+				"Samples": [{"Name": "consul.raft.commitTime", "Count": 2, "Mean": 1.5, "Max": 2}]
+			}`)
+		case "/v1/kv/services/":
+			require.Equal(t, "", r.URL.Query().Get("keys"))
+			fmt.Fprint(w, `["services/api","services/web"]`)
+		case "/v1/catalog/services":
+			mu.Lock()
+			i := queries
+			queries++
+			mu.Unlock()
+
+			if i >= len(catalogs) {
+				<-r.Context().Done()
+				return
+			}
+			w.Header().Set("X-Consul-Index", fmt.Sprint(i+1))
+			fmt.Fprint(w, catalogs[i])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestGatherRaftAndKV(t *testing.T) {
+	ts := consulServer(t)
+	defer ts.Close()
+
+	plugin := &Consul{
+		Address:    ts.Listener.Addr().String(),
+		GatherRaft: true,
+		KVPrefixes: []string{"services/"},
+		Log:        testutil.Logger{},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsFields(t, "consul_raft", map[string]interface{}{
+		"leader":           "10.0.0.1:8300",
+		"has_leader":       true,
+		"peers":            3,
+		"apply_count":      3,
+		"apply_sum":        5.0,
+		"commitTime_count": 2,
+		"commitTime_mean":  1.5,
+		"commitTime_max":   2.0,
+	})
+	acc.AssertContainsTaggedFields(t, "consul_kv",
+		map[string]interface{}{"keys": 2},
+		map[string]string{"prefix": "services/"})
+}
+
+func TestWatchServices(t *testing.T) {
+	ts := consulServer(t,
+		`{"consul": [], "web": ["v1"], "db": ["primary"]}`,
+		`{"consul": [], "web": ["v2"], "api": ["public", "v1"]}`,
+	)
+	defer ts.Close()
+
+	plugin := &Consul{
+		Address:       ts.Listener.Addr().String(),
+		WatchServices: true,
+		Log:           testutil.Logger{},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	acc.Wait(3)
+	plugin.Stop()
+
+	expected := []struct {
+		name   string
+		action string
+		tags   string
+	}{
+		{"api", "registered", "public,v1"},
+		{"db", "deregistered", "primary"},
+		{"web", "updated", "v2"},
+	}
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, len(expected))
+	for i, e := range expected {
+		require.Equal(t, "consul_service_change", metrics[i].Name())
+		require.Equal(t, map[string]string{"service_name": e.name, "action": e.action}, metrics[i].Tags())
+		require.Equal(t, map[string]interface{}{"tags": e.tags}, metrics[i].Fields())
+	}
+}
+
+func TestAddServiceChangesUnchanged(t *testing.T) {
+	var acc testutil.Accumulator
+	addServiceChanges(&acc,
+		map[string][]string{"web": {"b", "a"}},
+		map[string][]string{"web": {"a", "b"}})
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
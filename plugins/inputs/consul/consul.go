@@ -1,8 +1,10 @@
 package consul
 
 import (
+	"context"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/influxdata/telegraf"
@@ -21,8 +23,17 @@ type Consul struct {
 	tls.ClientConfig
 	TagDelimiter string
 
+	GatherRaft    bool     `toml:"gather_raft"`
+	KVPrefixes    []string `toml:"kv_prefixes"`
+	WatchServices bool     `toml:"watch_services"`
+
+	Log telegraf.Logger `toml:"-"`
+
 	// client used to connect to Consul agnet
 	client *api.Client
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 var sampleConfig = `
@@ -53,6 +64,17 @@ var sampleConfig = `
   # When tags are formatted like "key:value" with ":" as a delimiter then
   # they will be splitted and reported as proper key:value in Telegraf
   # tag_delimiter = ":"
+
+  ## Gather the raft leader and peers, and the raft telemetry of the agent when
+  ## it is a server.
+  # gather_raft = false
+
+  ## Gather the number of keys under each of these KV prefixes.
+  # kv_prefixes = []
+
+  ## Watch the services of the catalog and add a consul_service_change metric
+  ## when a service is registered, deregistered or its tags are changed.
+  # watch_services = false
 `
 
 func (c *Consul) Description() string {
@@ -140,6 +162,29 @@ func (c *Consul) GatherHealthCheck(acc telegraf.Accumulator, checks []*api.Healt
 	}
 }
 
+func (c *Consul) Start(acc telegraf.Accumulator) error {
+	if !c.WatchServices {
+		return nil
+	}
+
+	if c.client == nil {
+		newClient, err := c.createAPIClient()
+		if err != nil {
+			return err
+		}
+		c.client = newClient
+	}
+
+	var ctx context.Context
+	ctx, c.cancel = context.WithCancel(context.Background())
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.watchServices(ctx, acc)
+	}()
+	return nil
+}
+
 func (c *Consul) Gather(acc telegraf.Accumulator) error {
 	if c.client == nil {
 		newClient, err := c.createAPIClient()
@@ -159,9 +204,24 @@ func (c *Consul) Gather(acc telegraf.Accumulator) error {
 
 	c.GatherHealthCheck(acc, checks)
 
+	if c.GatherRaft {
+		acc.AddError(c.gatherRaft(acc))
+	}
+
+	for _, prefix := range c.KVPrefixes {
+		acc.AddError(c.gatherKV(acc, prefix))
+	}
+
 	return nil
 }
 
+func (c *Consul) Stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.wg.Wait()
+}
+
 func init() {
 	inputs.Add("consul", func() telegraf.Input {
 		return &Consul{}
@@ -0,0 +1,110 @@
+package dell_unity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// collection is a page of instances returned by the Unisphere REST API.
+type collection struct {
+	Entries []struct {
+		Content json.RawMessage `json:"content"`
+	} `json:"entries"`
+	Links []struct {
+		Rel  string `json:"rel"`
+		Href string `json:"href"`
+	} `json:"links"`
+}
+
+// apiError is the error returned by the Unisphere REST API.
+type apiError struct {
+	Error struct {
+		ErrorCode int                 `json:"errorCode"`
+		Messages  []map[string]string `json:"messages"`
+	} `json:"error"`
+}
+
+type client struct {
+	baseURL    *url.URL
+	username   string
+	password   string
+	httpClient *http.Client
+	perPage    int
+}
+
+// instances requests the given fields of all instances of the resource type
+// and calls fn with the content of each instance.
+func (c *client) instances(ctx context.Context, resourceType string, query url.Values, fn func(content json.RawMessage) error) error {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("compact", "true")
+	if c.perPage > 0 {
+		q.Set("per_page", strconv.Itoa(c.perPage))
+	}
+
+	for page := 1; ; page++ {
+		q.Set("page", strconv.Itoa(page))
+
+		var r collection
+		if err := c.get(ctx, "/api/types/"+resourceType+"/instances", q, &r); err != nil {
+			return err
+		}
+		for _, entry := range r.Entries {
+			if err := fn(entry.Content); err != nil {
+				return err
+			}
+		}
+
+		next := false
+		for _, link := range r.Links {
+			if link.Rel == "next" {
+				next = true
+			}
+		}
+		if !next || len(r.Entries) == 0 {
+			return nil
+		}
+	}
+}
+
+func (c *client) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	u, err := c.baseURL.Parse(path)
+	if err != nil {
+		return err
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.username, c.password)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-EMC-REST-CLIENT", "true")
+
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		var e apiError
+		if json.Unmarshal(body, &e) == nil && len(e.Error.Messages) > 0 {
+			for _, message := range e.Error.Messages[0] {
+				return fmt.Errorf("%s returned HTTP status %s: %s", path, resp.Status, message)
+			}
+		}
+		return fmt.Errorf("%s returned HTTP status %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
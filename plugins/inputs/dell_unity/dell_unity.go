@@ -0,0 +1,434 @@
+package dell_unity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/choice"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+var availableCollectors = []string{"pool", "lun", "replication"}
+
+// Health values of the Unisphere HealthEnum.
+const (
+	healthOK    = 5
+	healthOKBut = 7
+)
+
+// storageResourceConsistencyGroup is the type of storage resources grouping
+// several LUNs.
+const storageResourceConsistencyGroup = 2
+
+// lunMetrics maps the historical metric paths of LUNs to field names.
+var lunMetrics = []struct {
+	path  string
+	field string
+	// average is true for metrics which are averaged rather than summed
+	// across storage processors.
+	average bool
+}{
+	{path: "sp.*.storage.lun.*.readsRate", field: "reads_per_sec"},
+	{path: "sp.*.storage.lun.*.writesRate", field: "writes_per_sec"},
+	{path: "sp.*.storage.lun.*.readBytesRate", field: "read_bytes_per_sec"},
+	{path: "sp.*.storage.lun.*.writeBytesRate", field: "write_bytes_per_sec"},
+	{path: "sp.*.storage.lun.*.responseTime", field: "response_time_us", average: true},
+	{path: "sp.*.storage.lun.*.queueLength", field: "queue_length"},
+}
+
+// Unity gathers metrics of Dell EMC Unity arrays using the Unisphere REST API.
+type Unity struct {
+	URLs            []string          `toml:"urls"`
+	Username        string            `toml:"username"`
+	Password        string            `toml:"password"`
+	Collect         []string          `toml:"collect"`
+	PerPage         int               `toml:"per_page"`
+	ResponseTimeout internal.Duration `toml:"response_timeout"`
+	tls.ClientConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	clients []*client
+}
+
+const sampleConfig = `
+  ## URLs of the Unisphere management interfaces.
+  urls = ["https://unity1.example.com"]
+
+  ## Credentials of a user with at least the operator role.
+  username = "monitor"
+  password = "secret"
+
+  ## Metrics to collect; by default all are collected.
+  ## Available collectors are "pool", "lun" and "replication".
+  # collect = ["pool", "lun", "replication"]
+
+  ## Number of instances to request per page.
+  # per_page = 1000
+
+  ## Amount of time allowed to complete a single request.
+  # response_timeout = "10s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+// SampleConfig returns the default configuration of the plugin.
+func (u *Unity) SampleConfig() string {
+	return sampleConfig
+}
+
+// Description returns a one-sentence description of the plugin.
+func (u *Unity) Description() string {
+	return "Read LUN performance, capacity and replication metrics from Dell EMC Unity arrays"
+}
+
+// Init validates the configuration and creates the clients.
+func (u *Unity) Init() error {
+	if len(u.URLs) == 0 {
+		return fmt.Errorf("no urls configured")
+	}
+	if len(u.Collect) == 0 {
+		u.Collect = availableCollectors
+	}
+	if err := choice.CheckSlice(u.Collect, availableCollectors); err != nil {
+		return fmt.Errorf("invalid collect option: %v", err)
+	}
+	if u.PerPage == 0 {
+		u.PerPage = 1000
+	}
+	if u.ResponseTimeout.Duration == 0 {
+		u.ResponseTimeout.Duration = 10 * time.Second
+	}
+
+	tlsCfg, err := u.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	for _, addr := range u.URLs {
+		baseURL, err := url.Parse(addr)
+		if err != nil {
+			return fmt.Errorf("invalid url %q: %v", addr, err)
+		}
+		// The session cookie is kept to avoid creating a new session with
+		// every request.
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return err
+		}
+		u.clients = append(u.clients, &client{
+			baseURL:  baseURL,
+			username: u.Username,
+			password: u.Password,
+			httpClient: &http.Client{
+				Transport: &http.Transport{
+					Proxy:           http.ProxyFromEnvironment,
+					TLSClientConfig: tlsCfg,
+				},
+				Jar:     jar,
+				Timeout: u.ResponseTimeout.Duration,
+			},
+			perPage: u.PerPage,
+		})
+	}
+	return nil
+}
+
+// Gather collects the metrics of all arrays.
+func (u *Unity) Gather(acc telegraf.Accumulator) error {
+	var wg sync.WaitGroup
+	for _, c := range u.clients {
+		wg.Add(1)
+		go func(c *client) {
+			defer wg.Done()
+			u.gatherArray(context.Background(), c, acc)
+		}(c)
+	}
+	wg.Wait()
+	return nil
+}
+
+type health struct {
+	Value int `json:"value"`
+}
+
+// healthy returns true if the health is OK or OK with minor issues.
+func (h health) healthy() bool {
+	return h.Value == healthOK || h.Value == healthOKBut
+}
+
+type reference struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type pool struct {
+	Name           string `json:"name"`
+	Health         health `json:"health"`
+	SizeTotal      int64  `json:"sizeTotal"`
+	SizeUsed       int64  `json:"sizeUsed"`
+	SizeFree       int64  `json:"sizeFree"`
+	SizeSubscribed int64  `json:"sizeSubscribed"`
+}
+
+type lun struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	Health          health    `json:"health"`
+	SizeTotal       int64     `json:"sizeTotal"`
+	SizeAllocated   int64     `json:"sizeAllocated"`
+	Pool            reference `json:"pool"`
+	StorageResource struct {
+		Name string `json:"name"`
+		Type int    `json:"type"`
+	} `json:"storageResource"`
+}
+
+type replicationSession struct {
+	Name         string    `json:"name"`
+	Health       health    `json:"health"`
+	SyncState    int       `json:"syncState"`
+	SyncProgress *int      `json:"syncProgress"`
+	LastSyncTime string    `json:"lastSyncTime"`
+	RemoteSystem reference `json:"remoteSystem"`
+}
+
+// metricValue holds the values of a metric path per storage processor and
+// object.
+type metricValue struct {
+	Timestamp string                            `json:"timestamp"`
+	Values    map[string]map[string]interface{} `json:"values"`
+}
+
+func (u *Unity) gatherArray(ctx context.Context, c *client, acc telegraf.Accumulator) {
+	// The name of the system is needed to tag all metrics.
+	var name string
+	err := c.instances(ctx, "system", url.Values{"fields": {"name"}}, func(content json.RawMessage) error {
+		var system reference
+		if err := json.Unmarshal(content, &system); err != nil {
+			return err
+		}
+		name = system.Name
+		return nil
+	})
+	if err != nil {
+		acc.AddError(fmt.Errorf("%s: %v", c.baseURL.Host, err))
+		return
+	}
+	if name == "" {
+		name = c.baseURL.Hostname()
+	}
+
+	for _, collector := range u.Collect {
+		var err error
+		switch collector {
+		case "pool":
+			err = gatherPools(ctx, c, name, acc)
+		case "lun":
+			err = u.gatherLUNs(ctx, c, name, acc)
+		case "replication":
+			err = gatherReplication(ctx, c, name, acc)
+		}
+		if err != nil {
+			acc.AddError(fmt.Errorf("%s: collecting %s: %v", name, collector, err))
+		}
+	}
+}
+
+func gatherPools(ctx context.Context, c *client, arrayName string, acc telegraf.Accumulator) error {
+	query := url.Values{"fields": {"name,health,sizeTotal,sizeUsed,sizeFree,sizeSubscribed"}}
+	return c.instances(ctx, "pool", query, func(content json.RawMessage) error {
+		var p pool
+		if err := json.Unmarshal(content, &p); err != nil {
+			return err
+		}
+		tags := map[string]string{
+			"array": arrayName,
+			"pool":  p.Name,
+		}
+		fields := map[string]interface{}{
+			"health":           p.Health.Value,
+			"healthy":          p.Health.healthy(),
+			"size_total_bytes": p.SizeTotal,
+			"size_used_bytes":  p.SizeUsed,
+			"size_free_bytes":  p.SizeFree,
+			"subscribed_bytes": p.SizeSubscribed,
+		}
+		acc.AddFields("dell_unity_pool", fields, tags)
+		return nil
+	})
+}
+
+func (u *Unity) gatherLUNs(ctx context.Context, c *client, arrayName string, acc telegraf.Accumulator) error {
+	type entry struct {
+		tags   map[string]string
+		fields map[string]interface{}
+	}
+	luns := make(map[string]*entry)
+	var order []string
+
+	query := url.Values{"fields": {"id,name,health,sizeTotal,sizeAllocated,pool.name,storageResource.name,storageResource.type"}}
+	err := c.instances(ctx, "lun", query, func(content json.RawMessage) error {
+		var l lun
+		if err := json.Unmarshal(content, &l); err != nil {
+			return err
+		}
+		tags := map[string]string{
+			"array": arrayName,
+			"lun":   l.Name,
+		}
+		if l.Pool.Name != "" {
+			tags["pool"] = l.Pool.Name
+		}
+		if l.StorageResource.Type == storageResourceConsistencyGroup {
+			tags["volume_group"] = l.StorageResource.Name
+		}
+		fields := map[string]interface{}{
+			"health":               l.Health.Value,
+			"healthy":              l.Health.healthy(),
+			"size_total_bytes":     l.SizeTotal,
+			"size_allocated_bytes": l.SizeAllocated,
+		}
+		luns[l.ID] = &entry{tags: tags, fields: fields}
+		order = append(order, l.ID)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Performance metrics require historical metrics collection to be
+	// enabled; missing metrics are logged but do not prevent reporting the
+	// capacity.
+	for _, metric := range lunMetrics {
+		values, err := latestMetricValue(ctx, c, metric.path)
+		if err != nil {
+			u.Log.Debugf("%s: reading %s: %v", arrayName, metric.path, err)
+			continue
+		}
+		for id, v := range aggregateSPs(values, metric.average) {
+			if l, ok := luns[id]; ok {
+				l.fields[metric.field] = v
+			}
+		}
+	}
+
+	now := time.Now()
+	for _, id := range order {
+		l := luns[id]
+		acc.AddFields("dell_unity_lun", l.fields, l.tags, now)
+	}
+	return nil
+}
+
+// latestMetricValue returns the most recent historical value of the metric
+// path.
+func latestMetricValue(ctx context.Context, c *client, path string) (map[string]map[string]interface{}, error) {
+	query := url.Values{
+		"filter":   {fmt.Sprintf("path EQ %q", path)},
+		"orderby":  {"timestamp desc"},
+		"compact":  {"true"},
+		"per_page": {"1"},
+	}
+	var r collection
+	if err := c.get(ctx, "/api/types/metricValue/instances", query, &r); err != nil {
+		return nil, err
+	}
+	if len(r.Entries) == 0 {
+		return nil, fmt.Errorf("no values")
+	}
+	var value metricValue
+	if err := json.Unmarshal(r.Entries[0].Content, &value); err != nil {
+		return nil, err
+	}
+	return value.Values, nil
+}
+
+// aggregateSPs combines the values of each object reported by the storage
+// processors.
+func aggregateSPs(values map[string]map[string]interface{}, average bool) map[string]float64 {
+	result := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, objects := range values {
+		for id, raw := range objects {
+			v, ok := toFloat(raw)
+			if !ok {
+				continue
+			}
+			result[id] += v
+			counts[id]++
+		}
+	}
+	if average {
+		for id, count := range counts {
+			result[id] /= float64(count)
+		}
+	}
+	return result
+}
+
+// toFloat converts a metric value, which is either a number or a numeric
+// string, to a float.
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func gatherReplication(ctx context.Context, c *client, arrayName string, acc telegraf.Accumulator) error {
+	query := url.Values{"fields": {"name,health,syncState,syncProgress,lastSyncTime,remoteSystem.name"}}
+	return c.instances(ctx, "replicationSession", query, func(content json.RawMessage) error {
+		var s replicationSession
+		if err := json.Unmarshal(content, &s); err != nil {
+			return err
+		}
+		tags := map[string]string{
+			"array":   arrayName,
+			"session": s.Name,
+		}
+		if s.RemoteSystem.Name != "" {
+			tags["remote_system"] = s.RemoteSystem.Name
+		}
+		fields := map[string]interface{}{
+			"health":     s.Health.Value,
+			"healthy":    s.Health.healthy(),
+			"sync_state": s.SyncState,
+		}
+		if s.SyncProgress != nil {
+			fields["sync_progress"] = *s.SyncProgress
+		}
+		if s.LastSyncTime != "" {
+			if t, err := time.Parse(time.RFC3339, s.LastSyncTime); err == nil {
+				fields["last_sync_time"] = t.Unix()
+			}
+		}
+		acc.AddFields("dell_unity_replication", fields, tags)
+		return nil
+	})
+}
+
+func init() {
+	inputs.Add("dell_unity", func() telegraf.Input {
+		return &Unity{}
+	})
+}
@@ -0,0 +1,217 @@
+package dell_unity
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var responses = map[string]string{
+	"/api/types/system/instances": `{
+		"entries": [{"content": {"id": "0", "name": "unity1"}}]
+	}`,
+	"/api/types/pool/instances": `{
+		"entries": [{"content": {
+			"id": "pool_1",
+			"name": "Pool 1",
+			"health": {"value": 5},
+			"sizeTotal": 10000,
+			"sizeUsed": 4000,
+			"sizeFree": 6000,
+			"sizeSubscribed": 12000
+		}}]
+	}`,
+	"/api/types/lun/instances": `{
+		"entries": [{"content": {
+			"id": "sv_1",
+			"name": "lun1",
+			"health": {"value": 5},
+			"sizeTotal": 1000,
+			"sizeAllocated": 300,
+			"pool": {"id": "pool_1", "name": "Pool 1"},
+			"storageResource": {"id": "res_1", "name": "cg1", "type": 2}
+		}}],
+		"links": [{"rel": "self", "href": "&page=1"}, {"rel": "next", "href": "&page=2"}]
+	}`,
+	"/api/types/lun/instances?page=2": `{
+		"entries": [{"content": {
+			"id": "sv_2",
+			"name": "lun2",
+			"health": {"value": 20},
+			"sizeTotal": 2000,
+			"sizeAllocated": 2000,
+			"pool": {"id": "pool_1", "name": "Pool 1"},
+			"storageResource": {"id": "sv_2", "name": "lun2", "type": 8}
+		}}],
+		"links": [{"rel": "self", "href": "&page=2"}]
+	}`,
+	"sp.*.storage.lun.*.readsRate": `{
+		"entries": [{"content": {"path": "sp.*.storage.lun.*.readsRate", "timestamp": "2020-09-13T12:25:00.000Z",
+			"values": {"spa": {"sv_1": 10, "sv_2": 1}, "spb": {"sv_1": 5, "sv_2": 0}}}}]
+	}`,
+	"sp.*.storage.lun.*.responseTime": `{
+		"entries": [{"content": {"path": "sp.*.storage.lun.*.responseTime", "timestamp": "2020-09-13T12:25:00.000Z",
+			"values": {"spa": {"sv_1": "300", "sv_2": 1000}, "spb": {"sv_1": "100"}}}}]
+	}`,
+	"/api/types/replicationSession/instances": `{
+		"entries": [{"content": {
+			"id": "42949672965_FNM00150600267_0000_42949672965_FNM00150600267_0000",
+			"name": "rep_lun1",
+			"health": {"value": 7},
+			"syncState": 2,
+			"syncProgress": 100,
+			"lastSyncTime": "2020-09-13T12:00:00.000Z",
+			"remoteSystem": {"id": "RS_1", "name": "unity2"}
+		}}]
+	}`,
+}
+
+func newServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "true", r.Header.Get("X-EMC-REST-CLIENT"))
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "monitor" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": {"errorCode": 131149829, "httpStatusCode": 401, "messages": [{"en-US": "Unauthorized"}]}}`))
+			return
+		}
+		require.Equal(t, "true", r.URL.Query().Get("compact"))
+
+		key := r.URL.Path
+		if key == "/api/types/metricValue/instances" {
+			require.Equal(t, "1", r.URL.Query().Get("per_page"))
+			require.Equal(t, "timestamp desc", r.URL.Query().Get("orderby"))
+			filter := r.URL.Query().Get("filter")
+			key = filter[len(`path EQ "`) : len(filter)-1]
+		} else {
+			require.Equal(t, "1000", r.URL.Query().Get("per_page"))
+			if page := r.URL.Query().Get("page"); page != "1" {
+				key += "?page=" + page
+			}
+		}
+		response, ok := responses[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"errorCode": 131149826, "httpStatusCode": 404, "messages": [{"en-US": "The requested resource does not exist."}]}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+}
+
+func TestGather(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+
+	plugin := &Unity{
+		URLs:     []string{ts.URL},
+		Username: "monitor",
+		Password: "secret",
+		Log:      testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("dell_unity_pool",
+			map[string]string{
+				"array": "unity1",
+				"pool":  "Pool 1",
+			},
+			map[string]interface{}{
+				"health":           int64(5),
+				"healthy":          true,
+				"size_total_bytes": int64(10000),
+				"size_used_bytes":  int64(4000),
+				"size_free_bytes":  int64(6000),
+				"subscribed_bytes": int64(12000),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("dell_unity_lun",
+			map[string]string{
+				"array":        "unity1",
+				"lun":          "lun1",
+				"pool":         "Pool 1",
+				"volume_group": "cg1",
+			},
+			map[string]interface{}{
+				"health":               int64(5),
+				"healthy":              true,
+				"size_total_bytes":     int64(1000),
+				"size_allocated_bytes": int64(300),
+				"reads_per_sec":        15.0,
+				"response_time_us":     200.0,
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("dell_unity_lun",
+			map[string]string{
+				"array": "unity1",
+				"lun":   "lun2",
+				"pool":  "Pool 1",
+			},
+			map[string]interface{}{
+				"health":               int64(20),
+				"healthy":              false,
+				"size_total_bytes":     int64(2000),
+				"size_allocated_bytes": int64(2000),
+				"reads_per_sec":        1.0,
+				"response_time_us":     1000.0,
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("dell_unity_replication",
+			map[string]string{
+				"array":         "unity1",
+				"session":       "rep_lun1",
+				"remote_system": "unity2",
+			},
+			map[string]interface{}{
+				"health":         int64(7),
+				"healthy":        true,
+				"sync_state":     int64(2),
+				"sync_progress":  int64(100),
+				"last_sync_time": int64(1599998400),
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestGatherUnauthorized(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+
+	plugin := &Unity{
+		URLs:     []string{ts.URL},
+		Username: "monitor",
+		Password: "wrong",
+		Log:      testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "Unauthorized")
+	require.Empty(t, acc.Metrics)
+}
+
+func TestInitInvalidCollector(t *testing.T) {
+	plugin := &Unity{
+		URLs:    []string{"https://localhost"},
+		Collect: []string{"filesystem"},
+	}
+	require.Error(t, plugin.Init())
+}
@@ -0,0 +1,367 @@
+package directory_monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+const sampleConfig = `
+  ## Directory to monitor for new files.
+  directory = ""
+
+  ## Directory the files are moved to once their metrics are added.
+  finished_directory = ""
+
+  ## Directory the files are moved to when they cannot be processed.  A file
+  ## named after the file with the ".error" suffix holds the reason of the
+  ## error, unless error_reason_file is false.  The finished_directory is used
+  ## if not set.
+  # error_directory = ""
+  # error_reason_file = true
+
+  ## Names of the files to process and to ignore in the directory, as glob
+  ## patterns.  All the files are processed if files_to_monitor is empty.
+  # files_to_monitor = []
+  # files_to_ignore = []
+
+  ## Time a file must be left unmodified before it is processed, to not read
+  ## files still being written.
+  # directory_duration_threshold = "50ms"
+
+  ## Number of files processed at the same time.
+  # max_concurrent_files = 1
+
+  ## Maximum number of files waiting to be processed.  The other files are
+  ## picked at the next interval.
+  # file_queue_size = 100
+
+  ## Add a directory_monitor metric for each file processed.
+  # file_metrics = false
+
+  ## Upload the files processed successfully to S3 before moving them.  The
+  ## files that cannot be uploaded are moved to the error_directory.
+  # [inputs.directory_monitor.s3]
+  #   bucket = ""
+  #   ## Prefix of the object keys, the file name is appended to it.
+  #   # prefix = ""
+  #   region = "us-east-1"
+  #
+  #   ## Amazon Credentials
+  #   ## Credentials are loaded in the following order
+  #   ## 1) Assumed credentials via STS if role_arn is specified
+  #   ## 2) explicit credentials from 'access_key' and 'secret_key'
+  #   ## 3) shared profile from 'profile'
+  #   ## 4) environment variables
+  #   ## 5) shared credentials file
+  #   ## 6) EC2 Instance Profile
+  #   # access_key = ""
+  #   # secret_key = ""
+  #   # token = ""
+  #   # role_arn = ""
+  #   # profile = ""
+  #   # shared_credential_file = ""
+  #   # endpoint_url = ""
+
+  ## Data format to consume.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+  data_format = "influx"
+`
+
+type DirectoryMonitor struct {
+	Directory                  string            `toml:"directory"`
+	FinishedDirectory          string            `toml:"finished_directory"`
+	ErrorDirectory             string            `toml:"error_directory"`
+	ErrorReasonFile            bool              `toml:"error_reason_file"`
+	FilesToMonitor             []string          `toml:"files_to_monitor"`
+	FilesToIgnore              []string          `toml:"files_to_ignore"`
+	DirectoryDurationThreshold internal.Duration `toml:"directory_duration_threshold"`
+	MaxConcurrentFiles         int               `toml:"max_concurrent_files"`
+	FileQueueSize              int               `toml:"file_queue_size"`
+	FileMetrics                bool              `toml:"file_metrics"`
+	S3                         *S3Config         `toml:"s3"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	filter     filter.Filter
+	parserFunc parsers.ParserFunc
+	uploader   uploader
+
+	acc    telegraf.Accumulator
+	files  chan string
+	mu     sync.Mutex
+	queued map[string]bool
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func (d *DirectoryMonitor) Description() string {
+	return "Parse the files added to a directory and move them once processed"
+}
+
+func (d *DirectoryMonitor) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *DirectoryMonitor) SetParserFunc(fn parsers.ParserFunc) {
+	d.parserFunc = fn
+}
+
+func (d *DirectoryMonitor) Init() error {
+	if d.Directory == "" {
+		return errors.New("directory must be set")
+	}
+	if d.FinishedDirectory == "" {
+		return errors.New("finished_directory must be set")
+	}
+	if d.ErrorDirectory == "" {
+		d.ErrorDirectory = d.FinishedDirectory
+	}
+	for _, dir := range []string{d.FinishedDirectory, d.ErrorDirectory} {
+		if filepath.Clean(dir) == filepath.Clean(d.Directory) {
+			return errors.New("finished_directory and error_directory must differ from directory")
+		}
+	}
+	if d.MaxConcurrentFiles < 1 {
+		return errors.New("max_concurrent_files must be positive")
+	}
+	if d.FileQueueSize < 1 {
+		return errors.New("file_queue_size must be positive")
+	}
+
+	var err error
+	d.filter, err = filter.NewIncludeExcludeFilter(d.FilesToMonitor, d.FilesToIgnore)
+	if err != nil {
+		return err
+	}
+
+	if d.S3 != nil {
+		d.uploader, err = d.S3.uploader()
+		if err != nil {
+			return fmt.Errorf("s3: %v", err)
+		}
+	}
+	return nil
+}
+
+func (d *DirectoryMonitor) Start(acc telegraf.Accumulator) error {
+	d.acc = acc
+	d.files = make(chan string, d.FileQueueSize)
+	d.queued = make(map[string]bool)
+
+	var ctx context.Context
+	ctx, d.cancel = context.WithCancel(context.Background())
+	for i := 0; i < d.MaxConcurrentFiles; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.worker(ctx)
+		}()
+	}
+	return nil
+}
+
+// Gather queues the files of the directory that are not being processed.
+func (d *DirectoryMonitor) Gather(_ telegraf.Accumulator) error {
+	infos, err := ioutil.ReadDir(d.Directory)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, info := range infos {
+		if !info.Mode().IsRegular() || !d.filter.Match(info.Name()) {
+			continue
+		}
+		if time.Since(info.ModTime()) < d.DirectoryDurationThreshold.Duration {
+			continue
+		}
+
+		path := filepath.Join(d.Directory, info.Name())
+		if d.queued[path] {
+			continue
+		}
+		select {
+		case d.files <- path:
+			d.queued[path] = true
+		default:
+			// The queue is full
+			return nil
+		}
+	}
+	return nil
+}
+
+func (d *DirectoryMonitor) Stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+func (d *DirectoryMonitor) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case path := <-d.files:
+			d.processFile(path)
+
+			d.mu.Lock()
+			delete(d.queued, path)
+			d.mu.Unlock()
+		}
+	}
+}
+
+// processFile adds the metrics of the file and moves it to the finished
+// directory.  No metric is added if the file cannot be parsed or uploaded,
+// it is moved to the error directory instead.
+func (d *DirectoryMonitor) processFile(path string) {
+	start := time.Now()
+	metrics, size, err := d.parseFile(path)
+	if err == nil && d.uploader != nil {
+		if err = d.upload(path); err != nil {
+			err = fmt.Errorf("uploading: %v", err)
+		}
+	}
+
+	if err == nil {
+		for _, m := range metrics {
+			d.acc.AddMetric(m)
+		}
+		err = moveFile(path, d.FinishedDirectory)
+		if err != nil {
+			d.acc.AddError(fmt.Errorf("moving %s: %v", path, err))
+		}
+		d.addFileMetric(path, size, len(metrics), time.Since(start), nil)
+		return
+	}
+
+	d.acc.AddError(fmt.Errorf("processing %s: %v", path, err))
+	if moveErr := d.quarantine(path, err); moveErr != nil {
+		d.acc.AddError(fmt.Errorf("moving %s: %v", path, moveErr))
+	}
+	d.addFileMetric(path, size, 0, time.Since(start), err)
+}
+
+func (d *DirectoryMonitor) parseFile(path string) ([]telegraf.Metric, int64, error) {
+	parser, err := d.parserFunc()
+	if err != nil {
+		return nil, 0, fmt.Errorf("creating parser: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	var size int64
+	if info, err := f.Stat(); err == nil {
+		size = info.Size()
+	}
+
+	var metrics []telegraf.Metric
+	err = parsers.ParseStream(parser, f, func(m telegraf.Metric) error {
+		metrics = append(metrics, m)
+		return nil
+	})
+	return metrics, size, err
+}
+
+func (d *DirectoryMonitor) upload(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return d.uploader.Upload(filepath.Base(path), f)
+}
+
+// quarantine moves the file to the error directory and writes the reason of
+// the error next to it.
+func (d *DirectoryMonitor) quarantine(path string, reason error) error {
+	if err := moveFile(path, d.ErrorDirectory); err != nil {
+		return err
+	}
+	if !d.ErrorReasonFile {
+		return nil
+	}
+	name := filepath.Join(d.ErrorDirectory, filepath.Base(path)+".error")
+	return ioutil.WriteFile(name, []byte(reason.Error()+"\n"), 0640)
+}
+
+func (d *DirectoryMonitor) addFileMetric(path string, size int64, metrics int, duration time.Duration, err error) {
+	if !d.FileMetrics {
+		return
+	}
+
+	tags := map[string]string{
+		"directory": d.Directory,
+		"file":      filepath.Base(path),
+		"status":    "success",
+	}
+	fields := map[string]interface{}{
+		"bytes":       size,
+		"metrics":     metrics,
+		"duration_ns": duration.Nanoseconds(),
+	}
+	if err != nil {
+		tags["status"] = "error"
+		fields["error"] = err.Error()
+	}
+	d.acc.AddFields("directory_monitor", fields, tags)
+}
+
+// moveFile moves the file to the directory, copying it when the directory is
+// on another device.
+func moveFile(path, dir string) error {
+	dst := filepath.Join(dir, filepath.Base(path))
+	if err := os.Rename(path, dst); err == nil {
+		return nil
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func init() {
+	inputs.Add("directory_monitor", func() telegraf.Input {
+		return &DirectoryMonitor{
+			ErrorReasonFile:            true,
+			DirectoryDurationThreshold: internal.Duration{Duration: 50 * time.Millisecond},
+			MaxConcurrentFiles:         1,
+			FileQueueSize:              100,
+		}
+	})
+}
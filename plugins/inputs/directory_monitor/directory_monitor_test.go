@@ -0,0 +1,190 @@
+package directory_monitor
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUploader struct {
+	sync.Mutex
+	objects map[string]string
+	err     error
+}
+
+func (u *fakeUploader) Upload(key string, r io.Reader) error {
+	if u.err != nil {
+		return u.err
+	}
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	u.Lock()
+	defer u.Unlock()
+	u.objects[key] = string(buf)
+	return nil
+}
+
+func newDirectoryMonitor(t *testing.T) (*DirectoryMonitor, func()) {
+	dir, err := ioutil.TempDir("", "directory_monitor")
+	require.NoError(t, err)
+	for _, sub := range []string{"in", "done", "failed"} {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, sub), 0755))
+	}
+
+	d := &DirectoryMonitor{
+		Directory:          filepath.Join(dir, "in"),
+		FinishedDirectory:  filepath.Join(dir, "done"),
+		ErrorDirectory:     filepath.Join(dir, "failed"),
+		ErrorReasonFile:    true,
+		MaxConcurrentFiles: 2,
+		FileQueueSize:      10,
+		Log:                testutil.Logger{},
+	}
+	d.SetParserFunc(parsers.NewInfluxParser)
+	return d, func() { os.RemoveAll(dir) }
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+func TestProcessFiles(t *testing.T) {
+	d, cleanup := newDirectoryMonitor(t)
+	defer cleanup()
+	d.FilesToIgnore = []string{"*.tmp"}
+	d.FileMetrics = true
+	require.NoError(t, d.Init())
+
+	writeFile(t, d.Directory, "a.influx", "cpu value=1 1600000000000000000\n")
+	writeFile(t, d.Directory, "b.influx", "cpu value=\n")
+	writeFile(t, d.Directory, "c.tmp", "cpu value=3 1600000000000000000\n")
+
+	var acc testutil.Accumulator
+	require.NoError(t, d.Start(&acc))
+	defer d.Stop()
+	require.NoError(t, d.Gather(&acc))
+
+	// The metric of a.influx and a file metric per processed file
+	acc.Wait(3)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"value": 1.0},
+			time.Unix(1600000000, 0),
+		),
+	}
+	var cpu []telegraf.Metric
+	status := make(map[string]string)
+	for _, m := range acc.GetTelegrafMetrics() {
+		if m.Name() == "directory_monitor" {
+			file, _ := m.GetTag("file")
+			status[file], _ = m.GetTag("status")
+			continue
+		}
+		cpu = append(cpu, m)
+	}
+	testutil.RequireMetricsEqual(t, expected, cpu)
+	require.Equal(t, map[string]string{"a.influx": "success", "b.influx": "error"}, status)
+	require.Len(t, acc.Errors, 1)
+
+	require.FileExists(t, filepath.Join(d.FinishedDirectory, "a.influx"))
+	require.FileExists(t, filepath.Join(d.ErrorDirectory, "b.influx"))
+	reason, err := ioutil.ReadFile(filepath.Join(d.ErrorDirectory, "b.influx.error"))
+	require.NoError(t, err)
+	require.Contains(t, string(reason), "metric parse error")
+
+	// Ignored files stay in the directory
+	infos, err := ioutil.ReadDir(d.Directory)
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	require.Equal(t, "c.tmp", infos[0].Name())
+}
+
+func TestUpload(t *testing.T) {
+	d, cleanup := newDirectoryMonitor(t)
+	defer cleanup()
+	require.NoError(t, d.Init())
+	u := &fakeUploader{objects: make(map[string]string)}
+	d.uploader = u
+
+	content := "cpu value=1 1600000000000000000\n"
+	writeFile(t, d.Directory, "a.influx", content)
+
+	var acc testutil.Accumulator
+	require.NoError(t, d.Start(&acc))
+	defer d.Stop()
+	require.NoError(t, d.Gather(&acc))
+
+	acc.Wait(1)
+	require.Equal(t, map[string]string{"a.influx": content}, u.objects)
+
+	// Files that cannot be uploaded are quarantined without adding metrics
+	u.err = errors.New("access denied")
+	writeFile(t, d.Directory, "b.influx", content)
+	acc.ClearMetrics()
+	require.NoError(t, d.Gather(&acc))
+
+	waitFor(t, func() bool {
+		_, err := os.Stat(filepath.Join(d.ErrorDirectory, "b.influx.error"))
+		return err == nil
+	})
+	reason, err := ioutil.ReadFile(filepath.Join(d.ErrorDirectory, "b.influx.error"))
+	require.NoError(t, err)
+	require.Equal(t, "uploading: access denied\n", string(reason))
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestDurationThreshold(t *testing.T) {
+	d, cleanup := newDirectoryMonitor(t)
+	defer cleanup()
+	d.DirectoryDurationThreshold.Duration = time.Hour
+	require.NoError(t, d.Init())
+
+	writeFile(t, d.Directory, "a.influx", "cpu value=1\n")
+
+	var acc testutil.Accumulator
+	require.NoError(t, d.Start(&acc))
+	defer d.Stop()
+	require.NoError(t, d.Gather(&acc))
+	require.Empty(t, d.queued)
+}
+
+func TestInitErrors(t *testing.T) {
+	d, cleanup := newDirectoryMonitor(t)
+	defer cleanup()
+	d.ErrorDirectory = d.Directory
+	require.Error(t, d.Init())
+
+	d, cleanup = newDirectoryMonitor(t)
+	defer cleanup()
+	d.MaxConcurrentFiles = 0
+	require.Error(t, d.Init())
+
+	d, cleanup = newDirectoryMonitor(t)
+	defer cleanup()
+	d.S3 = &S3Config{}
+	require.Error(t, d.Init())
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
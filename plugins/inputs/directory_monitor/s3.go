@@ -0,0 +1,68 @@
+package directory_monitor
+
+import (
+	"errors"
+	"io"
+	"path"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	internalaws "github.com/influxdata/telegraf/config/aws"
+)
+
+// S3Config is the S3 bucket the processed files are uploaded to.
+type S3Config struct {
+	Bucket string `toml:"bucket"`
+	Prefix string `toml:"prefix"`
+
+	Region      string `toml:"region"`
+	AccessKey   string `toml:"access_key"`
+	SecretKey   string `toml:"secret_key"`
+	RoleARN     string `toml:"role_arn"`
+	Profile     string `toml:"profile"`
+	Filename    string `toml:"shared_credential_file"`
+	Token       string `toml:"token"`
+	EndpointURL string `toml:"endpoint_url"`
+}
+
+// uploader stores the content of a file under the key.
+type uploader interface {
+	Upload(key string, r io.Reader) error
+}
+
+func (c *S3Config) uploader() (uploader, error) {
+	if c.Bucket == "" {
+		return nil, errors.New("bucket must be set")
+	}
+
+	credentialConfig := &internalaws.CredentialConfig{
+		Region:      c.Region,
+		AccessKey:   c.AccessKey,
+		SecretKey:   c.SecretKey,
+		RoleARN:     c.RoleARN,
+		Profile:     c.Profile,
+		Filename:    c.Filename,
+		Token:       c.Token,
+		EndpointURL: c.EndpointURL,
+	}
+	return &s3Uploader{
+		bucket:   c.Bucket,
+		prefix:   c.Prefix,
+		uploader: s3manager.NewUploader(credentialConfig.Credentials()),
+	}, nil
+}
+
+type s3Uploader struct {
+	bucket   string
+	prefix   string
+	uploader *s3manager.Uploader
+}
+
+func (u *s3Uploader) Upload(key string, r io.Reader) error {
+	_, err := u.uploader.Upload(&s3manager.UploadInput{
+		Bucket: aws.String(u.bucket),
+		Key:    aws.String(path.Join(u.prefix, key)),
+		Body:   r,
+	})
+	return err
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/miekg/dns"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/targets"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -39,6 +40,9 @@ type DnsQuery struct {
 
 	// Dns query timeout in seconds. 0 means no timeout
 	Timeout int
+
+	// Domains to query loaded from a file or URL
+	targets.Config
 }
 
 var sampleConfig = `
@@ -60,6 +64,16 @@ var sampleConfig = `
 
   ## Query timeout in seconds.
   # timeout = 2
+
+  ## Load additional domains from a JSON file or HTTP endpoint, refreshed
+  ## periodically.  The list contains either domains or objects of the form
+  ## {"target": "example.org", "labels": {"region": "eu"}} with labels added
+  ## as tags.  Each domain is queried on all servers.
+  # targets_url = "http://localhost:8080/targets/dns"
+  # targets_file = "/etc/telegraf/dns_targets.json"
+  # targets_refresh_interval = "5m"
+  # targets_timeout = "5s"
+  # targets_headers = {"Authorization" = "Bearer mytoken"}
 `
 
 func (d *DnsQuery) SampleConfig() string {
@@ -73,7 +87,14 @@ func (d *DnsQuery) Gather(acc telegraf.Accumulator) error {
 	var wg sync.WaitGroup
 	d.setDefaultValues()
 
-	for _, domain := range d.Domains {
+	list, err := d.Targets(d.Domains)
+	if err != nil {
+		acc.AddError(err)
+	}
+
+	for _, target := range list {
+		acc := targets.Accumulator(acc, target)
+		domain := target.Target
 		for _, server := range d.Servers {
 			wg.Add(1)
 			go func(domain, server string) {
@@ -119,7 +140,7 @@ func (d *DnsQuery) setDefaultValues() {
 		d.RecordType = "NS"
 	}
 
-	if len(d.Domains) == 0 {
+	if len(d.Domains) == 0 && !d.Enabled() {
 		d.Domains = []string{"."}
 		d.RecordType = "NS"
 	}
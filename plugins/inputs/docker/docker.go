@@ -46,6 +46,8 @@ type Docker struct {
 
 	IncludeSourceTag bool `toml:"source_tag"`
 
+	StorageObjects []string `toml:"storage_objects"`
+
 	Log telegraf.Logger
 
 	tlsint.ClientConfig
@@ -61,6 +63,17 @@ type Docker struct {
 	labelFilter     filter.Filter
 	containerFilter filter.Filter
 	stateFilter     filter.Filter
+
+	healthMu sync.Mutex
+	health   map[string]*healthState
+}
+
+// healthState tracks the health status of a container between gathers.
+type healthState struct {
+	status      string
+	previous    string
+	transitions int64
+	seen        bool
 }
 
 // KB, MB, GB, TB, PB...human friendly
@@ -77,6 +90,7 @@ const (
 var (
 	sizeRegex       = regexp.MustCompile(`^(\d+(\.\d+)*) ?([kKmMgGtTpP])?[bB]?$`)
 	containerStates = []string{"created", "restarting", "running", "removing", "paused", "exited", "dead"}
+	storageObjects  = []string{"container", "image", "volume"}
 	now             = time.Now
 )
 
@@ -89,6 +103,12 @@ var sampleConfig = `
   ## Set to true to collect Swarm metrics(desired_replicas, running_replicas)
   gather_services = false
 
+  ## Storage objects to report the disk usage of, as "docker system df -v".
+  ## Valid values are "container", "image" and "volume".  The size of the
+  ## layers and of the build cache is reported when any is set.
+  ## Computing the disk usage can be expensive for the docker daemon.
+  # storage_objects = []
+
   ## Only collect metrics for these containers, collect all if empty
   container_names = []
 
@@ -165,6 +185,11 @@ func (d *Docker) Gather(acc telegraf.Accumulator) error {
 		if err != nil {
 			return err
 		}
+		for _, object := range d.StorageObjects {
+			if !sliceContains(object, storageObjects) {
+				return fmt.Errorf("invalid storage object %q", object)
+			}
+		}
 		d.filtersCreated = true
 	}
 
@@ -181,6 +206,13 @@ func (d *Docker) Gather(acc telegraf.Accumulator) error {
 		}
 	}
 
+	if len(d.StorageObjects) > 0 {
+		err := d.gatherDiskUsage(acc)
+		if err != nil {
+			acc.AddError(err)
+		}
+	}
+
 	filterArgs := filters.NewArgs()
 	for _, state := range containerStates {
 		if d.stateFilter.Match(state) {
@@ -221,6 +253,16 @@ func (d *Docker) Gather(acc telegraf.Accumulator) error {
 	}
 	wg.Wait()
 
+	// Forget the health of the containers that are gone
+	d.healthMu.Lock()
+	for id, state := range d.health {
+		if !state.seen {
+			delete(d.health, id)
+		}
+		state.seen = false
+	}
+	d.healthMu.Unlock()
+
 	return nil
 }
 
@@ -295,6 +337,102 @@ func (d *Docker) gatherSwarmInfo(acc telegraf.Accumulator) error {
 	return nil
 }
 
+func (d *Docker) gatherDiskUsage(acc telegraf.Accumulator) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout.Duration)
+	defer cancel()
+
+	du, err := d.client.DiskUsage(ctx)
+	if err == context.DeadlineExceeded {
+		return errDiskUsageTimeout
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	tags := map[string]string{
+		"engine_host":    d.engineHost,
+		"server_version": d.serverVersion,
+	}
+	acc.AddFields("docker_disk_usage",
+		map[string]interface{}{
+			"layers_size":  du.LayersSize,
+			"builder_size": du.BuilderSize,
+		},
+		tags,
+		now)
+
+	if sliceContains("container", d.StorageObjects) {
+		for _, container := range du.Containers {
+			var cname string
+			for _, name := range container.Names {
+				trimmedName := strings.TrimPrefix(name, "/")
+				if d.containerFilter.Match(trimmedName) {
+					cname = trimmedName
+					break
+				}
+			}
+			if cname == "" {
+				continue
+			}
+
+			imageName, imageVersion := docker.ParseImage(container.Image)
+			containerTags := copyTags(tags)
+			containerTags["container_name"] = cname
+			containerTags["container_image"] = imageName
+			containerTags["container_version"] = imageVersion
+			acc.AddFields("docker_disk_usage",
+				map[string]interface{}{
+					"size_rw":      container.SizeRw,
+					"size_root_fs": container.SizeRootFs,
+				},
+				containerTags,
+				now)
+		}
+	}
+
+	if sliceContains("image", d.StorageObjects) {
+		for _, image := range du.Images {
+			imageTags := copyTags(tags)
+			imageTags["image_id"] = strings.TrimPrefix(image.ID, "sha256:")
+			if len(image.RepoTags) > 0 {
+				imageName, imageVersion := docker.ParseImage(image.RepoTags[0])
+				imageTags["image_name"] = imageName
+				imageTags["image_version"] = imageVersion
+			}
+			acc.AddFields("docker_disk_usage",
+				map[string]interface{}{
+					"size":        image.Size,
+					"shared_size": image.SharedSize,
+					"containers":  image.Containers,
+				},
+				imageTags,
+				now)
+		}
+	}
+
+	if sliceContains("volume", d.StorageObjects) {
+		for _, volume := range du.Volumes {
+			// The usage is only known for the volumes of the local driver
+			if volume.UsageData == nil || volume.UsageData.Size < 0 {
+				continue
+			}
+			volumeTags := copyTags(tags)
+			volumeTags["volume_name"] = volume.Name
+			volumeTags["volume_driver"] = volume.Driver
+			acc.AddFields("docker_disk_usage",
+				map[string]interface{}{
+					"size":      volume.UsageData.Size,
+					"ref_count": volume.UsageData.RefCount,
+				},
+				volumeTags,
+				now)
+		}
+	}
+
+	return nil
+}
+
 func (d *Docker) gatherInfo(acc telegraf.Accumulator) error {
 	// Init vars
 	dataFields := make(map[string]interface{})
@@ -529,10 +667,11 @@ func (d *Docker) gatherContainerInspect(
 	if info.State != nil {
 		tags["container_status"] = info.State.Status
 		statefields := map[string]interface{}{
-			"oomkilled":    info.State.OOMKilled,
-			"pid":          info.State.Pid,
-			"exitcode":     info.State.ExitCode,
-			"container_id": container.ID,
+			"oomkilled":     info.State.OOMKilled,
+			"pid":           info.State.Pid,
+			"exitcode":      info.State.ExitCode,
+			"container_id":  container.ID,
+			"restart_count": info.RestartCount,
 		}
 
 		finished, err := time.Parse(time.RFC3339, info.State.FinishedAt)
@@ -561,6 +700,7 @@ func (d *Docker) gatherContainerInspect(
 				"health_status":  info.State.Health.Status,
 				"failing_streak": info.ContainerJSONBase.State.Health.FailingStreak,
 			}
+			d.trackHealth(container.ID, info.State.Health.Status, healthfields)
 			acc.AddFields("docker_container_health", healthfields, tags, now())
 		}
 	}
@@ -570,6 +710,33 @@ func (d *Docker) gatherContainerInspect(
 	return nil
 }
 
+// trackHealth adds the number of health status transitions of the container
+// observed since the first gather, and the status before the last one.
+func (d *Docker) trackHealth(id string, status string, fields map[string]interface{}) {
+	d.healthMu.Lock()
+	defer d.healthMu.Unlock()
+
+	if d.health == nil {
+		d.health = make(map[string]*healthState)
+	}
+	state, ok := d.health[id]
+	if !ok {
+		state = &healthState{status: status}
+		d.health[id] = state
+	}
+	if state.status != status {
+		state.previous = state.status
+		state.status = status
+		state.transitions++
+	}
+	state.seen = true
+
+	fields["health_transitions"] = state.transitions
+	if state.previous != "" {
+		fields["previous_health_status"] = state.previous
+	}
+}
+
 func parseContainerStats(
 	stat *types.StatsJSON,
 	acc telegraf.Accumulator,
@@ -24,6 +24,7 @@ type MockClient struct {
 	ServiceListF      func(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error)
 	TaskListF         func(ctx context.Context, options types.TaskListOptions) ([]swarm.Task, error)
 	NodeListF         func(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error)
+	DiskUsageF        func(ctx context.Context) (types.DiskUsage, error)
 }
 
 func (c *MockClient) Info(ctx context.Context) (types.Info, error) {
@@ -73,6 +74,10 @@ func (c *MockClient) NodeList(
 	return c.NodeListF(ctx, options)
 }
 
+func (c *MockClient) DiskUsage(ctx context.Context) (types.DiskUsage, error) {
+	return c.DiskUsageF(ctx)
+}
+
 var baseClient = MockClient{
 	InfoF: func(context.Context) (types.Info, error) {
 		return info, nil
@@ -95,6 +100,9 @@ var baseClient = MockClient{
 	NodeListF: func(context.Context, types.NodeListOptions) ([]swarm.Node, error) {
 		return NodeList, nil
 	},
+	DiskUsageF: func(context.Context) (types.DiskUsage, error) {
+		return diskUsage, nil
+	},
 }
 
 func newClient(host string, tlsConfig *tls.Config) (Client, error) {
@@ -580,12 +588,13 @@ func TestContainerStatus(t *testing.T) {
 						"source":            "e2173b9478a6",
 					},
 					map[string]interface{}{
-						"oomkilled":    false,
-						"pid":          1234,
-						"exitcode":     0,
-						"container_id": "e2173b9478a6ae55e237d4d74f8bbb753f0817192b5081334dc78476296b7dfb",
-						"started_at":   time.Date(2018, 6, 14, 5, 48, 53, 266176036, time.UTC).UnixNano(),
-						"uptime_ns":    int64(3 * time.Minute),
+						"oomkilled":     false,
+						"pid":           1234,
+						"exitcode":      0,
+						"container_id":  "e2173b9478a6ae55e237d4d74f8bbb753f0817192b5081334dc78476296b7dfb",
+						"restart_count": 0,
+						"started_at":    time.Date(2018, 6, 14, 5, 48, 53, 266176036, time.UTC).UnixNano(),
+						"uptime_ns":     int64(3 * time.Minute),
 					},
 					time.Date(2018, 6, 14, 5, 51, 53, 266176036, time.UTC),
 				),
@@ -616,13 +625,14 @@ func TestContainerStatus(t *testing.T) {
 						"source":            "e2173b9478a6",
 					},
 					map[string]interface{}{
-						"oomkilled":    false,
-						"pid":          1234,
-						"exitcode":     0,
-						"container_id": "e2173b9478a6ae55e237d4d74f8bbb753f0817192b5081334dc78476296b7dfb",
-						"started_at":   time.Date(2018, 6, 14, 5, 48, 53, 266176036, time.UTC).UnixNano(),
-						"finished_at":  time.Date(2018, 6, 14, 5, 53, 53, 266176036, time.UTC).UnixNano(),
-						"uptime_ns":    int64(5 * time.Minute),
+						"oomkilled":     false,
+						"pid":           1234,
+						"exitcode":      0,
+						"container_id":  "e2173b9478a6ae55e237d4d74f8bbb753f0817192b5081334dc78476296b7dfb",
+						"restart_count": 0,
+						"started_at":    time.Date(2018, 6, 14, 5, 48, 53, 266176036, time.UTC).UnixNano(),
+						"finished_at":   time.Date(2018, 6, 14, 5, 53, 53, 266176036, time.UTC).UnixNano(),
+						"uptime_ns":     int64(5 * time.Minute),
 					},
 					time.Date(2018, 6, 14, 5, 51, 53, 266176036, time.UTC),
 				),
@@ -654,11 +664,12 @@ func TestContainerStatus(t *testing.T) {
 						"source":            "e2173b9478a6",
 					},
 					map[string]interface{}{
-						"oomkilled":    false,
-						"pid":          1234,
-						"exitcode":     0,
-						"container_id": "e2173b9478a6ae55e237d4d74f8bbb753f0817192b5081334dc78476296b7dfb",
-						"finished_at":  time.Date(2018, 6, 14, 5, 53, 53, 266176036, time.UTC).UnixNano(),
+						"oomkilled":     false,
+						"pid":           1234,
+						"exitcode":      0,
+						"container_id":  "e2173b9478a6ae55e237d4d74f8bbb753f0817192b5081334dc78476296b7dfb",
+						"restart_count": 0,
+						"finished_at":   time.Date(2018, 6, 14, 5, 53, 53, 266176036, time.UTC).UnixNano(),
 					},
 					time.Date(2018, 6, 14, 5, 51, 53, 266176036, time.UTC),
 				),
@@ -690,13 +701,14 @@ func TestContainerStatus(t *testing.T) {
 						"source":            "e2173b9478a6",
 					},
 					map[string]interface{}{
-						"oomkilled":    false,
-						"pid":          1234,
-						"exitcode":     0,
-						"container_id": "e2173b9478a6ae55e237d4d74f8bbb753f0817192b5081334dc78476296b7dfb",
-						"started_at":   time.Date(2019, 1, 1, 0, 0, 2, 0, time.UTC).UnixNano(),
-						"finished_at":  time.Date(2019, 1, 1, 0, 0, 1, 0, time.UTC).UnixNano(),
-						"uptime_ns":    int64(1 * time.Second),
+						"oomkilled":     false,
+						"pid":           1234,
+						"exitcode":      0,
+						"container_id":  "e2173b9478a6ae55e237d4d74f8bbb753f0817192b5081334dc78476296b7dfb",
+						"restart_count": 0,
+						"started_at":    time.Date(2019, 1, 1, 0, 0, 2, 0, time.UTC).UnixNano(),
+						"finished_at":   time.Date(2019, 1, 1, 0, 0, 1, 0, time.UTC).UnixNano(),
+						"uptime_ns":     int64(1 * time.Second),
 					},
 					time.Date(2019, 1, 1, 0, 0, 3, 0, time.UTC),
 				),
@@ -1117,3 +1129,139 @@ func TestHostnameFromID(t *testing.T) {
 	}
 
 }
+
+func TestDockerGatherDiskUsage(t *testing.T) {
+	var acc testutil.Accumulator
+	d := Docker{
+		Log:            testutil.Logger{},
+		newClient:      newClient,
+		StorageObjects: []string{"container", "image", "volume"},
+	}
+
+	err := acc.GatherError(d.Gather)
+	require.NoError(t, err)
+
+	acc.AssertContainsTaggedFields(t,
+		"docker_disk_usage",
+		map[string]interface{}{
+			"layers_size":  int64(1e10),
+			"builder_size": int64(2e9),
+		},
+		map[string]string{
+			"engine_host":    "absol",
+			"server_version": "17.09.0-ce",
+		},
+	)
+
+	acc.AssertContainsTaggedFields(t,
+		"docker_disk_usage",
+		map[string]interface{}{
+			"size_rw":      int64(0),
+			"size_root_fs": int64(123456789),
+		},
+		map[string]string{
+			"engine_host":       "absol",
+			"server_version":    "17.09.0-ce",
+			"container_name":    "some_container",
+			"container_image":   "some_image",
+			"container_version": "1.0.0-alpine",
+		},
+	)
+
+	acc.AssertContainsTaggedFields(t,
+		"docker_disk_usage",
+		map[string]interface{}{
+			"size":        int64(123456789),
+			"shared_size": int64(0),
+			"containers":  int64(1),
+		},
+		map[string]string{
+			"engine_host":    "absol",
+			"server_version": "17.09.0-ce",
+			"image_id":       "some_imageid",
+			"image_name":     "some_image_tag",
+			"image_version":  "latest",
+		},
+	)
+
+	acc.AssertContainsTaggedFields(t,
+		"docker_disk_usage",
+		map[string]interface{}{
+			"size":      int64(123456789),
+			"ref_count": int64(1),
+		},
+		map[string]string{
+			"engine_host":    "absol",
+			"server_version": "17.09.0-ce",
+			"volume_name":    "some_volume",
+			"volume_driver":  "local",
+		},
+	)
+
+	// The usage of the volumes of other drivers is not known
+	for _, m := range acc.Metrics {
+		require.NotEqual(t, "remote_volume", m.Tags["volume_name"])
+	}
+}
+
+func TestDockerInvalidStorageObject(t *testing.T) {
+	var acc testutil.Accumulator
+	d := Docker{
+		Log:            testutil.Logger{},
+		newClient:      newClient,
+		StorageObjects: []string{"network"},
+	}
+	require.Error(t, d.Gather(&acc))
+}
+
+func TestContainerHealthTransitions(t *testing.T) {
+	status := "starting"
+	d := Docker{
+		Log: testutil.Logger{},
+		newClient: func(string, *tls.Config) (Client, error) {
+			client := baseClient
+			client.ContainerListF = func(context.Context, types.ContainerListOptions) ([]types.Container, error) {
+				return containerList[:1], nil
+			}
+			client.ContainerInspectF = func(context.Context, string) (types.ContainerJSON, error) {
+				inspect := containerInspect()
+				inspect.State.Health.Status = status
+				return inspect, nil
+			}
+			return &client, nil
+		},
+	}
+
+	healthFields := func(acc *testutil.Accumulator) map[string]interface{} {
+		for _, m := range acc.Metrics {
+			if m.Measurement == "docker_container_health" {
+				return m.Fields
+			}
+		}
+		t.Fatal("no health metric")
+		return nil
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(d.Gather))
+	require.Equal(t, map[string]interface{}{
+		"health_status":      "starting",
+		"failing_streak":     1,
+		"health_transitions": int64(0),
+	}, healthFields(&acc))
+
+	status = "healthy"
+	acc.ClearMetrics()
+	require.NoError(t, acc.GatherError(d.Gather))
+	require.NoError(t, acc.GatherError(d.Gather))
+	acc.ClearMetrics()
+	status = "unhealthy"
+	require.NoError(t, acc.GatherError(d.Gather))
+	require.Equal(t, map[string]interface{}{
+		"health_status":          "unhealthy",
+		"failing_streak":         1,
+		"health_transitions":     int64(2),
+		"previous_health_status": "healthy",
+	}, healthFields(&acc))
+	require.Len(t, d.health, 1)
+}
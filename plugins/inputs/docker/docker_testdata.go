@@ -523,3 +523,43 @@ func containerInspect() types.ContainerJSON {
 		},
 	}
 }
+
+var diskUsage = types.DiskUsage{
+	LayersSize:  1e10,
+	BuilderSize: 2e9,
+	Containers: []*types.Container{
+		{
+			Names:      []string{"/some_container"},
+			Image:      "some_image:1.0.0-alpine",
+			SizeRw:     0,
+			SizeRootFs: 123456789,
+		},
+	},
+	Images: []*types.ImageSummary{
+		{
+			ID:         "sha256:some_imageid",
+			RepoTags:   []string{"some_image_tag:latest"},
+			Size:       123456789,
+			SharedSize: 0,
+			Containers: 1,
+		},
+	},
+	Volumes: []*types.Volume{
+		{
+			Name:   "some_volume",
+			Driver: "local",
+			UsageData: &types.VolumeUsageData{
+				Size:     123456789,
+				RefCount: 1,
+			},
+		},
+		{
+			Name:   "remote_volume",
+			Driver: "nfs",
+			UsageData: &types.VolumeUsageData{
+				Size:     -1,
+				RefCount: 1,
+			},
+		},
+	},
+}
@@ -8,4 +8,6 @@ var (
 	errInspectTimeout = errors.New("timeout retrieving container environment")
 	errListTimeout    = errors.New("timeout retrieving container list")
 	errServiceTimeout = errors.New("timeout retrieving swarm service list")
+
+	errDiskUsageTimeout = errors.New("timeout retrieving disk usage")
 )
@@ -0,0 +1,215 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// uint64Value is an integer of the JSON gateway, which writes the 64 bits
+// integers as strings.
+type uint64Value uint64
+
+func (v *uint64Value) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*v = 0
+		return nil
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*v = uint64Value(n)
+	return nil
+}
+
+type responseHeader struct {
+	ClusterID uint64Value `json:"cluster_id"`
+	MemberID  uint64Value `json:"member_id"`
+	Revision  uint64Value `json:"revision"`
+	RaftTerm  uint64Value `json:"raft_term"`
+}
+
+type statusResponse struct {
+	Header           responseHeader `json:"header"`
+	Version          string         `json:"version"`
+	DBSize           uint64Value    `json:"db_size"`
+	DBSizeInUse      uint64Value    `json:"db_size_in_use"`
+	Leader           uint64Value    `json:"leader"`
+	RaftIndex        uint64Value    `json:"raft_index"`
+	RaftTerm         uint64Value    `json:"raft_term"`
+	RaftAppliedIndex uint64Value    `json:"raft_applied_index"`
+	Errors           []string       `json:"errors"`
+}
+
+// The keys are bytes, which are written in base64 by the JSON gateway as by
+// encoding/json.
+type rangeRequest struct {
+	Key       []byte `json:"key"`
+	RangeEnd  []byte `json:"range_end"`
+	CountOnly bool   `json:"count_only"`
+}
+
+type rangeResponse struct {
+	Count uint64Value `json:"count"`
+}
+
+type watchRequest struct {
+	CreateRequest watchCreateRequest `json:"create_request"`
+}
+
+type watchCreateRequest struct {
+	Key           []byte `json:"key"`
+	RangeEnd      []byte `json:"range_end"`
+	StartRevision uint64 `json:"start_revision,string,omitempty"`
+}
+
+type watchResponse struct {
+	Result struct {
+		Header          responseHeader `json:"header"`
+		Canceled        bool           `json:"canceled"`
+		CancelReason    string         `json:"cancel_reason"`
+		CompactRevision uint64Value    `json:"compact_revision"`
+		Events          []watchEvent   `json:"events"`
+	} `json:"result"`
+	Error *gatewayError `json:"error"`
+}
+
+type watchEvent struct {
+	// Type is missing for the PUT events, as it is the default value.
+	Type string `json:"type"`
+	KV   struct {
+		Key            []byte      `json:"key"`
+		CreateRevision uint64Value `json:"create_revision"`
+		ModRevision    uint64Value `json:"mod_revision"`
+	} `json:"kv"`
+}
+
+type gatewayError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type authRequest struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+type authResponse struct {
+	Token string `json:"token"`
+}
+
+// prefixRange returns the key and range end of the keys with the prefix, an
+// empty prefix is the range of all the keys.
+func prefixRange(prefix string) ([]byte, []byte) {
+	if prefix == "" {
+		return []byte{0}, []byte{0}
+	}
+
+	key := []byte(prefix)
+	end := make([]byte, len(key))
+	copy(end, key)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return key, end[:i+1]
+		}
+	}
+	// The prefix is only made of 0xff bytes, the range has no end.
+	return key, []byte{0}
+}
+
+// client calls the JSON gateway of an etcd member.
+type client struct {
+	endpoint string
+	username string
+	password string
+	client   *http.Client
+}
+
+// post sends the request to the path of the gateway and decodes the response
+// into resp.
+func (c *client) post(ctx context.Context, path, token string, req, resp interface{}) error {
+	body, err := c.do(ctx, path, token, req)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	return json.NewDecoder(body).Decode(resp)
+}
+
+// do sends the request to the path of the gateway and returns the body of the
+// response, which must be closed.
+func (c *client) do(ctx context.Context, path, token string, req interface{}) (io.ReadCloser, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest("POST", c.endpoint+"/v3"+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		request.Header.Set("Authorization", token)
+	}
+
+	resp, err := c.client.Do(request.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var gwErr gatewayError
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(body, &gwErr) == nil && gwErr.Message != "" {
+			return nil, fmt.Errorf("%s returned %q: %s", path, resp.Status, gwErr.Message)
+		}
+		return nil, fmt.Errorf("%s returned %q", path, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// authenticate returns the token of the user, or no token when the
+// authentication is not used.
+func (c *client) authenticate(ctx context.Context) (string, error) {
+	if c.username == "" {
+		return "", nil
+	}
+
+	var resp authResponse
+	req := authRequest{Name: c.username, Password: c.password}
+	if err := c.post(ctx, "/auth/authenticate", "", req, &resp); err != nil {
+		return "", fmt.Errorf("authenticating: %v", err)
+	}
+	return resp.Token, nil
+}
+
+func (c *client) status(ctx context.Context, token string) (*statusResponse, error) {
+	var resp statusResponse
+	if err := c.post(ctx, "/maintenance/status", token, struct{}{}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// countKeys returns the number of keys with the prefix.
+func (c *client) countKeys(ctx context.Context, token, prefix string) (uint64, error) {
+	key, end := prefixRange(prefix)
+	req := rangeRequest{Key: key, RangeEnd: end, CountOnly: true}
+
+	var resp rangeResponse
+	if err := c.post(ctx, "/kv/range", token, req, &resp); err != nil {
+		return 0, err
+	}
+	return uint64(resp.Count), nil
+}
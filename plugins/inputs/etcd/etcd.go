@@ -0,0 +1,196 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const sampleConfig = `
+  ## Client URLs of the etcd members, the JSON gateway of the v3 API is used.
+  endpoints = ["http://127.0.0.1:2379"]
+
+  ## Credentials of the etcd user when the authentication is enabled.
+  # username = ""
+  # password = ""
+
+  ## Timeout of the requests to the members.
+  # timeout = "5s"
+
+  ## Gather the number of keys under each of these prefixes.
+  # kv_prefixes = []
+
+  ## Watch the keys under these prefixes and add an etcd_watch_event metric
+  ## when a key is created, updated or deleted, such as when a service
+  ## registers itself.
+  # watch_prefixes = []
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+// watchRetryInterval is the interval between the watches of a prefix after a
+// failure.
+var watchRetryInterval = 10 * time.Second
+
+type Etcd struct {
+	Endpoints     []string          `toml:"endpoints"`
+	Username      string            `toml:"username"`
+	Password      string            `toml:"password"`
+	Timeout       internal.Duration `toml:"timeout"`
+	KVPrefixes    []string          `toml:"kv_prefixes"`
+	WatchPrefixes []string          `toml:"watch_prefixes"`
+	tls.ClientConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	clients []*client
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func (e *Etcd) Description() string {
+	return "Gather the status, key counts and key changes of etcd clusters"
+}
+
+func (e *Etcd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Etcd) Init() error {
+	if len(e.Endpoints) == 0 {
+		return errors.New("no endpoint configured")
+	}
+
+	tlsConfig, err := e.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}
+
+	e.clients = make([]*client, 0, len(e.Endpoints))
+	for _, endpoint := range e.Endpoints {
+		e.clients = append(e.clients, &client{
+			endpoint: strings.TrimSuffix(endpoint, "/"),
+			username: e.Username,
+			password: e.Password,
+			client:   httpClient,
+		})
+	}
+	return nil
+}
+
+func (e *Etcd) Start(acc telegraf.Accumulator) error {
+	var ctx context.Context
+	ctx, e.cancel = context.WithCancel(context.Background())
+	for _, prefix := range e.WatchPrefixes {
+		e.wg.Add(1)
+		go func(prefix string) {
+			defer e.wg.Done()
+			e.watch(ctx, acc, prefix)
+		}(prefix)
+	}
+	return nil
+}
+
+// Gather adds the status of each member, and the number of keys under the
+// prefixes as seen by the first member answering.
+func (e *Etcd) Gather(acc telegraf.Accumulator) error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.Timeout.Duration)
+	defer cancel()
+
+	var kvClient *client
+	var kvToken string
+	for _, c := range e.clients {
+		token, err := c.authenticate(ctx)
+		if err == nil {
+			err = e.gatherStatus(ctx, acc, c, token)
+		}
+		if err != nil {
+			acc.AddError(fmt.Errorf("%s: %v", c.endpoint, err))
+			continue
+		}
+		if kvClient == nil {
+			kvClient, kvToken = c, token
+		}
+	}
+
+	if kvClient == nil {
+		return nil
+	}
+	for _, prefix := range e.KVPrefixes {
+		count, err := kvClient.countKeys(ctx, kvToken, prefix)
+		if err != nil {
+			acc.AddError(fmt.Errorf("counting keys of %q: %v", prefix, err))
+			continue
+		}
+
+		fields := map[string]interface{}{
+			"keys": count,
+		}
+		tags := map[string]string{
+			"prefix": prefix,
+		}
+		acc.AddFields("etcd_kv", fields, tags)
+	}
+	return nil
+}
+
+func (e *Etcd) gatherStatus(ctx context.Context, acc telegraf.Accumulator, c *client, token string) error {
+	status, err := c.status(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]interface{}{
+		"is_leader":          status.Leader != 0 && status.Leader == status.Header.MemberID,
+		"has_leader":         status.Leader != 0,
+		"leader_id":          fmt.Sprintf("%x", uint64(status.Leader)),
+		"raft_term":          uint64(status.RaftTerm),
+		"raft_index":         uint64(status.RaftIndex),
+		"raft_applied_index": uint64(status.RaftAppliedIndex),
+		"db_size":            uint64(status.DBSize),
+		"db_size_in_use":     uint64(status.DBSizeInUse),
+		"revision":           uint64(status.Header.Revision),
+		"errors":             len(status.Errors),
+	}
+	tags := map[string]string{
+		"endpoint":  c.endpoint,
+		"member_id": fmt.Sprintf("%x", uint64(status.Header.MemberID)),
+		"version":   status.Version,
+	}
+	acc.AddFields("etcd_server", fields, tags)
+	return nil
+}
+
+func (e *Etcd) Stop() {
+	e.cancel()
+	e.wg.Wait()
+}
+
+func init() {
+	inputs.Add("etcd", func() telegraf.Input {
+		return &Etcd{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
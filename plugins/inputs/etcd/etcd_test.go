@@ -0,0 +1,200 @@
+package etcd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// gatewayServer is the JSON gateway of an etcd member with authentication.
+// The first watch stream sends the events and ends, the following ones block
+// until the client is gone.
+type gatewayServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	watches []watchCreateRequest
+}
+
+func newGatewayServer(t *testing.T) *gatewayServer {
+	s := &gatewayServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/auth/authenticate" && r.Header.Get("Authorization") != "secret-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"etcdserver: invalid auth token","code":16,"message":"etcdserver: invalid auth token"}`)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v3/auth/authenticate":
+			var req authRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			if req.Name != "telegraf" || req.Password != "pa$$word" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"code":3,"message":"etcdserver: authentication failed, invalid user ID or password"}`)
+				return
+			}
+			fmt.Fprint(w, `{"header":{"cluster_id":"14841639068965178418","member_id":"10276657743932975437","revision":"7","raft_term":"2"},"token":"secret-token"}`)
+		case "/v3/maintenance/status":
+			fmt.Fprint(w, `{"header":{"cluster_id":"14841639068965178418","member_id":"10276657743932975437","revision":"7","raft_term":"2"},`+
+				`"version":"3.4.13","db_size":"24576","leader":"10276657743932975437","raft_index":"12","raft_term":"2","raft_applied_index":"12","db_size_in_use":"20480"}`)
+		case "/v3/kv/range":
+			var req rangeRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			require.True(t, req.CountOnly)
+			require.Equal(t, "/services/", string(req.Key))
+			require.Equal(t, "/services0", string(req.RangeEnd))
+			fmt.Fprint(w, `{"header":{"revision":"7"},"count":"3"}`)
+		case "/v3/watch":
+			var req watchRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			s.mu.Lock()
+			s.watches = append(s.watches, req.CreateRequest)
+			n := len(s.watches)
+			s.mu.Unlock()
+
+			fmt.Fprintln(w, `{"result":{"header":{"revision":"7"},"created":true}}`)
+			if n > 1 {
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+				return
+			}
+			fmt.Fprintln(w, `{"result":{"header":{"revision":"8"},"events":[{"kv":{"key":"L3NlcnZpY2VzL3dlYi8x","create_revision":"8","mod_revision":"8","version":"1","value":"MTAuMC4wLjE="}}]}}`)
+			fmt.Fprintln(w, `{"result":{"header":{"revision":"10"},"events":[`+
+				`{"kv":{"key":"L3NlcnZpY2VzL2RiLzE=","create_revision":"3","mod_revision":"9","version":"2","value":"MTAuMC4xLjE="}},`+
+				`{"type":"DELETE","kv":{"key":"L3NlcnZpY2VzL3dlYi8x","mod_revision":"10"}}]}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return s
+}
+
+func newPlugin(endpoint string) *Etcd {
+	return &Etcd{
+		Endpoints: []string{endpoint},
+		Username:  "telegraf",
+		Password:  "pa$$word",
+		Timeout:   internal.Duration{Duration: 5 * time.Second},
+		Log:       testutil.Logger{},
+	}
+}
+
+func TestPrefixRange(t *testing.T) {
+	key, end := prefixRange("/services/")
+	require.Equal(t, "/services/", string(key))
+	require.Equal(t, "/services0", string(end))
+
+	key, end = prefixRange("a\xff")
+	require.Equal(t, "a\xff", string(key))
+	require.Equal(t, "b", string(end))
+
+	key, end = prefixRange("\xff")
+	require.Equal(t, []byte("\xff"), key)
+	require.Equal(t, []byte{0}, end)
+
+	key, end = prefixRange("")
+	require.Equal(t, []byte{0}, key)
+	require.Equal(t, []byte{0}, end)
+}
+
+func TestGather(t *testing.T) {
+	ts := newGatewayServer(t)
+	defer ts.Close()
+
+	plugin := newPlugin(ts.URL)
+	plugin.KVPrefixes = []string{"/services/"}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "etcd_server",
+		map[string]interface{}{
+			"is_leader":          true,
+			"has_leader":         true,
+			"leader_id":          "8e9e05c52164694d",
+			"raft_term":          uint64(2),
+			"raft_index":         uint64(12),
+			"raft_applied_index": uint64(12),
+			"db_size":            uint64(24576),
+			"db_size_in_use":     uint64(20480),
+			"revision":           uint64(7),
+			"errors":             0,
+		},
+		map[string]string{
+			"endpoint":  ts.URL,
+			"member_id": "8e9e05c52164694d",
+			"version":   "3.4.13",
+		})
+	acc.AssertContainsTaggedFields(t, "etcd_kv",
+		map[string]interface{}{"keys": uint64(3)},
+		map[string]string{"prefix": "/services/"})
+}
+
+func TestGatherAuthenticationFailure(t *testing.T) {
+	ts := newGatewayServer(t)
+	defer ts.Close()
+
+	plugin := newPlugin(ts.URL)
+	plugin.Password = "wrong"
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "authentication failed")
+}
+
+func TestWatch(t *testing.T) {
+	watchRetryInterval = 10 * time.Millisecond
+
+	ts := newGatewayServer(t)
+	defer ts.Close()
+
+	plugin := newPlugin(ts.URL)
+	plugin.WatchPrefixes = []string{"/services/"}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	require.Eventually(t, func() bool {
+		ts.mu.Lock()
+		defer ts.mu.Unlock()
+		return len(ts.watches) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	plugin.Stop()
+
+	// The watch is resumed after the last change.
+	require.Equal(t, uint64(0), ts.watches[0].StartRevision)
+	require.Equal(t, uint64(11), ts.watches[1].StartRevision)
+
+	expected := []struct {
+		action   string
+		key      string
+		revision uint64
+	}{
+		{"created", "/services/web/1", 8},
+		{"updated", "/services/db/1", 9},
+		{"deleted", "/services/web/1", 10},
+	}
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, len(expected))
+	for i, e := range expected {
+		require.Equal(t, "etcd_watch_event", metrics[i].Name())
+		require.Equal(t, map[string]string{"prefix": "/services/", "action": e.action}, metrics[i].Tags())
+		require.Equal(t, map[string]interface{}{"key": e.key, "revision": e.revision}, metrics[i].Fields())
+	}
+
+	// The end of the first stream is reported.
+	require.Len(t, acc.Errors, 1)
+}
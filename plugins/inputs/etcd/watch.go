@@ -0,0 +1,109 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// watch watches the keys with the prefix until the context is done, adding a
+// metric for each change.  The watch is resumed from the revision following
+// the last change, on the next member after a failure.
+func (e *Etcd) watch(ctx context.Context, acc telegraf.Accumulator, prefix string) {
+	var revision uint64
+	for i := 0; ; i++ {
+		c := e.clients[i%len(e.clients)]
+
+		var err error
+		revision, err = e.watchStream(ctx, acc, c, prefix, revision)
+		if ctx.Err() != nil {
+			return
+		}
+		acc.AddError(fmt.Errorf("watching %q on %s: %v", prefix, c.endpoint, err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchRetryInterval):
+		}
+	}
+}
+
+// watchStream watches the keys with the prefix from the revision, or from
+// the current one if it is zero, until the stream fails.  It returns the
+// revision to resume the watch from.
+func (e *Etcd) watchStream(ctx context.Context, acc telegraf.Accumulator, c *client, prefix string, revision uint64) (uint64, error) {
+	token, err := c.authenticate(ctx)
+	if err != nil {
+		return revision, err
+	}
+
+	key, end := prefixRange(prefix)
+	req := watchRequest{
+		CreateRequest: watchCreateRequest{
+			Key:           key,
+			RangeEnd:      end,
+			StartRevision: revision,
+		},
+	}
+	body, err := c.do(ctx, "/watch", token, req)
+	if err != nil {
+		return revision, err
+	}
+	defer body.Close()
+
+	decoder := json.NewDecoder(body)
+	for {
+		var resp watchResponse
+		if err := decoder.Decode(&resp); err != nil {
+			return revision, err
+		}
+		if resp.Error != nil {
+			return revision, fmt.Errorf("%s", resp.Error.Message)
+		}
+
+		result := resp.Result
+		if result.CompactRevision != 0 {
+			// The changes up to the compacted revision are lost.
+			return uint64(result.CompactRevision), fmt.Errorf("revision %d was compacted", revision)
+		}
+		if result.Canceled {
+			return revision, fmt.Errorf("watch canceled: %s", result.CancelReason)
+		}
+
+		for _, event := range result.Events {
+			addWatchEvent(acc, prefix, event)
+			revision = uint64(event.KV.ModRevision) + 1
+		}
+
+		// The watch started from the current revision is resumed from the one
+		// following it.
+		if revision == 0 && result.Header.Revision != 0 {
+			revision = uint64(result.Header.Revision) + 1
+		}
+	}
+}
+
+// addWatchEvent adds an etcd_watch_event metric for the change of a key.
+func addWatchEvent(acc telegraf.Accumulator, prefix string, event watchEvent) {
+	action := "updated"
+	switch {
+	case event.Type == "DELETE":
+		action = "deleted"
+	case event.KV.CreateRevision == event.KV.ModRevision:
+		action = "created"
+	}
+
+	fields := map[string]interface{}{
+		"key":      string(event.KV.Key),
+		"revision": uint64(event.KV.ModRevision),
+	}
+	tags := map[string]string{
+		"prefix": prefix,
+		"action": action,
+	}
+	acc.AddFields("etcd_watch_event", fields, tags)
+}
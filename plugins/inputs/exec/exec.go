@@ -44,7 +44,7 @@ const MaxStderrBytes = 512
 type Exec struct {
 	Commands []string
 	Command  string
-	Timeout  internal.Duration
+	Timeout  internal.Duration `range:"1ms,"`
 
 	parser parsers.Parser
 
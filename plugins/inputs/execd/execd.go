@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"os"
 	"os/exec"
 	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal/process"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
@@ -29,10 +31,38 @@ const sampleConfig = `
   ##   "SIGHUP"  : Send a HUP signal. Not available on Windows.
   ##   "SIGUSR1" : Send a USR1 signal. Not available on Windows.
   ##   "SIGUSR2" : Send a USR2 signal. Not available on Windows.
+  ##   "PIPE"    : Connect to a named pipe. Only available on Windows.
   signal = "none"
 
-  ## Delay before the process is restarted after an unexpected termination
+  ## Delay before the process is restarted after an unexpected termination.
+  ## The delay doubles on each consecutive restart up to restart_delay_max,
+  ## it is reset once the process ran for at least restart_delay_max.
   restart_delay = "10s"
+  # restart_delay_max = "5m"
+
+  ## Maximum number of consecutive restarts before giving up, 0 is unlimited.
+  # max_restarts = 0
+
+  ## Ping the process on STDIN every health_check_interval and restart it
+  ## when it does not answer within health_check_timeout.  The process must
+  ## answer a "# ping" line with a "# pong" line on STDOUT.  Disabled if 0.
+  # health_check_interval = "0s"
+  # health_check_timeout = "5s"
+
+  ## Transport used by the process to send metrics.
+  ## Valid values are:
+  ##   "stdio" : Metrics are read from STDOUT in the configured data format.
+  ##   "grpc"  : The process connects to a gRPC service and sends protobuf
+  ##             metrics, see the README.  The "STDIN" signal is sent over
+  ##             the gRPC stream.
+  # transport = "stdio"
+
+  ## Address of the gRPC service, the port is chosen randomly by default.
+  # grpc_address = "127.0.0.1:0"
+
+  ## Maximum number of metrics sent by the process with a delivery report
+  ## request that may be undelivered at once.  Only used with gRPC.
+  # max_undelivered_metrics = 1000
 
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
@@ -42,9 +72,18 @@ const sampleConfig = `
 `
 
 type Execd struct {
-	Command      []string
-	Signal       string
-	RestartDelay config.Duration
+	Command             []string
+	Signal              string
+	RestartDelay        config.Duration
+	RestartDelayMax     config.Duration
+	MaxRestarts         int
+	HealthCheckInterval config.Duration
+	HealthCheckTimeout  config.Duration
+	Transport           string
+	GRPCAddress         string `toml:"grpc_address"`
+	MaxUndelivered      int    `toml:"max_undelivered_metrics"`
+
+	Log telegraf.Logger `toml:"-"`
 
 	acc        telegraf.Accumulator
 	cmd        *exec.Cmd
@@ -52,6 +91,9 @@ type Execd struct {
 	stdin      io.WriteCloser
 	stdout     io.ReadCloser
 	stderr     io.ReadCloser
+	health     process.HealthCheck
+	grpc       *grpcServer
+	pipeName   string
 	cancel     context.CancelFunc
 	mainLoopWg sync.WaitGroup
 }
@@ -75,9 +117,29 @@ func (e *Execd) Start(acc telegraf.Accumulator) error {
 		return fmt.Errorf("FATAL no command specified")
 	}
 
-	e.mainLoopWg.Add(1)
-
 	ctx, cancel := context.WithCancel(context.Background())
+
+	switch e.Transport {
+	case "", "stdio":
+	case "grpc":
+		if e.HealthCheckInterval > 0 {
+			cancel()
+			return fmt.Errorf("health checks are not supported with the grpc transport")
+		}
+
+		server, err := newGRPCServer(acc, e.GRPCAddress, e.MaxUndelivered)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("starting gRPC server failed: %s", err)
+		}
+		e.grpc = server
+		go e.grpc.serve(ctx)
+	default:
+		cancel()
+		return fmt.Errorf("invalid transport: %s", e.Transport)
+	}
+
+	e.mainLoopWg.Add(1)
 	e.cancel = cancel
 
 	if err := e.cmdStart(); err != nil {
@@ -98,53 +160,45 @@ func (e *Execd) Stop() {
 	// don't try to stop before all stream readers have started.
 	e.cancel()
 	e.mainLoopWg.Wait()
+	if e.grpc != nil {
+		e.grpc.stop()
+	}
 }
 
 // cmdLoop watches an already running process, restarting it when appropriate.
 func (e *Execd) cmdLoop(ctx context.Context) error {
-	for {
-		// Use a buffered channel to ensure goroutine below can exit
-		// if `ctx.Done` is selected and nothing reads on `done` anymore
-		done := make(chan error, 1)
-		go func() {
-			done <- e.cmdWait()
-		}()
-
-		select {
-		case <-ctx.Done():
+	s := &process.Supervisor{
+		Command:             e.Command,
+		RestartDelay:        time.Duration(e.RestartDelay),
+		RestartDelayMax:     time.Duration(e.RestartDelayMax),
+		MaxRestarts:         e.MaxRestarts,
+		HealthCheckInterval: time.Duration(e.HealthCheckInterval),
+		HealthCheckTimeout:  time.Duration(e.HealthCheckTimeout),
+		Log:                 e.Log,
+		Start:               e.cmdStart,
+		Wait:                e.cmdWait,
+		Stop: func() {
 			if e.stdin != nil {
 				e.stdin.Close()
 				gracefulStop(e.cmd, 5*time.Second)
 			}
-			return nil
-		case err := <-done:
-			log.Printf("Process %s terminated: %s", e.Command, err)
-			if isQuitting(ctx) {
-				return err
-			}
-		}
-
-		log.Printf("Restarting in %s...", time.Duration(e.RestartDelay))
-
-		select {
-		case <-ctx.Done():
-			return nil
-		case <-time.After(time.Duration(e.RestartDelay)):
-			// Continue the loop and restart the process
-			if err := e.cmdStart(); err != nil {
-				return err
-			}
-		}
+		},
+		Write: e.writeStdin,
+		Kill: func() {
+			e.cmd.Process.Kill()
+		},
+		Health: &e.health,
 	}
+	return s.Run(ctx)
 }
 
-func isQuitting(ctx context.Context) bool {
-	select {
-	case <-ctx.Done():
-		return true
-	default:
-		return false
+// writeStdin writes to the stdin of the running process.
+func (e *Execd) writeStdin(b []byte) error {
+	if osStdin, ok := e.stdin.(*os.File); ok {
+		osStdin.SetWriteDeadline(time.Now().Add(1 * time.Second))
 	}
+	_, err := e.stdin.Write(b)
+	return err
 }
 
 func (e *Execd) cmdStart() (err error) {
@@ -154,6 +208,14 @@ func (e *Execd) cmdStart() (err error) {
 		e.cmd = exec.Command(e.Command[0])
 	}
 
+	env := e.signalEnv()
+	if e.grpc != nil {
+		env = append(env, e.grpc.env()...)
+	}
+	if len(env) > 0 {
+		e.cmd.Env = append(os.Environ(), env...)
+	}
+
 	e.stdin, err = e.cmd.StdinPipe()
 	if err != nil {
 		return fmt.Errorf("Error opening stdin pipe: %s", err)
@@ -198,6 +260,16 @@ func (e *Execd) cmdWait() error {
 }
 
 func (e *Execd) cmdReadOut(out io.Reader) {
+	if e.grpc != nil {
+		// Metrics are sent over gRPC, so stdout is just logged.
+		e.cmdReadLog(out, "stdout")
+		return
+	}
+
+	if e.HealthCheckInterval > 0 {
+		out = process.NewPongReader(out, e.health.Pong)
+	}
+
 	if _, isInfluxParser := e.parser.(*influx.Parser); isInfluxParser {
 		// work around the lack of built-in streaming parser. :(
 		e.cmdReadOutStream(out)
@@ -246,22 +318,32 @@ func (e *Execd) cmdReadOutStream(out io.Reader) {
 }
 
 func (e *Execd) cmdReadErr(out io.Reader) {
+	e.cmdReadLog(out, "stderr")
+}
+
+// cmdReadLog mirrors the output to the log.
+func (e *Execd) cmdReadLog(out io.Reader, name string) {
 	scanner := bufio.NewScanner(out)
 
 	for scanner.Scan() {
-		log.Printf("stderr: %q", scanner.Text())
+		log.Printf("%s: %q", name, scanner.Text())
 	}
 
 	if err := scanner.Err(); err != nil {
-		e.acc.AddError(fmt.Errorf("Error reading stderr: %s", err))
+		e.acc.AddError(fmt.Errorf("Error reading %s: %s", name, err))
 	}
 }
 
 func init() {
 	inputs.Add("execd", func() telegraf.Input {
 		return &Execd{
-			Signal:       "none",
-			RestartDelay: config.Duration(10 * time.Second),
+			Signal:             "none",
+			RestartDelay:       config.Duration(10 * time.Second),
+			RestartDelayMax:    config.Duration(5 * time.Minute),
+			HealthCheckTimeout: config.Duration(5 * time.Second),
+			Transport:          "stdio",
+			GRPCAddress:        "127.0.0.1:0",
+			MaxUndelivered:     1000,
 		}
 	})
 }
@@ -26,6 +26,9 @@ func (e *Execd) Gather(acc telegraf.Accumulator) error {
 	case "SIGUSR2":
 		e.cmd.Process.Signal(syscall.SIGUSR2)
 	case "STDIN":
+		if e.grpc != nil {
+			return e.grpc.gather()
+		}
 		if osStdin, ok := e.stdin.(*os.File); ok {
 			osStdin.SetWriteDeadline(time.Now().Add(1 * time.Second))
 		}
@@ -40,6 +43,11 @@ func (e *Execd) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
+// signalEnv returns the environment variables needed by the signal.
+func (e *Execd) signalEnv() []string {
+	return nil
+}
+
 func gracefulStop(cmd *exec.Cmd, timeout time.Duration) {
 	cmd.Process.Signal(syscall.SIGTERM)
 	go func() {
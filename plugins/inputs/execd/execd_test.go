@@ -3,6 +3,7 @@
 package execd
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
@@ -11,6 +12,7 @@ import (
 	"github.com/influxdata/telegraf/agent"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf/plugins/parsers"
@@ -27,6 +29,7 @@ func TestExternalInputWorks(t *testing.T) {
 		RestartDelay: config.Duration(5 * time.Second),
 		parser:       jsonParser,
 		Signal:       "STDIN",
+		Log:          testutil.Logger{},
 	}
 
 	metrics := make(chan telegraf.Metric, 10)
@@ -94,6 +97,58 @@ func TestParsesLinesContainingNewline(t *testing.T) {
 	}
 }
 
+func TestMaxRestarts(t *testing.T) {
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+
+	metrics := make(chan telegraf.Metric, 10)
+	defer close(metrics)
+	acc := agent.NewAccumulator(&TestMetricMaker{}, metrics)
+
+	e := &Execd{
+		Command:         []string{shell(), "-c", "exit 1"},
+		RestartDelay:    config.Duration(time.Millisecond),
+		RestartDelayMax: config.Duration(10 * time.Millisecond),
+		MaxRestarts:     2,
+		Log:             testutil.Logger{},
+		parser:          parser,
+		acc:             acc,
+	}
+
+	require.NoError(t, e.cmdStart())
+	err = e.cmdLoop(context.Background())
+	require.EqualError(t, err, "process [sh -c exit 1] restarted 2 times, giving up")
+}
+
+func TestHealthPongIsNotParsed(t *testing.T) {
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+
+	metrics := make(chan telegraf.Metric, 10)
+	defer close(metrics)
+	acc := agent.NewAccumulator(&TestMetricMaker{}, metrics)
+
+	e := &Execd{
+		HealthCheckInterval: config.Duration(time.Second),
+		parser:              parser,
+		acc:                 acc,
+	}
+
+	timedOut := make(chan bool, 1)
+	write := func(b []byte) error { return nil }
+	require.NoError(t, e.health.Ping(write, time.Second, func() {
+		timedOut <- true
+	}))
+
+	e.cmdReadOut(strings.NewReader("# pong\ncpu value=42 1587128639239000000\n"))
+
+	m := readChanWithTimeout(t, metrics, 1*time.Second)
+	require.Equal(t, "cpu", m.Name())
+
+	require.False(t, e.health.Pending())
+	require.Len(t, timedOut, 0)
+}
+
 func readChanWithTimeout(t *testing.T, metrics chan telegraf.Metric, timeout time.Duration) telegraf.Metric {
 	to := time.NewTimer(timeout)
 	defer to.Stop()
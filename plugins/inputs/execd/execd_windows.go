@@ -9,9 +9,16 @@ import (
 	"os/exec"
 	"time"
 
+	"github.com/Microsoft/go-winio"
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 )
 
+// envTriggerPipe is the environment variable passing the name of the pipe, it
+// is the same as shim.EnvTriggerPipe.  The shim is not imported as it depends
+// on the agent package.
+const envTriggerPipe = "TELEGRAF_EXECD_TRIGGER_PIPE"
+
 func (e *Execd) Gather(acc telegraf.Accumulator) error {
 	if e.cmd == nil || e.cmd.Process == nil {
 		return nil
@@ -19,12 +26,23 @@ func (e *Execd) Gather(acc telegraf.Accumulator) error {
 
 	switch e.Signal {
 	case "STDIN":
+		if e.grpc != nil {
+			return e.grpc.gather()
+		}
 		if osStdin, ok := e.stdin.(*os.File); ok {
 			osStdin.SetWriteDeadline(time.Now().Add(1 * time.Second))
 		}
 		if _, err := io.WriteString(e.stdin, "\n"); err != nil {
 			return fmt.Errorf("Error writing to stdin: %s", err)
 		}
+	case "PIPE":
+		timeout := time.Second
+		conn, err := winio.DialPipe(e.pipeName, &timeout)
+		if err != nil {
+			return fmt.Errorf("Error connecting to pipe: %s", err)
+		}
+		// Connecting is the request to collect metrics.
+		conn.Close()
 	case "none":
 	default:
 		return fmt.Errorf("invalid signal: %s", e.Signal)
@@ -33,6 +51,19 @@ func (e *Execd) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
+// signalEnv returns the environment variables needed by the signal.  The
+// name of the pipe is passed to the process, which listens on it.
+func (e *Execd) signalEnv() []string {
+	if e.Signal != "PIPE" {
+		return nil
+	}
+
+	if e.pipeName == "" {
+		e.pipeName = fmt.Sprintf(`\\.\pipe\telegraf-execd-%d-%s`, os.Getpid(), internal.RandomString(8))
+	}
+	return []string{envTriggerPipe + "=" + e.pipeName}
+}
+
 func gracefulStop(cmd *exec.Cmd, timeout time.Duration) {
 	cmd.Process.Kill()
 }
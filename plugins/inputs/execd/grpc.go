@@ -0,0 +1,219 @@
+package execd
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs/execd/rpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcServer receives the metrics of a process connecting to the Execd
+// service instead of writing to stdout.
+type grpcServer struct {
+	acc   telegraf.TrackingAccumulator
+	token string
+
+	listener net.Listener
+	server   *grpc.Server
+
+	sem chan empty
+
+	mu      sync.Mutex
+	stream  *grpcStream
+	pending map[telegraf.TrackingID]pendingDelivery
+}
+
+type empty struct{}
+
+// grpcStream is a connection of a process.
+type grpcStream struct {
+	sync.Mutex
+	rpc.Execd_ConnectServer
+}
+
+func (s *grpcStream) send(msg *rpc.AgentMessage) error {
+	s.Lock()
+	defer s.Unlock()
+	return s.Send(msg)
+}
+
+type pendingDelivery struct {
+	stream *grpcStream
+	id     uint64
+}
+
+func newGRPCServer(acc telegraf.Accumulator, address string, maxUndelivered int) (*grpcServer, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &grpcServer{
+		acc:      acc.WithTracking(maxUndelivered),
+		token:    hex.EncodeToString(token),
+		listener: listener,
+		server:   grpc.NewServer(),
+		sem:      make(chan empty, maxUndelivered),
+		pending:  make(map[telegraf.TrackingID]pendingDelivery),
+	}
+	rpc.RegisterExecdServer(s.server, s)
+	return s, nil
+}
+
+// env returns the environment variables telling the process how to connect.
+func (s *grpcServer) env() []string {
+	return []string{
+		rpc.EnvAddress + "=" + s.listener.Addr().String(),
+		rpc.EnvToken + "=" + s.token,
+	}
+}
+
+func (s *grpcServer) serve(ctx context.Context) {
+	go s.deliveryLoop(ctx)
+	if err := s.server.Serve(s.listener); err != nil {
+		log.Printf("gRPC server stopped: %s", err)
+	}
+}
+
+func (s *grpcServer) stop() {
+	s.server.Stop()
+}
+
+// gather asks the connected process to collect metrics.
+func (s *grpcServer) gather() error {
+	s.mu.Lock()
+	stream := s.stream
+	s.mu.Unlock()
+
+	if stream == nil {
+		return nil
+	}
+
+	msg := &rpc.AgentMessage{Message: &rpc.AgentMessage_Gather{Gather: &rpc.Gather{}}}
+	if err := stream.send(msg); err != nil {
+		return fmt.Errorf("Error sending gather request: %s", err)
+	}
+	return nil
+}
+
+// Connect implements the rpc.ExecdServer interface.
+func (s *grpcServer) Connect(conn rpc.Execd_ConnectServer) error {
+	msg, err := conn.Recv()
+	if err != nil {
+		return err
+	}
+
+	hello := msg.GetHello()
+	if hello == nil {
+		return status.Error(codes.InvalidArgument, "first message must be hello")
+	}
+	if subtle.ConstantTimeCompare([]byte(hello.Token), []byte(s.token)) != 1 {
+		return status.Error(codes.PermissionDenied, "invalid token")
+	}
+
+	// Only the latest connection receives gather requests.
+	stream := &grpcStream{Execd_ConnectServer: conn}
+	s.mu.Lock()
+	s.stream = stream
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		if s.stream == stream {
+			s.stream = nil
+		}
+		s.mu.Unlock()
+	}()
+
+	precision := time.Duration(hello.Precision)
+	for {
+		msg, err := conn.Recv()
+		if err != nil {
+			return err
+		}
+
+		batch := msg.GetMetrics()
+		if batch == nil {
+			return status.Error(codes.InvalidArgument, "expected metrics")
+		}
+
+		for _, pm := range batch.Metrics {
+			m, err := rpc.ToMetric(pm)
+			if err != nil {
+				s.acc.AddError(fmt.Errorf("Invalid metric: %s", err))
+				continue
+			}
+			if precision > 0 {
+				m.SetTime(m.Time().Round(precision))
+			}
+
+			if pm.TrackingId == 0 {
+				s.acc.AddMetric(m)
+				continue
+			}
+
+			if err := s.track(conn.Context(), stream, pm.TrackingId, m); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// track adds the metric, reporting its delivery to the process.  Blocks while
+// the maximum number of undelivered metrics is reached.
+func (s *grpcServer) track(ctx context.Context, stream *grpcStream, id uint64, m telegraf.Metric) error {
+	select {
+	case s.sem <- empty{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// Hold the lock so the delivery is not reported before it is pending.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	trackingID := s.acc.AddTrackingMetric(m)
+	s.pending[trackingID] = pendingDelivery{stream: stream, id: id}
+	return nil
+}
+
+func (s *grpcServer) deliveryLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case info := <-s.acc.Delivered():
+			<-s.sem
+
+			s.mu.Lock()
+			pending, ok := s.pending[info.ID()]
+			delete(s.pending, info.ID())
+			s.mu.Unlock()
+			if !ok {
+				continue
+			}
+
+			msg := &rpc.AgentMessage{Message: &rpc.AgentMessage_Delivery{Delivery: &rpc.Delivery{
+				TrackingId: pending.id,
+				Delivered:  info.Delivered(),
+			}}}
+			// The process may have terminated in the meantime.
+			pending.stream.send(msg)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// +build !windows
+
+package execd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/agent"
+	"github.com/influxdata/telegraf/plugins/inputs/execd/rpc"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func connectGRPC(t *testing.T, s *grpcServer, token string) (rpc.Execd_ConnectClient, func()) {
+	conn, err := grpc.Dial(s.listener.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+
+	stream, err := rpc.NewExecdClient(conn).Connect(context.Background())
+	require.NoError(t, err)
+
+	hello := &rpc.PluginMessage{Message: &rpc.PluginMessage_Hello{Hello: &rpc.Hello{
+		Token:     token,
+		Precision: int64(time.Second),
+	}}}
+	require.NoError(t, stream.Send(hello))
+	return stream, func() { conn.Close() }
+}
+
+func TestGRPCTransport(t *testing.T) {
+	metrics := make(chan telegraf.Metric, 10)
+	acc := agent.NewAccumulator(&TestMetricMaker{}, metrics)
+
+	s, err := newGRPCServer(acc, "127.0.0.1:0", 10)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.serve(ctx)
+	defer s.stop()
+
+	env := s.env()
+	require.Len(t, env, 2)
+	require.True(t, strings.HasPrefix(env[0], rpc.EnvAddress+"=127.0.0.1:"))
+	require.Equal(t, rpc.EnvToken+"="+s.token, env[1])
+
+	// Invalid token
+	stream, closeConn := connectGRPC(t, s, "invalid")
+	_, err = stream.Recv()
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	closeConn()
+
+	stream, closeConn = connectGRPC(t, s, s.token)
+	defer closeConn()
+
+	untracked := &rpc.Metric{
+		Name:   "cpu",
+		Tags:   map[string]string{"host": "server01"},
+		Fields: map[string]*rpc.FieldValue{"value": {Value: &rpc.FieldValue_Float{Float: 42.5}}},
+		Time:   time.Unix(10, 400000000).UnixNano(),
+		Type:   rpc.ValueType_GAUGE,
+	}
+	tracked := &rpc.Metric{
+		Name:       "mem",
+		Fields:     map[string]*rpc.FieldValue{"used": {Value: &rpc.FieldValue_Uint{Uint: 1024}}},
+		Time:       time.Unix(20, 0).UnixNano(),
+		TrackingId: 42,
+	}
+	require.NoError(t, stream.Send(&rpc.PluginMessage{Message: &rpc.PluginMessage_Metrics{
+		Metrics: &rpc.MetricBatch{Metrics: []*rpc.Metric{untracked, tracked}},
+	}}))
+
+	m := readChanWithTimeout(t, metrics, 5*time.Second)
+	require.Equal(t, "cpu", m.Name())
+	require.Equal(t, map[string]string{"host": "server01"}, m.Tags())
+	require.Equal(t, map[string]interface{}{"value": 42.5}, m.Fields())
+	require.Equal(t, telegraf.Gauge, m.Type())
+	// Rounded to the precision sent in the hello
+	require.Equal(t, time.Unix(10, 0), m.Time())
+	m.Accept()
+
+	m = readChanWithTimeout(t, metrics, 5*time.Second)
+	require.Equal(t, "mem", m.Name())
+	require.Equal(t, map[string]interface{}{"used": uint64(1024)}, m.Fields())
+
+	// Gather requests are sent to the connected process
+	require.NoError(t, s.gather())
+	msg, err := stream.Recv()
+	require.NoError(t, err)
+	require.NotNil(t, msg.GetGather())
+
+	// The delivery of tracked metrics is reported
+	m.Reject()
+	msg, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, &rpc.Delivery{TrackingId: 42, Delivered: false}, msg.GetDelivery())
+}
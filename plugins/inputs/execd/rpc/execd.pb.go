@@ -0,0 +1,772 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: execd.proto
+
+package rpc
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type ValueType int32
+
+const (
+	ValueType_UNTYPED   ValueType = 0
+	ValueType_COUNTER   ValueType = 1
+	ValueType_GAUGE     ValueType = 2
+	ValueType_SUMMARY   ValueType = 3
+	ValueType_HISTOGRAM ValueType = 4
+)
+
+var ValueType_name = map[int32]string{
+	0: "UNTYPED",
+	1: "COUNTER",
+	2: "GAUGE",
+	3: "SUMMARY",
+	4: "HISTOGRAM",
+}
+
+var ValueType_value = map[string]int32{
+	"UNTYPED":   0,
+	"COUNTER":   1,
+	"GAUGE":     2,
+	"SUMMARY":   3,
+	"HISTOGRAM": 4,
+}
+
+func (x ValueType) String() string {
+	return proto.EnumName(ValueType_name, int32(x))
+}
+
+func (ValueType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_1f6e278b353c2e12, []int{0}
+}
+
+type PluginMessage struct {
+	// Types that are valid to be assigned to Message:
+	//	*PluginMessage_Hello
+	//	*PluginMessage_Metrics
+	Message              isPluginMessage_Message `protobuf_oneof:"message"`
+	XXX_NoUnkeyedLiteral struct{}                `json:"-"`
+	XXX_unrecognized     []byte                  `json:"-"`
+	XXX_sizecache        int32                   `json:"-"`
+}
+
+func (m *PluginMessage) Reset()         { *m = PluginMessage{} }
+func (m *PluginMessage) String() string { return proto.CompactTextString(m) }
+func (*PluginMessage) ProtoMessage()    {}
+func (*PluginMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1f6e278b353c2e12, []int{0}
+}
+
+func (m *PluginMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PluginMessage.Unmarshal(m, b)
+}
+func (m *PluginMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PluginMessage.Marshal(b, m, deterministic)
+}
+func (m *PluginMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PluginMessage.Merge(m, src)
+}
+func (m *PluginMessage) XXX_Size() int {
+	return xxx_messageInfo_PluginMessage.Size(m)
+}
+func (m *PluginMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_PluginMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PluginMessage proto.InternalMessageInfo
+
+type isPluginMessage_Message interface {
+	isPluginMessage_Message()
+}
+
+type PluginMessage_Hello struct {
+	Hello *Hello `protobuf:"bytes,1,opt,name=hello,proto3,oneof"`
+}
+
+type PluginMessage_Metrics struct {
+	Metrics *MetricBatch `protobuf:"bytes,2,opt,name=metrics,proto3,oneof"`
+}
+
+func (*PluginMessage_Hello) isPluginMessage_Message() {}
+
+func (*PluginMessage_Metrics) isPluginMessage_Message() {}
+
+func (m *PluginMessage) GetMessage() isPluginMessage_Message {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+func (m *PluginMessage) GetHello() *Hello {
+	if x, ok := m.GetMessage().(*PluginMessage_Hello); ok {
+		return x.Hello
+	}
+	return nil
+}
+
+func (m *PluginMessage) GetMetrics() *MetricBatch {
+	if x, ok := m.GetMessage().(*PluginMessage_Metrics); ok {
+		return x.Metrics
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*PluginMessage) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*PluginMessage_Hello)(nil),
+		(*PluginMessage_Metrics)(nil),
+	}
+}
+
+type AgentMessage struct {
+	// Types that are valid to be assigned to Message:
+	//	*AgentMessage_Gather
+	//	*AgentMessage_Delivery
+	Message              isAgentMessage_Message `protobuf_oneof:"message"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *AgentMessage) Reset()         { *m = AgentMessage{} }
+func (m *AgentMessage) String() string { return proto.CompactTextString(m) }
+func (*AgentMessage) ProtoMessage()    {}
+func (*AgentMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1f6e278b353c2e12, []int{1}
+}
+
+func (m *AgentMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AgentMessage.Unmarshal(m, b)
+}
+func (m *AgentMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AgentMessage.Marshal(b, m, deterministic)
+}
+func (m *AgentMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AgentMessage.Merge(m, src)
+}
+func (m *AgentMessage) XXX_Size() int {
+	return xxx_messageInfo_AgentMessage.Size(m)
+}
+func (m *AgentMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_AgentMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AgentMessage proto.InternalMessageInfo
+
+type isAgentMessage_Message interface {
+	isAgentMessage_Message()
+}
+
+type AgentMessage_Gather struct {
+	Gather *Gather `protobuf:"bytes,1,opt,name=gather,proto3,oneof"`
+}
+
+type AgentMessage_Delivery struct {
+	Delivery *Delivery `protobuf:"bytes,2,opt,name=delivery,proto3,oneof"`
+}
+
+func (*AgentMessage_Gather) isAgentMessage_Message() {}
+
+func (*AgentMessage_Delivery) isAgentMessage_Message() {}
+
+func (m *AgentMessage) GetMessage() isAgentMessage_Message {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+func (m *AgentMessage) GetGather() *Gather {
+	if x, ok := m.GetMessage().(*AgentMessage_Gather); ok {
+		return x.Gather
+	}
+	return nil
+}
+
+func (m *AgentMessage) GetDelivery() *Delivery {
+	if x, ok := m.GetMessage().(*AgentMessage_Delivery); ok {
+		return x.Delivery
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*AgentMessage) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*AgentMessage_Gather)(nil),
+		(*AgentMessage_Delivery)(nil),
+	}
+}
+
+// Hello must be the first message sent by the plugin.
+type Hello struct {
+	// Token passed to the plugin in TELEGRAF_EXECD_GRPC_TOKEN.
+	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	// Precision of the metric timestamps in nanoseconds, 0 if unknown.
+	Precision            int64    `protobuf:"varint,2,opt,name=precision,proto3" json:"precision,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Hello) Reset()         { *m = Hello{} }
+func (m *Hello) String() string { return proto.CompactTextString(m) }
+func (*Hello) ProtoMessage()    {}
+func (*Hello) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1f6e278b353c2e12, []int{2}
+}
+
+func (m *Hello) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Hello.Unmarshal(m, b)
+}
+func (m *Hello) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Hello.Marshal(b, m, deterministic)
+}
+func (m *Hello) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Hello.Merge(m, src)
+}
+func (m *Hello) XXX_Size() int {
+	return xxx_messageInfo_Hello.Size(m)
+}
+func (m *Hello) XXX_DiscardUnknown() {
+	xxx_messageInfo_Hello.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Hello proto.InternalMessageInfo
+
+func (m *Hello) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *Hello) GetPrecision() int64 {
+	if m != nil {
+		return m.Precision
+	}
+	return 0
+}
+
+type MetricBatch struct {
+	Metrics              []*Metric `protobuf:"bytes,1,rep,name=metrics,proto3" json:"metrics,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *MetricBatch) Reset()         { *m = MetricBatch{} }
+func (m *MetricBatch) String() string { return proto.CompactTextString(m) }
+func (*MetricBatch) ProtoMessage()    {}
+func (*MetricBatch) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1f6e278b353c2e12, []int{3}
+}
+
+func (m *MetricBatch) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MetricBatch.Unmarshal(m, b)
+}
+func (m *MetricBatch) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MetricBatch.Marshal(b, m, deterministic)
+}
+func (m *MetricBatch) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MetricBatch.Merge(m, src)
+}
+func (m *MetricBatch) XXX_Size() int {
+	return xxx_messageInfo_MetricBatch.Size(m)
+}
+func (m *MetricBatch) XXX_DiscardUnknown() {
+	xxx_messageInfo_MetricBatch.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MetricBatch proto.InternalMessageInfo
+
+func (m *MetricBatch) GetMetrics() []*Metric {
+	if m != nil {
+		return m.Metrics
+	}
+	return nil
+}
+
+type Metric struct {
+	Name   string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Tags   map[string]string      `protobuf:"bytes,2,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Fields map[string]*FieldValue `protobuf:"bytes,3,rep,name=fields,proto3" json:"fields,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Nanoseconds since the epoch.
+	Time int64     `protobuf:"varint,4,opt,name=time,proto3" json:"time,omitempty"`
+	Type ValueType `protobuf:"varint,5,opt,name=type,proto3,enum=execd.ValueType" json:"type,omitempty"`
+	// Non-zero to request a delivery report for the metric.
+	TrackingId           uint64   `protobuf:"varint,6,opt,name=tracking_id,json=trackingId,proto3" json:"tracking_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Metric) Reset()         { *m = Metric{} }
+func (m *Metric) String() string { return proto.CompactTextString(m) }
+func (*Metric) ProtoMessage()    {}
+func (*Metric) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1f6e278b353c2e12, []int{4}
+}
+
+func (m *Metric) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Metric.Unmarshal(m, b)
+}
+func (m *Metric) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Metric.Marshal(b, m, deterministic)
+}
+func (m *Metric) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Metric.Merge(m, src)
+}
+func (m *Metric) XXX_Size() int {
+	return xxx_messageInfo_Metric.Size(m)
+}
+func (m *Metric) XXX_DiscardUnknown() {
+	xxx_messageInfo_Metric.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Metric proto.InternalMessageInfo
+
+func (m *Metric) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Metric) GetTags() map[string]string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *Metric) GetFields() map[string]*FieldValue {
+	if m != nil {
+		return m.Fields
+	}
+	return nil
+}
+
+func (m *Metric) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+func (m *Metric) GetType() ValueType {
+	if m != nil {
+		return m.Type
+	}
+	return ValueType_UNTYPED
+}
+
+func (m *Metric) GetTrackingId() uint64 {
+	if m != nil {
+		return m.TrackingId
+	}
+	return 0
+}
+
+type FieldValue struct {
+	// Types that are valid to be assigned to Value:
+	//	*FieldValue_Float
+	//	*FieldValue_Int
+	//	*FieldValue_Uint
+	//	*FieldValue_String_
+	//	*FieldValue_Bool
+	Value                isFieldValue_Value `protobuf_oneof:"value"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *FieldValue) Reset()         { *m = FieldValue{} }
+func (m *FieldValue) String() string { return proto.CompactTextString(m) }
+func (*FieldValue) ProtoMessage()    {}
+func (*FieldValue) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1f6e278b353c2e12, []int{5}
+}
+
+func (m *FieldValue) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FieldValue.Unmarshal(m, b)
+}
+func (m *FieldValue) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FieldValue.Marshal(b, m, deterministic)
+}
+func (m *FieldValue) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FieldValue.Merge(m, src)
+}
+func (m *FieldValue) XXX_Size() int {
+	return xxx_messageInfo_FieldValue.Size(m)
+}
+func (m *FieldValue) XXX_DiscardUnknown() {
+	xxx_messageInfo_FieldValue.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FieldValue proto.InternalMessageInfo
+
+type isFieldValue_Value interface {
+	isFieldValue_Value()
+}
+
+type FieldValue_Float struct {
+	Float float64 `protobuf:"fixed64,1,opt,name=float,proto3,oneof"`
+}
+
+type FieldValue_Int struct {
+	Int int64 `protobuf:"varint,2,opt,name=int,proto3,oneof"`
+}
+
+type FieldValue_Uint struct {
+	Uint uint64 `protobuf:"varint,3,opt,name=uint,proto3,oneof"`
+}
+
+type FieldValue_String_ struct {
+	String_ string `protobuf:"bytes,4,opt,name=string,proto3,oneof"`
+}
+
+type FieldValue_Bool struct {
+	Bool bool `protobuf:"varint,5,opt,name=bool,proto3,oneof"`
+}
+
+func (*FieldValue_Float) isFieldValue_Value() {}
+
+func (*FieldValue_Int) isFieldValue_Value() {}
+
+func (*FieldValue_Uint) isFieldValue_Value() {}
+
+func (*FieldValue_String_) isFieldValue_Value() {}
+
+func (*FieldValue_Bool) isFieldValue_Value() {}
+
+func (m *FieldValue) GetValue() isFieldValue_Value {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *FieldValue) GetFloat() float64 {
+	if x, ok := m.GetValue().(*FieldValue_Float); ok {
+		return x.Float
+	}
+	return 0
+}
+
+func (m *FieldValue) GetInt() int64 {
+	if x, ok := m.GetValue().(*FieldValue_Int); ok {
+		return x.Int
+	}
+	return 0
+}
+
+func (m *FieldValue) GetUint() uint64 {
+	if x, ok := m.GetValue().(*FieldValue_Uint); ok {
+		return x.Uint
+	}
+	return 0
+}
+
+func (m *FieldValue) GetString_() string {
+	if x, ok := m.GetValue().(*FieldValue_String_); ok {
+		return x.String_
+	}
+	return ""
+}
+
+func (m *FieldValue) GetBool() bool {
+	if x, ok := m.GetValue().(*FieldValue_Bool); ok {
+		return x.Bool
+	}
+	return false
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*FieldValue) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*FieldValue_Float)(nil),
+		(*FieldValue_Int)(nil),
+		(*FieldValue_Uint)(nil),
+		(*FieldValue_String_)(nil),
+		(*FieldValue_Bool)(nil),
+	}
+}
+
+// Gather requests the plugin to collect metrics.
+type Gather struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Gather) Reset()         { *m = Gather{} }
+func (m *Gather) String() string { return proto.CompactTextString(m) }
+func (*Gather) ProtoMessage()    {}
+func (*Gather) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1f6e278b353c2e12, []int{6}
+}
+
+func (m *Gather) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Gather.Unmarshal(m, b)
+}
+func (m *Gather) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Gather.Marshal(b, m, deterministic)
+}
+func (m *Gather) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Gather.Merge(m, src)
+}
+func (m *Gather) XXX_Size() int {
+	return xxx_messageInfo_Gather.Size(m)
+}
+func (m *Gather) XXX_DiscardUnknown() {
+	xxx_messageInfo_Gather.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Gather proto.InternalMessageInfo
+
+// Delivery reports if a tracked metric was written by the outputs.
+type Delivery struct {
+	TrackingId           uint64   `protobuf:"varint,1,opt,name=tracking_id,json=trackingId,proto3" json:"tracking_id,omitempty"`
+	Delivered            bool     `protobuf:"varint,2,opt,name=delivered,proto3" json:"delivered,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Delivery) Reset()         { *m = Delivery{} }
+func (m *Delivery) String() string { return proto.CompactTextString(m) }
+func (*Delivery) ProtoMessage()    {}
+func (*Delivery) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1f6e278b353c2e12, []int{7}
+}
+
+func (m *Delivery) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Delivery.Unmarshal(m, b)
+}
+func (m *Delivery) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Delivery.Marshal(b, m, deterministic)
+}
+func (m *Delivery) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Delivery.Merge(m, src)
+}
+func (m *Delivery) XXX_Size() int {
+	return xxx_messageInfo_Delivery.Size(m)
+}
+func (m *Delivery) XXX_DiscardUnknown() {
+	xxx_messageInfo_Delivery.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Delivery proto.InternalMessageInfo
+
+func (m *Delivery) GetTrackingId() uint64 {
+	if m != nil {
+		return m.TrackingId
+	}
+	return 0
+}
+
+func (m *Delivery) GetDelivered() bool {
+	if m != nil {
+		return m.Delivered
+	}
+	return false
+}
+
+func init() {
+	proto.RegisterEnum("execd.ValueType", ValueType_name, ValueType_value)
+	proto.RegisterType((*PluginMessage)(nil), "execd.PluginMessage")
+	proto.RegisterType((*AgentMessage)(nil), "execd.AgentMessage")
+	proto.RegisterType((*Hello)(nil), "execd.Hello")
+	proto.RegisterType((*MetricBatch)(nil), "execd.MetricBatch")
+	proto.RegisterType((*Metric)(nil), "execd.Metric")
+	proto.RegisterMapType((map[string]*FieldValue)(nil), "execd.Metric.FieldsEntry")
+	proto.RegisterMapType((map[string]string)(nil), "execd.Metric.TagsEntry")
+	proto.RegisterType((*FieldValue)(nil), "execd.FieldValue")
+	proto.RegisterType((*Gather)(nil), "execd.Gather")
+	proto.RegisterType((*Delivery)(nil), "execd.Delivery")
+}
+
+func init() {
+	proto.RegisterFile("execd.proto", fileDescriptor_1f6e278b353c2e12)
+}
+
+var fileDescriptor_1f6e278b353c2e12 = []byte{
+	// 607 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x54, 0xdd, 0x6e, 0xd3, 0x4c,
+	0x10, 0xf5, 0xc6, 0x3f, 0x89, 0xc7, 0xed, 0xf7, 0x99, 0xa5, 0x02, 0x53, 0x21, 0x11, 0x59, 0x95,
+	0x1a, 0x81, 0x88, 0x20, 0x48, 0x80, 0xca, 0x55, 0xd2, 0x86, 0xb8, 0x52, 0xd3, 0x56, 0xdb, 0x04,
+	0xa9, 0xdc, 0x20, 0xd7, 0xd9, 0xba, 0x56, 0x5d, 0x3b, 0xd8, 0xdb, 0x8a, 0x3c, 0x01, 0x6f, 0xc9,
+	0xb3, 0xa0, 0x1d, 0x6f, 0x12, 0xa7, 0xe2, 0x6e, 0xcf, 0x99, 0x33, 0x7f, 0x27, 0x13, 0x83, 0xc3,
+	0x7f, 0xf1, 0x68, 0xd6, 0x9d, 0x17, 0xb9, 0xc8, 0xa9, 0x89, 0xc0, 0x9f, 0xc3, 0xf6, 0x79, 0x7a,
+	0x1f, 0x27, 0xd9, 0x98, 0x97, 0x65, 0x18, 0x73, 0xba, 0x07, 0xe6, 0x0d, 0x4f, 0xd3, 0xdc, 0x23,
+	0x6d, 0xd2, 0x71, 0x7a, 0x5b, 0xdd, 0x2a, 0x29, 0x90, 0x5c, 0xa0, 0xb1, 0x2a, 0x48, 0xbb, 0xd0,
+	0xbc, 0xe3, 0xa2, 0x48, 0xa2, 0xd2, 0x6b, 0xa0, 0x8e, 0x2a, 0xdd, 0x18, 0xd9, 0x41, 0x28, 0xa2,
+	0x9b, 0x40, 0x63, 0x4b, 0xd1, 0xc0, 0x96, 0x7a, 0x6c, 0xe0, 0xff, 0x84, 0xad, 0x7e, 0xcc, 0x33,
+	0xb1, 0x6c, 0xb8, 0x0f, 0x56, 0x1c, 0x8a, 0x1b, 0x5e, 0xa8, 0x8e, 0xdb, 0xaa, 0xd2, 0x08, 0xc9,
+	0x40, 0x63, 0x2a, 0x4c, 0xdf, 0x42, 0x6b, 0xc6, 0xd3, 0xe4, 0x81, 0x17, 0x0b, 0xd5, 0xf4, 0x7f,
+	0x25, 0x3d, 0x52, 0x74, 0xa0, 0xb1, 0x95, 0xa4, 0xde, 0xf2, 0x0b, 0x98, 0x38, 0x3f, 0xdd, 0x01,
+	0x53, 0xe4, 0xb7, 0x3c, 0xc3, 0x56, 0x36, 0xab, 0x00, 0x7d, 0x09, 0xf6, 0xbc, 0xe0, 0x51, 0x52,
+	0x26, 0x79, 0x86, 0x95, 0x75, 0xb6, 0x26, 0xfc, 0x8f, 0xe0, 0xd4, 0x96, 0xa2, 0xfb, 0xeb, 0xcd,
+	0x49, 0x5b, 0xaf, 0xcd, 0x5b, 0x89, 0x56, 0x2b, 0xfb, 0x7f, 0x1a, 0x60, 0x55, 0x1c, 0xa5, 0x60,
+	0x64, 0xe1, 0x1d, 0x57, 0x5d, 0xf1, 0x4d, 0xdf, 0x80, 0x21, 0xc2, 0x58, 0xda, 0x27, 0x8b, 0x3c,
+	0xdf, 0x28, 0xd2, 0x9d, 0x84, 0x71, 0x39, 0xcc, 0x44, 0xb1, 0x60, 0x28, 0xa2, 0xef, 0xc1, 0xba,
+	0x4e, 0x78, 0x3a, 0x2b, 0x3d, 0x1d, 0xe5, 0x2f, 0x36, 0xe5, 0x5f, 0x31, 0x56, 0x25, 0x28, 0xa1,
+	0xec, 0x29, 0x92, 0x3b, 0xee, 0x19, 0xb8, 0x0f, 0xbe, 0xe9, 0x1e, 0x18, 0x62, 0x31, 0xe7, 0x9e,
+	0xd9, 0x26, 0x9d, 0xff, 0x7a, 0xae, 0x2a, 0xf2, 0x2d, 0x4c, 0xef, 0xf9, 0x64, 0x31, 0xe7, 0x0c,
+	0xa3, 0xf4, 0x15, 0x38, 0xa2, 0x08, 0xa3, 0xdb, 0x24, 0x8b, 0x7f, 0x24, 0x33, 0xcf, 0x6a, 0x93,
+	0x8e, 0xc1, 0x60, 0x49, 0x1d, 0xcf, 0x76, 0x3f, 0x81, 0xbd, 0x1a, 0x90, 0xba, 0xa0, 0xdf, 0xf2,
+	0x85, 0x5a, 0x4d, 0x3e, 0xa5, 0xc9, 0x0f, 0xb2, 0x24, 0x5a, 0x69, 0xb3, 0x0a, 0x1c, 0x34, 0x3e,
+	0x93, 0xdd, 0x13, 0x70, 0x6a, 0xa3, 0xfe, 0x23, 0x75, 0xbf, 0x9e, 0xea, 0xf4, 0x9e, 0xa8, 0x09,
+	0x31, 0x09, 0xc7, 0xac, 0x55, 0xf3, 0x7f, 0x13, 0x80, 0x75, 0x84, 0x3e, 0x03, 0xf3, 0x3a, 0xcd,
+	0x43, 0x81, 0xf5, 0x88, 0x3c, 0x55, 0x84, 0x94, 0x82, 0x9e, 0x64, 0xa2, 0xfa, 0x5d, 0x03, 0x8d,
+	0x49, 0x40, 0x77, 0xc0, 0xb8, 0x97, 0xa4, 0x2e, 0x77, 0x0b, 0x34, 0x86, 0x88, 0x7a, 0x60, 0x95,
+	0xa2, 0x48, 0xb2, 0x18, 0x4d, 0xb3, 0xe5, 0xe9, 0x55, 0x58, 0xea, 0xaf, 0xf2, 0x3c, 0x45, 0xe3,
+	0x5a, 0x52, 0x2f, 0xd1, 0xa0, 0xa9, 0xa6, 0xf5, 0x5b, 0x60, 0x55, 0xd7, 0xea, 0x1f, 0x43, 0x6b,
+	0x79, 0x8c, 0x8f, 0x7d, 0x24, 0x8f, 0x7d, 0x94, 0x77, 0xa7, 0xae, 0x95, 0xcf, 0x70, 0xbe, 0x16,
+	0x5b, 0x13, 0xaf, 0x4f, 0xc0, 0x5e, 0xfd, 0x32, 0xd4, 0x81, 0xe6, 0xf4, 0x74, 0x72, 0x79, 0x3e,
+	0x3c, 0x72, 0x35, 0x09, 0x0e, 0xcf, 0xa6, 0xa7, 0x93, 0x21, 0x73, 0x09, 0xb5, 0xc1, 0x1c, 0xf5,
+	0xa7, 0xa3, 0xa1, 0xdb, 0x90, 0xfc, 0xc5, 0x74, 0x3c, 0xee, 0xb3, 0x4b, 0x57, 0xa7, 0xdb, 0x60,
+	0x07, 0xc7, 0x17, 0x93, 0xb3, 0x11, 0xeb, 0x8f, 0x5d, 0xa3, 0x77, 0x08, 0xe6, 0x50, 0x7a, 0x49,
+	0x0f, 0xa0, 0x79, 0x98, 0x67, 0x19, 0x8f, 0x04, 0xdd, 0x51, 0xf6, 0x6e, 0x7c, 0x00, 0x76, 0x9f,
+	0x2a, 0xb6, 0xfe, 0x27, 0xf5, 0xb5, 0x0e, 0x79, 0x47, 0x06, 0xe6, 0x77, 0xbd, 0x98, 0x47, 0x57,
+	0x16, 0x7e, 0x41, 0x3e, 0xfc, 0x1d, 0x00, 0x99, 0xfd, 0x61, 0xa2, 0x50, 0x04, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// ExecdClient is the client API for Execd service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ExecdClient interface {
+	// Connect opens a stream for the lifetime of the plugin.  The plugin
+	// starts with a Hello message followed by metrics, Telegraf sends gather
+	// requests and delivery reports.
+	Connect(ctx context.Context, opts ...grpc.CallOption) (Execd_ConnectClient, error)
+}
+
+type execdClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewExecdClient(cc grpc.ClientConnInterface) ExecdClient {
+	return &execdClient{cc}
+}
+
+func (c *execdClient) Connect(ctx context.Context, opts ...grpc.CallOption) (Execd_ConnectClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Execd_serviceDesc.Streams[0], "/execd.Execd/Connect", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &execdConnectClient{stream}
+	return x, nil
+}
+
+type Execd_ConnectClient interface {
+	Send(*PluginMessage) error
+	Recv() (*AgentMessage, error)
+	grpc.ClientStream
+}
+
+type execdConnectClient struct {
+	grpc.ClientStream
+}
+
+func (x *execdConnectClient) Send(m *PluginMessage) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *execdConnectClient) Recv() (*AgentMessage, error) {
+	m := new(AgentMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ExecdServer is the server API for Execd service.
+type ExecdServer interface {
+	// Connect opens a stream for the lifetime of the plugin.  The plugin
+	// starts with a Hello message followed by metrics, Telegraf sends gather
+	// requests and delivery reports.
+	Connect(Execd_ConnectServer) error
+}
+
+// UnimplementedExecdServer can be embedded to have forward compatible implementations.
+type UnimplementedExecdServer struct {
+}
+
+func (*UnimplementedExecdServer) Connect(srv Execd_ConnectServer) error {
+	return status.Errorf(codes.Unimplemented, "method Connect not implemented")
+}
+
+func RegisterExecdServer(s *grpc.Server, srv ExecdServer) {
+	s.RegisterService(&_Execd_serviceDesc, srv)
+}
+
+func _Execd_Connect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ExecdServer).Connect(&execdConnectServer{stream})
+}
+
+type Execd_ConnectServer interface {
+	Send(*AgentMessage) error
+	Recv() (*PluginMessage, error)
+	grpc.ServerStream
+}
+
+type execdConnectServer struct {
+	grpc.ServerStream
+}
+
+func (x *execdConnectServer) Send(m *AgentMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *execdConnectServer) Recv() (*PluginMessage, error) {
+	m := new(PluginMessage)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _Execd_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "execd.Execd",
+	HandlerType: (*ExecdServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Connect",
+			Handler:       _Execd_Connect_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "execd.proto",
+}
@@ -0,0 +1,106 @@
+package rpc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+const (
+	// EnvAddress is the environment variable holding the address of the
+	// Execd service.
+	EnvAddress = "TELEGRAF_EXECD_GRPC_ADDRESS"
+
+	// EnvToken is the environment variable holding the token to send in the
+	// Hello message.
+	EnvToken = "TELEGRAF_EXECD_GRPC_TOKEN"
+)
+
+// FromMetric converts a metric to its protobuf representation.
+func FromMetric(m telegraf.Metric) *Metric {
+	pm := &Metric{
+		Name:   m.Name(),
+		Tags:   m.Tags(),
+		Fields: make(map[string]*FieldValue, len(m.FieldList())),
+		Time:   m.Time().UnixNano(),
+		Type:   fromValueType(m.Type()),
+	}
+
+	for _, field := range m.FieldList() {
+		switch v := field.Value.(type) {
+		case float64:
+			pm.Fields[field.Key] = &FieldValue{Value: &FieldValue_Float{Float: v}}
+		case int64:
+			pm.Fields[field.Key] = &FieldValue{Value: &FieldValue_Int{Int: v}}
+		case uint64:
+			pm.Fields[field.Key] = &FieldValue{Value: &FieldValue_Uint{Uint: v}}
+		case string:
+			pm.Fields[field.Key] = &FieldValue{Value: &FieldValue_String_{String_: v}}
+		case bool:
+			pm.Fields[field.Key] = &FieldValue{Value: &FieldValue_Bool{Bool: v}}
+		}
+	}
+	return pm
+}
+
+// ToMetric converts the protobuf representation to a metric.
+func ToMetric(pm *Metric) (telegraf.Metric, error) {
+	fields := make(map[string]interface{}, len(pm.Fields))
+	for key, value := range pm.Fields {
+		switch v := value.GetValue().(type) {
+		case *FieldValue_Float:
+			fields[key] = v.Float
+		case *FieldValue_Int:
+			fields[key] = v.Int
+		case *FieldValue_Uint:
+			fields[key] = v.Uint
+		case *FieldValue_String_:
+			fields[key] = v.String_
+		case *FieldValue_Bool:
+			fields[key] = v.Bool
+		default:
+			return nil, fmt.Errorf("field %q of metric %q has no value", key, pm.Name)
+		}
+	}
+
+	tp, err := toValueType(pm.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	return metric.New(pm.Name, pm.Tags, fields, time.Unix(0, pm.Time), tp)
+}
+
+func fromValueType(tp telegraf.ValueType) ValueType {
+	switch tp {
+	case telegraf.Counter:
+		return ValueType_COUNTER
+	case telegraf.Gauge:
+		return ValueType_GAUGE
+	case telegraf.Summary:
+		return ValueType_SUMMARY
+	case telegraf.Histogram:
+		return ValueType_HISTOGRAM
+	default:
+		return ValueType_UNTYPED
+	}
+}
+
+func toValueType(tp ValueType) (telegraf.ValueType, error) {
+	switch tp {
+	case ValueType_UNTYPED:
+		return telegraf.Untyped, nil
+	case ValueType_COUNTER:
+		return telegraf.Counter, nil
+	case ValueType_GAUGE:
+		return telegraf.Gauge, nil
+	case ValueType_SUMMARY:
+		return telegraf.Summary, nil
+	case ValueType_HISTOGRAM:
+		return telegraf.Histogram, nil
+	default:
+		return telegraf.Untyped, fmt.Errorf("unknown value type %d", tp)
+	}
+}
@@ -0,0 +1,82 @@
+package shim
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+// BatchAccumulator is the accumulator passed to the inputs.  Metrics added
+// between BeginBatch and EndBatch are written with a single flush when the
+// batch ends.  The metrics of each Gather call are batched automatically, so
+// only service inputs need to delimit their batches.
+type BatchAccumulator interface {
+	telegraf.Accumulator
+
+	BeginBatch()
+	EndBatch()
+}
+
+// batchMarker delimits a batch in the metric channel, keeping the order with
+// the metrics.
+type batchMarker struct {
+	telegraf.Metric
+	end bool
+}
+
+type batchAccumulator struct {
+	telegraf.Accumulator
+	metricCh chan<- telegraf.Metric
+}
+
+func (a *batchAccumulator) BeginBatch() {
+	a.metricCh <- &batchMarker{}
+}
+
+func (a *batchAccumulator) EndBatch() {
+	a.metricCh <- &batchMarker{end: true}
+}
+
+// metricWriter writes metrics to the execd input.  Written metrics may be
+// buffered until flush is called.
+type metricWriter interface {
+	write(m telegraf.Metric) error
+	flush() error
+}
+
+// writeBufferSize is the size of the stdout buffer, larger batches are
+// written in multiple chunks.
+const writeBufferSize = 64 * 1024
+
+// lineWriter writes metrics in line protocol to stdout.
+type lineWriter struct {
+	w          *bufio.Writer
+	serializer *influx.Serializer
+}
+
+func newLineWriter(w io.Writer) *lineWriter {
+	return &lineWriter{
+		w:          bufio.NewWriterSize(w, writeBufferSize),
+		serializer: influx.NewSerializer(),
+	}
+}
+
+func (l *lineWriter) write(m telegraf.Metric) error {
+	b, err := l.serializer.Serialize(m)
+	if err != nil {
+		return err
+	}
+	_, err = l.w.Write(b)
+	return err
+}
+
+func (l *lineWriter) writeString(s string) error {
+	_, err := l.w.WriteString(s)
+	return err
+}
+
+func (l *lineWriter) flush() error {
+	return l.w.Flush()
+}
@@ -18,7 +18,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/agent"
 	"github.com/influxdata/telegraf/plugins/inputs"
-	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/plugins/inputs/execd/rpc"
 )
 
 type empty struct{}
@@ -37,14 +37,27 @@ const (
 	// PollIntervalDisabled is used to indicate that you want to disable polling,
 	// as opposed to duration 0 meaning poll constantly.
 	PollIntervalDisabled = time.Duration(0)
+
+	// healthPing is sent by the execd input to check the process is alive,
+	// it is answered with healthPong instead of collecting metrics.
+	healthPing = "# ping"
+	healthPong = "# pong\n"
+
+	// EnvTriggerPipe is the environment variable holding the name of the pipe
+	// the execd input connects to for requesting a metric collection.  Only
+	// used on Windows.
+	EnvTriggerPipe = "TELEGRAF_EXECD_TRIGGER_PIPE"
 )
 
 // Shim allows you to wrap your inputs and run them as if they were part of Telegraf,
 // except built externally.
 type Shim struct {
 	Inputs            []telegraf.Input
+	Processor         telegraf.StreamingProcessor
+	Output            telegraf.Output
 	gatherPromptChans []chan empty
 	metricCh          chan telegraf.Metric
+	pingCh            chan empty
 }
 
 // New creates a new shim interface
@@ -83,6 +96,18 @@ func (s *Shim) Run(pollInterval time.Duration) error {
 	defer cancel()
 
 	s.metricCh = make(chan telegraf.Metric, 1)
+	s.pingCh = make(chan empty, 1)
+
+	// The execd input passes the address when using the gRPC transport.
+	var client *grpcClient
+	if address := os.Getenv(rpc.EnvAddress); address != "" {
+		var err error
+		client, err = dialExecd(address, os.Getenv(rpc.EnvToken))
+		if err != nil {
+			return fmt.Errorf("failed to connect to %s: %s", address, err)
+		}
+		defer client.close()
+	}
 
 	wg := sync.WaitGroup{}
 	quit := make(chan os.Signal, 1)
@@ -91,13 +116,18 @@ func (s *Shim) Run(pollInterval time.Duration) error {
 	collectMetricsPrompt := make(chan os.Signal, 1)
 	listenForCollectMetricsSignals(ctx, collectMetricsPrompt)
 
-	serializer := influx.NewSerializer()
+	lines := newLineWriter(stdout)
+	var out metricWriter = lines
+	if client != nil {
+		out = client
+	}
 
 	for _, input := range s.Inputs {
 		wrappedInput := inputShim{Input: input}
 
-		acc := agent.NewAccumulator(wrappedInput, s.metricCh)
-		acc.SetPrecision(time.Nanosecond)
+		agentAcc := agent.NewAccumulator(wrappedInput, s.metricCh)
+		agentAcc.SetPrecision(time.Nanosecond)
+		acc := &batchAccumulator{Accumulator: agentAcc, metricCh: s.metricCh}
 
 		if serviceInput, ok := input.(telegraf.ServiceInput); ok {
 			if err := serviceInput.Start(acc); err != nil {
@@ -117,9 +147,16 @@ func (s *Shim) Run(pollInterval time.Duration) error {
 		}(input)
 	}
 
-	go s.stdinCollectMetricsPrompt(ctx, cancel, collectMetricsPrompt)
+	if client != nil {
+		go client.receive(cancel, collectMetricsPrompt)
+	} else {
+		go s.stdinCollectMetricsPrompt(ctx, cancel, collectMetricsPrompt)
+	}
 	go s.closeMetricChannelWhenInputsFinish(&wg)
 
+	// number of batches currently open
+	batches := 0
+
 loop:
 	for {
 		select {
@@ -132,20 +169,40 @@ loop:
 				continue
 			}
 			s.collectMetrics(ctx)
+		case <-s.pingCh:
+			// answer from this loop so the reply is not interleaved with a metric
+			if err := lines.writeString(healthPong); err != nil {
+				return fmt.Errorf("failed to answer health check: %s", err)
+			}
+			if err := lines.flush(); err != nil {
+				return fmt.Errorf("failed to answer health check: %s", err)
+			}
 		case m, open := <-s.metricCh:
 			if !open {
 				break loop
 			}
-			b, err := serializer.Serialize(m)
-			if err != nil {
-				return fmt.Errorf("failed to serialize metric: %s", err)
+
+			if marker, ok := m.(*batchMarker); ok {
+				if marker.end {
+					batches--
+				} else {
+					batches++
+				}
+			} else if err := out.write(m); err != nil {
+				return fmt.Errorf("failed to write metric: %s", err)
+			}
+
+			// Flush once no batch is open and no further metrics are waiting.
+			if batches <= 0 && len(s.metricCh) == 0 {
+				batches = 0
+				if err := out.flush(); err != nil {
+					return fmt.Errorf("failed to write metrics: %s", err)
+				}
 			}
-			// Write this to stdout
-			fmt.Fprint(stdout, string(b))
 		}
 	}
 
-	return nil
+	return out.flush()
 }
 
 func hasQuit(ctx context.Context) bool {
@@ -172,6 +229,14 @@ func (s *Shim) stdinCollectMetricsPrompt(ctx context.Context, cancel context.Can
 			return
 		}
 
+		if scanner.Text() == healthPing {
+			select {
+			case s.pingCh <- empty{}:
+			default:
+			}
+			continue
+		}
+
 		// now push a non-blocking message to trigger metric collection.
 		pushCollectMetricsRequest(collectMetricsPrompt)
 	}
@@ -201,7 +266,7 @@ func (s *Shim) collectMetrics(ctx context.Context) {
 	}
 }
 
-func startGathering(ctx context.Context, input telegraf.Input, acc telegraf.Accumulator, gatherPromptCh <-chan empty, pollInterval time.Duration) {
+func startGathering(ctx context.Context, input telegraf.Input, acc BatchAccumulator, gatherPromptCh <-chan empty, pollInterval time.Duration) {
 	if pollInterval == PollIntervalDisabled {
 		return // don't poll
 	}
@@ -217,17 +282,23 @@ func startGathering(ctx context.Context, input telegraf.Input, acc telegraf.Accu
 		case <-ctx.Done():
 			return
 		case <-gatherPromptCh:
-			if err := input.Gather(acc); err != nil {
-				fmt.Fprintf(os.Stderr, "failed to gather metrics: %s", err)
-			}
+			gather(input, acc)
 		case <-t.C:
-			if err := input.Gather(acc); err != nil {
-				fmt.Fprintf(os.Stderr, "failed to gather metrics: %s", err)
-			}
+			gather(input, acc)
 		}
 	}
 }
 
+// gather collects the metrics of the input as a single batch.
+func gather(input telegraf.Input, acc BatchAccumulator) {
+	acc.BeginBatch()
+	defer acc.EndBatch()
+
+	if err := input.Gather(acc); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to gather metrics: %s", err)
+	}
+}
+
 // LoadConfig loads and adds the inputs to the shim
 func (s *Shim) LoadConfig(filePath *string) error {
 	loadedInputs, err := LoadConfig(filePath)
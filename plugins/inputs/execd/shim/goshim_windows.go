@@ -4,9 +4,12 @@ package shim
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+
+	"github.com/Microsoft/go-winio"
 )
 
 func listenForCollectMetricsSignals(ctx context.Context, collectMetricsPrompt chan os.Signal) {
@@ -19,4 +22,41 @@ func listenForCollectMetricsSignals(ctx context.Context, collectMetricsPrompt ch
 			signal.Stop(collectMetricsPrompt)
 		}
 	}()
+
+	if name := os.Getenv(EnvTriggerPipe); name != "" {
+		if err := listenForCollectMetricsPipe(ctx, name, collectMetricsPrompt); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to listen on pipe %s: %s\n", name, err)
+		}
+	}
+}
+
+// listenForCollectMetricsPipe triggers a metric collection for every
+// connection to the named pipe.
+func listenForCollectMetricsPipe(ctx context.Context, name string, collectMetricsPrompt chan<- os.Signal) error {
+	listener, err := winio.ListenPipe(name, nil)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+
+			// don't push messages to a closed channel
+			if hasQuit(ctx) {
+				return
+			}
+			pushCollectMetricsRequest(collectMetricsPrompt)
+		}
+	}()
+	return nil
 }
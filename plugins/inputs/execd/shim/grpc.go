@@ -0,0 +1,129 @@
+package shim
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs/execd/rpc"
+	"google.golang.org/grpc"
+)
+
+// grpcClient sends the metrics to the execd input over gRPC instead of
+// writing them to stdout.
+type grpcClient struct {
+	conn   *grpc.ClientConn
+	stream rpc.Execd_ConnectClient
+
+	batch []*rpc.Metric
+
+	mu      sync.Mutex
+	lastID  uint64
+	tracked map[uint64]telegraf.Metric
+}
+
+// dialExecd connects to the execd input.  The stream is not bound to a
+// context, so metrics can still be sent while the shim is quitting.
+func dialExecd(address, token string) (*grpcClient, error) {
+	conn, err := grpc.Dial(address, grpc.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := rpc.NewExecdClient(conn).Connect(context.Background())
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	hello := &rpc.PluginMessage{Message: &rpc.PluginMessage_Hello{Hello: &rpc.Hello{
+		Token:     token,
+		Precision: int64(time.Nanosecond),
+	}}}
+	if err := stream.Send(hello); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &grpcClient{
+		conn:    conn,
+		stream:  stream,
+		tracked: make(map[uint64]telegraf.Metric),
+	}, nil
+}
+
+// write adds the metric to the batch, requesting a delivery report for
+// tracking metrics.
+func (c *grpcClient) write(m telegraf.Metric) error {
+	pm := rpc.FromMetric(m)
+	if _, ok := m.(interface{ TrackingID() telegraf.TrackingID }); ok {
+		c.mu.Lock()
+		c.lastID++
+		pm.TrackingId = c.lastID
+		c.tracked[pm.TrackingId] = m
+		c.mu.Unlock()
+	}
+
+	c.batch = append(c.batch, pm)
+	return nil
+}
+
+// flush sends the batch in a single message.
+func (c *grpcClient) flush() error {
+	if len(c.batch) == 0 {
+		return nil
+	}
+
+	batch := &rpc.PluginMessage{Message: &rpc.PluginMessage_Metrics{Metrics: &rpc.MetricBatch{
+		Metrics: c.batch,
+	}}}
+	c.batch = nil
+	return c.stream.Send(batch)
+}
+
+// receive handles the messages of the execd input until the stream is
+// closed, which quits the shim just like closing stdin.
+func (c *grpcClient) receive(cancel context.CancelFunc, collectMetricsPrompt chan<- os.Signal) {
+	defer func() {
+		cancel()
+		close(collectMetricsPrompt)
+	}()
+
+	for {
+		msg, err := c.stream.Recv()
+		if err != nil {
+			return
+		}
+
+		switch {
+		case msg.GetGather() != nil:
+			pushCollectMetricsRequest(collectMetricsPrompt)
+		case msg.GetDelivery() != nil:
+			c.delivered(msg.GetDelivery())
+		}
+	}
+}
+
+func (c *grpcClient) delivered(delivery *rpc.Delivery) {
+	c.mu.Lock()
+	m, ok := c.tracked[delivery.TrackingId]
+	delete(c.tracked, delivery.TrackingId)
+	c.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	if delivery.Delivered {
+		m.Accept()
+	} else {
+		m.Reject()
+	}
+}
+
+func (c *grpcClient) close() {
+	c.stream.CloseSend()
+	c.conn.Close()
+}
@@ -0,0 +1,122 @@
+package shim
+
+import (
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/inputs/execd/rpc"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type testExecdServer struct {
+	streams chan rpc.Execd_ConnectServer
+}
+
+func (s *testExecdServer) Connect(stream rpc.Execd_ConnectServer) error {
+	s.streams <- stream
+	<-stream.Context().Done()
+	return nil
+}
+
+func TestShimGRPCTransport(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	execd := &testExecdServer{streams: make(chan rpc.Execd_ConnectServer, 1)}
+	server := grpc.NewServer()
+	rpc.RegisterExecdServer(server, execd)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	os.Setenv(rpc.EnvAddress, listener.Addr().String())
+	os.Setenv(rpc.EnvToken, "secret")
+	defer os.Unsetenv(rpc.EnvAddress)
+	defer os.Unsetenv(rpc.EnvToken)
+
+	inp := &trackingInput{delivered: make(chan bool, 1)}
+	shim := New()
+	require.NoError(t, shim.AddInput(inp))
+
+	exited := make(chan error)
+	go func() {
+		exited <- shim.Run(40 * time.Second)
+	}()
+
+	stream := <-execd.streams
+	msg, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, &rpc.Hello{Token: "secret", Precision: 1}, msg.GetHello())
+
+	// Gather over the stream
+	require.NoError(t, stream.Send(&rpc.AgentMessage{Message: &rpc.AgentMessage_Gather{Gather: &rpc.Gather{}}}))
+
+	msg, err = stream.Recv()
+	require.NoError(t, err)
+	require.Len(t, msg.GetMetrics().GetMetrics(), 1)
+	m := msg.GetMetrics().GetMetrics()[0]
+	require.Equal(t, "measurement", m.Name)
+	require.Equal(t, map[string]string{"tag": "tag"}, m.Tags)
+	require.Equal(t, int64(1), m.Fields["field"].GetInt())
+	require.Equal(t, time.Unix(1234, 5678).UnixNano(), m.Time)
+	require.NotZero(t, m.TrackingId)
+
+	// Report the delivery to the input
+	require.NoError(t, stream.Send(&rpc.AgentMessage{Message: &rpc.AgentMessage_Delivery{Delivery: &rpc.Delivery{
+		TrackingId: m.TrackingId,
+		Delivered:  true,
+	}}}))
+
+	select {
+	case delivered := <-inp.delivered:
+		require.True(t, delivered)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timeout waiting for delivery")
+	}
+
+	// Closing the stream quits the shim
+	server.Stop()
+	require.NoError(t, <-exited)
+}
+
+type trackingInput struct {
+	acc       telegraf.TrackingAccumulator
+	delivered chan bool
+}
+
+func (i *trackingInput) SampleConfig() string {
+	return ""
+}
+
+func (i *trackingInput) Description() string {
+	return ""
+}
+
+func (i *trackingInput) Start(acc telegraf.Accumulator) error {
+	i.acc = acc.WithTracking(1)
+	go func() {
+		for info := range i.acc.Delivered() {
+			i.delivered <- info.Delivered()
+		}
+	}()
+	return nil
+}
+
+func (i *trackingInput) Gather(acc telegraf.Accumulator) error {
+	m, err := metric.New("measurement",
+		map[string]string{"tag": "tag"},
+		map[string]interface{}{"field": int64(1)},
+		time.Unix(1234, 5678))
+	if err != nil {
+		return err
+	}
+	i.acc.AddTrackingMetric(m)
+	return nil
+}
+
+func (i *trackingInput) Stop() {
+}
@@ -0,0 +1,204 @@
+package shim
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// Result codes reported on stderr after each batch written by an output.
+const (
+	// ResultOK reports the batch was written.
+	ResultOK = "# ok"
+	// ResultError prefixes the report of a batch that was not written, it is
+	// followed by the error code and message.
+	ResultError = "# error"
+
+	// CodeRetry tells to send the batch again later.
+	CodeRetry = "retry"
+	// CodeDrop tells to drop the batch, it cannot be written.
+	CodeDrop = "drop"
+)
+
+var stderr io.Writer = os.Stderr
+
+// dropError is an error after which the batch must not be retried.
+type dropError struct {
+	err error
+}
+
+func (e *dropError) Error() string {
+	return e.err.Error()
+}
+
+// DropError marks the error returned by the Write method of an output as
+// permanent, the batch is then dropped instead of being sent again.
+func DropError(err error) error {
+	return &dropError{err: err}
+}
+
+// AddOutput adds the output to the shim.  Later calls to RunOutput() will
+// run this output.
+func (s *Shim) AddOutput(output telegraf.Output) error {
+	if s.Output != nil {
+		return errors.New("only one output can be run")
+	}
+
+	if p, ok := output.(telegraf.Initializer); ok {
+		err := p.Init()
+		if err != nil {
+			return fmt.Errorf("failed to init output: %s", err)
+		}
+	}
+
+	s.Output = output
+	return nil
+}
+
+// RunOutput writes the metrics read in line protocol from stdin with the
+// output.  The metrics of a batch are followed by a FrameEnd line, the
+// result of the batch is then reported on stderr with a ResultOK line, or a
+// ResultError line followed by CodeRetry or CodeDrop and the error message.
+// The output is connected before writing the first batch, and closed once
+// stdin is closed.
+func (s *Shim) RunOutput() error {
+	if s.Output == nil {
+		return errors.New("no output to run")
+	}
+
+	parser, err := parsers.NewInfluxParser()
+	if err != nil {
+		return err
+	}
+
+	connected := false
+	defer func() {
+		if connected {
+			if err := s.Output.Close(); err != nil {
+				fmt.Fprintf(stderr, "failed to close output: %s\n", err)
+			}
+		}
+	}()
+
+	var batch []telegraf.Metric
+	scanner := bufio.NewScanner(stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		switch scanner.Text() {
+		case healthPing:
+			if _, err := io.WriteString(stdout, healthPong); err != nil {
+				return err
+			}
+			continue
+		case FrameEnd:
+			if !connected {
+				if err := s.Output.Connect(); err != nil {
+					reportResult(fmt.Errorf("connect: %s", err))
+					batch = nil
+					continue
+				}
+				connected = true
+			}
+
+			reportResult(s.Output.Write(batch))
+			batch = nil
+			continue
+		}
+
+		metrics, err := parser.Parse(scanner.Bytes())
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to parse metric: %s\n", err)
+		}
+		batch = append(batch, metrics...)
+	}
+	return scanner.Err()
+}
+
+// reportResult writes the result of a batch to stderr.
+func reportResult(err error) {
+	if err == nil {
+		fmt.Fprintln(stderr, ResultOK)
+		return
+	}
+
+	code := CodeRetry
+	if _, ok := err.(*dropError); ok {
+		code = CodeDrop
+	}
+	// The message must fit on the line
+	msg := strings.Replace(err.Error(), "\n", " ", -1)
+	fmt.Fprintf(stderr, "%s %s %s\n", ResultError, code, msg)
+}
+
+// LoadOutputConfig loads and adds the output of the config file to the shim.
+// If no config file is given, the only output imported is used.
+func (s *Shim) LoadOutputConfig(filePath *string) error {
+	if filePath == nil || *filePath == "" {
+		if len(outputs.Outputs) != 1 {
+			return errors.New("a config file is needed unless exactly one output is imported")
+		}
+		for _, creator := range outputs.Outputs {
+			return s.AddOutput(creator())
+		}
+	}
+
+	b, err := ioutil.ReadFile(*filePath)
+	if err != nil {
+		return err
+	}
+
+	conf := struct {
+		Outputs map[string][]toml.Primitive
+	}{}
+
+	md, err := toml.Decode(expandEnvVars(b), &conf)
+	if err != nil {
+		return err
+	}
+
+	if len(conf.Outputs) != 1 {
+		return errors.New("the config file must define exactly one output")
+	}
+	for name, primitives := range conf.Outputs {
+		creator, ok := outputs.Outputs[name]
+		if !ok {
+			return errors.New("unknown output " + name)
+		}
+		if len(primitives) != 1 {
+			return errors.New("the config file must define exactly one output")
+		}
+
+		output := creator()
+		if err := md.PrimitiveDecode(primitives[0], output); err != nil {
+			return err
+		}
+
+		if o, ok := output.(serializers.SerializerOutput); ok {
+			c := &serializers.Config{DataFormat: "influx", TimestampUnits: time.Second}
+			if err := md.PrimitiveDecode(primitives[0], c); err != nil {
+				return err
+			}
+			serializer, err := serializers.NewSerializer(c)
+			if err != nil {
+				return err
+			}
+			o.SetSerializer(serializer)
+		}
+		if len(md.Undecoded()) > 0 {
+			fmt.Fprintf(stderr, "Some plugins were loaded but not used: %q\n", md.Undecoded())
+		}
+		return s.AddOutput(output)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package shim
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+func TestOutputShim(t *testing.T) {
+	stdinReader, stdinWriter := io.Pipe()
+	stderrReader, stderrWriter := io.Pipe()
+
+	stdin = stdinReader
+	stderr = stderrWriter
+	defer func() { stderr = os.Stderr }()
+
+	o := &testOutput{}
+	s := New()
+	require.NoError(t, s.AddOutput(o))
+
+	exited := make(chan error)
+	go func() {
+		exited <- s.RunOutput()
+		stderrWriter.Close()
+	}()
+
+	r := bufio.NewReader(stderrReader)
+
+	stdinWriter.Write([]byte("measurement,tag=tag field=1i 1234000005678\nmeasurement field=2i 1234000005678\n# end\n"))
+	out, err := r.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, ResultOK+"\n", out)
+	require.Len(t, o.metrics, 2)
+
+	o.err = DropError(errors.New("invalid\nmetric"))
+	stdinWriter.Write([]byte("measurement field=3i 1234000005678\n# end\n"))
+	out, err = r.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "# error drop invalid metric\n", out)
+
+	o.err = errors.New("unavailable")
+	stdinWriter.Write([]byte("measurement field=4i 1234000005678\n# end\n"))
+	out, err = r.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "# error retry unavailable\n", out)
+
+	stdinWriter.Close()
+	readUntilEmpty(r)
+	require.NoError(t, <-exited)
+	require.Equal(t, 1, o.connects)
+	require.True(t, o.closed)
+}
+
+func TestLoadOutputConfig(t *testing.T) {
+	outputs.Add("test", func() telegraf.Output {
+		return &testOutput{}
+	})
+	defer delete(outputs.Outputs, "test")
+
+	dir, err := ioutil.TempDir("", "shim")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := filepath.Join(dir, "output.conf")
+	require.NoError(t, ioutil.WriteFile(conf, []byte("[[outputs.test]]\n  database = \"db\"\n  data_format = \"json\"\n"), 0640))
+
+	s := New()
+	require.NoError(t, s.LoadOutputConfig(&conf))
+
+	o := s.Output.(*testOutput)
+	require.Equal(t, "db", o.Database)
+	require.NotNil(t, o.serializer)
+}
+
+type testOutput struct {
+	Database string `toml:"database"`
+
+	serializer serializers.Serializer
+	metrics    []telegraf.Metric
+	err        error
+	connects   int
+	closed     bool
+}
+
+func (o *testOutput) SetSerializer(serializer serializers.Serializer) {
+	o.serializer = serializer
+}
+
+func (o *testOutput) Connect() error {
+	o.connects++
+	return nil
+}
+
+func (o *testOutput) Close() error {
+	o.closed = true
+	return nil
+}
+
+func (o *testOutput) Description() string {
+	return ""
+}
+
+func (o *testOutput) SampleConfig() string {
+	return ""
+}
+
+func (o *testOutput) Write(metrics []telegraf.Metric) error {
+	if o.err != nil {
+		return o.err
+	}
+	o.metrics = append(o.metrics, metrics...)
+	return nil
+}
@@ -0,0 +1,215 @@
+package shim
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/agent"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+// FrameEnd is the line written after the metrics returned for each metric
+// read on stdin when running a processor, so that the reader knows the
+// metric is processed even when no or several metrics are returned.  It is a
+// comment in line protocol.
+const FrameEnd = "# end"
+
+// maxLineSize is the size of the longest line read from stdin.
+const maxLineSize = 1024 * 1024
+
+// AddProcessor adds the processor to the shim.  Later calls to
+// RunProcessor() will run this processor.
+func (s *Shim) AddProcessor(processor telegraf.Processor) error {
+	return s.AddStreamingProcessor(processors.NewStreamingProcessorFromProcessor(processor))
+}
+
+// AddStreamingProcessor adds the streaming processor to the shim.  Later
+// calls to RunProcessor() will run this processor.
+func (s *Shim) AddStreamingProcessor(processor telegraf.StreamingProcessor) error {
+	if s.Processor != nil {
+		return errors.New("only one processor can be run")
+	}
+
+	if p, ok := processor.(telegraf.Initializer); ok {
+		err := p.Init()
+		if err != nil {
+			return fmt.Errorf("failed to init processor: %s", err)
+		}
+	}
+
+	s.Processor = processor
+	return nil
+}
+
+// pongMarker requests the answer of a health check in the metric channel,
+// keeping the order with the metrics.
+type pongMarker struct {
+	telegraf.Metric
+}
+
+// RunProcessor runs the processor on the metrics read in line protocol from
+// stdin, and writes the metrics it returns in line protocol to stdout.  The
+// metrics returned for each metric read are followed by a FrameEnd line.
+// The processor is stopped once stdin is closed.
+func (s *Shim) RunProcessor() error {
+	if s.Processor == nil {
+		return errors.New("no processor to run")
+	}
+
+	parser, err := parsers.NewInfluxParser()
+	if err != nil {
+		return err
+	}
+
+	s.metricCh = make(chan telegraf.Metric, 1)
+	acc := agent.NewAccumulator(processorShim{}, s.metricCh)
+	acc.SetPrecision(time.Nanosecond)
+
+	if err := s.Processor.Start(acc); err != nil {
+		return fmt.Errorf("failed to start processor: %s", err)
+	}
+
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- s.readProcessorInput(parser, acc)
+		if err := s.Processor.Stop(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to stop processor: %s\n", err)
+		}
+		close(s.metricCh)
+	}()
+
+	lines := newLineWriter(stdout)
+	for m := range s.metricCh {
+		switch m.(type) {
+		case *batchMarker:
+			err = lines.writeString(FrameEnd + "\n")
+		case *pongMarker:
+			err = lines.writeString(healthPong)
+		default:
+			err = lines.write(m)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write metric: %s", err)
+		}
+
+		// Flush once no further metrics are waiting.
+		if len(s.metricCh) == 0 {
+			if err := lines.flush(); err != nil {
+				return fmt.Errorf("failed to write metrics: %s", err)
+			}
+		}
+	}
+
+	if err := lines.flush(); err != nil {
+		return err
+	}
+	return <-readErr
+}
+
+func (s *Shim) readProcessorInput(parser parsers.Parser, acc telegraf.Accumulator) error {
+	scanner := bufio.NewScanner(stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		if scanner.Text() == healthPing {
+			s.metricCh <- &pongMarker{}
+			continue
+		}
+
+		metrics, err := parser.Parse(scanner.Bytes())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to parse metric: %s\n", err)
+		}
+		for _, m := range metrics {
+			s.Processor.Add(m, acc)
+		}
+
+		// Empty lines are not answered, they are not metrics.
+		if len(metrics) > 0 || err != nil {
+			s.metricCh <- &batchMarker{end: true}
+		}
+	}
+	return scanner.Err()
+}
+
+// processorShim implements the MetricMaker interface.
+type processorShim struct{}
+
+func (processorShim) LogName() string {
+	return ""
+}
+
+func (processorShim) MakeMetric(m telegraf.Metric) telegraf.Metric {
+	return m // don't need to do anything to it.
+}
+
+func (processorShim) Log() telegraf.Logger {
+	return nil
+}
+
+// unwrappable is implemented by the streaming processors wrapping a
+// processor, the configuration is decoded into the wrapped processor.
+type unwrappable interface {
+	Unwrap() telegraf.Processor
+}
+
+// LoadProcessorConfig loads and adds the processor of the config file to the
+// shim.  If no config file is given, the only processor imported is used.
+func (s *Shim) LoadProcessorConfig(filePath *string) error {
+	if filePath == nil || *filePath == "" {
+		if len(processors.Processors) != 1 {
+			return errors.New("a config file is needed unless exactly one processor is imported")
+		}
+		for _, creator := range processors.Processors {
+			return s.AddStreamingProcessor(creator())
+		}
+	}
+
+	b, err := ioutil.ReadFile(*filePath)
+	if err != nil {
+		return err
+	}
+
+	conf := struct {
+		Processors map[string][]toml.Primitive
+	}{}
+
+	md, err := toml.Decode(expandEnvVars(b), &conf)
+	if err != nil {
+		return err
+	}
+
+	if len(conf.Processors) != 1 {
+		return errors.New("the config file must define exactly one processor")
+	}
+	for name, primitives := range conf.Processors {
+		creator, ok := processors.Processors[name]
+		if !ok {
+			return errors.New("unknown processor " + name)
+		}
+		if len(primitives) != 1 {
+			return errors.New("the config file must define exactly one processor")
+		}
+
+		processor := creator()
+		var target interface{} = processor
+		if p, ok := processor.(unwrappable); ok {
+			target = p.Unwrap()
+		}
+		if err := md.PrimitiveDecode(primitives[0], target); err != nil {
+			return err
+		}
+
+		if len(md.Undecoded()) > 0 {
+			fmt.Fprintf(os.Stderr, "Some plugins were loaded but not used: %q\n", md.Undecoded())
+		}
+		return s.AddStreamingProcessor(processor)
+	}
+	return nil
+}
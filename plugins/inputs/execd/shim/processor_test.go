@@ -0,0 +1,108 @@
+package shim
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+func TestProcessorShim(t *testing.T) {
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+
+	stdin = stdinReader
+	stdout = stdoutWriter
+
+	s := New()
+	require.NoError(t, s.AddProcessor(&testProcessor{Copies: 2}))
+
+	exited := make(chan error)
+	go func() {
+		exited <- s.RunProcessor()
+		stdoutWriter.Close()
+	}()
+
+	r := bufio.NewReader(stdoutReader)
+
+	stdinWriter.Write([]byte("measurement,tag=tag field=1i 1234000005678\n"))
+	for i := 0; i < 2; i++ {
+		out, err := r.ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, "measurement,processed=yes,tag=tag field=1i 1234000005678\n", out)
+	}
+	out, err := r.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, FrameEnd+"\n", out)
+
+	// A dropped metric is still answered with the end of its frame
+	stdinWriter.Write([]byte("drop field=1i 1234000005678\n"))
+	out, err = r.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, FrameEnd+"\n", out)
+
+	stdinWriter.Write([]byte("# ping\n"))
+	out, err = r.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "# pong\n", out)
+
+	stdinWriter.Close()
+	readUntilEmpty(r)
+	require.NoError(t, <-exited)
+}
+
+func TestLoadProcessorConfig(t *testing.T) {
+	processors.Add("test", func() telegraf.Processor {
+		return &testProcessor{}
+	})
+	defer delete(processors.Processors, "test")
+
+	dir, err := ioutil.TempDir("", "shim")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := filepath.Join(dir, "processor.conf")
+	require.NoError(t, ioutil.WriteFile(conf, []byte("[[processors.test]]\n  copies = 3\n"), 0640))
+
+	s := New()
+	require.NoError(t, s.LoadProcessorConfig(&conf))
+
+	p, ok := s.Processor.(unwrappable)
+	require.True(t, ok)
+	require.Equal(t, 3, p.Unwrap().(*testProcessor).Copies)
+}
+
+type testProcessor struct {
+	Copies int `toml:"copies"`
+}
+
+func (p *testProcessor) SampleConfig() string {
+	return ""
+}
+
+func (p *testProcessor) Description() string {
+	return ""
+}
+
+func (p *testProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	var out []telegraf.Metric
+	for _, m := range in {
+		if m.Name() == "drop" {
+			m.Drop()
+			continue
+		}
+		m.AddTag("processed", "yes")
+		out = append(out, m)
+		for i := 1; i < p.Copies; i++ {
+			out = append(out, m.Copy())
+		}
+	}
+	return out
+}
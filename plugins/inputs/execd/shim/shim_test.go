@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -61,6 +62,98 @@ func TestShimStdinSignalingWorks(t *testing.T) {
 	<-exited
 }
 
+func TestShimAnswersHealthPing(t *testing.T) {
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+
+	stdin = stdinReader
+	stdout = stdoutWriter
+
+	_, exited := runInputPlugin(t, 40*time.Second)
+
+	stdinWriter.Write([]byte("# ping\n"))
+
+	r := bufio.NewReader(stdoutReader)
+	out, err := r.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "# pong\n", out)
+
+	stdinWriter.Close()
+
+	readUntilEmpty(r)
+
+	<-exited
+}
+
+func TestShimWritesGatherAsBatch(t *testing.T) {
+	stdinReader, stdinWriter := io.Pipe()
+	out := &writeRecorder{}
+
+	stdin = stdinReader
+	stdout = out
+
+	gathered := make(chan bool, 1)
+	shim := New()
+	require.NoError(t, shim.AddInput(&multiInput{count: 100, gathered: gathered}))
+
+	exited := make(chan bool)
+	go func() {
+		require.NoError(t, shim.Run(40*time.Second))
+		exited <- true
+	}()
+
+	stdinWriter.Write([]byte("\n"))
+	<-gathered
+	stdinWriter.Close()
+	<-exited
+
+	writes := out.get()
+	require.Len(t, writes, 1)
+	require.Equal(t, 100, strings.Count(writes[0], "\n"))
+}
+
+type writeRecorder struct {
+	sync.Mutex
+	writes []string
+}
+
+func (w *writeRecorder) Write(b []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+	w.writes = append(w.writes, string(b))
+	return len(b), nil
+}
+
+func (w *writeRecorder) get() []string {
+	w.Lock()
+	defer w.Unlock()
+	return w.writes
+}
+
+type multiInput struct {
+	count    int
+	gathered chan bool
+}
+
+func (i *multiInput) SampleConfig() string {
+	return ""
+}
+
+func (i *multiInput) Description() string {
+	return ""
+}
+
+func (i *multiInput) Gather(acc telegraf.Accumulator) error {
+	for n := 0; n < i.count; n++ {
+		acc.AddFields("measurement",
+			map[string]interface{}{"field": n},
+			map[string]string{"tag": "tag"},
+			time.Unix(1234, 5678))
+	}
+	i.gathered <- true
+	return nil
+}
+
 func runInputPlugin(t *testing.T, interval time.Duration) (metricProcessed chan bool, exited chan bool) {
 	metricProcessed = make(chan bool, 10)
 	exited = make(chan bool)
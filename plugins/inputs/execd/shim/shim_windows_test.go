@@ -0,0 +1,33 @@
+// +build windows
+
+package shim
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Microsoft/go-winio"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShimPipeTrigger(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	name := `\\.\pipe\telegraf-shim-test`
+	collectMetricsPrompt := make(chan os.Signal, 1)
+	require.NoError(t, listenForCollectMetricsPipe(ctx, name, collectMetricsPrompt))
+
+	timeout := time.Second
+	conn, err := winio.DialPipe(name, &timeout)
+	require.NoError(t, err)
+	conn.Close()
+
+	select {
+	case <-collectMetricsPrompt:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timeout waiting for collect metrics prompt")
+	}
+}
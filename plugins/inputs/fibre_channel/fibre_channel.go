@@ -0,0 +1,32 @@
+package fibre_channel
+
+import (
+	"github.com/influxdata/telegraf"
+)
+
+// FibreChannel is used to store configuration values.
+type FibreChannel struct {
+	HostSys string          `toml:"host_sys"`
+	HBAs    []string        `toml:"hbas"`
+	Log     telegraf.Logger `toml:"-"`
+}
+
+var sampleConfig = `
+  ## Sets 'sys' directory path
+  ## If not specified, then default is /sys
+  # host_sys = "/sys"
+
+  ## By default, telegraf gathers stats for all Fibre Channel hosts.  Setting
+  ## hbas will restrict the stats to the specified hosts.
+  # hbas = ["host1", "host2"]
+`
+
+// Description returns information about the plugin.
+func (fc *FibreChannel) Description() string {
+	return "Gather link state and counters of Fibre Channel host bus adapters"
+}
+
+// SampleConfig displays configuration instructions.
+func (fc *FibreChannel) SampleConfig() string {
+	return sampleConfig
+}
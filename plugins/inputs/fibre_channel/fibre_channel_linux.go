@@ -0,0 +1,160 @@
+// +build linux
+
+package fibre_channel
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// default host sys path
+const defaultHostSys = "/sys"
+
+// env host sys variable name
+const envSys = "HOST_SYS"
+
+// Gather collects the link state and statistics of every Fibre Channel host.
+func (fc *FibreChannel) Gather(acc telegraf.Accumulator) error {
+	// load sys path, get default value if config value and env variable are empty
+	fc.loadPath()
+
+	hosts, err := fc.listHosts()
+	if err != nil {
+		return err
+	}
+	for _, host := range hosts {
+		if err := fc.gatherHost(host, acc); err != nil {
+			acc.AddError(fmt.Errorf("error inspecting Fibre Channel host %q: %v", host, err))
+		}
+	}
+	return nil
+}
+
+func (fc *FibreChannel) listHosts() ([]string, error) {
+	if len(fc.HBAs) > 0 {
+		return fc.HBAs, nil
+	}
+	paths, err := filepath.Glob(filepath.Join(fc.HostSys, "class", "fc_host", "*"))
+	if err != nil {
+		return nil, err
+	}
+	hosts := make([]string, 0, len(paths))
+	for _, p := range paths {
+		hosts = append(hosts, filepath.Base(p))
+	}
+	return hosts, nil
+}
+
+func (fc *FibreChannel) gatherHost(host string, acc telegraf.Accumulator) error {
+	hostPath := filepath.Join(fc.HostSys, "class", "fc_host", host)
+
+	portState, err := readAttribute(hostPath, "port_state")
+	if err != nil {
+		return err
+	}
+
+	tags := map[string]string{"fc_host": host}
+	if portName, err := readAttribute(hostPath, "port_name"); err == nil {
+		tags["port_name"] = portName
+	}
+	if nodeName, err := readAttribute(hostPath, "node_name"); err == nil {
+		tags["node_name"] = nodeName
+	}
+
+	fields := map[string]interface{}{
+		"port_state": portState,
+		"link_up":    portState == "Online",
+	}
+	if speed, err := readAttribute(hostPath, "speed"); err == nil {
+		if gbit, ok := parseSpeed(speed); ok {
+			fields["speed_gbit"] = gbit
+		}
+	}
+
+	statsPath := filepath.Join(hostPath, "statistics")
+	files, err := ioutil.ReadDir(statsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, file := range files {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		// Write-only attributes such as reset_statistics cannot be read and
+		// are skipped along with unsupported counters.
+		value, err := readAttribute(statsPath, file.Name())
+		if err != nil {
+			continue
+		}
+		if v, ok := parseCounter(value); ok {
+			fields[file.Name()] = v
+		}
+	}
+
+	acc.AddFields("fibre_channel", fields, tags)
+	return nil
+}
+
+// readAttribute returns the trimmed content of a sysfs attribute.
+func readAttribute(dir, name string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// parseCounter parses a hexadecimal statistics counter.  Counters not
+// supported by the driver are reported with all bits set.
+func parseCounter(s string) (uint64, bool) {
+	v, err := strconv.ParseUint(s, 0, 64)
+	if err != nil || v == math.MaxUint64 {
+		return 0, false
+	}
+	return v, true
+}
+
+// parseSpeed parses the link speed such as "16 Gbit" into Gbit/s.
+func parseSpeed(s string) (int64, bool) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 || fields[1] != "Gbit" {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// loadPath can be used to read path firstly from config
+// if it is empty then try read from env variable
+func (fc *FibreChannel) loadPath() {
+	if fc.HostSys == "" {
+		fc.HostSys = sys(envSys, defaultHostSys)
+	}
+}
+
+// sys can be used to read file paths from env
+func sys(env, path string) string {
+	// try to read full file path
+	if p := os.Getenv(env); p != "" {
+		return p
+	}
+	// return default path
+	return path
+}
+
+func init() {
+	inputs.Add("fibre_channel", func() telegraf.Input {
+		return &FibreChannel{}
+	})
+}
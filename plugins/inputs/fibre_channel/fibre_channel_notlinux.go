@@ -0,0 +1,23 @@
+// +build !linux
+
+package fibre_channel
+
+import (
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+func (fc *FibreChannel) Init() error {
+	fc.Log.Warn("Current platform is not supported")
+	return nil
+}
+
+func (fc *FibreChannel) Gather(acc telegraf.Accumulator) error {
+	return nil
+}
+
+func init() {
+	inputs.Add("fibre_channel", func() telegraf.Input {
+		return &FibreChannel{}
+	})
+}
@@ -0,0 +1,120 @@
+// +build linux
+
+package fibre_channel
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func writeAttributes(t *testing.T, dir string, attributes map[string]string) {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	for name, value := range attributes {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(value+"\n"), 0644))
+	}
+}
+
+func newSysfs(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "fibre_channel")
+	require.NoError(t, err)
+
+	host1 := filepath.Join(dir, "class", "fc_host", "host1")
+	writeAttributes(t, host1, map[string]string{
+		"port_name":  "0x10000090fa1b2c3d",
+		"node_name":  "0x20000090fa1b2c3d",
+		"port_state": "Online",
+		"speed":      "16 Gbit",
+	})
+	writeAttributes(t, filepath.Join(host1, "statistics"), map[string]string{
+		"tx_frames":            "0x1a2b",
+		"rx_frames":            "0x3c4d",
+		"link_failure_count":   "0x2",
+		"loss_of_signal_count": "0x1",
+		"loss_of_sync_count":   "0x0",
+		"invalid_crc_count":    "0xffffffffffffffff",
+	})
+	// Write-only attribute which must be skipped.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(host1, "statistics", "reset_statistics"), nil, 0200))
+
+	host2 := filepath.Join(dir, "class", "fc_host", "host2")
+	writeAttributes(t, host2, map[string]string{
+		"port_name":  "0x10000090fa1b2c3e",
+		"node_name":  "0x20000090fa1b2c3e",
+		"port_state": "Linkdown",
+		"speed":      "unknown",
+	})
+	writeAttributes(t, filepath.Join(host2, "statistics"), map[string]string{
+		"tx_frames":            "0x0",
+		"rx_frames":            "0x0",
+		"link_failure_count":   "0x5",
+		"loss_of_signal_count": "0x7",
+	})
+	return dir
+}
+
+func TestGather(t *testing.T) {
+	dir := newSysfs(t)
+	defer os.RemoveAll(dir)
+
+	plugin := &FibreChannel{HostSys: dir}
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("fibre_channel",
+			map[string]string{
+				"fc_host":   "host1",
+				"port_name": "0x10000090fa1b2c3d",
+				"node_name": "0x20000090fa1b2c3d",
+			},
+			map[string]interface{}{
+				"port_state":           "Online",
+				"link_up":              true,
+				"speed_gbit":           int64(16),
+				"tx_frames":            uint64(6699),
+				"rx_frames":            uint64(15437),
+				"link_failure_count":   uint64(2),
+				"loss_of_signal_count": uint64(1),
+				"loss_of_sync_count":   uint64(0),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("fibre_channel",
+			map[string]string{
+				"fc_host":   "host2",
+				"port_name": "0x10000090fa1b2c3e",
+				"node_name": "0x20000090fa1b2c3e",
+			},
+			map[string]interface{}{
+				"port_state":           "Linkdown",
+				"link_up":              false,
+				"tx_frames":            uint64(0),
+				"rx_frames":            uint64(0),
+				"link_failure_count":   uint64(5),
+				"loss_of_signal_count": uint64(7),
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestGatherSelectedHBAs(t *testing.T) {
+	dir := newSysfs(t)
+	defer os.RemoveAll(dir)
+
+	plugin := &FibreChannel{HostSys: dir, HBAs: []string{"host2", "host3"}}
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, "host2", acc.Metrics[0].Tags["fc_host"])
+}
@@ -2,21 +2,25 @@ package file
 
 import (
 	"fmt"
-	"io/ioutil"
+	"os"
 	"path/filepath"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/plugins/common/encoding"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
 type File struct {
-	Files   []string `toml:"files"`
-	FileTag string   `toml:"file_tag"`
-	parser  parsers.Parser
+	Files             []string `toml:"files"`
+	FileTag           string   `toml:"file_tag"`
+	Compression       string   `toml:"compression"`
+	CharacterEncoding string   `toml:"character_encoding"`
+	parser            parsers.Parser
 
 	filenames []string
+	decoder   *encoding.Decoder
 }
 
 const sampleConfig = `
@@ -33,6 +37,15 @@ const sampleConfig = `
   ## Name a tag containing the name of the file the data was parsed from.  Leave empty
   ## to disable.
   # file_tag = ""
+
+  ## Compression of the files, one of "none", "gzip", "zstd", or "auto" to
+  ## detect gzip and zstd files from their content.
+  # compression = "none"
+
+  ## Character encoding of the files, decoded to UTF-8 before parsing.  One of
+  ## "utf-8", "utf-16le", "utf-16be", "utf-16" detecting the byte order mark,
+  ## "iso-8859-1" or "windows-1252".  When empty the files are not decoded.
+  # character_encoding = ""
 `
 
 // SampleConfig returns the default configuration of the Input
@@ -44,22 +57,31 @@ func (f *File) Description() string {
 	return "Parse a complete file each interval"
 }
 
+func (f *File) Init() error {
+	if err := encoding.CheckCompression(f.Compression); err != nil {
+		return err
+	}
+
+	var err error
+	f.decoder, err = encoding.NewDecoder(f.CharacterEncoding)
+	return err
+}
+
 func (f *File) Gather(acc telegraf.Accumulator) error {
 	err := f.refreshFilePaths()
 	if err != nil {
 		return err
 	}
 	for _, k := range f.filenames {
-		metrics, err := f.readMetric(k)
-		if err != nil {
-			return err
-		}
-
-		for _, m := range metrics {
+		err := f.readMetric(k, func(m telegraf.Metric) error {
 			if f.FileTag != "" {
 				m.AddTag(f.FileTag, filepath.Base(k))
 			}
 			acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 	}
 	return nil
@@ -87,13 +109,21 @@ func (f *File) refreshFilePaths() error {
 	return nil
 }
 
-func (f *File) readMetric(filename string) ([]telegraf.Metric, error) {
-	fileContents, err := ioutil.ReadFile(filename)
+// readMetric streams the metrics parsed from the file to fn.
+func (f *File) readMetric(filename string, fn func(telegraf.Metric) error) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("E! Error file: %v could not be read, %s", filename, err)
+	}
+	defer file.Close()
+
+	r, err := encoding.NewDecompressor(f.Compression, file)
 	if err != nil {
-		return nil, fmt.Errorf("E! Error file: %v could not be read, %s", filename, err)
+		return fmt.Errorf("decompressing %s: %v", filename, err)
 	}
-	return f.parser.Parse(fileContents)
+	defer r.Close()
 
+	return parsers.ParseStream(f.parser, f.decoder.Reader(r), fn)
 }
 
 func init() {
@@ -1,10 +1,15 @@
 package file
 
 import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
@@ -87,3 +92,80 @@ func TestGrokParser(t *testing.T) {
 	err = r.Gather(&acc)
 	assert.Equal(t, len(acc.Metrics), 2)
 }
+
+func TestCompressedCharacterEncoding(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// A gzip compressed file in ISO-8859-1
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err = w.Write([]byte("cpu,host=caf\xe9 usage_idle=100 1600000000000000000\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	filename := filepath.Join(dir, "metrics.out.gz")
+	require.NoError(t, ioutil.WriteFile(filename, buf.Bytes(), 0644))
+
+	r := File{
+		Files:             []string{filename},
+		Compression:       "auto",
+		CharacterEncoding: "iso-8859-1",
+	}
+	require.NoError(t, r.Init())
+	r.parser, err = parsers.NewInfluxParser()
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+	require.NoError(t, r.Gather(&acc))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "café"},
+			map[string]interface{}{"usage_idle": 100.0},
+			time.Unix(1600000000, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+
+	r.Compression = "lz4"
+	require.Error(t, r.Init())
+}
+
+// The header of each csv file is read from the file.
+func TestCSVHeaderPerFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.csv"), []byte("usage_idle\n90\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "b.csv"), []byte("usage_user\n10\n"), 0644))
+
+	r := File{
+		Files: []string{filepath.Join(dir, "*.csv")},
+	}
+	require.NoError(t, r.Init())
+	r.parser, err = parsers.NewParser(&parsers.Config{
+		DataFormat:        "csv",
+		MetricName:        "cpu",
+		CSVHeaderRowCount: 1,
+	})
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+	require.NoError(t, r.Gather(&acc))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"usage_idle": 90},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"usage_user": 10},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
@@ -213,24 +213,13 @@ func (h *HTTP) gatherURL(
 			h.SuccessStatusCodes)
 	}
 
-	b, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-
-	metrics, err := h.parser.Parse(b)
-	if err != nil {
-		return err
-	}
-
-	for _, metric := range metrics {
+	return parsers.ParseStream(h.parser, resp.Body, func(metric telegraf.Metric) error {
 		if !metric.HasTag("url") {
 			metric.AddTag("url", url)
 		}
 		acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
-	}
-
-	return nil
+		return nil
+	})
 }
 
 func makeRequestBodyReader(contentEncoding, body string) (io.ReadCloser, error) {
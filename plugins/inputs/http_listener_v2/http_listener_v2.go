@@ -4,6 +4,7 @@ import (
 	"compress/gzip"
 	"crypto/subtle"
 	"crypto/tls"
+	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -15,6 +16,8 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/common/ratelimit"
+	"github.com/influxdata/telegraf/plugins/common/tenant"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 )
@@ -46,6 +49,8 @@ type HTTPListenerV2 struct {
 	BasicPassword  string            `toml:"basic_password"`
 	HTTPHeaderTags map[string]string `toml:"http_header_tags"`
 	tlsint.ServerConfig
+	tenant.Config
+	ratelimit.LimiterConfig
 
 	TimeFunc
 	Log telegraf.Logger
@@ -53,6 +58,8 @@ type HTTPListenerV2 struct {
 	wg sync.WaitGroup
 
 	listener net.Listener
+	tenants  *tenant.Tenants
+	limiter  *ratelimit.Limiter
 
 	parsers.Parser
 	acc telegraf.Accumulator
@@ -99,6 +106,41 @@ const sampleConfig = `
   ## If multiple instances of the http header are present, only the first value will be used
   # http_header_tags = {"HTTP_HEADER" = "TAG_NAME"}
 
+  ## Optional source of the tenant of each request, the metrics are tagged with
+  ## the tenant and limited per tenant.  Available options are
+  ## "basic_username", "token_claim" and "tls_cn".
+  # tenant_source = "basic_username"
+
+  ## Tag key holding the tenant.
+  # tenant_tag = "tenant"
+
+  ## Usernames and passwords of the tenants for "basic_username".
+  # tenant_users = {"acme" = "secret"}
+
+  ## Key verifying the JWT bearer tokens for "token_claim", either a PEM
+  ## encoded RSA or ECDSA public key or a file holding a HMAC secret, and the
+  ## claim holding the tenant.
+  # tenant_token_key = "/etc/telegraf/jwt.pem"
+  # tenant_token_claim = "sub"
+
+  ## Maximum number of metrics per second and maximum number of series accepted
+  ## from each tenant, metrics over the limits are rejected.  0 means no limit.
+  # tenant_rate_limit = 0.0
+  # tenant_max_series = 0
+
+  ## Series not received from a tenant for this duration no longer count
+  ## against its tenant_max_series.
+  # tenant_series_ttl = "1h"
+
+  ## Maximum number of requests per second accepted from each source, the
+  ## requests over the limit are answered with 429 Too Many Requests.  The
+  ## source is the client "ip", or the "tenant" when a tenant_source is set.
+  ## The burst is the number of requests accepted at once, by default one
+  ## second worth of the rate.  0 means no limit.
+  # rate_limit = 0.0
+  # rate_limit_burst = 0
+  # rate_limit_key = "ip"
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -137,6 +179,21 @@ func (h *HTTPListenerV2) Start(acc telegraf.Accumulator) error {
 
 	h.acc = acc
 
+	tenants, err := h.Config.Tenants()
+	if err != nil {
+		return err
+	}
+	if tenants.UsesBasicAuth() && h.BasicUsername != "" {
+		return fmt.Errorf("basic_username cannot be used with tenant_source %q", h.TenantSource)
+	}
+	h.tenants = tenants
+
+	limiter, err := h.ratelimit()
+	if err != nil {
+		return err
+	}
+	h.limiter = limiter
+
 	tlsConf, err := h.ServerConfig.TLSConfig()
 	if err != nil {
 		return err
@@ -186,7 +243,19 @@ func (h *HTTPListenerV2) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 		handler = http.NotFound
 	}
 
-	h.authenticateIfSet(handler, res, req)
+	h.tenants.Handler(
+		h.limiter.Handler(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			h.authenticateIfSet(handler, res, req)
+		}), nil),
+		unauthorized,
+	).ServeHTTP(res, req)
+}
+
+func (h *HTTPListenerV2) ratelimit() (*ratelimit.Limiter, error) {
+	tags := map[string]string{
+		"address": h.ServiceAddress,
+	}
+	return h.LimiterConfig.Limiter("http_listener_v2", "requests_rate_limited", tags)
 }
 
 func (h *HTTPListenerV2) serveWrite(res http.ResponseWriter, req *http.Request) {
@@ -230,7 +299,14 @@ func (h *HTTPListenerV2) serveWrite(res http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	limited := false
 	for _, m := range metrics {
+		if err := h.tenants.Apply(req.Context(), m); err != nil {
+			h.Log.Debugf("Dropping metric: %v", err)
+			limited = true
+			continue
+		}
+
 		for headerName, measurementName := range h.HTTPHeaderTags {
 			headerValues, foundHeader := req.Header[headerName]
 			if foundHeader && len(headerValues) > 0 {
@@ -241,6 +317,10 @@ func (h *HTTPListenerV2) serveWrite(res http.ResponseWriter, req *http.Request)
 		h.acc.AddMetric(m)
 	}
 
+	if limited {
+		tooManyRequests(res)
+		return
+	}
 	res.WriteHeader(http.StatusNoContent)
 }
 
@@ -294,6 +374,16 @@ func methodNotAllowed(res http.ResponseWriter) {
 	res.Write([]byte(`{"error":"http: method not allowed"}`))
 }
 
+func unauthorized(res http.ResponseWriter, req *http.Request) {
+	http.Error(res, "Unauthorized.", http.StatusUnauthorized)
+}
+
+func tooManyRequests(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusTooManyRequests)
+	res.Write([]byte(`{"error":"http: tenant limit exceeded"}`))
+}
+
 func internalServerError(res http.ResponseWriter) {
 	res.Header().Set("Content-Type", "application/json")
 	res.WriteHeader(http.StatusInternalServerError)
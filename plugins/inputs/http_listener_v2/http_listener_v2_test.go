@@ -160,6 +160,69 @@ func TestWriteHTTPBasicAuth(t *testing.T) {
 	require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
 }
 
+func TestWriteHTTPTenants(t *testing.T) {
+	listener := newTestHTTPListenerV2()
+	listener.TenantSource = "basic_username"
+	listener.TenantUsers = map[string]string{"acme": "secret"}
+	listener.TenantMaxSeries = 1
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	post := func(username, password, body string) int {
+		req, err := http.NewRequest("POST", createURL(listener, "http", "/write", ""), bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.SetBasicAuth(username, password)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusUnauthorized, post("acme", "wrong", testMsg))
+	require.Equal(t, http.StatusNoContent, post("acme", "secret", testMsg))
+	require.Equal(t, http.StatusTooManyRequests, post("acme", "secret", testMsgs))
+
+	acc.Wait(1)
+	require.Equal(t, uint64(1), acc.NMetrics())
+	acc.AssertContainsTaggedFields(t, "cpu_load_short",
+		map[string]interface{}{"value": float64(12)},
+		map[string]string{"host": "server01", "tenant": "acme"},
+	)
+}
+
+func TestWriteHTTPRateLimit(t *testing.T) {
+	listener := newTestHTTPListenerV2()
+	listener.RateLimit = 0.1
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	resp, err := http.Post(createURL(listener, "http", "/write", ""), "", bytes.NewBuffer([]byte(testMsg)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = http.Post(createURL(listener, "http", "/write", ""), "", bytes.NewBuffer([]byte(testMsg)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "10", resp.Header.Get("Retry-After"))
+
+	acc.Wait(1)
+	require.Equal(t, uint64(1), acc.NMetrics())
+}
+
+func TestTenantsConflictingBasicAuth(t *testing.T) {
+	listener := newTestHTTPAuthListener()
+	listener.TenantSource = "basic_username"
+	listener.TenantUsers = map[string]string{"acme": "secret"}
+
+	require.Error(t, listener.Start(&testutil.Accumulator{}))
+}
+
 func TestWriteHTTP(t *testing.T) {
 	listener := newTestHTTPListenerV2()
 
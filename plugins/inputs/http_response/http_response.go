@@ -17,6 +17,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/common/targets"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -47,6 +48,7 @@ type HTTPResponse struct {
 	Username string `toml:"username"`
 	Password string `toml:"password"`
 	tls.ClientConfig
+	targets.Config
 
 	Log telegraf.Logger
 
@@ -124,6 +126,16 @@ var sampleConfig = `
 
   ## Interface to use when dialing an address
   # interface = "eth0"
+
+  ## Load additional urls from a JSON file or HTTP endpoint, refreshed
+  ## periodically.  The list contains either urls or objects of the form
+  ## {"target": "https://example.org", "labels": {"region": "eu"}} with
+  ## labels added as tags.
+  # targets_url = "http://localhost:8080/targets/http"
+  # targets_file = "/etc/telegraf/http_targets.json"
+  # targets_refresh_interval = "5m"
+  # targets_timeout = "5s"
+  # targets_headers = {"Authorization" = "Bearer mytoken"}
 `
 
 // SampleConfig returns the plugin SampleConfig
@@ -400,7 +412,9 @@ func (h *HTTPResponse) Gather(acc telegraf.Accumulator) error {
 
 	if len(h.URLs) == 0 {
 		if h.Address == "" {
-			h.URLs = []string{"http://localhost"}
+			if !h.Enabled() {
+				h.URLs = []string{"http://localhost"}
+			}
 		} else {
 			h.Log.Warn("'address' deprecated in telegraf 1.12, please use 'urls'")
 			h.URLs = []string{h.Address}
@@ -415,7 +429,13 @@ func (h *HTTPResponse) Gather(acc telegraf.Accumulator) error {
 		h.client = client
 	}
 
-	for _, u := range h.URLs {
+	list, err := h.Targets(h.URLs)
+	if err != nil {
+		acc.AddError(err)
+	}
+
+	for _, target := range list {
+		u := target.Target
 		addr, err := url.Parse(u)
 		if err != nil {
 			acc.AddError(err)
@@ -439,7 +459,7 @@ func (h *HTTPResponse) Gather(acc telegraf.Accumulator) error {
 		}
 
 		// Add metrics
-		acc.AddFields("http_response", fields, tags)
+		targets.Accumulator(acc, target).AddFields("http_response", fields, tags)
 	}
 
 	return nil
@@ -1110,3 +1110,35 @@ func TestBasicAuth(t *testing.T) {
 	absentFields := []string{"response_string_match"}
 	checkOutput(t, &acc, expectedFields, expectedTags, absentFields, nil)
 }
+
+func TestTargetsURL(t *testing.T) {
+	mux := setUpTestMux()
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	targetsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"target": "%s/good", "labels": {"check": "good"}}]`, ts.URL)
+	}))
+	defer targetsServer.Close()
+
+	h := &HTTPResponse{
+		Log:             testutil.Logger{},
+		Method:          "GET",
+		ResponseTimeout: internal.Duration{Duration: time.Second * 20},
+	}
+	h.TargetsURL = targetsServer.URL
+
+	var acc testutil.Accumulator
+	err := h.Gather(&acc)
+	require.NoError(t, err)
+	require.Empty(t, acc.Errors)
+	require.Len(t, acc.Metrics, 1)
+
+	expectedTags := map[string]interface{}{
+		"server":      ts.URL + "/good",
+		"check":       "good",
+		"status_code": "200",
+		"result":      "success",
+	}
+	checkOutput(t, &acc, nil, expectedTags, nil, nil)
+}
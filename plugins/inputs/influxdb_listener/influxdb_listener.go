@@ -3,16 +3,21 @@ package influxdb_listener
 import (
 	"compress/gzip"
 	"context"
+	"crypto/subtle"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/common/ratelimit"
+	"github.com/influxdata/telegraf/plugins/common/tenant"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/selfstat"
@@ -37,8 +42,15 @@ type InfluxDBListener struct {
 	BasicPassword      string            `toml:"basic_password"`
 	DatabaseTag        string            `toml:"database_tag"`
 	RetentionPolicyTag string            `toml:"retention_policy_tag"`
+	BucketTag          string            `toml:"bucket_tag"`
+	OrgTag             string            `toml:"org_tag"`
+	Tokens             []string          `toml:"tokens"`
+	tenant.Config
+	ratelimit.LimiterConfig
 
 	timeFunc influx.TimeFunc
+	tenants  *tenant.Tenants
+	limiter  *ratelimit.Limiter
 
 	listener net.Listener
 	server   http.Server
@@ -83,6 +95,11 @@ const sampleConfig = `
   ## the value of this tag name.
   # retention_policy_tag = ""
 
+  ## Optional tag names used to store the bucket and organization of writes
+  ## to the InfluxDB 2.x "/api/v2/write" endpoint.
+  # bucket_tag = ""
+  # org_tag = ""
+
   ## Set one or more allowed client CA certificate file names to
   ## enable mutually authenticated TLS connections
   tls_allowed_cacerts = ["/etc/telegraf/clientca.pem"]
@@ -95,6 +112,46 @@ const sampleConfig = `
   ## You probably want to make sure you have TLS configured above for this.
   # basic_username = "foobar"
   # basic_password = "barfoo"
+
+  ## Optional tokens to accept in the "Authorization: Token" header of writes
+  ## to the "/api/v2/write" endpoint.  When set, basic authentication is not
+  ## used for this endpoint.
+  # tokens = ["my-token"]
+
+  ## Optional source of the tenant of each write, the metrics are tagged with
+  ## the tenant and limited per tenant.  Available options are
+  ## "basic_username", "token_claim" and "tls_cn".
+  # tenant_source = "basic_username"
+
+  ## Tag key holding the tenant.
+  # tenant_tag = "tenant"
+
+  ## Usernames and passwords of the tenants for "basic_username".
+  # tenant_users = {"acme" = "secret"}
+
+  ## Key verifying the JWT tokens for "token_claim", either a PEM encoded RSA
+  ## or ECDSA public key or a file holding a HMAC secret, and the claim holding
+  ## the tenant.
+  # tenant_token_key = "/etc/telegraf/jwt.pem"
+  # tenant_token_claim = "sub"
+
+  ## Maximum number of metrics per second and maximum number of series accepted
+  ## from each tenant, metrics over the limits are rejected.  0 means no limit.
+  # tenant_rate_limit = 0.0
+  # tenant_max_series = 0
+
+  ## Series not received from a tenant for this duration no longer count
+  ## against its tenant_max_series.
+  # tenant_series_ttl = "1h"
+
+  ## Maximum number of write requests per second accepted from each source,
+  ## the requests over the limit are answered with 429 Too Many Requests.  The
+  ## source is the client "ip", or the "tenant" when a tenant_source is set.
+  ## The burst is the number of requests accepted at once, by default one
+  ## second worth of the rate.  0 means no limit.
+  # rate_limit = 0.0
+  # rate_limit_burst = 0
+  # rate_limit_key = "ip"
 `
 
 func (h *InfluxDBListener) SampleConfig() string {
@@ -102,7 +159,7 @@ func (h *InfluxDBListener) SampleConfig() string {
 }
 
 func (h *InfluxDBListener) Description() string {
-	return "Accept metrics over InfluxDB 1.x HTTP API"
+	return "Accept metrics over InfluxDB 1.x and 2.x HTTP API"
 }
 
 func (h *InfluxDBListener) Gather(_ telegraf.Accumulator) error {
@@ -116,9 +173,34 @@ func (h *InfluxDBListener) routes() {
 		},
 	)
 
-	h.mux.Handle("/write", authHandler(h.handleWrite()))
+	writeV1 := h.tenants.Handler(
+		h.limiter.Handler(h.handleWrite(), func(res http.ResponseWriter, req *http.Request) {
+			tooManyRequests(res, "rate limit exceeded")
+		}),
+		func(res http.ResponseWriter, req *http.Request) {
+			h.authFailures.Incr(1)
+			http.Error(res, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		},
+	)
+	writeV2 := h.tenants.Handler(
+		h.limiter.Handler(h.handleWriteV2(), func(res http.ResponseWriter, req *http.Request) {
+			errorV2(res, http.StatusTooManyRequests, "too many requests", "rate limit exceeded")
+		}),
+		func(res http.ResponseWriter, req *http.Request) {
+			h.authFailures.Incr(1)
+			errorV2(res, http.StatusUnauthorized, "unauthorized", "unauthorized access")
+		},
+	)
+
+	h.mux.Handle("/write", authHandler(writeV1))
 	h.mux.Handle("/query", authHandler(h.handleQuery()))
 	h.mux.Handle("/ping", h.handlePing())
+
+	if len(h.Tokens) > 0 {
+		h.mux.Handle("/api/v2/write", h.tokenAuth(writeV2))
+	} else {
+		h.mux.Handle("/api/v2/write", authHandler(writeV2))
+	}
 	h.mux.Handle("/", authHandler(h.handleDefault()))
 }
 
@@ -135,6 +217,24 @@ func (h *InfluxDBListener) Init() error {
 	h.notFoundsServed = selfstat.Register("influxdb_listener", "not_founds_served", tags)
 	h.buffersCreated = selfstat.Register("influxdb_listener", "buffers_created", tags)
 	h.authFailures = selfstat.Register("influxdb_listener", "auth_failures", tags)
+
+	tenants, err := h.Config.Tenants()
+	if err != nil {
+		return err
+	}
+	if tenants.UsesBasicAuth() && h.BasicUsername != "" {
+		return fmt.Errorf("basic_username cannot be used with tenant_source %q", h.TenantSource)
+	}
+	if tenants.UsesTokens() && len(h.Tokens) > 0 {
+		return fmt.Errorf("tokens cannot be used with tenant_source %q", h.TenantSource)
+	}
+	h.tenants = tenants
+
+	limiter, err := h.LimiterConfig.Limiter("influxdb_listener", "requests_rate_limited", tags)
+	if err != nil {
+		return err
+	}
+	h.limiter = limiter
 	h.routes()
 
 	if h.MaxBodySize.Size == 0 {
@@ -262,89 +362,120 @@ func (h *InfluxDBListener) handleWrite() http.HandlerFunc {
 		db := req.URL.Query().Get("db")
 		rp := req.URL.Query().Get("rp")
 
-		body := req.Body
-		body = http.MaxBytesReader(res, body, h.MaxBodySize.Size)
-		// Handle gzip request bodies
-		if req.Header.Get("Content-Encoding") == "gzip" {
-			var err error
-			body, err = gzip.NewReader(body)
-			if err != nil {
-				h.Log.Debugf("Error decompressing request body: %v", err.Error())
-				badRequest(res, err.Error())
-				return
-			}
-			defer body.Close()
+		tags := make(map[string]string)
+		if h.DatabaseTag != "" && db != "" {
+			tags[h.DatabaseTag] = db
+		}
+		if h.RetentionPolicyTag != "" && rp != "" {
+			tags[h.RetentionPolicyTag] = rp
 		}
 
-		parser := influx.NewStreamParser(body)
-		parser.SetTimeFunc(h.timeFunc)
+		body, err := h.requestBody(res, req)
+		if err != nil {
+			h.Log.Debugf("Error decompressing request body: %v", err.Error())
+			badRequest(res, err.Error())
+			return
+		}
+		defer body.Close()
 
-		precisionStr := req.URL.Query().Get("precision")
-		if precisionStr != "" {
-			precision := getPrecisionMultiplier(precisionStr)
-			parser.SetTimePrecision(precision)
+		var precision time.Duration
+		if precisionStr := req.URL.Query().Get("precision"); precisionStr != "" {
+			precision = getPrecisionMultiplier(precisionStr)
 		}
 
-		var m telegraf.Metric
-		var err error
-		var parseErrorCount int
-		var lastPos int = 0
-		var firstParseErrorStr string
-		for {
-			select {
-			case <-req.Context().Done():
-				// Shutting down before parsing is finished.
-				res.WriteHeader(http.StatusServiceUnavailable)
-				return
-			default:
-			}
+		partialErrorString, limited, err := h.parseBody(req.Context(), body, precision, tags)
+		if req.Context().Err() != nil {
+			// Shutting down before parsing is finished.
+			res.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			h.Log.Debugf("Error parsing the request body: %v", err.Error())
+			badRequest(res, err.Error())
+			return
+		}
+		if partialErrorString != "" {
+			partialWrite(res, partialErrorString)
+			return
+		}
+		if limited {
+			tooManyRequests(res, "tenant limit exceeded")
+			return
+		}
 
-			m, err = parser.Next()
-			pos := parser.Position()
-			h.bytesRecv.Incr(int64(pos - lastPos))
-			lastPos = pos
-
-			// Continue parsing metrics even if some are malformed
-			if parseErr, ok := err.(*influx.ParseError); ok {
-				parseErrorCount += 1
-				errStr := parseErr.Error()
-				if firstParseErrorStr == "" {
-					firstParseErrorStr = errStr
-				}
-				continue
-			} else if err != nil {
-				// Either we're exiting cleanly (err ==
-				// influx.EOF) or there's an unexpected error
-				break
-			}
+		// http request success
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
 
-			if h.DatabaseTag != "" && db != "" {
-				m.AddTag(h.DatabaseTag, db)
-			}
+func (h *InfluxDBListener) handleWriteV2() http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		defer h.writesServed.Incr(1)
+		if req.Method != http.MethodPost {
+			res.Header().Set("Allow", http.MethodPost)
+			errorV2(res, http.StatusMethodNotAllowed, "method not allowed", "method not allowed")
+			return
+		}
 
-			if h.RetentionPolicyTag != "" && rp != "" {
-				m.AddTag(h.RetentionPolicyTag, rp)
-			}
+		// Check that the content length is not too large for us to handle.
+		if req.ContentLength > h.MaxBodySize.Size {
+			errorV2(res, http.StatusRequestEntityTooLarge, "request too large", "http: request body too large")
+			return
+		}
 
-			h.acc.AddMetric(m)
+		bucket := req.URL.Query().Get("bucket")
+		if bucket == "" {
+			errorV2(res, http.StatusBadRequest, "invalid", "bucket not specified")
+			return
+		}
+		org := req.URL.Query().Get("org")
+		if org == "" {
+			org = req.URL.Query().Get("orgID")
+		}
 
+		precision, err := getPrecisionV2(req.URL.Query().Get("precision"))
+		if err != nil {
+			errorV2(res, http.StatusBadRequest, "invalid", err.Error())
+			return
 		}
-		if err != influx.EOF {
-			h.Log.Debugf("Error parsing the request body: %v", err.Error())
-			badRequest(res, err.Error())
+
+		tags := make(map[string]string)
+		if h.BucketTag != "" {
+			tags[h.BucketTag] = bucket
+		}
+		if h.OrgTag != "" && org != "" {
+			tags[h.OrgTag] = org
+		}
+
+		body, err := h.requestBody(res, req)
+		if err != nil {
+			h.Log.Debugf("Error decompressing request body: %v", err.Error())
+			errorV2(res, http.StatusBadRequest, "invalid", err.Error())
+			return
+		}
+		defer body.Close()
+
+		partialErrorString, limited, err := h.parseBody(req.Context(), body, precision, tags)
+		if req.Context().Err() != nil {
+			// Shutting down before parsing is finished.
+			res.WriteHeader(http.StatusServiceUnavailable)
 			return
 		}
-		if parseErrorCount > 0 {
-			var partialErrorString string
-			switch parseErrorCount {
-			case 1:
-				partialErrorString = fmt.Sprintf("%s", firstParseErrorStr)
-			case 2:
-				partialErrorString = fmt.Sprintf("%s (and 1 other parse error)", firstParseErrorStr)
-			default:
-				partialErrorString = fmt.Sprintf("%s (and %d other parse errors)", firstParseErrorStr, parseErrorCount-1)
+		if err != nil {
+			h.Log.Debugf("Error parsing the request body: %v", err.Error())
+			if err.Error() == "http: request body too large" {
+				errorV2(res, http.StatusRequestEntityTooLarge, "request too large", err.Error())
+				return
 			}
-			partialWrite(res, partialErrorString)
+			errorV2(res, http.StatusBadRequest, "invalid", err.Error())
+			return
+		}
+		if partialErrorString != "" {
+			errorV2(res, http.StatusBadRequest, "invalid", partialErrorString)
+			return
+		}
+		if limited {
+			errorV2(res, http.StatusTooManyRequests, "too many requests", "tenant limit exceeded")
 			return
 		}
 
@@ -353,6 +484,118 @@ func (h *InfluxDBListener) handleWrite() http.HandlerFunc {
 	}
 }
 
+// requestBody returns the request body limited to the maximum body size,
+// decompressing it if required.
+func (h *InfluxDBListener) requestBody(res http.ResponseWriter, req *http.Request) (io.ReadCloser, error) {
+	body := http.MaxBytesReader(res, req.Body, h.MaxBodySize.Size)
+	// Handle gzip request bodies
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		return gzip.NewReader(body)
+	}
+	return body, nil
+}
+
+// parseBody adds the metrics in the line protocol body to the accumulator,
+// with the given tags added.  Malformed lines are skipped and described in
+// the returned partial error string, any other error stops the parsing.
+// Metrics exceeding the limits of the tenant are dropped and reported as
+// limited.
+func (h *InfluxDBListener) parseBody(ctx context.Context, body io.Reader, precision time.Duration, tags map[string]string) (string, bool, error) {
+	parser := influx.NewStreamParser(body)
+	parser.SetTimeFunc(h.timeFunc)
+	if precision != 0 {
+		parser.SetTimePrecision(precision)
+	}
+
+	var m telegraf.Metric
+	var err error
+	var parseErrorCount int
+	var lastPos int = 0
+	var firstParseErrorStr string
+	var limited bool
+	for {
+		select {
+		case <-ctx.Done():
+			return "", false, ctx.Err()
+		default:
+		}
+
+		m, err = parser.Next()
+		pos := parser.Position()
+		h.bytesRecv.Incr(int64(pos - lastPos))
+		lastPos = pos
+
+		// Continue parsing metrics even if some are malformed
+		if parseErr, ok := err.(*influx.ParseError); ok {
+			parseErrorCount += 1
+			errStr := parseErr.Error()
+			if firstParseErrorStr == "" {
+				firstParseErrorStr = errStr
+			}
+			continue
+		} else if err != nil {
+			// Either we're exiting cleanly (err ==
+			// influx.EOF) or there's an unexpected error
+			break
+		}
+
+		for k, v := range tags {
+			m.AddTag(k, v)
+		}
+
+		if err := h.tenants.Apply(ctx, m); err != nil {
+			h.Log.Debugf("Dropping metric: %v", err)
+			limited = true
+			continue
+		}
+
+		h.acc.AddMetric(m)
+	}
+	if err != influx.EOF {
+		return "", limited, err
+	}
+
+	switch parseErrorCount {
+	case 0:
+		return "", limited, nil
+	case 1:
+		return firstParseErrorStr, limited, nil
+	case 2:
+		return fmt.Sprintf("%s (and 1 other parse error)", firstParseErrorStr), limited, nil
+	default:
+		return fmt.Sprintf("%s (and %d other parse errors)", firstParseErrorStr, parseErrorCount-1), limited, nil
+	}
+}
+
+// tokenAuth returns a handler that requires one of the configured tokens in
+// the Authorization header, as sent by the InfluxDB 2.x clients.
+func (h *InfluxDBListener) tokenAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		auth := req.Header.Get("Authorization")
+		scheme, token := auth, ""
+		if i := strings.IndexByte(auth, ' '); i >= 0 {
+			scheme, token = auth[:i], strings.TrimSpace(auth[i+1:])
+		}
+
+		if scheme != "Token" || !h.validToken(token) {
+			h.authFailures.Incr(1)
+			errorV2(res, http.StatusUnauthorized, "unauthorized", "unauthorized access")
+			return
+		}
+		next.ServeHTTP(res, req)
+	})
+}
+
+func (h *InfluxDBListener) validToken(token string) bool {
+	valid := false
+	for _, t := range h.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
 func tooLarge(res http.ResponseWriter) {
 	res.Header().Set("Content-Type", "application/json")
 	res.Header().Set("X-Influxdb-Version", "1.0")
@@ -372,6 +615,14 @@ func badRequest(res http.ResponseWriter, errString string) {
 	res.Write([]byte(fmt.Sprintf(`{"error":%q}`, errString)))
 }
 
+func tooManyRequests(res http.ResponseWriter, errString string) {
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("X-Influxdb-Version", "1.0")
+	res.Header().Set("X-Influxdb-Error", errString)
+	res.WriteHeader(http.StatusTooManyRequests)
+	res.Write([]byte(fmt.Sprintf(`{"error":%q}`, errString)))
+}
+
 func partialWrite(res http.ResponseWriter, errString string) {
 	res.Header().Set("Content-Type", "application/json")
 	res.Header().Set("X-Influxdb-Version", "1.0")
@@ -380,6 +631,32 @@ func partialWrite(res http.ResponseWriter, errString string) {
 	res.Write([]byte(fmt.Sprintf(`{"error":%q}`, errString)))
 }
 
+func errorV2(res http.ResponseWriter, code int, errCode string, message string) {
+	res.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res.Header().Set("X-Influxdb-Version", "2.0")
+	res.Header().Set("X-Platform-Error-Code", errCode)
+	res.WriteHeader(code)
+	b, _ := json.Marshal(map[string]string{"code": errCode, "message": message})
+	res.Write(b)
+}
+
+// getPrecisionV2 returns the timestamp precision of the 2.x write API, which
+// unlike the 1.x API rejects unknown values.
+func getPrecisionV2(precision string) (time.Duration, error) {
+	switch precision {
+	case "", "ns":
+		return time.Nanosecond, nil
+	case "us":
+		return time.Microsecond, nil
+	case "ms":
+		return time.Millisecond, nil
+	case "s":
+		return time.Second, nil
+	default:
+		return 0, fmt.Errorf("invalid precision %q; precision must be one of: ns, us, ms, s", precision)
+	}
+}
+
 func getPrecisionMultiplier(precision string) time.Duration {
 	// Influxdb defaults silently to nanoseconds if precision isn't
 	// one of the following:
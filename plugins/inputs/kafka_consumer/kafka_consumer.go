@@ -74,6 +74,12 @@ const sampleConfig = `
   ## waiting until the next flush_interval.
   # max_undelivered_messages = 1000
 
+  ## Delivery guarantee of the messages; one of "best_effort" or
+  ## "at_least_once".  With "at_least_once" the offset of a message is only
+  ## committed once it and all previous messages of the partition have been
+  ## written by the outputs, undelivered messages are consumed again.
+  # delivery_mode = "best_effort"
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -86,6 +92,9 @@ const (
 	defaultMaxMessageLen          = 1000000
 	defaultConsumerGroup          = "telegraf_metrics_consumers"
 	reconnectDelay                = 5 * time.Second
+
+	deliveryBestEffort  = "best_effort"
+	deliveryAtLeastOnce = "at_least_once"
 )
 
 type empty struct{}
@@ -97,6 +106,7 @@ type KafkaConsumer struct {
 	ConsumerGroup          string   `toml:"consumer_group"`
 	MaxMessageLen          int      `toml:"max_message_len"`
 	MaxUndeliveredMessages int      `toml:"max_undelivered_messages"`
+	DeliveryMode           string   `toml:"delivery_mode"`
 	Offset                 string   `toml:"offset"`
 	BalanceStrategy        string   `toml:"balance_strategy"`
 	Topics                 []string `toml:"topics"`
@@ -156,6 +166,14 @@ func (k *KafkaConsumer) Init() error {
 		k.ConsumerGroup = defaultConsumerGroup
 	}
 
+	switch k.DeliveryMode {
+	case "":
+		k.DeliveryMode = deliveryBestEffort
+	case deliveryBestEffort, deliveryAtLeastOnce:
+	default:
+		return fmt.Errorf("invalid delivery_mode %q", k.DeliveryMode)
+	}
+
 	config := sarama.NewConfig()
 	config.Consumer.Return.Errors = true
 
@@ -259,6 +277,7 @@ func (k *KafkaConsumer) Start(acc telegraf.Accumulator) error {
 			handler := NewConsumerGroupHandler(acc, k.MaxUndeliveredMessages, k.parser)
 			handler.MaxMessageLen = k.MaxMessageLen
 			handler.TopicTag = k.TopicTag
+			handler.AtLeastOnce = k.DeliveryMode == deliveryAtLeastOnce
 			err := k.consumer.Consume(ctx, k.Topics, handler)
 			if err != nil {
 				acc.AddError(err)
@@ -303,16 +322,30 @@ func NewConsumerGroupHandler(acc telegraf.Accumulator, maxUndelivered int, parse
 		acc:         acc.WithTracking(maxUndelivered),
 		sem:         make(chan empty, maxUndelivered),
 		undelivered: make(map[telegraf.TrackingID]Message, maxUndelivered),
+		pending:     make(map[topicPartition][]*sarama.ConsumerMessage),
+		done:        make(map[*sarama.ConsumerMessage]bool),
+		restart:     make(chan empty),
 		parser:      parser,
 	}
 	return handler
 }
 
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
 // ConsumerGroupHandler is a sarama.ConsumerGroupHandler implementation.
 type ConsumerGroupHandler struct {
 	MaxMessageLen int
 	TopicTag      string
 
+	// AtLeastOnce only marks the offsets of the messages up to the first
+	// message of the partition not yet delivered.  An undelivered message
+	// ends the session, so that it is consumed again from the last marked
+	// offset.
+	AtLeastOnce bool
+
 	acc    telegraf.TrackingAccumulator
 	sem    semaphore
 	parser parsers.Parser
@@ -321,12 +354,26 @@ type ConsumerGroupHandler struct {
 
 	mu          sync.Mutex
 	undelivered map[telegraf.TrackingID]Message
+
+	// pending holds the messages of each partition not yet marked in the
+	// order received, and done the pending messages already processed.
+	pending map[topicPartition][]*sarama.ConsumerMessage
+	done    map[*sarama.ConsumerMessage]bool
+
+	// restart is closed when the session must end to consume the
+	// undelivered messages again.
+	restart    chan empty
+	restarting bool
 }
 
 // Setup is called once when a new session is opened.  It setups up the handler
 // and begins processing delivered messages.
 func (h *ConsumerGroupHandler) Setup(sarama.ConsumerGroupSession) error {
 	h.undelivered = make(map[telegraf.TrackingID]Message)
+	h.pending = make(map[topicPartition][]*sarama.ConsumerMessage)
+	h.done = make(map[*sarama.ConsumerMessage]bool)
+	h.restart = make(chan empty)
+	h.restarting = false
 
 	ctx, cancel := context.WithCancel(context.Background())
 	h.cancel = cancel
@@ -361,14 +408,45 @@ func (h *ConsumerGroupHandler) onDelivery(track telegraf.DeliveryInfo) {
 		return
 	}
 
-	if track.Delivered() {
-		msg.session.MarkMessage(msg.message, "")
+	switch {
+	case !h.AtLeastOnce:
+		if track.Delivered() {
+			msg.session.MarkMessage(msg.message, "")
+		}
+	case track.Delivered():
+		h.markDone(msg.session, msg.message)
+	case !h.restarting:
+		log.Printf("W! [inputs.kafka_consumer] Message at offset %d of partition %s/%d was not delivered, consuming again from the last committed offset",
+			msg.message.Offset, msg.message.Topic, msg.message.Partition)
+		close(h.restart)
+		h.restarting = true
 	}
 
 	delete(h.undelivered, track.ID())
 	<-h.sem
 }
 
+// markDone marks the message as processed and, with at-least-once delivery,
+// marks the messages of its partition up to the first message still pending.
+// Must be called with the lock held.
+func (h *ConsumerGroupHandler) markDone(session sarama.ConsumerGroupSession, msg *sarama.ConsumerMessage) {
+	if !h.AtLeastOnce {
+		session.MarkMessage(msg, "")
+		return
+	}
+
+	tp := topicPartition{topic: msg.Topic, partition: msg.Partition}
+	h.done[msg] = true
+
+	pending := h.pending[tp]
+	for len(pending) > 0 && h.done[pending[0]] {
+		session.MarkMessage(pending[0], "")
+		delete(h.done, pending[0])
+		pending = pending[1:]
+	}
+	h.pending[tp] = pending
+}
+
 // Reserve blocks until there is an available slot for a new message.
 func (h *ConsumerGroupHandler) Reserve(ctx context.Context) error {
 	select {
@@ -386,16 +464,22 @@ func (h *ConsumerGroupHandler) release() {
 // Handle processes a message and if successful saves it to be acknowledged
 // after delivery.
 func (h *ConsumerGroupHandler) Handle(session sarama.ConsumerGroupSession, msg *sarama.ConsumerMessage) error {
+	if h.AtLeastOnce {
+		tp := topicPartition{topic: msg.Topic, partition: msg.Partition}
+		h.mu.Lock()
+		h.pending[tp] = append(h.pending[tp], msg)
+		h.mu.Unlock()
+	}
+
 	if h.MaxMessageLen != 0 && len(msg.Value) > h.MaxMessageLen {
-		session.MarkMessage(msg, "")
-		h.release()
+		h.skip(session, msg)
 		return fmt.Errorf("message exceeds max_message_len (actual %d, max %d)",
 			len(msg.Value), h.MaxMessageLen)
 	}
 
 	metrics, err := h.parser.Parse(msg.Value)
 	if err != nil {
-		h.release()
+		h.skip(session, msg)
 		return err
 	}
 
@@ -412,6 +496,14 @@ func (h *ConsumerGroupHandler) Handle(session sarama.ConsumerGroupSession, msg *
 	return nil
 }
 
+// skip marks a message that can never be delivered as processed.
+func (h *ConsumerGroupHandler) skip(session sarama.ConsumerGroupSession, msg *sarama.ConsumerMessage) {
+	h.mu.Lock()
+	h.markDone(session, msg)
+	h.mu.Unlock()
+	h.release()
+}
+
 // ConsumeClaim is called once each claim in a goroutine and must be
 // thread-safe.  Should run until the claim is closed.
 func (h *ConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
@@ -426,6 +518,9 @@ func (h *ConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession,
 		select {
 		case <-ctx.Done():
 			return nil
+		case <-h.restart:
+			h.release()
+			return nil
 		case msg, ok := <-claim.Messages():
 			if !ok {
 				return nil
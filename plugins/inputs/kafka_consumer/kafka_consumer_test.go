@@ -111,6 +111,14 @@ func TestInit(t *testing.T) {
 			},
 			initError: true,
 		},
+		{
+			name: "invalid delivery mode",
+			plugin: &KafkaConsumer{
+				DeliveryMode: "exactly_once",
+				Log:          testutil.Logger{},
+			},
+			initError: true,
+		},
 		{
 			name: "default tls without tls config",
 			plugin: &KafkaConsumer{
@@ -188,7 +196,8 @@ func TestStartStop(t *testing.T) {
 }
 
 type FakeConsumerGroupSession struct {
-	ctx context.Context
+	ctx    context.Context
+	marked []int64
 }
 
 func (s *FakeConsumerGroupSession) Claims() map[string][]int32 {
@@ -212,6 +221,7 @@ func (s *FakeConsumerGroupSession) ResetOffset(topic string, partition int32, of
 }
 
 func (s *FakeConsumerGroupSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.marked = append(s.marked, msg.Offset)
 }
 
 func (s *FakeConsumerGroupSession) Context() context.Context {
@@ -394,3 +404,64 @@ func TestConsumerGroupHandler_Handle(t *testing.T) {
 		})
 	}
 }
+
+type deliveryInfo struct {
+	id        telegraf.TrackingID
+	delivered bool
+}
+
+func (d *deliveryInfo) ID() telegraf.TrackingID {
+	return d.id
+}
+
+func (d *deliveryInfo) Delivered() bool {
+	return d.delivered
+}
+
+func TestConsumerGroupHandler_AtLeastOnce(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	parser := &value.ValueParser{MetricName: "cpu", DataType: "int"}
+	cg := NewConsumerGroupHandler(acc, 4, parser)
+	cg.AtLeastOnce = true
+
+	ctx := context.Background()
+	session := &FakeConsumerGroupSession{ctx: ctx}
+
+	ids := make(map[int64]telegraf.TrackingID)
+	for offset, value := range []string{"1", "2", "not an integer", "4"} {
+		msg := &sarama.ConsumerMessage{
+			Topic:  "telegraf",
+			Offset: int64(offset),
+			Value:  []byte(value),
+		}
+		require.NoError(t, cg.Reserve(ctx))
+		cg.Handle(session, msg)
+		for id, m := range cg.undelivered {
+			if m.message == msg {
+				ids[msg.Offset] = id
+			}
+		}
+	}
+	require.Len(t, ids, 3)
+
+	// A message is only marked once all previous messages are processed
+	cg.onDelivery(&deliveryInfo{id: ids[1], delivered: true})
+	require.Empty(t, session.marked)
+
+	cg.onDelivery(&deliveryInfo{id: ids[0], delivered: true})
+	require.Equal(t, []int64{0, 1, 2}, session.marked)
+
+	// An undelivered message ends the session
+	cg.onDelivery(&deliveryInfo{id: ids[3], delivered: false})
+	require.Equal(t, []int64{0, 1, 2}, session.marked)
+	select {
+	case <-cg.restart:
+	default:
+		t.Fatal("session not restarted")
+	}
+
+	claim := &FakeConsumerGroupClaim{
+		messages: make(chan *sarama.ConsumerMessage),
+	}
+	require.NoError(t, cg.ConsumeClaim(session, claim))
+}
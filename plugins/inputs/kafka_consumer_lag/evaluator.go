@@ -0,0 +1,112 @@
+package kafka_consumer_lag
+
+// The statuses of the partitions and groups, as reported by Burrow.
+const (
+	statusOK    = "OK"
+	statusWarn  = "WARN"
+	statusErr   = "ERR"
+	statusStop  = "STOP"
+	statusStall = "STALL"
+)
+
+func mapStatusToCode(status string) int {
+	switch status {
+	case statusOK:
+		return 1
+	case statusWarn:
+		return 3
+	case statusErr:
+		return 4
+	case statusStop:
+		return 5
+	case statusStall:
+		return 6
+	default:
+		return 0
+	}
+}
+
+// sample is the committed offset of a partition and its lag at a gather.
+type sample struct {
+	offset int64
+	lag    int64
+}
+
+// window holds the last samples of a partition, the oldest first.
+type window struct {
+	samples []sample
+	size    int
+	seen    bool
+}
+
+func (w *window) add(s sample) {
+	if len(w.samples) == w.size {
+		copy(w.samples, w.samples[1:])
+		w.samples = w.samples[:w.size-1]
+	}
+	w.samples = append(w.samples, s)
+}
+
+func (w *window) full() bool {
+	return len(w.samples) == w.size
+}
+
+// evaluate returns the status of the partition from its samples, following
+// the rules of Burrow:
+//   - the consumer is OK if it caught up at any time in the window,
+//   - it is in error if its offset went backward,
+//   - it stopped if its offset did not change over the window with a lag,
+//     and stalled if it did not change although the group has members,
+//   - it is lagging behind if its lag increased at every sample of the
+//     window.
+func (w *window) evaluate(active bool) string {
+	for _, s := range w.samples {
+		if s.lag == 0 {
+			return statusOK
+		}
+	}
+	if len(w.samples) < 2 {
+		return statusOK
+	}
+
+	for i := 1; i < len(w.samples); i++ {
+		if w.samples[i].offset < w.samples[i-1].offset {
+			return statusErr
+		}
+	}
+
+	if !w.full() {
+		return statusOK
+	}
+
+	first, last := w.samples[0], w.samples[len(w.samples)-1]
+	if first.offset == last.offset {
+		if active {
+			return statusStall
+		}
+		return statusStop
+	}
+
+	for i := 1; i < len(w.samples); i++ {
+		if w.samples[i].lag <= w.samples[i-1].lag {
+			return statusOK
+		}
+	}
+	return statusWarn
+}
+
+// groupStatus returns the status of a group from the status of its
+// partitions: in error if any partition is not progressing, lagging behind
+// if any partition is.
+func groupStatus(statuses []string) string {
+	status := statusOK
+	for _, s := range statuses {
+		switch s {
+		case statusErr, statusStop, statusStall:
+			return statusErr
+		case statusWarn:
+			status = statusWarn
+		}
+	}
+	return status
+}
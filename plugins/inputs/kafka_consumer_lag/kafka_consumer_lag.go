@@ -0,0 +1,448 @@
+package kafka_consumer_lag
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/Shopify/sarama"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/common/kafka"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const sampleConfig = `
+  ## Kafka brokers.
+  brokers = ["localhost:9092"]
+
+  ## Optional Client id
+  # client_id = "Telegraf"
+
+  ## Set the minimal supported Kafka version.  Must be 0.10.2.0 or greater.
+  ##   ex: version = "1.1.0"
+  # version = ""
+
+  ## Optional TLS Config
+  # enable_tls = true
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## SASL authentication credentials.  These settings should typically be used
+  ## with TLS encryption enabled using the "enable_tls" option.
+  # sasl_username = "kafka"
+  # sasl_password = "secret"
+
+  ## SASL protocol version.  When connecting to Azure EventHub set to 0.
+  # sasl_version = 1
+
+  ## Filter consumer groups, default is no filtering.
+  ## Values can be specified as glob patterns.
+  # groups_include = []
+  # groups_exclude = []
+
+  ## Filter topics, default is no filtering.
+  ## Values can be specified as glob patterns.
+  # topics_include = []
+  # topics_exclude = []
+
+  ## Number of samples of the offsets, one per interval, over which the
+  ## status of the consumers is evaluated.
+  # evaluation_window = 10
+`
+
+type KafkaConsumerLag struct {
+	Brokers          []string `toml:"brokers"`
+	ClientID         string   `toml:"client_id"`
+	Version          string   `toml:"version"`
+	SASLUsername     string   `toml:"sasl_username"`
+	SASLPassword     string   `toml:"sasl_password"`
+	SASLVersion      *int     `toml:"sasl_version"`
+	GroupsInclude    []string `toml:"groups_include"`
+	GroupsExclude    []string `toml:"groups_exclude"`
+	TopicsInclude    []string `toml:"topics_include"`
+	TopicsExclude    []string `toml:"topics_exclude"`
+	EvaluationWindow int      `toml:"evaluation_window"`
+
+	EnableTLS *bool `toml:"enable_tls"`
+	tls.ClientConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	config       *sarama.Config
+	filterGroups filter.Filter
+	filterTopics filter.Filter
+
+	newClient func(brokers []string, config *sarama.Config) (offsetClient, error)
+	client    offsetClient
+	windows   map[partitionKey]*window
+}
+
+// partitionKey identifies the partition of a topic consumed by a group.
+type partitionKey struct {
+	group     string
+	topic     string
+	partition int32
+}
+
+// groupInfo is the state of a consumer group.
+type groupInfo struct {
+	state   string
+	members int
+}
+
+// offsetClient reads the consumer groups and the offsets from Kafka.
+type offsetClient interface {
+	// Groups returns the state of the consumer groups.
+	Groups() (map[string]groupInfo, error)
+	// CommittedOffsets returns the offsets committed by the group, by topic
+	// and partition.
+	CommittedOffsets(group string) (map[string]map[int32]int64, error)
+	// NewestOffset returns the offset of the next message produced to the
+	// partition.
+	NewestOffset(topic string, partition int32) (int64, error)
+	Close() error
+}
+
+func (k *KafkaConsumerLag) SampleConfig() string {
+	return sampleConfig
+}
+
+func (k *KafkaConsumerLag) Description() string {
+	return "Evaluate the lag of the Kafka consumer groups from their offsets"
+}
+
+func (k *KafkaConsumerLag) Init() error {
+	if k.EvaluationWindow < 2 {
+		return fmt.Errorf("evaluation_window must be at least 2")
+	}
+
+	config := sarama.NewConfig()
+
+	// Kafka version 0.10.2.0 is required to fetch all the offsets of a group.
+	config.Version = sarama.V0_10_2_0
+
+	if k.Version != "" {
+		version, err := sarama.ParseKafkaVersion(k.Version)
+		if err != nil {
+			return err
+		}
+		config.Version = version
+	}
+
+	if k.EnableTLS != nil && *k.EnableTLS {
+		config.Net.TLS.Enable = true
+	}
+
+	tlsConfig, err := k.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		config.Net.TLS.Config = tlsConfig
+	}
+
+	if k.SASLUsername != "" && k.SASLPassword != "" {
+		config.Net.SASL.User = k.SASLUsername
+		config.Net.SASL.Password = k.SASLPassword
+		config.Net.SASL.Enable = true
+
+		version, err := kafka.SASLVersion(config.Version, k.SASLVersion)
+		if err != nil {
+			return err
+		}
+		config.Net.SASL.Version = version
+	}
+
+	if k.ClientID != "" {
+		config.ClientID = k.ClientID
+	} else {
+		config.ClientID = "Telegraf"
+	}
+	k.config = config
+
+	k.filterGroups, err = filter.NewIncludeExcludeFilter(k.GroupsInclude, k.GroupsExclude)
+	if err != nil {
+		return fmt.Errorf("groups filter: %v", err)
+	}
+	k.filterTopics, err = filter.NewIncludeExcludeFilter(k.TopicsInclude, k.TopicsExclude)
+	if err != nil {
+		return fmt.Errorf("topics filter: %v", err)
+	}
+
+	if k.newClient == nil {
+		k.newClient = newSaramaClient
+	}
+	k.windows = make(map[partitionKey]*window)
+	return nil
+}
+
+func (k *KafkaConsumerLag) Start(_ telegraf.Accumulator) error {
+	return nil
+}
+
+func (k *KafkaConsumerLag) Stop() {
+	if k.client != nil {
+		k.client.Close()
+		k.client = nil
+	}
+}
+
+func (k *KafkaConsumerLag) Gather(acc telegraf.Accumulator) error {
+	if k.client == nil {
+		client, err := k.newClient(k.Brokers, k.config)
+		if err != nil {
+			return fmt.Errorf("connecting to Kafka: %v", err)
+		}
+		k.client = client
+	}
+
+	groups, err := k.client.Groups()
+	if err != nil {
+		// Connect again at the next interval
+		k.Stop()
+		return err
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		if k.filterGroups.Match(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, w := range k.windows {
+		w.seen = false
+	}
+
+	newest := newOffsetCache(k.client)
+	for _, name := range names {
+		if err := k.gatherGroup(acc, name, groups[name], newest); err != nil {
+			acc.AddError(fmt.Errorf("group %s: %v", name, err))
+		}
+	}
+
+	// Forget the partitions that are not consumed anymore
+	for key, w := range k.windows {
+		if !w.seen {
+			delete(k.windows, key)
+		}
+	}
+	return nil
+}
+
+func (k *KafkaConsumerLag) gatherGroup(acc telegraf.Accumulator, group string, info groupInfo, newest *offsetCache) error {
+	offsets, err := k.client.CommittedOffsets(group)
+	if err != nil {
+		return err
+	}
+
+	var (
+		statuses  []string
+		totalLag  int64
+		maxLag    int64
+		partCount int
+	)
+	active := info.members > 0
+
+	for _, topic := range sortedTopics(offsets) {
+		if !k.filterTopics.Match(topic) {
+			continue
+		}
+		for partition, offset := range offsets[topic] {
+			// No offset committed for the partition
+			if offset < 0 {
+				continue
+			}
+
+			end, err := newest.get(topic, partition)
+			if err != nil {
+				acc.AddError(fmt.Errorf("newest offset of %s/%d: %v", topic, partition, err))
+				continue
+			}
+			lag := end - offset
+			if lag < 0 {
+				lag = 0
+			}
+
+			key := partitionKey{group: group, topic: topic, partition: partition}
+			w, ok := k.windows[key]
+			if !ok {
+				w = &window{size: k.EvaluationWindow}
+				k.windows[key] = w
+			}
+			w.add(sample{offset: offset, lag: lag})
+			w.seen = true
+			status := w.evaluate(active)
+
+			acc.AddFields("kafka_consumer_lag_partition",
+				map[string]interface{}{
+					"offset":         offset,
+					"log_end_offset": end,
+					"lag":            lag,
+					"status":         status,
+					"status_code":    mapStatusToCode(status),
+				},
+				map[string]string{
+					"group":     group,
+					"topic":     topic,
+					"partition": strconv.Itoa(int(partition)),
+				})
+
+			statuses = append(statuses, status)
+			totalLag += lag
+			if lag > maxLag {
+				maxLag = lag
+			}
+			partCount++
+		}
+	}
+
+	if partCount == 0 {
+		return nil
+	}
+
+	status := groupStatus(statuses)
+	acc.AddFields("kafka_consumer_lag_group",
+		map[string]interface{}{
+			"status":          status,
+			"status_code":     mapStatusToCode(status),
+			"partition_count": partCount,
+			"total_lag":       totalLag,
+			"max_lag":         maxLag,
+			"members":         info.members,
+		},
+		map[string]string{
+			"group": group,
+			"state": info.state,
+		})
+	return nil
+}
+
+func sortedTopics(offsets map[string]map[int32]int64) []string {
+	topics := make([]string, 0, len(offsets))
+	for topic := range offsets {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// offsetCache reads the newest offset of each partition once per gather,
+// when consumed by several groups.
+type offsetCache struct {
+	client  offsetClient
+	offsets map[string]map[int32]int64
+}
+
+func newOffsetCache(client offsetClient) *offsetCache {
+	return &offsetCache{
+		client:  client,
+		offsets: make(map[string]map[int32]int64),
+	}
+}
+
+func (c *offsetCache) get(topic string, partition int32) (int64, error) {
+	if offset, ok := c.offsets[topic][partition]; ok {
+		return offset, nil
+	}
+	offset, err := c.client.NewestOffset(topic, partition)
+	if err != nil {
+		return 0, err
+	}
+	if c.offsets[topic] == nil {
+		c.offsets[topic] = make(map[int32]int64)
+	}
+	c.offsets[topic][partition] = offset
+	return offset, nil
+}
+
+// saramaClient reads the offsets with the admin API of Kafka.
+type saramaClient struct {
+	client sarama.Client
+	admin  sarama.ClusterAdmin
+	mu     sync.Mutex
+}
+
+func newSaramaClient(brokers []string, config *sarama.Config) (offsetClient, error) {
+	client, err := sarama.NewClient(brokers, config)
+	if err != nil {
+		return nil, err
+	}
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &saramaClient{client: client, admin: admin}, nil
+}
+
+func (c *saramaClient) Groups() (map[string]groupInfo, error) {
+	list, err := c.admin.ListConsumerGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(list))
+	for name := range list {
+		names = append(names, name)
+	}
+	descriptions, err := c.admin.DescribeConsumerGroups(names)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]groupInfo, len(names))
+	for _, name := range names {
+		groups[name] = groupInfo{}
+	}
+	for _, d := range descriptions {
+		groups[d.GroupId] = groupInfo{state: d.State, members: len(d.Members)}
+	}
+	return groups, nil
+}
+
+func (c *saramaClient) CommittedOffsets(group string) (map[string]map[int32]int64, error) {
+	// All the partitions are fetched without listing them
+	resp, err := c.admin.ListConsumerGroupOffsets(group, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Err != sarama.ErrNoError {
+		return nil, resp.Err
+	}
+
+	offsets := make(map[string]map[int32]int64, len(resp.Blocks))
+	for topic, partitions := range resp.Blocks {
+		offsets[topic] = make(map[int32]int64, len(partitions))
+		for partition, block := range partitions {
+			if block.Err != sarama.ErrNoError {
+				continue
+			}
+			offsets[topic][partition] = block.Offset
+		}
+	}
+	return offsets, nil
+}
+
+func (c *saramaClient) NewestOffset(topic string, partition int32) (int64, error) {
+	return c.client.GetOffset(topic, partition, sarama.OffsetNewest)
+}
+
+func (c *saramaClient) Close() error {
+	return c.admin.Close()
+}
+
+func init() {
+	inputs.Add("kafka_consumer_lag", func() telegraf.Input {
+		return &KafkaConsumerLag{
+			EvaluationWindow: 10,
+		}
+	})
+}
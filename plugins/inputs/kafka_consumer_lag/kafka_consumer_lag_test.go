@@ -0,0 +1,191 @@
+package kafka_consumer_lag
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name     string
+		samples  []sample
+		active   bool
+		expected string
+	}{
+		{
+			name:     "caught up",
+			samples:  []sample{{10, 5}, {10, 0}, {10, 5}},
+			expected: statusOK,
+		},
+		{
+			name:     "single sample",
+			samples:  []sample{{10, 5}},
+			expected: statusOK,
+		},
+		{
+			name:     "offset rewind",
+			samples:  []sample{{10, 5}, {20, 5}, {15, 10}},
+			expected: statusErr,
+		},
+		{
+			name:     "window not full",
+			samples:  []sample{{10, 5}, {10, 6}},
+			expected: statusOK,
+		},
+		{
+			name:     "stopped",
+			samples:  []sample{{10, 5}, {10, 6}, {10, 7}},
+			expected: statusStop,
+		},
+		{
+			name:     "stalled",
+			samples:  []sample{{10, 5}, {10, 6}, {10, 7}},
+			active:   true,
+			expected: statusStall,
+		},
+		{
+			name:     "lagging",
+			samples:  []sample{{10, 5}, {11, 6}, {12, 7}},
+			active:   true,
+			expected: statusWarn,
+		},
+		{
+			name:     "keeping up",
+			samples:  []sample{{10, 5}, {12, 6}, {15, 4}},
+			active:   true,
+			expected: statusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &window{size: 3}
+			for _, s := range tt.samples {
+				w.add(s)
+			}
+			require.Equal(t, tt.expected, w.evaluate(tt.active))
+		})
+	}
+}
+
+func TestWindowSlides(t *testing.T) {
+	w := &window{size: 2}
+	w.add(sample{1, 1})
+	w.add(sample{2, 1})
+	w.add(sample{3, 1})
+	require.Equal(t, []sample{{2, 1}, {3, 1}}, w.samples)
+}
+
+func TestGroupStatus(t *testing.T) {
+	require.Equal(t, statusOK, groupStatus([]string{statusOK, statusOK}))
+	require.Equal(t, statusWarn, groupStatus([]string{statusOK, statusWarn}))
+	require.Equal(t, statusErr, groupStatus([]string{statusWarn, statusStall}))
+}
+
+type fakeClient struct {
+	groups    map[string]groupInfo
+	committed map[string]map[string]map[int32]int64
+	newest    map[string]map[int32]int64
+	closed    bool
+}
+
+func (c *fakeClient) Groups() (map[string]groupInfo, error) {
+	return c.groups, nil
+}
+
+func (c *fakeClient) CommittedOffsets(group string) (map[string]map[int32]int64, error) {
+	return c.committed[group], nil
+}
+
+func (c *fakeClient) NewestOffset(topic string, partition int32) (int64, error) {
+	return c.newest[topic][partition], nil
+}
+
+func (c *fakeClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestGather(t *testing.T) {
+	client := &fakeClient{
+		groups: map[string]groupInfo{
+			"app":     {state: "Stable", members: 2},
+			"ignored": {state: "Empty"},
+		},
+		committed: map[string]map[string]map[int32]int64{
+			"app": {
+				"events": {0: 100, 1: 200, 2: -1},
+				"other":  {0: 10},
+			},
+		},
+		newest: map[string]map[int32]int64{
+			"events": {0: 110, 1: 200},
+			"other":  {0: 10},
+		},
+	}
+
+	k := &KafkaConsumerLag{
+		Brokers:          []string{"localhost:9092"},
+		GroupsExclude:    []string{"ign*"},
+		TopicsInclude:    []string{"events"},
+		EvaluationWindow: 2,
+		Log:              testutil.Logger{},
+		newClient: func([]string, *sarama.Config) (offsetClient, error) {
+			return client, nil
+		},
+	}
+	require.NoError(t, k.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, k.Gather(&acc))
+
+	acc.AssertContainsTaggedFields(t, "kafka_consumer_lag_partition",
+		map[string]interface{}{
+			"offset":         int64(100),
+			"log_end_offset": int64(110),
+			"lag":            int64(10),
+			"status":         statusOK,
+			"status_code":    1,
+		},
+		map[string]string{"group": "app", "topic": "events", "partition": "0"})
+	acc.AssertContainsTaggedFields(t, "kafka_consumer_lag_group",
+		map[string]interface{}{
+			"status":          statusOK,
+			"status_code":     1,
+			"partition_count": 2,
+			"total_lag":       int64(10),
+			"max_lag":         int64(10),
+			"members":         2,
+		},
+		map[string]string{"group": "app", "state": "Stable"})
+	require.Equal(t, 3, len(acc.Metrics))
+
+	// The consumer does not commit while the partition grows
+	client.newest["events"][0] = 120
+	acc.ClearMetrics()
+	require.NoError(t, k.Gather(&acc))
+	acc.AssertContainsTaggedFields(t, "kafka_consumer_lag_partition",
+		map[string]interface{}{
+			"offset":         int64(100),
+			"log_end_offset": int64(120),
+			"lag":            int64(20),
+			"status":         statusStall,
+			"status_code":    6,
+		},
+		map[string]string{"group": "app", "topic": "events", "partition": "0"})
+
+	// The windows of the partitions not consumed anymore are removed
+	delete(client.committed["app"]["events"], 1)
+	require.NoError(t, k.Gather(&acc))
+	require.Equal(t, 1, len(k.windows))
+
+	k.Stop()
+	require.True(t, client.closed)
+}
+
+func TestInitInvalidWindow(t *testing.T) {
+	k := &KafkaConsumerLag{EvaluationWindow: 1}
+	require.Error(t, k.Init())
+}
@@ -0,0 +1,373 @@
+package lb_pool_health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const sampleConfig = `
+  ## Interval of the health checks of the pool members.  The metrics added at
+  ## each collection interval aggregate the checks made since the previous one.
+  # check_interval = "1s"
+
+  ## Timeout of each health check.
+  # timeout = "1s"
+
+  ## Number of consecutive successful checks for a member to be marked up, and
+  ## of consecutive failed checks for it to be marked down.
+  # rise = 1
+  # fall = 1
+
+  ## HTTP method and expected status codes of the HTTP checks.  Any 2xx or 3xx
+  ## status is a success when no status code is set.
+  # method = "GET"
+  # status_codes = []
+
+  ## Optional TLS Config for the HTTPS checks
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## Pools and their members.  A member is either the HTTP or HTTPS URL of its
+  ## health endpoint, or a "tcp://host:port" address checked by connecting to
+  ## it.
+  [[inputs.lb_pool_health.pool]]
+    name = "web"
+    members = ["http://10.0.0.1:8080/health", "http://10.0.0.2:8080/health"]
+
+  # [[inputs.lb_pool_health.pool]]
+  #   name = "db"
+  #   members = ["tcp://10.0.1.1:5432", "tcp://10.0.1.2:5432"]
+`
+
+// maxBodySize is the maximum size of the body of an HTTP check read before
+// closing the connection.
+const maxBodySize = 64 * 1024
+
+type Pool struct {
+	Name    string   `toml:"name"`
+	Members []string `toml:"members"`
+}
+
+type LBPoolHealth struct {
+	CheckInterval internal.Duration `toml:"check_interval"`
+	Timeout       internal.Duration `toml:"timeout"`
+	Rise          int               `toml:"rise"`
+	Fall          int               `toml:"fall"`
+	Method        string            `toml:"method"`
+	StatusCodes   []int             `toml:"status_codes"`
+	Pools         []Pool            `toml:"pool"`
+	tls.ClientConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	client  *http.Client
+	members [][]*member
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// member is the health state of a pool member.  The counters are those of
+// the checks made since the last collection.
+type member struct {
+	address string
+	check   func(ctx context.Context) error
+
+	known     bool
+	up        bool
+	successes int
+	failures  int
+
+	checks       int64
+	failed       int64
+	flaps        int64
+	responseTime time.Duration
+}
+
+func (l *LBPoolHealth) Description() string {
+	return "Check the health of the members of load balancer pools"
+}
+
+func (l *LBPoolHealth) SampleConfig() string {
+	return sampleConfig
+}
+
+func (l *LBPoolHealth) Init() error {
+	if l.CheckInterval.Duration <= 0 {
+		return errors.New("check_interval must be positive")
+	}
+	if l.Timeout.Duration <= 0 {
+		return errors.New("timeout must be positive")
+	}
+	if l.Rise < 1 || l.Fall < 1 {
+		return errors.New("rise and fall must be positive")
+	}
+	if len(l.Pools) == 0 {
+		return errors.New("no pool configured")
+	}
+
+	tlsConfig, err := l.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	l.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+		// A redirect is the response of the member.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	names := make(map[string]bool, len(l.Pools))
+	l.members = make([][]*member, 0, len(l.Pools))
+	for _, pool := range l.Pools {
+		if pool.Name == "" {
+			return errors.New("pool name must be set")
+		}
+		if names[pool.Name] {
+			return fmt.Errorf("duplicate pool %q", pool.Name)
+		}
+		names[pool.Name] = true
+		if len(pool.Members) == 0 {
+			return fmt.Errorf("pool %q has no member", pool.Name)
+		}
+
+		members := make([]*member, 0, len(pool.Members))
+		for _, address := range pool.Members {
+			check, err := l.newCheck(address)
+			if err != nil {
+				return fmt.Errorf("pool %q: %v", pool.Name, err)
+			}
+			members = append(members, &member{address: address, check: check})
+		}
+		l.members = append(l.members, members)
+	}
+	return nil
+}
+
+// newCheck returns the health check of the member address.
+func (l *LBPoolHealth) newCheck(address string) (func(ctx context.Context) error, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid member %q: %v", address, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return func(ctx context.Context) error {
+			return l.checkHTTP(ctx, address)
+		}, nil
+	case "tcp":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid member %q: missing address", address)
+		}
+		return func(ctx context.Context) error {
+			return checkTCP(ctx, u.Host)
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid member %q: unsupported scheme %q", address, u.Scheme)
+	}
+}
+
+func (l *LBPoolHealth) checkHTTP(ctx context.Context, address string) error {
+	req, err := http.NewRequest(l.Method, address, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := l.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxBodySize))
+
+	if len(l.StatusCodes) == 0 {
+		if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+			return fmt.Errorf("unexpected status %q", resp.Status)
+		}
+		return nil
+	}
+	for _, code := range l.StatusCodes {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+	return fmt.Errorf("unexpected status %q", resp.Status)
+}
+
+func checkTCP(ctx context.Context, address string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (l *LBPoolHealth) Start(_ telegraf.Accumulator) error {
+	var ctx context.Context
+	ctx, l.cancel = context.WithCancel(context.Background())
+	for _, members := range l.members {
+		for _, m := range members {
+			l.wg.Add(1)
+			go func(m *member) {
+				defer l.wg.Done()
+				l.monitor(ctx, m)
+			}(m)
+		}
+	}
+	return nil
+}
+
+// monitor checks the member at each check interval until the context is
+// done.
+func (l *LBPoolHealth) monitor(ctx context.Context, m *member) {
+	ticker := time.NewTicker(l.CheckInterval.Duration)
+	defer ticker.Stop()
+
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, l.Timeout.Duration)
+		start := time.Now()
+		err := m.check(checkCtx)
+		elapsed := time.Since(start)
+		cancel()
+
+		// The check interrupted by Stop is not a failure of the member.
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			l.Log.Debugf("Check of %q failed: %v", m.address, err)
+		}
+
+		l.mu.Lock()
+		m.record(err, elapsed, l.Rise, l.Fall)
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// record updates the state of the member with the result of a check.  The
+// first check sets the state, which then changes after rise consecutive
+// successful or fall consecutive failed checks, counting a flap.
+func (m *member) record(err error, elapsed time.Duration, rise, fall int) {
+	m.checks++
+	m.responseTime += elapsed
+	if err != nil {
+		m.failed++
+		m.failures++
+		m.successes = 0
+	} else {
+		m.successes++
+		m.failures = 0
+	}
+
+	switch {
+	case !m.known:
+		m.known = true
+		m.up = err == nil
+	case m.up && m.failures >= fall:
+		m.up = false
+		m.flaps++
+	case !m.up && m.successes >= rise:
+		m.up = true
+		m.flaps++
+	}
+}
+
+// Gather adds the state of the members and the availability of the pools,
+// and resets the counters of the checks.
+func (l *LBPoolHealth) Gather(acc telegraf.Accumulator) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for i, pool := range l.Pools {
+		var up, checks, failed, flaps int64
+		for _, m := range l.members[i] {
+			fields := map[string]interface{}{
+				"up":            m.up,
+				"checks":        m.checks,
+				"checks_failed": m.failed,
+				"flaps":         m.flaps,
+			}
+			if m.checks > 0 {
+				avg := m.responseTime / time.Duration(m.checks)
+				fields["response_time_ms"] = float64(avg) / float64(time.Millisecond)
+			}
+			tags := map[string]string{
+				"pool":   pool.Name,
+				"member": m.address,
+			}
+			acc.AddFields("lb_pool_health_member", fields, tags, now)
+
+			if m.up {
+				up++
+			}
+			checks += m.checks
+			failed += m.failed
+			flaps += m.flaps
+
+			m.checks = 0
+			m.failed = 0
+			m.flaps = 0
+			m.responseTime = 0
+		}
+
+		members := int64(len(l.members[i]))
+		fields := map[string]interface{}{
+			"members":      members,
+			"members_up":   up,
+			"availability": float64(up) / float64(members),
+			"flaps":        flaps,
+		}
+		if checks > 0 {
+			fields["check_success_ratio"] = float64(checks-failed) / float64(checks)
+		}
+		acc.AddFields("lb_pool_health", fields, map[string]string{"pool": pool.Name}, now)
+	}
+	return nil
+}
+
+func (l *LBPoolHealth) Stop() {
+	l.cancel()
+	l.wg.Wait()
+}
+
+func init() {
+	inputs.Add("lb_pool_health", func() telegraf.Input {
+		return &LBPoolHealth{
+			CheckInterval: internal.Duration{Duration: time.Second},
+			Timeout:       internal.Duration{Duration: time.Second},
+			Rise:          1,
+			Fall:          1,
+			Method:        "GET",
+		}
+	})
+}
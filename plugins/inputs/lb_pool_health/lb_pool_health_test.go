@@ -0,0 +1,229 @@
+package lb_pool_health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newPlugin(pools ...Pool) *LBPoolHealth {
+	return &LBPoolHealth{
+		CheckInterval: internal.Duration{Duration: 10 * time.Millisecond},
+		Timeout:       internal.Duration{Duration: time.Second},
+		Rise:          1,
+		Fall:          1,
+		Method:        "GET",
+		Pools:         pools,
+		Log:           testutil.Logger{},
+	}
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name  string
+		pools []Pool
+		err   bool
+	}{
+		{
+			name:  "valid",
+			pools: []Pool{{Name: "web", Members: []string{"http://127.0.0.1/health", "tcp://127.0.0.1:80"}}},
+		},
+		{
+			name: "no pool",
+			err:  true,
+		},
+		{
+			name:  "no name",
+			pools: []Pool{{Members: []string{"tcp://127.0.0.1:80"}}},
+			err:   true,
+		},
+		{
+			name: "duplicate pool",
+			pools: []Pool{
+				{Name: "web", Members: []string{"tcp://127.0.0.1:80"}},
+				{Name: "web", Members: []string{"tcp://127.0.0.1:81"}},
+			},
+			err: true,
+		},
+		{
+			name:  "no member",
+			pools: []Pool{{Name: "web"}},
+			err:   true,
+		},
+		{
+			name:  "unsupported scheme",
+			pools: []Pool{{Name: "web", Members: []string{"udp://127.0.0.1:80"}}},
+			err:   true,
+		},
+		{
+			name:  "tcp without address",
+			pools: []Pool{{Name: "web", Members: []string{"tcp:///"}}},
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newPlugin(tt.pools...).Init()
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	failed := errors.New("connection refused")
+	m := &member{}
+
+	// The first check sets the state without flapping.
+	m.record(nil, time.Millisecond, 2, 3)
+	require.True(t, m.up)
+	require.Equal(t, int64(0), m.flaps)
+
+	m.record(failed, time.Millisecond, 2, 3)
+	m.record(failed, time.Millisecond, 2, 3)
+	require.True(t, m.up)
+	m.record(failed, time.Millisecond, 2, 3)
+	require.False(t, m.up)
+	require.Equal(t, int64(1), m.flaps)
+
+	m.record(nil, time.Millisecond, 2, 3)
+	require.False(t, m.up)
+	m.record(nil, time.Millisecond, 2, 3)
+	require.True(t, m.up)
+	require.Equal(t, int64(2), m.flaps)
+
+	require.Equal(t, int64(6), m.checks)
+	require.Equal(t, int64(3), m.failed)
+	require.Equal(t, 6*time.Millisecond, m.responseTime)
+}
+
+func TestCheckHTTP(t *testing.T) {
+	var status int32 = http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer ts.Close()
+
+	plugin := newPlugin(Pool{Name: "web", Members: []string{ts.URL}})
+	require.NoError(t, plugin.Init())
+	check := plugin.members[0][0].check
+
+	require.NoError(t, check(context.Background()))
+
+	atomic.StoreInt32(&status, http.StatusFound)
+	require.NoError(t, check(context.Background()))
+
+	atomic.StoreInt32(&status, http.StatusServiceUnavailable)
+	require.Error(t, check(context.Background()))
+
+	plugin.StatusCodes = []int{http.StatusServiceUnavailable}
+	require.NoError(t, check(context.Background()))
+}
+
+func TestCheckTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+
+	plugin := newPlugin(Pool{Name: "db", Members: []string{"tcp://" + addr}})
+	require.NoError(t, plugin.Init())
+	check := plugin.members[0][0].check
+
+	require.NoError(t, check(context.Background()))
+
+	listener.Close()
+	require.Error(t, check(context.Background()))
+}
+
+func TestGather(t *testing.T) {
+	plugin := newPlugin(Pool{Name: "web", Members: []string{"tcp://10.0.0.1:80", "tcp://10.0.0.2:80"}})
+	require.NoError(t, plugin.Init())
+
+	first, second := plugin.members[0][0], plugin.members[0][1]
+	first.record(nil, 2*time.Millisecond, 1, 1)
+	first.record(nil, 4*time.Millisecond, 1, 1)
+	second.record(nil, time.Millisecond, 1, 1)
+	second.record(errors.New("timeout"), time.Millisecond, 1, 1)
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+
+	acc.AssertContainsTaggedFields(t, "lb_pool_health_member",
+		map[string]interface{}{
+			"up":               true,
+			"checks":           int64(2),
+			"checks_failed":    int64(0),
+			"flaps":            int64(0),
+			"response_time_ms": 3.0,
+		},
+		map[string]string{"pool": "web", "member": "tcp://10.0.0.1:80"})
+	acc.AssertContainsTaggedFields(t, "lb_pool_health_member",
+		map[string]interface{}{
+			"up":               false,
+			"checks":           int64(2),
+			"checks_failed":    int64(1),
+			"flaps":            int64(1),
+			"response_time_ms": 1.0,
+		},
+		map[string]string{"pool": "web", "member": "tcp://10.0.0.2:80"})
+	acc.AssertContainsTaggedFields(t, "lb_pool_health",
+		map[string]interface{}{
+			"members":             int64(2),
+			"members_up":          int64(1),
+			"availability":        0.5,
+			"check_success_ratio": 0.75,
+			"flaps":               int64(1),
+		},
+		map[string]string{"pool": "web"})
+
+	// The counters are reset at each collection, the state is kept.
+	acc.ClearMetrics()
+	require.NoError(t, plugin.Gather(&acc))
+	acc.AssertContainsTaggedFields(t, "lb_pool_health",
+		map[string]interface{}{
+			"members":      int64(2),
+			"members_up":   int64(1),
+			"availability": 0.5,
+			"flaps":        int64(0),
+		},
+		map[string]string{"pool": "web"})
+}
+
+func TestStartStop(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	plugin := newPlugin(Pool{Name: "web", Members: []string{ts.URL}})
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	require.Eventually(t, func() bool {
+		plugin.mu.Lock()
+		defer plugin.mu.Unlock()
+		return plugin.members[0][0].checks >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, plugin.Gather(&acc))
+	fields, ok := acc.Get("lb_pool_health")
+	require.True(t, ok)
+	require.Equal(t, 1.0, fields.Fields["availability"])
+	require.Equal(t, 1.0, fields.Fields["check_success_ratio"])
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/influxdata/tail"
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/globpath"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
@@ -32,6 +33,11 @@ type GrokConfig struct {
 	CustomPatternFiles []string
 	Timezone           string
 	UniqueTimestamp    string
+	PatternSets        []string
+	ReloadInterval     internal.Duration
+	TimestampLocale    string
+	TimestampFallbacks []string
+	InheritTimestamp   bool
 }
 
 type logEntry struct {
@@ -125,6 +131,27 @@ const sampleConfig = `
 	## When set to "disable", timestamp will not incremented if there is a
 	## duplicate.
     # unique_timestamp = "auto"
+
+    ## Bundled pattern sets to load, available sets are "haproxy", "java",
+    ## "nginx" and "postgres".
+    # pattern_sets = []
+
+    ## Interval at which the custom pattern files are checked for changes,
+    ## they are reloaded when modified.  Disabled when unset.
+    # reload_interval = "1m"
+
+    ## Language of month and weekday names in timestamps, one of "de", "es",
+    ## "fr", "it" or "nl".  Default: "" for English.
+    # timestamp_locale = ""
+
+    ## Timestamp layouts, or built-in timestamp modifiers such as
+    ## "ts-rfc3339", tried in order when a timestamp does not match the
+    ## layout of its pattern.
+    # timestamp_fallbacks = []
+
+    ## Use the timestamp of the last line with a timestamp for lines
+    ## without one, such as continuation lines of multiline messages.
+    # inherit_timestamp = false
 `
 
 // SampleConfig returns the sample configuration for the plugin
@@ -175,6 +202,11 @@ func (l *LogParserPlugin) Start(acc telegraf.Accumulator) error {
 		GrokCustomPatternFiles: l.GrokConfig.CustomPatternFiles,
 		GrokTimezone:           l.GrokConfig.Timezone,
 		GrokUniqueTimestamp:    l.GrokConfig.UniqueTimestamp,
+		GrokPatternSets:        l.GrokConfig.PatternSets,
+		GrokReloadInterval:     l.GrokConfig.ReloadInterval.Duration,
+		GrokTimestampLocale:    l.GrokConfig.TimestampLocale,
+		GrokTimestampFallbacks: l.GrokConfig.TimestampFallbacks,
+		GrokInheritTimestamp:   l.GrokConfig.InheritTimestamp,
 		DataFormat:             "grok",
 	}
 
@@ -0,0 +1,43 @@
+package multipath
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+)
+
+// Multipath is used to store configuration values.
+type Multipath struct {
+	Socket  string            `toml:"socket"`
+	Timeout internal.Duration `toml:"timeout"`
+	Log     telegraf.Logger   `toml:"-"`
+
+	// faults holds the path fault counter and the time of the last observed
+	// failure of each map.
+	faults map[string]*faultState
+}
+
+type faultState struct {
+	count       int64
+	lastFailure time.Time
+}
+
+var sampleConfig = `
+  ## Path of the multipathd socket; a leading '@' denotes a socket in the
+  ## abstract namespace.
+  # socket = "@/org/kernel/linux/storage/multipathd"
+
+  ## Timeout for the multipathd command to complete.
+  # timeout = "5s"
+`
+
+// Description returns information about the plugin.
+func (m *Multipath) Description() string {
+	return "Gather the path state of dm-multipath maps from multipathd"
+}
+
+// SampleConfig displays configuration instructions.
+func (m *Multipath) SampleConfig() string {
+	return sampleConfig
+}
@@ -0,0 +1,236 @@
+// +build linux
+
+package multipath
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// default multipathd socket in the abstract namespace
+const defaultSocket = "@/org/kernel/linux/storage/multipathd"
+
+// maximum size of a reply accepted from multipathd
+const maxReplySize = 64 * 1024 * 1024
+
+type mapsReply struct {
+	Maps []mpath `json:"maps"`
+}
+
+type mpath struct {
+	Name       string      `json:"name"`
+	UUID       string      `json:"uuid"`
+	Sysfs      string      `json:"sysfs"`
+	DMState    string      `json:"dm_st"`
+	PathFaults int64       `json:"path_faults"`
+	Vendor     string      `json:"vend"`
+	Product    string      `json:"prod"`
+	PathGroups []pathGroup `json:"path_groups"`
+}
+
+type pathGroup struct {
+	Group   int    `json:"group"`
+	DMState string `json:"dm_st"`
+	Paths   []path `json:"paths"`
+}
+
+type path struct {
+	Dev          string `json:"dev"`
+	DMState      string `json:"dm_st"`
+	DevState     string `json:"dev_st"`
+	CheckerState string `json:"chk_st"`
+}
+
+func (m *Multipath) Init() error {
+	if m.Socket == "" {
+		m.Socket = defaultSocket
+	}
+	if m.Timeout.Duration == 0 {
+		m.Timeout.Duration = 5 * time.Second
+	}
+	m.faults = make(map[string]*faultState)
+	return nil
+}
+
+// Gather collects the path state of all multipath maps.
+func (m *Multipath) Gather(acc telegraf.Accumulator) error {
+	reply, err := m.command("show maps json")
+	if err != nil {
+		return err
+	}
+
+	var maps mapsReply
+	if err := json.Unmarshal([]byte(reply), &maps); err != nil {
+		return fmt.Errorf("parsing reply of multipathd: %v", err)
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool, len(maps.Maps))
+	for _, mp := range maps.Maps {
+		seen[mp.Name] = true
+		m.gatherMap(mp, now, acc)
+	}
+	for name := range m.faults {
+		if !seen[name] {
+			delete(m.faults, name)
+		}
+	}
+	return nil
+}
+
+func (m *Multipath) gatherMap(mp mpath, now time.Time, acc telegraf.Accumulator) {
+	tags := map[string]string{
+		"map":  mp.Name,
+		"wwid": mp.UUID,
+	}
+	if mp.Sysfs != "" {
+		tags["dm"] = mp.Sysfs
+	}
+	if mp.Vendor != "" {
+		tags["vendor"] = mp.Vendor
+	}
+	if mp.Product != "" {
+		tags["product"] = mp.Product
+	}
+
+	var paths, active, failed int64
+	for _, group := range mp.PathGroups {
+		for _, p := range group.Paths {
+			paths++
+			switch p.DMState {
+			case "active":
+				active++
+			case "failed":
+				failed++
+			}
+
+			pathTags := map[string]string{
+				"map":        mp.Name,
+				"device":     p.Dev,
+				"path_group": strconv.Itoa(group.Group),
+			}
+			pathFields := map[string]interface{}{
+				"dm_state":      p.DMState,
+				"device_state":  p.DevState,
+				"checker_state": p.CheckerState,
+				"active":        p.DMState == "active",
+			}
+			acc.AddFields("multipath_path", pathFields, pathTags, now)
+		}
+	}
+
+	// multipathd does not keep the time of the last failure, so the time a
+	// new fault is observed is used instead.
+	state, ok := m.faults[mp.Name]
+	if !ok {
+		state = &faultState{count: mp.PathFaults}
+		if failed > 0 {
+			state.lastFailure = now
+		}
+		m.faults[mp.Name] = state
+	} else if mp.PathFaults > state.count {
+		state.lastFailure = now
+	}
+	state.count = mp.PathFaults
+
+	fields := map[string]interface{}{
+		"dm_state":     mp.DMState,
+		"paths":        paths,
+		"active_paths": active,
+		"failed_paths": failed,
+		"path_groups":  len(mp.PathGroups),
+		"path_faults":  mp.PathFaults,
+	}
+	if !state.lastFailure.IsZero() {
+		fields["last_failure"] = state.lastFailure.Unix()
+	}
+	acc.AddFields("multipath", fields, tags, now)
+}
+
+// command sends a command to multipathd and returns the reply.  Both are
+// prefixed with their length as a native size_t.
+func (m *Multipath) command(cmd string) (string, error) {
+	conn, err := net.DialTimeout("unix", m.Socket, m.Timeout.Duration)
+	if err != nil {
+		return "", fmt.Errorf("connecting to multipathd: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(m.Timeout.Duration)); err != nil {
+		return "", err
+	}
+
+	// The length includes the terminating NUL character.
+	msg := append([]byte(cmd), 0)
+	if err := writeSize(conn, len(msg)); err != nil {
+		return "", err
+	}
+	if _, err := conn.Write(msg); err != nil {
+		return "", err
+	}
+
+	size, err := readSize(conn)
+	if err != nil {
+		return "", fmt.Errorf("reading reply of multipathd: %v", err)
+	}
+	if size > maxReplySize {
+		return "", fmt.Errorf("reply of multipathd too large: %d bytes", size)
+	}
+	reply := make([]byte, size)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return "", fmt.Errorf("reading reply of multipathd: %v", err)
+	}
+
+	s := strings.TrimRight(string(reply), "\x00\n")
+	if strings.HasPrefix(s, "fail") {
+		return "", fmt.Errorf("multipathd: %s", s)
+	}
+	return s, nil
+}
+
+// nativeEndian is the byte order of the host, used by multipathd for the
+// length prefix.
+var nativeEndian binary.ByteOrder = func() binary.ByteOrder {
+	x := uint16(1)
+	if *(*byte)(unsafe.Pointer(&x)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}()
+
+func writeSize(w io.Writer, size int) error {
+	buf := make([]byte, strconv.IntSize/8)
+	if strconv.IntSize == 64 {
+		nativeEndian.PutUint64(buf, uint64(size))
+	} else {
+		nativeEndian.PutUint32(buf, uint32(size))
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+func readSize(r io.Reader) (uint64, error) {
+	buf := make([]byte, strconv.IntSize/8)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, err
+	}
+	if strconv.IntSize == 64 {
+		return nativeEndian.Uint64(buf), nil
+	}
+	return uint64(nativeEndian.Uint32(buf)), nil
+}
+
+func init() {
+	inputs.Add("multipath", func() telegraf.Input {
+		return &Multipath{}
+	})
+}
@@ -0,0 +1,23 @@
+// +build !linux
+
+package multipath
+
+import (
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+func (m *Multipath) Init() error {
+	m.Log.Warn("Current platform is not supported")
+	return nil
+}
+
+func (m *Multipath) Gather(acc telegraf.Accumulator) error {
+	return nil
+}
+
+func init() {
+	inputs.Add("multipath", func() telegraf.Input {
+		return &Multipath{}
+	})
+}
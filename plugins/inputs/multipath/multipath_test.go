@@ -0,0 +1,210 @@
+// +build linux
+
+package multipath
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const mapsHealthy = `{
+   "major_version": 0,
+   "minor_version": 1,
+   "maps": [{
+      "name" : "mpatha",
+      "uuid" : "3624a93701c0d5fa4a2384e2b00011a3e",
+      "sysfs" : "dm-0",
+      "dm_st" : "active",
+      "path_faults" : 3,
+      "vend" : "PURE",
+      "prod" : "FlashArray",
+      "path_groups": [{
+         "selector" : "service-time 0",
+         "pri" : 50,
+         "dm_st" : "active",
+         "group" : 1,
+         "paths": [{
+            "dev" : "sdb",
+            "dev_t" : "8:16",
+            "dm_st" : "active",
+            "dev_st" : "running",
+            "chk_st" : "ready"
+         },{
+            "dev" : "sdc",
+            "dev_t" : "8:32",
+            "dm_st" : "failed",
+            "dev_st" : "running",
+            "chk_st" : "faulty"
+         }]
+      }]
+   }]
+}
+`
+
+// serve answers each connection on the socket with the next reply.
+func serve(t *testing.T, l net.Listener, replies []string) {
+	for _, reply := range replies {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		size, err := readSize(conn)
+		require.NoError(t, err)
+		cmd := make([]byte, size)
+		_, err = io.ReadFull(conn, cmd)
+		require.NoError(t, err)
+		require.Equal(t, "show maps json\x00", string(cmd))
+
+		require.NoError(t, writeSize(conn, len(reply)+1))
+		_, err = conn.Write(append([]byte(reply), 0))
+		require.NoError(t, err)
+		conn.Close()
+	}
+}
+
+func newSocket(t *testing.T) (string, net.Listener) {
+	dir, err := ioutil.TempDir("", "multipath")
+	require.NoError(t, err)
+	socket := filepath.Join(dir, "multipathd.sock")
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	return dir, l
+}
+
+func TestGather(t *testing.T) {
+	dir, l := newSocket(t)
+	defer os.RemoveAll(dir)
+	defer l.Close()
+	go serve(t, l, []string{mapsHealthy})
+
+	plugin := &Multipath{
+		Socket:  l.Addr().String(),
+		Timeout: internal.Duration{Duration: time.Second},
+		Log:     testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+
+	now := time.Now()
+	expected := []telegraf.Metric{
+		testutil.MustMetric("multipath",
+			map[string]string{
+				"map":     "mpatha",
+				"wwid":    "3624a93701c0d5fa4a2384e2b00011a3e",
+				"dm":      "dm-0",
+				"vendor":  "PURE",
+				"product": "FlashArray",
+			},
+			map[string]interface{}{
+				"dm_state":     "active",
+				"paths":        int64(2),
+				"active_paths": int64(1),
+				"failed_paths": int64(1),
+				"path_groups":  int64(1),
+				"path_faults":  int64(3),
+				"last_failure": now.Unix(),
+			},
+			now,
+		),
+		testutil.MustMetric("multipath_path",
+			map[string]string{
+				"map":        "mpatha",
+				"device":     "sdb",
+				"path_group": "1",
+			},
+			map[string]interface{}{
+				"dm_state":      "active",
+				"device_state":  "running",
+				"checker_state": "ready",
+				"active":        true,
+			},
+			now,
+		),
+		testutil.MustMetric("multipath_path",
+			map[string]string{
+				"map":        "mpatha",
+				"device":     "sdc",
+				"path_group": "1",
+			},
+			map[string]interface{}{
+				"dm_state":      "failed",
+				"device_state":  "running",
+				"checker_state": "faulty",
+				"active":        false,
+			},
+			now,
+		),
+	}
+	actual := acc.GetTelegrafMetrics()
+	for _, m := range actual {
+		if v, ok := m.GetField("last_failure"); ok {
+			require.InDelta(t, now.Unix(), v, 1)
+			m.AddField("last_failure", now.Unix())
+		}
+	}
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestGatherLastFailure(t *testing.T) {
+	dir, l := newSocket(t)
+	defer os.RemoveAll(dir)
+	defer l.Close()
+
+	noFaults := `{"maps": [{"name": "mpatha", "dm_st": "active", "path_faults": 0,
+		"path_groups": [{"group": 1, "paths": [{"dev": "sdb", "dm_st": "active"}]}]}]}`
+	newFault := `{"maps": [{"name": "mpatha", "dm_st": "active", "path_faults": 1,
+		"path_groups": [{"group": 1, "paths": [{"dev": "sdb", "dm_st": "active"}]}]}]}`
+	go serve(t, l, []string{noFaults, newFault, newFault})
+
+	plugin := &Multipath{
+		Socket:  l.Addr().String(),
+		Timeout: internal.Duration{Duration: time.Second},
+		Log:     testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.False(t, acc.HasField("multipath", "last_failure"))
+
+	acc.ClearMetrics()
+	require.NoError(t, plugin.Gather(&acc))
+	require.True(t, acc.HasField("multipath", "last_failure"))
+	first, _ := acc.Get("multipath")
+
+	// The failure time is kept while the fault counter is unchanged.
+	acc.ClearMetrics()
+	require.NoError(t, plugin.Gather(&acc))
+	second, _ := acc.Get("multipath")
+	require.Equal(t, first.Fields["last_failure"], second.Fields["last_failure"])
+}
+
+func TestGatherCommandFailed(t *testing.T) {
+	dir, l := newSocket(t)
+	defer os.RemoveAll(dir)
+	defer l.Close()
+	go serve(t, l, []string{"fail\n"})
+
+	plugin := &Multipath{
+		Socket:  l.Addr().String(),
+		Timeout: internal.Duration{Duration: time.Second},
+		Log:     testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.Error(t, plugin.Gather(&acc))
+}
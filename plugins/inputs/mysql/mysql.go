@@ -2,11 +2,17 @@ package mysql
 
 import (
 	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
 	"database/sql"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
@@ -37,14 +43,21 @@ type Mysql struct {
 	GatherFileEventsStats               bool     `toml:"gather_file_events_stats"`
 	GatherPerfEventsStatements          bool     `toml:"gather_perf_events_statements"`
 	GatherGlobalVars                    bool     `toml:"gather_global_variables"`
+	GatherGroupReplication              bool     `toml:"gather_group_replication"`
+	GatherCloneStatus                   bool     `toml:"gather_clone_status"`
+	GatherInnoDBBufferPoolStats         bool     `toml:"gather_innodb_buffer_pool_stats"`
+	PerfEventsStatementsNormalize       bool     `toml:"perf_events_statements_normalize"`
 	IntervalSlow                        string   `toml:"interval_slow"`
 	MetricVersion                       int      `toml:"metric_version"`
+	ServerPubKey                        string   `toml:"server_pub_key"`
 
 	Log telegraf.Logger `toml:"-"`
 	tls.ClientConfig
 	lastT            time.Time
 	initDone         bool
 	scanIntervalSlow uint32
+	tlsName          string
+	pubKeyName       string
 }
 
 const sampleConfig = `
@@ -121,6 +134,21 @@ const sampleConfig = `
   # perf_events_statements_limit = 250
   # perf_events_statements_time_limit = 86400
 
+  ## normalize the digest_text tag of perf_events_statements, so that the
+  ## same statement has the same text across server versions
+  # perf_events_statements_normalize = false
+
+  ## gather metrics from PERFORMANCE_SCHEMA.REPLICATION_GROUP_MEMBERS and
+  ## PERFORMANCE_SCHEMA.REPLICATION_GROUP_MEMBER_STATS
+  # gather_group_replication = false
+
+  ## gather metrics from PERFORMANCE_SCHEMA.CLONE_PROGRESS
+  # gather_clone_status = false
+
+  ## gather metrics from INFORMATION_SCHEMA.INNODB_BUFFER_POOL_STATS, for each
+  ## buffer pool instance
+  # gather_innodb_buffer_pool_stats = false
+
   ## Some queries we may want to run less often (such as SHOW GLOBAL VARIABLES)
   ##   example: interval_slow = "30m"
   # interval_slow = ""
@@ -131,6 +159,11 @@ const sampleConfig = `
   # tls_key = "/etc/telegraf/key.pem"
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
+
+  ## RSA public key of the server in PEM format, used to send the password
+  ## of caching_sha2_password and sha256_password users over connections
+  ## without TLS, instead of requesting the key from the server.
+  # server_pub_key = "/etc/telegraf/mysql_pub.pem"
 `
 
 const (
@@ -162,22 +195,18 @@ func (m *Mysql) InitMysql() {
 }
 
 func (m *Mysql) Gather(acc telegraf.Accumulator) error {
-	if len(m.Servers) == 0 {
-		// default to localhost if nothing specified.
-		return m.gatherServer(localhost, acc)
-	}
 	// Initialise additional query intervals
 	if !m.initDone {
 		m.InitMysql()
 	}
 
-	tlsConfig, err := m.ClientConfig.TLSConfig()
-	if err != nil {
-		return fmt.Errorf("registering TLS config: %s", err)
+	if err := m.registerAuth(); err != nil {
+		return err
 	}
 
-	if tlsConfig != nil {
-		mysql.RegisterTLSConfig("custom", tlsConfig)
+	if len(m.Servers) == 0 {
+		// default to localhost if nothing specified.
+		return m.gatherServer(localhost, acc)
 	}
 
 	var wg sync.WaitGroup
@@ -195,6 +224,62 @@ func (m *Mysql) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
+// registeredNames numbers the TLS configs and server public keys registered
+// in the driver, so that each plugin instance uses its own.
+var registeredNames uint64
+
+// registerAuth registers the TLS config and the server public key of the
+// plugin in the driver.
+func (m *Mysql) registerAuth() error {
+	tlsConfig, err := m.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("registering TLS config: %s", err)
+	}
+
+	if tlsConfig != nil {
+		if m.tlsName == "" {
+			m.tlsName = fmt.Sprintf("custom-%d", atomic.AddUint64(&registeredNames, 1))
+		}
+		// The servers refer to the config as "custom", which must be known
+		// when parsing them.  They are then connected with the config of
+		// this instance.
+		mysql.RegisterTLSConfig("custom", tlsConfig)
+		mysql.RegisterTLSConfig(m.tlsName, tlsConfig)
+	}
+
+	if m.ServerPubKey != "" && m.pubKeyName == "" {
+		key, err := readServerPubKey(m.ServerPubKey)
+		if err != nil {
+			return fmt.Errorf("reading server_pub_key: %s", err)
+		}
+		m.pubKeyName = fmt.Sprintf("telegraf-%d", atomic.AddUint64(&registeredNames, 1))
+		mysql.RegisterServerPubKey(m.pubKeyName, key)
+	}
+	return nil
+}
+
+// readServerPubKey reads the RSA public key in PEM format of the file.
+func readServerPubKey(path string) (*rsa.PublicKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("no PEM encoded public key in %s", path)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("the public key in %s is not an RSA key", path)
+	}
+	return key, nil
+}
+
 // These are const but can't be declared as such because golang doesn't allow const maps
 var (
 	// status counter
@@ -409,6 +494,20 @@ const (
             SCHEMA_NAME
             FROM information_schema.schemata
         WHERE SCHEMA_NAME NOT IN ('mysql', 'performance_schema', 'information_schema')
+    `
+	groupReplicationQuery = `
+        SELECT *
+        FROM performance_schema.replication_group_members
+        LEFT JOIN performance_schema.replication_group_member_stats USING (CHANNEL_NAME, MEMBER_ID)
+    `
+	cloneProgressQuery = `
+        SELECT STAGE, STATE, THREADS, ESTIMATE AS ESTIMATE_BYTES, DATA AS DATA_BYTES,
+        NETWORK AS NETWORK_BYTES, DATA_SPEED, NETWORK_SPEED
+        FROM performance_schema.clone_progress
+    `
+	innoDBBufferPoolStatsQuery = `
+        SELECT *
+        FROM information_schema.INNODB_BUFFER_POOL_STATS
     `
 	perfSchemaTablesQuery = `
 		SELECT
@@ -424,6 +523,11 @@ func (m *Mysql) gatherServer(serv string, acc telegraf.Accumulator) error {
 		return err
 	}
 
+	serv, err = m.dsnAddAuth(serv)
+	if err != nil {
+		return err
+	}
+
 	db, err := sql.Open("mysql", serv)
 	if err != nil {
 		return err
@@ -539,9 +643,127 @@ func (m *Mysql) gatherServer(serv string, acc telegraf.Accumulator) error {
 			return err
 		}
 	}
+
+	if m.GatherGroupReplication {
+		err = m.gatherTaggedRows(db, serv, acc, groupReplicationQuery, "mysql_group_replication", groupReplicationTags)
+		if err != nil {
+			return err
+		}
+	}
+
+	if m.GatherCloneStatus {
+		err = m.gatherCloneStatus(db, serv, acc)
+		if err != nil {
+			return err
+		}
+	}
+
+	if m.GatherInnoDBBufferPoolStats {
+		err = m.gatherTaggedRows(db, serv, acc, innoDBBufferPoolStatsQuery, "mysql_innodb_buffer_pool", innoDBBufferPoolTags)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+var (
+	// columns of the group replication members used as tags, the other
+	// columns holding numbers are fields
+	groupReplicationTags = map[string]bool{
+		"channel_name":   true,
+		"member_id":      true,
+		"member_host":    true,
+		"member_port":    true,
+		"member_state":   true,
+		"member_role":    true,
+		"member_version": true,
+	}
+	cloneProgressTags = map[string]bool{
+		"stage": true,
+		"state": true,
+	}
+	innoDBBufferPoolTags = map[string]bool{
+		"pool_id": true,
+	}
+)
+
+// gatherCloneStatus can be used to get the progress of the stages of the
+// running or last clone operation, the clone plugin is available since MySQL
+// 8.0.17.
+func (m *Mysql) gatherCloneStatus(db *sql.DB, serv string, acc telegraf.Accumulator) error {
+	var tableName string
+	err := db.QueryRow(perfSchemaTablesQuery, "clone_progress").Scan(&tableName)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil
+	case err != nil:
+		return err
+	}
+
+	return m.gatherTaggedRows(db, serv, acc, cloneProgressQuery, "mysql_clone", cloneProgressTags)
+}
+
+// gatherTaggedRows adds a metric for each row of the query.  The columns of
+// tagColumns are tags, and the other columns holding numbers are fields.
+func (m *Mysql) gatherTaggedRows(db *sql.DB, serv string, acc telegraf.Accumulator,
+	query string, measurement string, tagColumns map[string]bool) error {
+	rows, err := db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := columnsToLower(rows.Columns())
+	if err != nil {
+		return err
+	}
+
+	servtag := getDSNTag(serv)
+	values := make([]sql.RawBytes, len(columns))
+	scanArgs := make([]interface{}, len(columns))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return err
+		}
+
+		tags, fields := taggedRow(columns, values, tagColumns)
+		tags["server"] = servtag
+		if len(fields) > 0 {
+			acc.AddFields(measurement, fields, tags)
+		}
+	}
+	return rows.Err()
+}
+
+// taggedRow returns the tags and the numeric fields of the row.
+func taggedRow(columns []string, values []sql.RawBytes, tagColumns map[string]bool) (map[string]string, map[string]interface{}) {
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+	for i, column := range columns {
+		if values[i] == nil {
+			continue
+		}
+		if tagColumns[column] {
+			if len(values[i]) > 0 {
+				tags[column] = string(values[i])
+			}
+			continue
+		}
+
+		if v, err := strconv.ParseInt(string(values[i]), 10, 64); err == nil {
+			fields[column] = v
+		} else if v, err := strconv.ParseFloat(string(values[i]), 64); err == nil {
+			fields[column] = v
+		}
+	}
+	return tags, fields
+}
+
 // gatherGlobalVariables can be used to fetch all global variables from
 // MySQL environment.
 func (m *Mysql) gatherGlobalVariables(db *sql.DB, serv string, acc telegraf.Accumulator) error {
@@ -1527,6 +1749,10 @@ func (m *Mysql) gatherPerfEventsStatements(db *sql.DB, serv string, acc telegraf
 		if err != nil {
 			return err
 		}
+		if m.PerfEventsStatementsNormalize {
+			digest_text = normalizeDigestText(digest_text)
+		}
+
 		tags["schema"] = schemaName
 		tags["digest"] = digest
 		tags["digest_text"] = digest_text
@@ -1735,6 +1961,25 @@ func findThreadState(rawCommand, rawState string) string {
 }
 
 // newNamespace can be used to make a namespace
+var (
+	digestQuotes     = strings.NewReplacer("`", "", `"`, "")
+	digestList       = regexp.MustCompile(`\(\s*(?:\.\.\.|\?(?:\s*,\s*\?)*)\s*\)`)
+	digestRowList    = regexp.MustCompile(`\(\?\)(?:\s*,\s*\(\?\))+`)
+	digestWhitespace = regexp.MustCompile(`\s+`)
+)
+
+// normalizeDigestText normalizes the text of a statement digest, which
+// differs between MySQL versions: the quotes are removed, the lists of
+// values are replaced by "(?)", the spaces are collapsed and the text is lower
+// cased.
+func normalizeDigestText(text string) string {
+	text = digestQuotes.Replace(text)
+	text = digestList.ReplaceAllString(text, "(?)")
+	text = digestRowList.ReplaceAllString(text, "(?)")
+	text = digestWhitespace.ReplaceAllString(text, " ")
+	return strings.ToLower(strings.TrimSpace(text))
+}
+
 func newNamespace(words ...string) string {
 	return strings.Replace(strings.Join(words, "_"), " ", "_", -1)
 }
@@ -1760,6 +2005,24 @@ func dsnAddTimeout(dsn string) (string, error) {
 	return conf.FormatDSN(), nil
 }
 
+// dsnAddAuth makes the DSN use the TLS config and the server public key of
+// the plugin instance.
+func (m *Mysql) dsnAddAuth(dsn string) (string, error) {
+	conf, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+
+	if conf.TLSConfig == "custom" && m.tlsName != "" {
+		conf.TLSConfig = m.tlsName
+	}
+	if conf.ServerPubKey == "" && m.pubKeyName != "" {
+		conf.ServerPubKey = m.pubKeyName
+	}
+
+	return conf.FormatDSN(), nil
+}
+
 func getDSNTag(dsn string) string {
 	conf, err := mysql.ParseDSN(dsn)
 	if err != nil {
@@ -1,10 +1,17 @@
 package mysql
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"database/sql"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"testing"
 
+	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -222,3 +229,88 @@ func TestNewNamespace(t *testing.T) {
 		}
 	}
 }
+
+func TestNormalizeDigestText(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{
+			"SELECT * FROM `users` WHERE `id` = ?",
+			"select * from users where id = ?",
+		},
+		{
+			"SELECT * FROM `users` WHERE `id` IN (...)",
+			"select * from users where id in (?)",
+		},
+		{
+			"SELECT  *  FROM users\nWHERE id IN ( ? , ? , ? )",
+			"select * from users where id in (?)",
+		},
+		{
+			"INSERT INTO `t` VALUES (...) , (...)",
+			"insert into t values (?)",
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.output, normalizeDigestText(test.input))
+	}
+}
+
+func TestTaggedRow(t *testing.T) {
+	columns := []string{"member_id", "member_state", "view_id", "count_transactions_in_queue", "member_role"}
+	values := []sql.RawBytes{
+		sql.RawBytes("uuid"), sql.RawBytes("ONLINE"), sql.RawBytes("1600000000:3"), sql.RawBytes("12"), nil,
+	}
+
+	tags, fields := taggedRow(columns, values, groupReplicationTags)
+	assert.Equal(t, map[string]string{"member_id": "uuid", "member_state": "ONLINE"}, tags)
+	assert.Equal(t, map[string]interface{}{"count_transactions_in_queue": int64(12)}, fields)
+}
+
+func TestMysqlDSNAddAuth(t *testing.T) {
+	m := &Mysql{
+		ClientConfig: tls.ClientConfig{InsecureSkipVerify: true},
+	}
+	require.NoError(t, m.registerAuth())
+	require.NotEmpty(t, m.tlsName)
+
+	m2 := &Mysql{
+		ClientConfig: tls.ClientConfig{InsecureSkipVerify: true},
+	}
+	require.NoError(t, m2.registerAuth())
+	require.NotEqual(t, m.tlsName, m2.tlsName)
+
+	dsn, err := m.dsnAddAuth("root:passwd@tcp(192.168.1.1:3306)/?tls=custom")
+	require.NoError(t, err)
+	assert.Equal(t, "root:passwd@tcp(192.168.1.1:3306)/?tls="+m.tlsName, dsn)
+
+	dsn, err = m.dsnAddAuth("root:passwd@tcp(192.168.1.1:3306)/?tls=false")
+	require.NoError(t, err)
+	assert.Equal(t, "root:passwd@tcp(192.168.1.1:3306)/?tls=false", dsn)
+}
+
+func TestMysqlServerPubKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	f, err := ioutil.TempFile("", "mysql_pub")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	require.NoError(t, pem.Encode(f, &pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	require.NoError(t, f.Close())
+
+	m := &Mysql{ServerPubKey: f.Name()}
+	require.NoError(t, m.registerAuth())
+	require.NotEmpty(t, m.pubKeyName)
+
+	dsn, err := m.dsnAddAuth("root:passwd@tcp(192.168.1.1:3306)/")
+	require.NoError(t, err)
+	assert.Equal(t, "root:passwd@tcp(192.168.1.1:3306)/?serverPubKey="+m.pubKeyName, dsn)
+
+	m = &Mysql{ServerPubKey: "/nonexistent.pem"}
+	require.Error(t, m.registerAuth())
+}
@@ -2,9 +2,12 @@ package natsconsumer
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/tls"
@@ -17,6 +20,21 @@ var (
 	defaultMaxUndeliveredMessages = 1000
 )
 
+const (
+	deliveryBestEffort  = "best_effort"
+	deliveryAtLeastOnce = "at_least_once"
+
+	// jsAPITimeout is the timeout of the JetStream API requests.
+	jsAPITimeout = 5 * time.Second
+)
+
+var (
+	// Acknowledgements of the JetStream messages, a negative acknowledgement
+	// redelivers the message.
+	jsAck = []byte("+ACK")
+	jsNak = []byte("-NAK")
+)
+
 type empty struct{}
 type semaphore chan empty
 
@@ -48,7 +66,12 @@ type natsConsumer struct {
 	PendingMessageLimit int `toml:"pending_message_limit"`
 	PendingBytesLimit   int `toml:"pending_bytes_limit"`
 
-	MaxUndeliveredMessages int `toml:"max_undelivered_messages"`
+	MaxUndeliveredMessages int    `toml:"max_undelivered_messages"`
+	DeliveryMode           string `toml:"delivery_mode"`
+
+	// JetStream stream and durable consumer the messages are read from.
+	JetStreamStream   string `toml:"jetstream_stream"`
+	JetStreamConsumer string `toml:"jetstream_consumer"`
 
 	// Legacy metric buffer support; deprecated in v0.10.3
 	MetricBuffer int
@@ -64,6 +87,33 @@ type natsConsumer struct {
 	acc    telegraf.TrackingAccumulator
 	wg     sync.WaitGroup
 	cancel context.CancelFunc
+
+	// messages not yet written by the outputs
+	undelivered map[telegraf.TrackingID]*nats.Msg
+	// publish sends the acknowledgement of a JetStream message
+	publish func(subject string, data []byte) error
+}
+
+// jsConsumerCreate is the JetStream API request creating a durable consumer.
+type jsConsumerCreate struct {
+	Stream string           `json:"stream_name"`
+	Config jsConsumerConfig `json:"config"`
+}
+
+type jsConsumerConfig struct {
+	Durable        string `json:"durable_name"`
+	DeliverSubject string `json:"deliver_subject"`
+	AckPolicy      string `json:"ack_policy"`
+	MaxAckPending  int    `json:"max_ack_pending"`
+	FilterSubject  string `json:"filter_subject,omitempty"`
+}
+
+// jsResponse is the response of the JetStream API, only the error is used.
+type jsResponse struct {
+	Error *struct {
+		Code        int    `json:"code"`
+		Description string `json:"description"`
+	} `json:"error"`
 }
 
 var sampleConfig = `
@@ -108,6 +158,19 @@ var sampleConfig = `
   ## waiting until the next flush_interval.
   # max_undelivered_messages = 1000
 
+  ## JetStream stream to consume.  The messages of the stream matching the
+  ## subject, only a single subject is supported, are read from a durable
+  ## consumer and acknowledged once written by the outputs.  The consumer is
+  ## created if missing.
+  # jetstream_stream = ""
+  # jetstream_consumer = "telegraf"
+
+  ## Delivery guarantee of the messages; one of "best_effort" or
+  ## "at_least_once".  With "at_least_once" JetStream messages not written by
+  ## the outputs are redelivered instead of acknowledged, it requires
+  ## jetstream_stream to be set.
+  # delivery_mode = "best_effort"
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -127,6 +190,30 @@ func (n *natsConsumer) SetParser(parser parsers.Parser) {
 	n.parser = parser
 }
 
+func (n *natsConsumer) Init() error {
+	switch n.DeliveryMode {
+	case "":
+		n.DeliveryMode = deliveryBestEffort
+	case deliveryBestEffort:
+	case deliveryAtLeastOnce:
+		if n.JetStreamStream == "" {
+			return fmt.Errorf("delivery_mode %q requires jetstream_stream", n.DeliveryMode)
+		}
+	default:
+		return fmt.Errorf("invalid delivery_mode %q", n.DeliveryMode)
+	}
+
+	if n.JetStreamStream != "" {
+		if len(n.Subjects) > 1 {
+			return errors.New("jetstream_stream supports a single subject")
+		}
+		if n.JetStreamConsumer == "" {
+			return errors.New("jetstream_consumer must be set with jetstream_stream")
+		}
+	}
+	return nil
+}
+
 func (n *natsConsumer) natsErrHandler(c *nats.Conn, s *nats.Subscription, e error) {
 	select {
 	case n.errs <- natsError{conn: c, sub: s, err: e}:
@@ -174,7 +261,17 @@ func (n *natsConsumer) Start(acc telegraf.Accumulator) error {
 		n.errs = make(chan error)
 
 		n.in = make(chan *nats.Msg, 1000)
-		for _, subj := range n.Subjects {
+		subjects := n.Subjects
+		if n.JetStreamStream != "" {
+			deliver, err := n.createConsumer()
+			if err != nil {
+				return err
+			}
+			subjects = []string{deliver}
+		}
+		n.publish = n.conn.Publish
+
+		for _, subj := range subjects {
 			sub, err := n.conn.QueueSubscribe(subj, n.QueueGroup, func(m *nats.Msg) {
 				n.in <- m
 			})
@@ -208,16 +305,59 @@ func (n *natsConsumer) Start(acc telegraf.Accumulator) error {
 	return nil
 }
 
+// createConsumer creates the durable JetStream consumer of the stream, or
+// checks the existing one, and returns the subject its messages are delivered
+// to.
+func (n *natsConsumer) createConsumer() (string, error) {
+	deliver := fmt.Sprintf("telegraf.deliver.%s.%s", n.JetStreamStream, n.JetStreamConsumer)
+
+	req := jsConsumerCreate{
+		Stream: n.JetStreamStream,
+		Config: jsConsumerConfig{
+			Durable:        n.JetStreamConsumer,
+			DeliverSubject: deliver,
+			AckPolicy:      "explicit",
+			MaxAckPending:  n.MaxUndeliveredMessages,
+		},
+	}
+	if len(n.Subjects) == 1 {
+		req.Config.FilterSubject = n.Subjects[0]
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	subject := fmt.Sprintf("$JS.API.CONSUMER.DURABLE.CREATE.%s.%s", n.JetStreamStream, n.JetStreamConsumer)
+	msg, err := n.conn.Request(subject, data, jsAPITimeout)
+	if err != nil {
+		return "", fmt.Errorf("creating JetStream consumer %s: %v", n.JetStreamConsumer, err)
+	}
+
+	var resp jsResponse
+	if err := json.Unmarshal(msg.Data, &resp); err != nil {
+		return "", fmt.Errorf("creating JetStream consumer %s: %v", n.JetStreamConsumer, err)
+	}
+	if resp.Error != nil {
+		return "", fmt.Errorf("creating JetStream consumer %s: %s (%d)",
+			n.JetStreamConsumer, resp.Error.Description, resp.Error.Code)
+	}
+	return deliver, nil
+}
+
 // receiver() reads all incoming messages from NATS, and parses them into
 // telegraf metrics.
 func (n *natsConsumer) receiver(ctx context.Context) {
 	sem := make(semaphore, n.MaxUndeliveredMessages)
+	n.undelivered = make(map[telegraf.TrackingID]*nats.Msg, n.MaxUndeliveredMessages)
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-n.acc.Delivered():
+		case track := <-n.acc.Delivered():
+			n.onDelivery(track)
 			<-sem
 		case err := <-n.errs:
 			n.Log.Error(err)
@@ -228,23 +368,74 @@ func (n *natsConsumer) receiver(ctx context.Context) {
 			case err := <-n.errs:
 				<-sem
 				n.Log.Error(err)
-			case <-n.acc.Delivered():
+			case track := <-n.acc.Delivered():
+				n.onDelivery(track)
 				<-sem
 				<-sem
 			case msg := <-n.in:
-				metrics, err := n.parser.Parse(msg.Data)
-				if err != nil {
-					n.Log.Errorf("Subject: %s, error: %s", msg.Subject, err.Error())
+				if !n.onMessage(msg) {
 					<-sem
-					continue
 				}
-
-				n.acc.AddTrackingMetricGroup(metrics)
 			}
 		}
 	}
 }
 
+// onMessage parses the message and adds its metrics, it returns false if the
+// message has no metrics to track.
+func (n *natsConsumer) onMessage(msg *nats.Msg) bool {
+	metrics, err := n.parser.Parse(msg.Data)
+	if err != nil {
+		n.Log.Errorf("Subject: %s, error: %s", msg.Subject, err.Error())
+
+		// An invalid message is acknowledged, it would fail again if
+		// redelivered.
+		n.acknowledge(msg, jsAck)
+		return false
+	}
+
+	id := n.acc.AddTrackingMetricGroup(metrics)
+	n.undelivered[id] = msg
+	return true
+}
+
+// onDelivery acknowledges the JetStream message once its metrics are written
+// by the outputs.  A message not written is redelivered with the
+// "at_least_once" delivery mode and lost otherwise, core NATS messages are
+// never redelivered.
+func (n *natsConsumer) onDelivery(track telegraf.DeliveryInfo) {
+	msg, ok := n.undelivered[track.ID()]
+	if !ok {
+		return
+	}
+	delete(n.undelivered, track.ID())
+
+	if track.Delivered() {
+		n.acknowledge(msg, jsAck)
+		return
+	}
+
+	if n.JetStreamStream != "" && n.DeliveryMode == deliveryAtLeastOnce {
+		n.acknowledge(msg, jsNak)
+		return
+	}
+
+	n.Log.Warnf("Message from subject %s was not written by the outputs and is lost", msg.Subject)
+	n.acknowledge(msg, jsAck)
+}
+
+// acknowledge replies to a JetStream message, core NATS messages are not
+// acknowledged.
+func (n *natsConsumer) acknowledge(msg *nats.Msg, ack []byte) {
+	if n.JetStreamStream == "" || msg.Reply == "" {
+		return
+	}
+
+	if err := n.publish(msg.Reply, ack); err != nil {
+		n.Log.Errorf("Unable to acknowledge message from subject %s: %v", msg.Subject, err)
+	}
+}
+
 func (n *natsConsumer) clean() {
 	for _, sub := range n.subs {
 		if err := sub.Unsubscribe(); err != nil {
@@ -278,6 +469,7 @@ func init() {
 			PendingBytesLimit:      nats.DefaultSubPendingBytesLimit,
 			PendingMessageLimit:    nats.DefaultSubPendingMsgsLimit,
 			MaxUndeliveredMessages: defaultMaxUndeliveredMessages,
+			JetStreamConsumer:      "telegraf",
 		}
 	})
 }
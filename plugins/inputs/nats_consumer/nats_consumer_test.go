@@ -0,0 +1,114 @@
+package natsconsumer
+
+import (
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/parsers/value"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+)
+
+type deliveryInfo struct {
+	id        telegraf.TrackingID
+	delivered bool
+}
+
+func (d *deliveryInfo) ID() telegraf.TrackingID {
+	return d.id
+}
+
+func (d *deliveryInfo) Delivered() bool {
+	return d.delivered
+}
+
+func TestInit_DeliveryMode(t *testing.T) {
+	n := &natsConsumer{Subjects: []string{"telegraf"}, DeliveryMode: "at_least_once"}
+	require.Error(t, n.Init())
+
+	n.JetStreamStream = "metrics"
+	n.JetStreamConsumer = "telegraf"
+	require.NoError(t, n.Init())
+
+	n.Subjects = []string{"cpu", "mem"}
+	require.Error(t, n.Init())
+}
+
+func TestOnDelivery_JetStream(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		stream    string
+		data      string
+		delivered bool
+		expected  []string
+	}{
+		{
+			name:      "delivered",
+			mode:      "best_effort",
+			stream:    "metrics",
+			data:      "42",
+			delivered: true,
+			expected:  []string{"+ACK"},
+		},
+		{
+			name:     "rejected best effort",
+			mode:     "best_effort",
+			stream:   "metrics",
+			data:     "42",
+			expected: []string{"+ACK"},
+		},
+		{
+			name:     "rejected at least once",
+			mode:     "at_least_once",
+			stream:   "metrics",
+			data:     "42",
+			expected: []string{"-NAK"},
+		},
+		{
+			name:     "invalid",
+			mode:     "at_least_once",
+			stream:   "metrics",
+			data:     "not an integer",
+			expected: []string{"+ACK"},
+		},
+		{
+			name:      "core NATS",
+			mode:      "best_effort",
+			data:      "42",
+			delivered: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var acks []string
+			n := &natsConsumer{
+				DeliveryMode:    tt.mode,
+				JetStreamStream: tt.stream,
+				Log:             testutil.Logger{},
+				parser:          &value.ValueParser{MetricName: "cpu", DataType: "int"},
+				acc:             &testutil.Accumulator{},
+				undelivered:     make(map[telegraf.TrackingID]*nats.Msg),
+				publish: func(subject string, data []byte) error {
+					require.Equal(t, "$JS.ACK.metrics.telegraf.1.1.1", subject)
+					acks = append(acks, string(data))
+					return nil
+				},
+			}
+
+			msg := &nats.Msg{
+				Subject: "telegraf.deliver.metrics.telegraf",
+				Reply:   "$JS.ACK.metrics.telegraf.1.1.1",
+				Data:    []byte(tt.data),
+			}
+			if n.onMessage(msg) {
+				for id := range n.undelivered {
+					n.onDelivery(&deliveryInfo{id: id, delivered: tt.delivered})
+				}
+			}
+			require.Empty(t, n.undelivered)
+			require.Equal(t, tt.expected, acks)
+		})
+	}
+}
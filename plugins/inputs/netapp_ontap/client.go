@@ -0,0 +1,121 @@
+package netapp_ontap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// page is a single page of a collection returned by the ONTAP REST API.
+type page struct {
+	Records    json.RawMessage `json:"records"`
+	NumRecords int             `json:"num_records"`
+	Links      struct {
+		Next *struct {
+			Href string `json:"href"`
+		} `json:"next"`
+	} `json:"_links"`
+}
+
+// apiError is the error returned by the ONTAP REST API.
+type apiError struct {
+	Error struct {
+		Message string `json:"message"`
+		Code    string `json:"code"`
+	} `json:"error"`
+}
+
+type client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	username   string
+	password   string
+	maxRecords int
+}
+
+// get requests the path and decodes the response into v.
+func (c *client) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	u, err := c.baseURL.Parse(path)
+	if err != nil {
+		return err
+	}
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
+	return c.do(ctx, u.String(), v)
+}
+
+// getAll requests all pages of the collection at path and calls fn with the
+// records of each page.  The API returns a link to the next page until all
+// records have been returned.
+func (c *client) getAll(ctx context.Context, path string, query url.Values, fn func(records json.RawMessage) error) error {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	if c.maxRecords > 0 {
+		q.Set("max_records", strconv.Itoa(c.maxRecords))
+	}
+
+	u, err := c.baseURL.Parse(path)
+	if err != nil {
+		return err
+	}
+	u.RawQuery = q.Encode()
+
+	next := u.String()
+	for next != "" {
+		var p page
+		if err := c.do(ctx, next, &p); err != nil {
+			return err
+		}
+		if len(p.Records) > 0 {
+			if err := fn(p.Records); err != nil {
+				return err
+			}
+		}
+
+		next = ""
+		if p.Links.Next != nil && p.Links.Next.Href != "" {
+			n, err := c.baseURL.Parse(p.Links.Next.Href)
+			if err != nil {
+				return err
+			}
+			next = n.String()
+		}
+	}
+	return nil
+}
+
+func (c *client) do(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		var apiErr apiError
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+			return fmt.Errorf("%s returned HTTP status %s: %s", req.URL.Path, resp.Status, apiErr.Error.Message)
+		}
+		return fmt.Errorf("%s returned HTTP status %s", req.URL.Path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
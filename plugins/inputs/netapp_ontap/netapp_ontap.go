@@ -0,0 +1,461 @@
+package netapp_ontap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/choice"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+var availableCollectors = []string{"aggregate", "volume", "lun", "svm", "snapmirror"}
+
+// NetAppONTAP gathers performance and capacity metrics of ONTAP clusters
+// using the REST API.
+type NetAppONTAP struct {
+	URLs            []string          `toml:"urls"`
+	Username        string            `toml:"username"`
+	Password        string            `toml:"password"`
+	Collect         []string          `toml:"collect"`
+	MaxRecords      int               `toml:"max_records"`
+	ResponseTimeout internal.Duration `toml:"response_timeout"`
+	tls.ClientConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	clients []*client
+}
+
+const sampleConfig = `
+  ## URLs of the cluster management interfaces.
+  urls = ["https://cluster1.example.com"]
+
+  ## Credentials for basic authentication.  Leave empty to authenticate with
+  ## the TLS client certificate configured below.
+  # username = "monitor"
+  # password = "secret"
+
+  ## Metrics to collect; by default all are collected.
+  ## Available collectors are "aggregate", "volume", "lun", "svm" and
+  ## "snapmirror".
+  # collect = ["aggregate", "volume", "lun", "svm", "snapmirror"]
+
+  ## Number of records to request per page.
+  # max_records = 1000
+
+  ## Amount of time allowed to complete a single request.
+  # response_timeout = "10s"
+
+  ## Optional TLS Config; tls_cert and tls_key are used for certificate
+  ## authentication.
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+// SampleConfig returns the default configuration of the plugin.
+func (n *NetAppONTAP) SampleConfig() string {
+	return sampleConfig
+}
+
+// Description returns a one-sentence description of the plugin.
+func (n *NetAppONTAP) Description() string {
+	return "Read performance and capacity metrics from NetApp ONTAP using the REST API"
+}
+
+// Init validates the configuration and creates the clients.
+func (n *NetAppONTAP) Init() error {
+	if len(n.URLs) == 0 {
+		return fmt.Errorf("no urls configured")
+	}
+	if len(n.Collect) == 0 {
+		n.Collect = availableCollectors
+	}
+	if err := choice.CheckSlice(n.Collect, availableCollectors); err != nil {
+		return fmt.Errorf("invalid collect option: %v", err)
+	}
+	if n.MaxRecords == 0 {
+		n.MaxRecords = 1000
+	}
+	if n.ResponseTimeout.Duration == 0 {
+		n.ResponseTimeout.Duration = 10 * time.Second
+	}
+
+	tlsCfg, err := n.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: n.ResponseTimeout.Duration,
+	}
+
+	for _, u := range n.URLs {
+		baseURL, err := url.Parse(u)
+		if err != nil {
+			return fmt.Errorf("invalid url %q: %v", u, err)
+		}
+		n.clients = append(n.clients, &client{
+			baseURL:    baseURL,
+			httpClient: httpClient,
+			username:   n.Username,
+			password:   n.Password,
+			maxRecords: n.MaxRecords,
+		})
+	}
+	return nil
+}
+
+// Gather collects the metrics of all clusters.
+func (n *NetAppONTAP) Gather(acc telegraf.Accumulator) error {
+	var wg sync.WaitGroup
+	for _, c := range n.clients {
+		wg.Add(1)
+		go func(c *client) {
+			defer wg.Done()
+			n.gatherCluster(context.Background(), c, acc)
+		}(c)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (n *NetAppONTAP) gatherCluster(ctx context.Context, c *client, acc telegraf.Accumulator) {
+	var cluster struct {
+		Name string `json:"name"`
+	}
+	err := c.get(ctx, "/api/cluster", url.Values{"fields": {"name"}}, &cluster)
+	if err != nil {
+		acc.AddError(fmt.Errorf("%s: %v", c.baseURL.Host, err))
+		return
+	}
+	if cluster.Name == "" {
+		cluster.Name = c.baseURL.Hostname()
+	}
+
+	for _, collector := range n.Collect {
+		var err error
+		switch collector {
+		case "aggregate":
+			err = gatherAggregates(ctx, c, cluster.Name, acc)
+		case "volume":
+			err = gatherVolumes(ctx, c, cluster.Name, acc)
+		case "lun":
+			err = gatherLUNs(ctx, c, cluster.Name, acc)
+		case "svm":
+			err = gatherSVMs(ctx, c, cluster.Name, acc)
+		case "snapmirror":
+			err = gatherSnapMirror(ctx, c, cluster.Name, acc)
+		}
+		if err != nil {
+			acc.AddError(fmt.Errorf("%s: collecting %s: %v", cluster.Name, collector, err))
+		}
+	}
+}
+
+// ioMetrics are the read, write, other and total values of a performance
+// metric.
+type ioMetrics struct {
+	Read  float64 `json:"read"`
+	Write float64 `json:"write"`
+	Other float64 `json:"other"`
+	Total float64 `json:"total"`
+}
+
+// performance is the latest performance sample of an object.
+type performance struct {
+	Status     string    `json:"status"`
+	IOPS       ioMetrics `json:"iops"`
+	Latency    ioMetrics `json:"latency"`
+	Throughput ioMetrics `json:"throughput"`
+}
+
+// addFields adds the performance metrics to fields, if the sample is valid.
+// Latencies are in microseconds and throughput in bytes per second.
+func (p *performance) addFields(fields map[string]interface{}) {
+	if p == nil || (p.Status != "" && p.Status != "ok") {
+		return
+	}
+	ops := []struct {
+		name                      string
+		iops, latency, throughput float64
+	}{
+		{"read", p.IOPS.Read, p.Latency.Read, p.Throughput.Read},
+		{"write", p.IOPS.Write, p.Latency.Write, p.Throughput.Write},
+		{"other", p.IOPS.Other, p.Latency.Other, p.Throughput.Other},
+		{"total", p.IOPS.Total, p.Latency.Total, p.Throughput.Total},
+	}
+	for _, op := range ops {
+		fields["iops_"+op.name] = op.iops
+		fields["latency_"+op.name+"_us"] = op.latency
+		fields["throughput_"+op.name+"_bytes"] = op.throughput
+	}
+}
+
+type aggregate struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+	Node  struct {
+		Name string `json:"name"`
+	} `json:"home_node"`
+	Space struct {
+		BlockStorage struct {
+			Size      int64 `json:"size"`
+			Available int64 `json:"available"`
+			Used      int64 `json:"used"`
+		} `json:"block_storage"`
+	} `json:"space"`
+	Metric *performance `json:"metric"`
+}
+
+func gatherAggregates(ctx context.Context, c *client, cluster string, acc telegraf.Accumulator) error {
+	query := url.Values{"fields": {"name,state,home_node.name,space.block_storage,metric"}}
+	return c.getAll(ctx, "/api/storage/aggregates", query, func(records json.RawMessage) error {
+		var aggregates []aggregate
+		if err := json.Unmarshal(records, &aggregates); err != nil {
+			return err
+		}
+		for _, a := range aggregates {
+			tags := map[string]string{
+				"cluster":   cluster,
+				"aggregate": a.Name,
+				"node":      a.Node.Name,
+				"state":     a.State,
+			}
+			fields := map[string]interface{}{
+				"size_bytes":      a.Space.BlockStorage.Size,
+				"available_bytes": a.Space.BlockStorage.Available,
+				"used_bytes":      a.Space.BlockStorage.Used,
+			}
+			a.Metric.addFields(fields)
+			acc.AddFields("netapp_ontap_aggregate", fields, tags)
+		}
+		return nil
+	})
+}
+
+type volume struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+	Style string `json:"style"`
+	SVM   struct {
+		Name string `json:"name"`
+	} `json:"svm"`
+	Aggregates []struct {
+		Name string `json:"name"`
+	} `json:"aggregates"`
+	Space struct {
+		Size      int64 `json:"size"`
+		Available int64 `json:"available"`
+		Used      int64 `json:"used"`
+	} `json:"space"`
+	Metric *performance `json:"metric"`
+}
+
+func gatherVolumes(ctx context.Context, c *client, cluster string, acc telegraf.Accumulator) error {
+	query := url.Values{"fields": {"name,state,style,svm.name,aggregates.name,space.size,space.available,space.used,metric"}}
+	return c.getAll(ctx, "/api/storage/volumes", query, func(records json.RawMessage) error {
+		var volumes []volume
+		if err := json.Unmarshal(records, &volumes); err != nil {
+			return err
+		}
+		for _, v := range volumes {
+			tags := map[string]string{
+				"cluster": cluster,
+				"volume":  v.Name,
+				"svm":     v.SVM.Name,
+				"state":   v.State,
+			}
+			if v.Style != "" {
+				tags["style"] = v.Style
+			}
+			// FlexGroup volumes span multiple aggregates
+			if len(v.Aggregates) == 1 {
+				tags["aggregate"] = v.Aggregates[0].Name
+			}
+			fields := map[string]interface{}{
+				"size_bytes":      v.Space.Size,
+				"available_bytes": v.Space.Available,
+				"used_bytes":      v.Space.Used,
+			}
+			v.Metric.addFields(fields)
+			acc.AddFields("netapp_ontap_volume", fields, tags)
+		}
+		return nil
+	})
+}
+
+type lun struct {
+	Name string `json:"name"`
+	SVM  struct {
+		Name string `json:"name"`
+	} `json:"svm"`
+	Status struct {
+		State string `json:"state"`
+	} `json:"status"`
+	Space struct {
+		Size int64 `json:"size"`
+		Used int64 `json:"used"`
+	} `json:"space"`
+	Metric *performance `json:"metric"`
+}
+
+func gatherLUNs(ctx context.Context, c *client, cluster string, acc telegraf.Accumulator) error {
+	query := url.Values{"fields": {"name,svm.name,status.state,space.size,space.used,metric"}}
+	return c.getAll(ctx, "/api/storage/luns", query, func(records json.RawMessage) error {
+		var luns []lun
+		if err := json.Unmarshal(records, &luns); err != nil {
+			return err
+		}
+		for _, l := range luns {
+			tags := map[string]string{
+				"cluster": cluster,
+				"lun":     l.Name,
+				"svm":     l.SVM.Name,
+				"state":   l.Status.State,
+			}
+			fields := map[string]interface{}{
+				"size_bytes": l.Space.Size,
+				"used_bytes": l.Space.Used,
+			}
+			l.Metric.addFields(fields)
+			acc.AddFields("netapp_ontap_lun", fields, tags)
+		}
+		return nil
+	})
+}
+
+type svm struct {
+	Name    string `json:"name"`
+	State   string `json:"state"`
+	Subtype string `json:"subtype"`
+}
+
+func gatherSVMs(ctx context.Context, c *client, cluster string, acc telegraf.Accumulator) error {
+	query := url.Values{"fields": {"name,state,subtype"}}
+	return c.getAll(ctx, "/api/svm/svms", query, func(records json.RawMessage) error {
+		var svms []svm
+		if err := json.Unmarshal(records, &svms); err != nil {
+			return err
+		}
+		for _, s := range svms {
+			tags := map[string]string{
+				"cluster": cluster,
+				"svm":     s.Name,
+			}
+			if s.Subtype != "" {
+				tags["subtype"] = s.Subtype
+			}
+			fields := map[string]interface{}{
+				"state":   s.State,
+				"running": s.State == "running",
+			}
+			acc.AddFields("netapp_ontap_svm", fields, tags)
+		}
+		return nil
+	})
+}
+
+type snapMirrorRelationship struct {
+	Source struct {
+		Path string `json:"path"`
+	} `json:"source"`
+	Destination struct {
+		Path string `json:"path"`
+	} `json:"destination"`
+	Policy struct {
+		Name string `json:"name"`
+	} `json:"policy"`
+	State   string `json:"state"`
+	Healthy bool   `json:"healthy"`
+	LagTime string `json:"lag_time"`
+}
+
+func gatherSnapMirror(ctx context.Context, c *client, cluster string, acc telegraf.Accumulator) error {
+	query := url.Values{"fields": {"source.path,destination.path,policy.name,state,healthy,lag_time"}}
+	return c.getAll(ctx, "/api/snapmirror/relationships", query, func(records json.RawMessage) error {
+		var relationships []snapMirrorRelationship
+		if err := json.Unmarshal(records, &relationships); err != nil {
+			return err
+		}
+		for _, r := range relationships {
+			tags := map[string]string{
+				"cluster":     cluster,
+				"source":      r.Source.Path,
+				"destination": r.Destination.Path,
+			}
+			if r.Policy.Name != "" {
+				tags["policy"] = r.Policy.Name
+			}
+			fields := map[string]interface{}{
+				"state":   r.State,
+				"healthy": r.Healthy,
+			}
+			// The lag time is only reported once the relationship has been
+			// initialized.
+			if r.LagTime != "" {
+				lag, err := parseISO8601Duration(r.LagTime)
+				if err != nil {
+					return fmt.Errorf("parsing lag time of %s: %v", r.Destination.Path, err)
+				}
+				fields["lag_time_seconds"] = int64(lag.Seconds())
+			}
+			acc.AddFields("netapp_ontap_snapmirror", fields, tags)
+		}
+		return nil
+	})
+}
+
+var iso8601Duration = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// parseISO8601Duration parses durations as returned by ONTAP, like
+// "P1DT2H3M4S".  Years, months and weeks are not supported.
+func parseISO8601Duration(s string) (time.Duration, error) {
+	m := iso8601Duration.FindStringSubmatch(s)
+	if m == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	var d time.Duration
+	units := []time.Duration{24 * time.Hour, time.Hour, time.Minute}
+	for i, unit := range units {
+		if m[i+1] == "" {
+			continue
+		}
+		v, err := strconv.ParseInt(m[i+1], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(v) * unit
+	}
+	if m[4] != "" {
+		v, err := strconv.ParseFloat(m[4], 64)
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(v * float64(time.Second))
+	}
+	return d, nil
+}
+
+func init() {
+	inputs.Add("netapp_ontap", func() telegraf.Input {
+		return &NetAppONTAP{}
+	})
+}
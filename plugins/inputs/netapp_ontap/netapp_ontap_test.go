@@ -0,0 +1,271 @@
+package netapp_ontap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var responses = map[string]string{
+	"/api/cluster": `{"name": "cluster1"}`,
+	"/api/storage/aggregates": `{
+		"records": [{
+			"name": "aggr1",
+			"state": "online",
+			"home_node": {"name": "node1"},
+			"space": {"block_storage": {"size": 1000, "available": 400, "used": 600}},
+			"metric": {
+				"status": "ok",
+				"iops": {"read": 10, "write": 20, "other": 1, "total": 31},
+				"latency": {"read": 100, "write": 200, "other": 10, "total": 160},
+				"throughput": {"read": 1024, "write": 2048, "other": 0, "total": 3072}
+			}
+		}],
+		"num_records": 1
+	}`,
+	"/api/storage/volumes": `{
+		"records": [{
+			"name": "vol1",
+			"state": "online",
+			"style": "flexvol",
+			"svm": {"name": "svm1"},
+			"aggregates": [{"name": "aggr1"}],
+			"space": {"size": 100, "available": 60, "used": 40},
+			"metric": {"status": "partial_no_response"}
+		}],
+		"num_records": 1,
+		"_links": {"next": {"href": "/api/storage/volumes?start.name=vol1&max_records=1"}}
+	}`,
+	"/api/storage/volumes?start": `{
+		"records": [{
+			"name": "vol2",
+			"state": "offline",
+			"style": "flexgroup",
+			"svm": {"name": "svm1"},
+			"aggregates": [{"name": "aggr1"}, {"name": "aggr2"}],
+			"space": {"size": 200, "available": 200, "used": 0}
+		}],
+		"num_records": 1
+	}`,
+	"/api/storage/luns": `{"records": [], "num_records": 0}`,
+	"/api/svm/svms": `{
+		"records": [{"name": "svm1", "state": "running", "subtype": "default"}],
+		"num_records": 1
+	}`,
+	"/api/snapmirror/relationships": `{
+		"records": [{
+			"source": {"path": "svm1:vol1"},
+			"destination": {"path": "svm2:vol1_dst"},
+			"policy": {"name": "MirrorAllSnapshots"},
+			"state": "snapmirrored",
+			"healthy": true,
+			"lag_time": "PT1H2M3S"
+		}, {
+			"source": {"path": "svm1:vol2"},
+			"destination": {"path": "svm2:vol2_dst"},
+			"state": "uninitialized",
+			"healthy": false
+		}],
+		"num_records": 2
+	}`,
+}
+
+func newServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "monitor" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": {"message": "not authorized", "code": "6"}}`))
+			return
+		}
+
+		key := r.URL.Path
+		if r.URL.Query().Get("start.name") != "" {
+			key += "?start"
+		} else if key != "/api/cluster" {
+			require.Equal(t, "1000", r.URL.Query().Get("max_records"))
+		}
+		response, ok := responses[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+}
+
+func TestGather(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+
+	plugin := &NetAppONTAP{
+		URLs:     []string{ts.URL},
+		Username: "monitor",
+		Password: "secret",
+		Log:      testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("netapp_ontap_aggregate",
+			map[string]string{
+				"cluster":   "cluster1",
+				"aggregate": "aggr1",
+				"node":      "node1",
+				"state":     "online",
+			},
+			map[string]interface{}{
+				"size_bytes":             int64(1000),
+				"available_bytes":        int64(400),
+				"used_bytes":             int64(600),
+				"iops_read":              10.0,
+				"iops_write":             20.0,
+				"iops_other":             1.0,
+				"iops_total":             31.0,
+				"latency_read_us":        100.0,
+				"latency_write_us":       200.0,
+				"latency_other_us":       10.0,
+				"latency_total_us":       160.0,
+				"throughput_read_bytes":  1024.0,
+				"throughput_write_bytes": 2048.0,
+				"throughput_other_bytes": 0.0,
+				"throughput_total_bytes": 3072.0,
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("netapp_ontap_volume",
+			map[string]string{
+				"cluster":   "cluster1",
+				"volume":    "vol1",
+				"svm":       "svm1",
+				"state":     "online",
+				"style":     "flexvol",
+				"aggregate": "aggr1",
+			},
+			map[string]interface{}{
+				"size_bytes":      int64(100),
+				"available_bytes": int64(60),
+				"used_bytes":      int64(40),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("netapp_ontap_volume",
+			map[string]string{
+				"cluster": "cluster1",
+				"volume":  "vol2",
+				"svm":     "svm1",
+				"state":   "offline",
+				"style":   "flexgroup",
+			},
+			map[string]interface{}{
+				"size_bytes":      int64(200),
+				"available_bytes": int64(200),
+				"used_bytes":      int64(0),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("netapp_ontap_svm",
+			map[string]string{
+				"cluster": "cluster1",
+				"svm":     "svm1",
+				"subtype": "default",
+			},
+			map[string]interface{}{
+				"state":   "running",
+				"running": true,
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("netapp_ontap_snapmirror",
+			map[string]string{
+				"cluster":     "cluster1",
+				"source":      "svm1:vol1",
+				"destination": "svm2:vol1_dst",
+				"policy":      "MirrorAllSnapshots",
+			},
+			map[string]interface{}{
+				"state":            "snapmirrored",
+				"healthy":          true,
+				"lag_time_seconds": int64(3723),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("netapp_ontap_snapmirror",
+			map[string]string{
+				"cluster":     "cluster1",
+				"source":      "svm1:vol2",
+				"destination": "svm2:vol2_dst",
+			},
+			map[string]interface{}{
+				"state":   "uninitialized",
+				"healthy": false,
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestGatherUnauthorized(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+
+	plugin := &NetAppONTAP{
+		URLs:     []string{ts.URL},
+		Username: "monitor",
+		Password: "wrong",
+		Log:      testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "not authorized")
+	require.Empty(t, acc.Metrics)
+}
+
+func TestInitInvalidCollector(t *testing.T) {
+	plugin := &NetAppONTAP{
+		URLs:    []string{"https://localhost"},
+		Collect: []string{"qtree"},
+	}
+	require.Error(t, plugin.Init())
+}
+
+func TestParseISO8601Duration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		err      bool
+	}{
+		{input: "PT30S", expected: 30 * time.Second},
+		{input: "PT1H2M3S", expected: time.Hour + 2*time.Minute + 3*time.Second},
+		{input: "P2DT5M", expected: 48*time.Hour + 5*time.Minute},
+		{input: "PT0.5S", expected: 500 * time.Millisecond},
+		{input: "P", err: true},
+		{input: "PT", err: true},
+		{input: "1H", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			d, err := parseISO8601Duration(tt.input)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, d)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package nomad
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxErrorSize is the maximum size of the body of an error response read.
+const maxErrorSize = 4096
+
+type resources struct {
+	CPU struct {
+		CPUShares int64 `json:"CpuShares"`
+	} `json:"Cpu"`
+	Memory struct {
+		MemoryMB int64 `json:"MemoryMB"`
+	} `json:"Memory"`
+	Disk struct {
+		DiskMB int64 `json:"DiskMB"`
+	} `json:"Disk"`
+}
+
+type node struct {
+	ID                    string     `json:"ID"`
+	NodePool              string     `json:"NodePool"`
+	Status                string     `json:"Status"`
+	SchedulingEligibility string     `json:"SchedulingEligibility"`
+	NodeResources         *resources `json:"NodeResources"`
+	ReservedResources     *resources `json:"ReservedResources"`
+}
+
+type allocation struct {
+	ID                 string `json:"ID"`
+	Namespace          string `json:"Namespace"`
+	NodeID             string `json:"NodeID"`
+	ClientStatus       string `json:"ClientStatus"`
+	DesiredStatus      string `json:"DesiredStatus"`
+	AllocatedResources *struct {
+		Tasks  map[string]resources `json:"Tasks"`
+		Shared struct {
+			DiskMB int64 `json:"DiskMB"`
+		} `json:"Shared"`
+	} `json:"AllocatedResources"`
+}
+
+type evaluation struct {
+	ID        string `json:"ID"`
+	Namespace string `json:"Namespace"`
+	Status    string `json:"Status"`
+}
+
+type autopilotHealth struct {
+	Healthy          bool           `json:"Healthy"`
+	FailureTolerance int            `json:"FailureTolerance"`
+	Servers          []serverHealth `json:"Servers"`
+}
+
+type serverHealth struct {
+	ID          string   `json:"ID"`
+	Name        string   `json:"Name"`
+	Address     string   `json:"Address"`
+	SerfStatus  string   `json:"SerfStatus"`
+	Version     string   `json:"Version"`
+	Leader      bool     `json:"Leader"`
+	LastContact duration `json:"LastContact"`
+	LastTerm    uint64   `json:"LastTerm"`
+	LastIndex   uint64   `json:"LastIndex"`
+	Healthy     bool     `json:"Healthy"`
+	Voter       bool     `json:"Voter"`
+}
+
+// duration is a duration of the API, written either as a string such as
+// "10ms" or as a number of nanoseconds.
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			*d = 0
+			return nil
+		}
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		*d = duration(v)
+		return nil
+	}
+
+	var v int64
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+// get sends a GET request to the path of the API and decodes the response
+// into out.
+func (n *Nomad) get(path string, out interface{}) error {
+	req, err := http.NewRequest("GET", n.URL+path, nil)
+	if err != nil {
+		return err
+	}
+	if n.Token != "" {
+		req.Header.Set("X-Nomad-Token", n.Token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorSize))
+		return fmt.Errorf("%s returned %q: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
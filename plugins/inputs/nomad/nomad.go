@@ -0,0 +1,339 @@
+package nomad
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const sampleConfig = `
+  ## URL of a Nomad agent.
+  url = "http://127.0.0.1:4646"
+
+  ## ACL token used in the requests, or file containing it.  The token needs
+  ## the read-job capability of the namespaces, and the node:read and
+  ## operator:read policies.
+  # token = ""
+  # token_file = "/path/to/token"
+
+  ## Region and namespace of the allocations and evaluations, "*" gathers
+  ## those of all the namespaces.
+  # region = ""
+  # namespace = "*"
+
+  ## Timeout of each request.
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+// The statuses always reported, with a zero count when no allocation or
+// evaluation has them.
+var (
+	allocationStatuses = []string{"pending", "running", "complete", "failed", "lost"}
+	evaluationStatuses = []string{"blocked", "pending", "complete", "failed", "canceled"}
+)
+
+// defaultNodePool is the pool of the nodes of the versions without pools.
+const defaultNodePool = "default"
+
+type Nomad struct {
+	URL       string            `toml:"url"`
+	Token     string            `toml:"token"`
+	TokenFile string            `toml:"token_file"`
+	Region    string            `toml:"region"`
+	Namespace string            `toml:"namespace"`
+	Timeout   internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	client *http.Client
+}
+
+// nodePool is the capacity of the ready nodes of a pool and the resources
+// allocated on its nodes.
+type nodePool struct {
+	nodes         int
+	ready         int
+	eligible      int
+	cpu           int64
+	memory        int64
+	disk          int64
+	cpuAllocated  int64
+	memAllocated  int64
+	diskAllocated int64
+}
+
+func (n *Nomad) Description() string {
+	return "Gather the allocations, evaluations, node pool capacity and raft health of Nomad clusters"
+}
+
+func (n *Nomad) SampleConfig() string {
+	return sampleConfig
+}
+
+func (n *Nomad) Init() error {
+	if n.URL == "" {
+		return errors.New("url must be set")
+	}
+	n.URL = strings.TrimSuffix(n.URL, "/")
+
+	if n.Token != "" && n.TokenFile != "" {
+		return errors.New("only one of token and token_file can be set")
+	}
+	if n.TokenFile != "" {
+		token, err := ioutil.ReadFile(n.TokenFile)
+		if err != nil {
+			return err
+		}
+		n.Token = strings.TrimSpace(string(token))
+	}
+
+	tlsConfig, err := n.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	n.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+		Timeout: n.Timeout.Duration,
+	}
+	return nil
+}
+
+func (n *Nomad) Gather(acc telegraf.Accumulator) error {
+	var allocations []allocation
+	if err := n.get(n.path("/v1/allocations", true, "resources=true"), &allocations); err != nil {
+		acc.AddError(fmt.Errorf("listing allocations: %v", err))
+		allocations = nil
+	} else {
+		gatherAllocations(acc, allocations)
+	}
+
+	var evaluations []evaluation
+	if err := n.get(n.path("/v1/evaluations", true), &evaluations); err != nil {
+		acc.AddError(fmt.Errorf("listing evaluations: %v", err))
+	} else {
+		gatherEvaluations(acc, evaluations)
+	}
+
+	// The allocated resources are missing when the allocations are not
+	// listed.
+	var nodes []node
+	if err := n.get(n.path("/v1/nodes", false, "resources=true"), &nodes); err != nil {
+		acc.AddError(fmt.Errorf("listing nodes: %v", err))
+	} else {
+		gatherNodePools(acc, nodes, allocations)
+	}
+
+	var health autopilotHealth
+	if err := n.get(n.path("/v1/operator/autopilot/health", false), &health); err != nil {
+		acc.AddError(fmt.Errorf("gathering raft health: %v", err))
+	} else {
+		gatherRaft(acc, &health)
+	}
+	return nil
+}
+
+// path returns the path of the API with the region, the namespace when
+// namespaced, and the parameters.
+func (n *Nomad) path(path string, namespaced bool, params ...string) string {
+	if n.Region != "" {
+		params = append(params, "region="+url.QueryEscape(n.Region))
+	}
+	if namespaced && n.Namespace != "" {
+		params = append(params, "namespace="+url.QueryEscape(n.Namespace))
+	}
+	if len(params) == 0 {
+		return path
+	}
+	return path + "?" + strings.Join(params, "&")
+}
+
+// gatherAllocations adds the number of allocations of each namespace by
+// client status.
+func gatherAllocations(acc telegraf.Accumulator, allocations []allocation) {
+	counts := make(map[string]map[string]interface{})
+	for _, a := range allocations {
+		fields, ok := counts[a.Namespace]
+		if !ok {
+			fields = make(map[string]interface{})
+			for _, status := range allocationStatuses {
+				fields[status] = 0
+			}
+			counts[a.Namespace] = fields
+		}
+		count, _ := fields[a.ClientStatus].(int)
+		fields[a.ClientStatus] = count + 1
+	}
+
+	now := time.Now()
+	for namespace, fields := range counts {
+		acc.AddFields("nomad_allocations", fields, namespaceTags(namespace), now)
+	}
+}
+
+// gatherEvaluations adds the number of evaluations of each namespace by
+// status.
+func gatherEvaluations(acc telegraf.Accumulator, evaluations []evaluation) {
+	counts := make(map[string]map[string]interface{})
+	for _, e := range evaluations {
+		fields, ok := counts[e.Namespace]
+		if !ok {
+			fields = make(map[string]interface{})
+			for _, status := range evaluationStatuses {
+				fields[status] = 0
+			}
+			counts[e.Namespace] = fields
+		}
+		count, _ := fields[e.Status].(int)
+		fields[e.Status] = count + 1
+	}
+
+	now := time.Now()
+	for namespace, fields := range counts {
+		acc.AddFields("nomad_evaluations", fields, namespaceTags(namespace), now)
+	}
+}
+
+func namespaceTags(namespace string) map[string]string {
+	tags := make(map[string]string)
+	if namespace != "" {
+		tags["namespace"] = namespace
+	}
+	return tags
+}
+
+// gatherNodePools adds the capacity of the ready nodes of each pool, less
+// their reserved resources, and the resources of the pending and running
+// allocations on its nodes.
+func gatherNodePools(acc telegraf.Accumulator, nodes []node, allocations []allocation) {
+	pools := make(map[string]*nodePool)
+	nodePools := make(map[string]*nodePool, len(nodes))
+	for _, node := range nodes {
+		name := node.NodePool
+		if name == "" {
+			name = defaultNodePool
+		}
+		pool, ok := pools[name]
+		if !ok {
+			pool = &nodePool{}
+			pools[name] = pool
+		}
+		nodePools[node.ID] = pool
+
+		pool.nodes++
+		if node.Status != "ready" {
+			continue
+		}
+		pool.ready++
+		if node.SchedulingEligibility == "eligible" {
+			pool.eligible++
+		}
+		if node.NodeResources != nil {
+			pool.cpu += node.NodeResources.CPU.CPUShares
+			pool.memory += node.NodeResources.Memory.MemoryMB
+			pool.disk += node.NodeResources.Disk.DiskMB
+		}
+		if node.ReservedResources != nil {
+			pool.cpu -= node.ReservedResources.CPU.CPUShares
+			pool.memory -= node.ReservedResources.Memory.MemoryMB
+			pool.disk -= node.ReservedResources.Disk.DiskMB
+		}
+	}
+
+	for _, a := range allocations {
+		pool, ok := nodePools[a.NodeID]
+		if !ok || a.AllocatedResources == nil || a.DesiredStatus != "run" {
+			continue
+		}
+		if a.ClientStatus != "pending" && a.ClientStatus != "running" {
+			continue
+		}
+		for _, task := range a.AllocatedResources.Tasks {
+			pool.cpuAllocated += task.CPU.CPUShares
+			pool.memAllocated += task.Memory.MemoryMB
+		}
+		pool.diskAllocated += a.AllocatedResources.Shared.DiskMB
+	}
+
+	now := time.Now()
+	for name, pool := range pools {
+		fields := map[string]interface{}{
+			"nodes":               pool.nodes,
+			"nodes_ready":         pool.ready,
+			"nodes_eligible":      pool.eligible,
+			"cpu_mhz":             pool.cpu,
+			"memory_mb":           pool.memory,
+			"disk_mb":             pool.disk,
+			"cpu_allocated_mhz":   pool.cpuAllocated,
+			"memory_allocated_mb": pool.memAllocated,
+			"disk_allocated_mb":   pool.diskAllocated,
+		}
+		acc.AddFields("nomad_node_pool", fields, map[string]string{"node_pool": name}, now)
+	}
+}
+
+// gatherRaft adds the autopilot health of the cluster and of each server.
+func gatherRaft(acc telegraf.Accumulator, health *autopilotHealth) {
+	now := time.Now()
+	var voters, healthy int
+	for _, server := range health.Servers {
+		if server.Voter {
+			voters++
+		}
+		if server.Healthy {
+			healthy++
+		}
+
+		fields := map[string]interface{}{
+			"leader":          server.Leader,
+			"voter":           server.Voter,
+			"healthy":         server.Healthy,
+			"serf_status":     server.SerfStatus,
+			"last_contact_ms": float64(server.LastContact) / float64(time.Millisecond),
+			"last_term":       server.LastTerm,
+			"last_index":      server.LastIndex,
+		}
+		tags := map[string]string{
+			"server":  server.Name,
+			"address": server.Address,
+			"version": server.Version,
+		}
+		acc.AddFields("nomad_raft_server", fields, tags, now)
+	}
+
+	fields := map[string]interface{}{
+		"healthy":           health.Healthy,
+		"failure_tolerance": health.FailureTolerance,
+		"servers":           len(health.Servers),
+		"servers_healthy":   healthy,
+		"voters":            voters,
+	}
+	acc.AddFields("nomad_raft", fields, nil, now)
+}
+
+func init() {
+	inputs.Add("nomad", func() telegraf.Input {
+		return &Nomad{
+			URL:       "http://127.0.0.1:4646",
+			Namespace: "*",
+			Timeout:   internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
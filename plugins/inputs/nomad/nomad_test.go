@@ -0,0 +1,199 @@
+package nomad
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	allocationsResponse = `[
+  {"ID":"a1","Namespace":"default","NodeID":"n1","ClientStatus":"running","DesiredStatus":"run",
+   "AllocatedResources":{"Tasks":{"web":{"Cpu":{"CpuShares":500},"Memory":{"MemoryMB":256}},"proxy":{"Cpu":{"CpuShares":100},"Memory":{"MemoryMB":64}}},"Shared":{"DiskMB":300}}},
+  {"ID":"a2","Namespace":"default","NodeID":"n2","ClientStatus":"pending","DesiredStatus":"run",
+   "AllocatedResources":{"Tasks":{"web":{"Cpu":{"CpuShares":500},"Memory":{"MemoryMB":256}}},"Shared":{"DiskMB":300}}},
+  {"ID":"a3","Namespace":"default","NodeID":"n1","ClientStatus":"complete","DesiredStatus":"stop",
+   "AllocatedResources":{"Tasks":{"web":{"Cpu":{"CpuShares":500},"Memory":{"MemoryMB":256}}},"Shared":{"DiskMB":300}}},
+  {"ID":"a4","Namespace":"batch","NodeID":"n3","ClientStatus":"failed","DesiredStatus":"run",
+   "AllocatedResources":{"Tasks":{"job":{"Cpu":{"CpuShares":1000},"Memory":{"MemoryMB":1024}}},"Shared":{"DiskMB":150}}}
+]`
+
+	evaluationsResponse = `[
+  {"ID":"e1","Namespace":"default","Status":"complete"},
+  {"ID":"e2","Namespace":"default","Status":"blocked"},
+  {"ID":"e3","Namespace":"batch","Status":"complete"}
+]`
+
+	nodesResponse = `[
+  {"ID":"n1","NodePool":"default","Status":"ready","SchedulingEligibility":"eligible",
+   "NodeResources":{"Cpu":{"CpuShares":4000},"Memory":{"MemoryMB":8192},"Disk":{"DiskMB":100000}},
+   "ReservedResources":{"Cpu":{"CpuShares":100},"Memory":{"MemoryMB":256},"Disk":{"DiskMB":1000}}},
+  {"ID":"n2","NodePool":"default","Status":"ready","SchedulingEligibility":"ineligible",
+   "NodeResources":{"Cpu":{"CpuShares":4000},"Memory":{"MemoryMB":8192},"Disk":{"DiskMB":100000}},
+   "ReservedResources":{"Cpu":{"CpuShares":0},"Memory":{"MemoryMB":0},"Disk":{"DiskMB":0}}},
+  {"ID":"n3","NodePool":"batch","Status":"down","SchedulingEligibility":"eligible",
+   "NodeResources":{"Cpu":{"CpuShares":8000},"Memory":{"MemoryMB":16384},"Disk":{"DiskMB":200000}}}
+]`
+
+	healthResponse = `{"Healthy":true,"FailureTolerance":1,"Servers":[
+  {"ID":"s1","Name":"server1.global","Address":"10.0.0.1:4647","SerfStatus":"alive","Version":"1.6.1","Leader":true,
+   "LastContact":"0s","LastTerm":3,"LastIndex":120,"Healthy":true,"Voter":true},
+  {"ID":"s2","Name":"server2.global","Address":"10.0.0.2:4647","SerfStatus":"alive","Version":"1.6.1","Leader":false,
+   "LastContact":"12.5ms","LastTerm":3,"LastIndex":119,"Healthy":true,"Voter":true},
+  {"ID":"s3","Name":"server3.global","Address":"10.0.0.3:4647","SerfStatus":"failed","Version":"1.6.1","Leader":false,
+   "LastContact":2000000000,"LastTerm":2,"LastIndex":80,"Healthy":false,"Voter":false}
+]}`
+)
+
+func newNomadServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Nomad-Token") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, "Permission denied")
+			return
+		}
+
+		query := r.URL.Query()
+		require.Equal(t, "east", query.Get("region"))
+		switch r.URL.Path {
+		case "/v1/allocations":
+			require.Equal(t, "*", query.Get("namespace"))
+			require.Equal(t, "true", query.Get("resources"))
+			fmt.Fprint(w, allocationsResponse)
+		case "/v1/evaluations":
+			require.Equal(t, "*", query.Get("namespace"))
+			fmt.Fprint(w, evaluationsResponse)
+		case "/v1/nodes":
+			require.Equal(t, "true", query.Get("resources"))
+			fmt.Fprint(w, nodesResponse)
+		case "/v1/operator/autopilot/health":
+			fmt.Fprint(w, healthResponse)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newPlugin(url string) *Nomad {
+	return &Nomad{
+		URL:       url,
+		Token:     "secret",
+		Region:    "east",
+		Namespace: "*",
+		Timeout:   internal.Duration{Duration: 5 * time.Second},
+	}
+}
+
+func TestGather(t *testing.T) {
+	ts := newNomadServer(t)
+	defer ts.Close()
+
+	plugin := newPlugin(ts.URL)
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "nomad_allocations",
+		map[string]interface{}{"pending": 1, "running": 1, "complete": 1, "failed": 0, "lost": 0},
+		map[string]string{"namespace": "default"})
+	acc.AssertContainsTaggedFields(t, "nomad_allocations",
+		map[string]interface{}{"pending": 0, "running": 0, "complete": 0, "failed": 1, "lost": 0},
+		map[string]string{"namespace": "batch"})
+
+	acc.AssertContainsTaggedFields(t, "nomad_evaluations",
+		map[string]interface{}{"blocked": 1, "pending": 0, "complete": 1, "failed": 0, "canceled": 0},
+		map[string]string{"namespace": "default"})
+
+	acc.AssertContainsTaggedFields(t, "nomad_node_pool",
+		map[string]interface{}{
+			"nodes":               2,
+			"nodes_ready":         2,
+			"nodes_eligible":      1,
+			"cpu_mhz":             int64(7900),
+			"memory_mb":           int64(16128),
+			"disk_mb":             int64(199000),
+			"cpu_allocated_mhz":   int64(1100),
+			"memory_allocated_mb": int64(576),
+			"disk_allocated_mb":   int64(600),
+		},
+		map[string]string{"node_pool": "default"})
+	acc.AssertContainsTaggedFields(t, "nomad_node_pool",
+		map[string]interface{}{
+			"nodes":               1,
+			"nodes_ready":         0,
+			"nodes_eligible":      0,
+			"cpu_mhz":             int64(0),
+			"memory_mb":           int64(0),
+			"disk_mb":             int64(0),
+			"cpu_allocated_mhz":   int64(0),
+			"memory_allocated_mb": int64(0),
+			"disk_allocated_mb":   int64(0),
+		},
+		map[string]string{"node_pool": "batch"})
+
+	acc.AssertContainsTaggedFields(t, "nomad_raft",
+		map[string]interface{}{
+			"healthy":           true,
+			"failure_tolerance": 1,
+			"servers":           3,
+			"servers_healthy":   2,
+			"voters":            2,
+		},
+		map[string]string{})
+	acc.AssertContainsTaggedFields(t, "nomad_raft_server",
+		map[string]interface{}{
+			"leader":          false,
+			"voter":           true,
+			"healthy":         true,
+			"serf_status":     "alive",
+			"last_contact_ms": 12.5,
+			"last_term":       uint64(3),
+			"last_index":      uint64(119),
+		},
+		map[string]string{"server": "server2.global", "address": "10.0.0.2:4647", "version": "1.6.1"})
+	acc.AssertContainsTaggedFields(t, "nomad_raft_server",
+		map[string]interface{}{
+			"leader":          false,
+			"voter":           false,
+			"healthy":         false,
+			"serf_status":     "failed",
+			"last_contact_ms": 2000.0,
+			"last_term":       uint64(2),
+			"last_index":      uint64(80),
+		},
+		map[string]string{"server": "server3.global", "address": "10.0.0.3:4647", "version": "1.6.1"})
+}
+
+func TestGatherPermissionDenied(t *testing.T) {
+	ts := newNomadServer(t)
+	defer ts.Close()
+
+	plugin := newPlugin(ts.URL)
+	plugin.Token = "wrong"
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 4)
+	require.Contains(t, acc.Errors[0].Error(), "Permission denied")
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestPath(t *testing.T) {
+	plugin := &Nomad{}
+	require.Equal(t, "/v1/nodes", plugin.path("/v1/nodes", true))
+
+	plugin.Region = "east"
+	plugin.Namespace = "*"
+	require.Equal(t, "/v1/nodes?region=east", plugin.path("/v1/nodes", false))
+	require.Equal(t, "/v1/allocations?resources=true&region=east&namespace=%2A",
+		plugin.path("/v1/allocations", true, "resources=true"))
+}
@@ -0,0 +1,32 @@
+package nvmet
+
+import (
+	"github.com/influxdata/telegraf"
+)
+
+// Nvmet is used to store configuration values.
+type Nvmet struct {
+	HostSys    string          `toml:"host_sys"`
+	Subsystems []string        `toml:"subsystems"`
+	Log        telegraf.Logger `toml:"-"`
+}
+
+var sampleConfig = `
+  ## Sets 'sys' directory path
+  ## If not specified, then default is /sys
+  # host_sys = "/sys"
+
+  ## By default, telegraf gathers stats for all NVMe-oF subsystems.  Setting
+  ## subsystems will restrict the stats to the specified NQNs.
+  # subsystems = ["nqn.2014-08.org.example:storage1"]
+`
+
+// Description returns information about the plugin.
+func (n *Nvmet) Description() string {
+	return "Gather subsystem, namespace and port stats of the Linux NVMe over Fabrics target"
+}
+
+// SampleConfig displays configuration instructions.
+func (n *Nvmet) SampleConfig() string {
+	return sampleConfig
+}
@@ -0,0 +1,255 @@
+// +build linux
+
+package nvmet
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// default host sys path
+const defaultHostSys = "/sys"
+
+// env host sys variable name
+const envSys = "HOST_SYS"
+
+// size of a sector in the block device statistics
+const sectorSize = 512
+
+// Gather collects the stats of the subsystems and ports of the target.
+func (n *Nvmet) Gather(acc telegraf.Accumulator) error {
+	// load sys path, get default value if config value and env variable are empty
+	n.loadPath()
+
+	root := filepath.Join(n.HostSys, "kernel", "config", "nvmet")
+	if _, err := os.Stat(root); err != nil {
+		return fmt.Errorf("NVMe target configuration not found, is the nvmet module loaded and configfs mounted? %v", err)
+	}
+
+	subsystems, err := n.listSubsystems(root)
+	if err != nil {
+		return err
+	}
+
+	// count the ports exporting each subsystem
+	exported := make(map[string]int)
+	ports, err := listDir(filepath.Join(root, "ports"))
+	if err != nil {
+		return err
+	}
+	for _, port := range ports {
+		linked, err := gatherPort(filepath.Join(root, "ports", port), port, acc)
+		if err != nil {
+			acc.AddError(fmt.Errorf("error inspecting NVMe target port %q: %v", port, err))
+			continue
+		}
+		for _, nqn := range linked {
+			exported[nqn]++
+		}
+	}
+
+	for _, nqn := range subsystems {
+		if err := n.gatherSubsystem(root, nqn, exported[nqn], acc); err != nil {
+			acc.AddError(fmt.Errorf("error inspecting NVMe target subsystem %q: %v", nqn, err))
+		}
+	}
+	return nil
+}
+
+func (n *Nvmet) listSubsystems(root string) ([]string, error) {
+	if len(n.Subsystems) > 0 {
+		return n.Subsystems, nil
+	}
+	return listDir(filepath.Join(root, "subsystems"))
+}
+
+func gatherPort(dir, port string, acc telegraf.Accumulator) ([]string, error) {
+	trtype, err := readAttribute(dir, "addr_trtype")
+	if err != nil {
+		return nil, err
+	}
+	tags := map[string]string{
+		"port":      port,
+		"transport": trtype,
+	}
+	if traddr, err := readAttribute(dir, "addr_traddr"); err == nil && traddr != "" {
+		tags["address"] = traddr
+	}
+	if trsvcid, err := readAttribute(dir, "addr_trsvcid"); err == nil && trsvcid != "" {
+		tags["service"] = trsvcid
+	}
+
+	linked, err := listDir(filepath.Join(dir, "subsystems"))
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{
+		"subsystems": len(linked),
+	}
+	acc.AddFields("nvmet_port", fields, tags)
+	return linked, nil
+}
+
+func (n *Nvmet) gatherSubsystem(root, nqn string, ports int, acc telegraf.Accumulator) error {
+	dir := filepath.Join(root, "subsystems", nqn)
+	allowAnyHost, err := readAttribute(dir, "attr_allow_any_host")
+	if err != nil {
+		return err
+	}
+
+	allowedHosts, err := listDir(filepath.Join(dir, "allowed_hosts"))
+	if err != nil {
+		return err
+	}
+	namespaces, err := listDir(filepath.Join(dir, "namespaces"))
+	if err != nil {
+		return err
+	}
+
+	enabled := 0
+	for _, nsid := range namespaces {
+		if n.gatherNamespace(filepath.Join(dir, "namespaces", nsid), nqn, nsid, acc) {
+			enabled++
+		}
+	}
+
+	tags := map[string]string{"subsystem": nqn}
+	fields := map[string]interface{}{
+		"allow_any_host":     allowAnyHost == "1",
+		"allowed_hosts":      len(allowedHosts),
+		"namespaces":         len(namespaces),
+		"enabled_namespaces": enabled,
+		"ports":              ports,
+	}
+
+	// Connected controllers are only exposed in debugfs by recent kernels.
+	if entries, err := ioutil.ReadDir(filepath.Join(n.HostSys, "kernel", "debug", "nvmet", nqn)); err == nil {
+		controllers := 0
+		for _, entry := range entries {
+			if entry.IsDir() && strings.HasPrefix(entry.Name(), "ctrl") {
+				controllers++
+			}
+		}
+		fields["controllers"] = controllers
+	}
+
+	acc.AddFields("nvmet_subsystem", fields, tags)
+	return nil
+}
+
+// gatherNamespace adds the state of the namespace and the I/O statistics of
+// the backing block device and returns whether the namespace is enabled.
+func (n *Nvmet) gatherNamespace(dir, nqn, nsid string, acc telegraf.Accumulator) bool {
+	enable, _ := readAttribute(dir, "enable")
+	devicePath, _ := readAttribute(dir, "device_path")
+
+	tags := map[string]string{
+		"subsystem": nqn,
+		"namespace": nsid,
+	}
+	if devicePath != "" {
+		tags["device"] = devicePath
+	}
+	fields := map[string]interface{}{
+		"enabled": enable == "1",
+	}
+
+	// File backed namespaces have no block device statistics.
+	if devicePath != "" {
+		if resolved, err := filepath.EvalSymlinks(devicePath); err == nil {
+			stat := filepath.Join(n.HostSys, "class", "block", filepath.Base(resolved), "stat")
+			if err := readBlockStat(stat, fields); err != nil && !os.IsNotExist(err) {
+				n.Log.Debugf("Reading statistics of %s: %v", devicePath, err)
+			}
+		}
+	}
+
+	acc.AddFields("nvmet_namespace", fields, tags)
+	return enable == "1"
+}
+
+// readBlockStat adds the counters of a block device stat file, see
+// https://www.kernel.org/doc/Documentation/block/stat.txt
+func readBlockStat(path string, fields map[string]interface{}) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	values := strings.Fields(string(b))
+	if len(values) < 11 {
+		return fmt.Errorf("unexpected format %q", strings.TrimSpace(string(b)))
+	}
+	counters := make([]uint64, 11)
+	for i := range counters {
+		v, err := strconv.ParseUint(values[i], 10, 64)
+		if err != nil {
+			return err
+		}
+		counters[i] = v
+	}
+	fields["reads"] = counters[0]
+	fields["read_bytes"] = counters[2] * sectorSize
+	fields["read_time_ms"] = counters[3]
+	fields["writes"] = counters[4]
+	fields["write_bytes"] = counters[6] * sectorSize
+	fields["write_time_ms"] = counters[7]
+	fields["io_in_progress"] = counters[8]
+	fields["io_time_ms"] = counters[9]
+	return nil
+}
+
+// listDir returns the names of the entries of a configfs directory.
+func listDir(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
+
+// readAttribute returns the trimmed content of a configfs attribute.
+func readAttribute(dir, name string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// loadPath can be used to read path firstly from config
+// if it is empty then try read from env variable
+func (n *Nvmet) loadPath() {
+	if n.HostSys == "" {
+		n.HostSys = sys(envSys, defaultHostSys)
+	}
+}
+
+// sys can be used to read file paths from env
+func sys(env, path string) string {
+	// try to read full file path
+	if p := os.Getenv(env); p != "" {
+		return p
+	}
+	// return default path
+	return path
+}
+
+func init() {
+	inputs.Add("nvmet", func() telegraf.Input {
+		return &Nvmet{}
+	})
+}
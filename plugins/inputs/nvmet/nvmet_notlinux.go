@@ -0,0 +1,23 @@
+// +build !linux
+
+package nvmet
+
+import (
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+func (n *Nvmet) Init() error {
+	n.Log.Warn("Current platform is not supported")
+	return nil
+}
+
+func (n *Nvmet) Gather(acc telegraf.Accumulator) error {
+	return nil
+}
+
+func init() {
+	inputs.Add("nvmet", func() telegraf.Input {
+		return &Nvmet{}
+	})
+}
@@ -0,0 +1,175 @@
+// +build linux
+
+package nvmet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	nqn1 = "nqn.2014-08.org.example:storage1"
+	nqn2 = "nqn.2014-08.org.example:storage2"
+)
+
+func writeAttributes(t *testing.T, dir string, attributes map[string]string) {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	for name, value := range attributes {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(value+"\n"), 0644))
+	}
+}
+
+func newSysfs(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "nvmet")
+	require.NoError(t, err)
+	root := filepath.Join(dir, "kernel", "config", "nvmet")
+
+	// backing devices
+	dev := filepath.Join(dir, "dev")
+	writeAttributes(t, dev, map[string]string{"dm-3": ""})
+	require.NoError(t, os.MkdirAll(filepath.Join(dev, "mapper"), 0755))
+	require.NoError(t, os.Symlink("../dm-3", filepath.Join(dev, "mapper", "vol1")))
+	writeAttributes(t, filepath.Join(dir, "class", "block", "dm-3"), map[string]string{
+		"stat": "    1000        0    16000      500     2000        0    64000     1500        2     1800     2000",
+	})
+
+	sub1 := filepath.Join(root, "subsystems", nqn1)
+	writeAttributes(t, sub1, map[string]string{"attr_allow_any_host": "0"})
+	writeAttributes(t, filepath.Join(sub1, "namespaces", "1"), map[string]string{
+		"enable":      "1",
+		"device_path": filepath.Join(dev, "mapper", "vol1"),
+	})
+	writeAttributes(t, filepath.Join(sub1, "namespaces", "2"), map[string]string{
+		"enable":      "0",
+		"device_path": "/var/lib/nvmet/file.img",
+	})
+	require.NoError(t, os.MkdirAll(filepath.Join(sub1, "allowed_hosts", "nqn.2014-08.org.example:host1"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "kernel", "debug", "nvmet", nqn1, "ctrl1"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "kernel", "debug", "nvmet", nqn1, "ctrl2"), 0755))
+
+	sub2 := filepath.Join(root, "subsystems", nqn2)
+	writeAttributes(t, sub2, map[string]string{"attr_allow_any_host": "1"})
+	require.NoError(t, os.MkdirAll(filepath.Join(sub2, "namespaces"), 0755))
+
+	port := filepath.Join(root, "ports", "1")
+	writeAttributes(t, port, map[string]string{
+		"addr_trtype":  "tcp",
+		"addr_traddr":  "192.168.1.10",
+		"addr_trsvcid": "4420",
+	})
+	require.NoError(t, os.MkdirAll(filepath.Join(port, "subsystems"), 0755))
+	require.NoError(t, os.Symlink(sub1, filepath.Join(port, "subsystems", nqn1)))
+	return dir
+}
+
+func TestGather(t *testing.T) {
+	dir := newSysfs(t)
+	defer os.RemoveAll(dir)
+
+	plugin := &Nvmet{HostSys: dir, Log: testutil.Logger{}}
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("nvmet_port",
+			map[string]string{
+				"port":      "1",
+				"transport": "tcp",
+				"address":   "192.168.1.10",
+				"service":   "4420",
+			},
+			map[string]interface{}{
+				"subsystems": int64(1),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("nvmet_subsystem",
+			map[string]string{
+				"subsystem": nqn1,
+			},
+			map[string]interface{}{
+				"allow_any_host":     false,
+				"allowed_hosts":      int64(1),
+				"namespaces":         int64(2),
+				"enabled_namespaces": int64(1),
+				"ports":              int64(1),
+				"controllers":        int64(2),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("nvmet_namespace",
+			map[string]string{
+				"subsystem": nqn1,
+				"namespace": "1",
+				"device":    filepath.Join(dir, "dev", "mapper", "vol1"),
+			},
+			map[string]interface{}{
+				"enabled":        true,
+				"reads":          uint64(1000),
+				"read_bytes":     uint64(8192000),
+				"read_time_ms":   uint64(500),
+				"writes":         uint64(2000),
+				"write_bytes":    uint64(32768000),
+				"write_time_ms":  uint64(1500),
+				"io_in_progress": uint64(2),
+				"io_time_ms":     uint64(1800),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("nvmet_namespace",
+			map[string]string{
+				"subsystem": nqn1,
+				"namespace": "2",
+				"device":    "/var/lib/nvmet/file.img",
+			},
+			map[string]interface{}{
+				"enabled": false,
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("nvmet_subsystem",
+			map[string]string{
+				"subsystem": nqn2,
+			},
+			map[string]interface{}{
+				"allow_any_host":     true,
+				"allowed_hosts":      int64(0),
+				"namespaces":         int64(0),
+				"enabled_namespaces": int64(0),
+				"ports":              int64(0),
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestGatherSelectedSubsystems(t *testing.T) {
+	dir := newSysfs(t)
+	defer os.RemoveAll(dir)
+
+	plugin := &Nvmet{HostSys: dir, Subsystems: []string{nqn2, "nqn.2014-08.org.example:missing"}, Log: testutil.Logger{}}
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.True(t, acc.HasTag("nvmet_subsystem", "subsystem"))
+	require.False(t, acc.HasMeasurement("nvmet_namespace"))
+}
+
+func TestGatherNotConfigured(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nvmet")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	plugin := &Nvmet{HostSys: dir, Log: testutil.Logger{}}
+	var acc testutil.Accumulator
+	require.Error(t, plugin.Gather(&acc))
+}
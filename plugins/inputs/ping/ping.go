@@ -15,6 +15,7 @@ import (
 	"github.com/glinton/ping"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/targets"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -31,16 +32,16 @@ type Ping struct {
 	wg sync.WaitGroup
 
 	// Interval at which to ping (ping -i <INTERVAL>)
-	PingInterval float64 `toml:"ping_interval"`
+	PingInterval float64 `toml:"ping_interval" range:"0,"`
 
 	// Number of pings to send (ping -c <COUNT>)
-	Count int
+	Count int `range:"1,"`
 
 	// Per-ping timeout, in seconds. 0 means no timeout (ping -W <TIMEOUT>)
-	Timeout float64
+	Timeout float64 `range:"0,"`
 
 	// Ping deadline, in seconds. 0 means no deadline. (ping -w <DEADLINE>)
-	Deadline int
+	Deadline int `range:"0,"`
 
 	// Interface or source address to send ping from (ping -I/-S <INTERFACE/SRC_ADDR>)
 	Interface string
@@ -61,6 +62,9 @@ type Ping struct {
 	// Whether to resolve addresses using ipv6 or not.
 	IPv6 bool
 
+	// Hosts to ping loaded from a file or URL
+	targets.Config
+
 	// host ping function
 	pingHost HostPinger
 
@@ -118,6 +122,16 @@ const sampleConfig = `
 
   ## Use only IPv6 addresses when resolving a hostname.
   # ipv6 = false
+
+  ## Load additional hosts from a JSON file or HTTP endpoint, refreshed
+  ## periodically.  The list contains either hostnames or objects of the form
+  ## {"target": "example.org", "labels": {"region": "eu"}} with labels added
+  ## as tags.
+  # targets_url = "http://localhost:8080/targets/ping"
+  # targets_file = "/etc/telegraf/ping_targets.json"
+  # targets_refresh_interval = "5m"
+  # targets_timeout = "5s"
+  # targets_headers = {"Authorization" = "Bearer mytoken"}
 `
 
 func (*Ping) SampleConfig() string {
@@ -129,18 +143,24 @@ func (p *Ping) Gather(acc telegraf.Accumulator) error {
 		p.listenAddr = getAddr(p.Interface)
 	}
 
-	for _, host := range p.Urls {
+	list, err := p.Targets(p.Urls)
+	if err != nil {
+		acc.AddError(err)
+	}
+
+	for _, target := range list {
 		p.wg.Add(1)
-		go func(host string) {
+		go func(target targets.Target) {
 			defer p.wg.Done()
 
+			acc := targets.Accumulator(acc, target)
 			switch p.Method {
 			case "native":
-				p.pingToURLNative(host, acc)
+				p.pingToURLNative(target.Target, acc)
 			default:
-				p.pingToURL(host, acc)
+				p.pingToURL(target.Target, acc)
 			}
-		}(host)
+		}(target)
 	}
 
 	p.wg.Wait()
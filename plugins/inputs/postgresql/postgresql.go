@@ -46,6 +46,10 @@ var sampleConfig = `
   ## default is forever (0s)
   max_lifetime = "0s"
 
+  ## Use the simple query protocol instead of prepared statements, needed
+  ## when connecting through pgbouncer in transaction pooling mode.
+  # prefer_simple_protocol = false
+
   ## A  list of databases to explicitly ignore.  If not specified, metrics for all
   ## databases are gathered.  Do NOT use with the 'databases' option.
   # ignored_databases = ["postgres", "template0", "template1"]
@@ -94,6 +94,10 @@ type Service struct {
 	MaxLifetime   internal.Duration
 	DB            *sql.DB
 	IsPgBouncer   bool
+
+	// PreferSimpleProtocol disables the prepared statements, as done for
+	// pgbouncer.
+	PreferSimpleProtocol bool `toml:"prefer_simple_protocol"`
 }
 
 // Start starts the ServiceInput's service, whatever that may be
@@ -108,7 +112,7 @@ func (p *Service) Start(telegraf.Accumulator) (err error) {
 
 	// Specific support to make it work with PgBouncer too
 	// See https://github.com/influxdata/telegraf/issues/3253#issuecomment-357505343
-	if p.IsPgBouncer {
+	if p.IsPgBouncer || p.PreferSimpleProtocol {
 		d := &stdlib.DriverConfig{
 			ConnConfig: pgx.ConnConfig{
 				PreferSimpleProtocol: true,
@@ -0,0 +1,294 @@
+package postgresql_extensible
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// statementsQuery returns the query of the statements taking the most time
+// in pg_stat_statements, the time columns were renamed in PostgreSQL 13.
+func statementsQuery(version int, top int) string {
+	totalTime, meanTime := "total_time", "mean_time"
+	if version >= 1300 {
+		totalTime, meanTime = "total_exec_time", "mean_exec_time"
+	}
+	return fmt.Sprintf(`SELECT d.datname, r.rolname, s.queryid, s.query, s.calls,
+  s.%[1]s AS total_time, s.%[2]s AS mean_time, s.rows,
+  s.shared_blks_hit, s.shared_blks_read, s.temp_blks_written
+FROM pg_stat_statements s
+  JOIN pg_database d ON d.oid = s.dbid
+  JOIN pg_roles r ON r.oid = s.userid
+ORDER BY s.%[1]s DESC
+LIMIT %[3]d`, totalTime, meanTime, top)
+}
+
+var statementsColumns = []string{
+	"datname", "rolname", "queryid", "query", "calls", "total_time", "mean_time",
+	"rows", "shared_blks_hit", "shared_blks_read", "temp_blks_written",
+}
+
+// replicationSlotsQuery returns the query of the lag of the replication
+// slots in bytes, the functions were renamed in PostgreSQL 10.
+func replicationSlotsQuery(version int) string {
+	diff, current, received := "pg_wal_lsn_diff", "pg_current_wal_lsn()", "pg_last_wal_receive_lsn()"
+	if version < 1000 {
+		diff, current, received = "pg_xlog_location_diff", "pg_current_xlog_location()", "pg_last_xlog_receive_location()"
+	}
+	return fmt.Sprintf(`WITH lsn AS (
+  SELECT CASE WHEN pg_is_in_recovery() THEN %[3]s ELSE %[2]s END AS current
+)
+SELECT slot_name, slot_type, database AS datname, plugin, active,
+  %[1]s(lsn.current, restart_lsn)::bigint AS restart_lag_bytes,
+  %[1]s(lsn.current, confirmed_flush_lsn)::bigint AS confirmed_flush_lag_bytes
+FROM pg_replication_slots, lsn`, diff, current, received)
+}
+
+var replicationSlotsColumns = []string{
+	"slot_name", "slot_type", "datname", "plugin", "active",
+	"restart_lag_bytes", "confirmed_flush_lag_bytes",
+}
+
+// waitEventsQuery samples the sessions waiting, by wait event.  The wait
+// events are available since PostgreSQL 9.6.
+const waitEventsQuery = `SELECT datname, wait_event_type, wait_event, state, count(*) AS sessions
+FROM pg_stat_activity
+WHERE wait_event IS NOT NULL AND pid <> pg_backend_pid()
+GROUP BY datname, wait_event_type, wait_event, state`
+
+var waitEventsColumns = []string{"datname", "wait_event_type", "wait_event", "state", "sessions"}
+
+// gatherBuiltin gathers the metrics of the built-in queries enabled.
+func (p *Postgresql) gatherBuiltin(acc telegraf.Accumulator, version int) {
+	if p.StatementsTop > 0 {
+		p.gatherQuery(acc, statementsQuery(version, p.StatementsTop), statementsColumns, p.accStatement)
+	}
+	if p.ReplicationSlots {
+		if version < 904 {
+			p.Log.Debug("Replication slots need PostgreSQL 9.4 or later")
+		} else {
+			query := replicationSlotsQuery(version)
+			if version < 906 {
+				// confirmed_flush_lsn was added in PostgreSQL 9.6
+				query = strings.Replace(query, "confirmed_flush_lsn", "NULL", 1)
+			}
+			p.gatherQuery(acc, query, replicationSlotsColumns, p.accReplicationSlot)
+		}
+	}
+	if p.WaitEvents {
+		if version < 906 {
+			p.Log.Debug("Wait events need PostgreSQL 9.6 or later")
+		} else {
+			p.gatherQuery(acc, waitEventsQuery, waitEventsColumns, p.accWaitEvent)
+		}
+	}
+}
+
+func (p *Postgresql) gatherQuery(acc telegraf.Accumulator, query string, columns []string,
+	accFn func(map[string]interface{}, telegraf.Accumulator) error) {
+	rows, err := p.DB.Query(query)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		values, err := scanRow(rows, columns)
+		if err == nil {
+			err = accFn(values, acc)
+		}
+		if err != nil {
+			acc.AddError(err)
+			return
+		}
+	}
+	if err := rows.Err(); err != nil {
+		acc.AddError(err)
+	}
+}
+
+// scanRow returns the values of the columns of the row, the NULL values are
+// left out.
+func scanRow(row scanner, columns []string) (map[string]interface{}, error) {
+	columnVars := make([]interface{}, len(columns))
+	for i := range columnVars {
+		columnVars[i] = new(interface{})
+	}
+	if err := row.Scan(columnVars...); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		v := *columnVars[i].(*interface{})
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		if v != nil {
+			values[column] = v
+		}
+	}
+	return values, nil
+}
+
+func (p *Postgresql) tags(values map[string]interface{}) (map[string]string, error) {
+	server, err := p.SanitizedAddress()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := map[string]string{"server": server, "db": "postgres"}
+	if datname, ok := values["datname"].(string); ok {
+		tags["db"] = datname
+	}
+	return tags, nil
+}
+
+func (p *Postgresql) accStatement(values map[string]interface{}, acc telegraf.Accumulator) error {
+	tags, err := p.tags(values)
+	if err != nil {
+		return err
+	}
+	if user, ok := values["rolname"].(string); ok {
+		tags["user"] = user
+	}
+	if queryid, ok := values["queryid"]; ok {
+		tags["queryid"] = fmt.Sprint(queryid)
+	}
+
+	// The query ids differ between servers and versions, the hash of the
+	// normalized text identifies the query everywhere.
+	query, _ := values["query"].(string)
+	normalized := normalizeQuery(query)
+	tags["query_hash"] = queryHash(normalized)
+
+	fields := make(map[string]interface{})
+	for _, column := range []string{"calls", "rows", "shared_blks_hit", "shared_blks_read", "temp_blks_written"} {
+		if v, ok := toInt64(values[column]); ok {
+			fields[column] = v
+		}
+	}
+	for _, column := range []string{"total_time", "mean_time"} {
+		if v, ok := toFloat64(values[column]); ok {
+			fields[column+"_ms"] = v
+		}
+	}
+	if p.StatementsQueryLength > 0 {
+		if len(normalized) > p.StatementsQueryLength {
+			normalized = normalized[:p.StatementsQueryLength]
+		}
+		fields["query"] = normalized
+	}
+
+	acc.AddFields("postgresql_statements", fields, tags)
+	return nil
+}
+
+func (p *Postgresql) accReplicationSlot(values map[string]interface{}, acc telegraf.Accumulator) error {
+	tags, err := p.tags(values)
+	if err != nil {
+		return err
+	}
+	for _, column := range []string{"slot_name", "slot_type", "plugin"} {
+		if v, ok := values[column].(string); ok {
+			tags[column] = v
+		}
+	}
+
+	fields := make(map[string]interface{})
+	if active, ok := values["active"].(bool); ok {
+		fields["active"] = active
+	}
+	for _, column := range []string{"restart_lag_bytes", "confirmed_flush_lag_bytes"} {
+		if v, ok := toInt64(values[column]); ok {
+			fields[column] = v
+		}
+	}
+
+	acc.AddFields("postgresql_replication_slots", fields, tags)
+	return nil
+}
+
+func (p *Postgresql) accWaitEvent(values map[string]interface{}, acc telegraf.Accumulator) error {
+	tags, err := p.tags(values)
+	if err != nil {
+		return err
+	}
+	for _, column := range []string{"wait_event_type", "wait_event", "state"} {
+		if v, ok := values[column].(string); ok {
+			tags[column] = v
+		}
+	}
+
+	sessions, _ := toInt64(values["sessions"])
+	acc.AddFields("postgresql_wait_events", map[string]interface{}{"sessions": sessions}, tags)
+	return nil
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case int32:
+		return int64(v), true
+	case int:
+		return int64(v), true
+	case float64:
+		return int64(v), true
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+var (
+	blockComment  = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	lineComment   = regexp.MustCompile(`--[^\n]*`)
+	stringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+	numberLiteral = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	parameter     = regexp.MustCompile(`\$\d+`)
+	valueList     = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	whitespace    = regexp.MustCompile(`\s+`)
+)
+
+// normalizeQuery normalizes the text of a query, so that the same query has
+// the same text whatever its parameters, comments, spacing and case.  The
+// literals and parameters are replaced by "?", and lists of them by "(?)".
+func normalizeQuery(query string) string {
+	query = blockComment.ReplaceAllString(query, " ")
+	query = lineComment.ReplaceAllString(query, " ")
+	query = stringLiteral.ReplaceAllString(query, "?")
+	query = parameter.ReplaceAllString(query, "?")
+	query = numberLiteral.ReplaceAllString(query, "?")
+	query = valueList.ReplaceAllString(query, "(?)")
+	query = whitespace.ReplaceAllString(query, " ")
+	return strings.ToLower(strings.TrimSpace(query))
+}
+
+// queryHash returns the hash of the normalized query text.
+func queryHash(normalized string) string {
+	h := fnv.New64a()
+	h.Write([]byte(normalized))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
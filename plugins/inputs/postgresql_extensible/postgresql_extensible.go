@@ -22,6 +22,11 @@ type Postgresql struct {
 	Query          query
 	Debug          bool
 
+	StatementsTop         int  `toml:"statements_top"`
+	StatementsQueryLength int  `toml:"statements_query_length"`
+	ReplicationSlots      bool `toml:"replication_slots"`
+	WaitEvents            bool `toml:"wait_events"`
+
 	Log telegraf.Logger
 }
 
@@ -56,6 +61,10 @@ var sampleConfig = `
   ## default is forever (0s)
   max_lifetime = "0s"
 
+  ## Use the simple query protocol instead of prepared statements, needed
+  ## when connecting through pgbouncer in transaction pooling mode.
+  # prefer_simple_protocol = false
+
   ## A list of databases to pull metrics about. If not specified, metrics for all
   ## databases are gathered.
   ## databases = ["app_production", "testing"]
@@ -65,6 +74,23 @@ var sampleConfig = `
   ## the connection address is used.
   # outputaddress = "db01"
   #
+  ## Gather the statements taking the most time from pg_stat_statements in
+  ## the postgresql_statements measurement, when set to more than 0.  The
+  ## statements are tagged with the hash of their normalized text, which is
+  ## added as the query field when statements_query_length is more than 0,
+  ## truncated to that length.  The pg_stat_statements extension must be
+  ## installed.
+  # statements_top = 0
+  # statements_query_length = 0
+  #
+  ## Gather the lag in bytes of the replication slots in the
+  ## postgresql_replication_slots measurement.
+  # replication_slots = false
+  #
+  ## Sample the sessions waiting by wait event in the postgresql_wait_events
+  ## measurement.
+  # wait_events = false
+  #
   ## Define the toml config where the sql queries are stored
   ## New queries can be added, if the withdbname is set to true and there is no
   ## databases defined in the 'databases field', the sql query is ended by a
@@ -215,6 +241,8 @@ func (p *Postgresql) Gather(acc telegraf.Accumulator) error {
 			}
 		}
 	}
+
+	p.gatherBuiltin(acc, db_version)
 	return nil
 }
 
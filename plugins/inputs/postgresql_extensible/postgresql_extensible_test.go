@@ -293,3 +293,110 @@ func (f fakeRow) Scan(dest ...interface{}) error {
 	}
 	return nil
 }
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{
+			query:    "SELECT * FROM users WHERE id = $1",
+			expected: "select * from users where id = ?",
+		},
+		{
+			query:    "select *\n  from users -- by name\n where name = 'O''Brien' /* test */ and age > 42",
+			expected: "select * from users where name = ? and age > ?",
+		},
+		{
+			query:    "SELECT * FROM t1 WHERE id IN ($1, $2,$3)",
+			expected: "select * from t1 where id in (?)",
+		},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, normalizeQuery(tt.query))
+	}
+
+	require.Equal(t,
+		queryHash(normalizeQuery("SELECT * FROM users WHERE id IN (1, 2)")),
+		queryHash(normalizeQuery("select * from users where id in ($1)")))
+	require.NotEqual(t,
+		queryHash(normalizeQuery("SELECT * FROM users")),
+		queryHash(normalizeQuery("SELECT * FROM groups")))
+}
+
+func TestStatementsQueryVersion(t *testing.T) {
+	require.Contains(t, statementsQuery(1200, 10), "s.total_time AS total_time")
+	require.Contains(t, statementsQuery(1300, 10), "s.total_exec_time AS total_time")
+	require.Contains(t, statementsQuery(1300, 10), "LIMIT 10")
+	require.Contains(t, replicationSlotsQuery(906), "pg_xlog_location_diff")
+	require.Contains(t, replicationSlotsQuery(1000), "pg_wal_lsn_diff")
+}
+
+func TestAccBuiltin(t *testing.T) {
+	p := Postgresql{
+		Service:               postgresql.Service{Address: "host=localhost password=secret"},
+		StatementsQueryLength: 16,
+		Log:                   testutil.Logger{},
+	}
+
+	var acc testutil.Accumulator
+
+	values, err := scanRow(fakeRow{fields: []interface{}{
+		"app", "alice", int64(-42), []byte("SELECT * FROM users WHERE id = $1"), int64(10),
+		float64(25.5), float64(2.55), int64(10), int64(100), int64(5), nil,
+	}}, statementsColumns)
+	require.NoError(t, err)
+	require.NoError(t, p.accStatement(values, &acc))
+
+	values, err = scanRow(fakeRow{fields: []interface{}{
+		"slot", "logical", "app", "pgoutput", true, int64(1024), int64(512),
+	}}, replicationSlotsColumns)
+	require.NoError(t, err)
+	require.NoError(t, p.accReplicationSlot(values, &acc))
+
+	values, err = scanRow(fakeRow{fields: []interface{}{
+		nil, "Lock", "relation", "active", int64(2),
+	}}, waitEventsColumns)
+	require.NoError(t, err)
+	require.NoError(t, p.accWaitEvent(values, &acc))
+
+	acc.AssertContainsTaggedFields(t, "postgresql_statements",
+		map[string]interface{}{
+			"calls":            int64(10),
+			"rows":             int64(10),
+			"shared_blks_hit":  int64(100),
+			"shared_blks_read": int64(5),
+			"total_time_ms":    float64(25.5),
+			"mean_time_ms":     float64(2.55),
+			"query":            "select * from us",
+		},
+		map[string]string{
+			"server":     "host=localhost ",
+			"db":         "app",
+			"user":       "alice",
+			"queryid":    "-42",
+			"query_hash": queryHash("select * from users where id = ?"),
+		})
+	acc.AssertContainsTaggedFields(t, "postgresql_replication_slots",
+		map[string]interface{}{
+			"active":                    true,
+			"restart_lag_bytes":         int64(1024),
+			"confirmed_flush_lag_bytes": int64(512),
+		},
+		map[string]string{
+			"server":    "host=localhost ",
+			"db":        "app",
+			"slot_name": "slot",
+			"slot_type": "logical",
+			"plugin":    "pgoutput",
+		})
+	acc.AssertContainsTaggedFields(t, "postgresql_wait_events",
+		map[string]interface{}{"sessions": int64(2)},
+		map[string]string{
+			"server":          "host=localhost ",
+			"db":              "postgres",
+			"wait_event_type": "Lock",
+			"wait_event":      "relation",
+			"state":           "active",
+		})
+}
@@ -138,7 +138,7 @@ func makeBucketsV2(m *dto.Metric, tags map[string]string, metricName string, met
 	} else {
 		t = now
 	}
-	fields[metricName+"_count"] = float64(m.GetHistogram().GetSampleCount())
+	fields[metricName+"_count"] = histogramCount(m.GetHistogram())
 	fields[metricName+"_sum"] = float64(m.GetHistogram().GetSampleSum())
 
 	met, err := metric.New("prometheus", tags, fields, t, valueType(metricType))
@@ -146,11 +146,11 @@ func makeBucketsV2(m *dto.Metric, tags map[string]string, metricName string, met
 		metrics = append(metrics, met)
 	}
 
-	for _, b := range m.GetHistogram().Bucket {
+	for _, b := range histogramBuckets(m.GetHistogram()) {
 		newTags := tags
 		fields = make(map[string]interface{})
 		newTags["le"] = fmt.Sprint(b.GetUpperBound())
-		fields[metricName+"_bucket"] = float64(b.GetCumulativeCount())
+		fields[metricName+"_bucket"] = bucketCount(b)
 
 		histogramMetric, err := metric.New("prometheus", newTags, fields, t, valueType(metricType))
 		if err == nil {
@@ -212,7 +212,7 @@ func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 			} else if mf.GetType() == dto.MetricType_HISTOGRAM {
 				// histogram metric
 				fields = makeBuckets(m)
-				fields["count"] = float64(m.GetHistogram().GetSampleCount())
+				fields["count"] = histogramCount(m.GetHistogram())
 				fields["sum"] = float64(m.GetHistogram().GetSampleSum())
 
 			} else {
@@ -267,12 +267,136 @@ func makeQuantiles(m *dto.Metric) map[string]interface{} {
 // Get Buckets  from histogram metric
 func makeBuckets(m *dto.Metric) map[string]interface{} {
 	fields := make(map[string]interface{})
-	for _, b := range m.GetHistogram().Bucket {
-		fields[fmt.Sprint(b.GetUpperBound())] = float64(b.GetCumulativeCount())
+	for _, b := range histogramBuckets(m.GetHistogram()) {
+		fields[fmt.Sprint(b.GetUpperBound())] = bucketCount(b)
 	}
 	return fields
 }
 
+// histogramCount returns the number of observations in the histogram, which
+// is a float for native histograms with float counts.
+func histogramCount(h *dto.Histogram) float64 {
+	if h.SampleCountFloat != nil {
+		return h.GetSampleCountFloat()
+	}
+	return float64(h.GetSampleCount())
+}
+
+// bucketCount returns the cumulative count of the bucket, which is a float
+// for histograms with float counts.
+func bucketCount(b *dto.Bucket) float64 {
+	if b.CumulativeCountFloat != nil {
+		return b.GetCumulativeCountFloat()
+	}
+	return float64(b.GetCumulativeCount())
+}
+
+// isNativeHistogram reports if the histogram has native (sparse) buckets.
+func isNativeHistogram(h *dto.Histogram) bool {
+	return h.GetZeroThreshold() > 0 || h.GetZeroCount() > 0 || h.GetZeroCountFloat() > 0 ||
+		len(h.GetPositiveSpan()) > 0 || len(h.GetNegativeSpan()) > 0
+}
+
+// histogramBuckets returns the cumulative buckets of the histogram.  The
+// buckets of a native histogram without classic buckets are converted to
+// cumulative buckets, with the zero bucket bounded by the zero threshold.
+func histogramBuckets(h *dto.Histogram) []*dto.Bucket {
+	if len(h.GetBucket()) > 0 || !isNativeHistogram(h) {
+		return h.GetBucket()
+	}
+
+	type bucket struct {
+		upper float64
+		count float64
+	}
+
+	// Boundaries of native buckets are powers of base, bucket i covers the
+	// range (base^(i-1), base^i].
+	base := math.Pow(2, math.Pow(2, -float64(h.GetSchema())))
+
+	negative := nativeBuckets(h.GetNegativeSpan(), h.GetNegativeDelta(), h.GetNegativeCount())
+	positive := nativeBuckets(h.GetPositiveSpan(), h.GetPositiveDelta(), h.GetPositiveCount())
+
+	var buckets []bucket
+	// Negative buckets mirror the positive ones, the bucket with the highest
+	// index has the lowest upper bound.
+	for i := len(negative) - 1; i >= 0; i-- {
+		buckets = append(buckets, bucket{
+			upper: -math.Pow(base, float64(negative[i].index-1)),
+			count: negative[i].count,
+		})
+	}
+
+	zeroCount := float64(h.GetZeroCount())
+	if h.ZeroCountFloat != nil {
+		zeroCount = h.GetZeroCountFloat()
+	}
+	buckets = append(buckets, bucket{upper: h.GetZeroThreshold(), count: zeroCount})
+
+	for _, b := range positive {
+		buckets = append(buckets, bucket{
+			upper: math.Pow(base, float64(b.index)),
+			count: b.count,
+		})
+	}
+
+	result := make([]*dto.Bucket, 0, len(buckets)+1)
+	var cumulative float64
+	for _, b := range buckets {
+		cumulative += b.count
+		upper, count := b.upper, cumulative
+		result = append(result, &dto.Bucket{
+			UpperBound:           &upper,
+			CumulativeCountFloat: &count,
+		})
+	}
+
+	inf := math.Inf(1)
+	count := histogramCount(h)
+	result = append(result, &dto.Bucket{
+		UpperBound:           &inf,
+		CumulativeCountFloat: &count,
+	})
+	return result
+}
+
+type nativeBucket struct {
+	index int32
+	count float64
+}
+
+// nativeBuckets expands the spans of a native histogram into buckets with
+// absolute counts.  Integer counts are delta encoded, float counts are
+// absolute.
+func nativeBuckets(spans []*dto.BucketSpan, deltas []int64, counts []float64) []nativeBucket {
+	var buckets []nativeBucket
+	var index int32
+	var n int
+	var value int64
+	for _, span := range spans {
+		index += span.GetOffset()
+		for j := uint32(0); j < span.GetLength(); j++ {
+			var count float64
+			if len(counts) > 0 {
+				if n >= len(counts) {
+					return buckets
+				}
+				count = counts[n]
+			} else {
+				if n >= len(deltas) {
+					return buckets
+				}
+				value += deltas[n]
+				count = float64(value)
+			}
+			buckets = append(buckets, nativeBucket{index: index, count: count})
+			index++
+			n++
+		}
+	}
+	return buckets
+}
+
 // Get labels from metric
 func makeLabels(m *dto.Metric) map[string]string {
 	result := map[string]string{}
@@ -1,11 +1,16 @@
 package prometheus
 
 import (
+	"bytes"
 	"net/http"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/matttproud/golang_protobuf_extensions/pbutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var exptime = time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
@@ -165,3 +170,83 @@ func TestParseValidPrometheus(t *testing.T) {
 		metrics[0].Tags())
 
 }
+
+func nativeHistogram(t *testing.T) ([]byte, http.Header) {
+	mf := &dto.MetricFamily{
+		Name: proto.String("request_duration_seconds"),
+		Type: dto.MetricType_HISTOGRAM.Enum(),
+		Metric: []*dto.Metric{
+			{
+				Label: []*dto.LabelPair{
+					{Name: proto.String("handler"), Value: proto.String("api")},
+				},
+				Histogram: &dto.Histogram{
+					SampleCount:   proto.Uint64(7),
+					SampleSum:     proto.Float64(10),
+					Schema:        proto.Int32(0),
+					ZeroThreshold: proto.Float64(0.001),
+					ZeroCount:     proto.Uint64(1),
+					NegativeSpan: []*dto.BucketSpan{
+						{Offset: proto.Int32(1), Length: proto.Uint32(1)},
+					},
+					NegativeDelta: []int64{1},
+					PositiveSpan: []*dto.BucketSpan{
+						{Offset: proto.Int32(0), Length: proto.Uint32(2)},
+					},
+					PositiveDelta: []int64{2, 1},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	_, err := pbutil.WriteDelimited(&buf, mf)
+	require.NoError(t, err)
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=delimited")
+	return buf.Bytes(), header
+}
+
+func TestParseNativeHistogram(t *testing.T) {
+	buf, header := nativeHistogram(t)
+	metrics, err := Parse(buf, header)
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	require.Equal(t, "request_duration_seconds", metrics[0].Name())
+	require.Equal(t, map[string]interface{}{
+		"-1":    1.0,
+		"0.001": 2.0,
+		"1":     4.0,
+		"2":     7.0,
+		"+Inf":  7.0,
+		"count": 7.0,
+		"sum":   10.0,
+	}, metrics[0].Fields())
+	require.Equal(t, map[string]string{"handler": "api"}, metrics[0].Tags())
+}
+
+func TestParseNativeHistogramV2(t *testing.T) {
+	buf, header := nativeHistogram(t)
+	metrics, err := ParseV2(buf, header)
+	require.NoError(t, err)
+	require.Len(t, metrics, 6)
+
+	buckets := make(map[string]interface{})
+	for _, m := range metrics[1:] {
+		le, ok := m.GetTag("le")
+		require.True(t, ok)
+		buckets[le] = m.Fields()["request_duration_seconds_bucket"]
+	}
+	require.Equal(t, map[string]interface{}{
+		"-1":    1.0,
+		"0.001": 2.0,
+		"1":     4.0,
+		"2":     7.0,
+		"+Inf":  7.0,
+	}, buckets)
+	require.Equal(t, map[string]interface{}{
+		"request_duration_seconds_count": 7.0,
+		"request_duration_seconds_sum":   10.0,
+	}, metrics[0].Fields())
+}
@@ -0,0 +1,162 @@
+package pure_flasharray
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// errUnauthorized is returned when the session expired.
+var errUnauthorized = errors.New("unauthorized")
+
+// response is a page of items returned by the REST 2.x API.
+type response struct {
+	Items             json.RawMessage `json:"items"`
+	ContinuationToken string          `json:"continuation_token"`
+}
+
+// apiErrors are the errors returned by the REST 2.x API.
+type apiErrors struct {
+	Errors []struct {
+		Message string `json:"message"`
+		Context string `json:"context"`
+	} `json:"errors"`
+}
+
+type client struct {
+	baseURL    *url.URL
+	apiVersion string
+	apiToken   string
+	httpClient *http.Client
+	limit      int
+
+	mu        sync.Mutex
+	authToken string
+}
+
+// login exchanges the API token for a session token.
+func (c *client) login(ctx context.Context) error {
+	u, err := c.baseURL.Parse("/api/" + c.apiVersion + "/login")
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("api-token", c.apiToken)
+
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(req, resp)
+	}
+	token := resp.Header.Get("x-auth-token")
+	if token == "" {
+		return fmt.Errorf("login did not return a session token")
+	}
+
+	c.mu.Lock()
+	c.authToken = token
+	c.mu.Unlock()
+	return nil
+}
+
+// getAll requests all items of the endpoint and calls fn with the items of
+// each page.  The session is renewed once if it expired.
+func (c *client) getAll(ctx context.Context, endpoint string, query url.Values, fn func(items json.RawMessage) error) error {
+	c.mu.Lock()
+	loggedIn := c.authToken != ""
+	c.mu.Unlock()
+	if !loggedIn {
+		if err := c.login(ctx); err != nil {
+			return err
+		}
+	}
+
+	err := c.getPages(ctx, endpoint, query, fn)
+	if err == errUnauthorized {
+		if err := c.login(ctx); err != nil {
+			return err
+		}
+		err = c.getPages(ctx, endpoint, query, fn)
+	}
+	return err
+}
+
+func (c *client) getPages(ctx context.Context, endpoint string, query url.Values, fn func(items json.RawMessage) error) error {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	if c.limit > 0 {
+		q.Set("limit", strconv.Itoa(c.limit))
+	}
+
+	for {
+		var r response
+		if err := c.get(ctx, endpoint, q, &r); err != nil {
+			return err
+		}
+		if len(r.Items) > 0 {
+			if err := fn(r.Items); err != nil {
+				return err
+			}
+		}
+		if r.ContinuationToken == "" {
+			return nil
+		}
+		q.Set("continuation_token", r.ContinuationToken)
+	}
+}
+
+func (c *client) get(ctx context.Context, endpoint string, query url.Values, v interface{}) error {
+	u, err := c.baseURL.Parse("/api/" + c.apiVersion + "/" + endpoint)
+	if err != nil {
+		return err
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	c.mu.Lock()
+	req.Header.Set("x-auth-token", c.authToken)
+	c.mu.Unlock()
+
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return errUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError(req, resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func responseError(req *http.Request, resp *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+	var e apiErrors
+	if json.Unmarshal(body, &e) == nil && len(e.Errors) > 0 {
+		return fmt.Errorf("%s returned HTTP status %s: %s", req.URL.Path, resp.Status, e.Errors[0].Message)
+	}
+	return fmt.Errorf("%s returned HTTP status %s", req.URL.Path, resp.Status)
+}
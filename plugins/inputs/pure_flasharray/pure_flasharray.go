@@ -0,0 +1,355 @@
+package pure_flasharray
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/choice"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+var availableCollectors = []string{"array", "volume", "replication"}
+
+// FlashArray gathers metrics of Pure Storage FlashArrays using the REST 2.x
+// API.
+type FlashArray struct {
+	URLs            []string          `toml:"urls"`
+	APIToken        string            `toml:"api_token"`
+	APIVersion      string            `toml:"api_version"`
+	Collect         []string          `toml:"collect"`
+	Limit           int               `toml:"limit"`
+	ResponseTimeout internal.Duration `toml:"response_timeout"`
+	tls.ClientConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	clients []*client
+}
+
+const sampleConfig = `
+  ## URLs of the arrays.
+  urls = ["https://flasharray1.example.com"]
+
+  ## API token of a user with at least the read-only role.
+  api_token = "${PURE_API_TOKEN}"
+
+  ## REST API version; requires Purity//FA 6.0 or later for version 2.4.
+  # api_version = "2.4"
+
+  ## Metrics to collect; by default all are collected.
+  ## Available collectors are "array", "volume" and "replication".
+  # collect = ["array", "volume", "replication"]
+
+  ## Number of items to request per page.
+  # limit = 1000
+
+  ## Amount of time allowed to complete a single request.
+  # response_timeout = "10s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+// SampleConfig returns the default configuration of the plugin.
+func (f *FlashArray) SampleConfig() string {
+	return sampleConfig
+}
+
+// Description returns a one-sentence description of the plugin.
+func (f *FlashArray) Description() string {
+	return "Read volume performance, capacity and replication metrics from Pure Storage FlashArrays"
+}
+
+// Init validates the configuration and creates the clients.
+func (f *FlashArray) Init() error {
+	if len(f.URLs) == 0 {
+		return fmt.Errorf("no urls configured")
+	}
+	if f.APIToken == "" {
+		return fmt.Errorf("api_token is required")
+	}
+	if f.APIVersion == "" {
+		f.APIVersion = "2.4"
+	}
+	if len(f.Collect) == 0 {
+		f.Collect = availableCollectors
+	}
+	if err := choice.CheckSlice(f.Collect, availableCollectors); err != nil {
+		return fmt.Errorf("invalid collect option: %v", err)
+	}
+	if f.Limit == 0 {
+		f.Limit = 1000
+	}
+	if f.ResponseTimeout.Duration == 0 {
+		f.ResponseTimeout.Duration = 10 * time.Second
+	}
+
+	tlsCfg, err := f.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: f.ResponseTimeout.Duration,
+	}
+
+	for _, u := range f.URLs {
+		baseURL, err := url.Parse(u)
+		if err != nil {
+			return fmt.Errorf("invalid url %q: %v", u, err)
+		}
+		f.clients = append(f.clients, &client{
+			baseURL:    baseURL,
+			apiVersion: f.APIVersion,
+			apiToken:   f.APIToken,
+			httpClient: httpClient,
+			limit:      f.Limit,
+		})
+	}
+	return nil
+}
+
+// Gather collects the metrics of all arrays.
+func (f *FlashArray) Gather(acc telegraf.Accumulator) error {
+	var wg sync.WaitGroup
+	for _, c := range f.clients {
+		wg.Add(1)
+		go func(c *client) {
+			defer wg.Done()
+			f.gatherArray(context.Background(), c, acc)
+		}(c)
+	}
+	wg.Wait()
+	return nil
+}
+
+type reference struct {
+	Name string `json:"name"`
+}
+
+type space struct {
+	TotalPhysical int64   `json:"total_physical"`
+	Unique        int64   `json:"unique"`
+	Snapshots     int64   `json:"snapshots"`
+	Virtual       int64   `json:"virtual"`
+	DataReduction float64 `json:"data_reduction"`
+}
+
+type array struct {
+	Name     string `json:"name"`
+	Capacity int64  `json:"capacity"`
+	Space    space  `json:"space"`
+}
+
+type volume struct {
+	Name        string    `json:"name"`
+	Provisioned int64     `json:"provisioned"`
+	Space       space     `json:"space"`
+	VolumeGroup reference `json:"volume_group"`
+	Pod         reference `json:"pod"`
+}
+
+type performance struct {
+	Name                  string  `json:"name"`
+	ReadsPerSec           float64 `json:"reads_per_sec"`
+	WritesPerSec          float64 `json:"writes_per_sec"`
+	ReadBytesPerSec       float64 `json:"read_bytes_per_sec"`
+	WriteBytesPerSec      float64 `json:"write_bytes_per_sec"`
+	UsecPerReadOp         float64 `json:"usec_per_read_op"`
+	UsecPerWriteOp        float64 `json:"usec_per_write_op"`
+	QueueUsecPerReadOp    float64 `json:"queue_usec_per_read_op"`
+	QueueUsecPerWriteOp   float64 `json:"queue_usec_per_write_op"`
+	ServiceUsecPerReadOp  float64 `json:"service_usec_per_read_op"`
+	ServiceUsecPerWriteOp float64 `json:"service_usec_per_write_op"`
+}
+
+type replicaLink struct {
+	LocalPod      reference   `json:"local_pod"`
+	RemotePod     reference   `json:"remote_pod"`
+	Remotes       []reference `json:"remotes"`
+	Direction     string      `json:"direction"`
+	Status        string      `json:"status"`
+	Lag           *int64      `json:"lag"`
+	RecoveryPoint *int64      `json:"recovery_point"`
+}
+
+func (f *FlashArray) gatherArray(ctx context.Context, c *client, acc telegraf.Accumulator) {
+	// The name of the array is needed to tag all metrics.
+	var name string
+	err := c.getAll(ctx, "arrays", nil, func(items json.RawMessage) error {
+		var arrays []array
+		if err := json.Unmarshal(items, &arrays); err != nil {
+			return err
+		}
+		for _, a := range arrays {
+			name = a.Name
+			if choice.Contains("array", f.Collect) {
+				gatherArraySpace(a, acc)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		acc.AddError(fmt.Errorf("%s: %v", c.baseURL.Host, err))
+		return
+	}
+	if name == "" {
+		name = c.baseURL.Hostname()
+	}
+
+	for _, collector := range f.Collect {
+		var err error
+		switch collector {
+		case "volume":
+			err = gatherVolumes(ctx, c, name, acc)
+		case "replication":
+			err = gatherReplication(ctx, c, name, acc)
+		}
+		if err != nil {
+			acc.AddError(fmt.Errorf("%s: collecting %s: %v", name, collector, err))
+		}
+	}
+}
+
+func gatherArraySpace(a array, acc telegraf.Accumulator) {
+	tags := map[string]string{"array": a.Name}
+	fields := map[string]interface{}{
+		"capacity_bytes":       a.Capacity,
+		"total_physical_bytes": a.Space.TotalPhysical,
+		"unique_bytes":         a.Space.Unique,
+		"snapshots_bytes":      a.Space.Snapshots,
+		"data_reduction":       a.Space.DataReduction,
+	}
+	acc.AddFields("pure_flasharray_array", fields, tags)
+}
+
+func gatherVolumes(ctx context.Context, c *client, arrayName string, acc telegraf.Accumulator) error {
+	type entry struct {
+		tags   map[string]string
+		fields map[string]interface{}
+	}
+	volumes := make(map[string]*entry)
+	var order []string
+
+	err := c.getAll(ctx, "volumes", url.Values{"destroyed": {"false"}}, func(items json.RawMessage) error {
+		var page []volume
+		if err := json.Unmarshal(items, &page); err != nil {
+			return err
+		}
+		for _, v := range page {
+			tags := map[string]string{
+				"array":  arrayName,
+				"volume": v.Name,
+			}
+			if v.VolumeGroup.Name != "" {
+				tags["volume_group"] = v.VolumeGroup.Name
+			}
+			if v.Pod.Name != "" {
+				tags["pod"] = v.Pod.Name
+			}
+			fields := map[string]interface{}{
+				"provisioned_bytes":    v.Provisioned,
+				"total_physical_bytes": v.Space.TotalPhysical,
+				"unique_bytes":         v.Space.Unique,
+				"snapshots_bytes":      v.Space.Snapshots,
+				"virtual_bytes":        v.Space.Virtual,
+				"data_reduction":       v.Space.DataReduction,
+			}
+			volumes[v.Name] = &entry{tags: tags, fields: fields}
+			order = append(order, v.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = c.getAll(ctx, "volumes/performance", url.Values{"destroyed": {"false"}}, func(items json.RawMessage) error {
+		var page []performance
+		if err := json.Unmarshal(items, &page); err != nil {
+			return err
+		}
+		for _, p := range page {
+			v, ok := volumes[p.Name]
+			if !ok {
+				continue
+			}
+			v.fields["reads_per_sec"] = p.ReadsPerSec
+			v.fields["writes_per_sec"] = p.WritesPerSec
+			v.fields["read_bytes_per_sec"] = p.ReadBytesPerSec
+			v.fields["write_bytes_per_sec"] = p.WriteBytesPerSec
+			v.fields["usec_per_read_op"] = p.UsecPerReadOp
+			v.fields["usec_per_write_op"] = p.UsecPerWriteOp
+			v.fields["queue_usec_per_read_op"] = p.QueueUsecPerReadOp
+			v.fields["queue_usec_per_write_op"] = p.QueueUsecPerWriteOp
+			v.fields["service_usec_per_read_op"] = p.ServiceUsecPerReadOp
+			v.fields["service_usec_per_write_op"] = p.ServiceUsecPerWriteOp
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, name := range order {
+		v := volumes[name]
+		acc.AddFields("pure_flasharray_volume", v.fields, v.tags, now)
+	}
+	return nil
+}
+
+func gatherReplication(ctx context.Context, c *client, arrayName string, acc telegraf.Accumulator) error {
+	return c.getAll(ctx, "pod-replica-links", nil, func(items json.RawMessage) error {
+		var links []replicaLink
+		if err := json.Unmarshal(items, &links); err != nil {
+			return err
+		}
+		for _, l := range links {
+			tags := map[string]string{
+				"array":      arrayName,
+				"local_pod":  l.LocalPod.Name,
+				"remote_pod": l.RemotePod.Name,
+			}
+			if len(l.Remotes) > 0 {
+				tags["remote"] = l.Remotes[0].Name
+			}
+			if l.Direction != "" {
+				tags["direction"] = l.Direction
+			}
+			fields := map[string]interface{}{
+				"status":  l.Status,
+				"healthy": l.Status == "replicating" || l.Status == "baselining",
+			}
+			if l.Lag != nil {
+				fields["lag_ms"] = *l.Lag
+			}
+			if l.RecoveryPoint != nil {
+				fields["recovery_point"] = *l.RecoveryPoint
+			}
+			acc.AddFields("pure_flasharray_replication", fields, tags)
+		}
+		return nil
+	})
+}
+
+func init() {
+	inputs.Add("pure_flasharray", func() telegraf.Input {
+		return &FlashArray{}
+	})
+}
@@ -0,0 +1,277 @@
+package pure_flasharray
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var responses = map[string]string{
+	"/api/2.4/arrays": `{
+		"items": [{
+			"name": "fa1",
+			"capacity": 10000,
+			"space": {"total_physical": 4000, "unique": 3000, "snapshots": 500, "data_reduction": 3.5}
+		}]
+	}`,
+	"/api/2.4/volumes": `{
+		"continuation_token": "page2",
+		"items": [{
+			"name": "vg1/vol1",
+			"provisioned": 1000,
+			"volume_group": {"name": "vg1"},
+			"space": {"total_physical": 100, "unique": 80, "snapshots": 20, "virtual": 400, "data_reduction": 4.0}
+		}]
+	}`,
+	"/api/2.4/volumes?page2": `{
+		"items": [{
+			"name": "pod1::vol2",
+			"provisioned": 2000,
+			"pod": {"name": "pod1"},
+			"space": {"total_physical": 200, "unique": 200, "snapshots": 0, "virtual": 600, "data_reduction": 3.0}
+		}]
+	}`,
+	"/api/2.4/volumes/performance": `{
+		"items": [{
+			"name": "vg1/vol1",
+			"reads_per_sec": 10,
+			"writes_per_sec": 20,
+			"read_bytes_per_sec": 4096,
+			"write_bytes_per_sec": 8192,
+			"usec_per_read_op": 150,
+			"usec_per_write_op": 250,
+			"queue_usec_per_read_op": 5,
+			"queue_usec_per_write_op": 7,
+			"service_usec_per_read_op": 100,
+			"service_usec_per_write_op": 200
+		}]
+	}`,
+	"/api/2.4/pod-replica-links": `{
+		"items": [{
+			"local_pod": {"name": "pod1"},
+			"remote_pod": {"name": "pod1-dr"},
+			"remotes": [{"name": "fa2"}],
+			"direction": "outbound",
+			"status": "replicating",
+			"lag": 1500,
+			"recovery_point": 1600000000000
+		}, {
+			"local_pod": {"name": "pod2"},
+			"remote_pod": {"name": "pod2-dr"},
+			"remotes": [{"name": "fa2"}],
+			"direction": "outbound",
+			"status": "unhealthy"
+		}]
+	}`,
+}
+
+type server struct {
+	t      *testing.T
+	mu     sync.Mutex
+	logins int
+	token  string
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/api/2.4/login" {
+		require.Equal(s.t, "POST", r.Method)
+		if r.Header.Get("api-token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors": [{"message": "invalid api token"}]}`))
+			return
+		}
+		s.logins++
+		s.token = fmt.Sprintf("session%d", s.logins)
+		w.Header().Set("x-auth-token", s.token)
+		return
+	}
+
+	if s.token == "" || r.Header.Get("x-auth-token") != s.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	require.Equal(s.t, "1000", r.URL.Query().Get("limit"))
+
+	key := r.URL.Path
+	if token := r.URL.Query().Get("continuation_token"); token != "" {
+		key += "?" + token
+	}
+	response, ok := responses[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(response))
+}
+
+func TestGather(t *testing.T) {
+	ts := httptest.NewServer(&server{t: t})
+	defer ts.Close()
+
+	plugin := &FlashArray{
+		URLs:     []string{ts.URL},
+		APIToken: "secret",
+		Log:      testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("pure_flasharray_array",
+			map[string]string{
+				"array": "fa1",
+			},
+			map[string]interface{}{
+				"capacity_bytes":       int64(10000),
+				"total_physical_bytes": int64(4000),
+				"unique_bytes":         int64(3000),
+				"snapshots_bytes":      int64(500),
+				"data_reduction":       3.5,
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("pure_flasharray_volume",
+			map[string]string{
+				"array":        "fa1",
+				"volume":       "vg1/vol1",
+				"volume_group": "vg1",
+			},
+			map[string]interface{}{
+				"provisioned_bytes":         int64(1000),
+				"total_physical_bytes":      int64(100),
+				"unique_bytes":              int64(80),
+				"snapshots_bytes":           int64(20),
+				"virtual_bytes":             int64(400),
+				"data_reduction":            4.0,
+				"reads_per_sec":             10.0,
+				"writes_per_sec":            20.0,
+				"read_bytes_per_sec":        4096.0,
+				"write_bytes_per_sec":       8192.0,
+				"usec_per_read_op":          150.0,
+				"usec_per_write_op":         250.0,
+				"queue_usec_per_read_op":    5.0,
+				"queue_usec_per_write_op":   7.0,
+				"service_usec_per_read_op":  100.0,
+				"service_usec_per_write_op": 200.0,
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("pure_flasharray_volume",
+			map[string]string{
+				"array":  "fa1",
+				"volume": "pod1::vol2",
+				"pod":    "pod1",
+			},
+			map[string]interface{}{
+				"provisioned_bytes":    int64(2000),
+				"total_physical_bytes": int64(200),
+				"unique_bytes":         int64(200),
+				"snapshots_bytes":      int64(0),
+				"virtual_bytes":        int64(600),
+				"data_reduction":       3.0,
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("pure_flasharray_replication",
+			map[string]string{
+				"array":      "fa1",
+				"local_pod":  "pod1",
+				"remote_pod": "pod1-dr",
+				"remote":     "fa2",
+				"direction":  "outbound",
+			},
+			map[string]interface{}{
+				"status":         "replicating",
+				"healthy":        true,
+				"lag_ms":         int64(1500),
+				"recovery_point": int64(1600000000000),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("pure_flasharray_replication",
+			map[string]string{
+				"array":      "fa1",
+				"local_pod":  "pod2",
+				"remote_pod": "pod2-dr",
+				"remote":     "fa2",
+				"direction":  "outbound",
+			},
+			map[string]interface{}{
+				"status":  "unhealthy",
+				"healthy": false,
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestGatherSessionExpired(t *testing.T) {
+	s := &server{t: t}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	plugin := &FlashArray{
+		URLs:     []string{ts.URL},
+		APIToken: "secret",
+		Collect:  []string{"array"},
+		Log:      testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	// Invalidate the session on the server.
+	s.mu.Lock()
+	s.token = "expired"
+	s.mu.Unlock()
+
+	acc.ClearMetrics()
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, 2, s.logins)
+}
+
+func TestGatherInvalidToken(t *testing.T) {
+	ts := httptest.NewServer(&server{t: t})
+	defer ts.Close()
+
+	plugin := &FlashArray{
+		URLs:     []string{ts.URL},
+		APIToken: "wrong",
+		Log:      testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "invalid api token")
+	require.Empty(t, acc.Metrics)
+}
+
+func TestInitInvalidCollector(t *testing.T) {
+	plugin := &FlashArray{
+		URLs:     []string{"https://localhost"},
+		APIToken: "secret",
+		Collect:  []string{"host"},
+	}
+	require.Error(t, plugin.Init())
+}
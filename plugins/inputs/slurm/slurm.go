@@ -0,0 +1,454 @@
+package slurm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/choice"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+var availableCollectors = []string{"diag", "jobs", "nodes", "partitions"}
+
+// Slurm gathers scheduler, job, partition and node metrics of a Slurm
+// cluster from the slurmrestd REST API.
+type Slurm struct {
+	URL             string            `toml:"url"`
+	APIVersion      string            `toml:"api_version"`
+	Username        string            `toml:"username"`
+	Token           string            `toml:"token"`
+	Collect         []string          `toml:"collect"`
+	ResponseTimeout internal.Duration `toml:"response_timeout"`
+	tls.ClientConfig
+
+	baseURL *url.URL
+	client  *http.Client
+
+	// now returns the current time, replaced in tests.
+	now func() time.Time
+}
+
+const sampleConfig = `
+  ## URL of the slurmrestd service.
+  url = "http://localhost:6820"
+
+  ## Version of the REST API, "v0.0.36" and "v0.0.37" are supported.
+  # api_version = "v0.0.36"
+
+  ## User name and JSON Web Token, generated with "scontrol token", used when
+  ## slurmrestd authenticates with auth/jwt.
+  # username = "telegraf"
+  # token = ""
+
+  ## Metrics to collect; by default all are collected.
+  ## Available collectors are "diag", "jobs", "nodes" and "partitions".
+  # collect = ["diag", "jobs", "nodes", "partitions"]
+
+  ## Amount of time allowed to complete a single request.
+  # response_timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+// SampleConfig returns the default configuration of the plugin.
+func (s *Slurm) SampleConfig() string {
+	return sampleConfig
+}
+
+// Description returns a one-sentence description of the plugin.
+func (s *Slurm) Description() string {
+	return "Read scheduler, job, partition and node metrics from the Slurm REST API"
+}
+
+// Init validates the configuration and creates the HTTP client.
+func (s *Slurm) Init() error {
+	if s.URL == "" {
+		return fmt.Errorf("no url configured")
+	}
+	var err error
+	if s.baseURL, err = url.Parse(s.URL); err != nil {
+		return fmt.Errorf("invalid url %q: %v", s.URL, err)
+	}
+
+	switch s.APIVersion {
+	case "":
+		s.APIVersion = "v0.0.36"
+	case "v0.0.36", "v0.0.37":
+	default:
+		return fmt.Errorf("unsupported api_version %q", s.APIVersion)
+	}
+
+	if len(s.Collect) == 0 {
+		s.Collect = availableCollectors
+	}
+	if err := choice.CheckSlice(s.Collect, availableCollectors); err != nil {
+		return fmt.Errorf("invalid collect option: %v", err)
+	}
+
+	if s.ResponseTimeout.Duration == 0 {
+		s.ResponseTimeout.Duration = 5 * time.Second
+	}
+
+	tlsCfg, err := s.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	s.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: s.ResponseTimeout.Duration,
+	}
+	if s.now == nil {
+		s.now = time.Now
+	}
+	return nil
+}
+
+// Gather collects the metrics of the cluster.  Partition metrics are
+// computed from the jobs and nodes.
+func (s *Slurm) Gather(acc telegraf.Accumulator) error {
+	ctx := context.Background()
+	server := s.baseURL.Host
+
+	if choice.Contains("diag", s.Collect) {
+		if err := s.gatherDiag(ctx, server, acc); err != nil {
+			acc.AddError(fmt.Errorf("%s: collecting diag: %v", server, err))
+		}
+	}
+
+	var jobs []job
+	if choice.Contains("jobs", s.Collect) || choice.Contains("partitions", s.Collect) {
+		var resp struct {
+			Jobs []job `json:"jobs"`
+		}
+		if err := s.get(ctx, "jobs", &resp); err != nil {
+			return fmt.Errorf("%s: collecting jobs: %v", server, err)
+		}
+		jobs = resp.Jobs
+	}
+
+	var nodes []node
+	if choice.Contains("nodes", s.Collect) || choice.Contains("partitions", s.Collect) {
+		var resp struct {
+			Nodes []node `json:"nodes"`
+		}
+		if err := s.get(ctx, "nodes", &resp); err != nil {
+			return fmt.Errorf("%s: collecting nodes: %v", server, err)
+		}
+		nodes = resp.Nodes
+	}
+
+	if choice.Contains("jobs", s.Collect) {
+		counts := make(map[string]int64)
+		for _, j := range jobs {
+			counts[j.State.String()]++
+		}
+		for state, count := range counts {
+			tags := map[string]string{"server": server, "state": state}
+			acc.AddFields("slurm_jobs", map[string]interface{}{"count": count}, tags)
+		}
+	}
+
+	if choice.Contains("nodes", s.Collect) {
+		counts := make(map[string]int64)
+		for _, n := range nodes {
+			counts[n.state()]++
+		}
+		for state, count := range counts {
+			tags := map[string]string{"server": server, "state": state}
+			acc.AddFields("slurm_nodes", map[string]interface{}{"count": count}, tags)
+		}
+	}
+
+	if choice.Contains("partitions", s.Collect) {
+		var resp struct {
+			Partitions []partition `json:"partitions"`
+		}
+		if err := s.get(ctx, "partitions", &resp); err != nil {
+			return fmt.Errorf("%s: collecting partitions: %v", server, err)
+		}
+		s.gatherPartitions(server, resp.Partitions, jobs, nodes, acc)
+	}
+	return nil
+}
+
+// gatherDiag adds the numeric scheduler statistics reported by sdiag.
+func (s *Slurm) gatherDiag(ctx context.Context, server string, acc telegraf.Accumulator) error {
+	var resp struct {
+		Statistics map[string]json.RawMessage `json:"statistics"`
+	}
+	if err := s.get(ctx, "diag", &resp); err != nil {
+		return err
+	}
+
+	fields := make(map[string]interface{})
+	for name, raw := range resp.Statistics {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			continue
+		}
+		// Nested statistics, such as the RPCs by user, are skipped
+		number, ok := value.(json.Number)
+		if !ok {
+			continue
+		}
+		if v, err := number.Int64(); err == nil {
+			fields[name] = v
+		} else if v, err := number.Float64(); err == nil {
+			fields[name] = v
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	acc.AddFields("slurm_diag", fields, map[string]string{"server": server})
+	return nil
+}
+
+// partitionStats accumulates the metrics of a partition.
+type partitionStats struct {
+	jobsPending   int64
+	jobsRunning   int64
+	waitCount     int64
+	waitTotal     float64
+	waitMax       float64
+	cpusAllocated int64
+	cpusTotal     int64
+	nodeStates    map[string]int64
+}
+
+func (s *Slurm) gatherPartitions(server string, partitions []partition, jobs []job, nodes []node, acc telegraf.Accumulator) {
+	stats := make(map[string]*partitionStats, len(partitions))
+	for _, p := range partitions {
+		stats[p.Name] = &partitionStats{nodeStates: make(map[string]int64)}
+	}
+
+	now := s.now()
+	for _, j := range jobs {
+		// Pending jobs may be submitted to several partitions
+		for _, name := range strings.Split(j.Partition, ",") {
+			st, ok := stats[name]
+			if !ok {
+				continue
+			}
+			switch j.State.String() {
+			case "pending":
+				st.jobsPending++
+				if j.SubmitTime > 0 {
+					wait := now.Sub(time.Unix(j.SubmitTime, 0)).Seconds()
+					if wait < 0 {
+						wait = 0
+					}
+					st.waitCount++
+					st.waitTotal += wait
+					if wait > st.waitMax {
+						st.waitMax = wait
+					}
+				}
+			case "running":
+				st.jobsRunning++
+			}
+		}
+	}
+
+	for _, n := range nodes {
+		for _, name := range n.Partitions {
+			st, ok := stats[name]
+			if !ok {
+				continue
+			}
+			st.cpusAllocated += n.AllocCPUs
+			st.cpusTotal += n.CPUs
+			st.nodeStates[n.state()]++
+		}
+	}
+
+	for _, p := range partitions {
+		st := stats[p.Name]
+		cpusTotal := p.TotalCPUs
+		if cpusTotal == 0 {
+			cpusTotal = st.cpusTotal
+		}
+
+		fields := map[string]interface{}{
+			"jobs_pending":   st.jobsPending,
+			"jobs_running":   st.jobsRunning,
+			"nodes_total":    p.TotalNodes,
+			"cpus_total":     cpusTotal,
+			"cpus_allocated": st.cpusAllocated,
+		}
+		if cpusTotal > 0 {
+			fields["cpu_utilization"] = 100 * float64(st.cpusAllocated) / float64(cpusTotal)
+		}
+		if st.waitCount > 0 {
+			fields["pending_wait_time_max"] = st.waitMax
+			fields["pending_wait_time_mean"] = st.waitTotal / float64(st.waitCount)
+		}
+		for state, count := range st.nodeStates {
+			fields["nodes_"+state] = count
+		}
+
+		tags := map[string]string{
+			"server":    server,
+			"partition": p.Name,
+		}
+		if state := p.State.String(); state != "" {
+			tags["state"] = state
+		}
+		acc.AddFields("slurm_partition", fields, tags)
+	}
+}
+
+// stateList is a state reported either as a string or, with flags, as a
+// list of strings.  The first element is the base state.
+type stateList []string
+
+func (s *stateList) UnmarshalJSON(data []byte) error {
+	var state string
+	if err := json.Unmarshal(data, &state); err == nil {
+		*s = stateList{state}
+		return nil
+	}
+	var states []string
+	if err := json.Unmarshal(data, &states); err != nil {
+		return err
+	}
+	*s = states
+	return nil
+}
+
+// String returns the base state in lower case.
+func (s stateList) String() string {
+	if len(s) == 0 {
+		return ""
+	}
+	return strings.ToLower(s[0])
+}
+
+// has returns true if the state includes the flag.
+func (s stateList) has(flag string) bool {
+	for _, state := range s {
+		if strings.EqualFold(state, flag) {
+			return true
+		}
+	}
+	return false
+}
+
+type job struct {
+	State      stateList `json:"job_state"`
+	Partition  string    `json:"partition"`
+	SubmitTime int64     `json:"submit_time"`
+}
+
+type node struct {
+	State      stateList `json:"state"`
+	StateFlags []string  `json:"state_flags"`
+	CPUs       int64     `json:"cpus"`
+	AllocCPUs  int64     `json:"alloc_cpus"`
+	Partitions []string  `json:"partitions"`
+}
+
+// state returns the state of the node like sinfo, draining nodes still
+// running jobs are "draining" and idle ones "drained".
+func (n *node) state() string {
+	state := n.State.String()
+	drain := n.State.has("DRAIN")
+	for _, flag := range n.StateFlags {
+		if strings.EqualFold(flag, "DRAIN") {
+			drain = true
+		}
+	}
+	if !drain {
+		return state
+	}
+	switch state {
+	case "allocated", "mixed", "completing":
+		return "draining"
+	case "down":
+		return state
+	default:
+		return "drained"
+	}
+}
+
+type partition struct {
+	Name       string    `json:"name"`
+	State      stateList `json:"state"`
+	TotalCPUs  int64     `json:"total_cpus"`
+	TotalNodes int64     `json:"total_nodes"`
+}
+
+// apiErrors are the errors returned by slurmrestd.
+type apiErrors struct {
+	Errors []struct {
+		Error string `json:"error"`
+		Errno int    `json:"errno"`
+	} `json:"errors"`
+}
+
+// get requests the endpoint of the API and decodes the response into v.
+func (s *Slurm) get(ctx context.Context, endpoint string, v interface{}) error {
+	u, err := s.baseURL.Parse(path.Join("/slurm", s.APIVersion, endpoint))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.Username != "" {
+		req.Header.Set("X-SLURM-USER-NAME", s.Username)
+	}
+	if s.Token != "" {
+		req.Header.Set("X-SLURM-USER-TOKEN", s.Token)
+	}
+
+	resp, err := s.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var errs apiErrors
+	if json.Unmarshal(body, &errs) == nil && len(errs.Errors) > 0 && errs.Errors[0].Error != "" {
+		return fmt.Errorf("%s returned HTTP status %s: %s", u.Path, resp.Status, errs.Errors[0].Error)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP status %s", u.Path, resp.Status)
+	}
+	return json.Unmarshal(body, v)
+}
+
+func init() {
+	inputs.Add("slurm", func() telegraf.Input {
+		return &Slurm{}
+	})
+}
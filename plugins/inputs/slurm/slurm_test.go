@@ -0,0 +1,229 @@
+package slurm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var responses = map[string]string{
+	"/slurm/v0.0.36/diag": `{
+		"meta": {"plugin": {"type": "openapi/v0.0.36"}},
+		"errors": [],
+		"statistics": {
+			"parts_packed": 1,
+			"server_thread_count": 3,
+			"agent_queue_size": 0,
+			"jobs_submitted": 120,
+			"jobs_started": 100,
+			"jobs_completed": 90,
+			"jobs_canceled": 2,
+			"jobs_failed": 1,
+			"schedule_cycle_last": 1500,
+			"schedule_cycle_mean": 1200,
+			"schedule_queue_length": 4,
+			"bf_active": false,
+			"rpcs_by_message_type": [{"message_type": "REQUEST_PING", "count": 10}]
+		}
+	}`,
+	"/slurm/v0.0.36/jobs": `{
+		"errors": [],
+		"jobs": [
+			{"job_id": 1, "job_state": "RUNNING", "partition": "batch", "submit_time": 1600000000, "start_time": 1600000010},
+			{"job_id": 2, "job_state": "PENDING", "partition": "batch", "submit_time": 1600000900},
+			{"job_id": 3, "job_state": "PENDING", "partition": "batch,debug", "submit_time": 1600000500},
+			{"job_id": 4, "job_state": "COMPLETED", "partition": "debug", "submit_time": 1600000000}
+		]
+	}`,
+	"/slurm/v0.0.36/nodes": `{
+		"errors": [],
+		"nodes": [
+			{"name": "n1", "state": "allocated", "cpus": 32, "alloc_cpus": 32, "partitions": ["batch"]},
+			{"name": "n2", "state": "mixed", "cpus": 32, "alloc_cpus": 8, "partitions": ["batch"]},
+			{"name": "n3", "state": "idle", "state_flags": ["DRAIN"], "cpus": 16, "alloc_cpus": 0, "partitions": ["batch", "debug"]},
+			{"name": "n4", "state": "idle", "cpus": 16, "alloc_cpus": 0, "partitions": ["debug"]}
+		]
+	}`,
+	"/slurm/v0.0.36/partitions": `{
+		"errors": [],
+		"partitions": [
+			{"name": "batch", "state": "UP", "total_cpus": 80, "total_nodes": 3},
+			{"name": "debug", "state": "UP", "total_cpus": 32, "total_nodes": 2}
+		]
+	}`,
+}
+
+func newServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-SLURM-USER-NAME") != "telegraf" || r.Header.Get("X-SLURM-USER-TOKEN") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors": [{"error": "Authentication failure", "errno": 1}]}`))
+			return
+		}
+
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+}
+
+func TestGather(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	plugin := &Slurm{
+		URL:      ts.URL,
+		Username: "telegraf",
+		Token:    "secret",
+		now:      func() time.Time { return time.Unix(1600001000, 0) },
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	server := u.Host
+	expected := []telegraf.Metric{
+		testutil.MustMetric("slurm_diag",
+			map[string]string{"server": server},
+			map[string]interface{}{
+				"parts_packed":          int64(1),
+				"server_thread_count":   int64(3),
+				"agent_queue_size":      int64(0),
+				"jobs_submitted":        int64(120),
+				"jobs_started":          int64(100),
+				"jobs_completed":        int64(90),
+				"jobs_canceled":         int64(2),
+				"jobs_failed":           int64(1),
+				"schedule_cycle_last":   int64(1500),
+				"schedule_cycle_mean":   int64(1200),
+				"schedule_queue_length": int64(4),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("slurm_jobs",
+			map[string]string{"server": server, "state": "running"},
+			map[string]interface{}{"count": int64(1)},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("slurm_jobs",
+			map[string]string{"server": server, "state": "pending"},
+			map[string]interface{}{"count": int64(2)},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("slurm_jobs",
+			map[string]string{"server": server, "state": "completed"},
+			map[string]interface{}{"count": int64(1)},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("slurm_nodes",
+			map[string]string{"server": server, "state": "allocated"},
+			map[string]interface{}{"count": int64(1)},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("slurm_nodes",
+			map[string]string{"server": server, "state": "mixed"},
+			map[string]interface{}{"count": int64(1)},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("slurm_nodes",
+			map[string]string{"server": server, "state": "drained"},
+			map[string]interface{}{"count": int64(1)},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("slurm_nodes",
+			map[string]string{"server": server, "state": "idle"},
+			map[string]interface{}{"count": int64(1)},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("slurm_partition",
+			map[string]string{"server": server, "partition": "batch", "state": "up"},
+			map[string]interface{}{
+				"jobs_pending":           int64(2),
+				"jobs_running":           int64(1),
+				"nodes_total":            int64(3),
+				"cpus_total":             int64(80),
+				"cpus_allocated":         int64(40),
+				"cpu_utilization":        50.0,
+				"pending_wait_time_max":  500.0,
+				"pending_wait_time_mean": 300.0,
+				"nodes_allocated":        int64(1),
+				"nodes_mixed":            int64(1),
+				"nodes_drained":          int64(1),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("slurm_partition",
+			map[string]string{"server": server, "partition": "debug", "state": "up"},
+			map[string]interface{}{
+				"jobs_pending":           int64(1),
+				"jobs_running":           int64(0),
+				"nodes_total":            int64(2),
+				"cpus_total":             int64(32),
+				"cpus_allocated":         int64(0),
+				"cpu_utilization":        0.0,
+				"pending_wait_time_max":  500.0,
+				"pending_wait_time_mean": 500.0,
+				"nodes_drained":          int64(1),
+				"nodes_idle":             int64(1),
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestGatherUnauthorized(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+
+	plugin := &Slurm{
+		URL:      ts.URL,
+		Username: "telegraf",
+		Token:    "wrong",
+		Collect:  []string{"jobs"},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	err := plugin.Gather(&acc)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Authentication failure")
+	require.Empty(t, acc.Metrics)
+}
+
+func TestNodeState(t *testing.T) {
+	tests := []struct {
+		node     node
+		expected string
+	}{
+		{node: node{State: stateList{"IDLE"}}, expected: "idle"},
+		{node: node{State: stateList{"MIXED"}, StateFlags: []string{"DRAIN"}}, expected: "draining"},
+		{node: node{State: stateList{"IDLE", "DRAIN"}}, expected: "drained"},
+		{node: node{State: stateList{"DOWN", "DRAIN"}}, expected: "down"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			require.Equal(t, tt.expected, tt.node.state())
+		})
+	}
+}
+
+func TestInit(t *testing.T) {
+	require.Error(t, (&Slurm{}).Init())
+	require.Error(t, (&Slurm{URL: "http://localhost:6820", APIVersion: "v0.0.40"}).Init())
+	require.Error(t, (&Slurm{URL: "http://localhost:6820", Collect: []string{"qos"}}).Init())
+}
@@ -0,0 +1,121 @@
+package snmp
+
+import (
+	"fmt"
+	"sort"
+)
+
+// profiles are the built-in bundles of tables which can be selected with the
+// profiles and agent_profiles options.  Numeric OIDs are used so that the
+// profiles work without the MIB files being installed.
+var profiles = map[string][]Table{
+	// IF-MIB ifTable and ifXTable, using the 64-bit high capacity counters.
+	"if_mib": {
+		{
+			Name: "interface",
+			Fields: []Field{
+				{Name: "ifName", Oid: ".1.3.6.1.2.1.31.1.1.1.1", IsTag: true},
+				{Name: "ifDescr", Oid: ".1.3.6.1.2.1.2.2.1.2", IsTag: true},
+				{Name: "ifAlias", Oid: ".1.3.6.1.2.1.31.1.1.1.18"},
+				{Name: "ifType", Oid: ".1.3.6.1.2.1.2.2.1.3"},
+				{Name: "ifMtu", Oid: ".1.3.6.1.2.1.2.2.1.4"},
+				{Name: "ifPhysAddress", Oid: ".1.3.6.1.2.1.2.2.1.6", Conversion: "hwaddr"},
+				{Name: "ifHighSpeed", Oid: ".1.3.6.1.2.1.31.1.1.1.15"},
+				{Name: "ifAdminStatus", Oid: ".1.3.6.1.2.1.2.2.1.7"},
+				{Name: "ifOperStatus", Oid: ".1.3.6.1.2.1.2.2.1.8"},
+				{Name: "ifLastChange", Oid: ".1.3.6.1.2.1.2.2.1.9"},
+				{Name: "ifHCInOctets", Oid: ".1.3.6.1.2.1.31.1.1.1.6"},
+				{Name: "ifHCInUcastPkts", Oid: ".1.3.6.1.2.1.31.1.1.1.7"},
+				{Name: "ifHCInMulticastPkts", Oid: ".1.3.6.1.2.1.31.1.1.1.8"},
+				{Name: "ifHCInBroadcastPkts", Oid: ".1.3.6.1.2.1.31.1.1.1.9"},
+				{Name: "ifInDiscards", Oid: ".1.3.6.1.2.1.2.2.1.13"},
+				{Name: "ifInErrors", Oid: ".1.3.6.1.2.1.2.2.1.14"},
+				{Name: "ifInUnknownProtos", Oid: ".1.3.6.1.2.1.2.2.1.15"},
+				{Name: "ifHCOutOctets", Oid: ".1.3.6.1.2.1.31.1.1.1.10"},
+				{Name: "ifHCOutUcastPkts", Oid: ".1.3.6.1.2.1.31.1.1.1.11"},
+				{Name: "ifHCOutMulticastPkts", Oid: ".1.3.6.1.2.1.31.1.1.1.12"},
+				{Name: "ifHCOutBroadcastPkts", Oid: ".1.3.6.1.2.1.31.1.1.1.13"},
+				{Name: "ifOutDiscards", Oid: ".1.3.6.1.2.1.2.2.1.19"},
+				{Name: "ifOutErrors", Oid: ".1.3.6.1.2.1.2.2.1.20"},
+			},
+		},
+	},
+	// EtherLike-MIB dot3StatsTable.  The table is indexed by ifIndex, so the
+	// ifName is looked up from the ifXTable.
+	"etherlike_mib": {
+		{
+			Name: "ethernet",
+			Fields: []Field{
+				{Name: "ifName", Oid: ".1.3.6.1.2.1.31.1.1.1.1", IsTag: true},
+				{Name: "dot3StatsAlignmentErrors", Oid: ".1.3.6.1.2.1.10.7.2.1.2"},
+				{Name: "dot3StatsFCSErrors", Oid: ".1.3.6.1.2.1.10.7.2.1.3"},
+				{Name: "dot3StatsSingleCollisionFrames", Oid: ".1.3.6.1.2.1.10.7.2.1.4"},
+				{Name: "dot3StatsMultipleCollisionFrames", Oid: ".1.3.6.1.2.1.10.7.2.1.5"},
+				{Name: "dot3StatsSQETestErrors", Oid: ".1.3.6.1.2.1.10.7.2.1.6"},
+				{Name: "dot3StatsDeferredTransmissions", Oid: ".1.3.6.1.2.1.10.7.2.1.7"},
+				{Name: "dot3StatsLateCollisions", Oid: ".1.3.6.1.2.1.10.7.2.1.8"},
+				{Name: "dot3StatsExcessiveCollisions", Oid: ".1.3.6.1.2.1.10.7.2.1.9"},
+				{Name: "dot3StatsInternalMacTransmitErrors", Oid: ".1.3.6.1.2.1.10.7.2.1.10"},
+				{Name: "dot3StatsCarrierSenseErrors", Oid: ".1.3.6.1.2.1.10.7.2.1.11"},
+				{Name: "dot3StatsFrameTooLongs", Oid: ".1.3.6.1.2.1.10.7.2.1.13"},
+				{Name: "dot3StatsInternalMacReceiveErrors", Oid: ".1.3.6.1.2.1.10.7.2.1.16"},
+				{Name: "dot3StatsSymbolErrors", Oid: ".1.3.6.1.2.1.10.7.2.1.18"},
+				{Name: "dot3StatsDuplexStatus", Oid: ".1.3.6.1.2.1.10.7.2.1.19"},
+			},
+		},
+	},
+	// CISCO-PROCESS-MIB cpmCPUTotalTable.
+	"cisco_cpu": {
+		{
+			Name:       "cisco_cpu",
+			IndexAsTag: true,
+			Fields: []Field{
+				{Name: "cpmCPUTotalPhysicalIndex", Oid: ".1.3.6.1.4.1.9.9.109.1.1.1.1.2", IsTag: true},
+				{Name: "cpmCPUTotal5secRev", Oid: ".1.3.6.1.4.1.9.9.109.1.1.1.1.6"},
+				{Name: "cpmCPUTotal1minRev", Oid: ".1.3.6.1.4.1.9.9.109.1.1.1.1.7"},
+				{Name: "cpmCPUTotal5minRev", Oid: ".1.3.6.1.4.1.9.9.109.1.1.1.1.8"},
+				{Name: "cpmCPUMemoryUsed", Oid: ".1.3.6.1.4.1.9.9.109.1.1.1.1.12"},
+				{Name: "cpmCPUMemoryFree", Oid: ".1.3.6.1.4.1.9.9.109.1.1.1.1.13"},
+			},
+		},
+	},
+	// CISCO-MEMORY-POOL-MIB ciscoMemoryPoolTable.
+	"cisco_memory": {
+		{
+			Name: "cisco_memory_pool",
+			Fields: []Field{
+				{Name: "ciscoMemoryPoolName", Oid: ".1.3.6.1.4.1.9.9.48.1.1.1.2", IsTag: true},
+				{Name: "ciscoMemoryPoolValid", Oid: ".1.3.6.1.4.1.9.9.48.1.1.1.4"},
+				{Name: "ciscoMemoryPoolUsed", Oid: ".1.3.6.1.4.1.9.9.48.1.1.1.5"},
+				{Name: "ciscoMemoryPoolFree", Oid: ".1.3.6.1.4.1.9.9.48.1.1.1.6"},
+				{Name: "ciscoMemoryPoolLargestFree", Oid: ".1.3.6.1.4.1.9.9.48.1.1.1.7"},
+			},
+		},
+	},
+}
+
+// profileNames returns the sorted names of the built-in profiles.
+func profileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// profileTables returns a copy of the tables of the named profile, so that
+// initializing them does not modify the built-in definition.
+func profileTables(name string) ([]Table, error) {
+	tables, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q; available profiles are %v", name, profileNames())
+	}
+
+	result := make([]Table, 0, len(tables))
+	for _, t := range tables {
+		t.Fields = append([]Field(nil), t.Fields...)
+		result = append(result, t)
+	}
+	return result, nil
+}
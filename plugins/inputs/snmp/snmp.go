@@ -60,6 +60,15 @@ const sampleConfig = `
   ## Privacy password used for encrypted messages.
   # priv_password = ""
 
+  ## Built-in profiles to collect from all agents; available profiles are
+  ## "if_mib", "etherlike_mib", "cisco_cpu" and "cisco_memory".
+  # profiles = []
+
+  ## Additional profiles to collect from individual agents.  Agents must be
+  ## written exactly as in the agents list.
+  # [inputs.snmp.agent_profiles]
+  #   "udp://127.0.0.1:161" = ["cisco_cpu", "cisco_memory"]
+
   ## Add fields and tables defining the variables you wish to collect.  This
   ## example collects the system uptime and interface variables.  Reference the
   ## full plugin documentation for configuration details.
@@ -132,7 +141,15 @@ type Snmp struct {
 	Name   string  // deprecated in 1.14; use name_override
 	Fields []Field `toml:"field"`
 
+	// Profiles are the names of built-in table bundles collected from all
+	// agents.
+	Profiles []string `toml:"profiles"`
+	// AgentProfiles are the names of built-in table bundles collected in
+	// addition to Profiles, keyed by agent.
+	AgentProfiles map[string][]string `toml:"agent_profiles"`
+
 	connectionCache []snmpConnection
+	profileTables   map[string][]Table
 	initialized     bool
 }
 
@@ -155,10 +172,72 @@ func (s *Snmp) init() error {
 		}
 	}
 
+	if err := s.initProfiles(); err != nil {
+		return err
+	}
+
 	s.initialized = true
 	return nil
 }
 
+// initProfiles initializes the tables of all selected profiles.
+func (s *Snmp) initProfiles() error {
+	names := append([]string(nil), s.Profiles...)
+	for agent, agentProfiles := range s.AgentProfiles {
+		if !containsString(s.Agents, agent) {
+			return fmt.Errorf("agent_profiles: agent %q is not in agents", agent)
+		}
+		names = append(names, agentProfiles...)
+	}
+
+	s.profileTables = make(map[string][]Table)
+	for _, name := range names {
+		if _, ok := s.profileTables[name]; ok {
+			continue
+		}
+
+		tables, err := profileTables(name)
+		if err != nil {
+			return err
+		}
+		for i := range tables {
+			if err := tables[i].init(); err != nil {
+				return Errorf(err, "initializing table %s of profile %s", tables[i].Name, name)
+			}
+		}
+		s.profileTables[name] = tables
+	}
+	return nil
+}
+
+// agentTables returns the configured tables along with the tables of the
+// profiles selected for the agent.
+func (s *Snmp) agentTables(agent string) []Table {
+	if len(s.Profiles) == 0 && len(s.AgentProfiles[agent]) == 0 {
+		return s.Tables
+	}
+
+	tables := append([]Table(nil), s.Tables...)
+	seen := make(map[string]bool)
+	for _, name := range append(append([]string(nil), s.Profiles...), s.AgentProfiles[agent]...) {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		tables = append(tables, s.profileTables[name]...)
+	}
+	return tables
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
 // Table holds the configuration for a SNMP table.
 type Table struct {
 	// Name will be the name of the measurement.
@@ -375,7 +454,7 @@ func (s *Snmp) Gather(acc telegraf.Accumulator) error {
 			}
 
 			// Now is the real tables.
-			for _, t := range s.Tables {
+			for _, t := range s.agentTables(agent) {
 				if err := s.gatherTable(acc, gs, t, topTags, true); err != nil {
 					acc.AddError(Errorf(err, "agent %s: gathering table %s", agent, t.Name))
 				}
@@ -801,3 +801,147 @@ func TestError(t *testing.T) {
 	assert.Contains(t, err.Error(), "top error 123")
 	assert.Contains(t, err.Error(), "nested error")
 }
+
+func TestSnmpInit_profiles(t *testing.T) {
+	// override execCommand so it returns exec.ErrNotFound
+	defer func(ec func(string, ...string) *exec.Cmd) { execCommand = ec }(execCommand)
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("snmptranslateExecErrNotFound")
+	}
+
+	s := &Snmp{
+		Agents:   []string{"udp://127.0.0.1:161", "udp://127.0.0.2:161"},
+		Profiles: []string{"if_mib"},
+		AgentProfiles: map[string][]string{
+			"udp://127.0.0.2:161": {"cisco_cpu", "if_mib"},
+		},
+	}
+
+	err := s.init()
+	require.NoError(t, err)
+
+	tables := s.agentTables("udp://127.0.0.1:161")
+	require.Len(t, tables, 1)
+	assert.Equal(t, "interface", tables[0].Name)
+
+	tables = s.agentTables("udp://127.0.0.2:161")
+	require.Len(t, tables, 2)
+	assert.Equal(t, "interface", tables[0].Name)
+	assert.Equal(t, "cisco_cpu", tables[1].Name)
+	assert.True(t, tables[1].initialized)
+
+	// The built-in definitions are not modified.
+	assert.False(t, profiles["cisco_cpu"][0].Fields[0].initialized)
+}
+
+func TestSnmpInit_profilesError(t *testing.T) {
+	tests := []struct {
+		name string
+		snmp *Snmp
+	}{
+		{
+			name: "unknown profile",
+			snmp: &Snmp{
+				Agents:   []string{"udp://127.0.0.1:161"},
+				Profiles: []string{"foo"},
+			},
+		},
+		{
+			name: "unknown agent profile",
+			snmp: &Snmp{
+				Agents: []string{"udp://127.0.0.1:161"},
+				AgentProfiles: map[string][]string{
+					"udp://127.0.0.1:161": {"foo"},
+				},
+			},
+		},
+		{
+			name: "unknown agent",
+			snmp: &Snmp{
+				Agents: []string{"udp://127.0.0.1:161"},
+				AgentProfiles: map[string][]string{
+					"udp://127.0.0.2:161": {"cisco_cpu"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.snmp.init()
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestGather_profiles(t *testing.T) {
+	router := &testSNMPConnection{
+		host: "router",
+		values: map[string]interface{}{
+			".1.3.6.1.2.1.31.1.1.1.1.1":        "Gi0/1",
+			".1.3.6.1.2.1.31.1.1.1.15.1":       1000,
+			".1.3.6.1.2.1.31.1.1.1.6.1":        uint64(123456789012),
+			".1.3.6.1.4.1.9.9.109.1.1.1.1.2.7": 22,
+			".1.3.6.1.4.1.9.9.109.1.1.1.1.8.7": 13,
+		},
+	}
+	server := &testSNMPConnection{
+		host: "server",
+		values: map[string]interface{}{
+			".1.3.6.1.2.1.31.1.1.1.1.2":  "eth0",
+			".1.3.6.1.2.1.31.1.1.1.15.2": 10000,
+		},
+	}
+
+	s := &Snmp{
+		Agents:   []string{"router", "server"},
+		Profiles: []string{"if_mib"},
+		AgentProfiles: map[string][]string{
+			"router": {"cisco_cpu"},
+		},
+		profileTables: map[string][]Table{
+			"if_mib":    profiles["if_mib"],
+			"cisco_cpu": profiles["cisco_cpu"],
+		},
+
+		connectionCache: []snmpConnection{
+			router,
+			server,
+		},
+		initialized: true,
+	}
+	acc := &testutil.Accumulator{}
+
+	err := s.Gather(acc)
+	require.NoError(t, err)
+
+	acc.AssertContainsTaggedFields(t, "interface",
+		map[string]interface{}{
+			"ifHighSpeed":  1000,
+			"ifHCInOctets": uint64(123456789012),
+		},
+		map[string]string{
+			"agent_host": "router",
+			"ifName":     "Gi0/1",
+		},
+	)
+	acc.AssertContainsTaggedFields(t, "cisco_cpu",
+		map[string]interface{}{
+			"cpmCPUTotal5minRev": 13,
+		},
+		map[string]string{
+			"agent_host":               "router",
+			"index":                    "7",
+			"cpmCPUTotalPhysicalIndex": "22",
+		},
+	)
+	acc.AssertContainsTaggedFields(t, "interface",
+		map[string]interface{}{
+			"ifHighSpeed": 10000,
+		},
+		map[string]string{
+			"agent_host": "server",
+			"ifName":     "eth0",
+		},
+	)
+	require.Len(t, acc.Metrics, 3)
+}
@@ -3,6 +3,7 @@ package socket_listener
 import (
 	"bufio"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"net"
@@ -15,10 +16,48 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/common/proxyproto"
+	"github.com/influxdata/telegraf/plugins/common/ratelimit"
+	"github.com/influxdata/telegraf/plugins/common/reuseport"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	"golang.org/x/net/ipv4"
 )
 
+const (
+	// maxDatagramSize is the maximum size of an IP packet.
+	maxDatagramSize = 64 * 1024
+
+	// handshakeTimeout is the time allowed to read the PROXY protocol header
+	// and complete the TLS handshake of connections without read_timeout.
+	handshakeTimeout = 10 * time.Second
+)
+
+// bufferPool holds the read buffers of the listeners, reused across the
+// datagram sockets and the stream connections.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return make([]byte, maxDatagramSize)
+	},
+}
+
+// certAttributes returns the attributes of the client certificates available
+// as tags.
+var certAttributes = map[string]func(cert *x509.Certificate) string{
+	"common_name": func(cert *x509.Certificate) string {
+		return cert.Subject.CommonName
+	},
+	"organization": func(cert *x509.Certificate) string {
+		return strings.Join(cert.Subject.Organization, ",")
+	},
+	"organizational_unit": func(cert *x509.Certificate) string {
+		return strings.Join(cert.Subject.OrganizationalUnit, ",")
+	},
+	"serial_number": func(cert *x509.Certificate) string {
+		return cert.SerialNumber.Text(16)
+	},
+}
+
 type setReadBufferer interface {
 	SetReadBuffer(bytes int) error
 }
@@ -27,7 +66,8 @@ type streamSocketListener struct {
 	net.Listener
 	*SocketListener
 
-	sockType string
+	sockType  string
+	tlsConfig *tls.Config
 
 	connections    map[string]net.Conn
 	connectionsMtx sync.Mutex
@@ -111,12 +151,22 @@ func (ssl *streamSocketListener) read(c net.Conn) {
 	defer ssl.removeConnection(c)
 	defer c.Close()
 
-	decoder, err := internal.NewStreamContentDecoder(ssl.ContentEncoding, c)
+	conn, addr, tags, err := ssl.handshake(c)
+	if err != nil {
+		ssl.Log.Errorf("Unable to accept connection from %s: %v", c.RemoteAddr(), err)
+		return
+	}
+
+	decoder, err := internal.NewStreamContentDecoder(ssl.ContentEncoding, conn)
 	if err != nil {
 		ssl.Log.Error("Read error: %v", err)
 	}
 
+	buf := bufferPool.Get().([]byte)
+	defer bufferPool.Put(buf)
+
 	scnr := bufio.NewScanner(decoder)
+	scnr.Buffer(buf, bufio.MaxScanTokenSize)
 	for {
 		if ssl.ReadTimeout != nil && ssl.ReadTimeout.Duration > 0 {
 			c.SetReadDeadline(time.Now().Add(ssl.ReadTimeout.Duration))
@@ -133,9 +183,7 @@ func (ssl *streamSocketListener) read(c net.Conn) {
 			// TODO rate limit
 			continue
 		}
-		for _, m := range metrics {
-			ssl.AddMetric(m)
-		}
+		ssl.addMetrics(addr, tags, metrics)
 	}
 
 	if err := scnr.Err(); err != nil {
@@ -147,16 +195,80 @@ func (ssl *streamSocketListener) read(c net.Conn) {
 	}
 }
 
+// handshake reads the PROXY protocol header and completes the TLS handshake
+// of the connection when enabled.  It returns the connection to read the
+// metrics from, the address of the client and the tags of its certificate.
+func (ssl *streamSocketListener) handshake(c net.Conn) (net.Conn, net.Addr, map[string]string, error) {
+	if !ssl.ProxyProtocol && ssl.tlsConfig == nil {
+		return c, c.RemoteAddr(), nil, nil
+	}
+
+	timeout := handshakeTimeout
+	if ssl.ReadTimeout != nil && ssl.ReadTimeout.Duration > 0 {
+		timeout = ssl.ReadTimeout.Duration
+	}
+	c.SetDeadline(time.Now().Add(timeout))
+	defer c.SetDeadline(time.Time{})
+
+	conn, addr := c, c.RemoteAddr()
+	if ssl.ProxyProtocol {
+		pc := proxyproto.NewConn(c)
+		if err := pc.ReadHeader(); err != nil {
+			return nil, nil, nil, err
+		}
+		conn, addr = pc, pc.Source()
+	}
+
+	if ssl.tlsConfig == nil {
+		return conn, addr, nil, nil
+	}
+	tlsConn := tls.Server(conn, ssl.tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, nil, nil, err
+	}
+	return tlsConn, addr, ssl.certTags(tlsConn.ConnectionState()), nil
+}
+
 type packetSocketListener struct {
 	net.PacketConn
 	*SocketListener
 	decoder internal.ContentDecoder
 }
 
+// packetSocketListeners are the listeners of the sockets sharing an address.
+type packetSocketListeners []*packetSocketListener
+
+func (l packetSocketListeners) Close() error {
+	var err error
+	for _, psl := range l {
+		if cerr := psl.Close(); cerr != nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
 func (psl *packetSocketListener) listen() {
-	buf := make([]byte, 64*1024) // 64kb - maximum size of IP packet
+	// Only UDP sockets read several datagrams at once.
+	var batch *ipv4.PacketConn
+	size := 1
+	if _, ok := psl.PacketConn.(*net.UDPConn); ok && psl.ReadBatchSize > 1 {
+		batch = ipv4.NewPacketConn(psl.PacketConn)
+		size = psl.ReadBatchSize
+	}
+
+	msgs := make([]ipv4.Message, size)
+	for i := range msgs {
+		msgs[i].Buffers = [][]byte{bufferPool.Get().([]byte)}
+	}
+	defer func() {
+		for _, msg := range msgs {
+			bufferPool.Put(msg.Buffers[0])
+		}
+	}()
+
 	for {
-		n, _, err := psl.ReadFrom(buf)
+		n, err := psl.read(batch, msgs)
 		if err != nil {
 			if !strings.HasSuffix(err.Error(), ": use of closed network connection") {
 				psl.Log.Error(err.Error())
@@ -164,21 +276,51 @@ func (psl *packetSocketListener) listen() {
 			break
 		}
 
-		body, err := psl.decoder.Decode(buf[:n])
-		if err != nil {
-			psl.Log.Errorf("Unable to decode incoming packet: %s", err.Error())
+		for _, msg := range msgs[:n] {
+			psl.handle(msg.Buffers[0][:msg.N], msg.Addr)
 		}
+	}
+}
 
-		metrics, err := psl.Parse(body)
+// read reads the next datagrams into msgs and returns their number.
+func (psl *packetSocketListener) read(batch *ipv4.PacketConn, msgs []ipv4.Message) (int, error) {
+	if batch != nil {
+		return batch.ReadBatch(msgs, 0)
+	}
+
+	n, addr, err := psl.ReadFrom(msgs[0].Buffers[0])
+	if err != nil {
+		return 0, err
+	}
+	msgs[0].N, msgs[0].Addr = n, addr
+	return 1, nil
+}
+
+func (psl *packetSocketListener) handle(body []byte, addr net.Addr) {
+	if psl.ProxyProtocol {
+		source, payload, err := proxyproto.ParseDatagram(body)
 		if err != nil {
-			psl.Log.Errorf("Unable to parse incoming packet: %s", err.Error())
-			// TODO rate limit
-			continue
+			psl.Log.Errorf("Unable to read incoming packet from %s: %v", addr, err)
+			return
 		}
-		for _, m := range metrics {
-			psl.AddMetric(m)
+		if source != nil {
+			addr = source
 		}
+		body = payload
 	}
+
+	body, err := psl.decoder.Decode(body)
+	if err != nil {
+		psl.Log.Errorf("Unable to decode incoming packet: %s", err.Error())
+	}
+
+	metrics, err := psl.Parse(body)
+	if err != nil {
+		psl.Log.Errorf("Unable to parse incoming packet: %s", err.Error())
+		// TODO rate limit
+		return
+	}
+	psl.addMetrics(addr, nil, metrics)
 }
 
 type SocketListener struct {
@@ -189,9 +331,16 @@ type SocketListener struct {
 	KeepAlivePeriod *internal.Duration `toml:"keep_alive_period"`
 	SocketMode      string             `toml:"socket_mode"`
 	ContentEncoding string             `toml:"content_encoding"`
+	ProxyProtocol   bool               `toml:"proxy_protocol"`
+	SourceIPTag     string             `toml:"source_ip_tag"`
+	ReadBatchSize   int                `toml:"read_batch_size"`
+	UDPSockets      int                `toml:"udp_sockets"`
 	tlsint.ServerConfig
+	TLSClientCertTags map[string]string `toml:"tls_client_cert_tags"`
+	ratelimit.LimiterConfig
 
-	wg sync.WaitGroup
+	wg      sync.WaitGroup
+	limiter *ratelimit.Limiter
 
 	Log telegraf.Logger
 
@@ -241,6 +390,28 @@ func (sl *SocketListener) SampleConfig() string {
   # tls_key  = "/etc/telegraf/key.pem"
   ## Enables client authentication if set.
   # tls_allowed_cacerts = ["/etc/telegraf/clientca.pem"]
+  ## Tags set from the verified client certificate, mapping attributes of the
+  ## certificate to tag keys.  Available attributes are "common_name",
+  ## "organization", "organizational_unit" and "serial_number".
+  # tls_client_cert_tags = {common_name = "client_cn"}
+
+  ## Expect the PROXY protocol header, version 1 or 2, sent by load balancers
+  ## at the start of the connections, or version 2 at the start of the
+  ## datagrams.  The address of the header is used as client address.
+  # proxy_protocol = false
+
+  ## Tag key holding the IP address of the client of each metric.
+  # source_ip_tag = "source_ip"
+
+  ## Number of datagrams read at once, reducing the system calls on Linux.
+  ## Only applies to UDP sockets.
+  # read_batch_size = 1
+
+  ## Number of UDP sockets opened on the address with SO_REUSEPORT, each read
+  ## by its own goroutine, the kernel spreads the datagrams across the
+  ## sockets.  Only applies to UDP sockets, on platforms supporting
+  ## SO_REUSEPORT such as Linux and BSD.
+  # udp_sockets = 1
 
   ## Maximum socket buffer size (in bytes when no unit specified).
   ## For stream sockets, once the buffer fills up, the sender will start backing up.
@@ -263,6 +434,13 @@ func (sl *SocketListener) SampleConfig() string {
   ## Content encoding for message payloads, can be set to "gzip" to or
   ## "identity" to apply no encoding.
   # content_encoding = "identity"
+
+  ## Maximum number of metrics per second accepted from each client IP, the
+  ## metrics over the limit are dropped.  The burst is the number of metrics
+  ## accepted at once, by default one second worth of the rate.  Clients of
+  ## unix sockets share a single limit.  0 means no limit.
+  # rate_limit = 0.0
+  # rate_limit_burst = 0
 `
 }
 
@@ -284,6 +462,18 @@ func (sl *SocketListener) Start(acc telegraf.Accumulator) error {
 	protocol := spl[0]
 	addr := spl[1]
 
+	if sl.RateLimitKey != "" && sl.RateLimitKey != "ip" {
+		return fmt.Errorf("invalid rate_limit_key %q, only \"ip\" is supported", sl.RateLimitKey)
+	}
+	tags := map[string]string{
+		"address": sl.ServiceAddress,
+	}
+	limiter, err := sl.LimiterConfig.Limiter("socket_listener", "metrics_rate_limited", tags)
+	if err != nil {
+		return err
+	}
+	sl.limiter = limiter
+
 	if protocol == "unix" || protocol == "unixpacket" || protocol == "unixgram" {
 		// no good way of testing for "file does not exist".
 		// Instead just ignore error and blow up when we try to listen, which will
@@ -298,12 +488,16 @@ func (sl *SocketListener) Start(acc telegraf.Accumulator) error {
 			return err
 		}
 
-		var l net.Listener
-		if tlsCfg == nil {
-			l, err = net.Listen(protocol, addr)
-		} else {
-			l, err = tls.Listen(protocol, addr, tlsCfg)
+		if len(sl.TLSClientCertTags) > 0 && len(sl.TLSAllowedCACerts) == 0 {
+			return fmt.Errorf("tls_client_cert_tags requires tls_allowed_cacerts")
 		}
+		for attr := range sl.TLSClientCertTags {
+			if _, ok := certAttributes[attr]; !ok {
+				return fmt.Errorf("unknown client certificate attribute %q", attr)
+			}
+		}
+
+		l, err := net.Listen(protocol, addr)
 		if err != nil {
 			return err
 		}
@@ -325,6 +519,7 @@ func (sl *SocketListener) Start(acc telegraf.Accumulator) error {
 			Listener:       l,
 			SocketListener: sl,
 			sockType:       spl[0],
+			tlsConfig:      tlsCfg,
 		}
 
 		sl.Closer = ssl
@@ -335,12 +530,11 @@ func (sl *SocketListener) Start(acc telegraf.Accumulator) error {
 			ssl.listen()
 		}()
 	case "udp", "udp4", "udp6", "ip", "ip4", "ip6", "unixgram":
-		decoder, err := internal.NewContentDecoder(sl.ContentEncoding)
-		if err != nil {
-			return err
+		sockets := sl.UDPSockets
+		if sockets < 1 {
+			sockets = 1
 		}
-
-		pc, err := udpListen(protocol, addr)
+		pcs, err := udpListen(protocol, addr, sockets)
 		if err != nil {
 			return err
 		}
@@ -356,29 +550,47 @@ func (sl *SocketListener) Start(acc telegraf.Accumulator) error {
 			os.Chmod(spl[1], os.FileMode(uint32(i)))
 		}
 
-		if sl.ReadBufferSize.Size > 0 {
-			if srb, ok := pc.(setReadBufferer); ok {
-				srb.SetReadBuffer(int(sl.ReadBufferSize.Size))
-			} else {
-				sl.Log.Warnf("Unable to set read buffer on a %s socket", protocol)
+		psls := make(packetSocketListeners, 0, len(pcs))
+		for _, pc := range pcs {
+			// The decoders keep state, each socket needs its own.
+			decoder, err := internal.NewContentDecoder(sl.ContentEncoding)
+			if err != nil {
+				for _, pc := range pcs {
+					pc.Close()
+				}
+				return err
 			}
-		}
 
-		sl.Log.Infof("Listening on %s://%s", protocol, pc.LocalAddr())
+			if sl.ReadBufferSize.Size > 0 {
+				if srb, ok := pc.(setReadBufferer); ok {
+					srb.SetReadBuffer(int(sl.ReadBufferSize.Size))
+				} else {
+					sl.Log.Warnf("Unable to set read buffer on a %s socket", protocol)
+				}
+			}
 
-		psl := &packetSocketListener{
-			PacketConn:     pc,
-			SocketListener: sl,
-			decoder:        decoder,
+			psls = append(psls, &packetSocketListener{
+				PacketConn:     pc,
+				SocketListener: sl,
+				decoder:        decoder,
+			})
 		}
 
-		sl.Closer = psl
+		if len(psls) == 1 {
+			sl.Log.Infof("Listening on %s://%s", protocol, pcs[0].LocalAddr())
+			sl.Closer = psls[0]
+		} else {
+			sl.Log.Infof("Listening on %s://%s with %d sockets", protocol, pcs[0].LocalAddr(), len(psls))
+			sl.Closer = psls
+		}
 		sl.wg = sync.WaitGroup{}
-		sl.wg.Add(1)
-		go func() {
-			defer sl.wg.Done()
-			psl.listen()
-		}()
+		for _, psl := range psls {
+			sl.wg.Add(1)
+			go func(psl *packetSocketListener) {
+				defer sl.wg.Done()
+				psl.listen()
+			}(psl)
+		}
 	default:
 		return fmt.Errorf("unknown protocol '%s' in '%s'", protocol, sl.ServiceAddress)
 	}
@@ -390,7 +602,75 @@ func (sl *SocketListener) Start(acc telegraf.Accumulator) error {
 	return nil
 }
 
-func udpListen(network string, address string) (net.PacketConn, error) {
+// addMetrics adds the metrics received from the address with the tags of its
+// client, unless the client is over the rate limit.
+func (sl *SocketListener) addMetrics(addr net.Addr, tags map[string]string, metrics []telegraf.Metric) {
+	var source string
+	if addr != nil {
+		source = ratelimit.AddrSource(addr.String())
+	}
+	if !sl.limiter.Allow(source, len(metrics)) {
+		return
+	}
+
+	ip := addrIP(addr)
+	for _, m := range metrics {
+		if sl.SourceIPTag != "" && ip != "" {
+			m.AddTag(sl.SourceIPTag, ip)
+		}
+		for k, v := range tags {
+			m.AddTag(k, v)
+		}
+	}
+
+	if acc, ok := sl.Accumulator.(telegraf.BatchAccumulator); ok {
+		acc.AddMetrics(metrics)
+		return
+	}
+	for _, m := range metrics {
+		sl.AddMetric(m)
+	}
+}
+
+// certTags returns the tags of the verified client certificate.
+func (sl *SocketListener) certTags(state tls.ConnectionState) map[string]string {
+	if len(sl.TLSClientCertTags) == 0 || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+
+	cert := state.PeerCertificates[0]
+	tags := make(map[string]string, len(sl.TLSClientCertTags))
+	for attr, key := range sl.TLSClientCertTags {
+		if value := certAttributes[attr](cert); value != "" {
+			tags[key] = value
+		}
+	}
+	return tags
+}
+
+// addrIP returns the IP of an IP address, or "" for the other addresses such
+// as unix sockets.
+func addrIP(addr net.Addr) string {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		if a != nil {
+			return a.IP.String()
+		}
+	case *net.UDPAddr:
+		if a != nil {
+			return a.IP.String()
+		}
+	case *net.IPAddr:
+		if a != nil {
+			return a.IP.String()
+		}
+	}
+	return ""
+}
+
+// udpListen opens the sockets of the address, several UDP sockets are opened
+// with SO_REUSEPORT.
+func udpListen(network string, address string, sockets int) ([]net.PacketConn, error) {
 	switch network {
 	case "udp", "udp4", "udp6":
 		var addr *net.UDPAddr
@@ -408,11 +688,32 @@ func udpListen(network string, address string) (net.PacketConn, error) {
 			return nil, err
 		}
 		if addr.IP.IsMulticast() {
-			return net.ListenMulticastUDP(network, ifi, addr)
+			if sockets > 1 {
+				return nil, fmt.Errorf("udp_sockets cannot be used with multicast addresses")
+			}
+			pc, err := net.ListenMulticastUDP(network, ifi, addr)
+			if err != nil {
+				return nil, err
+			}
+			return []net.PacketConn{pc}, nil
 		}
-		return net.ListenUDP(network, addr)
+		if sockets > 1 {
+			return reuseport.ListenPacket(network, addr.String(), sockets)
+		}
+		pc, err := net.ListenUDP(network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return []net.PacketConn{pc}, nil
+	}
+	if sockets > 1 {
+		return nil, fmt.Errorf("udp_sockets only applies to UDP sockets")
+	}
+	pc, err := net.ListenPacket(network, address)
+	if err != nil {
+		return nil, err
 	}
-	return net.ListenPacket(network, address)
+	return []net.PacketConn{pc}, nil
 }
 
 func (sl *SocketListener) Stop() {
@@ -3,9 +3,13 @@ package socket_listener
 import (
 	"bytes"
 	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
+	"math/big"
 	"net"
 	"os"
 	"path/filepath"
@@ -220,6 +224,223 @@ func TestSocketListenerDecode_udp(t *testing.T) {
 	testSocketListener(t, sl, client)
 }
 
+func TestSocketListenerRateLimit(t *testing.T) {
+	sl := newSocketListener()
+	sl.Log = testutil.Logger{}
+	sl.ServiceAddress = "udp://127.0.0.1:0"
+	sl.RateLimit = 2
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, sl.Start(acc))
+	sl.Stop()
+
+	metrics, err := sl.Parse([]byte("test,foo=bar v=1i 123456789\ntest,foo=baz v=2i 123456790\n"))
+	require.NoError(t, err)
+
+	first := &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1000}
+	second := &net.UDPAddr{IP: net.ParseIP("10.0.0.2"), Port: 1000}
+	sl.addMetrics(first, nil, metrics)
+	sl.addMetrics(&net.UDPAddr{IP: first.IP, Port: 2000}, nil, metrics)
+	sl.addMetrics(second, nil, metrics[:1])
+	require.Equal(t, uint64(3), acc.NMetrics())
+
+	sl.RateLimitKey = "tenant"
+	require.Error(t, sl.Start(acc))
+}
+
+func TestSocketListener_udpBatch(t *testing.T) {
+	defer testEmptyLog(t)()
+
+	sl := newSocketListener()
+	sl.Log = testutil.Logger{}
+	sl.ServiceAddress = "udp://127.0.0.1:0"
+	sl.ReadBatchSize = 8
+
+	acc := &testutil.Accumulator{}
+	err := sl.Start(acc)
+	require.NoError(t, err)
+	defer sl.Stop()
+
+	client, err := net.Dial("udp", sl.Closer.(net.PacketConn).LocalAddr().String())
+	require.NoError(t, err)
+
+	testSocketListener(t, sl, client)
+}
+
+func TestSocketListener_udpSockets(t *testing.T) {
+	defer testEmptyLog(t)()
+
+	sl := newSocketListener()
+	sl.Log = testutil.Logger{}
+	sl.ServiceAddress = "udp://127.0.0.1:0"
+	sl.UDPSockets = 4
+	sl.ContentEncoding = "gzip"
+
+	acc := &testutil.Accumulator{}
+	err := sl.Start(acc)
+	require.NoError(t, err)
+	defer sl.Stop()
+
+	psls := sl.Closer.(packetSocketListeners)
+	require.Len(t, psls, 4)
+
+	// The datagrams of each client are read by one of the sockets.
+	for i := 0; i < 16; i++ {
+		client, err := net.Dial("udp", psls[0].LocalAddr().String())
+		require.NoError(t, err)
+		encoder, err := internal.NewContentEncoder(sl.ContentEncoding)
+		require.NoError(t, err)
+		body, err := encoder.Encode([]byte(fmt.Sprintf("test,client=%d v=1i\n", i)))
+		require.NoError(t, err)
+		_, err = client.Write(body)
+		require.NoError(t, err)
+		client.Close()
+	}
+
+	acc.Wait(16)
+	require.Equal(t, uint64(16), acc.NMetrics())
+}
+
+func TestSocketListener_unixgramSockets(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "telegraf")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	sl := newSocketListener()
+	sl.Log = testutil.Logger{}
+	sl.ServiceAddress = "unixgram://" + filepath.Join(tmpdir, "sl.TestSocketListener_unixgramSockets.sock")
+	sl.UDPSockets = 2
+
+	require.Error(t, sl.Start(&testutil.Accumulator{}))
+}
+
+func TestSocketListenerProxyProtocol_tcp(t *testing.T) {
+	sl := newSocketListener()
+	sl.Log = testutil.Logger{}
+	sl.ServiceAddress = "tcp://127.0.0.1:0"
+	sl.ProxyProtocol = true
+	sl.SourceIPTag = "source_ip"
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, sl.Start(acc))
+	defer sl.Stop()
+
+	client, err := net.Dial("tcp", sl.Closer.(net.Listener).Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Write([]byte("PROXY TCP4 192.0.2.1 192.0.2.2 4000 8094\r\ntest,foo=bar v=1i 123456789\n"))
+	require.NoError(t, err)
+
+	acc.Wait(1)
+	acc.AssertContainsTaggedFields(t, "test",
+		map[string]interface{}{"v": int64(1)},
+		map[string]string{"foo": "bar", "source_ip": "192.0.2.1"},
+	)
+}
+
+func TestSocketListenerProxyProtocol_udp(t *testing.T) {
+	sl := newSocketListener()
+	sl.Log = testutil.Logger{}
+	sl.ServiceAddress = "udp://127.0.0.1:0"
+	sl.ProxyProtocol = true
+	sl.SourceIPTag = "source_ip"
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, sl.Start(acc))
+	defer sl.Stop()
+
+	client, err := net.Dial("udp", sl.Closer.(net.PacketConn).LocalAddr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	// Datagrams without header are dropped.
+	_, err = client.Write([]byte("test,foo=baz v=2i 123456790\n"))
+	require.NoError(t, err)
+
+	header := []byte("\r\n\r\n\x00\r\nQUIT\n\x21\x12\x00\x0c")
+	header = append(header, 198, 51, 100, 7, 192, 0, 2, 2, 0x13, 0x88, 0x1f, 0x96)
+	_, err = client.Write(append(header, "test,foo=bar v=1i 123456789\n"...))
+	require.NoError(t, err)
+
+	acc.Wait(1)
+	require.Equal(t, uint64(1), acc.NMetrics())
+	acc.AssertContainsTaggedFields(t, "test",
+		map[string]interface{}{"v": int64(1)},
+		map[string]string{"foo": "bar", "source_ip": "198.51.100.7"},
+	)
+}
+
+func TestSocketListenerProxyProtocol_tls(t *testing.T) {
+	sl := newSocketListener()
+	sl.Log = testutil.Logger{}
+	sl.ServiceAddress = "tcp://127.0.0.1:0"
+	sl.ServerConfig = *pki.TLSServerConfig()
+	sl.TLSCipherSuites = nil
+	sl.TLSMinVersion = ""
+	sl.TLSMaxVersion = ""
+	sl.TLSClientCertTags = map[string]string{"common_name": "client_cn"}
+	sl.ProxyProtocol = true
+	sl.SourceIPTag = "source_ip"
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, sl.Start(acc))
+	defer sl.Stop()
+
+	tlsCfg, err := pki.TLSClientConfig().TLSConfig()
+	require.NoError(t, err)
+	tlsCfg.ServerName = "localhost"
+
+	// The header is sent before the TLS handshake.
+	conn, err := net.Dial("tcp", sl.Closer.(net.Listener).Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("PROXY TCP4 192.0.2.1 192.0.2.2 4000 8094\r\n"))
+	require.NoError(t, err)
+
+	client := tls.Client(conn, tlsCfg)
+	_, err = client.Write([]byte("test,foo=bar v=1i 123456789\n"))
+	require.NoError(t, err)
+
+	acc.Wait(1)
+	acc.AssertContainsTaggedFields(t, "test",
+		map[string]interface{}{"v": int64(1)},
+		map[string]string{"foo": "bar", "source_ip": "192.0.2.1", "client_cn": "client.localdomain"},
+	)
+}
+
+func TestSocketListenerCertTags(t *testing.T) {
+	sl := newSocketListener()
+	sl.Log = testutil.Logger{}
+	sl.ServiceAddress = "tcp://127.0.0.1:0"
+	sl.TLSClientCertTags = map[string]string{
+		"common_name":   "client_cn",
+		"organization":  "client_o",
+		"serial_number": "client_serial",
+	}
+
+	acc := &testutil.Accumulator{}
+	require.Error(t, sl.Start(acc))
+
+	sl.TLSAllowedCACerts = []string{pki.CACertPath()}
+	sl.TLSClientCertTags["country"] = "client_country"
+	require.Error(t, sl.Start(acc))
+
+	delete(sl.TLSClientCertTags, "country")
+	tags := sl.certTags(tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{{
+			SerialNumber: big.NewInt(255),
+			Subject:      pkix.Name{CommonName: "client.local", Organization: []string{"acme", "dev"}},
+		}},
+	})
+	require.Equal(t, map[string]string{
+		"client_cn":     "client.local",
+		"client_o":      "acme,dev",
+		"client_serial": "ff",
+	}, tags)
+	require.Nil(t, sl.certTags(tls.ConnectionState{}))
+}
+
 func testSocketListener(t *testing.T, sl *SocketListener, client net.Conn) {
 	mstr12 := []byte("test,foo=bar v=1i 123456789\ntest,foo=baz v=2i 123456790\n")
 	mstr3 := []byte("test,foo=zab v=3i 123456791\n")
@@ -0,0 +1,81 @@
+package starlark
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// maxBodySize limits the size of the responses read into the scripts.
+const maxBodySize = 10 * 1024 * 1024
+
+// newHTTPModule returns the http module available to scripts.
+func newHTTPModule(client *http.Client, userAgent string) *starlarkstruct.Module {
+	get := func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var url string
+		var headers *starlark.Dict
+		if err := starlark.UnpackArgs(b.Name(), args, kwargs, "url", &url, "headers?", &headers); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", userAgent)
+		if headers != nil {
+			for _, item := range headers.Items() {
+				k, ok1 := starlark.AsString(item[0])
+				v, ok2 := starlark.AsString(item[1])
+				if !ok1 || !ok2 {
+					return nil, fmt.Errorf("%s: headers must be strings", b.Name())
+				}
+				req.Header.Set(k, v)
+			}
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+		if err != nil {
+			return nil, err
+		}
+
+		keys := make([]string, 0, len(resp.Header))
+		for k := range resp.Header {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		respHeaders := starlark.NewDict(len(keys))
+		for _, k := range keys {
+			key := strings.ToLower(k)
+			value := strings.Join(resp.Header[k], ", ")
+			if err := respHeaders.SetKey(starlark.String(key), starlark.String(value)); err != nil {
+				return nil, err
+			}
+		}
+
+		return starlarkstruct.FromStringDict(starlark.String("response"), starlark.StringDict{
+			"status_code": starlark.MakeInt(resp.StatusCode),
+			"headers":     respHeaders,
+			"body":        starlark.String(body),
+		}), nil
+	}
+
+	return &starlarkstruct.Module{
+		Name: "http",
+		Members: starlark.StringDict{
+			"get": starlark.NewBuiltin("get", get),
+		},
+	}
+}
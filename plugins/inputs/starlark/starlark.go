@@ -0,0 +1,133 @@
+package starlark
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	common "github.com/influxdata/telegraf/plugins/common/starlark"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"go.starlark.net/starlark"
+)
+
+const sampleConfig = `
+  ## The Starlark source can be set as a string in this configuration file, or
+  ## by referencing a file containing the script.  Only one source or script
+  ## should be set at once.
+  ##
+  ## Source of the Starlark script.
+  source = '''
+def gather():
+	metric = Metric("example")
+	metric.fields["value"] = 42
+	return metric
+'''
+
+  ## File containing a Starlark script.  Modules loaded with load() are read
+  ## relative to the directory of the file loading them.
+  # script = "/usr/local/bin/myscript.star"
+
+  ## Allow the script to read environment variables using os.getenv.
+  # allow_env = false
+
+  ## Timeout of the requests made with http.get.
+  # http_timeout = "5s"
+
+  ## Optional TLS Config for the requests made with http.get.
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## Constants exposed to the script in the read-only constants dict.
+  # [inputs.starlark.constants]
+  #   url = "http://localhost:8080/stats"
+`
+
+type Starlark struct {
+	common.Common
+	HTTPTimeout internal.Duration `toml:"http_timeout"`
+	tls.ClientConfig
+
+	gatherFunc *starlark.Function
+}
+
+func (s *Starlark) Description() string {
+	return "Collect metrics using a Starlark script"
+}
+
+func (s *Starlark) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Starlark) Init() error {
+	tlsConfig, err := s.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+		Timeout: s.HTTPTimeout.Duration,
+	}
+
+	builtins := starlark.StringDict{
+		"http": newHTTPModule(client, internal.ProductToken()),
+	}
+	if err := s.Common.Init("input.starlark", builtins); err != nil {
+		return err
+	}
+
+	// The source should define a gather function.
+	fn, err := s.Function("gather", 0)
+	if err != nil {
+		return err
+	}
+	s.gatherFunc = fn
+	return nil
+}
+
+func (s *Starlark) Gather(acc telegraf.Accumulator) error {
+	rv, err := s.Call(s.gatherFunc)
+	if err != nil {
+		s.LogError(err)
+		return fmt.Errorf("calling gather: %v", err)
+	}
+
+	// The metrics are copied, the script can keep them in the state and
+	// modify them in the next calls.
+	switch rv := rv.(type) {
+	case *starlark.List:
+		iter := rv.Iterate()
+		defer iter.Done()
+		var v starlark.Value
+		for iter.Next(&v) {
+			m, ok := v.(*common.Metric)
+			if !ok {
+				acc.AddError(fmt.Errorf("invalid type returned in list: %s", v.Type()))
+				continue
+			}
+			acc.AddMetric(m.Unwrap().Copy())
+		}
+	case *common.Metric:
+		acc.AddMetric(rv.Unwrap().Copy())
+	case starlark.NoneType:
+	default:
+		return fmt.Errorf("invalid type returned: %s", rv.Type())
+	}
+	return nil
+}
+
+func init() {
+	inputs.Add("starlark", func() telegraf.Input {
+		return &Starlark{
+			HTTPTimeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
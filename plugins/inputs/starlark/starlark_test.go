@@ -0,0 +1,165 @@
+package starlark
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	common "github.com/influxdata/telegraf/plugins/common/starlark"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGather(t *testing.T) {
+	plugin := &Starlark{
+		Common: common.Common{
+			Source: `
+def gather():
+	metric = Metric("example")
+	metric.tags["source"] = "starlark"
+	metric.fields["value"] = 42
+	metric.time = 0
+	other = deepcopy(metric)
+	other.name = "other"
+	return [metric, other]
+`,
+			Log: testutil.Logger{},
+		},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(plugin.Gather))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("example",
+			map[string]string{"source": "starlark"},
+			map[string]interface{}{"value": 42},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("other",
+			map[string]string{"source": "starlark"},
+			map[string]interface{}{"value": 42},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestGatherHTTP(t *testing.T) {
+	requests := 100
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stats" || r.Header.Get("Accept") != "application/json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		requests += 5
+		fmt.Fprintf(w, `{"server": "web01", "requests": %d}`, requests)
+	}))
+	defer ts.Close()
+
+	plugin := &Starlark{
+		Common: common.Common{
+			Script:    "testdata/stats.star",
+			Constants: map[string]interface{}{"url": ts.URL + "/stats"},
+			Log:       testutil.Logger{},
+		},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.NoError(t, plugin.Gather(&acc))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("stats",
+			map[string]string{"server": "web01"},
+			map[string]interface{}{"requests": 105},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("stats",
+			map[string]string{"server": "web01"},
+			map[string]interface{}{"requests": 110, "new_requests": 5},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+
+	// Errors of the script are errors of the gather
+	plugin = &Starlark{
+		Common: common.Common{
+			Script:    "testdata/stats.star",
+			Constants: map[string]interface{}{"url": ts.URL + "/missing"},
+			Log:       testutil.Logger{},
+		},
+	}
+	require.NoError(t, plugin.Init())
+	err := plugin.Gather(&acc)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "stats returned status 404")
+}
+
+func TestGatherReturnValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		count  int
+		errors int
+		err    bool
+	}{
+		{
+			name:   "none",
+			source: "def gather():\n\treturn None\n",
+		},
+		{
+			name:   "single metric",
+			source: "def gather():\n\treturn Metric('cpu')\n",
+			count:  0, // A metric without field is dropped by the accumulator
+		},
+		{
+			name:   "invalid item",
+			source: "def gather():\n\tm = Metric('cpu')\n\tm.fields['x'] = 1\n\treturn [m, 42]\n",
+			count:  1,
+			errors: 1,
+		},
+		{
+			name:   "invalid type",
+			source: "def gather():\n\treturn 42\n",
+			err:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Starlark{
+				Common: common.Common{
+					Source: tt.source,
+					Log:    testutil.Logger{},
+				},
+			}
+			require.NoError(t, plugin.Init())
+
+			var acc testutil.Accumulator
+			err := plugin.Gather(&acc)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, acc.Errors, tt.errors)
+			require.Len(t, acc.GetTelegrafMetrics(), tt.count)
+		})
+	}
+}
+
+func TestInitError(t *testing.T) {
+	plugin := &Starlark{
+		Common: common.Common{
+			Source: "def gather(metric):\n\treturn metric\n",
+			Log:    testutil.Logger{},
+		},
+	}
+	require.Error(t, plugin.Init())
+}
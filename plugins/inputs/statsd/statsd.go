@@ -10,10 +10,13 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/ratelimit"
+	"github.com/influxdata/telegraf/plugins/common/reuseport"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers/graphite"
 	"github.com/influxdata/telegraf/selfstat"
@@ -37,6 +40,9 @@ const (
 
 // Statsd allows the importing of statsd and dogstatsd data.
 type Statsd struct {
+	// drops tracks the number of dropped metrics, must be 64-bit aligned.
+	drops int64
+
 	// Protocol used on listener - udp or tcp
 	Protocol string `toml:"protocol"`
 
@@ -77,6 +83,11 @@ type Statsd struct {
 
 	ReadBufferSize int `toml:"read_buffer_size"`
 
+	// UDPSockets is the number of UDP sockets opened with SO_REUSEPORT.
+	UDPSockets int `toml:"udp_sockets"`
+
+	ratelimit.LimiterConfig
+
 	sync.Mutex
 	// Lock for preventing a data race during resource cleanup
 	cleanup sync.Mutex
@@ -85,8 +96,6 @@ type Statsd struct {
 	// is an available bool in accept, then we are below the maximum and can
 	// accept the connection
 	accept chan bool
-	// drops tracks the number of dropped metrics.
-	drops int
 	// malformed tracks the number of malformed packets
 	malformed int
 
@@ -108,6 +117,7 @@ type Statsd struct {
 	// Protocol listeners
 	UDPlistener *net.UDPConn
 	TCPlistener *net.TCPListener
+	udpConns    []*net.UDPConn
 
 	// track current connections so we can close them in Stop()
 	conns map[string]*net.TCPConn
@@ -118,6 +128,7 @@ type Statsd struct {
 	TCPKeepAlivePeriod *internal.Duration `toml:"tcp_keep_alive_period"`
 
 	graphiteParser *graphite.GraphiteParser
+	limiter        *ratelimit.Limiter
 
 	acc telegraf.Accumulator
 
@@ -243,6 +254,19 @@ const sampleConfig = `
   ## calculation of percentiles. Raising this limit increases the accuracy
   ## of percentiles but also increases the memory usage and cpu time.
   percentile_limit = 1000
+
+  ## Maximum number of metrics per second accepted from each client IP, the
+  ## metrics over the limit are dropped.  The burst is the number of metrics
+  ## accepted at once, by default one second worth of the rate.  0 means no
+  ## limit.
+  # rate_limit = 0.0
+  # rate_limit_burst = 0
+
+  ## Number of UDP sockets opened on the address with SO_REUSEPORT, each read
+  ## by its own goroutine, the kernel spreads the packets across the sockets.
+  ## Only applies to UDP, on platforms supporting SO_REUSEPORT such as Linux
+  ## and BSD.
+  # udp_sockets = 1
 `
 
 func (_ *Statsd) SampleConfig() string {
@@ -340,6 +364,15 @@ func (s *Statsd) Start(ac telegraf.Accumulator) error {
 	s.UDPBytesRecv = selfstat.Register("statsd", "udp_bytes_received", tags)
 	s.ParseTimeNS = selfstat.Register("statsd", "parse_time_ns", tags)
 
+	if s.RateLimitKey != "" && s.RateLimitKey != "ip" {
+		return fmt.Errorf("invalid rate_limit_key %q, only \"ip\" is supported", s.RateLimitKey)
+	}
+	limiter, err := s.LimiterConfig.Limiter("statsd", "metrics_rate_limited", tags)
+	if err != nil {
+		return err
+	}
+	s.limiter = limiter
+
 	s.in = make(chan input, s.AllowedPendingMessages)
 	s.done = make(chan struct{})
 	s.accept = make(chan bool, s.MaxTCPConnections)
@@ -367,19 +400,33 @@ func (s *Statsd) Start(ac telegraf.Accumulator) error {
 			return err
 		}
 
-		conn, err := net.ListenUDP(s.Protocol, address)
+		sockets := s.UDPSockets
+		if sockets < 1 {
+			sockets = 1
+		}
+		conns, err := reuseport.ListenPacket(s.Protocol, address.String(), sockets)
 		if err != nil {
 			return err
 		}
 
-		s.Log.Infof("UDP listening on %q", conn.LocalAddr().String())
-		s.UDPlistener = conn
+		if len(conns) == 1 {
+			s.Log.Infof("UDP listening on %q", conns[0].LocalAddr().String())
+		} else {
+			s.Log.Infof("UDP listening on %q with %d sockets", conns[0].LocalAddr().String(), len(conns))
+		}
+		s.udpConns = make([]*net.UDPConn, 0, len(conns))
+		for _, pc := range conns {
+			s.udpConns = append(s.udpConns, pc.(*net.UDPConn))
+		}
+		s.UDPlistener = s.udpConns[0]
 
-		s.wg.Add(1)
-		go func() {
-			defer s.wg.Done()
-			s.udpListen(conn)
-		}()
+		for _, conn := range s.udpConns {
+			s.wg.Add(1)
+			go func(conn *net.UDPConn) {
+				defer s.wg.Done()
+				s.udpListen(conn)
+			}(conn)
+		}
 	} else {
 		address, err := net.ResolveTCPAddr("tcp", s.ServiceAddress)
 		if err != nil {
@@ -456,7 +503,7 @@ func (s *Statsd) tcpListen(listener *net.TCPListener) error {
 // udpListen starts listening for udp packets on the configured port.
 func (s *Statsd) udpListen(conn *net.UDPConn) error {
 	if s.ReadBufferSize > 0 {
-		s.UDPlistener.SetReadBuffer(s.ReadBufferSize)
+		conn.SetReadBuffer(s.ReadBufferSize)
 	}
 
 	buf := make([]byte, UDP_MAX_PACKET_SIZE)
@@ -485,11 +532,11 @@ func (s *Statsd) udpListen(conn *net.UDPConn) error {
 				Addr:   addr.IP.String()}:
 			default:
 				s.UDPPacketsDrop.Incr(1)
-				s.drops++
-				if s.drops == 1 || s.AllowedPendingMessages == 0 || s.drops%s.AllowedPendingMessages == 0 {
+				drops := atomic.AddInt64(&s.drops, 1)
+				if drops == 1 || s.AllowedPendingMessages == 0 || drops%int64(s.AllowedPendingMessages) == 0 {
 					s.Log.Errorf("Statsd message queue full. "+
 						"We have dropped %d messages so far. "+
-						"You may want to increase allowed_pending_messages in the config", s.drops)
+						"You may want to increase allowed_pending_messages in the config", drops)
 				}
 			}
 		}
@@ -506,8 +553,11 @@ func (s *Statsd) parser() error {
 			return nil
 		case in := <-s.in:
 			start := time.Now()
-			lines := strings.Split(in.Buffer.String(), "\n")
+			lines := strings.Split(strings.TrimSpace(in.Buffer.String()), "\n")
 			s.bufPool.Put(in.Buffer)
+			if !s.limiter.Allow(in.Addr, len(lines)) {
+				continue
+			}
 			for _, line := range lines {
 				line = strings.TrimSpace(line)
 				switch {
@@ -863,11 +913,11 @@ func (s *Statsd) handler(conn *net.TCPConn, id string) {
 			select {
 			case s.in <- input{Buffer: b, Time: time.Now(), Addr: remoteIP}:
 			default:
-				s.drops++
-				if s.drops == 1 || s.drops%s.AllowedPendingMessages == 0 {
+				drops := atomic.AddInt64(&s.drops, 1)
+				if drops == 1 || drops%int64(s.AllowedPendingMessages) == 0 {
 					s.Log.Errorf("Statsd message queue full. "+
 						"We have dropped %d messages so far. "+
-						"You may want to increase allowed_pending_messages in the config", s.drops)
+						"You may want to increase allowed_pending_messages in the config", drops)
 				}
 			}
 		}
@@ -900,7 +950,9 @@ func (s *Statsd) Stop() {
 	s.Log.Infof("Stopping the statsd service")
 	close(s.done)
 	if s.isUDP() {
-		s.UDPlistener.Close()
+		for _, conn := range s.udpConns {
+			conn.Close()
+		}
 	} else {
 		s.TCPlistener.Close()
 		// Close all open TCP connections
@@ -1,9 +1,11 @@
 package statsd
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/selfstat"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"net"
@@ -200,6 +202,41 @@ func BenchmarkTCP(b *testing.B) {
 	}
 }
 
+// Metrics of a client over the rate limit should be dropped
+func TestRateLimit(t *testing.T) {
+	s := NewTestStatsd()
+	s.RateLimit = 1
+	limiter, err := s.LimiterConfig.Limiter("statsd", "metrics_rate_limited", map[string]string{"test": t.Name()})
+	require.NoError(t, err)
+	s.limiter = limiter
+	s.ParseTimeNS = selfstat.Register("statsd", "parse_time_ns", map[string]string{"test": t.Name()})
+
+	done := make(chan struct{})
+	go func() {
+		s.parser()
+		close(done)
+	}()
+
+	for _, in := range []struct {
+		addr  string
+		lines string
+	}{
+		{"10.0.0.1", "rate:1|c\nrate:1|c\n"},
+		{"10.0.0.1", "rate:1|c\n"},
+		{"10.0.0.2", "rate:1|c\n"},
+		// The unbuffered channel is read once the previous packets are parsed.
+		{"10.0.0.3", ""},
+	} {
+		s.in <- input{Buffer: bytes.NewBufferString(in.lines), Time: time.Now(), Addr: in.addr}
+	}
+	close(s.done)
+	<-done
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, s.Gather(acc))
+	acc.AssertContainsFields(t, "rate", map[string]interface{}{"value": int64(3)})
+}
+
 // Valid lines should be parsed and their values should be cached
 func TestParse_ValidLines(t *testing.T) {
 	s := NewTestStatsd()
@@ -1745,3 +1782,36 @@ func TestUdp(t *testing.T) {
 		testutil.IgnoreTime(),
 	)
 }
+
+func TestUdpSockets(t *testing.T) {
+	statsd := Statsd{
+		Log:                    testutil.Logger{},
+		Protocol:               "udp",
+		ServiceAddress:         "127.0.0.1:0",
+		AllowedPendingMessages: 250000,
+		UDPSockets:             4,
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, statsd.Start(&acc))
+	defer statsd.Stop()
+	require.Len(t, statsd.udpConns, 4)
+
+	// The packets of each client are read by one of the sockets.
+	for i := 0; i < 16; i++ {
+		conn, err := net.Dial("udp", statsd.UDPlistener.LocalAddr().String())
+		require.NoError(t, err)
+		_, err = conn.Write([]byte("cpu.time_idle:1|c\n"))
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+	}
+
+	for {
+		acc.ClearMetrics()
+		require.NoError(t, statsd.Gather(&acc))
+
+		if len(acc.Metrics) > 0 && acc.Metrics[0].Fields["value"] == int64(16) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
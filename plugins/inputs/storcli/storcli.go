@@ -0,0 +1,347 @@
+package storcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// executables searched for in the PATH if no path is configured
+var executables = []string{"storcli64", "storcli", "perccli64", "perccli"}
+
+type Storcli struct {
+	Path    string            `toml:"path"`
+	UseSudo bool              `toml:"use_sudo"`
+	Timeout internal.Duration `toml:"timeout"`
+	Log     telegraf.Logger   `toml:"-"`
+}
+
+var sampleConfig = `
+  ## Optionally specify the path to the storcli executable; by default
+  ## storcli64, storcli, perccli64 and perccli are searched in the PATH.
+  # path = "/opt/MegaRAID/storcli/storcli64"
+
+  ## storcli requires root access.
+  ## Setting 'use_sudo' to true will make use of sudo to run storcli.
+  ## Sudo must be configured to to allow the telegraf user to run storcli
+  ## without a password.
+  # use_sudo = false
+
+  ## Timeout for the storcli command to complete.
+  # timeout = "30s"
+`
+
+func (s *Storcli) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Storcli) Description() string {
+	return "Read controller, virtual drive, physical drive and BBU state from MegaRAID controllers using storcli"
+}
+
+func (s *Storcli) Init() error {
+	if s.Path == "" {
+		for _, name := range executables {
+			if path, err := exec.LookPath(name); err == nil {
+				s.Path = path
+				break
+			}
+		}
+	}
+	if s.Path == "" {
+		return fmt.Errorf("storcli not found: verify that storcli is installed and that storcli is in your PATH")
+	}
+	return nil
+}
+
+// response is the JSON output of storcli for all controllers.
+type response struct {
+	Controllers []struct {
+		CommandStatus struct {
+			Controller  interface{} `json:"Controller"`
+			Status      string      `json:"Status"`
+			Description string      `json:"Description"`
+		} `json:"Command Status"`
+		ResponseData json.RawMessage `json:"Response Data"`
+	} `json:"Controllers"`
+}
+
+type controllerData struct {
+	Basics struct {
+		Controller   interface{} `json:"Controller"`
+		Model        string      `json:"Model"`
+		SerialNumber string      `json:"Serial Number"`
+	} `json:"Basics"`
+	Status struct {
+		ControllerStatus          string `json:"Controller Status"`
+		MemoryCorrectableErrors   int64  `json:"Memory Correctable Errors"`
+		MemoryUncorrectableErrors int64  `json:"Memory Uncorrectable Errors"`
+	} `json:"Status"`
+	VirtualDrives  []virtualDrive `json:"VD LIST"`
+	PhysicalDrives []struct {
+		EIDSlot string      `json:"EID:Slt"`
+		State   string      `json:"State"`
+		DG      interface{} `json:"DG"`
+		Size    string      `json:"Size"`
+		Intf    string      `json:"Intf"`
+		Med     string      `json:"Med"`
+		Model   string      `json:"Model"`
+	} `json:"PD LIST"`
+	BBU        []battery `json:"BBU_Info"`
+	Cachevault []battery `json:"Cachevault_Info"`
+}
+
+type virtualDrive struct {
+	DGVD  string `json:"DG/VD"`
+	Type  string `json:"TYPE"`
+	State string `json:"State"`
+	Size  string `json:"Size"`
+	Name  string `json:"Name"`
+}
+
+type battery struct {
+	Model string `json:"Model"`
+	State string `json:"State"`
+	Temp  string `json:"Temp"`
+}
+
+type rebuildStatus struct {
+	DriveID  string      `json:"Drive-ID"`
+	Progress interface{} `json:"Progress%"`
+	Status   string      `json:"Status"`
+}
+
+func (s *Storcli) Gather(acc telegraf.Accumulator) error {
+	var controllers response
+	if err := s.run(&controllers, "/call", "show", "all", "J"); err != nil {
+		return err
+	}
+
+	// Rebuild progress is optional; it is not available without physical
+	// drives.
+	rebuilds := make(map[string]float64)
+	var rebuild response
+	if err := s.run(&rebuild, "/call/eall/sall", "show", "rebuild", "J"); err != nil {
+		s.Log.Debugf("Reading rebuild progress: %v", err)
+	}
+	for _, c := range rebuild.Controllers {
+		var statuses []rebuildStatus
+		if err := json.Unmarshal(c.ResponseData, &statuses); err != nil {
+			continue
+		}
+		for _, status := range statuses {
+			if progress, ok := toFloat(status.Progress); ok {
+				rebuilds[status.DriveID] = progress
+			}
+		}
+	}
+
+	for _, c := range controllers.Controllers {
+		id := toString(c.CommandStatus.Controller)
+		if c.CommandStatus.Status != "Success" {
+			acc.AddError(fmt.Errorf("controller %s: %s", id, c.CommandStatus.Description))
+			continue
+		}
+		var data controllerData
+		if err := json.Unmarshal(c.ResponseData, &data); err != nil {
+			acc.AddError(fmt.Errorf("controller %s: parsing response: %v", id, err))
+			continue
+		}
+		gatherController(id, data, rebuilds, acc)
+	}
+	return nil
+}
+
+func gatherController(id string, data controllerData, rebuilds map[string]float64, acc telegraf.Accumulator) {
+	tags := map[string]string{
+		"controller": id,
+		"model":      data.Basics.Model,
+		"serial":     data.Basics.SerialNumber,
+	}
+	fields := map[string]interface{}{
+		"status":                      data.Status.ControllerStatus,
+		"optimal":                     data.Status.ControllerStatus == "Optimal",
+		"memory_correctable_errors":   data.Status.MemoryCorrectableErrors,
+		"memory_uncorrectable_errors": data.Status.MemoryUncorrectableErrors,
+		"virtual_drives":              len(data.VirtualDrives),
+		"physical_drives":             len(data.PhysicalDrives),
+	}
+	acc.AddFields("storcli_controller", fields, tags)
+
+	for _, vd := range data.VirtualDrives {
+		tags := map[string]string{
+			"controller":    id,
+			"virtual_drive": vd.DGVD,
+			"raid_level":    vd.Type,
+		}
+		if vd.Name != "" {
+			tags["name"] = vd.Name
+		}
+		fields := map[string]interface{}{
+			"state":   vd.State,
+			"optimal": vd.State == "Optl",
+		}
+		if size, ok := parseSize(vd.Size); ok {
+			fields["size_bytes"] = size
+		}
+		acc.AddFields("storcli_virtual_drive", fields, tags)
+	}
+
+	for _, pd := range data.PhysicalDrives {
+		enclosure, slot := splitEIDSlot(pd.EIDSlot)
+		tags := map[string]string{
+			"controller": id,
+			"slot":       slot,
+			"interface":  pd.Intf,
+			"media":      pd.Med,
+			"model":      strings.TrimSpace(pd.Model),
+		}
+		if enclosure != "" {
+			tags["enclosure"] = enclosure
+		}
+		if dg := toString(pd.DG); dg != "" && dg != "-" {
+			tags["drive_group"] = dg
+		}
+		fields := map[string]interface{}{
+			"state":  pd.State,
+			"online": pd.State == "Onln",
+		}
+		if size, ok := parseSize(pd.Size); ok {
+			fields["size_bytes"] = size
+		}
+		if progress, ok := rebuilds[driveID(id, enclosure, slot)]; ok {
+			fields["rebuild_progress"] = progress
+		}
+		acc.AddFields("storcli_physical_drive", fields, tags)
+	}
+
+	gatherBatteries(id, "bbu", data.BBU, acc)
+	gatherBatteries(id, "cachevault", data.Cachevault, acc)
+}
+
+func gatherBatteries(id, batteryType string, batteries []battery, acc telegraf.Accumulator) {
+	for _, b := range batteries {
+		tags := map[string]string{
+			"controller": id,
+			"type":       batteryType,
+			"model":      b.Model,
+		}
+		fields := map[string]interface{}{
+			"state":   b.State,
+			"optimal": b.State == "Optimal",
+		}
+		if temp, err := strconv.ParseInt(strings.TrimSuffix(b.Temp, "C"), 10, 64); err == nil {
+			fields["temperature_c"] = temp
+		}
+		acc.AddFields("storcli_bbu", fields, tags)
+	}
+}
+
+// Wrap with sudo
+var runCmd = func(timeout internal.Duration, sudo bool, command string, args ...string) ([]byte, error) {
+	cmd := exec.Command(command, args...)
+	if sudo {
+		cmd = exec.Command("sudo", append([]string{"-n", command}, args...)...)
+	}
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err := internal.RunTimeout(cmd, timeout.Duration)
+	return stdout.Bytes(), err
+}
+
+// run executes storcli and decodes the JSON output.  storcli exits with a
+// non-zero status if a command failed for any controller, so the output is
+// decoded regardless of the exit status.
+func (s *Storcli) run(v interface{}, args ...string) error {
+	out, err := runCmd(s.Timeout, s.UseSudo, s.Path, args...)
+	if jsonErr := json.Unmarshal(out, v); jsonErr != nil {
+		if err != nil {
+			return fmt.Errorf("failed to run command '%s %s': %v", s.Path, strings.Join(args, " "), err)
+		}
+		return fmt.Errorf("parsing output of '%s %s': %v", s.Path, strings.Join(args, " "), jsonErr)
+	}
+	return nil
+}
+
+// splitEIDSlot splits the enclosure and slot such as "252:1"; the enclosure
+// is empty for drives attached directly to the controller.
+func splitEIDSlot(s string) (string, string) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return "", strings.TrimSpace(s)
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+}
+
+// driveID returns the drive identifier used in the rebuild status.
+func driveID(controller, enclosure, slot string) string {
+	if enclosure == "" {
+		return fmt.Sprintf("/c%s/s%s", controller, slot)
+	}
+	return fmt.Sprintf("/c%s/e%s/s%s", controller, enclosure, slot)
+}
+
+var sizeUnits = map[string]float64{
+	"B":  1,
+	"KB": 1 << 10,
+	"MB": 1 << 20,
+	"GB": 1 << 30,
+	"TB": 1 << 40,
+	"PB": 1 << 50,
+}
+
+// parseSize parses sizes such as "558.375 GB" into bytes.
+func parseSize(s string) (int64, bool) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return 0, false
+	}
+	unit, ok := sizeUnits[fields[1]]
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, false
+	}
+	return int64(v * unit), true
+}
+
+// toString formats numbers as reported by storcli, which are decoded as
+// floats.
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	}
+	return ""
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func init() {
+	inputs.Add("storcli", func() telegraf.Input {
+		return &Storcli{
+			Timeout: internal.Duration{Duration: 30 * time.Second},
+		}
+	})
+}
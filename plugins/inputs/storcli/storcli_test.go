@@ -0,0 +1,207 @@
+package storcli
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const showAll = `{
+"Controllers":[
+{
+	"Command Status" : {
+		"CLI Version" : "007.1017.0000.0000 May 10, 2019",
+		"Operating system" : "Linux 4.18.0",
+		"Controller" : 0,
+		"Status" : "Success",
+		"Description" : "None"
+	},
+	"Response Data" : {
+		"Basics" : {
+			"Controller" : 0,
+			"Model" : "AVAGO MegaRAID SAS 9361-8i",
+			"Serial Number" : "SK12345678"
+		},
+		"Status" : {
+			"Controller Status" : "Needs Attention",
+			"Memory Correctable Errors" : 0,
+			"Memory Uncorrectable Errors" : 0
+		},
+		"Virtual Drives" : 1,
+		"VD LIST" : [
+			{"DG/VD" : "0/0", "TYPE" : "RAID1", "State" : "Dgrd", "Access" : "RW", "Consist" : "No", "Cache" : "RWBD", "Cac" : "-", "sCC" : "ON", "Size" : "558.375 GB", "Name" : "os"}
+		],
+		"Physical Drives" : 2,
+		"PD LIST" : [
+			{"EID:Slt" : "252:0", "DID" : 8, "State" : "Onln", "DG" : 0, "Size" : "558.375 GB", "Intf" : "SAS", "Med" : "HDD", "SED" : "N", "PI" : "N", "SeSz" : "512B", "Model" : "ST600MM0208     ", "Sp" : "U", "Type" : "-"},
+			{"EID:Slt" : "252:1", "DID" : 9, "State" : "Rbld", "DG" : 0, "Size" : "558.375 GB", "Intf" : "SAS", "Med" : "HDD", "SED" : "N", "PI" : "N", "SeSz" : "512B", "Model" : "ST600MM0208     ", "Sp" : "U", "Type" : "-"}
+		],
+		"Cachevault_Info" : [
+			{"Model" : "CVPM02", "State" : "Optimal", "Temp" : "28C", "Mode" : "-", "MfgDate" : "2019/03/18"}
+		]
+	}
+},
+{
+	"Command Status" : {
+		"Controller" : 1,
+		"Status" : "Failure",
+		"Description" : "Controller 1 not found"
+	}
+}
+]
+}`
+
+const showRebuild = `{
+"Controllers":[
+{
+	"Command Status" : {
+		"Controller" : 0,
+		"Status" : "Success",
+		"Description" : "Show Drive Rebuild Status Succeeded."
+	},
+	"Response Data" : [
+		{"Drive-ID" : "/c0/e252/s0", "Progress%" : "-", "Status" : "Not in progress", "Estimited Time Left" : "-"},
+		{"Drive-ID" : "/c0/e252/s1", "Progress%" : 45, "Status" : "In progress", "Estimited Time Left" : "1 Hours 2 Minutes"}
+	]
+}
+]
+}`
+
+func TestGather(t *testing.T) {
+	runCmd = func(timeout internal.Duration, sudo bool, command string, args ...string) ([]byte, error) {
+		switch strings.Join(args, " ") {
+		case "/call show all J":
+			return []byte(showAll), errors.New("exit status 6")
+		case "/call/eall/sall show rebuild J":
+			return []byte(showRebuild), nil
+		}
+		return nil, errors.New("unexpected command")
+	}
+
+	plugin := &Storcli{Path: "storcli64", Log: testutil.Logger{}}
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "Controller 1 not found")
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("storcli_controller",
+			map[string]string{
+				"controller": "0",
+				"model":      "AVAGO MegaRAID SAS 9361-8i",
+				"serial":     "SK12345678",
+			},
+			map[string]interface{}{
+				"status":                      "Needs Attention",
+				"optimal":                     false,
+				"memory_correctable_errors":   int64(0),
+				"memory_uncorrectable_errors": int64(0),
+				"virtual_drives":              int64(1),
+				"physical_drives":             int64(2),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("storcli_virtual_drive",
+			map[string]string{
+				"controller":    "0",
+				"virtual_drive": "0/0",
+				"raid_level":    "RAID1",
+				"name":          "os",
+			},
+			map[string]interface{}{
+				"state":      "Dgrd",
+				"optimal":    false,
+				"size_bytes": int64(599550590976),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("storcli_physical_drive",
+			map[string]string{
+				"controller":  "0",
+				"enclosure":   "252",
+				"slot":        "0",
+				"drive_group": "0",
+				"interface":   "SAS",
+				"media":       "HDD",
+				"model":       "ST600MM0208",
+			},
+			map[string]interface{}{
+				"state":      "Onln",
+				"online":     true,
+				"size_bytes": int64(599550590976),
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("storcli_physical_drive",
+			map[string]string{
+				"controller":  "0",
+				"enclosure":   "252",
+				"slot":        "1",
+				"drive_group": "0",
+				"interface":   "SAS",
+				"media":       "HDD",
+				"model":       "ST600MM0208",
+			},
+			map[string]interface{}{
+				"state":            "Rbld",
+				"online":           false,
+				"size_bytes":       int64(599550590976),
+				"rebuild_progress": 45.0,
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("storcli_bbu",
+			map[string]string{
+				"controller": "0",
+				"type":       "cachevault",
+				"model":      "CVPM02",
+			},
+			map[string]interface{}{
+				"state":         "Optimal",
+				"optimal":       true,
+				"temperature_c": int64(28),
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestGatherCommandFailed(t *testing.T) {
+	runCmd = func(timeout internal.Duration, sudo bool, command string, args ...string) ([]byte, error) {
+		return []byte("sudo: a password is required\n"), errors.New("exit status 1")
+	}
+
+	plugin := &Storcli{Path: "storcli64", UseSudo: true, Log: testutil.Logger{}}
+	var acc testutil.Accumulator
+	err := plugin.Gather(&acc)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "exit status 1")
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		ok       bool
+	}{
+		{input: "558.375 GB", expected: 599550590976, ok: true},
+		{input: "1.745 TB", expected: 1918647790469, ok: true},
+		{input: "512 B", expected: 512, ok: true},
+		{input: "-", ok: false},
+		{input: "10 XB", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			size, ok := parseSize(tt.input)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.expected, size)
+		})
+	}
+}
@@ -415,3 +415,72 @@ func TestTimeIncrement_udp(t *testing.T) {
 	}
 	testutil.RequireMetricsEqual(t, want, acc.GetTelegrafMetrics())
 }
+
+func TestRateLimit_udp(t *testing.T) {
+	receiver := newUDPSyslogReceiver("udp://127.0.0.1:0", false)
+	receiver.RateLimit = 0.1
+	acc := &testutil.Accumulator{}
+	require.NoError(t, receiver.Start(acc))
+	defer receiver.Stop()
+
+	dial := func(ip string) net.Conn {
+		conn, err := net.DialUDP("udp", &net.UDPAddr{IP: net.ParseIP(ip)}, receiver.udpListener.LocalAddr().(*net.UDPAddr))
+		require.NoError(t, err)
+		return conn
+	}
+	first := dial("127.0.0.1")
+	defer first.Close()
+	second := dial("127.0.0.2")
+	defer second.Close()
+
+	// The second message of the first client is over its limit.
+	for _, write := range []struct {
+		conn net.Conn
+		data string
+	}{
+		{first, "<1>1 - - - - - - first"},
+		{first, "<1>1 - - - - - - dropped"},
+		{second, "<1>1 - - - - - - second"},
+	} {
+		_, err := write.conn.Write([]byte(write.data))
+		require.NoError(t, err)
+	}
+
+	acc.Wait(2)
+	var messages []interface{}
+	for _, m := range acc.GetTelegrafMetrics() {
+		messages = append(messages, m.Fields()["message"])
+	}
+	require.Equal(t, []interface{}{"first", "second"}, messages)
+}
+
+func TestSockets_udp(t *testing.T) {
+	receiver := newUDPSyslogReceiver("udp://127.0.0.1:0", false)
+	receiver.UDPSockets = 4
+	acc := &testutil.Accumulator{}
+	require.NoError(t, receiver.Start(acc))
+	defer receiver.Stop()
+	require.Len(t, receiver.Closer, 4)
+
+	// The messages of each client are read by one of the sockets.
+	for i := 0; i < 16; i++ {
+		conn, err := net.Dial("udp", receiver.udpListener.LocalAddr().String())
+		require.NoError(t, err)
+		_, err = conn.Write([]byte("<1>1 - - - - - -"))
+		require.NoError(t, err)
+		conn.Close()
+	}
+
+	acc.Wait(16)
+	require.Equal(t, uint64(16), acc.NMetrics())
+}
+
+func TestSockets_unixgram(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "telegraf")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	receiver := newUDPSyslogReceiver("unixgram://"+filepath.Join(tmpdir, "syslog.TestSockets_unixgram.sock"), false)
+	receiver.UDPSockets = 2
+	require.Error(t, receiver.Start(&testutil.Accumulator{}))
+}
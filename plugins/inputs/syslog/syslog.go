@@ -10,7 +10,6 @@ import (
 	"strings"
 	"sync"
 	"time"
-	"unicode"
 
 	"github.com/influxdata/go-syslog/v2"
 	"github.com/influxdata/go-syslog/v2/nontransparent"
@@ -20,7 +19,10 @@ import (
 	"github.com/influxdata/telegraf/internal"
 	framing "github.com/influxdata/telegraf/internal/syslog"
 	tlsConfig "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/common/ratelimit"
+	"github.com/influxdata/telegraf/plugins/common/reuseport"
 	"github.com/influxdata/telegraf/plugins/inputs"
+	syslogparser "github.com/influxdata/telegraf/plugins/parsers/syslog"
 )
 
 const defaultReadTimeout = time.Second * 5
@@ -37,9 +39,13 @@ type Syslog struct {
 	Trailer         nontransparent.TrailerType
 	BestEffort      bool
 	Separator       string `toml:"sdparam_separator"`
+	UDPSockets      int    `toml:"udp_sockets"`
+	ratelimit.LimiterConfig
 
 	now      func() time.Time
 	lastTime time.Time
+	timeMu   sync.Mutex
+	limiter  *ratelimit.Limiter
 
 	mu sync.Mutex
 	wg sync.WaitGroup
@@ -101,6 +107,19 @@ var sampleConfig = `
   ## For each combination a field is created.
   ## Its name is created concatenating identifier, sdparam_separator, and parameter name.
   # sdparam_separator = "_"
+
+  ## Maximum number of messages per second accepted from each client IP, the
+  ## messages over the limit are dropped.  The burst is the number of messages
+  ## accepted at once, by default one second worth of the rate.  Clients of
+  ## unix sockets share a single limit.  0 means no limit.
+  # rate_limit = 0.0
+  # rate_limit_burst = 0
+
+  ## Number of UDP sockets opened on the address with SO_REUSEPORT, each read
+  ## by its own goroutine, the kernel spreads the messages across the
+  ## sockets.  Only applies to UDP, on platforms supporting SO_REUSEPORT such
+  ## as Linux and BSD.
+  # udp_sockets = 1
 `
 
 // SampleConfig returns sample configuration message
@@ -127,6 +146,17 @@ func (s *Syslog) Start(acc telegraf.Accumulator) error {
 	if err != nil {
 		return err
 	}
+
+	if s.RateLimitKey != "" && s.RateLimitKey != "ip" {
+		return fmt.Errorf("invalid rate_limit_key %q, only \"ip\" is supported", s.RateLimitKey)
+	}
+	tags := map[string]string{
+		"address": s.Address,
+	}
+	s.limiter, err = s.LimiterConfig.Limiter("syslog", "messages_rate_limited", tags)
+	if err != nil {
+		return err
+	}
 	s.Address = host
 
 	switch scheme {
@@ -157,15 +187,28 @@ func (s *Syslog) Start(acc telegraf.Accumulator) error {
 		s.wg.Add(1)
 		go s.listenStream(acc)
 	} else {
-		l, err := net.ListenPacket(scheme, s.Address)
+		sockets := s.UDPSockets
+		if sockets < 1 {
+			sockets = 1
+		}
+		if sockets > 1 && !strings.HasPrefix(scheme, "udp") {
+			return fmt.Errorf("udp_sockets only applies to UDP sockets")
+		}
+		conns, err := reuseport.ListenPacket(scheme, s.Address, sockets)
 		if err != nil {
 			return err
 		}
-		s.Closer = l
-		s.udpListener = l
+		if len(conns) == 1 {
+			s.Closer = conns[0]
+		} else {
+			s.Closer = packetConns(conns)
+		}
+		s.udpListener = conns[0]
 
-		s.wg.Add(1)
-		go s.listenPacket(acc)
+		for _, conn := range conns {
+			s.wg.Add(1)
+			go s.listenPacket(conn, acc)
+		}
 	}
 
 	if scheme == "unix" || scheme == "unixpacket" || scheme == "unixgram" {
@@ -215,7 +258,7 @@ func getAddressParts(a string) (string, string, error) {
 	return u.Scheme, host, nil
 }
 
-func (s *Syslog) listenPacket(acc telegraf.Accumulator) {
+func (s *Syslog) listenPacket(conn net.PacketConn, acc telegraf.Accumulator) {
 	defer s.wg.Done()
 	b := make([]byte, ipMaxPacketSize)
 	var p syslog.Machine
@@ -225,17 +268,20 @@ func (s *Syslog) listenPacket(acc telegraf.Accumulator) {
 		p = rfc5424.NewParser()
 	}
 	for {
-		n, _, err := s.udpListener.ReadFrom(b)
+		n, addr, err := conn.ReadFrom(b)
 		if err != nil {
 			if !strings.HasSuffix(err.Error(), ": use of closed network connection") {
 				acc.AddError(err)
 			}
 			break
 		}
+		if !s.limiter.Allow(source(addr), 1) {
+			continue
+		}
 
 		message, err := p.Parse(b[:n])
 		if message != nil {
-			acc.AddFields("syslog", fields(message, s), tags(message), s.time())
+			acc.AddFields("syslog", syslogparser.Fields(message, s.Separator), syslogparser.Tags(message), s.time())
 		}
 		if err != nil {
 			acc.AddError(err)
@@ -298,8 +344,11 @@ func (s *Syslog) handle(conn net.Conn, acc telegraf.Accumulator) {
 
 	var p syslog.Parser
 
+	src := source(conn.RemoteAddr())
 	emit := func(r *syslog.Result) {
-		s.store(*r, acc)
+		if r.Message == nil || s.limiter.Allow(src, 1) {
+			s.store(*r, acc)
+		}
 		if s.ReadTimeout != nil && s.ReadTimeout.Duration > 0 {
 			conn.SetReadDeadline(time.Now().Add(s.ReadTimeout.Duration))
 		}
@@ -349,69 +398,29 @@ func (s *Syslog) store(res syslog.Result, acc telegraf.Accumulator) {
 		acc.AddError(res.Error)
 	}
 	if res.Message != nil {
-		acc.AddFields("syslog", fields(res.Message, s), tags(res.Message), s.time())
+		acc.AddFields("syslog", syslogparser.Fields(res.Message, s.Separator), syslogparser.Tags(res.Message), s.time())
 	}
 }
 
-func tags(msg syslog.Message) map[string]string {
-	ts := map[string]string{}
-
-	// Not checking assuming a minimally valid message
-	ts["severity"] = *msg.SeverityShortLevel()
-	ts["facility"] = *msg.FacilityLevel()
-
-	if msg.Hostname() != nil {
-		ts["hostname"] = *msg.Hostname()
+// source returns the source the messages of a client are limited as.
+func source(addr net.Addr) string {
+	if addr == nil {
+		return ""
 	}
-
-	if msg.Appname() != nil {
-		ts["appname"] = *msg.Appname()
-	}
-
-	return ts
+	return ratelimit.AddrSource(addr.String())
 }
 
-func fields(msg syslog.Message, s *Syslog) map[string]interface{} {
-	// Not checking assuming a minimally valid message
-	flds := map[string]interface{}{
-		"version": msg.Version(),
-	}
-	flds["severity_code"] = int(*msg.Severity())
-	flds["facility_code"] = int(*msg.Facility())
+// packetConns are the sockets opened with SO_REUSEPORT.
+type packetConns []net.PacketConn
 
-	if msg.Timestamp() != nil {
-		flds["timestamp"] = (*msg.Timestamp()).UnixNano()
-	}
-
-	if msg.ProcID() != nil {
-		flds["procid"] = *msg.ProcID()
-	}
-
-	if msg.MsgID() != nil {
-		flds["msgid"] = *msg.MsgID()
-	}
-
-	if msg.Message() != nil {
-		flds["message"] = strings.TrimRightFunc(*msg.Message(), func(r rune) bool {
-			return unicode.IsSpace(r)
-		})
-	}
-
-	if msg.StructuredData() != nil {
-		for sdid, sdparams := range *msg.StructuredData() {
-			if len(sdparams) == 0 {
-				// When SD-ID does not have params we indicate its presence with a bool
-				flds[sdid] = true
-				continue
-			}
-			for name, value := range sdparams {
-				// Using whitespace as separator since it is not allowed by the grammar within SDID
-				flds[sdid+s.Separator+name] = value
-			}
+func (pc packetConns) Close() error {
+	var err error
+	for _, c := range pc {
+		if cerr := c.Close(); cerr != nil {
+			err = cerr
 		}
 	}
-
-	return flds
+	return err
 }
 
 type unixCloser struct {
@@ -426,6 +435,9 @@ func (uc unixCloser) Close() error {
 }
 
 func (s *Syslog) time() time.Time {
+	s.timeMu.Lock()
+	defer s.timeMu.Unlock()
+
 	t := s.now()
 	if t == s.lastTime {
 		t = t.Add(time.Nanosecond)
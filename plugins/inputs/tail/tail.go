@@ -11,6 +11,7 @@ import (
 	"github.com/influxdata/tail"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/plugins/common/encoding"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/plugins/parsers/csv"
@@ -35,6 +36,7 @@ type Tail struct {
 	Pipe                bool     `toml:"pipe"`
 	WatchMethod         string   `toml:"watch_method"`
 	MaxUndeliveredLines int      `toml:"max_undelivered_lines"`
+	CharacterEncoding   string   `toml:"character_encoding"`
 
 	Log        telegraf.Logger `toml:"-"`
 	tailers    map[string]*tail.Tail
@@ -45,6 +47,7 @@ type Tail struct {
 	cancel     context.CancelFunc
 	acc        telegraf.TrackingAccumulator
 	sem        semaphore
+	decoder    *encoding.Decoder
 }
 
 func NewTail() *Tail {
@@ -88,6 +91,13 @@ const sampleConfig = `
   ## line and the size of the output's metric_batch_size.
   # max_undelivered_lines = 1000
 
+  ## Character encoding of the files, decoded to UTF-8 before parsing.  One of
+  ## "utf-8", "utf-16le", "utf-16be", "utf-16" detecting the byte order mark,
+  ## "iso-8859-1" or "windows-1252".  When empty the files are not decoded.
+  ## The byte order mark is only read with from_beginning, set the endianness
+  ## of UTF-16 files otherwise.
+  # character_encoding = ""
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -108,7 +118,10 @@ func (t *Tail) Init() error {
 		return errors.New("max_undelivered_lines must be positive")
 	}
 	t.sem = make(semaphore, t.MaxUndeliveredLines)
-	return nil
+
+	var err error
+	t.decoder, err = encoding.NewDecoder(t.CharacterEncoding)
+	return err
 }
 
 func (t *Tail) Gather(acc telegraf.Accumulator) error {
@@ -221,50 +234,62 @@ func (t *Tail) tailNewFiles(fromBeginning bool) error {
 	return nil
 }
 
-// ParseLine parses a line of text.
-func parseLine(parser parsers.Parser, line string, firstLine bool) ([]telegraf.Metric, error) {
-	switch parser.(type) {
-	case *csv.Parser:
-		// The csv parser parses headers in Parse and skips them in ParseLine.
-		// As a temporary solution call Parse only when getting the first
-		// line from the file.
-		if firstLine {
-			return parser.Parse([]byte(line))
-		} else {
-			m, err := parser.ParseLine(line)
-			if err != nil {
-				return nil, err
-			}
-
-			if m != nil {
-				return []telegraf.Metric{m}, nil
-			}
-			return []telegraf.Metric{}, nil
-		}
-	default:
+// parseLine parses a line of text.  The lines of a file parsed as CSV are
+// parsed by the line parser of the file, which reads its header.
+func parseLine(parser parsers.Parser, csvLines *csv.LineParser, line string) ([]telegraf.Metric, error) {
+	if csvLines == nil {
 		return parser.Parse([]byte(line))
 	}
+
+	m, err := csvLines.ParseLine(line)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil {
+		return []telegraf.Metric{m}, nil
+	}
+	return []telegraf.Metric{}, nil
 }
 
 // Receiver is launched as a goroutine to continuously watch a tailed logfile
 // for changes, parse any incoming msgs, and add to the accumulator.
 func (t *Tail) receiver(parser parsers.Parser, tailer *tail.Tail) {
-	var firstLine = true
+	var csvLines *csv.LineParser
+	if p, ok := parser.(*csv.Parser); ok {
+		csvLines = p.NewLineParser()
+	}
+	var decoder *encoding.LineDecoder
+	if t.CharacterEncoding != "" {
+		decoder = t.decoder.NewLineDecoder()
+	}
 	for line := range tailer.Lines {
 		if line.Err != nil {
 			t.Log.Errorf("Tailing %q: %s", tailer.Filename, line.Err.Error())
 			continue
 		}
+
+		text := line.Text
+		if decoder != nil {
+			var ok bool
+			var err error
+			text, ok, err = decoder.Decode(line.Text)
+			if err != nil {
+				t.Log.Errorf("Decoding line in %q: %s", tailer.Filename, err.Error())
+			}
+			if !ok {
+				continue
+			}
+		}
+
 		// Fix up files with Windows line endings.
-		text := strings.TrimRight(line.Text, "\r")
+		text = strings.TrimRight(text, "\r")
 
-		metrics, err := parseLine(parser, text, firstLine)
+		metrics, err := parseLine(parser, csvLines, text)
 		if err != nil {
 			t.Log.Errorf("Malformed log line in %q: [%q]: %s",
 				tailer.Filename, line.Text, err.Error())
 			continue
 		}
-		firstLine = false
 
 		for _, metric := range metrics {
 			metric.AddTag("path", tailer.Filename)
@@ -0,0 +1,370 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/inputs/prometheus"
+)
+
+const sampleConfig = `
+  ## URL of the Vault server.
+  url = "http://127.0.0.1:8200"
+
+  ## Token used in the requests, or file containing it.  The token needs to
+  ## read sys/metrics and to list auth/token/accessors, which requires sudo.
+  # token = ""
+  # token_file = "/path/to/token"
+
+  ## Namespace of the requests, added as the namespace tag of the metrics.
+  # namespace = ""
+
+  ## Renew the token before it expires.
+  # renew_token = false
+
+  ## Timeout of each request.
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+// maxErrorSize is the maximum size of the body of an error response read.
+const maxErrorSize = 4096
+
+type Vault struct {
+	URL        string            `toml:"url"`
+	Token      string            `toml:"token"`
+	TokenFile  string            `toml:"token_file"`
+	Namespace  string            `toml:"namespace"`
+	RenewToken bool              `toml:"renew_token"`
+	Timeout    internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	client *http.Client
+
+	// renewAt is the time of the next renewal of the token, which is not
+	// renewed anymore once noRenew is set.
+	renewAt time.Time
+	noRenew bool
+}
+
+type sealStatus struct {
+	Type        string `json:"type"`
+	Initialized bool   `json:"initialized"`
+	Sealed      bool   `json:"sealed"`
+	Threshold   int    `json:"t"`
+	Shares      int    `json:"n"`
+	Progress    int    `json:"progress"`
+	Version     string `json:"version"`
+	ClusterName string `json:"cluster_name"`
+}
+
+type replicationStatus struct {
+	Data map[string]struct {
+		Mode  string `json:"mode"`
+		State string `json:"state"`
+	} `json:"data"`
+}
+
+type listResponse struct {
+	Data struct {
+		Keys []string `json:"keys"`
+	} `json:"data"`
+}
+
+type renewResponse struct {
+	Auth struct {
+		LeaseDuration int  `json:"lease_duration"`
+		Renewable     bool `json:"renewable"`
+	} `json:"auth"`
+}
+
+// statusError is the error of a request answered with an unexpected status.
+type statusError struct {
+	path   string
+	status int
+	text   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s returned %d: %s", e.path, e.status, e.text)
+}
+
+func (v *Vault) Description() string {
+	return "Gather the telemetry, seal status and replication mode of HashiCorp Vault"
+}
+
+func (v *Vault) SampleConfig() string {
+	return sampleConfig
+}
+
+func (v *Vault) Init() error {
+	if v.URL == "" {
+		return errors.New("url must be set")
+	}
+	v.URL = strings.TrimSuffix(v.URL, "/")
+
+	if v.Token != "" && v.TokenFile != "" {
+		return errors.New("only one of token and token_file can be set")
+	}
+	if v.TokenFile != "" {
+		token, err := ioutil.ReadFile(v.TokenFile)
+		if err != nil {
+			return err
+		}
+		v.Token = strings.TrimSpace(string(token))
+	}
+
+	tlsConfig, err := v.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	v.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+		Timeout: v.Timeout.Duration,
+	}
+	return nil
+}
+
+// Gather adds the seal status, and the telemetry, token and lease counts and
+// replication mode of an unsealed server.
+func (v *Vault) Gather(acc telegraf.Accumulator) error {
+	if v.RenewToken {
+		if err := v.renewToken(); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	var status sealStatus
+	if err := v.get("/v1/sys/seal-status", &status); err != nil {
+		return err
+	}
+	fields := map[string]interface{}{
+		"initialized": status.Initialized,
+		"sealed":      status.Sealed,
+		"threshold":   status.Threshold,
+		"shares":      status.Shares,
+		"progress":    status.Progress,
+	}
+	tags := v.tags(map[string]string{
+		"cluster_name": status.ClusterName,
+		"seal_type":    status.Type,
+		"version":      status.Version,
+	})
+	acc.AddFields("vault_seal", fields, tags)
+
+	// The other endpoints are not available on a sealed server.
+	if status.Sealed || !status.Initialized {
+		return nil
+	}
+
+	leases, err := v.gatherTelemetry(acc)
+	if err != nil {
+		acc.AddError(fmt.Errorf("gathering telemetry: %v", err))
+	}
+	if err := v.gatherUsage(acc, leases); err != nil {
+		acc.AddError(fmt.Errorf("counting tokens: %v", err))
+	}
+	if err := v.gatherReplication(acc); err != nil {
+		acc.AddError(fmt.Errorf("gathering replication status: %v", err))
+	}
+	return nil
+}
+
+// gatherTelemetry adds the metrics of sys/metrics and returns the number of
+// leases they report, which is nil when missing.
+func (v *Vault) gatherTelemetry(acc telegraf.Accumulator) (interface{}, error) {
+	resp, err := v.do("GET", "/v1/sys/metrics?format=prometheus")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := prometheus.Parse(body, resp.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	var leases interface{}
+	for _, m := range metrics {
+		if v.Namespace != "" && !m.HasTag("namespace") {
+			m.AddTag("namespace", v.Namespace)
+		}
+		if m.Name() == "vault_expire_num_leases" {
+			leases, _ = m.GetField("gauge")
+		}
+		acc.AddMetric(m)
+	}
+	return leases, nil
+}
+
+// gatherUsage adds the number of tokens of the namespace and the number of
+// leases reported by the telemetry.
+func (v *Vault) gatherUsage(acc telegraf.Accumulator, leases interface{}) error {
+	var accessors listResponse
+	err := v.get("/v1/auth/token/accessors?list=true", &accessors)
+	// No token is listed as not found.
+	if e, ok := err.(*statusError); ok && e.status == http.StatusNotFound {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]interface{}{
+		"tokens": len(accessors.Data.Keys),
+	}
+	if leases != nil {
+		fields["leases"] = leases
+	}
+	acc.AddFields("vault_usage", fields, v.tags(nil))
+	return nil
+}
+
+func (v *Vault) gatherReplication(acc telegraf.Accumulator) error {
+	var status replicationStatus
+	err := v.get("/v1/sys/replication/status", &status)
+	// The open source version has no replication.
+	if e, ok := err.(*statusError); ok && e.status == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for name, replication := range status.Data {
+		fields := map[string]interface{}{
+			"mode":    replication.Mode,
+			"enabled": replication.Mode != "" && replication.Mode != "disabled",
+		}
+		if replication.State != "" {
+			fields["state"] = replication.State
+		}
+		acc.AddFields("vault_replication", fields, v.tags(map[string]string{"type": name}))
+	}
+	return nil
+}
+
+// renewToken renews the token once half of its time to live has elapsed
+// since the previous renewal.
+func (v *Vault) renewToken() error {
+	if v.Token == "" || v.noRenew || time.Now().Before(v.renewAt) {
+		return nil
+	}
+
+	var resp renewResponse
+	if err := v.post("/v1/auth/token/renew-self", &resp); err != nil {
+		return fmt.Errorf("renewing token: %v", err)
+	}
+	if !resp.Auth.Renewable || resp.Auth.LeaseDuration <= 0 {
+		v.Log.Debug("Token does not expire or is not renewable, it is not renewed anymore")
+		v.noRenew = true
+		return nil
+	}
+
+	ttl := time.Duration(resp.Auth.LeaseDuration) * time.Second
+	v.renewAt = time.Now().Add(ttl / 2)
+	v.Log.Debugf("Renewed token, valid for %s", ttl)
+	return nil
+}
+
+// tags returns the tags with the namespace of the plugin.
+func (v *Vault) tags(tags map[string]string) map[string]string {
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+	if v.Namespace != "" {
+		tags["namespace"] = v.Namespace
+	}
+	return tags
+}
+
+func (v *Vault) get(path string, out interface{}) error {
+	return v.decode("GET", path, out)
+}
+
+func (v *Vault) post(path string, out interface{}) error {
+	return v.decode("POST", path, out)
+}
+
+// decode sends the request and decodes the response into out.
+func (v *Vault) decode(method, path string, out interface{}) error {
+	resp, err := v.do(method, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// do sends the request and returns the response, whose body must be closed,
+// or a statusError when its status is not 200.
+func (v *Vault) do(method, path string) (*http.Response, error) {
+	req, err := http.NewRequest(method, v.URL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if v.Token != "" {
+		req.Header.Set("X-Vault-Token", v.Token)
+	}
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, &statusError{path: path, status: resp.StatusCode, text: readErrors(resp.Body)}
+	}
+	return resp, nil
+}
+
+// readErrors returns the errors of a Vault error response, or its body when
+// it has none.
+func readErrors(r io.Reader) string {
+	body, _ := ioutil.ReadAll(io.LimitReader(r, maxErrorSize))
+	var resp struct {
+		Errors []string `json:"errors"`
+	}
+	if json.Unmarshal(body, &resp) == nil && len(resp.Errors) > 0 {
+		return strings.Join(resp.Errors, ", ")
+	}
+	return strings.TrimSpace(string(body))
+}
+
+func init() {
+	inputs.Add("vault", func() telegraf.Input {
+		return &Vault{
+			URL:     "http://127.0.0.1:8200",
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,219 @@
+package vault
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const telemetry = `# HELP vault_core_unsealed vault_core_unsealed
+# TYPE vault_core_unsealed gauge
+vault_core_unsealed{cluster="vault-cluster-1"} 1
+# HELP vault_expire_num_leases vault_expire_num_leases
+# TYPE vault_expire_num_leases gauge
+vault_expire_num_leases 12
+# HELP vault_token_count vault_token_count
+# TYPE vault_token_count gauge
+vault_token_count{namespace="root"} 3
+`
+
+// vaultServer is a Vault server requiring the token and namespace of the
+// plugin.
+type vaultServer struct {
+	*httptest.Server
+
+	sealed      bool
+	replication bool
+	renewals    int32
+}
+
+func newVaultServer(t *testing.T) *vaultServer {
+	s := &vaultServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/sys/seal-status" {
+			fmt.Fprintf(w, `{"type":"shamir","initialized":true,"sealed":%t,"t":3,"n":5,"progress":0,`+
+				`"version":"1.6.0","cluster_name":"vault-cluster-1","cluster_id":"a1b2"}`, s.sealed)
+			return
+		}
+		if s.sealed {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"errors":["Vault is sealed"]}`)
+			return
+		}
+		if r.Header.Get("X-Vault-Token") != "s.token" || r.Header.Get("X-Vault-Namespace") != "team" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"errors":["permission denied"]}`)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/sys/metrics":
+			require.Equal(t, "prometheus", r.URL.Query().Get("format"))
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+			fmt.Fprint(w, telemetry)
+		case "/v1/auth/token/accessors":
+			require.Equal(t, "true", r.URL.Query().Get("list"))
+			fmt.Fprint(w, `{"data":{"keys":["acc1","acc2","acc3"]}}`)
+		case "/v1/sys/replication/status":
+			if !s.replication {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"errors":[]}`)
+				return
+			}
+			fmt.Fprint(w, `{"data":{"dr":{"mode":"disabled"},"performance":{"mode":"primary","state":"running"}}}`)
+		case "/v1/auth/token/renew-self":
+			require.Equal(t, "POST", r.Method)
+			atomic.AddInt32(&s.renewals, 1)
+			fmt.Fprint(w, `{"auth":{"client_token":"s.token","lease_duration":3600,"renewable":true}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return s
+}
+
+func newPlugin(url string) *Vault {
+	return &Vault{
+		URL:       url,
+		Token:     "s.token",
+		Namespace: "team",
+		Timeout:   internal.Duration{Duration: 5 * time.Second},
+		Log:       testutil.Logger{},
+	}
+}
+
+func TestGather(t *testing.T) {
+	ts := newVaultServer(t)
+	ts.replication = true
+	defer ts.Close()
+
+	plugin := newPlugin(ts.URL)
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "vault_seal",
+		map[string]interface{}{
+			"initialized": true,
+			"sealed":      false,
+			"threshold":   3,
+			"shares":      5,
+			"progress":    0,
+		},
+		map[string]string{
+			"cluster_name": "vault-cluster-1",
+			"seal_type":    "shamir",
+			"version":      "1.6.0",
+			"namespace":    "team",
+		})
+	acc.AssertContainsTaggedFields(t, "vault_core_unsealed",
+		map[string]interface{}{"gauge": 1.0},
+		map[string]string{"cluster": "vault-cluster-1", "namespace": "team"})
+	// The namespace of the telemetry is kept.
+	acc.AssertContainsTaggedFields(t, "vault_token_count",
+		map[string]interface{}{"gauge": 3.0},
+		map[string]string{"namespace": "root"})
+	acc.AssertContainsTaggedFields(t, "vault_usage",
+		map[string]interface{}{"tokens": 3, "leases": 12.0},
+		map[string]string{"namespace": "team"})
+	acc.AssertContainsTaggedFields(t, "vault_replication",
+		map[string]interface{}{"mode": "disabled", "enabled": false},
+		map[string]string{"type": "dr", "namespace": "team"})
+	acc.AssertContainsTaggedFields(t, "vault_replication",
+		map[string]interface{}{"mode": "primary", "enabled": true, "state": "running"},
+		map[string]string{"type": "performance", "namespace": "team"})
+}
+
+func TestGatherWithoutReplication(t *testing.T) {
+	ts := newVaultServer(t)
+	defer ts.Close()
+
+	plugin := newPlugin(ts.URL)
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+	require.True(t, acc.HasMeasurement("vault_usage"))
+	require.False(t, acc.HasMeasurement("vault_replication"))
+}
+
+func TestGatherSealed(t *testing.T) {
+	ts := newVaultServer(t)
+	ts.sealed = true
+	defer ts.Close()
+
+	plugin := newPlugin(ts.URL)
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+	require.Len(t, acc.GetTelegrafMetrics(), 1)
+
+	fields, ok := acc.Get("vault_seal")
+	require.True(t, ok)
+	require.Equal(t, true, fields.Fields["sealed"])
+}
+
+func TestGatherPermissionDenied(t *testing.T) {
+	ts := newVaultServer(t)
+	defer ts.Close()
+
+	plugin := newPlugin(ts.URL)
+	plugin.Token = "s.other"
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 3)
+	require.Contains(t, acc.Errors[0].Error(), "permission denied")
+	require.True(t, acc.HasMeasurement("vault_seal"))
+}
+
+func TestRenewToken(t *testing.T) {
+	ts := newVaultServer(t)
+	defer ts.Close()
+
+	plugin := newPlugin(ts.URL)
+	plugin.RenewToken = true
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	// The token is renewed again after half of its time to live.
+	require.Equal(t, int32(1), atomic.LoadInt32(&ts.renewals))
+	require.WithinDuration(t, time.Now().Add(30*time.Minute), plugin.renewAt, time.Minute)
+}
+
+func TestTokenFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "vault-token")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("s.token\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	plugin := newPlugin("http://127.0.0.1:8200")
+	plugin.Token = ""
+	plugin.TokenFile = f.Name()
+	require.NoError(t, plugin.Init())
+	require.Equal(t, "s.token", plugin.Token)
+
+	plugin.Token = "s.token"
+	require.Error(t, plugin.Init())
+}
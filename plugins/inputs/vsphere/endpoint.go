@@ -61,6 +61,10 @@ type Endpoint struct {
 	customAttrEnabled bool
 	metricNameLookup  map[int32]string
 	metricNameMux     sync.RWMutex
+	vsanEnabled       bool
+	vsanEntityTypes   []string
+	vsanLatest        map[string]time.Time
+	vsanLastColl      time.Time
 	log               telegraf.Logger
 }
 
@@ -127,8 +131,11 @@ func NewEndpoint(ctx context.Context, parent *VSphere, url *url.URL, log telegra
 		clientFactory:     NewClientFactory(ctx, url, parent),
 		customAttrFilter:  newFilterOrPanic(parent.CustomAttributeInclude, parent.CustomAttributeExclude),
 		customAttrEnabled: anythingEnabled(parent.CustomAttributeExclude),
+		vsanEntityTypes:   vsanEntities(parent.VSANMetricInclude, parent.VSANMetricExclude),
+		vsanLatest:        make(map[string]time.Time),
 		log:               log,
 	}
+	e.vsanEnabled = len(e.vsanEntityTypes) > 0
 
 	e.resourceKinds = map[string]*resourceKind{
 		"datacenter": {
@@ -453,6 +460,9 @@ func (e *Endpoint) discover(ctx context.Context) error {
 
 				SendInternalCounterWithTags("discovered_objects", e.URL.Host, map[string]string{"type": res.name}, int64(len(objects)))
 				numRes += int64(len(objects))
+			} else if k == "cluster" && e.vsanEnabled {
+				// Clusters are needed to collect vSAN metrics
+				newObjects[k] = objects
 			}
 		}
 		if err != nil {
@@ -639,6 +649,12 @@ func getClusters(ctx context.Context, e *Endpoint, filter *ResourceFilter) (obje
 					cache[r.Parent.Value] = p
 				}
 			}
+			m[r.ExtensibleManagedObject.Reference().Value] = &objectRef{
+				name:         r.Name,
+				ref:          r.ExtensibleManagedObject.Reference(),
+				parentRef:    p,
+				customValues: e.loadCustomAttributes(&r.ManagedEntity),
+			}
 			return nil
 		}()
 		if err != nil {
@@ -850,6 +866,16 @@ func (e *Endpoint) Collect(ctx context.Context, acc telegraf.Accumulator) error
 			}(k)
 		}
 	}
+	if e.vsanEnabled {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := e.collectVsan(ctx, acc)
+			if err != nil {
+				acc.AddError(err)
+			}
+		}()
+	}
 	wg.Wait()
 
 	// Purge old timestamps from the cache
@@ -0,0 +1,277 @@
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/govmomi/vsan"
+	vsantypes "github.com/vmware/govmomi/vsan/types"
+)
+
+// vsanSampling is the interval of the vSAN performance service in seconds.
+const vsanSampling = 300
+
+// vsanTimeFormat is the format of the timestamps in the vSAN performance
+// sample info.
+const vsanTimeFormat = "2006-01-02 15:04:05"
+
+// vsanEntityTypes are the vSAN performance entity types that can be
+// collected.
+var vsanEntityTypes = []string{
+	"cluster-domclient",
+	"cluster-domcompmgr",
+	"host-domclient",
+	"host-domcompmgr",
+	"disk-group",
+	"cache-disk",
+	"capacity-disk",
+	"vsan-host-net",
+	"vsan-vnic-net",
+	"vsan-pnic-net",
+}
+
+// vsanLookup maps the vSAN UUIDs of hosts and disk groups of a cluster to the
+// names of the hosts.
+type vsanLookup struct {
+	nodes      map[string]string
+	diskGroups map[string]string
+}
+
+// vsanEntities returns the entity types selected by the include and exclude
+// filters.
+func vsanEntities(include []string, exclude []string) []string {
+	f := newFilterOrPanic(include, exclude)
+	var result []string
+	for _, entity := range vsanEntityTypes {
+		if f.Match(entity) {
+			result = append(result, entity)
+		}
+	}
+	return result
+}
+
+// collectVsan collects the vSAN performance metrics of all discovered
+// clusters with vSAN enabled.
+func (e *Endpoint) collectVsan(ctx context.Context, acc telegraf.Accumulator) error {
+	localNow := time.Now()
+	if !e.vsanLastColl.IsZero() && localNow.Sub(e.vsanLastColl) < time.Duration(vsanSampling)*time.Second {
+		e.log.Debugf("Sampling period for vSAN of %d has not elapsed on %s", vsanSampling, e.URL.Host)
+		return nil
+	}
+	e.vsanLastColl = localNow
+
+	client, err := e.clientFactory.GetClient(ctx)
+	if err != nil {
+		return err
+	}
+	vsanClient, err := vsan.NewClient(ctx, client.Client.Client)
+	if err != nil {
+		return err
+	}
+	now, err := client.GetServerTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	internalTags := map[string]string{"resourcetype": "vsan"}
+	sw := NewStopwatchWithTags("gather_duration", e.URL.Host, internalTags)
+
+	count := int64(0)
+	for moid, cluster := range e.resourceKinds["cluster"].objects {
+		n, err := e.collectVsanCluster(ctx, client, vsanClient, moid, cluster, now, acc)
+		if err != nil {
+			acc.AddError(fmt.Errorf("while collecting vSAN metrics of %s: %v", cluster.name, err))
+			continue
+		}
+		count += int64(n)
+	}
+
+	sw.Stop()
+	SendInternalCounterWithTags("gather_count", e.URL.Host, internalTags, count)
+	return nil
+}
+
+func (e *Endpoint) collectVsanCluster(ctx context.Context, client *Client, vsanClient *vsan.Client, moid string, cluster *objectRef, now time.Time, acc telegraf.Accumulator) (int, error) {
+	ctx1, cancel1 := context.WithTimeout(ctx, e.Parent.Timeout.Duration)
+	defer cancel1()
+
+	pc := property.DefaultCollector(client.Client.Client)
+	var ccr mo.ClusterComputeResource
+	err := pc.RetrieveOne(ctx1, cluster.ref, []string{"host", "configurationEx"}, &ccr)
+	if err != nil {
+		return 0, err
+	}
+	if cfg, ok := ccr.ConfigurationEx.(*types.ClusterConfigInfoEx); !ok || cfg.VsanConfigInfo == nil ||
+		cfg.VsanConfigInfo.Enabled == nil || !*cfg.VsanConfigInfo.Enabled {
+		e.log.Debugf("vSAN is not enabled on cluster %s", cluster.name)
+		return 0, nil
+	}
+
+	lookup := vsanLookup{
+		nodes:      make(map[string]string),
+		diskGroups: make(map[string]string),
+	}
+	if len(ccr.Host) > 0 {
+		var hosts []mo.HostSystem
+		err = pc.Retrieve(ctx1, ccr.Host, []string{"name", "config.vsanHostConfig"}, &hosts)
+		if err != nil {
+			return 0, err
+		}
+		for _, host := range hosts {
+			if host.Config == nil || host.Config.VsanHostConfig == nil {
+				continue
+			}
+			vsanCfg := host.Config.VsanHostConfig
+			if vsanCfg.ClusterInfo != nil && vsanCfg.ClusterInfo.NodeUuid != "" {
+				lookup.nodes[vsanCfg.ClusterInfo.NodeUuid] = host.Name
+			}
+			if vsanCfg.StorageInfo != nil {
+				for _, mapping := range vsanCfg.StorageInfo.DiskMapping {
+					if mapping.Ssd.VsanDiskInfo != nil {
+						lookup.diskGroups[mapping.Ssd.VsanDiskInfo.VsanUuid] = host.Name
+					}
+				}
+			}
+		}
+	}
+
+	start := now.Add(-time.Duration(vsanSampling) * time.Second)
+	if latest, ok := e.vsanLatest[moid]; ok && latest.After(start.Add(-time.Hour)) {
+		start = latest
+	}
+	specs := make([]vsantypes.VsanPerfQuerySpec, 0, len(e.vsanEntityTypes))
+	for _, entity := range e.vsanEntityTypes {
+		specs = append(specs, vsantypes.VsanPerfQuerySpec{
+			EntityRefId: entity + ":*",
+			StartTime:   &start,
+			EndTime:     &now,
+		})
+	}
+
+	ctx2, cancel2 := context.WithTimeout(ctx, e.Parent.Timeout.Duration)
+	defer cancel2()
+	ref := cluster.ref
+	results, err := vsanClient.VsanPerfQueryPerf(ctx2, &ref, specs)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	latestSample := e.vsanLatest[moid]
+	for _, result := range results {
+		tags := map[string]string{
+			"vcenter":     e.URL.Host,
+			"source":      cluster.name,
+			"moid":        moid,
+			"clustername": cluster.name,
+		}
+		if cluster.dcname != "" {
+			tags["dcname"] = cluster.dcname
+		}
+
+		entries, err := e.parseVsanEntity(result, tags, lookup, start)
+		if err != nil {
+			e.log.Errorf("Parsing vSAN metrics for %s: %v", result.EntityRefId, err)
+			continue
+		}
+		for _, entry := range entries {
+			acc.AddFields(entry.name, entry.fields, entry.tags, entry.ts)
+			count += len(entry.fields)
+			if entry.ts.After(latestSample) {
+				latestSample = entry.ts
+			}
+		}
+	}
+	e.vsanLatest[moid] = latestSample
+	return count, nil
+}
+
+// parseVsanEntity converts the CSV encoded samples of a vSAN performance
+// entity into metrics.  Only samples after the given time are returned.
+func (e *Endpoint) parseVsanEntity(result vsantypes.VsanPerfEntityMetricCSV, clusterTags map[string]string, lookup vsanLookup, after time.Time) ([]metricEntry, error) {
+	parts := strings.SplitN(result.EntityRefId, ":", 2)
+	entityType := parts[0]
+	uuid := ""
+	if len(parts) > 1 {
+		uuid = parts[1]
+	}
+
+	tags := make(map[string]string, len(clusterTags)+2)
+	for k, v := range clusterTags {
+		tags[k] = v
+	}
+	switch entityType {
+	case "cluster-domclient", "cluster-domcompmgr":
+	case "disk-group":
+		tags["disk_group"] = uuid
+		if host, ok := lookup.diskGroups[uuid]; ok {
+			tags["esxhostname"] = host
+		}
+	case "cache-disk", "capacity-disk":
+		tags["disk"] = uuid
+	default:
+		// Host entities are identified by the vSAN node UUID, optionally
+		// followed by the name of the device.
+		node := uuid
+		if i := strings.Index(uuid, "|"); i >= 0 {
+			node = uuid[:i]
+			tags["instance"] = uuid[i+1:]
+		}
+		if host, ok := lookup.nodes[node]; ok {
+			tags["esxhostname"] = host
+		} else {
+			tags["uuid"] = node
+		}
+	}
+
+	var timestamps []time.Time
+	for _, s := range strings.Split(result.SampleInfo, ",") {
+		if s == "" {
+			continue
+		}
+		ts, err := time.Parse(vsanTimeFormat, s)
+		if err != nil {
+			return nil, err
+		}
+		timestamps = append(timestamps, ts)
+	}
+
+	name := "vsphere" + e.Parent.Separator + "vsan" + e.Parent.Separator + strings.Replace(entityType, "-", e.Parent.Separator, -1)
+	entries := make([]metricEntry, len(timestamps))
+	for i, ts := range timestamps {
+		entries[i] = metricEntry{name: name, ts: ts, tags: tags, fields: make(map[string]interface{})}
+	}
+
+	for _, series := range result.Value {
+		values := strings.Split(series.Values, ",")
+		for i, s := range values {
+			if i >= len(entries) {
+				break
+			}
+			// Missing samples are reported as empty values or "None".
+			if s == "" || s == "None" {
+				continue
+			}
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parsing value of %s: %v", series.MetricId.Label, err)
+			}
+			entries[i].fields[series.MetricId.Label] = v
+		}
+	}
+
+	filtered := entries[:0]
+	for _, entry := range entries {
+		if len(entry.fields) > 0 && entry.ts.After(after) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered, nil
+}
@@ -43,6 +43,8 @@ type VSphere struct {
 	DatastoreMetricExclude  []string
 	DatastoreInclude        []string
 	DatastoreExclude        []string
+	VSANMetricInclude       []string `toml:"vsan_metric_include"`
+	VSANMetricExclude       []string `toml:"vsan_metric_exclude"`
 	Separator               string
 	CustomAttributeInclude  []string
 	CustomAttributeExclude  []string
@@ -196,6 +198,14 @@ var sampleConfig = `
   datacenter_metric_exclude = [ "*" ] ## Datacenters are not collected by default.
   # datacenter_instances = false ## false by default
 
+  ## vSAN
+  ## vSAN performance service entity types of the clusters selected by
+  ## cluster_include.  Possible values are cluster-domclient,
+  ## cluster-domcompmgr, host-domclient, host-domcompmgr, disk-group,
+  ## cache-disk, capacity-disk, vsan-host-net, vsan-vnic-net and vsan-pnic-net.
+  # vsan_metric_include = [ "cluster-domclient", "cluster-domcompmgr", "host-domcompmgr", "disk-group" ]
+  # vsan_metric_exclude = [ "*" ] ## vSAN metrics are not collected by default.
+
   ## Plugin Settings
   ## separator character to use for measurement and field names (default: "_")
   # separator = "_"
@@ -354,6 +364,8 @@ func init() {
 			DatastoreMetricInclude:  nil,
 			DatastoreMetricExclude:  nil,
 			DatastoreInclude:        []string{"/*/datastore/**"},
+			VSANMetricInclude:       nil,
+			VSANMetricExclude:       []string{"*"},
 			Separator:               "_",
 			CustomAttributeInclude:  []string{},
 			CustomAttributeExclude:  []string{"*"},
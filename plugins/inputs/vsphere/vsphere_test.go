@@ -18,6 +18,7 @@ import (
 	"github.com/vmware/govmomi/simulator"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
+	vsantypes "github.com/vmware/govmomi/vsan/types"
 )
 
 var configHeader = `
@@ -464,3 +465,103 @@ func TestAll(t *testing.T) {
 	require.Equal(t, 0, len(acc.Errors), fmt.Sprintf("Errors found: %s", acc.Errors))
 	require.True(t, len(acc.Metrics) > 0, "No metrics were collected")
 }
+
+func TestVsanEntities(t *testing.T) {
+	require.Empty(t, vsanEntities(nil, []string{"*"}))
+	require.Equal(t, vsanEntityTypes, vsanEntities(nil, nil))
+	require.Equal(t, []string{"cluster-domclient", "cluster-domcompmgr"}, vsanEntities([]string{"cluster-*"}, nil))
+	require.Equal(t, []string{"host-domcompmgr", "disk-group"}, vsanEntities([]string{"host-domcompmgr", "disk-group"}, nil))
+}
+
+func TestParseVsanEntity(t *testing.T) {
+	e := Endpoint{Parent: defaultVSphere(), log: testutil.Logger{}}
+	e.Parent.Separator = "_"
+
+	lookup := vsanLookup{
+		nodes:      map[string]string{"5d2f-node": "esx1.local"},
+		diskGroups: map[string]string{"52a8-dg": "esx2.local"},
+	}
+	clusterTags := map[string]string{"clustername": "cluster1"}
+	after := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	entity := vsantypes.VsanPerfEntityMetricCSV{
+		EntityRefId: "disk-group:52a8-dg",
+		SampleInfo:  "2020-05-01 10:00:00,2020-05-01 10:05:00,2020-05-01 10:10:00",
+		Value: []vsantypes.VsanPerfMetricSeriesCSV{
+			{
+				MetricId: vsantypes.VsanPerfMetricId{Label: "iopsSched"},
+				Values:   "10,20,None",
+			},
+			{
+				MetricId: vsantypes.VsanPerfMetricId{Label: "latencySched"},
+				Values:   "100,200.5,",
+			},
+		},
+	}
+	entries, err := e.parseVsanEntity(entity, clusterTags, lookup, after)
+	require.NoError(t, err)
+	// The first sample is not after the start, the last one has no values.
+	require.Len(t, entries, 1)
+	require.Equal(t, "vsphere_vsan_disk_group", entries[0].name)
+	require.Equal(t, time.Date(2020, 5, 1, 10, 5, 0, 0, time.UTC), entries[0].ts)
+	require.Equal(t, map[string]string{
+		"clustername": "cluster1",
+		"disk_group":  "52a8-dg",
+		"esxhostname": "esx2.local",
+	}, entries[0].tags)
+	require.Equal(t, map[string]interface{}{
+		"iopsSched":    20.0,
+		"latencySched": 200.5,
+	}, entries[0].fields)
+
+	entity = vsantypes.VsanPerfEntityMetricCSV{
+		EntityRefId: "host-domcompmgr:5d2f-node",
+		SampleInfo:  "2020-05-01 10:05:00",
+		Value: []vsantypes.VsanPerfMetricSeriesCSV{
+			{
+				MetricId: vsantypes.VsanPerfMetricId{Label: "iopsResyncRead"},
+				Values:   "5",
+			},
+		},
+	}
+	entries, err = e.parseVsanEntity(entity, clusterTags, lookup, after)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "vsphere_vsan_host_domcompmgr", entries[0].name)
+	require.Equal(t, "esx1.local", entries[0].tags["esxhostname"])
+	require.Equal(t, 5.0, entries[0].fields["iopsResyncRead"])
+
+	entity.SampleInfo = "invalid"
+	_, err = e.parseVsanEntity(entity, clusterTags, lookup, after)
+	require.Error(t, err)
+}
+
+func TestVsanNotEnabled(t *testing.T) {
+	// Don't run test on 32-bit machines due to bug in simulator.
+	// https://github.com/vmware/govmomi/issues/1330
+	var i int
+	if unsafe.Sizeof(i) < 8 {
+		return
+	}
+
+	m, s, err := createSim(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Remove()
+	defer s.Close()
+
+	var acc testutil.Accumulator
+	v := defaultVSphere()
+	v.Vcenters = []string{s.URL.String()}
+	v.ClusterMetricExclude = []string{"*"}
+	v.VSANMetricInclude = []string{"cluster-domclient"}
+	require.NoError(t, v.Start(&acc))
+	defer v.Stop()
+	require.NoError(t, v.Gather(&acc))
+	require.Equal(t, 0, len(acc.Errors), fmt.Sprintf("Errors found: %s", acc.Errors))
+	require.NotEmpty(t, v.endpoints[0].resourceKinds["cluster"].objects)
+	for _, m := range acc.Metrics {
+		require.NotContains(t, m.Measurement, "vsan")
+	}
+}
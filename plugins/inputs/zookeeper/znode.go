@@ -0,0 +1,186 @@
+package zookeeper
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+var (
+	defaultSessionTimeout = 10 * time.Second
+
+	// watchRetryInterval is the interval between the watches of a znode
+	// after a failure, such as when the znode does not exist.
+	watchRetryInterval = 10 * time.Second
+)
+
+// znodeClient is the part of the zookeeper session used by the plugin.
+type znodeClient interface {
+	Children(path string) ([]string, *zk.Stat, error)
+	ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error)
+	Close()
+}
+
+// zkLogger logs the messages of the zookeeper client at the debug level.
+type zkLogger struct {
+	log telegraf.Logger
+}
+
+func (l zkLogger) Printf(format string, v ...interface{}) {
+	l.log.Debugf(format, v...)
+}
+
+// connect opens a session to the servers, the client connects and reconnects
+// in the background.
+func (z *Zookeeper) connect() (*zk.Conn, error) {
+	servers := make([]string, 0, len(z.Servers))
+	for _, address := range z.Servers {
+		servers = append(servers, sessionAddress(address))
+	}
+	if len(servers) == 0 {
+		servers = append(servers, sessionAddress(":2181"))
+	}
+
+	timeout := z.SessionTimeout.Duration
+	if timeout == 0 {
+		timeout = defaultSessionTimeout
+	}
+
+	dial := func(network, address string, timeout time.Duration) (net.Conn, error) {
+		dialer := &net.Dialer{Timeout: timeout}
+		if z.EnableTLS || z.EnableSSL {
+			return tls.DialWithDialer(dialer, network, address, z.tlsConfig)
+		}
+		return dialer.Dial(network, address)
+	}
+
+	conn, _, err := zk.Connect(servers, timeout, zk.WithLogger(zkLogger{z.Log}), zk.WithDialer(dial))
+	return conn, err
+}
+
+// sessionAddress returns the address of the server with the default host and
+// port of the mntr queries.
+func sessionAddress(address string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port = address, "2181"
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// gatherZnode adds the number of children and descendants of the znode.
+func (z *Zookeeper) gatherZnode(acc telegraf.Accumulator, znode string) error {
+	children, _, err := z.conn.Children(znode)
+	if err != nil {
+		return fmt.Errorf("listing children of %q: %v", znode, err)
+	}
+
+	descendants, err := z.countDescendants(znode, children)
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]interface{}{
+		"children":    len(children),
+		"descendants": descendants,
+	}
+	tags := map[string]string{
+		"path": znode,
+	}
+	acc.AddFields("zookeeper_znode", fields, tags)
+	return nil
+}
+
+// countDescendants returns the number of descendants of the znode with the
+// given children.  The children deleted while counting are ignored.
+func (z *Zookeeper) countDescendants(znode string, children []string) (int, error) {
+	count := len(children)
+	for _, child := range children {
+		childPath := path.Join(znode, child)
+		grandchildren, _, err := z.conn.Children(childPath)
+		if err == zk.ErrNoNode {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("listing children of %q: %v", childPath, err)
+		}
+
+		n, err := z.countDescendants(childPath, grandchildren)
+		if err != nil {
+			return 0, err
+		}
+		count += n
+	}
+	return count, nil
+}
+
+// watch watches the children of the znode until the context is done, adding
+// a metric for each child created or deleted.  The watch is set again after
+// each event, the changes made in between are found by comparing the
+// children.
+func (z *Zookeeper) watch(ctx context.Context, acc telegraf.Accumulator, znode string) {
+	var previous []string
+	for {
+		children, _, events, err := z.conn.ChildrenW(znode)
+		if err != nil {
+			acc.AddError(fmt.Errorf("watching children of %q: %v", znode, err))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(watchRetryInterval):
+			}
+			continue
+		}
+
+		sort.Strings(children)
+		// The first children are the ones to compare the changes to.
+		if previous != nil {
+			addZnodeChanges(acc, znode, previous, children)
+		}
+		previous = children
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-events:
+		}
+	}
+}
+
+// addZnodeChanges adds a zookeeper_znode_change metric for each child created
+// or deleted, the children are sorted.
+func addZnodeChanges(acc telegraf.Accumulator, znode string, previous, current []string) {
+	now := time.Now()
+	add := func(child, action string) {
+		acc.AddFields("zookeeper_znode_change",
+			map[string]interface{}{"children": len(current)},
+			map[string]string{"path": znode, "child": child, "action": action},
+			now)
+	}
+
+	i, j := 0, 0
+	for i < len(previous) || j < len(current) {
+		switch {
+		case j == len(current) || (i < len(previous) && previous[i] < current[j]):
+			add(previous[i], "deleted")
+			i++
+		case i == len(previous) || current[j] < previous[i]:
+			add(current[j], "created")
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+}
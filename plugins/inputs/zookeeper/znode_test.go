@@ -0,0 +1,137 @@
+package zookeeper
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/samuel/go-zookeeper/zk"
+	"github.com/stretchr/testify/require"
+)
+
+// mockZnodes is a tree of znodes, a child watch is triggered when a child of
+// the znode is created or deleted.
+type mockZnodes struct {
+	sync.Mutex
+	children map[string][]string
+	watches  map[string]chan zk.Event
+}
+
+func (m *mockZnodes) Children(path string) ([]string, *zk.Stat, error) {
+	m.Lock()
+	defer m.Unlock()
+	children, ok := m.children[path]
+	if !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	return children, &zk.Stat{NumChildren: int32(len(children))}, nil
+}
+
+func (m *mockZnodes) ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	children, stat, err := m.Children(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	ch := make(chan zk.Event, 1)
+	m.watches[path] = ch
+	return children, stat, ch, nil
+}
+
+func (m *mockZnodes) Close() {}
+
+func (m *mockZnodes) setChildren(path string, children ...string) {
+	m.Lock()
+	defer m.Unlock()
+	m.children[path] = children
+	if ch, ok := m.watches[path]; ok {
+		ch <- zk.Event{Type: zk.EventNodeChildrenChanged, Path: path}
+		delete(m.watches, path)
+	}
+}
+
+func (m *mockZnodes) watching(path string) bool {
+	m.Lock()
+	defer m.Unlock()
+	_, ok := m.watches[path]
+	return ok
+}
+
+const (
+	testTimeout = 5 * time.Second
+	testTick    = 10 * time.Millisecond
+)
+
+func TestSessionAddress(t *testing.T) {
+	require.Equal(t, "localhost:2181", sessionAddress(":2181"))
+	require.Equal(t, "10.0.0.1:2181", sessionAddress("10.0.0.1"))
+	require.Equal(t, "zk01:2182", sessionAddress("zk01:2182"))
+}
+
+func TestGatherZnode(t *testing.T) {
+	z := &Zookeeper{
+		conn: &mockZnodes{children: map[string][]string{
+			"/services":                {"web", "db"},
+			"/services/web":            {"instance-1", "instance-2"},
+			"/services/web/instance-1": {},
+			"/services/web/instance-2": {},
+			"/services/db":             {"primary"},
+			"/services/db/primary":     {},
+		}},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, z.gatherZnode(&acc, "/services"))
+	acc.AssertContainsTaggedFields(t, "zookeeper_znode",
+		map[string]interface{}{"children": 2, "descendants": 5},
+		map[string]string{"path": "/services"})
+
+	require.Error(t, z.gatherZnode(&acc, "/missing"))
+}
+
+func TestWatch(t *testing.T) {
+	znodes := &mockZnodes{
+		children: map[string][]string{"/services/web": {"instance-1", "instance-2"}},
+		watches:  map[string]chan zk.Event{},
+	}
+	z := &Zookeeper{conn: znodes}
+
+	var acc testutil.Accumulator
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		z.watch(ctx, &acc, "/services/web")
+	}()
+
+	waitWatch := func() {
+		require.Eventually(t, func() bool { return znodes.watching("/services/web") }, testTimeout, testTick)
+	}
+	waitWatch()
+	znodes.setChildren("/services/web", "instance-2", "instance-3")
+	acc.Wait(2)
+	cancel()
+	<-done
+
+	acc.AssertContainsTaggedFields(t, "zookeeper_znode_change",
+		map[string]interface{}{"children": 2},
+		map[string]string{"path": "/services/web", "child": "instance-1", "action": "deleted"})
+	acc.AssertContainsTaggedFields(t, "zookeeper_znode_change",
+		map[string]interface{}{"children": 2},
+		map[string]string{"path": "/services/web", "child": "instance-3", "action": "created"})
+}
+
+func TestAddZnodeChanges(t *testing.T) {
+	var acc testutil.Accumulator
+	addZnodeChanges(&acc, "/services", []string{"a", "c", "d"}, []string{"b", "c", "e"})
+
+	var changes []string
+	for _, m := range acc.GetTelegrafMetrics() {
+		changes = append(changes, m.Tags()["action"]+" "+m.Tags()["child"])
+	}
+	require.Equal(t, []string{"deleted a", "created b", "deleted d", "created e"}, changes)
+}
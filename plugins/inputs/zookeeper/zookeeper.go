@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -28,8 +29,18 @@ type Zookeeper struct {
 	EnableSSL bool `toml:"enable_ssl"` // deprecated in 1.7; use enable_tls
 	tlsint.ClientConfig
 
+	ZnodePaths     []string          `toml:"znode_paths"`
+	WatchPaths     []string          `toml:"watch_paths"`
+	SessionTimeout internal.Duration `toml:"session_timeout"`
+
+	Log telegraf.Logger `toml:"-"`
+
 	initialized bool
 	tlsConfig   *tls.Config
+
+	conn   znodeClient
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 var sampleConfig = `
@@ -50,6 +61,17 @@ var sampleConfig = `
   # tls_key = "/etc/telegraf/key.pem"
   ## If false, skip chain & host verification
   # insecure_skip_verify = true
+
+  ## Gather the number of children and descendants of these znodes.
+  # znode_paths = []
+
+  ## Watch the children of these znodes and add a zookeeper_znode_change
+  ## metric when a child is created or deleted, such as when a service
+  ## registers itself with an ephemeral znode.
+  # watch_paths = []
+
+  ## Timeout of the session used for the znode_paths and watch_paths.
+  # session_timeout = "10s"
 `
 
 var defaultTimeout = 5 * time.Second
@@ -77,17 +99,53 @@ func (z *Zookeeper) dial(ctx context.Context, addr string) (net.Conn, error) {
 	}
 }
 
+func (z *Zookeeper) init() error {
+	if z.initialized {
+		return nil
+	}
+
+	tlsConfig, err := z.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	z.tlsConfig = tlsConfig
+	z.initialized = true
+	return nil
+}
+
+// Start opens a session to the servers when znodes are gathered or watched.
+func (z *Zookeeper) Start(acc telegraf.Accumulator) error {
+	if len(z.ZnodePaths) == 0 && len(z.WatchPaths) == 0 {
+		return nil
+	}
+	if err := z.init(); err != nil {
+		return err
+	}
+
+	conn, err := z.connect()
+	if err != nil {
+		return err
+	}
+	z.conn = conn
+
+	var ctx context.Context
+	ctx, z.cancel = context.WithCancel(context.Background())
+	for _, path := range z.WatchPaths {
+		z.wg.Add(1)
+		go func(path string) {
+			defer z.wg.Done()
+			z.watch(ctx, acc, path)
+		}(path)
+	}
+	return nil
+}
+
 // Gather reads stats from all configured servers accumulates stats
 func (z *Zookeeper) Gather(acc telegraf.Accumulator) error {
 	ctx := context.Background()
 
-	if !z.initialized {
-		tlsConfig, err := z.ClientConfig.TLSConfig()
-		if err != nil {
-			return err
-		}
-		z.tlsConfig = tlsConfig
-		z.initialized = true
+	if err := z.init(); err != nil {
+		return err
 	}
 
 	if z.Timeout.Duration < 1*time.Second {
@@ -104,9 +162,25 @@ func (z *Zookeeper) Gather(acc telegraf.Accumulator) error {
 	for _, serverAddress := range z.Servers {
 		acc.AddError(z.gatherServer(ctx, serverAddress, acc))
 	}
+
+	if z.conn != nil {
+		for _, path := range z.ZnodePaths {
+			acc.AddError(z.gatherZnode(acc, path))
+		}
+	}
 	return nil
 }
 
+func (z *Zookeeper) Stop() {
+	if z.cancel != nil {
+		z.cancel()
+	}
+	z.wg.Wait()
+	if z.conn != nil {
+		z.conn.Close()
+	}
+}
+
 func (z *Zookeeper) gatherServer(ctx context.Context, address string, acc telegraf.Accumulator) error {
 	var zookeeper_state string
 	_, _, err := net.SplitHostPort(address)
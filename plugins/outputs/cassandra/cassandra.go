@@ -0,0 +1,366 @@
+package cassandra
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+var identifierRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+
+type Cassandra struct {
+	Servers               []string                     `toml:"servers"`
+	Keyspace              string                       `toml:"keyspace"`
+	Table                 string                       `toml:"table"`
+	TableCreate           bool                         `toml:"table_create"`
+	Username              string                       `toml:"username"`
+	Password              string                       `toml:"password"`
+	Consistency           string                       `toml:"consistency"`
+	Timeout               internal.Duration            `toml:"timeout"`
+	PartitionTags         []string                     `toml:"partition_tags"`
+	BucketSize            internal.Duration            `toml:"bucket_size"`
+	TTL                   internal.Duration            `toml:"ttl"`
+	MeasurementTTL        map[string]internal.Duration `toml:"measurement_ttl"`
+	MaxBatchSize          int                          `toml:"max_batch_size"`
+	MaxPreparedStatements int                          `toml:"max_prepared_statements"`
+	tls.ClientConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	session     *gocql.Session
+	consistency gocql.Consistency
+}
+
+var sampleConfig = `
+  ## Contact points of the Cassandra or ScyllaDB cluster.
+  servers = ["127.0.0.1:9042"]
+
+  ## Keyspace and table to write to, the keyspace must exist.
+  keyspace = "telegraf"
+  table = "metrics"
+
+  ## Create the table if it does not exist.
+  # table_create = true
+
+  ## Authentication credentials.
+  # username = ""
+  # password = ""
+
+  ## Consistency level of the writes.
+  # consistency = "quorum"
+
+  ## Timeout for connecting and writing.
+  # timeout = "5s"
+
+  ## Tags forming the partition together with the measurement and the time
+  ## bucket.  If empty all tags are used, so each series is a partition.
+  # partition_tags = []
+
+  ## Time span of a partition, 0 disables time bucketing.
+  # bucket_size = "24h"
+
+  ## Time to live of the written metrics, 0 keeps them forever.
+  # ttl = "0s"
+
+  ## Time to live of specific measurements, overriding ttl.
+  # [outputs.cassandra.measurement_ttl]
+  #   cpu = "720h"
+
+  ## Maximum number of rows written in a single batch.  Each batch holds the
+  ## rows of a single partition.
+  # max_batch_size = 100
+
+  ## Number of prepared statements cached per connection.
+  # max_prepared_statements = 1000
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+func (c *Cassandra) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *Cassandra) Description() string {
+	return "Write metrics to Cassandra or ScyllaDB"
+}
+
+func (c *Cassandra) Init() error {
+	if len(c.Servers) == 0 {
+		return fmt.Errorf("no servers specified")
+	}
+	if !identifierRe.MatchString(c.Keyspace) {
+		return fmt.Errorf("invalid keyspace %q", c.Keyspace)
+	}
+	if !identifierRe.MatchString(c.Table) {
+		return fmt.Errorf("invalid table %q", c.Table)
+	}
+	if c.MaxBatchSize < 1 {
+		return fmt.Errorf("max_batch_size must be positive")
+	}
+
+	consistency, err := gocql.ParseConsistencyWrapper(c.Consistency)
+	if err != nil {
+		return err
+	}
+	c.consistency = consistency
+	return nil
+}
+
+func (c *Cassandra) Connect() error {
+	cluster := gocql.NewCluster(c.Servers...)
+	cluster.Keyspace = c.Keyspace
+	cluster.Consistency = c.consistency
+	cluster.Timeout = c.Timeout.Duration
+	cluster.ConnectTimeout = c.Timeout.Duration
+	// Version 4 is required for unset values, avoiding tombstones for
+	// empty field maps.
+	cluster.ProtoVersion = 4
+	cluster.MaxPreparedStmts = c.MaxPreparedStatements
+	// Token awareness sends each batch to a replica of its partition.
+	cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(gocql.RoundRobinHostPolicy())
+
+	if c.Username != "" {
+		cluster.Authenticator = gocql.PasswordAuthenticator{
+			Username: c.Username,
+			Password: c.Password,
+		}
+	}
+
+	tlsConfig, err := c.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		cluster.SslOpts = &gocql.SslOptions{
+			Config:                 tlsConfig,
+			EnableHostVerification: !tlsConfig.InsecureSkipVerify,
+		}
+	}
+
+	session, err := cluster.CreateSession()
+	if err != nil {
+		return err
+	}
+
+	if c.TableCreate {
+		if err := session.Query(c.createTableCQL()).Exec(); err != nil {
+			session.Close()
+			return fmt.Errorf("creating table failed: %v", err)
+		}
+	}
+
+	c.session = session
+	return nil
+}
+
+func (c *Cassandra) Close() error {
+	if c.session != nil {
+		c.session.Close()
+		c.session = nil
+	}
+	return nil
+}
+
+func (c *Cassandra) Write(metrics []telegraf.Metric) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout.Duration)
+	defer cancel()
+
+	// The statement is prepared once and cached by the session.
+	stmt := c.insertCQL()
+	for _, rows := range c.batches(metrics) {
+		batch := c.session.NewBatch(gocql.UnloggedBatch).WithContext(ctx)
+		for _, r := range rows {
+			batch.Query(stmt, r.values()...)
+		}
+
+		if err := c.session.ExecuteBatch(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Cassandra) createTableCQL() string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.%s (
+	measurement text,
+	partition_key text,
+	bucket timestamp,
+	time timestamp,
+	series text,
+	tags map<text, text>,
+	int_fields map<text, bigint>,
+	float_fields map<text, double>,
+	string_fields map<text, text>,
+	bool_fields map<text, boolean>,
+	PRIMARY KEY ((measurement, partition_key, bucket), time, series)
+) WITH CLUSTERING ORDER BY (time DESC, series ASC)`, c.Keyspace, c.Table)
+}
+
+func (c *Cassandra) insertCQL() string {
+	return fmt.Sprintf("INSERT INTO %s.%s (measurement, partition_key, bucket, time, series, tags, "+
+		"int_fields, float_fields, string_fields, bool_fields) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) USING TTL ?",
+		c.Keyspace, c.Table)
+}
+
+type row struct {
+	measurement  string
+	partitionKey string
+	bucket       time.Time
+	time         time.Time
+	series       string
+	tags         map[string]string
+	intFields    map[string]int64
+	floatFields  map[string]float64
+	stringFields map[string]string
+	boolFields   map[string]bool
+	ttl          int
+}
+
+// values returns the bind values of the insert statement.  Empty maps are
+// unset instead of null to not create tombstones.
+func (r *row) values() []interface{} {
+	values := []interface{}{r.measurement, r.partitionKey, r.bucket, r.time, r.series}
+
+	maps := []interface{}{r.tags, r.intFields, r.floatFields, r.stringFields, r.boolFields}
+	lengths := []int{len(r.tags), len(r.intFields), len(r.floatFields), len(r.stringFields), len(r.boolFields)}
+	for i, m := range maps {
+		if lengths[i] == 0 {
+			values = append(values, gocql.UnsetValue)
+		} else {
+			values = append(values, m)
+		}
+	}
+	return append(values, r.ttl)
+}
+
+// batches groups the metrics by partition, splitting the groups into
+// batches of at most MaxBatchSize rows.
+func (c *Cassandra) batches(metrics []telegraf.Metric) [][]*row {
+	var keys []string
+	groups := make(map[string][]*row)
+	for _, m := range metrics {
+		r := c.newRow(m)
+		key := r.measurement + "\x00" + r.partitionKey + "\x00" + r.bucket.String()
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], r)
+	}
+
+	var batches [][]*row
+	for _, key := range keys {
+		rows := groups[key]
+		for len(rows) > c.MaxBatchSize {
+			batches = append(batches, rows[:c.MaxBatchSize])
+			rows = rows[c.MaxBatchSize:]
+		}
+		batches = append(batches, rows)
+	}
+	return batches
+}
+
+func (c *Cassandra) newRow(m telegraf.Metric) *row {
+	r := &row{
+		measurement: m.Name(),
+		time:        m.Time(),
+		tags:        m.Tags(),
+		ttl:         c.ttl(m.Name()),
+	}
+
+	series := make([]string, 0, len(m.TagList()))
+	for _, tag := range m.TagList() {
+		series = append(series, tag.Key+"="+tag.Value)
+	}
+	r.series = strings.Join(series, ",")
+
+	if len(c.PartitionTags) == 0 {
+		r.partitionKey = r.series
+	} else {
+		partition := make([]string, 0, len(c.PartitionTags))
+		for _, key := range c.PartitionTags {
+			if value, ok := m.GetTag(key); ok {
+				partition = append(partition, key+"="+value)
+			}
+		}
+		r.partitionKey = strings.Join(partition, ",")
+	}
+
+	if c.BucketSize.Duration > 0 {
+		r.bucket = m.Time().Truncate(c.BucketSize.Duration).UTC()
+	} else {
+		r.bucket = time.Unix(0, 0).UTC()
+	}
+
+	for _, field := range m.FieldList() {
+		switch v := field.Value.(type) {
+		case int64:
+			r.addInt(field.Key, v)
+		case uint64:
+			if v > math.MaxInt64 {
+				r.addInt(field.Key, math.MaxInt64)
+			} else {
+				r.addInt(field.Key, int64(v))
+			}
+		case float64:
+			if r.floatFields == nil {
+				r.floatFields = make(map[string]float64)
+			}
+			r.floatFields[field.Key] = v
+		case string:
+			if r.stringFields == nil {
+				r.stringFields = make(map[string]string)
+			}
+			r.stringFields[field.Key] = v
+		case bool:
+			if r.boolFields == nil {
+				r.boolFields = make(map[string]bool)
+			}
+			r.boolFields[field.Key] = v
+		}
+	}
+	return r
+}
+
+func (r *row) addInt(key string, value int64) {
+	if r.intFields == nil {
+		r.intFields = make(map[string]int64)
+	}
+	r.intFields[key] = value
+}
+
+// ttl returns the time to live of the measurement in seconds.
+func (c *Cassandra) ttl(measurement string) int {
+	ttl, ok := c.MeasurementTTL[measurement]
+	if !ok {
+		ttl = c.TTL
+	}
+	return int(ttl.Duration / time.Second)
+}
+
+func init() {
+	outputs.Add("cassandra", func() telegraf.Output {
+		return &Cassandra{
+			Table:                 "metrics",
+			TableCreate:           true,
+			Consistency:           "quorum",
+			Timeout:               internal.Duration{Duration: 5 * time.Second},
+			BucketSize:            internal.Duration{Duration: 24 * time.Hour},
+			MaxBatchSize:          100,
+			MaxPreparedStatements: 1000,
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package cassandra
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newCassandra() *Cassandra {
+	return &Cassandra{
+		Servers:      []string{"localhost:9042"},
+		Keyspace:     "telegraf",
+		Table:        "metrics",
+		Consistency:  "quorum",
+		BucketSize:   internal.Duration{Duration: 24 * time.Hour},
+		MaxBatchSize: 100,
+	}
+}
+
+func TestInit(t *testing.T) {
+	c := newCassandra()
+	require.NoError(t, c.Init())
+	require.Equal(t, gocql.Quorum, c.consistency)
+
+	c = newCassandra()
+	c.Table = "metrics; DROP TABLE x"
+	require.Error(t, c.Init())
+
+	c = newCassandra()
+	c.Consistency = "most"
+	require.Error(t, c.Init())
+}
+
+func TestNewRow(t *testing.T) {
+	c := newCassandra()
+	c.TTL = internal.Duration{Duration: time.Hour}
+
+	m := testutil.MustMetric(
+		"cpu",
+		map[string]string{"host": "server01", "cpu": "cpu0"},
+		map[string]interface{}{
+			"usage":  42.5,
+			"count":  int64(7),
+			"big":    uint64(1 << 63),
+			"ok":     true,
+			"status": "up",
+		},
+		time.Date(2020, 9, 1, 17, 30, 0, 0, time.UTC),
+	)
+
+	r := c.newRow(m)
+	require.Equal(t, "cpu", r.measurement)
+	require.Equal(t, "cpu=cpu0,host=server01", r.series)
+	require.Equal(t, r.series, r.partitionKey)
+	require.Equal(t, time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC), r.bucket)
+	require.Equal(t, map[string]int64{"count": 7, "big": 1<<63 - 1}, r.intFields)
+	require.Equal(t, map[string]float64{"usage": 42.5}, r.floatFields)
+	require.Equal(t, map[string]string{"status": "up"}, r.stringFields)
+	require.Equal(t, map[string]bool{"ok": true}, r.boolFields)
+	require.Equal(t, 3600, r.ttl)
+}
+
+func TestPartitionTags(t *testing.T) {
+	c := newCassandra()
+	c.PartitionTags = []string{"host", "region"}
+	c.BucketSize = internal.Duration{}
+
+	m := testutil.MustMetric(
+		"cpu",
+		map[string]string{"host": "server01", "cpu": "cpu0"},
+		map[string]interface{}{"usage": 42.5},
+		time.Unix(1000, 0),
+	)
+
+	r := c.newRow(m)
+	require.Equal(t, "host=server01", r.partitionKey)
+	require.Equal(t, "cpu=cpu0,host=server01", r.series)
+	require.Equal(t, time.Unix(0, 0).UTC(), r.bucket)
+}
+
+func TestMeasurementTTL(t *testing.T) {
+	c := newCassandra()
+	c.TTL = internal.Duration{Duration: time.Hour}
+	c.MeasurementTTL = map[string]internal.Duration{
+		"mem": {Duration: 30 * 24 * time.Hour},
+		"net": {},
+	}
+
+	require.Equal(t, 3600, c.ttl("cpu"))
+	require.Equal(t, 2592000, c.ttl("mem"))
+	require.Equal(t, 0, c.ttl("net"))
+}
+
+func TestBatches(t *testing.T) {
+	c := newCassandra()
+	c.MaxBatchSize = 2
+	c.BucketSize = internal.Duration{Duration: time.Hour}
+
+	metric := func(host string, ts int64) telegraf.Metric {
+		return testutil.MustMetric(
+			"cpu",
+			map[string]string{"host": host},
+			map[string]interface{}{"usage": 42.5},
+			time.Unix(ts, 0),
+		)
+	}
+
+	metrics := []telegraf.Metric{
+		metric("a", 0),
+		metric("b", 0),
+		metric("a", 1),
+		metric("a", 2),
+		metric("a", 3600),
+	}
+
+	var got [][]string
+	for _, rows := range c.batches(metrics) {
+		var batch []string
+		for _, r := range rows {
+			batch = append(batch, r.partitionKey+"@"+r.time.UTC().Format("15:04:05"))
+		}
+		got = append(got, batch)
+	}
+
+	require.Equal(t, [][]string{
+		{"host=a@00:00:00", "host=a@00:00:01"},
+		{"host=a@00:00:02"},
+		{"host=b@00:00:00"},
+		{"host=a@01:00:00"},
+	}, got)
+}
+
+func TestRowValuesUnsetEmptyMaps(t *testing.T) {
+	r := &row{
+		measurement: "cpu",
+		floatFields: map[string]float64{"usage": 42.5},
+	}
+
+	values := r.values()
+	require.Len(t, values, 11)
+	require.Equal(t, gocql.UnsetValue, values[5])
+	require.Equal(t, gocql.UnsetValue, values[6])
+	require.Equal(t, map[string]float64{"usage": 42.5}, values[7])
+	require.Equal(t, gocql.UnsetValue, values[8])
+	require.Equal(t, gocql.UnsetValue, values[9])
+	require.Equal(t, 0, values[10])
+}
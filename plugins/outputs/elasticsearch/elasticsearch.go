@@ -283,16 +283,53 @@ func (a *Elasticsearch) Write(metrics []telegraf.Metric) error {
 	}
 
 	if res.Errors {
-		for id, err := range res.Failed() {
-			log.Printf("E! Elasticsearch indexing failure, id: %d, error: %s, caused by: %s, %s", id, err.Error.Reason, err.Error.CausedBy["reason"], err.Error.CausedBy["type"])
-		}
-		return fmt.Errorf("W! Elasticsearch failed to index %d metrics", len(res.Failed()))
+		return bulkError(res)
 	}
 
 	return nil
 
 }
 
+// bulkError returns an error describing the failed items of a bulk request.
+// Items that failed because of the document itself, such as a mapping
+// conflict, are reported as rejected so that only the remaining items are
+// retried.
+func bulkError(res *elastic.BulkResponse) error {
+	partial := &internal.PartialWriteError{}
+	retry := 0
+	for i, item := range res.Items {
+		for _, result := range item {
+			if result.Status >= 200 && result.Status <= 299 {
+				continue
+			}
+
+			var reason string
+			if result.Error != nil {
+				reason = fmt.Sprintf("%s, caused by: %v, %v", result.Error.Reason,
+					result.Error.CausedBy["reason"], result.Error.CausedBy["type"])
+			}
+
+			if result.Status == http.StatusTooManyRequests || result.Status >= 500 {
+				log.Printf("E! Elasticsearch indexing failure, id: %d, status: %d, error: %s", i, result.Status, reason)
+				retry++
+				continue
+			}
+
+			partial.MetricsReject = append(partial.MetricsReject, i)
+			partial.MetricsRejectErrors = append(partial.MetricsRejectErrors,
+				fmt.Errorf("status %d: %s", result.Status, reason))
+		}
+	}
+
+	if retry > 0 {
+		partial.Err = fmt.Errorf("Elasticsearch failed to index %d metrics", retry)
+	}
+	if len(partial.MetricsReject) == 0 {
+		return partial.Err
+	}
+	return partial
+}
+
 func (a *Elasticsearch) manageTemplate(ctx context.Context) error {
 	if a.TemplateName == "" {
 		return fmt.Errorf("Elasticsearch template_name configuration not defined")
@@ -414,6 +451,12 @@ func (a *Elasticsearch) Description() string {
 	return "Configuration for Elasticsearch to send metrics to."
 }
 
+// StructuredFields returns true, the arrays and maps of the fields are indexed
+// as arrays and objects of the documents.
+func (a *Elasticsearch) StructuredFields() bool {
+	return true
+}
+
 func (a *Elasticsearch) Close() error {
 	a.Client = nil
 	return nil
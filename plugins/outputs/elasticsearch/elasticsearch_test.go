@@ -9,6 +9,7 @@ import (
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/olivere/elastic.v5"
 )
 
 func TestConnectAndWrite(t *testing.T) {
@@ -257,3 +258,35 @@ func TestGetIndexName(t *testing.T) {
 		}
 	}
 }
+
+func TestBulkError(t *testing.T) {
+	res := &elastic.BulkResponse{
+		Errors: true,
+		Items: []map[string]*elastic.BulkResponseItem{
+			{"index": {Status: 201}},
+			{"index": {Status: 400, Error: &elastic.ErrorDetails{Reason: "failed to parse"}}},
+			{"index": {Status: 201}},
+		},
+	}
+
+	err := bulkError(res)
+	partial, ok := err.(*internal.PartialWriteError)
+	require.True(t, ok)
+	require.Equal(t, []int{1}, partial.MetricsReject)
+	require.NoError(t, partial.Err)
+
+	res.Items = append(res.Items, map[string]*elastic.BulkResponseItem{
+		"index": {Status: 429},
+	})
+	err = bulkError(res)
+	partial, ok = err.(*internal.PartialWriteError)
+	require.True(t, ok)
+	require.Equal(t, []int{1}, partial.MetricsReject)
+	require.Error(t, partial.Err)
+
+	res.Items = res.Items[2:]
+	err = bulkError(res)
+	_, ok = err.(*internal.PartialWriteError)
+	require.False(t, ok)
+	require.Error(t, err)
+}
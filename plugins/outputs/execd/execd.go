@@ -0,0 +1,352 @@
+package execd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+// The protocol of the execd shim: each batch ends with frameEnd, and its
+// result is reported on stderr with resultOK or resultError followed by the
+// error code and message.
+const (
+	frameEnd    = "# end"
+	resultOK    = "# ok"
+	resultError = "# error "
+	codeDrop    = "drop"
+)
+
+// stopTimeout is the time given to the process to exit once its stdin is
+// closed, before it is killed.
+const stopTimeout = 5 * time.Second
+
+const sampleConfig = `
+  ## Program to run as daemon.  The batches of metrics are written to its
+  ## STDIN in influx line protocol, each followed by a "# end" line.  The
+  ## program reports the result of each batch on STDERR, see the README.
+  command = ["telegraf-output", "-config", "/etc/telegraf/output.conf"]
+
+  ## Delay before the process is restarted after an unexpected termination.
+  ## The delay doubles on each consecutive restart up to restart_delay_max,
+  ## it is reset once the process ran for at least restart_delay_max.
+  # restart_delay = "10s"
+  # restart_delay_max = "5m"
+
+  ## Maximum number of consecutive restarts before giving up, 0 is unlimited.
+  # max_restarts = 0
+
+  ## Ping the process on STDIN every health_check_interval and restart it
+  ## when it does not answer within health_check_timeout.  The process must
+  ## answer a "# ping" line with a "# pong" line on STDOUT.  Disabled if 0.
+  # health_check_interval = "0s"
+  # health_check_timeout = "5s"
+
+  ## Time to wait for the result of a batch.  The batch is sent again when
+  ## the result is not reported in time.
+  # timeout = "30s"
+`
+
+type Execd struct {
+	Command             []string        `toml:"command"`
+	RestartDelay        config.Duration `toml:"restart_delay"`
+	RestartDelayMax     config.Duration `toml:"restart_delay_max"`
+	MaxRestarts         int             `toml:"max_restarts"`
+	HealthCheckInterval config.Duration `toml:"health_check_interval"`
+	HealthCheckTimeout  config.Duration `toml:"health_check_timeout"`
+	Timeout             config.Duration `toml:"timeout"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	serializer *influx.Serializer
+
+	mu      sync.Mutex
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stdout  io.ReadCloser
+	stderr  io.ReadCloser
+	results chan error
+	health  process.HealthCheck
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (e *Execd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Execd) Description() string {
+	return "Run executable as long-running output plugin"
+}
+
+func (e *Execd) Init() error {
+	if len(e.Command) == 0 {
+		return errors.New("no command specified")
+	}
+	e.serializer = influx.NewSerializer()
+	return nil
+}
+
+func (e *Execd) Connect() error {
+	e.results = make(chan error, 1)
+	if err := e.cmdStart(); err != nil {
+		return err
+	}
+
+	var ctx context.Context
+	ctx, e.cancel = context.WithCancel(context.Background())
+	e.done = make(chan struct{})
+	go func() {
+		if err := e.cmdLoop(ctx); err != nil {
+			e.Log.Error(err)
+		}
+		close(e.done)
+	}()
+	return nil
+}
+
+func (e *Execd) Close() error {
+	if e.cancel == nil {
+		return nil
+	}
+	e.cancel()
+
+	e.mu.Lock()
+	e.stdin.Close()
+	cmd := e.cmd
+	e.mu.Unlock()
+
+	select {
+	case <-e.done:
+	case <-time.After(stopTimeout):
+		e.Log.Errorf("Process %s did not exit within %s, killing it", e.Command, stopTimeout)
+		cmd.Process.Kill()
+		<-e.done
+	}
+	return nil
+}
+
+func (e *Execd) Write(metrics []telegraf.Metric) error {
+	var buf bytes.Buffer
+	for _, metric := range metrics {
+		b, err := e.serializer.Serialize(metric)
+		if err != nil {
+			e.Log.Debugf("Could not serialize metric: %v", err)
+			continue
+		}
+		buf.Write(b)
+	}
+	buf.WriteString(frameEnd + "\n")
+
+	// Forget the result of a previous batch reported too late
+	select {
+	case <-e.results:
+	default:
+	}
+
+	e.mu.Lock()
+	_, err := e.stdin.Write(buf.Bytes())
+	e.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("error writing to process %s: %v", e.Command, err)
+	}
+
+	select {
+	case err := <-e.results:
+		if err, ok := err.(*dropError); ok {
+			// Sending the batch again would fail the same way
+			e.Log.Errorf("Dropping %d metrics: %v", len(metrics), err)
+			return nil
+		}
+		return err
+	case <-time.After(time.Duration(e.Timeout)):
+		return fmt.Errorf("process %s did not report the result within %s", e.Command, time.Duration(e.Timeout))
+	}
+}
+
+// dropError is reported by the process when the batch cannot be written.
+type dropError struct {
+	msg string
+}
+
+func (e *dropError) Error() string {
+	return e.msg
+}
+
+// report passes the result of the batch to Write, unless the result of
+// the previous batch was not read.
+func (e *Execd) report(err error) {
+	select {
+	case e.results <- err:
+	default:
+	}
+}
+
+// cmdLoop waits for the running process, restarting it until the output is
+// closed.
+func (e *Execd) cmdLoop(ctx context.Context) error {
+	s := &process.Supervisor{
+		Command:             e.Command,
+		RestartDelay:        time.Duration(e.RestartDelay),
+		RestartDelayMax:     time.Duration(e.RestartDelayMax),
+		MaxRestarts:         e.MaxRestarts,
+		HealthCheckInterval: time.Duration(e.HealthCheckInterval),
+		HealthCheckTimeout:  time.Duration(e.HealthCheckTimeout),
+		Log:                 e.Log,
+		Start:               e.cmdStart,
+		Wait:                e.cmdWait,
+		Terminated: func(error) {
+			e.report(fmt.Errorf("process %s terminated", e.Command))
+		},
+		Write:  e.writeStdin,
+		Kill:   e.kill,
+		Health: &e.health,
+	}
+	return s.Run(ctx)
+}
+
+// writeStdin writes to the stdin of the running process, without
+// interleaving with a batch.
+func (e *Execd) writeStdin(b []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err := e.stdin.Write(b)
+	return err
+}
+
+// kill kills the running process.
+func (e *Execd) kill() {
+	e.mu.Lock()
+	cmd := e.cmd
+	e.mu.Unlock()
+	cmd.Process.Kill()
+}
+
+func (e *Execd) cmdStart() error {
+	var cmd *exec.Cmd
+	if len(e.Command) > 1 {
+		cmd = exec.Command(e.Command[0], e.Command[1:]...)
+	} else {
+		cmd = exec.Command(e.Command[0])
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("error opening stdin pipe: %v", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("error opening stdout pipe: %v", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("error opening stderr pipe: %v", err)
+	}
+
+	e.Log.Infof("Starting process: %s", e.Command)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("error starting process: %v", err)
+	}
+
+	e.mu.Lock()
+	e.cmd, e.stdin, e.stdout, e.stderr = cmd, stdin, stdout, stderr
+	e.mu.Unlock()
+	return nil
+}
+
+func (e *Execd) cmdWait() error {
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		e.cmdReadOut(e.stdout)
+		wg.Done()
+	}()
+
+	go func() {
+		e.cmdReadErr(e.stderr)
+		wg.Done()
+	}()
+
+	wg.Wait()
+	return e.cmd.Wait()
+}
+
+// cmdReadOut mirrors stdout to the log, the process is not expected to
+// write anything but health check answers on it.
+func (e *Execd) cmdReadOut(out io.Reader) {
+	if e.HealthCheckInterval > 0 {
+		out = process.NewPongReader(out, e.health.Pong)
+	}
+
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "#") {
+			continue
+		}
+		e.Log.Infof("stdout: %q", scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stdout: %v", err)
+	}
+}
+
+// cmdReadErr reads the results of the batches, and mirrors the other lines
+// to the log.
+func (e *Execd) cmdReadErr(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == resultOK:
+			e.report(nil)
+		case strings.HasPrefix(line, resultError):
+			e.report(parseError(strings.TrimPrefix(line, resultError)))
+		default:
+			e.Log.Errorf("stderr: %q", line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stderr: %v", err)
+	}
+}
+
+// parseError returns the error of the code and message reported by the
+// process.  All codes but codeDrop are retried.
+func parseError(s string) error {
+	parts := strings.SplitN(s, " ", 2)
+	msg := parts[0]
+	if len(parts) == 2 {
+		msg = parts[1]
+	}
+	if parts[0] == codeDrop {
+		return &dropError{msg: msg}
+	}
+	return errors.New(msg)
+}
+
+func init() {
+	outputs.Add("execd", func() telegraf.Output {
+		return &Execd{
+			RestartDelay:       config.Duration(10 * time.Second),
+			RestartDelayMax:    config.Duration(5 * time.Minute),
+			HealthCheckTimeout: config.Duration(5 * time.Second),
+			Timeout:            config.Duration(30 * time.Second),
+		}
+	})
+}
@@ -0,0 +1,80 @@
+// +build !windows
+
+package execd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// resultScript reports the results of the batches by the name of their
+// first metric.
+const resultScript = `
+result=""
+while read line; do
+  case "$line" in
+    "# end") echo "$result" >&2; result="" ;;
+    ok*) [ -z "$result" ] && result="# ok" ;;
+    drop*) [ -z "$result" ] && result="# error drop invalid metric" ;;
+    *) [ -z "$result" ] && result="# error retry unavailable" ;;
+  esac
+done
+`
+
+func TestExternalOutputWorks(t *testing.T) {
+	e := &Execd{
+		Command:      []string{"sh", "-c", resultScript},
+		RestartDelay: config.Duration(5 * time.Second),
+		Timeout:      config.Duration(10 * time.Second),
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, e.Init())
+	require.NoError(t, e.Connect())
+	defer e.Close()
+
+	require.NoError(t, e.Write([]telegraf.Metric{newMetric("ok"), newMetric("other")}))
+
+	// Dropped batches are not written again
+	require.NoError(t, e.Write([]telegraf.Metric{newMetric("drop")}))
+
+	require.EqualError(t, e.Write([]telegraf.Metric{newMetric("retry")}), "unavailable")
+}
+
+func TestWriteTimeout(t *testing.T) {
+	e := &Execd{
+		Command:      []string{"cat"},
+		RestartDelay: config.Duration(5 * time.Second),
+		Timeout:      config.Duration(10 * time.Millisecond),
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, e.Init())
+	require.NoError(t, e.Connect())
+	defer e.Close()
+
+	require.Error(t, e.Write([]telegraf.Metric{newMetric("ok")}))
+}
+
+func TestProcessTerminated(t *testing.T) {
+	e := &Execd{
+		Command:      []string{"sh", "-c", "read line"},
+		RestartDelay: config.Duration(5 * time.Second),
+		Timeout:      config.Duration(10 * time.Second),
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, e.Init())
+	require.NoError(t, e.Connect())
+	defer e.Close()
+
+	require.Error(t, e.Write([]telegraf.Metric{newMetric("ok")}))
+}
+
+func newMetric(name string) telegraf.Metric {
+	m, _ := metric.New(name, map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	return m
+}
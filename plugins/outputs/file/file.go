@@ -4,6 +4,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
@@ -12,21 +17,33 @@ import (
 	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
+// cleanupInterval is the minimum time between two removals of the expired
+// templated files, and the time after which a templated file not written to
+// is closed.
+const cleanupInterval = time.Minute
+
 type File struct {
 	Files               []string          `toml:"files"`
 	RotationInterval    internal.Duration `toml:"rotation_interval"`
 	RotationMaxSize     internal.Size     `toml:"rotation_max_size"`
 	RotationMaxArchives int               `toml:"rotation_max_archives"`
+	RotationCompression string            `toml:"rotation_compression"`
+	Retention           internal.Duration `toml:"retention"`
 	UseBatchFormat      bool              `toml:"use_batch_format"`
 	Log                 telegraf.Logger   `toml:"-"`
 
-	writer     io.Writer
-	closers    []io.Closer
-	serializer serializers.Serializer
+	writer      io.Writer
+	closers     []io.Closer
+	templates   []*templateFile
+	lastCleanup time.Time
+	serializer  serializers.Serializer
 }
 
 var sampleConfig = `
-  ## Files to write to, "stdout" is a specially handled file.
+  ## Files to write to, "stdout" is a specially handled file.  A file name can
+  ## be a Go template, executed for each metric with its name, tags, fields
+  ## and time, to partition the metrics in several files.
+  ## ex: files = ['/var/metrics/{{.Time.Format "2006-01-02"}}/{{.Tag "host"}}.out']
   files = ["stdout", "/tmp/metrics.out"]
 
   ## Use batch serialization format instead of line based delimiting.  The
@@ -46,6 +63,15 @@ var sampleConfig = `
   ## If set to -1, no archives are removed.
   # rotation_max_archives = 5
 
+  ## Compression of the rotated archives, "gzip" or "zstd".  When empty the
+  ## archives are not compressed.
+  # rotation_compression = ""
+
+  ## Rotated archives, and files created from a templated name, that are not
+  ## modified for longer than the retention are deleted.  When set to 0 no
+  ## file is deleted by age.
+  # retention = "0s"
+
   ## Data format to output.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -57,6 +83,12 @@ func (f *File) SetSerializer(serializer serializers.Serializer) {
 	f.serializer = serializer
 }
 
+// StructuredFields returns true if the serializer writes the arrays and maps of
+// the fields as such.
+func (f *File) StructuredFields() bool {
+	return serializers.StructuredFields(f.serializer)
+}
+
 func (f *File) Connect() error {
 	writers := []io.Writer{}
 
@@ -67,9 +99,15 @@ func (f *File) Connect() error {
 	for _, file := range f.Files {
 		if file == "stdout" {
 			writers = append(writers, os.Stdout)
+		} else if strings.Contains(file, "{{") {
+			t, err := newTemplateFile(file)
+			if err != nil {
+				return err
+			}
+			f.templates = append(f.templates, t)
 		} else {
-			of, err := rotate.NewFileWriter(
-				file, f.RotationInterval.Duration, f.RotationMaxSize.Size, f.RotationMaxArchives)
+			of, err := rotate.NewFileWriterWithOptions(
+				file, f.RotationInterval.Duration, f.RotationMaxSize.Size, f.RotationMaxArchives, f.rotateOptions())
 			if err != nil {
 				return err
 			}
@@ -78,10 +116,21 @@ func (f *File) Connect() error {
 			f.closers = append(f.closers, of)
 		}
 	}
-	f.writer = io.MultiWriter(writers...)
+	if len(writers) > 0 {
+		f.writer = io.MultiWriter(writers...)
+	}
+
+	f.cleanup()
 	return nil
 }
 
+func (f *File) rotateOptions() rotate.Options {
+	return rotate.Options{
+		Compression: f.RotationCompression,
+		MaxAge:      f.Retention.Duration,
+	}
+}
+
 func (f *File) Close() error {
 	var err error
 	for _, c := range f.closers {
@@ -90,6 +139,16 @@ func (f *File) Close() error {
 			err = errClose
 		}
 	}
+	for _, t := range f.templates {
+		for path, w := range t.writers {
+			errClose := w.Close()
+			if errClose != nil {
+				err = errClose
+			}
+			delete(t.writers, path)
+			delete(t.lastUsed, path)
+		}
+	}
 	return err
 }
 
@@ -104,13 +163,54 @@ func (f *File) Description() string {
 func (f *File) Write(metrics []telegraf.Metric) error {
 	var writeErr error = nil
 
+	if f.writer != nil {
+		writeErr = f.write(f.writer, metrics)
+	}
+
+	for _, t := range f.templates {
+		// Group the metrics by file to write them in batch format
+		var paths []string
+		groups := make(map[string][]telegraf.Metric)
+		for _, metric := range metrics {
+			path, err := t.path(metric)
+			if err != nil {
+				f.Log.Errorf("Could not execute file name template: %v", err)
+				continue
+			}
+			if _, ok := groups[path]; !ok {
+				paths = append(paths, path)
+			}
+			groups[path] = append(groups[path], metric)
+		}
+
+		for _, path := range paths {
+			w, err := f.open(t, path)
+			if err != nil {
+				writeErr = fmt.Errorf("E! [outputs.file] failed to open file: %v", err)
+				continue
+			}
+			if err := f.write(w, groups[path]); err != nil {
+				writeErr = err
+			}
+		}
+	}
+
+	if time.Since(f.lastCleanup) >= cleanupInterval {
+		f.cleanup()
+	}
+	return writeErr
+}
+
+func (f *File) write(w io.Writer, metrics []telegraf.Metric) error {
+	var writeErr error = nil
+
 	if f.UseBatchFormat {
 		octets, err := f.serializer.SerializeBatch(metrics)
 		if err != nil {
 			f.Log.Errorf("Could not serialize metric: %v", err)
 		}
 
-		_, err = f.writer.Write(octets)
+		_, err = w.Write(octets)
 		if err != nil {
 			f.Log.Errorf("Error writing to file: %v", err)
 		}
@@ -121,7 +221,7 @@ func (f *File) Write(metrics []telegraf.Metric) error {
 				f.Log.Debugf("Could not serialize metric: %v", err)
 			}
 
-			_, err = f.writer.Write(b)
+			_, err = w.Write(b)
 			if err != nil {
 				writeErr = fmt.Errorf("E! [outputs.file] failed to write message: %v", err)
 			}
@@ -131,6 +231,156 @@ func (f *File) Write(metrics []telegraf.Metric) error {
 	return writeErr
 }
 
+// open returns the writer of the file of a templated name, creating its
+// directory if needed.
+func (f *File) open(t *templateFile, path string) (io.Writer, error) {
+	if w, ok := t.writers[path]; ok {
+		t.lastUsed[path] = time.Now()
+		return w, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	// The file is not rotated when closed as it is not written to anymore,
+	// it is reopened if metrics are written to it again.
+	options := f.rotateOptions()
+	options.KeepOnClose = true
+	w, err := rotate.NewFileWriterWithOptions(
+		path, f.RotationInterval.Duration, f.RotationMaxSize.Size, f.RotationMaxArchives, options)
+	if err != nil {
+		return nil, err
+	}
+	t.writers[path] = w
+	t.lastUsed[path] = time.Now()
+	return w, nil
+}
+
+// cleanup closes the templated files not written to recently, and removes
+// the templated files older than the retention.
+func (f *File) cleanup() {
+	f.lastCleanup = time.Now()
+	for _, t := range f.templates {
+		for path, lastUsed := range t.lastUsed {
+			if time.Since(lastUsed) < cleanupInterval {
+				continue
+			}
+			if err := t.writers[path].Close(); err != nil {
+				f.Log.Errorf("Error closing file: %v", err)
+			}
+			delete(t.writers, path)
+			delete(t.lastUsed, path)
+		}
+
+		if f.Retention.Duration > 0 {
+			if err := t.removeExpired(f.Retention.Duration); err != nil {
+				f.Log.Errorf("Error removing expired files: %v", err)
+			}
+		}
+	}
+}
+
+var templateAction = regexp.MustCompile(`\{\{.*?\}\}`)
+
+// templateFile is a file with a templated name, written to by the writers
+// of the files created from it.
+type templateFile struct {
+	tmpl *template.Template
+	// globs match the files created from the template, and their archives
+	globs    []string
+	partDirs bool
+	writers  map[string]io.WriteCloser
+	lastUsed map[string]time.Time
+}
+
+func newTemplateFile(name string) (*templateFile, error) {
+	tmpl, err := template.New("file").Parse(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file name template %q: %v", name, err)
+	}
+
+	// The archives have the rotation time inserted before the extension,
+	// and the extension of the compression appended.
+	glob := templateAction.ReplaceAllString(name, "*")
+	ext := filepath.Ext(glob)
+	glob = strings.TrimSuffix(glob, ext) + "*" + ext
+	for strings.Contains(glob, "**") {
+		glob = strings.Replace(glob, "**", "*", -1)
+	}
+	return &templateFile{
+		tmpl:     tmpl,
+		globs:    []string{glob, glob + ".gz", glob + ".zst"},
+		partDirs: strings.Contains(filepath.Dir(name), "{{"),
+		writers:  make(map[string]io.WriteCloser),
+		lastUsed: make(map[string]time.Time),
+	}, nil
+}
+
+func (t *templateFile) path(metric telegraf.Metric) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, &templateMetric{metric}); err != nil {
+		return "", err
+	}
+	return filepath.Clean(b.String()), nil
+}
+
+// removeExpired removes the files created from the template that are not
+// open and not modified for longer than the retention, and the directories
+// of the partitions left empty.
+func (t *templateFile) removeExpired(retention time.Duration) error {
+	var matches []string
+	for _, glob := range t.globs {
+		m, err := filepath.Glob(glob)
+		if err != nil {
+			return err
+		}
+		matches = append(matches, m...)
+	}
+
+	expired := time.Now().Add(-retention)
+	for _, path := range matches {
+		if _, ok := t.writers[path]; ok {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || !info.ModTime().Before(expired) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		if t.partDirs {
+			// Fails unless the directory is empty
+			os.Remove(filepath.Dir(path))
+		}
+	}
+	return nil
+}
+
+// templateMetric is the metric passed to the file name templates.
+type templateMetric struct {
+	metric telegraf.Metric
+}
+
+func (m *templateMetric) Name() string {
+	return m.metric.Name()
+}
+
+func (m *templateMetric) Tag(key string) string {
+	tagString, _ := m.metric.GetTag(key)
+	return tagString
+}
+
+func (m *templateMetric) Field(key string) interface{} {
+	field, _ := m.metric.GetField(key)
+	return field
+}
+
+func (m *templateMetric) Time() time.Time {
+	return m.metric.Time()
+}
+
 func init() {
 	outputs.Add("file", func() telegraf.Output {
 		return &File{}
@@ -5,10 +5,13 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/telegraf/testutil"
@@ -180,6 +183,49 @@ func TestFileStdout(t *testing.T) {
 	assert.Equal(t, expNewFile, out)
 }
 
+func TestFileTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, _ := serializers.NewInfluxSerializer()
+	f := File{
+		Files:      []string{filepath.Join(dir, `{{.Time.UTC.Format "2006-01-02"}}`, `{{.Tag "host"}}.out`)},
+		Retention:  internal.Duration{Duration: 24 * time.Hour},
+		serializer: s,
+		Log:        testutil.Logger{},
+	}
+
+	// A partition expired long ago
+	expired := filepath.Join(dir, "2009-11-09", "a.out")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(expired), 0755))
+	assert.NoError(t, ioutil.WriteFile(expired, []byte("expired\n"), 0644))
+	past := time.Now().Add(-48 * time.Hour)
+	assert.NoError(t, os.Chtimes(expired, past, past))
+
+	err = f.Connect()
+	assert.NoError(t, err)
+
+	tm := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}, tm),
+		testutil.MustMetric("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 2.0}, tm),
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 3.0}, tm.Add(2*time.Hour)),
+	}
+	err = f.Write(metrics)
+	assert.NoError(t, err)
+	err = f.Close()
+	assert.NoError(t, err)
+
+	validateFile(filepath.Join(dir, "2009-11-10", "a.out"), "cpu,host=a value=1 1257894000000000000\n", t)
+	validateFile(filepath.Join(dir, "2009-11-10", "b.out"), "cpu,host=b value=2 1257894000000000000\n", t)
+	validateFile(filepath.Join(dir, "2009-11-11", "a.out"), "cpu,host=a value=3 1257901200000000000\n", t)
+
+	// The expired partition is removed with its directory
+	_, err = os.Stat(filepath.Dir(expired))
+	assert.True(t, os.IsNotExist(err))
+}
+
 func createFile() *os.File {
 	f, err := ioutil.TempFile("", "")
 	if err != nil {
@@ -16,8 +16,9 @@ import (
 )
 
 type Graphite struct {
-	GraphiteTagSupport bool
-	GraphiteSeparator  string
+	GraphiteTagSupport     bool
+	GraphiteSeparator      string
+	GraphiteStrictSanitize bool
 	// URL is only for backwards compatibility
 	Servers   []string
 	Prefix    string
@@ -26,6 +27,8 @@ type Graphite struct {
 	Timeout   int
 	conns     []net.Conn
 	tlsint.ClientConfig
+
+	serializer serializers.Serializer
 }
 
 var sampleConfig = `
@@ -45,6 +48,10 @@ var sampleConfig = `
   ## Character for separating metric name and field for Graphite tags
   # graphite_separator = "."
 
+  ## Replace all characters not allowed by Graphite in metric paths, tag names
+  ## and tag values with an underscore, instead of the historical rules.
+  # graphite_strict_sanitize = false
+
   ## Graphite templates patterns
   ## 1. Template for cpu
   ## 2. Template for disk*
@@ -147,19 +154,17 @@ func checkEOF(conn net.Conn) {
 // Choose a random server in the cluster to write to until a successful write
 // occurs, logging each unsuccessful. If all servers fail, return error.
 func (g *Graphite) Write(metrics []telegraf.Metric) error {
-	// Prepare data
-	var batch []byte
-	s, err := serializers.NewGraphiteSerializer(g.Prefix, g.Template, g.GraphiteTagSupport, g.GraphiteSeparator, g.Templates)
-	if err != nil {
-		return err
-	}
-
-	for _, metric := range metrics {
-		buf, err := s.Serialize(metric)
+	if g.serializer == nil {
+		s, err := serializers.NewGraphiteSerializer(g.Prefix, g.Template, g.GraphiteTagSupport, g.GraphiteStrictSanitize, g.GraphiteSeparator, g.Templates)
 		if err != nil {
-			log.Printf("E! Error serializing some metrics to graphite: %s", err.Error())
+			return err
 		}
-		batch = append(batch, buf...)
+		g.serializer = s
+	}
+
+	batch, err := g.serializer.SerializeBatch(metrics)
+	if err != nil {
+		return err
 	}
 
 	err = g.send(batch)
@@ -0,0 +1,300 @@
+package honeycomb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+// maxBatchSize is the maximum number of events sent in one request.
+const maxBatchSize = 1000
+
+type Honeycomb struct {
+	URL             string            `toml:"url"`
+	APIKey          string            `toml:"api_key"`
+	Dataset         string            `toml:"dataset"`
+	SampleRate      int               `toml:"sample_rate"`
+	FieldSeparator  string            `toml:"field_separator"`
+	Timeout         internal.Duration `toml:"timeout"`
+	ContentEncoding string            `toml:"content_encoding"`
+	tls.ClientConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	client *http.Client
+	// sampled returns true if an event is kept at the sample rate.
+	sampled func(rate int) bool
+}
+
+var sampleConfig = `
+  ## Events API endpoint.
+  # url = "https://api.honeycomb.io"
+
+  ## API key of the team and dataset the events are sent to.
+  api_key = ""
+  dataset = "telegraf"
+
+  ## Only one event out of sample_rate is sent, the events sent carry the
+  ## sample rate so that the counts are computed as if all were sent.
+  # sample_rate = 1
+
+  ## Separator between the measurement name and the field key in the names
+  ## of the event fields.
+  # field_separator = "."
+
+  ## Timeout for HTTP requests.
+  # timeout = "5s"
+
+  ## Content encoding of the requests, "gzip" or "identity".
+  # content_encoding = "gzip"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+func (h *Honeycomb) SampleConfig() string {
+	return sampleConfig
+}
+
+func (h *Honeycomb) Description() string {
+	return "Send metrics as wide events to Honeycomb"
+}
+
+func (h *Honeycomb) Init() error {
+	if h.APIKey == "" {
+		return fmt.Errorf("api_key is required")
+	}
+	if h.Dataset == "" {
+		return fmt.Errorf("dataset is required")
+	}
+	if h.SampleRate < 1 {
+		return fmt.Errorf("sample_rate must be at least 1")
+	}
+	switch h.ContentEncoding {
+	case "", "identity", "gzip":
+	default:
+		return fmt.Errorf("unknown content_encoding %q", h.ContentEncoding)
+	}
+
+	if h.sampled == nil {
+		r := rand.New(rand.NewSource(time.Now().UnixNano()))
+		h.sampled = func(rate int) bool {
+			return rate <= 1 || r.Intn(rate) == 0
+		}
+	}
+	return nil
+}
+
+func (h *Honeycomb) Connect() error {
+	tlsCfg, err := h.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	h.client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+			Proxy:           http.ProxyFromEnvironment,
+		},
+		Timeout: h.Timeout.Duration,
+	}
+	return nil
+}
+
+func (h *Honeycomb) Close() error {
+	return nil
+}
+
+// event is a wide event of the batch API.
+type event struct {
+	Time       string                 `json:"time"`
+	SampleRate int                    `json:"samplerate,omitempty"`
+	Data       map[string]interface{} `json:"data"`
+
+	// indexes of the metrics of the event in the written batch
+	metrics []int
+}
+
+// eventResponse is the status of an event returned by the batch API.
+type eventResponse struct {
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+}
+
+func (h *Honeycomb) Write(metrics []telegraf.Metric) error {
+	events := h.events(metrics)
+
+	var rejected []int
+	var reasons []error
+	for start := 0; start < len(events); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(events) {
+			end = len(events)
+		}
+		batch := events[start:end]
+
+		responses, err := h.send(batch)
+		if err != nil {
+			return err
+		}
+
+		// Rejected events would be rejected again, they are not retried.
+		for i, resp := range responses {
+			if i >= len(batch) || resp.Status == http.StatusAccepted {
+				continue
+			}
+			reason := fmt.Errorf("event rejected with status %d: %s", resp.Status, resp.Error)
+			for _, index := range batch[i].metrics {
+				rejected = append(rejected, index)
+				reasons = append(reasons, reason)
+			}
+		}
+	}
+
+	if len(rejected) > 0 {
+		return &internal.PartialWriteError{
+			MetricsReject:       rejected,
+			MetricsRejectErrors: reasons,
+		}
+	}
+	return nil
+}
+
+// events groups the fields of the metrics sharing their tags and time into
+// wide events, and samples these events.  The event fields are named after
+// the measurement and the field key.
+func (h *Honeycomb) events(metrics []telegraf.Metric) []*event {
+	var events []*event
+	series := make(map[string]*event)
+	for i, m := range metrics {
+		key := seriesKey(m)
+		e, ok := series[key]
+		if !ok {
+			e = &event{
+				Time: m.Time().UTC().Format(time.RFC3339Nano),
+				Data: make(map[string]interface{}, len(m.TagList())+len(m.FieldList())),
+			}
+			for _, tag := range m.TagList() {
+				e.Data[tag.Key] = tag.Value
+			}
+			series[key] = e
+			events = append(events, e)
+		}
+
+		for _, field := range m.FieldList() {
+			value := field.Value
+			if v, ok := value.(float64); ok && (math.IsNaN(v) || math.IsInf(v, 0)) {
+				continue
+			}
+			e.Data[m.Name()+h.FieldSeparator+field.Key] = value
+		}
+		e.metrics = append(e.metrics, i)
+	}
+
+	if h.SampleRate <= 1 {
+		return events
+	}
+
+	sampled := events[:0]
+	for _, e := range events {
+		if h.sampled(h.SampleRate) {
+			e.SampleRate = h.SampleRate
+			sampled = append(sampled, e)
+		}
+	}
+	return sampled
+}
+
+// seriesKey identifies the tags and time of the metric.
+func seriesKey(m telegraf.Metric) string {
+	var b strings.Builder
+	b.WriteString(strconv.FormatInt(m.Time().UnixNano(), 10))
+	for _, tag := range m.TagList() {
+		b.WriteByte('\n')
+		b.WriteString(tag.Key)
+		b.WriteByte('=')
+		b.WriteString(tag.Value)
+	}
+	return b.String()
+}
+
+func (h *Honeycomb) send(events []*event) ([]eventResponse, error) {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.ContentEncoding == "gzip" {
+		enc, err := internal.NewGzipEncoder()
+		if err != nil {
+			return nil, err
+		}
+		body, err = enc.Encode(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	u := strings.TrimRight(h.URL, "/") + "/1/batch/" + url.PathEscape(h.Dataset)
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", internal.ProductToken())
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Honeycomb-Team", h.APIKey)
+	if h.ContentEncoding == "gzip" {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("when writing to [%s] received status code: %d: %s",
+			u, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var responses []eventResponse
+	if err := json.Unmarshal(respBody, &responses); err != nil {
+		return nil, fmt.Errorf("decoding response failed: %v", err)
+	}
+	return responses, nil
+}
+
+func init() {
+	outputs.Add("honeycomb", func() telegraf.Output {
+		return &Honeycomb{
+			URL:             "https://api.honeycomb.io",
+			Dataset:         "telegraf",
+			SampleRate:      1,
+			FieldSeparator:  ".",
+			Timeout:         internal.Duration{Duration: 5 * time.Second},
+			ContentEncoding: "gzip",
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package honeycomb
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type request struct {
+	path   string
+	team   string
+	events []map[string]interface{}
+}
+
+func newServer(t *testing.T, requests *[]request, statuses func(n int) []eventResponse) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reader, err := internal.NewStreamContentDecoder(r.Header.Get("Content-Encoding"), r.Body)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(reader)
+		require.NoError(t, err)
+
+		var events []map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &events))
+		*requests = append(*requests, request{
+			path:   r.URL.Path,
+			team:   r.Header.Get("X-Honeycomb-Team"),
+			events: events,
+		})
+
+		require.NoError(t, json.NewEncoder(w).Encode(statuses(len(events))))
+	}))
+}
+
+func accepted(n int) []eventResponse {
+	responses := make([]eventResponse, n)
+	for i := range responses {
+		responses[i].Status = http.StatusAccepted
+	}
+	return responses
+}
+
+func newHoneycomb(u string) *Honeycomb {
+	return &Honeycomb{
+		URL:             u,
+		APIKey:          "secret",
+		Dataset:         "hosts",
+		SampleRate:      1,
+		FieldSeparator:  ".",
+		Timeout:         internal.Duration{Duration: 5 * time.Second},
+		ContentEncoding: "gzip",
+		Log:             testutil.Logger{},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var requests []request
+	ts := newServer(t, &requests, accepted)
+	defer ts.Close()
+
+	h := newHoneycomb(ts.URL)
+	require.NoError(t, h.Init())
+	require.NoError(t, h.Connect())
+
+	now := time.Unix(1600000000, 5)
+	require.NoError(t, h.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"usage": 1.5},
+			now),
+		testutil.MustMetric("mem",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"used": int64(42)},
+			now),
+		testutil.MustMetric("mem",
+			map[string]string{"host": "b"},
+			map[string]interface{}{"used": int64(7)},
+			now),
+		testutil.MustMetric("mem",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"used": int64(43)},
+			now.Add(time.Second)),
+	}))
+
+	require.Len(t, requests, 1)
+	require.Equal(t, "/1/batch/hosts", requests[0].path)
+	require.Equal(t, "secret", requests[0].team)
+	require.Equal(t, []map[string]interface{}{
+		{
+			"time": "2020-09-13T12:26:40.000000005Z",
+			"data": map[string]interface{}{"host": "a", "cpu.usage": 1.5, "mem.used": 42.0},
+		},
+		{
+			"time": "2020-09-13T12:26:40.000000005Z",
+			"data": map[string]interface{}{"host": "b", "mem.used": 7.0},
+		},
+		{
+			"time": "2020-09-13T12:26:41.000000005Z",
+			"data": map[string]interface{}{"host": "a", "mem.used": 43.0},
+		},
+	}, requests[0].events)
+}
+
+func TestWriteSampled(t *testing.T) {
+	var requests []request
+	ts := newServer(t, &requests, accepted)
+	defer ts.Close()
+
+	h := newHoneycomb(ts.URL)
+	h.SampleRate = 4
+	kept := 0
+	h.sampled = func(rate int) bool {
+		require.Equal(t, 4, rate)
+		kept++
+		return kept%2 == 1
+	}
+	require.NoError(t, h.Init())
+	require.NoError(t, h.Connect())
+
+	require.NoError(t, h.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 1.0}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", map[string]string{"host": "b"}, map[string]interface{}{"usage": 2.0}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", map[string]string{"host": "c"}, map[string]interface{}{"usage": 3.0}, time.Unix(0, 0)),
+	}))
+
+	require.Len(t, requests, 1)
+	require.Len(t, requests[0].events, 2)
+	for _, e := range requests[0].events {
+		require.Equal(t, 4.0, e["samplerate"])
+	}
+	require.Equal(t, "a", requests[0].events[0]["data"].(map[string]interface{})["host"])
+	require.Equal(t, "c", requests[0].events[1]["data"].(map[string]interface{})["host"])
+}
+
+func TestWriteRejected(t *testing.T) {
+	var requests []request
+	ts := newServer(t, &requests, func(n int) []eventResponse {
+		responses := accepted(n)
+		responses[0] = eventResponse{Status: http.StatusBadRequest, Error: "invalid"}
+		return responses
+	})
+	defer ts.Close()
+
+	h := newHoneycomb(ts.URL)
+	require.NoError(t, h.Init())
+	require.NoError(t, h.Connect())
+
+	err := h.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 1.0}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", map[string]string{"host": "b"}, map[string]interface{}{"usage": 2.0}, time.Unix(0, 0)),
+		testutil.MustMetric("mem", map[string]string{"host": "a"}, map[string]interface{}{"used": 3.0}, time.Unix(0, 0)),
+	})
+	partial, ok := err.(*internal.PartialWriteError)
+	require.True(t, ok)
+	require.Equal(t, []int{0, 2}, partial.MetricsReject)
+	require.Len(t, partial.MetricsRejectErrors, 2)
+}
+
+func TestWriteError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"unknown API key"}`))
+	}))
+	defer ts.Close()
+
+	h := newHoneycomb(ts.URL)
+	require.NoError(t, h.Init())
+	require.NoError(t, h.Connect())
+	require.Error(t, h.Write(testutil.MockMetrics()))
+}
+
+func TestInit(t *testing.T) {
+	h := newHoneycomb("")
+	h.SampleRate = 0
+	require.Error(t, h.Init())
+
+	h = newHoneycomb("")
+	h.APIKey = ""
+	require.Error(t, h.Init())
+}
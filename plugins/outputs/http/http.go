@@ -94,6 +94,12 @@ func (h *HTTP) SetSerializer(serializer serializers.Serializer) {
 	h.serializer = serializer
 }
 
+// StructuredFields returns true if the serializer writes the arrays and maps of
+// the fields as such.
+func (h *HTTP) StructuredFields() bool {
+	return serializers.StructuredFields(h.serializer)
+}
+
 func (h *HTTP) createClient(ctx context.Context) (*http.Client, error) {
 	tlsCfg, err := h.ClientConfig.TLSConfig()
 	if err != nil {
@@ -159,16 +165,46 @@ func (h *HTTP) SampleConfig() string {
 }
 
 func (h *HTTP) Write(metrics []telegraf.Metric) error {
-	reqBody, err := h.serializer.SerializeBatch(metrics)
-	if err != nil {
+	// Metrics that can not be serialized are reported as rejected so that
+	// they are not retried along with the rest of the batch.
+	reqBody, err := serializers.SerializeBatchPartial(h.serializer, metrics)
+	partial, isPartial := err.(*internal.PartialWriteError)
+	if err != nil && !isPartial {
 		return err
 	}
+	if isPartial && len(partial.MetricsReject) == len(metrics) {
+		return partial
+	}
 
-	if err := h.write(reqBody); err != nil {
-		return err
+	if writeErr := h.write(reqBody); writeErr != nil {
+		if _, ok := writeErr.(*invalidDataError); ok {
+			// The request is not retried, so all metrics are rejected.
+			reject := make([]int, 0, len(metrics))
+			for i := range metrics {
+				reject = append(reject, i)
+			}
+			return &internal.PartialWriteError{MetricsReject: reject}
+		}
+
+		if isPartial {
+			partial.Err = writeErr
+			return partial
+		}
+		return writeErr
 	}
 
-	return nil
+	return err
+}
+
+// invalidDataError is returned when the server refuses the request because
+// of its content, retrying the same request would fail again.
+type invalidDataError struct {
+	url        string
+	statusCode int
+}
+
+func (e *invalidDataError) Error() string {
+	return fmt.Sprintf("when writing to [%s] received status code: %d", e.url, e.statusCode)
 }
 
 func (h *HTTP) write(reqBody []byte) error {
@@ -212,6 +248,11 @@ func (h *HTTP) write(reqBody []byte) error {
 	defer resp.Body.Close()
 	_, err = ioutil.ReadAll(resp.Body)
 
+	switch resp.StatusCode {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return &invalidDataError{url: h.URL, statusCode: resp.StatusCode}
+	}
+
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return fmt.Errorf("when writing to [%s] received status code: %d", h.URL, resp.StatusCode)
 	}
@@ -164,6 +164,19 @@ func TestStatusCode(t *testing.T) {
 				require.Error(t, err)
 			},
 		},
+		{
+			name: "400 status rejects the metrics",
+			plugin: &HTTP{
+				URL: u.String(),
+			},
+			statusCode: http.StatusBadRequest,
+			errFunc: func(t *testing.T, err error) {
+				partial, ok := err.(*internal.PartialWriteError)
+				require.True(t, ok)
+				require.Equal(t, []int{0}, partial.MetricsReject)
+				require.NoError(t, partial.Err)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -11,6 +12,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"regexp"
 	"strings"
 	"time"
 
@@ -36,6 +38,10 @@ var (
 		`\`, `\\`,
 		`"`, `\"`,
 	)
+
+	// Field type conflict of a partial write, the field, measurement and
+	// type of the rejected field are captured.
+	fieldTypeConflict = regexp.MustCompile(`field type conflict: input field "([^"]*)" on measurement "([^"]*)" is type (\w+)`)
 )
 
 // APIError is a general error reported by the InfluxDB server
@@ -263,7 +269,8 @@ func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error
 	}
 
 	batches := make(map[dbrp][]telegraf.Metric)
-	for _, metric := range metrics {
+	indexes := make(map[dbrp][]int)
+	for i, metric := range metrics {
 		db, ok := metric.GetTag(c.config.DatabaseTag)
 		if !ok {
 			db = c.config.Database
@@ -292,8 +299,12 @@ func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error
 		}
 
 		batches[dbrp] = append(batches[dbrp], metric)
+		indexes[dbrp] = append(indexes[dbrp], i)
 	}
 
+	// The metrics rejected by the requests of the batches are reported
+	// together, with their index into the metrics written.
+	rejected := &internal.PartialWriteError{}
 	for dbrp, batch := range batches {
 		if !c.config.SkipDatabaseCreation && !c.createDatabaseExecuted[dbrp.Database] {
 			err := c.CreateDatabase(ctx, dbrp.Database)
@@ -304,10 +315,22 @@ func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error
 		}
 
 		err := c.writeBatch(ctx, dbrp.Database, dbrp.RetentionPolicy, batch)
+		if partial, ok := err.(*internal.PartialWriteError); ok {
+			rejected.AddRejected(partial, indexes[dbrp])
+			err = partial.Err
+		}
 		if err != nil {
+			if len(rejected.MetricsReject) > 0 {
+				rejected.Err = err
+				return rejected
+			}
 			return err
 		}
 	}
+
+	if len(rejected.MetricsReject) > 0 {
+		return rejected
+	}
 	return nil
 }
 
@@ -375,20 +398,20 @@ func (c *httpClient) writeBatch(ctx context.Context, db, rp string, metrics []te
 	}
 
 	// Other partial write errors, such as "field type conflict", are not
-	// correctable at this point and so the point is dropped instead of
+	// correctable at this point and so the points are rejected instead of
 	// retrying.
 	if strings.Contains(desc, errStringPartialWrite) {
-		c.log.Errorf("When writing to [%s]: received error %v; discarding points",
+		c.log.Errorf("When writing to [%s]: received error %v; rejecting points",
 			c.URL(), desc)
-		return nil
+		return rejectPartialWrite(desc, metrics)
 	}
 
 	// This error indicates a bug in either Telegraf line protocol
 	// serialization, retries would not be successful.
 	if strings.Contains(desc, errStringUnableToParse) {
-		c.log.Errorf("When writing to [%s]: received error %v; discarding points",
+		c.log.Errorf("When writing to [%s]: received error %v; rejecting points",
 			c.URL(), desc)
-		return nil
+		return internal.RejectAll(len(metrics), errors.New(desc))
 	}
 
 	return &APIError{
@@ -398,6 +421,55 @@ func (c *httpClient) writeBatch(ctx context.Context, db, rp string, metrics []te
 	}
 }
 
+// rejectPartialWrite returns the metrics dropped by a partial write.  For a
+// field type conflict the metrics with the conflicting field are rejected,
+// the response does not tell which metrics were dropped otherwise and the
+// whole batch is rejected.
+func rejectPartialWrite(desc string, metrics []telegraf.Metric) *internal.PartialWriteError {
+	match := fieldTypeConflict.FindStringSubmatch(desc)
+	if match == nil {
+		return internal.RejectAll(len(metrics), errors.New(desc))
+	}
+	field, measurement, typ := match[1], match[2], match[3]
+
+	partial := &internal.PartialWriteError{}
+	for i, m := range metrics {
+		if m.Name() != measurement {
+			continue
+		}
+		value, ok := m.GetField(field)
+		if !ok || !isFieldType(value, typ) {
+			continue
+		}
+		partial.MetricsReject = append(partial.MetricsReject, i)
+		partial.MetricsRejectErrors = append(partial.MetricsRejectErrors, errors.New(desc))
+	}
+
+	if len(partial.MetricsReject) == 0 {
+		return internal.RejectAll(len(metrics), errors.New(desc))
+	}
+	return partial
+}
+
+// isFieldType returns true if the field value is written with the line
+// protocol type.  Unsigned integers are written as integers unless the
+// unsigned support is enabled.
+func isFieldType(value interface{}, typ string) bool {
+	switch value.(type) {
+	case float64:
+		return typ == "float"
+	case int64:
+		return typ == "integer"
+	case uint64:
+		return typ == "unsigned" || typ == "integer"
+	case string:
+		return typ == "string"
+	case bool:
+		return typ == "boolean"
+	}
+	return false
+}
+
 func (c *httpClient) makeQueryRequest(query string) (*http.Request, error) {
 	queryURL, err := makeQueryURL(c.config.URL)
 	if err != nil {
@@ -381,7 +381,7 @@ func TestHTTP_Write(t *testing.T) {
 			},
 		},
 		{
-			name: "partial write errors are logged no error",
+			name: "partial write errors are logged and reject the metrics",
 			config: influxdb.HTTPConfig{
 				URL:      u,
 				Database: "telegraf",
@@ -389,14 +389,20 @@ func TestHTTP_Write(t *testing.T) {
 			},
 			queryHandlerFunc: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(http.StatusBadRequest)
-				w.Write([]byte(`{"error": "partial write: field type conflict:"}`))
+				w.Write([]byte(`{"error": "partial write: field type conflict: input field \"value\" on measurement \"cpu\" is type float, already exists as type integer dropped=1"}`))
+			},
+			errFunc: func(t *testing.T, err error) {
+				partial, ok := err.(*internal.PartialWriteError)
+				require.True(t, ok)
+				require.Equal(t, []int{0}, partial.MetricsReject)
+				require.NoError(t, partial.Err)
 			},
 			logFunc: func(t *testing.T, str string) {
 				require.Contains(t, str, "partial write")
 			},
 		},
 		{
-			name: "parse errors are logged no error",
+			name: "parse errors are logged and reject the metrics",
 			config: influxdb.HTTPConfig{
 				URL:      u,
 				Database: "telegraf",
@@ -406,6 +412,12 @@ func TestHTTP_Write(t *testing.T) {
 				w.WriteHeader(http.StatusBadRequest)
 				w.Write([]byte(`{"error": "unable to parse 'cpu value': invalid field format"}`))
 			},
+			errFunc: func(t *testing.T, err error) {
+				partial, ok := err.(*internal.PartialWriteError)
+				require.True(t, ok)
+				require.Equal(t, []int{0}, partial.MetricsReject)
+				require.NoError(t, partial.Err)
+			},
 			logFunc: func(t *testing.T, str string) {
 				require.Contains(t, str, "unable to parse")
 			},
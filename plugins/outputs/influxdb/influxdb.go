@@ -220,6 +220,10 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 		}
 
 		switch apiError := err.(type) {
+		case *internal.PartialWriteError:
+			// The metrics are rejected by the database, the other
+			// addresses would reject them as well.
+			return apiError
 		case *DatabaseNotFoundError:
 			if !i.SkipDatabaseCreation {
 				err := client.CreateDatabase(ctx, apiError.Database)
@@ -1,6 +1,8 @@
 package influxdb_v2
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/tls"
 	"encoding/json"
@@ -13,11 +15,14 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 )
 
@@ -38,6 +43,13 @@ const (
 	defaultRequestTimeout = time.Second * 5
 	defaultMaxWait        = 10 // seconds
 	defaultDatabase       = "telegraf"
+	errStringPartialWrite = "partial write"
+)
+
+var (
+	// Field type conflict of a partial write, the field, measurement and
+	// type of the rejected field are captured.
+	fieldTypeConflict = regexp.MustCompile(`field type conflict: input field "([^"]*)" on measurement "([^"]*)" is type (\w+)`)
 )
 
 type HTTPConfig struct {
@@ -55,6 +67,10 @@ type HTTPConfig struct {
 	TLSConfig        *tls.Config
 
 	Serializer *influx.Serializer
+
+	// Pool serializes and compresses the batches concurrently when set,
+	// otherwise the Serializer streams them.
+	Pool *serializers.Pool
 }
 
 type httpClient struct {
@@ -68,6 +84,7 @@ type httpClient struct {
 
 	client     *http.Client
 	serializer *influx.Serializer
+	pool       *serializers.Pool
 	url        *url.URL
 	retryTime  time.Time
 }
@@ -129,6 +146,7 @@ func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
 
 	client := &httpClient{
 		serializer: serializer,
+		pool:       config.Pool,
 		client: &http.Client{
 			Timeout:   timeout,
 			Transport: transport,
@@ -173,13 +191,14 @@ func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error
 	}
 
 	batches := make(map[string][]telegraf.Metric)
+	indexes := make(map[string][]int)
 	if c.BucketTag == "" {
 		err := c.writeBatch(ctx, c.Bucket, metrics)
 		if err != nil {
 			return err
 		}
 	} else {
-		for _, metric := range metrics {
+		for i, metric := range metrics {
 			bucket, ok := metric.GetTag(c.BucketTag)
 			if !ok {
 				bucket = c.Bucket
@@ -197,14 +216,30 @@ func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error
 			}
 
 			batches[bucket] = append(batches[bucket], metric)
+			indexes[bucket] = append(indexes[bucket], i)
 		}
 
+		// The metrics rejected by the requests of the batches are
+		// reported together, with their index into the metrics written.
+		rejected := &internal.PartialWriteError{}
 		for bucket, batch := range batches {
 			err := c.writeBatch(ctx, bucket, batch)
+			if partial, ok := err.(*internal.PartialWriteError); ok {
+				rejected.AddRejected(partial, indexes[bucket])
+				err = partial.Err
+			}
 			if err != nil {
+				if len(rejected.MetricsReject) > 0 {
+					rejected.Err = err
+					return rejected
+				}
 				return err
 			}
 		}
+
+		if len(rejected.MetricsReject) > 0 {
+			return rejected
+		}
 	}
 	return nil
 }
@@ -245,9 +280,14 @@ func (c *httpClient) writeBatch(ctx context.Context, bucket string, metrics []te
 	}
 
 	switch resp.StatusCode {
-	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusRequestEntityTooLarge:
+		// The request is not retried, so the metrics dropped by a partial
+		// write, or else all metrics, are rejected.
 		log.Printf("E! [outputs.influxdb_v2] Failed to write metric: %s\n", desc)
-		return nil
+		if strings.Contains(desc, errStringPartialWrite) {
+			return rejectPartialWrite(desc, metrics)
+		}
+		return internal.RejectAll(len(metrics), errors.New(desc))
 	case http.StatusUnauthorized, http.StatusForbidden:
 		return fmt.Errorf("failed to write metric: %s", desc)
 	case http.StatusTooManyRequests:
@@ -287,6 +327,55 @@ func (c *httpClient) writeBatch(ctx context.Context, bucket string, metrics []te
 	}
 }
 
+// rejectPartialWrite returns the metrics dropped by a partial write.  For a
+// field type conflict the metrics with the conflicting field are rejected,
+// the response does not tell which metrics were dropped otherwise and the
+// whole batch is rejected.
+func rejectPartialWrite(desc string, metrics []telegraf.Metric) *internal.PartialWriteError {
+	match := fieldTypeConflict.FindStringSubmatch(desc)
+	if match == nil {
+		return internal.RejectAll(len(metrics), errors.New(desc))
+	}
+	field, measurement, typ := match[1], match[2], match[3]
+
+	partial := &internal.PartialWriteError{}
+	for i, m := range metrics {
+		if m.Name() != measurement {
+			continue
+		}
+		value, ok := m.GetField(field)
+		if !ok || !isFieldType(value, typ) {
+			continue
+		}
+		partial.MetricsReject = append(partial.MetricsReject, i)
+		partial.MetricsRejectErrors = append(partial.MetricsRejectErrors, errors.New(desc))
+	}
+
+	if len(partial.MetricsReject) == 0 {
+		return internal.RejectAll(len(metrics), errors.New(desc))
+	}
+	return partial
+}
+
+// isFieldType returns true if the field value is written with the line
+// protocol type.  Unsigned integers are written as integers unless the
+// unsigned support is enabled.
+func isFieldType(value interface{}, typ string) bool {
+	switch value.(type) {
+	case float64:
+		return typ == "float"
+	case int64:
+		return typ == "integer"
+	case uint64:
+		return typ == "unsigned" || typ == "integer"
+	case string:
+		return typ == "string"
+	case bool:
+		return typ == "boolean"
+	}
+	return false
+}
+
 func (c *httpClient) makeWriteRequest(url string, body io.Reader) (*http.Request, error) {
 	var err error
 
@@ -308,6 +397,10 @@ func (c *httpClient) makeWriteRequest(url string, body io.Reader) (*http.Request
 // requestBodyReader warp io.Reader from influx.NewReader to io.ReadCloser, which is usefully to fast close the write
 // side of the connection in case of error
 func (c *httpClient) requestBodyReader(metrics []telegraf.Metric) (io.ReadCloser, error) {
+	if c.pool != nil {
+		return c.parallelBodyReader(metrics)
+	}
+
 	reader := influx.NewReader(metrics, c.serializer)
 
 	if c.ContentEncoding == "gzip" {
@@ -322,6 +415,40 @@ func (c *httpClient) requestBodyReader(metrics []telegraf.Metric) (io.ReadCloser
 	return ioutil.NopCloser(reader), nil
 }
 
+// parallelBodyReader serializes and compresses the chunks of the metrics with
+// the workers of the pool.  The gzip members of the chunks are concatenated,
+// which is a valid gzip stream of the whole batch.
+func (c *httpClient) parallelBodyReader(metrics []telegraf.Metric) (io.ReadCloser, error) {
+	var encode func([]byte) ([]byte, error)
+	if c.ContentEncoding == "gzip" {
+		encode = compress
+	}
+
+	chunks, err := c.pool.SerializeBatch(metrics, encode)
+	if err != nil {
+		return nil, err
+	}
+
+	readers := make([]io.Reader, 0, len(chunks))
+	for _, chunk := range chunks {
+		readers = append(readers, bytes.NewReader(chunk))
+	}
+	return ioutil.NopCloser(io.MultiReader(readers...)), nil
+}
+
+// compress returns the data compressed as a gzip member.
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 func (c *httpClient) addHeaders(req *http.Request) {
 	for header, value := range c.Headers {
 		req.Header.Set(header, value)
@@ -1,16 +1,23 @@
 package influxdb_v2_test
 
 import (
+	"compress/gzip"
 	"context"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/models"
 	influxdb "github.com/influxdata/telegraf/plugins/outputs/influxdb_v2"
+	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -111,3 +118,123 @@ func TestWriteBucketTagWorksOnRetry(t *testing.T) {
 	err = client.Write(ctx, metrics)
 	require.NoError(t, err)
 }
+
+func TestWriteSerializationWorkers(t *testing.T) {
+	var metrics []telegraf.Metric
+	var expected []string
+	for i := 0; i < 1000; i++ {
+		metrics = append(metrics, testutil.MustMetric(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{
+				"value": i,
+			},
+			time.Unix(0, 0),
+		))
+		expected = append(expected, fmt.Sprintf("cpu value=%di 0", i))
+	}
+
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+
+			gr, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			body, err := ioutil.ReadAll(gr)
+			require.NoError(t, err)
+			require.Equal(t, strings.Join(expected, "\n")+"\n", string(body))
+
+			w.WriteHeader(http.StatusNoContent)
+		}),
+	)
+	defer ts.Close()
+
+	pool, err := serializers.NewPool(4, func() (serializers.Serializer, error) {
+		return influx.NewSerializer(), nil
+	})
+	require.NoError(t, err)
+
+	config := &influxdb.HTTPConfig{
+		URL:             genURL(ts.URL),
+		Bucket:          "telegraf",
+		ContentEncoding: "gzip",
+		Pool:            pool,
+	}
+
+	client, err := influxdb.NewHTTPClient(config)
+	require.NoError(t, err)
+
+	err = client.Write(context.Background(), metrics)
+	require.NoError(t, err)
+}
+
+type discardOutput struct{}
+
+func (o *discardOutput) Connect() error                  { return nil }
+func (o *discardOutput) Close() error                    { return nil }
+func (o *discardOutput) Description() string             { return "" }
+func (o *discardOutput) SampleConfig() string            { return "" }
+func (o *discardOutput) Write(_ []telegraf.Metric) error { return nil }
+
+func TestWriteBadRequestDeadLetter(t *testing.T) {
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"invalid","message":"unable to parse 'cpu value': invalid field format"}`))
+		}),
+	)
+	defer ts.Close()
+
+	output := &influxdb.InfluxDB{
+		URLs:   []string{ts.URL},
+		Bucket: "telegraf",
+	}
+	require.NoError(t, output.Connect())
+
+	ro := models.NewRunningOutput("influxdb_v2", output,
+		&models.OutputConfig{Name: "influxdb_v2"}, 1000, 10000)
+	deadLetter := models.NewRunningOutput("file", &discardOutput{},
+		&models.OutputConfig{Name: "file", Alias: "dead"}, 1000, 10000)
+	ro.SetDeadLetter(deadLetter)
+
+	ro.AddMetric(testutil.MustMetric("cpu", map[string]string{},
+		map[string]interface{}{"value": 42}, time.Unix(0, 0)))
+
+	// The rejected metrics are not retried but sent to the dead letter
+	// output.
+	require.NoError(t, ro.Write())
+	require.Equal(t, 0, ro.BufferLength())
+	require.Equal(t, 1, deadLetter.BufferLength())
+}
+
+func TestWriteFieldTypeConflict(t *testing.T) {
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"code":"unprocessable entity","message":"failure writing points to database: partial write: field type conflict: input field \"value\" on measurement \"cpu\" is type integer, already exists as type float dropped=1"}`))
+		}),
+	)
+	defer ts.Close()
+
+	config := &influxdb.HTTPConfig{
+		URL:    genURL(ts.URL),
+		Bucket: "telegraf",
+	}
+	client, err := influxdb.NewHTTPClient(config)
+	require.NoError(t, err)
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{},
+			map[string]interface{}{"value": 42.0}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", map[string]string{},
+			map[string]interface{}{"value": 42}, time.Unix(0, 0)),
+		testutil.MustMetric("mem", map[string]string{},
+			map[string]interface{}{"value": 42}, time.Unix(0, 0)),
+	}
+
+	err = client.Write(context.Background(), metrics)
+	partial, ok := err.(*internal.PartialWriteError)
+	require.True(t, ok)
+	require.Equal(t, []int{1}, partial.MetricsReject)
+	require.NoError(t, partial.Err)
+}
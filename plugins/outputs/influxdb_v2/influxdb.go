@@ -13,6 +13,7 @@ import (
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 )
 
@@ -63,6 +64,11 @@ var sampleConfig = `
   ## compress body or "identity" to apply no encoding.
   # content_encoding = "gzip"
 
+  ## The number of workers serializing and compressing the metrics of a batch
+  ## concurrently, increase it when a single CPU core can not keep up with the
+  ## metrics.  The metrics are always written in order.
+  # serialization_workers = 1
+
   ## Enable or disable uint support for writing uints influxdb 2.0.
   # influx_uint_support = false
 
@@ -82,18 +88,19 @@ type Client interface {
 }
 
 type InfluxDB struct {
-	URLs             []string          `toml:"urls"`
-	Token            string            `toml:"token"`
-	Organization     string            `toml:"organization"`
-	Bucket           string            `toml:"bucket"`
-	BucketTag        string            `toml:"bucket_tag"`
-	ExcludeBucketTag bool              `toml:"exclude_bucket_tag"`
-	Timeout          internal.Duration `toml:"timeout"`
-	HTTPHeaders      map[string]string `toml:"http_headers"`
-	HTTPProxy        string            `toml:"http_proxy"`
-	UserAgent        string            `toml:"user_agent"`
-	ContentEncoding  string            `toml:"content_encoding"`
-	UintSupport      bool              `toml:"influx_uint_support"`
+	URLs                 []string          `toml:"urls"`
+	Token                string            `toml:"token"`
+	Organization         string            `toml:"organization"`
+	Bucket               string            `toml:"bucket"`
+	BucketTag            string            `toml:"bucket_tag"`
+	ExcludeBucketTag     bool              `toml:"exclude_bucket_tag"`
+	Timeout              internal.Duration `toml:"timeout"`
+	HTTPHeaders          map[string]string `toml:"http_headers"`
+	HTTPProxy            string            `toml:"http_proxy"`
+	UserAgent            string            `toml:"user_agent"`
+	ContentEncoding      string            `toml:"content_encoding"`
+	UintSupport          bool              `toml:"influx_uint_support"`
+	SerializationWorkers int               `toml:"serialization_workers"`
 	tls.ClientConfig
 
 	clients []Client
@@ -165,6 +172,12 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 			return nil
 		}
 
+		// The metrics are rejected by the database, the other addresses
+		// would reject them as well.
+		if _, ok := err.(*internal.PartialWriteError); ok {
+			return err
+		}
+
 		log.Printf("E! [outputs.influxdb_v2] when writing to [%s]: %v", client.URL(), err)
 	}
 
@@ -177,6 +190,16 @@ func (i *InfluxDB) getHTTPClient(ctx context.Context, url *url.URL, proxy *url.U
 		return nil, err
 	}
 
+	var pool *serializers.Pool
+	if i.SerializationWorkers > 1 {
+		pool, err = serializers.NewPool(i.SerializationWorkers, func() (serializers.Serializer, error) {
+			return i.newSerializer(), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	config := &HTTPConfig{
 		URL:              url,
 		Token:            i.Token,
@@ -191,6 +214,7 @@ func (i *InfluxDB) getHTTPClient(ctx context.Context, url *url.URL, proxy *url.U
 		ContentEncoding:  i.ContentEncoding,
 		TLSConfig:        tlsConfig,
 		Serializer:       i.newSerializer(),
+		Pool:             pool,
 	}
 
 	c, err := NewHTTPClient(config)
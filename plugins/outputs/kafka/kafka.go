@@ -39,6 +39,8 @@ type (
 		MaxRetry         int         `toml:"max_retry"`
 		MaxMessageBytes  int         `toml:"max_message_bytes"`
 
+		SerializationWorkers int `toml:"serialization_workers"`
+
 		Version string `toml:"version"`
 
 		// Legacy TLS config options
@@ -63,7 +65,9 @@ type (
 		producerFunc func(addrs []string, config *sarama.Config) (sarama.SyncProducer, error)
 		producer     sarama.SyncProducer
 
-		serializer serializers.Serializer
+		serializer     serializers.Serializer
+		serializerFunc serializers.SerializerFunc
+		pool           *serializers.Pool
 	}
 	TopicSuffix struct {
 		Method    string   `toml:"method"`
@@ -192,6 +196,11 @@ var sampleConfig = `
   ## smaller than the broker's 'message.max.bytes'.
   # max_message_bytes = 1000000
 
+  ## The number of workers serializing the metrics of a batch concurrently,
+  ## increase it when a single CPU core can not keep up with the metrics.
+  ## The messages are always sent in the order of the metrics.
+  # serialization_workers = 1
+
   ## Optional TLS Config
   # enable_tls = true
   # tls_ca = "/etc/telegraf/ca.pem"
@@ -263,6 +272,10 @@ func (k *Kafka) SetSerializer(serializer serializers.Serializer) {
 	k.serializer = serializer
 }
 
+func (k *Kafka) SetSerializerFunc(fn serializers.SerializerFunc) {
+	k.serializerFunc = fn
+}
+
 func (k *Kafka) Connect() error {
 	err := ValidateTopicSuffixMethod(k.TopicSuffix.Method)
 	if err != nil {
@@ -332,6 +345,19 @@ func (k *Kafka) Connect() error {
 		config.Net.SASL.Version = version
 	}
 
+	// The serializer set by SetSerializer is enough for a single worker.
+	newSerializer := k.serializerFunc
+	if newSerializer == nil || k.SerializationWorkers <= 1 {
+		newSerializer = func() (serializers.Serializer, error) {
+			return k.serializer, nil
+		}
+	}
+	pool, err := serializers.NewPool(k.SerializationWorkers, newSerializer)
+	if err != nil {
+		return err
+	}
+	k.pool = pool
+
 	producer, err := k.producerFunc(k.Brokers, config)
 	if err != nil {
 		return err
@@ -372,19 +398,26 @@ func (k *Kafka) routingKey(metric telegraf.Metric) (string, error) {
 }
 
 func (k *Kafka) Write(metrics []telegraf.Metric) error {
-	msgs := make([]*sarama.ProducerMessage, 0, len(metrics))
-	for _, metric := range metrics {
-		metric, topic := k.GetTopicName(metric)
+	// The metrics of the batch are not modified, as they are acknowledged
+	// after the write.
+	batch := make([]telegraf.Metric, len(metrics))
+	topics := make([]string, len(metrics))
+	for i, metric := range metrics {
+		batch[i], topics[i] = k.GetTopicName(metric)
+	}
 
-		buf, err := k.serializer.Serialize(metric)
-		if err != nil {
-			k.Log.Debugf("Could not serialize metric: %v", err)
+	bufs, errs := k.pool.Serialize(batch)
+
+	msgs := make([]*sarama.ProducerMessage, 0, len(batch))
+	for i, metric := range batch {
+		if errs[i] != nil {
+			k.Log.Debugf("Could not serialize metric: %v", errs[i])
 			continue
 		}
 
 		m := &sarama.ProducerMessage{
-			Topic: topic,
-			Value: sarama.ByteEncoder(buf),
+			Topic: topics[i],
+			Value: sarama.ByteEncoder(bufs[i]),
 		}
 
 		// Negative timestamps are not allowed by the Kafka protocol.
@@ -1,6 +1,7 @@
 package kafka
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -300,3 +301,38 @@ func TestTopicTag(t *testing.T) {
 		})
 	}
 }
+
+func TestSerializationWorkers(t *testing.T) {
+	k := &Kafka{
+		Topic:                "telegraf",
+		SerializationWorkers: 4,
+		producerFunc:         NewMockProducer,
+	}
+	k.SetSerializerFunc(func() (serializers.Serializer, error) {
+		return serializers.NewInfluxSerializer()
+	})
+	require.NoError(t, k.Connect())
+	require.Equal(t, 4, k.pool.Workers())
+
+	var metrics []telegraf.Metric
+	for i := 0; i < 1000; i++ {
+		metrics = append(metrics, testutil.MustMetric(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{
+				"value": i,
+			},
+			time.Unix(0, 0),
+		))
+	}
+
+	require.NoError(t, k.Write(metrics))
+
+	producer := k.producer.(*MockProducer)
+	require.Len(t, producer.sent, len(metrics))
+	for i, msg := range producer.sent {
+		encoded, err := msg.Value.Encode()
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("cpu value=%di 0\n", i), string(encoded))
+	}
+}
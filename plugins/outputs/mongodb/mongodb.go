@@ -0,0 +1,335 @@
+package mongodb
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const maxInt64 = int64(^uint64(0) >> 1)
+
+// errNamespaceExists is the code of the error returned when creating a
+// collection that already exists.
+const errNamespaceExists = 48
+
+var granularities = map[string]bool{
+	"seconds": true,
+	"minutes": true,
+	"hours":   true,
+}
+
+type MongoDB struct {
+	DSN         string            `toml:"dsn"`
+	Database    string            `toml:"database"`
+	Collection  string            `toml:"collection"`
+	TimeField   string            `toml:"time_field"`
+	MetaField   string            `toml:"meta_field"`
+	Granularity string            `toml:"granularity"`
+	TTL         internal.Duration `toml:"ttl"`
+	Ordered     bool              `toml:"ordered"`
+	Timeout     internal.Duration `toml:"timeout"`
+	tlsint.ClientConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	store       store
+	collections map[string]bool
+}
+
+// store is the database the documents are written into.
+type store interface {
+	CollectionNames() ([]string, error)
+	Run(cmd interface{}) error
+	Insert(collection string, ordered bool, docs []interface{}) error
+	Close()
+}
+
+var sampleConfig = `
+  ## MongoDB connection string, the credentials are given in the string.
+  ## See https://docs.mongodb.com/manual/reference/connection-string/
+  dsn = "mongodb://localhost:27017"
+
+  ## Database the metrics are written into.
+  # database = "telegraf"
+
+  ## Collection the metrics are written into, by default each measurement is
+  ## written into the collection of its name.
+  # collection = ""
+
+  ## Fields of the documents holding the metric time and the metric tags.  The
+  ## tags are written as top level fields if the meta field is empty.
+  # time_field = "timestamp"
+  # meta_field = "tags"
+
+  ## Granularity of the time-series collections created, one of "seconds",
+  ## "minutes" or "hours".  Collections that already exist are not changed.
+  # granularity = "seconds"
+
+  ## Documents older than the ttl are deleted by the server, 0 keeps all
+  ## documents.  Only set on the collections created.
+  # ttl = "0s"
+
+  ## When ordered, the documents of a batch are inserted in order and the
+  ## insertion stops at the first error; unordered inserts are faster.
+  # ordered = true
+
+  ## Timeout for connecting and writing.
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+func (m *MongoDB) SampleConfig() string {
+	return sampleConfig
+}
+
+func (m *MongoDB) Description() string {
+	return "Write metrics into MongoDB time-series collections"
+}
+
+func (m *MongoDB) Init() error {
+	if m.DSN == "" {
+		return fmt.Errorf("dsn is required")
+	}
+	if m.Database == "" {
+		return fmt.Errorf("database is required")
+	}
+	if m.TimeField == "" {
+		return fmt.Errorf("time_field is required")
+	}
+	if m.MetaField == m.TimeField {
+		return fmt.Errorf("meta_field and time_field must differ")
+	}
+	if !granularities[m.Granularity] {
+		return fmt.Errorf("unknown granularity %q", m.Granularity)
+	}
+	return nil
+}
+
+// StructuredFields returns true, the arrays and maps of the fields are written
+// as arrays and embedded documents.
+func (m *MongoDB) StructuredFields() bool {
+	return true
+}
+
+func (m *MongoDB) Connect() error {
+	info, err := mgo.ParseURL(m.DSN)
+	if err != nil {
+		return fmt.Errorf("unable to parse dsn: %v", err)
+	}
+	info.Timeout = m.Timeout.Duration
+
+	tlsConfig, err := m.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	if tlsConfig != nil {
+		info.DialServer = func(addr *mgo.ServerAddr) (net.Conn, error) {
+			dialer := &net.Dialer{Timeout: m.Timeout.Duration}
+			return tls.DialWithDialer(dialer, "tcp", addr.String(), tlsConfig)
+		}
+	}
+
+	session, err := mgo.DialWithInfo(info)
+	if err != nil {
+		return fmt.Errorf("unable to connect to MongoDB: %v", err)
+	}
+	session.SetSocketTimeout(m.Timeout.Duration)
+
+	m.store = &mgoStore{session: session, database: m.Database}
+	return m.loadCollections()
+}
+
+func (m *MongoDB) Close() error {
+	if m.store != nil {
+		m.store.Close()
+		m.store = nil
+	}
+	return nil
+}
+
+func (m *MongoDB) loadCollections() error {
+	names, err := m.store.CollectionNames()
+	if err != nil {
+		return fmt.Errorf("listing collections of %q failed: %v", m.Database, err)
+	}
+
+	m.collections = make(map[string]bool, len(names))
+	for _, name := range names {
+		m.collections[name] = true
+	}
+	return nil
+}
+
+func (m *MongoDB) Write(metrics []telegraf.Metric) error {
+	var order []string
+	batches := make(map[string][]interface{})
+	for _, metric := range metrics {
+		name := m.Collection
+		if name == "" {
+			name = metric.Name()
+		}
+		if _, ok := batches[name]; !ok {
+			order = append(order, name)
+		}
+		batches[name] = append(batches[name], m.document(metric))
+	}
+
+	for _, name := range order {
+		if err := m.createCollection(name); err != nil {
+			return err
+		}
+		if err := m.store.Insert(name, m.Ordered, batches[name]); err != nil {
+			return fmt.Errorf("writing into %q failed: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// createCollection creates the time-series collection unless it exists.
+func (m *MongoDB) createCollection(name string) error {
+	if m.collections[name] {
+		return nil
+	}
+
+	options := bson.D{
+		{Name: "timeField", Value: m.TimeField},
+	}
+	if m.MetaField != "" {
+		options = append(options, bson.DocElem{Name: "metaField", Value: m.MetaField})
+	}
+	options = append(options, bson.DocElem{Name: "granularity", Value: m.Granularity})
+
+	cmd := bson.D{
+		{Name: "create", Value: name},
+		{Name: "timeseries", Value: options},
+	}
+	if m.TTL.Duration > 0 {
+		cmd = append(cmd, bson.DocElem{Name: "expireAfterSeconds", Value: int64(m.TTL.Duration / time.Second)})
+	}
+
+	err := m.store.Run(cmd)
+	if qerr, ok := err.(*mgo.QueryError); ok && qerr.Code == errNamespaceExists {
+		err = nil
+	}
+	if err != nil {
+		return fmt.Errorf("creating collection %q failed: %v", name, err)
+	}
+
+	m.Log.Debugf("Created time-series collection %q", name)
+	m.collections[name] = true
+	return nil
+}
+
+// document returns the document of the metric.  The tags are nested in the
+// meta field, the fields named like the time or meta field are skipped.
+func (m *MongoDB) document(metric telegraf.Metric) bson.M {
+	doc := bson.M{m.TimeField: metric.Time()}
+
+	if len(metric.TagList()) > 0 {
+		tags := doc
+		if m.MetaField != "" {
+			tags = make(bson.M, len(metric.TagList()))
+			doc[m.MetaField] = tags
+		}
+		for _, tag := range metric.TagList() {
+			if tag.Key == m.TimeField {
+				continue
+			}
+			tags[tag.Key] = tag.Value
+		}
+	}
+
+	for _, field := range metric.FieldList() {
+		if field.Key == m.TimeField || field.Key == m.MetaField {
+			continue
+		}
+		doc[field.Key] = value(field.Value)
+	}
+	return doc
+}
+
+// value converts the field value to a BSON value, BSON has no unsigned
+// integers and the values above the maximum int64 are clamped.
+func value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case uint64:
+		if v > uint64(maxInt64) {
+			return maxInt64
+		}
+		return int64(v)
+	case map[string]interface{}:
+		doc := make(bson.M, len(v))
+		for k, item := range v {
+			doc[k] = value(item)
+		}
+		return doc
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = value(item)
+		}
+		return items
+	default:
+		return v
+	}
+}
+
+// mgoStore writes into a database of a MongoDB server.
+type mgoStore struct {
+	session  *mgo.Session
+	database string
+}
+
+func (s *mgoStore) CollectionNames() ([]string, error) {
+	return s.session.DB(s.database).CollectionNames()
+}
+
+func (s *mgoStore) Run(cmd interface{}) error {
+	return s.session.DB(s.database).Run(cmd, nil)
+}
+
+func (s *mgoStore) Insert(collection string, ordered bool, docs []interface{}) error {
+	bulk := s.session.DB(s.database).C(collection).Bulk()
+	if !ordered {
+		bulk.Unordered()
+	}
+	bulk.Insert(docs...)
+
+	_, err := bulk.Run()
+	if err != nil {
+		// Recover the connection if the error is due to the socket.
+		s.session.Refresh()
+	}
+	return err
+}
+
+func (s *mgoStore) Close() {
+	s.session.Close()
+}
+
+func init() {
+	outputs.Add("mongodb", func() telegraf.Output {
+		return &MongoDB{
+			Database:    "telegraf",
+			TimeField:   "timestamp",
+			MetaField:   "tags",
+			Granularity: "seconds",
+			Ordered:     true,
+			Timeout:     internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type insert struct {
+	collection string
+	ordered    bool
+	docs       []interface{}
+}
+
+type fakeStore struct {
+	collections []string
+	commands    []interface{}
+	inserts     []insert
+	runErr      error
+	insertErr   error
+}
+
+func (s *fakeStore) CollectionNames() ([]string, error) {
+	return s.collections, nil
+}
+
+func (s *fakeStore) Run(cmd interface{}) error {
+	s.commands = append(s.commands, cmd)
+	return s.runErr
+}
+
+func (s *fakeStore) Insert(collection string, ordered bool, docs []interface{}) error {
+	s.inserts = append(s.inserts, insert{collection: collection, ordered: ordered, docs: docs})
+	return s.insertErr
+}
+
+func (s *fakeStore) Close() {}
+
+func newMongoDB(store *fakeStore) *MongoDB {
+	return &MongoDB{
+		DSN:         "mongodb://localhost",
+		Database:    "telegraf",
+		TimeField:   "timestamp",
+		MetaField:   "tags",
+		Granularity: "seconds",
+		Ordered:     true,
+		Log:         testutil.Logger{},
+		store:       store,
+	}
+}
+
+func TestInit(t *testing.T) {
+	m := newMongoDB(nil)
+	require.NoError(t, m.Init())
+
+	m.Granularity = "days"
+	require.Error(t, m.Init())
+
+	m = newMongoDB(nil)
+	m.MetaField = "timestamp"
+	require.Error(t, m.Init())
+}
+
+func TestWrite(t *testing.T) {
+	store := &fakeStore{collections: []string{"cpu"}}
+	m := newMongoDB(store)
+	m.TTL = internal.Duration{Duration: 24 * time.Hour}
+	require.NoError(t, m.loadCollections())
+
+	ts := time.Unix(1600000000, 0)
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("mem",
+			map[string]string{"host": "a"},
+			map[string]interface{}{
+				"used":   uint64(1 << 63),
+				"labels": []interface{}{"x", uint64(2)},
+				"tags":   "skipped",
+			},
+			ts),
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"usage": 1.5},
+			ts),
+		testutil.MustMetric("mem",
+			map[string]string{"host": "b"},
+			map[string]interface{}{"used": int64(3)},
+			ts),
+	}
+	require.NoError(t, m.Write(metrics))
+	require.NoError(t, m.Write(metrics[:1]))
+
+	// Only the missing collection is created, once.
+	require.Equal(t, []interface{}{
+		bson.D{
+			{Name: "create", Value: "mem"},
+			{Name: "timeseries", Value: bson.D{
+				{Name: "timeField", Value: "timestamp"},
+				{Name: "metaField", Value: "tags"},
+				{Name: "granularity", Value: "seconds"},
+			}},
+			{Name: "expireAfterSeconds", Value: int64(86400)},
+		},
+	}, store.commands)
+
+	require.Len(t, store.inserts, 3)
+	require.Equal(t, insert{
+		collection: "mem",
+		ordered:    true,
+		docs: []interface{}{
+			bson.M{
+				"timestamp": ts,
+				"tags":      bson.M{"host": "a"},
+				"used":      maxInt64,
+				"labels":    []interface{}{"x", int64(2)},
+			},
+			bson.M{
+				"timestamp": ts,
+				"tags":      bson.M{"host": "b"},
+				"used":      int64(3),
+			},
+		},
+	}, store.inserts[0])
+	require.Equal(t, insert{
+		collection: "cpu",
+		ordered:    true,
+		docs: []interface{}{
+			bson.M{"timestamp": ts, "usage": 1.5},
+		},
+	}, store.inserts[1])
+}
+
+func TestWriteOptions(t *testing.T) {
+	store := &fakeStore{
+		runErr: &mgo.QueryError{Code: errNamespaceExists, Message: "already exists"},
+	}
+	m := newMongoDB(store)
+	m.Collection = "metrics"
+	m.MetaField = ""
+	m.Granularity = "minutes"
+	m.Ordered = false
+	require.NoError(t, m.loadCollections())
+
+	ts := time.Unix(1600000000, 0)
+	require.NoError(t, m.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"usage": 1.5},
+			ts),
+	}))
+
+	require.Equal(t, []interface{}{
+		bson.D{
+			{Name: "create", Value: "metrics"},
+			{Name: "timeseries", Value: bson.D{
+				{Name: "timeField", Value: "timestamp"},
+				{Name: "granularity", Value: "minutes"},
+			}},
+		},
+	}, store.commands)
+	require.Equal(t, []insert{{
+		collection: "metrics",
+		ordered:    false,
+		docs: []interface{}{
+			bson.M{"timestamp": ts, "host": "a", "usage": 1.5},
+		},
+	}}, store.inserts)
+}
+
+func TestWriteErrors(t *testing.T) {
+	metrics := []telegraf.Metric{testutil.TestMetric(1)}
+
+	store := &fakeStore{runErr: errors.New("unauthorized")}
+	m := newMongoDB(store)
+	require.NoError(t, m.loadCollections())
+	require.Error(t, m.Write(metrics))
+	require.Empty(t, store.inserts)
+
+	// The creation is retried with the next write.
+	store.runErr = nil
+	store.insertErr = errors.New("timeout")
+	require.Error(t, m.Write(metrics))
+	require.Len(t, store.commands, 2)
+}
+
+func TestIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	m := &MongoDB{
+		DSN:         "mongodb://" + testutil.GetLocalHost() + ":27017",
+		Database:    "telegraf_test",
+		TimeField:   "timestamp",
+		MetaField:   "tags",
+		Granularity: "seconds",
+		Ordered:     true,
+		Timeout:     internal.Duration{Duration: 5 * time.Second},
+		Log:         testutil.Logger{},
+	}
+	require.NoError(t, m.Init())
+	require.NoError(t, m.Connect())
+	defer m.Close()
+
+	require.NoError(t, m.Write(testutil.MockMetrics()))
+}
@@ -0,0 +1,160 @@
+package prometheus
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/influxdata/telegraf"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// createdTimes is implemented by collectors tracking the creation time of
+// their series.
+type createdTimes interface {
+	Created(family *dto.MetricFamily, metric *dto.Metric) (time.Time, bool)
+}
+
+type createdLookup func(family *dto.MetricFamily, metric *dto.Metric) (time.Time, bool)
+
+func createdFunc(collector Collector) createdLookup {
+	if c, ok := collector.(createdTimes); ok {
+		return c.Created
+	}
+	return nil
+}
+
+// openMetricsHandler serves the OpenMetrics format to clients requesting it,
+// adding the _created samples not written by the expfmt encoder.  Other
+// requests are passed to the next handler.
+type openMetricsHandler struct {
+	gatherer prometheus.Gatherer
+	next     http.Handler
+	created  createdLookup
+	log      telegraf.Logger
+}
+
+func (h *openMetricsHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if expfmt.NegotiateIncludingOpenMetrics(req.Header) != expfmt.FmtOpenMetrics {
+		h.next.ServeHTTP(rw, req)
+		return
+	}
+
+	mfs, err := h.gatherer.Gather()
+	if err != nil {
+		h.log.Errorf("Error gathering metrics: %v", err)
+		if len(mfs) == 0 {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	rw.Header().Set("Content-Type", string(expfmt.FmtOpenMetrics))
+
+	w := io.Writer(rw)
+	if strings.Contains(req.Header.Get("Accept-Encoding"), "gzip") {
+		rw.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		w = gz
+	}
+
+	for _, mf := range mfs {
+		if err := h.writeFamily(w, mf); err != nil {
+			h.log.Errorf("Error encoding metric family %q: %v", mf.GetName(), err)
+			return
+		}
+	}
+	if _, err := expfmt.FinalizeOpenMetrics(w); err != nil {
+		h.log.Errorf("Error encoding metrics: %v", err)
+	}
+}
+
+// writeFamily writes the family in the OpenMetrics format.  Counters,
+// histograms and summaries with a known creation time are followed by a
+// _created sample, which the expfmt encoder does not write.
+func (h *openMetricsHandler) writeFamily(w io.Writer, mf *dto.MetricFamily) error {
+	name, ok := createdName(mf)
+	if !ok || h.created == nil {
+		_, err := expfmt.MetricFamilyToOpenMetrics(w, mf)
+		return err
+	}
+
+	// Encode each metric on its own, as the samples of a metric must be
+	// contiguous, and keep the comments of the first one only.
+	var buf bytes.Buffer
+	for i, m := range mf.Metric {
+		buf.Reset()
+		single := &dto.MetricFamily{
+			Name:   mf.Name,
+			Help:   mf.Help,
+			Type:   mf.Type,
+			Metric: []*dto.Metric{m},
+		}
+		if _, err := expfmt.MetricFamilyToOpenMetrics(&buf, single); err != nil {
+			return err
+		}
+
+		if t, ok := h.created(mf, m); ok {
+			created := &dto.MetricFamily{
+				Name: proto.String(name),
+				Type: dto.MetricType_GAUGE.Enum(),
+				Metric: []*dto.Metric{
+					{
+						Label: m.Label,
+						Gauge: &dto.Gauge{Value: proto.Float64(float64(t.UnixNano()) / float64(time.Second))},
+					},
+				},
+			}
+			if _, err := expfmt.MetricFamilyToOpenMetrics(&buf, created); err != nil {
+				return err
+			}
+		}
+
+		scanner := bufio.NewScanner(&buf)
+		first := true
+		for scanner.Scan() {
+			line := scanner.Text()
+			// The comments of the _created gauge are always dropped.
+			if strings.HasPrefix(line, "#") && (i > 0 || !first) {
+				continue
+			}
+			if !strings.HasPrefix(line, "#") {
+				first = false
+			}
+			if _, err := io.WriteString(w, line+"\n"); err != nil {
+				return err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createdName returns the name of the _created sample of the family, if the
+// family type has one.
+func createdName(mf *dto.MetricFamily) (string, bool) {
+	name := mf.GetName()
+	switch mf.GetType() {
+	case dto.MetricType_COUNTER:
+		// Counters not ending in _total are written as unknown type and have
+		// no _created sample.
+		if !strings.HasSuffix(name, "_total") {
+			return "", false
+		}
+		return strings.TrimSuffix(name, "_total") + "_created", true
+	case dto.MetricType_HISTOGRAM, dto.MetricType_SUMMARY:
+		return name + "_created", true
+	default:
+		return "", false
+	}
+}
@@ -70,6 +70,16 @@ var sampleConfig = `
 
   ## Export metric collection time.
   # export_timestamp = false
+
+  ## Serve the OpenMetrics format to clients requesting it, such as
+  ## Prometheus 2.5.0 and newer.  With metric_version = 2 counters,
+  ## histograms and summaries include a _created sample.
+  # openmetrics = false
+
+  ## Tag containing a trace id, added as exemplar of counters when serving
+  ## the OpenMetrics format.  The tag is not added as a label.  Requires
+  ## metric_version = 2.
+  # exemplar_trace_id_tag = ""
 `
 
 type Collector interface {
@@ -89,6 +99,8 @@ type PrometheusClient struct {
 	CollectorsExclude  []string          `toml:"collectors_exclude"`
 	StringAsLabel      bool              `toml:"string_as_label"`
 	ExportTimestamp    bool              `toml:"export_timestamp"`
+	OpenMetrics        bool              `toml:"openmetrics"`
+	ExemplarTraceIDTag string            `toml:"exemplar_trace_id_tag"`
 	tlsint.ServerConfig
 
 	Log telegraf.Logger `toml:"-"`
@@ -133,13 +145,16 @@ func (p *PrometheusClient) Init() error {
 		fallthrough
 	case 1:
 		p.Log.Warnf("Use of deprecated configuration: metric_version = 1; please update to metric_version = 2")
+		if p.ExemplarTraceIDTag != "" {
+			return fmt.Errorf("exemplar_trace_id_tag requires metric_version = 2")
+		}
 		p.collector = v1.NewCollector(p.ExpirationInterval.Duration, p.StringAsLabel, p.Log)
 		err := registry.Register(p.collector)
 		if err != nil {
 			return err
 		}
 	case 2:
-		p.collector = v2.NewCollector(p.ExpirationInterval.Duration, p.StringAsLabel, p.ExportTimestamp)
+		p.collector = v2.NewCollector(p.ExpirationInterval.Duration, p.StringAsLabel, p.ExportTimestamp, p.ExemplarTraceIDTag)
 		err := registry.Register(p.collector)
 		if err != nil {
 			return err
@@ -158,7 +173,15 @@ func (p *PrometheusClient) Init() error {
 
 	authHandler := internal.AuthHandler(p.BasicUsername, p.BasicPassword, "prometheus", onAuthError)
 	rangeHandler := internal.IPRangeHandler(ipRange, onError)
-	promHandler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+	var promHandler http.Handler = promhttp.HandlerFor(registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+	if p.OpenMetrics {
+		promHandler = &openMetricsHandler{
+			gatherer: registry,
+			next:     promHandler,
+			created:  createdFunc(p.collector),
+			log:      p.Log,
+		}
+	}
 
 	mux := http.NewServeMux()
 	if p.Path == "" {
@@ -402,3 +402,68 @@ rpc_duration_seconds_count 2693
 		})
 	}
 }
+
+func TestOpenMetricsVersion2(t *testing.T) {
+	output := &PrometheusClient{
+		Listen:             ":0",
+		MetricVersion:      2,
+		CollectorsExclude:  []string{"gocollector", "process"},
+		Path:               "/metrics",
+		OpenMetrics:        true,
+		ExemplarTraceIDTag: "trace_id",
+		Log:                testutil.Logger{Name: "outputs.prometheus_client"},
+	}
+	require.NoError(t, output.Init())
+	require.NoError(t, output.Connect())
+	defer func() {
+		require.NoError(t, output.Close())
+	}()
+
+	start := time.Now()
+	err := output.Write([]telegraf.Metric{
+		testutil.MustMetric(
+			"http",
+			map[string]string{"host": "example.org", "trace_id": "abc123"},
+			map[string]interface{}{"requests_total": 42.0},
+			time.Unix(1, 0),
+			telegraf.Counter,
+		),
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{"host": "example.org"},
+			map[string]interface{}{"time_idle": 42.0},
+			time.Unix(1, 0),
+		),
+	})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("GET", output.URL(), nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "application/openmetrics-text; version=0.0.1")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, resp.Header.Get("Content-Type"), "application/openmetrics-text")
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	require.Len(t, lines, 8)
+
+	// The creation time is the time the series was first written.
+	var created float64
+	_, err = fmt.Sscanf(lines[6], `http_requests_created{host="example.org"} %g`, &created)
+	require.NoError(t, err)
+	require.InDelta(t, float64(start.Unix()), created, 5)
+
+	require.Equal(t, []string{
+		`# HELP cpu_time_idle Telegraf collected metric`,
+		`# TYPE cpu_time_idle unknown`,
+		`cpu_time_idle{host="example.org"} 42.0`,
+		`# HELP http_requests Telegraf collected metric`,
+		`# TYPE http_requests counter`,
+		`http_requests_total{host="example.org"} 42.0 # {trace_id="abc123"} 42.0 1.0`,
+		`# EOF`,
+	}, append(lines[:6], lines[7:]...))
+}
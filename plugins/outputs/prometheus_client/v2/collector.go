@@ -43,8 +43,10 @@ type Collector struct {
 	coll           *serializer.Collection
 }
 
-func NewCollector(expire time.Duration, stringsAsLabel bool, exportTimestamp bool) *Collector {
-	config := serializer.FormatConfig{}
+func NewCollector(expire time.Duration, stringsAsLabel bool, exportTimestamp bool, exemplarTraceIDTag string) *Collector {
+	config := serializer.FormatConfig{
+		ExemplarTraceIDTag: exemplarTraceIDTag,
+	}
 	if stringsAsLabel {
 		config.StringHandling = serializer.StringAsLabel
 	}
@@ -83,6 +85,15 @@ func (c *Collector) Collect(ch chan<- prometheus.Metric) {
 	}
 }
 
+// Created returns the creation time of the series of a metric gathered from
+// the collector.
+func (c *Collector) Created(family *dto.MetricFamily, metric *dto.Metric) (time.Time, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.coll.Created(family, metric)
+}
+
 func (c *Collector) Add(metrics []telegraf.Metric) error {
 	c.Lock()
 	defer c.Unlock()
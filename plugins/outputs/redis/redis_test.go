@@ -0,0 +1,167 @@
+package redis
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// server records the commands received and replies to them as Redis.
+type server struct {
+	sync.Mutex
+	listener net.Listener
+	commands [][]string
+}
+
+func newServer(t *testing.T) *server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &server{listener: l}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *server) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		cmd, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		s.Lock()
+		s.commands = append(s.commands, cmd)
+		s.Unlock()
+
+		switch strings.ToLower(cmd[0]) {
+		case "ping":
+			io.WriteString(conn, "+PONG\r\n")
+		case "xadd":
+			io.WriteString(conn, "$3\r\n1-0\r\n")
+		default:
+			io.WriteString(conn, ":1\r\n")
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		cmd = append(cmd, string(buf[:size]))
+	}
+	return cmd, nil
+}
+
+func (s *server) Commands() [][]string {
+	s.Lock()
+	defer s.Unlock()
+	return s.commands
+}
+
+func newRedis(addr string) *Redis {
+	s, _ := serializers.NewInfluxSerializer()
+	return &Redis{
+		Server:                  "tcp://" + addr,
+		Mode:                    modeStream,
+		Key:                     `telegraf:{{.Name}}:{{.Tag "host"}}`,
+		StreamField:             "metric",
+		StreamMaxLen:            100,
+		StreamMaxLenApproximate: true,
+		Timeout:                 internal.Duration{Duration: time.Second},
+		Log:                     testutil.Logger{},
+		serializer:              s,
+	}
+}
+
+var testMetrics = []telegraf.Metric{
+	testutil.MustMetric("cpu",
+		map[string]string{"host": "a"},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0),
+	),
+}
+
+func TestWriteStream(t *testing.T) {
+	s := newServer(t)
+	defer s.listener.Close()
+
+	r := newRedis(s.listener.Addr().String())
+	require.NoError(t, r.Init())
+	require.NoError(t, r.Connect())
+	defer r.Close()
+	require.NoError(t, r.Write(testMetrics))
+
+	commands := s.Commands()
+	require.Equal(t, []string{
+		"xadd", "telegraf:cpu:a", "maxlen", "~", "100", "*", "metric", "cpu,host=a value=42 0\n",
+	}, commands[len(commands)-1])
+}
+
+func TestWritePubSub(t *testing.T) {
+	s := newServer(t)
+	defer s.listener.Close()
+
+	r := newRedis(s.listener.Addr().String())
+	r.Mode = modePubSub
+	r.Key = "metrics"
+	require.NoError(t, r.Init())
+	require.NoError(t, r.Connect())
+	defer r.Close()
+	require.NoError(t, r.Write(testMetrics))
+
+	commands := s.Commands()
+	require.Equal(t, []string{
+		"publish", "metrics", "cpu,host=a value=42 0\n",
+	}, commands[len(commands)-1])
+}
+
+func TestInitErrors(t *testing.T) {
+	r := newRedis("localhost:6379")
+	r.Mode = "list"
+	require.Error(t, r.Init())
+
+	r = newRedis("localhost:6379")
+	r.Key = "{{.Name"
+	require.Error(t, r.Init())
+}
@@ -0,0 +1,308 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const maxInt64 = int64(^uint64(0) >> 1)
+
+// timestampColumn holds the metric time in nanoseconds since the epoch.
+const timestampColumn = "timestamp"
+
+type SQLite struct {
+	Path          string            `toml:"path"`
+	Retention     internal.Duration `toml:"retention"`
+	PruneInterval internal.Duration `toml:"prune_interval"`
+	Log           telegraf.Logger   `toml:"-"`
+
+	db        *sql.DB
+	tables    map[string]map[string]bool
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+var sampleConfig = `
+  ## Database file, created if it does not exist.
+  path = "/var/lib/telegraf/metrics.db"
+
+  ## Metrics older than the retention are deleted, 0 keeps all metrics.
+  # retention = "0s"
+
+  ## Interval at which metrics older than the retention are deleted.
+  # prune_interval = "1h"
+`
+
+func (s *SQLite) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *SQLite) Description() string {
+	return "Write metrics into per-measurement tables of a local SQLite database"
+}
+
+func (s *SQLite) Connect() error {
+	db, err := sql.Open("sqlite3", s.Path)
+	if err != nil {
+		return err
+	}
+
+	// SQLite allows only one writer at a time.
+	db.SetMaxOpenConns(1)
+
+	tables, err := loadTables(db)
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("reading schema of %q failed: %v", s.Path, err)
+	}
+
+	s.db = db
+	s.tables = tables
+	return nil
+}
+
+func (s *SQLite) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	err := s.db.Close()
+	s.db = nil
+	return err
+}
+
+func (s *SQLite) Write(metrics []telegraf.Metric) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range metrics {
+		if err := s.insert(tx, m); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		// The schema may be out of date after a failed transaction.
+		s.reloadTables()
+		return err
+	}
+
+	return s.prune()
+}
+
+// insert writes the metric into the table of its measurement, creating the
+// table and columns as needed.
+func (s *SQLite) insert(tx *sql.Tx, m telegraf.Metric) error {
+	values := map[string]interface{}{
+		timestampColumn: m.Time().UnixNano(),
+	}
+	for _, tag := range m.TagList() {
+		if tag.Key == timestampColumn {
+			continue
+		}
+		values[tag.Key] = tag.Value
+	}
+	for _, field := range m.FieldList() {
+		if field.Key == timestampColumn {
+			continue
+		}
+		values[field.Key] = columnValue(field.Value)
+	}
+
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	if err := s.ensureColumns(tx, m.Name(), columns, values); err != nil {
+		return err
+	}
+
+	args := make([]interface{}, 0, len(columns))
+	quoted := make([]string, 0, len(columns))
+	for _, column := range columns {
+		quoted = append(quoted, quoteIdent(column))
+		args = append(args, values[column])
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		quoteIdent(m.Name()),
+		strings.Join(quoted, ","),
+		strings.TrimSuffix(strings.Repeat("?,", len(columns)), ","))
+	_, err := tx.Exec(query, args...)
+	return err
+}
+
+// ensureColumns creates the table if it does not exist and adds the missing
+// columns to it.
+func (s *SQLite) ensureColumns(tx *sql.Tx, table string, columns []string, values map[string]interface{}) error {
+	known, ok := s.tables[table]
+	if !ok {
+		query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s INTEGER NOT NULL)",
+			quoteIdent(table), quoteIdent(timestampColumn))
+		if _, err := tx.Exec(query); err != nil {
+			return err
+		}
+
+		query = fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)",
+			quoteIdent(table+"_"+timestampColumn), quoteIdent(table), quoteIdent(timestampColumn))
+		if _, err := tx.Exec(query); err != nil {
+			return err
+		}
+
+		known = map[string]bool{timestampColumn: true}
+		s.tables[table] = known
+	}
+
+	for _, column := range columns {
+		if known[column] {
+			continue
+		}
+
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s",
+			quoteIdent(table), quoteIdent(column), columnType(values[column]))
+		if _, err := tx.Exec(query); err != nil {
+			return err
+		}
+		known[column] = true
+	}
+	return nil
+}
+
+// prune deletes the metrics older than the retention, at most once per prune
+// interval.
+func (s *SQLite) prune() error {
+	if s.Retention.Duration <= 0 {
+		return nil
+	}
+
+	now := s.now()
+	if now.Sub(s.lastPrune) < s.PruneInterval.Duration {
+		return nil
+	}
+	s.lastPrune = now
+
+	cutoff := now.Add(-s.Retention.Duration).UnixNano()
+	for table := range s.tables {
+		query := fmt.Sprintf("DELETE FROM %s WHERE %s < ?",
+			quoteIdent(table), quoteIdent(timestampColumn))
+		result, err := s.db.Exec(query, cutoff)
+		if err != nil {
+			return fmt.Errorf("pruning table %q failed: %v", table, err)
+		}
+
+		if n, err := result.RowsAffected(); err == nil && n > 0 {
+			s.Log.Debugf("Pruned %d rows from table %q", n, table)
+		}
+	}
+	return nil
+}
+
+func (s *SQLite) reloadTables() {
+	tables, err := loadTables(s.db)
+	if err != nil {
+		s.Log.Errorf("Reading schema failed: %v", err)
+		return
+	}
+	s.tables = tables
+}
+
+// loadTables returns the columns of all tables in the database.
+func loadTables(db *sql.DB) (map[string]map[string]bool, error) {
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND substr(name, 1, 7) != 'sqlite_'")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tables := make(map[string]map[string]bool, len(names))
+	for _, name := range names {
+		columns, err := loadColumns(db, name)
+		if err != nil {
+			return nil, err
+		}
+		tables[name] = columns
+	}
+	return tables, nil
+}
+
+func loadColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT name FROM pragma_table_info(%s)", quoteString(table)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// columnValue converts a field value to a value supported by the driver.
+func columnValue(value interface{}) interface{} {
+	if v, ok := value.(uint64); ok {
+		if v > uint64(maxInt64) {
+			return maxInt64
+		}
+		return int64(v)
+	}
+	return value
+}
+
+func columnType(value interface{}) string {
+	switch value.(type) {
+	case int64, bool:
+		return "INTEGER"
+	case float64:
+		return "REAL"
+	default:
+		return "TEXT"
+	}
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+func quoteString(s string) string {
+	return `'` + strings.Replace(s, `'`, `''`, -1) + `'`
+}
+
+func init() {
+	outputs.Add("sqlite", func() telegraf.Output {
+		return &SQLite{
+			PruneInterval: internal.Duration{Duration: time.Hour},
+			now:           time.Now,
+		}
+	})
+}
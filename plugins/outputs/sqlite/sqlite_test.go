@@ -0,0 +1,145 @@
+package sqlite
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newSQLite(t *testing.T) (*SQLite, func()) {
+	dir, err := ioutil.TempDir("", "telegraf-sqlite")
+	require.NoError(t, err)
+
+	s := &SQLite{
+		Path:          filepath.Join(dir, "metrics.db"),
+		PruneInterval: internal.Duration{Duration: time.Hour},
+		Log:           testutil.Logger{},
+		now:           time.Now,
+	}
+	require.NoError(t, s.Connect())
+
+	return s, func() {
+		s.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func query(t *testing.T, s *SQLite, q string) [][]interface{} {
+	rows, err := s.db.Query(q)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	require.NoError(t, err)
+
+	var result [][]interface{}
+	for rows.Next() {
+		row := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		require.NoError(t, rows.Scan(ptrs...))
+		result = append(result, row)
+	}
+	require.NoError(t, rows.Err())
+	return result
+}
+
+func TestWrite(t *testing.T) {
+	s, cleanup := newSQLite(t)
+	defer cleanup()
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{"host": "server01"},
+			map[string]interface{}{
+				"usage":  42.5,
+				"count":  int64(7),
+				"big":    uint64(1 << 63),
+				"ok":     true,
+				"status": "up",
+			},
+			time.Unix(10, 0),
+		),
+	}
+	require.NoError(t, s.Write(metrics))
+
+	rows := query(t, s, `SELECT timestamp, host, usage, count, big, ok, status FROM cpu`)
+	require.Equal(t, [][]interface{}{
+		{int64(10e9), "server01", 42.5, int64(7), maxInt64, int64(1), "up"},
+	}, rows)
+}
+
+func TestWriteAddsColumns(t *testing.T) {
+	s, cleanup := newSQLite(t)
+	defer cleanup()
+
+	require.NoError(t, s.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{}, map[string]interface{}{"a": int64(1)}, time.Unix(1, 0)),
+	}))
+	require.NoError(t, s.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "server01"}, map[string]interface{}{"b": int64(2)}, time.Unix(2, 0)),
+	}))
+
+	rows := query(t, s, `SELECT timestamp, host, a, b FROM cpu ORDER BY timestamp`)
+	require.Equal(t, [][]interface{}{
+		{int64(1e9), nil, int64(1), nil},
+		{int64(2e9), "server01", nil, int64(2)},
+	}, rows)
+}
+
+func TestReconnectLoadsSchema(t *testing.T) {
+	s, cleanup := newSQLite(t)
+	defer cleanup()
+
+	require.NoError(t, s.Write([]telegraf.Metric{
+		testutil.MustMetric("my \"table\"", map[string]string{}, map[string]interface{}{"value": int64(1)}, time.Unix(1, 0)),
+	}))
+	require.NoError(t, s.Close())
+
+	require.NoError(t, s.Connect())
+	require.Equal(t, map[string]bool{"timestamp": true, "value": true}, s.tables[`my "table"`])
+
+	require.NoError(t, s.Write([]telegraf.Metric{
+		testutil.MustMetric("my \"table\"", map[string]string{}, map[string]interface{}{"value": int64(2)}, time.Unix(2, 0)),
+	}))
+	rows := query(t, s, `SELECT value FROM "my ""table""" ORDER BY timestamp`)
+	require.Equal(t, [][]interface{}{{int64(1)}, {int64(2)}}, rows)
+}
+
+func TestRetention(t *testing.T) {
+	s, cleanup := newSQLite(t)
+	defer cleanup()
+
+	now := time.Unix(1000, 0)
+	s.now = func() time.Time { return now }
+	s.Retention = internal.Duration{Duration: 100 * time.Second}
+
+	require.NoError(t, s.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{}, map[string]interface{}{"value": int64(1)}, time.Unix(800, 0)),
+		testutil.MustMetric("mem", map[string]string{}, map[string]interface{}{"value": int64(2)}, time.Unix(950, 0)),
+	}))
+	require.Len(t, query(t, s, `SELECT * FROM cpu`), 0)
+	require.Len(t, query(t, s, `SELECT * FROM mem`), 1)
+
+	// No pruning before the prune interval elapsed
+	now = now.Add(time.Minute)
+	require.NoError(t, s.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{}, map[string]interface{}{"value": int64(3)}, time.Unix(900, 0)),
+	}))
+	require.Len(t, query(t, s, `SELECT * FROM cpu`), 1)
+
+	now = now.Add(time.Hour)
+	require.NoError(t, s.Write(nil))
+	require.Len(t, query(t, s, `SELECT * FROM cpu`), 0)
+	require.Len(t, query(t, s, `SELECT * FROM mem`), 0)
+}
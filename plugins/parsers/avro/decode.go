@@ -0,0 +1,198 @@
+package avro
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+)
+
+var errShortBuffer = errors.New("unexpected end of data")
+
+// decoder reads values encoded with the Avro binary encoding.
+type decoder struct {
+	buf []byte
+	pos int
+}
+
+// decode reads a value of the schema.  Records are decoded to a
+// []recordValue, arrays to a []interface{}, maps to a map[string]interface{},
+// timestamps to a time.Time, and the other values to a bool, int64, float64
+// or string, or nil.
+func (d *decoder) decode(s *schema) (interface{}, error) {
+	switch s.Type {
+	case "null":
+		return nil, nil
+	case "boolean":
+		if d.pos >= len(d.buf) {
+			return nil, errShortBuffer
+		}
+		b := d.buf[d.pos]
+		d.pos++
+		return b != 0, nil
+	case "int", "long":
+		n, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		switch s.LogicalType {
+		case "timestamp-millis":
+			return time.Unix(0, n*int64(time.Millisecond)).UTC(), nil
+		case "timestamp-micros":
+			return time.Unix(0, n*int64(time.Microsecond)).UTC(), nil
+		}
+		return n, nil
+	case "float":
+		b, err := d.next(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))), nil
+	case "double":
+		b, err := d.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	case "bytes", "string":
+		n, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("negative length %d", n)
+		}
+		b, err := d.next(int(n))
+		if err != nil {
+			return nil, err
+		}
+		if s.LogicalType == "decimal" {
+			return decimal(b, s.Scale), nil
+		}
+		return string(b), nil
+	case "fixed":
+		b, err := d.next(s.Size)
+		if err != nil {
+			return nil, err
+		}
+		if s.LogicalType == "decimal" {
+			return decimal(b, s.Scale), nil
+		}
+		return string(b), nil
+	case "enum":
+		n, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 || n >= int64(len(s.Symbols)) {
+			return nil, fmt.Errorf("enum %s has no symbol %d", s.Name, n)
+		}
+		return s.Symbols[n], nil
+	case "union":
+		n, err := d.long()
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 || n >= int64(len(s.Branches)) {
+			return nil, fmt.Errorf("union has no branch %d", n)
+		}
+		return d.decode(s.Branches[n])
+	case "record":
+		values := make([]recordValue, 0, len(s.Fields))
+		for _, f := range s.Fields {
+			v, err := d.decode(f.Schema)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %v", s.Name, f.Name, err)
+			}
+			values = append(values, recordValue{name: f.Name, value: v})
+		}
+		return values, nil
+	case "array":
+		var values []interface{}
+		err := d.blocks(func() error {
+			v, err := d.decode(s.Items)
+			values = append(values, v)
+			return err
+		})
+		return values, err
+	case "map":
+		values := make(map[string]interface{})
+		err := d.blocks(func() error {
+			key, err := d.decode(&schema{Type: "string"})
+			if err != nil {
+				return err
+			}
+			v, err := d.decode(s.Values)
+			values[key.(string)] = v
+			return err
+		})
+		return values, err
+	}
+	return nil, fmt.Errorf("unsupported type %q", s.Type)
+}
+
+// recordValue is the value of a record field.
+type recordValue struct {
+	name  string
+	value interface{}
+}
+
+// blocks calls fn for each item of the blocks of an array or map.
+func (d *decoder) blocks(fn func() error) error {
+	for {
+		count, err := d.long()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		// A negative count is followed by the size of the block in bytes
+		if count < 0 {
+			count = -count
+			if _, err := d.long(); err != nil {
+				return err
+			}
+		}
+		for i := int64(0); i < count; i++ {
+			if err := fn(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// long reads a zig-zag encoded variable length integer.
+func (d *decoder) long() (int64, error) {
+	u, n := binary.Uvarint(d.buf[d.pos:])
+	if n <= 0 {
+		return 0, errShortBuffer
+	}
+	d.pos += n
+	return int64(u>>1) ^ -int64(u&1), nil
+}
+
+func (d *decoder) next(n int) ([]byte, error) {
+	if n > len(d.buf)-d.pos {
+		return nil, errShortBuffer
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// decimal converts the two's-complement big-endian unscaled value to a
+// float.
+func decimal(b []byte, scale int) float64 {
+	unscaled := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+	f, _ := new(big.Float).Quo(
+		new(big.Float).SetInt(unscaled),
+		new(big.Float).SetFloat64(math.Pow10(scale)),
+	).Float64()
+	return f
+}
@@ -0,0 +1,225 @@
+package avro
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// Parser decodes Avro messages into metrics.  Messages are either framed
+// like the Confluent serializers, with the ID of their schema in a Schema
+// Registry, or plain Avro binary data of a fixed schema.
+type Parser struct {
+	MetricName      string
+	SchemaRegistry  string
+	Schema          string
+	Measurement     string
+	Tags            []string
+	Fields          []string
+	Timestamp       string
+	TimestampFormat string
+	FieldSeparator  string
+	DefaultTags     map[string]string
+	Now             func() time.Time
+
+	registry *schemaRegistry
+	schema   *schema
+	fields   filter.Filter
+	tags     map[string]bool
+}
+
+// Init validates the configuration and parses the fixed schema.
+func (p *Parser) Init() error {
+	switch {
+	case p.SchemaRegistry != "" && p.Schema != "":
+		return fmt.Errorf("only one of avro_schema_registry and avro_schema can be set")
+	case p.SchemaRegistry != "":
+		registry, err := newSchemaRegistry(p.SchemaRegistry)
+		if err != nil {
+			return err
+		}
+		p.registry = registry
+	case p.Schema != "":
+		s, err := parseSchema(p.Schema)
+		if err != nil {
+			return err
+		}
+		p.schema = s
+	default:
+		return fmt.Errorf("one of avro_schema_registry or avro_schema must be set")
+	}
+
+	var err error
+	if p.fields, err = filter.Compile(p.Fields); err != nil {
+		return fmt.Errorf("invalid avro_fields: %v", err)
+	}
+	p.tags = make(map[string]bool, len(p.Tags))
+	for _, tag := range p.Tags {
+		p.tags[tag] = true
+	}
+	if p.FieldSeparator == "" {
+		p.FieldSeparator = "_"
+	}
+	if p.Now == nil {
+		p.Now = time.Now
+	}
+	return nil
+}
+
+// Parse converts an Avro message to a metric.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	s := p.schema
+	if p.registry != nil {
+		// Confluent framing: a zero magic byte and the ID of the schema
+		if len(buf) < 5 || buf[0] != 0 {
+			return nil, fmt.Errorf("message is not framed with a schema ID")
+		}
+		var err error
+		s, err = p.registry.get(int(binary.BigEndian.Uint32(buf[1:5])))
+		if err != nil {
+			return nil, err
+		}
+		buf = buf[5:]
+	}
+
+	d := &decoder{buf: buf}
+	value, err := d.decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("decoding message: %v", err)
+	}
+	if d.pos != len(buf) {
+		return nil, fmt.Errorf("%d bytes left after decoding message", len(buf)-d.pos)
+	}
+
+	values := make(map[string]interface{})
+	if s.Type == "record" {
+		p.flatten("", value, values)
+	} else {
+		p.flatten("value", value, values)
+	}
+
+	name := p.Measurement
+	if name == "" {
+		name = s.Name
+	}
+	if name == "" {
+		name = p.MetricName
+	}
+
+	timestamp := p.Now()
+	if p.Timestamp != "" {
+		v, ok := values[p.Timestamp]
+		if !ok {
+			return nil, fmt.Errorf("timestamp field %q not found", p.Timestamp)
+		}
+		if timestamp, err = p.parseTimestamp(v); err != nil {
+			return nil, fmt.Errorf("parsing timestamp field %q: %v", p.Timestamp, err)
+		}
+		delete(values, p.Timestamp)
+	}
+
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+	for k, v := range values {
+		if p.tags[k] {
+			tags[k] = format(v)
+			continue
+		}
+		if p.fields != nil && !p.fields.Match(k) {
+			continue
+		}
+		if t, ok := v.(time.Time); ok {
+			v = t.UnixNano()
+		}
+		fields[k] = v
+	}
+
+	for k, v := range p.DefaultTags {
+		if _, ok := tags[k]; !ok {
+			tags[k] = v
+		}
+	}
+
+	m, err := metric.New(name, tags, fields, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return []telegraf.Metric{m}, nil
+}
+
+// ParseLine converts an Avro message to a metric.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+	return metrics[0], nil
+}
+
+// SetDefaultTags sets the tags added to all parsed metrics.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// flatten adds the values to the map, the names of nested values are joined
+// with the field separator.  Null values are omitted.
+func (p *Parser) flatten(name string, value interface{}, values map[string]interface{}) {
+	join := func(key string) string {
+		if name == "" {
+			return key
+		}
+		return name + p.FieldSeparator + key
+	}
+
+	switch v := value.(type) {
+	case nil:
+	case []recordValue:
+		for _, f := range v {
+			p.flatten(join(f.name), f.value, values)
+		}
+	case []interface{}:
+		for i, item := range v {
+			p.flatten(join(strconv.Itoa(i)), item, values)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			p.flatten(join(key), item, values)
+		}
+	default:
+		values[name] = v
+	}
+}
+
+func (p *Parser) parseTimestamp(value interface{}) (time.Time, error) {
+	if t, ok := value.(time.Time); ok {
+		return t, nil
+	}
+
+	format := p.TimestampFormat
+	if format == "" {
+		format = "unix"
+	}
+	return internal.ParseTimestamp(format, value, "")
+}
+
+func format(value interface{}) string {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(value)
+}
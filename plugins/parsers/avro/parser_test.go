@@ -0,0 +1,234 @@
+package avro
+
+import (
+	"encoding/binary"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const sensorSchema = `{
+	"type": "record",
+	"name": "Sensor",
+	"namespace": "com.example",
+	"fields": [
+		{"name": "id", "type": "string"},
+		{"name": "site", "type": {"type": "enum", "name": "Site", "symbols": ["north", "south"]}},
+		{"name": "time", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "temperature", "type": "double"},
+		{"name": "humidity", "type": ["null", "float"]},
+		{"name": "battery", "type": {
+			"type": "record",
+			"name": "Battery",
+			"fields": [
+				{"name": "level", "type": "int"},
+				{"name": "charging", "type": "boolean"}
+			]
+		}},
+		{"name": "errors", "type": {"type": "array", "items": "long"}},
+		{"name": "labels", "type": {"type": "map", "values": "string"}},
+		{"name": "previous", "type": ["null", "Battery"]}
+	]
+}`
+
+// encoder writes values with the Avro binary encoding.
+type encoder []byte
+
+func (e *encoder) long(n int64) *encoder {
+	var b [binary.MaxVarintLen64]byte
+	*e = append(*e, b[:binary.PutUvarint(b[:], uint64((n<<1)^(n>>63)))]...)
+	return e
+}
+
+func (e *encoder) str(s string) *encoder {
+	e.long(int64(len(s)))
+	*e = append(*e, s...)
+	return e
+}
+
+func (e *encoder) float(f float32) *encoder {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], math.Float32bits(f))
+	*e = append(*e, b[:]...)
+	return e
+}
+
+func (e *encoder) double(f float64) *encoder {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], math.Float64bits(f))
+	*e = append(*e, b[:]...)
+	return e
+}
+
+func (e *encoder) boolean(v bool) *encoder {
+	if v {
+		*e = append(*e, 1)
+	} else {
+		*e = append(*e, 0)
+	}
+	return e
+}
+
+func sensorMessage() []byte {
+	e := &encoder{}
+	e.str("s1")
+	e.long(1)                // site: south
+	e.long(1600000000123)    // time
+	e.double(21.5)           // temperature
+	e.long(1).float(0.5)     // humidity: float branch
+	e.long(80).boolean(true) // battery
+	e.long(2).long(3).long(4).long(0)
+	e.long(1).str("room").str("lab").long(0)
+	e.long(0) // previous: null
+	return *e
+}
+
+func TestParse(t *testing.T) {
+	p := &Parser{
+		MetricName: "kafka_consumer",
+		Schema:     sensorSchema,
+		Tags:       []string{"id", "site"},
+		Timestamp:  "time",
+	}
+	require.NoError(t, p.Init())
+
+	metrics, err := p.Parse(sensorMessage())
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("Sensor",
+			map[string]string{
+				"id":   "s1",
+				"site": "south",
+			},
+			map[string]interface{}{
+				"temperature":      21.5,
+				"humidity":         0.5,
+				"battery_level":    int64(80),
+				"battery_charging": true,
+				"errors_0":         int64(3),
+				"errors_1":         int64(4),
+				"labels_room":      "lab",
+			},
+			time.Unix(1600000000, 123000000),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseFieldsAndMeasurement(t *testing.T) {
+	p := &Parser{
+		Schema:         sensorSchema,
+		Measurement:    "sensor",
+		Fields:         []string{"temperature", "battery.*"},
+		FieldSeparator: ".",
+		Now:            func() time.Time { return time.Unix(42, 0) },
+	}
+	require.NoError(t, p.Init())
+
+	metrics, err := p.Parse(sensorMessage())
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("sensor",
+			map[string]string{},
+			map[string]interface{}{
+				"temperature":      21.5,
+				"battery.level":    int64(80),
+				"battery.charging": true,
+			},
+			time.Unix(42, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseSchemaRegistry(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path != "/registry/schemas/ids/7" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error_code": 40403, "message": "Schema not found"}`))
+			return
+		}
+		w.Write([]byte(`{"schema": "{\"type\": \"record\", \"name\": \"cpu\", \"fields\": [{\"name\": \"host\", \"type\": \"string\"}, {\"name\": \"usage\", \"type\": \"double\"}, {\"name\": \"ts\", \"type\": \"long\"}]}"}`))
+	}))
+	defer ts.Close()
+
+	p := &Parser{
+		SchemaRegistry: ts.URL + "/registry",
+		Tags:           []string{"host"},
+		Timestamp:      "ts",
+	}
+	require.NoError(t, p.Init())
+
+	frame := func(id uint32, payload []byte) []byte {
+		buf := []byte{0, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(buf[1:], id)
+		return append(buf, payload...)
+	}
+	payload := (&encoder{}).str("a").double(12.5).long(1600000000)
+
+	for i := 0; i < 2; i++ {
+		metrics, err := p.Parse(frame(7, *payload))
+		require.NoError(t, err)
+		expected := []telegraf.Metric{
+			testutil.MustMetric("cpu",
+				map[string]string{"host": "a"},
+				map[string]interface{}{"usage": 12.5},
+				time.Unix(1600000000, 0),
+			),
+		}
+		testutil.RequireMetricsEqual(t, expected, metrics)
+	}
+	// The schema is cached
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	_, err := p.Parse(frame(8, *payload))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Schema not found")
+
+	_, err = p.Parse(*payload)
+	require.Error(t, err)
+}
+
+func TestParseInvalid(t *testing.T) {
+	p := &Parser{Schema: sensorSchema}
+	require.NoError(t, p.Init())
+
+	msg := sensorMessage()
+	_, err := p.Parse(msg[:len(msg)-3])
+	require.Error(t, err)
+
+	_, err = p.Parse(append(msg, 0))
+	require.Error(t, err)
+}
+
+func TestParseDecimal(t *testing.T) {
+	p := &Parser{
+		MetricName: "price",
+		Schema:     `{"type": "bytes", "logicalType": "decimal", "precision": 6, "scale": 2}`,
+	}
+	require.NoError(t, p.Init())
+
+	// -1234 as two's-complement big-endian
+	metrics, err := p.Parse(*(&encoder{}).str("\xfb\x2e"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	require.Equal(t, "price", metrics[0].Name())
+	require.Equal(t, map[string]interface{}{"value": -12.34}, metrics[0].Fields())
+}
+
+func TestInit(t *testing.T) {
+	require.Error(t, (&Parser{}).Init())
+	require.Error(t, (&Parser{Schema: sensorSchema, SchemaRegistry: "http://localhost:8081"}).Init())
+	require.Error(t, (&Parser{Schema: `{"type": "record", "name": "a", "fields": [{"name": "b", "type": "Unknown"}]}`}).Init())
+}
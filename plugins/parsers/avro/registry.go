@@ -0,0 +1,96 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// schemaRegistry fetches the schemas of a Confluent Schema Registry by ID.
+// The schemas of an ID never change, so they are cached indefinitely.
+type schemaRegistry struct {
+	url    *url.URL
+	client *http.Client
+
+	mu      sync.Mutex
+	schemas map[int]*schema
+}
+
+func newSchemaRegistry(address string) (*schemaRegistry, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema registry url %q: %v", address, err)
+	}
+	// The paths of the API are relative to the path of the registry
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return &schemaRegistry{
+		url:     u,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		schemas: make(map[int]*schema),
+	}, nil
+}
+
+// get returns the schema with the ID.
+func (r *schemaRegistry) get(id int) (*schema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s, ok := r.schemas[id]; ok {
+		return s, nil
+	}
+
+	text, err := r.fetch(id)
+	if err != nil {
+		return nil, fmt.Errorf("fetching schema %d: %v", id, err)
+	}
+	s, err := parseSchema(text)
+	if err != nil {
+		return nil, fmt.Errorf("schema %d: %v", id, err)
+	}
+	r.schemas[id] = s
+	return s, nil
+}
+
+func (r *schemaRegistry) fetch(id int) (string, error) {
+	u, err := r.url.Parse("schemas/ids/" + strconv.Itoa(id))
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("%s returned HTTP status %s: %s", u.Path, resp.Status, body)
+	}
+
+	var result struct {
+		Schema     string `json:"schema"`
+		SchemaType string `json:"schemaType"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.SchemaType != "" && result.SchemaType != "AVRO" {
+		return "", fmt.Errorf("schema of type %s is not supported", result.SchemaType)
+	}
+	return result.Schema, nil
+}
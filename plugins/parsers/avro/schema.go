@@ -0,0 +1,196 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// schema is a parsed Avro schema.
+type schema struct {
+	Type        string
+	Name        string
+	LogicalType string
+
+	// Fields of a record
+	Fields []*recordField
+	// Items of an array
+	Items *schema
+	// Values of a map
+	Values *schema
+	// Branches of a union
+	Branches []*schema
+	// Symbols of an enum
+	Symbols []string
+	// Size of a fixed
+	Size int
+	// Scale of a decimal
+	Scale int
+}
+
+type recordField struct {
+	Name   string
+	Schema *schema
+}
+
+var primitives = map[string]bool{
+	"null":    true,
+	"boolean": true,
+	"int":     true,
+	"long":    true,
+	"float":   true,
+	"double":  true,
+	"bytes":   true,
+	"string":  true,
+}
+
+// parseSchema parses the JSON representation of an Avro schema.
+func parseSchema(text string) (*schema, error) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	p := &schemaParser{named: make(map[string]*schema)}
+	return p.parse(v, "")
+}
+
+// schemaParser resolves the references to the named types, records, enums
+// and fixed, of a schema.
+type schemaParser struct {
+	named map[string]*schema
+}
+
+func (p *schemaParser) parse(v interface{}, namespace string) (*schema, error) {
+	switch v := v.(type) {
+	case string:
+		if primitives[v] {
+			return &schema{Type: v}, nil
+		}
+		if s, ok := p.named[fullName(v, namespace)]; ok {
+			return s, nil
+		}
+		if s, ok := p.named[v]; ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("unknown type %q", v)
+	case []interface{}:
+		s := &schema{Type: "union"}
+		for _, branch := range v {
+			b, err := p.parse(branch, namespace)
+			if err != nil {
+				return nil, err
+			}
+			s.Branches = append(s.Branches, b)
+		}
+		return s, nil
+	case map[string]interface{}:
+		return p.parseComplex(v, namespace)
+	}
+	return nil, fmt.Errorf("invalid schema %v", v)
+}
+
+func (p *schemaParser) parseComplex(v map[string]interface{}, namespace string) (*schema, error) {
+	typ, _ := v["type"].(string)
+	logicalType, _ := v["logicalType"].(string)
+
+	// A type with attributes, such as a logical type, or a nested definition
+	if t, ok := v["type"]; ok && (typ == "" || primitives[typ]) {
+		s, err := p.parse(t, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if logicalType == "" || s.Type == "union" {
+			return s, nil
+		}
+		annotated := *s
+		annotated.LogicalType = logicalType
+		if scale, ok := v["scale"].(float64); ok {
+			annotated.Scale = int(scale)
+		}
+		return &annotated, nil
+	}
+
+	s := &schema{Type: typ, LogicalType: logicalType}
+	switch typ {
+	case "record", "error", "enum", "fixed":
+		name, _ := v["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("%s without name", typ)
+		}
+		if ns, ok := v["namespace"].(string); ok && !strings.Contains(name, ".") {
+			namespace = ns
+		}
+		full := fullName(name, namespace)
+		if i := strings.LastIndex(full, "."); i >= 0 {
+			namespace = full[:i]
+			s.Name = full[i+1:]
+		} else {
+			s.Name = full
+		}
+		// Registered before the fields, so that records can be recursive
+		p.named[full] = s
+	}
+
+	switch typ {
+	case "record", "error":
+		s.Type = "record"
+		fields, _ := v["fields"].([]interface{})
+		for _, f := range fields {
+			f, ok := f.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid field of record %s", s.Name)
+			}
+			name, _ := f["name"].(string)
+			if name == "" {
+				return nil, fmt.Errorf("field without name in record %s", s.Name)
+			}
+			fs, err := p.parse(f["type"], namespace)
+			if err != nil {
+				return nil, fmt.Errorf("field %s of record %s: %v", name, s.Name, err)
+			}
+			s.Fields = append(s.Fields, &recordField{Name: name, Schema: fs})
+		}
+	case "enum":
+		symbols, _ := v["symbols"].([]interface{})
+		for _, symbol := range symbols {
+			str, ok := symbol.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid symbol of enum %s", s.Name)
+			}
+			s.Symbols = append(s.Symbols, str)
+		}
+	case "fixed":
+		size, ok := v["size"].(float64)
+		if !ok || size < 0 {
+			return nil, fmt.Errorf("invalid size of fixed %s", s.Name)
+		}
+		s.Size = int(size)
+		if scale, ok := v["scale"].(float64); ok {
+			s.Scale = int(scale)
+		}
+	case "array":
+		items, err := p.parse(v["items"], namespace)
+		if err != nil {
+			return nil, fmt.Errorf("array items: %v", err)
+		}
+		s.Items = items
+	case "map":
+		values, err := p.parse(v["values"], namespace)
+		if err != nil {
+			return nil, fmt.Errorf("map values: %v", err)
+		}
+		s.Values = values
+	default:
+		return nil, fmt.Errorf("unknown type %q", typ)
+	}
+	return s, nil
+}
+
+// fullName returns the name qualified with the namespace, unless it is
+// already qualified.
+func fullName(name, namespace string) string {
+	if namespace == "" || strings.Contains(name, ".") {
+		return name
+	}
+	return namespace + "." + name
+}
@@ -0,0 +1,255 @@
+package binary
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// Field describes the location and type of a value in the binary payload.
+type Field struct {
+	// Name is the name of the field or tag.
+	Name string `toml:"name"`
+
+	// Type is one of int8, int16, int32, int64, uint8, uint16, uint32,
+	// uint64, float32, float64, bool or string.
+	Type string `toml:"type"`
+
+	// Offset is the position of the value in bytes.  If unset the value
+	// follows the previous one.
+	Offset *int `toml:"offset"`
+
+	// Size is the length in bytes of a string, trailing NUL bytes are
+	// removed.
+	Size int `toml:"size"`
+
+	// BitOffset and BitCount select a bitfield of an integer or bool value,
+	// BitOffset counts from the least significant bit.
+	BitOffset int `toml:"bit_offset"`
+	BitCount  int `toml:"bit_count"`
+
+	// Tag stores the value as a tag instead of a field.
+	Tag bool `toml:"tag"`
+}
+
+var typeSizes = map[string]int{
+	"int8":    1,
+	"int16":   2,
+	"int32":   4,
+	"int64":   8,
+	"uint8":   1,
+	"uint16":  2,
+	"uint32":  4,
+	"uint64":  8,
+	"float32": 4,
+	"float64": 8,
+	"bool":    1,
+	"string":  0,
+}
+
+// layout is a field with its resolved position.
+type layout struct {
+	Field
+	offset int
+	size   int
+}
+
+// Parser decodes fixed-layout binary payloads into metrics.
+type Parser struct {
+	MetricName  string
+	ByteOrder   binary.ByteOrder
+	DefaultTags map[string]string
+	Now         func() time.Time
+
+	layouts []layout
+	// minSize is the minimum length of a payload.
+	minSize int
+}
+
+// NewParser creates a parser.  The endianness is either "be" (default) or
+// "le".
+func NewParser(metricName string, endianness string, fields []Field, defaultTags map[string]string) (*Parser, error) {
+	var order binary.ByteOrder
+	switch endianness {
+	case "", "be":
+		order = binary.BigEndian
+	case "le":
+		order = binary.LittleEndian
+	default:
+		return nil, fmt.Errorf("invalid endianness %q; must be \"be\" or \"le\"", endianness)
+	}
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no fields defined")
+	}
+
+	p := &Parser{
+		MetricName:  metricName,
+		ByteOrder:   order,
+		DefaultTags: defaultTags,
+		Now:         time.Now,
+	}
+
+	offset := 0
+	for _, f := range fields {
+		l, err := newLayout(f, offset)
+		if err != nil {
+			return nil, err
+		}
+		p.layouts = append(p.layouts, l)
+
+		offset = l.offset + l.size
+		if offset > p.minSize {
+			p.minSize = offset
+		}
+	}
+	return p, nil
+}
+
+func newLayout(f Field, offset int) (layout, error) {
+	if f.Name == "" {
+		return layout{}, fmt.Errorf("field without name")
+	}
+
+	size, ok := typeSizes[f.Type]
+	if !ok {
+		return layout{}, fmt.Errorf("field %q has unknown type %q", f.Name, f.Type)
+	}
+
+	if f.Type == "string" {
+		if f.Size <= 0 {
+			return layout{}, fmt.Errorf("string field %q requires a size", f.Name)
+		}
+		size = f.Size
+	} else if f.Size != 0 && f.Size != size {
+		return layout{}, fmt.Errorf("field %q of type %s has size %d", f.Name, f.Type, size)
+	}
+
+	if f.Offset != nil {
+		if *f.Offset < 0 {
+			return layout{}, fmt.Errorf("field %q has negative offset", f.Name)
+		}
+		offset = *f.Offset
+	}
+
+	if f.BitOffset != 0 || f.BitCount != 0 {
+		switch f.Type {
+		case "float32", "float64", "string":
+			return layout{}, fmt.Errorf("bitfield %q must be of an integer or bool type", f.Name)
+		}
+		if f.BitOffset < 0 || f.BitCount <= 0 || f.BitOffset+f.BitCount > size*8 {
+			return layout{}, fmt.Errorf("bitfield %q exceeds the %d bits of type %s", f.Name, size*8, f.Type)
+		}
+	}
+
+	return layout{Field: f, offset: offset, size: size}, nil
+}
+
+// Parse converts a binary payload to a metric.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	if len(buf) < p.minSize {
+		return nil, fmt.Errorf("payload of %d bytes is shorter than the %d bytes required", len(buf), p.minSize)
+	}
+
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+	for _, l := range p.layouts {
+		value := p.decode(l, buf[l.offset:l.offset+l.size])
+		if l.Tag {
+			tags[l.Name] = format(value)
+		} else {
+			fields[l.Name] = value
+		}
+	}
+
+	for k, v := range p.DefaultTags {
+		if _, ok := tags[k]; !ok {
+			tags[k] = v
+		}
+	}
+
+	m, err := metric.New(p.MetricName, tags, fields, p.Now())
+	if err != nil {
+		return nil, err
+	}
+	return []telegraf.Metric{m}, nil
+}
+
+// ParseLine converts a binary payload to a metric.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+	return metrics[0], nil
+}
+
+// SetDefaultTags sets the tags added to all parsed metrics.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) decode(l layout, b []byte) interface{} {
+	if l.Type == "string" {
+		return string(bytes.TrimRight(b, "\x00"))
+	}
+
+	var raw uint64
+	switch l.size {
+	case 1:
+		raw = uint64(b[0])
+	case 2:
+		raw = uint64(p.ByteOrder.Uint16(b))
+	case 4:
+		raw = uint64(p.ByteOrder.Uint32(b))
+	case 8:
+		raw = p.ByteOrder.Uint64(b)
+	}
+
+	bits := l.size * 8
+	if l.BitCount > 0 {
+		raw >>= uint(l.BitOffset)
+		if l.BitCount < 64 {
+			raw &= 1<<uint(l.BitCount) - 1
+		}
+		bits = l.BitCount
+	}
+
+	switch l.Type {
+	case "int8", "int16", "int32", "int64":
+		// Sign extend from the width of the value
+		shift := uint(64 - bits)
+		return int64(raw<<shift) >> shift
+	case "uint8", "uint16", "uint32", "uint64":
+		return raw
+	case "float32":
+		return float64(math.Float32frombits(uint32(raw)))
+	case "float64":
+		return math.Float64frombits(raw)
+	case "bool":
+		return raw != 0
+	}
+	return nil
+}
+
+func format(value interface{}) string {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	}
+	return ""
+}
@@ -0,0 +1,198 @@
+package binary
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var defaultTime = time.Unix(1577836800, 0)
+
+func offset(n int) *int {
+	return &n
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		endianness string
+		fields     []Field
+		input      []byte
+		expected   telegraf.Metric
+	}{
+		{
+			name: "sequential big endian",
+			fields: []Field{
+				{Name: "id", Type: "uint8", Tag: true},
+				{Name: "temperature", Type: "int16"},
+				{Name: "pressure", Type: "uint32"},
+				{Name: "humidity", Type: "float32"},
+			},
+			input: []byte{
+				0x07,
+				0xff, 0x38,
+				0x00, 0x01, 0x86, 0xa0,
+				0x42, 0x48, 0x00, 0x00,
+			},
+			expected: testutil.MustMetric(
+				"binary",
+				map[string]string{
+					"id": "7",
+				},
+				map[string]interface{}{
+					"temperature": int64(-200),
+					"pressure":    uint64(100000),
+					"humidity":    float64(50),
+				},
+				defaultTime,
+			),
+		},
+		{
+			name:       "little endian with offsets",
+			endianness: "le",
+			fields: []Field{
+				{Name: "counter", Type: "uint16", Offset: offset(2)},
+				{Name: "value", Type: "float64"},
+				{Name: "flag", Type: "bool", Offset: offset(0)},
+			},
+			input: []byte{
+				0x01, 0x00,
+				0x34, 0x12,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf8, 0x3f,
+			},
+			expected: testutil.MustMetric(
+				"binary",
+				map[string]string{},
+				map[string]interface{}{
+					"counter": uint64(0x1234),
+					"value":   float64(1.5),
+					"flag":    true,
+				},
+				defaultTime,
+			),
+		},
+		{
+			name: "bitfields",
+			fields: []Field{
+				{Name: "mode", Type: "uint8", Offset: offset(0), BitOffset: 4, BitCount: 4},
+				{Name: "alarm", Type: "bool", Offset: offset(0), BitOffset: 3, BitCount: 1},
+				{Name: "delta", Type: "int8", Offset: offset(0), BitOffset: 0, BitCount: 3},
+				{Name: "status", Type: "uint16", Offset: offset(1), BitOffset: 2, BitCount: 10},
+			},
+			input: []byte{0xae, 0x0f, 0xfc},
+			expected: testutil.MustMetric(
+				"binary",
+				map[string]string{},
+				map[string]interface{}{
+					"mode":   uint64(0xa),
+					"alarm":  true,
+					"delta":  int64(-2),
+					"status": uint64(0x3ff),
+				},
+				defaultTime,
+			),
+		},
+		{
+			name: "string",
+			fields: []Field{
+				{Name: "device", Type: "string", Size: 8, Tag: true},
+				{Name: "value", Type: "int32"},
+			},
+			input: []byte{'s', 'e', 'n', 's', 'o', 'r', 0, 0, 0xff, 0xff, 0xff, 0xff},
+			expected: testutil.MustMetric(
+				"binary",
+				map[string]string{
+					"device": "sensor",
+				},
+				map[string]interface{}{
+					"value": int64(-1),
+				},
+				defaultTime,
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewParser("binary", tt.endianness, tt.fields, nil)
+			require.NoError(t, err)
+			p.Now = func() time.Time { return defaultTime }
+
+			actual, err := p.Parse(tt.input)
+			require.NoError(t, err)
+			require.Len(t, actual, 1)
+			testutil.RequireMetricEqual(t, tt.expected, actual[0])
+		})
+	}
+}
+
+func TestParseShortPayload(t *testing.T) {
+	p, err := NewParser("binary", "", []Field{
+		{Name: "a", Type: "uint16"},
+		{Name: "b", Type: "uint32", Offset: offset(4)},
+	}, nil)
+	require.NoError(t, err)
+
+	_, err = p.Parse([]byte{0, 1, 2, 3, 4, 5, 6})
+	require.Error(t, err)
+}
+
+func TestDefaultTags(t *testing.T) {
+	p, err := NewParser("binary", "", []Field{
+		{Name: "id", Type: "uint8", Tag: true},
+	}, map[string]string{"id": "ignored", "source": "udp"})
+	require.NoError(t, err)
+
+	m, err := p.ParseLine("\x02")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"id": "2", "source": "udp"}, m.Tags())
+}
+
+func TestInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		endianness string
+		fields     []Field
+	}{
+		{
+			name:       "unknown endianness",
+			endianness: "middle",
+			fields:     []Field{{Name: "a", Type: "uint8"}},
+		},
+		{
+			name: "no fields",
+		},
+		{
+			name:   "missing name",
+			fields: []Field{{Type: "uint8"}},
+		},
+		{
+			name:   "unknown type",
+			fields: []Field{{Name: "a", Type: "int128"}},
+		},
+		{
+			name:   "string without size",
+			fields: []Field{{Name: "a", Type: "string"}},
+		},
+		{
+			name:   "negative offset",
+			fields: []Field{{Name: "a", Type: "uint8", Offset: offset(-1)}},
+		},
+		{
+			name:   "bitfield on float",
+			fields: []Field{{Name: "a", Type: "float32", BitCount: 1}},
+		},
+		{
+			name:   "bitfield exceeding type",
+			fields: []Field{{Name: "a", Type: "uint8", BitOffset: 4, BitCount: 5}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParser("binary", tt.endianness, tt.fields, nil)
+			require.Error(t, err)
+		})
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/csv"
 	"fmt"
+	"io"
+	"log"
 	"strconv"
 	"strings"
 	"time"
@@ -23,6 +25,7 @@ type Parser struct {
 	Delimiter         string
 	Comment           string
 	TrimSpace         bool
+	SkipErrors        bool
 	ColumnNames       []string
 	ColumnTypes       []string
 	TagColumns        []string
@@ -38,10 +41,11 @@ func (p *Parser) SetTimeFunc(fn TimeFunc) {
 	p.TimeFunc = fn
 }
 
-func (p *Parser) compile(r *bytes.Reader) (*csv.Reader, error) {
+func (p *Parser) compile(r io.Reader) (*csv.Reader, error) {
 	csvReader := csv.NewReader(r)
 	// ensures that the reader reads records of different lengths without an error
 	csvReader.FieldsPerRecord = -1
+	csvReader.ReuseRecord = true
 	if p.Delimiter != "" {
 		csvReader.Comma = []rune(p.Delimiter)[0]
 	}
@@ -52,66 +56,125 @@ func (p *Parser) compile(r *bytes.Reader) (*csv.Reader, error) {
 	return csvReader, nil
 }
 
-func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
-	r := bytes.NewReader(buf)
-	csvReader, err := p.compile(r)
-	if err != nil {
-		return nil, err
+// state is the state of the parsing of a CSV document: the rows remaining to
+// skip, the header rows remaining to read and the column names, either
+// configured or read from the header of the document.
+type state struct {
+	skipRows   int
+	headerRows int
+	header     []string
+	columns    []string
+}
+
+func (p *Parser) newState() *state {
+	return &state{
+		skipRows:   p.SkipRows,
+		headerRows: p.HeaderRowCount,
+		columns:    p.ColumnNames,
 	}
-	// skip first rows
-	for i := 0; i < p.SkipRows; i++ {
-		csvReader.Read()
+}
+
+// process parses a record of the document, returning no metric for the
+// skipped and header rows.
+func (p *Parser) process(s *state, record []string) (telegraf.Metric, error) {
+	if s.skipRows > 0 {
+		s.skipRows--
+		return nil, nil
 	}
-	// if there is a header and nothing in DataColumns
-	// set DataColumns to names extracted from the header
-	headerNames := make([]string, 0)
-	if len(p.ColumnNames) == 0 {
-		for i := 0; i < p.HeaderRowCount; i++ {
-			header, err := csvReader.Read()
-			if err != nil {
-				return nil, err
+
+	if s.headerRows > 0 {
+		s.headerRows--
+		// if columns are named, just skip header rows
+		if len(p.ColumnNames) > 0 {
+			return nil, nil
+		}
+
+		//concatenate header names
+		for i, name := range record {
+			if p.TrimSpace {
+				name = strings.Trim(name, " ")
 			}
-			//concatenate header names
-			for i := range header {
-				name := header[i]
-				if p.TrimSpace {
-					name = strings.Trim(name, " ")
-				}
-				if len(headerNames) <= i {
-					headerNames = append(headerNames, name)
-				} else {
-					headerNames[i] = headerNames[i] + name
-				}
+			if len(s.header) <= i {
+				s.header = append(s.header, name)
+			} else {
+				s.header[i] = s.header[i] + name
 			}
 		}
-		p.ColumnNames = headerNames[p.SkipColumns:]
-	} else {
-		// if columns are named, just skip header rows
-		for i := 0; i < p.HeaderRowCount; i++ {
-			csvReader.Read()
+		if s.headerRows == 0 && len(s.header) > p.SkipColumns {
+			s.columns = s.header[p.SkipColumns:]
 		}
+		return nil, nil
 	}
 
-	table, err := csvReader.ReadAll()
+	return p.parseRecord(s.columns, record)
+}
+
+// parse reads the records of the document and calls fn with the metric of
+// each data row.  The rows which cannot be parsed are logged and skipped when
+// SkipErrors is set.
+func (p *Parser) parse(r io.Reader, s *state, fn func(telegraf.Metric) error) error {
+	csvReader, err := p.compile(r)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	metrics := make([]telegraf.Metric, 0)
-	for _, record := range table {
-		m, err := p.parseRecord(record)
+	for row := 1; ; row++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		// Only the syntax errors can be skipped, not those of the reader.
+		if _, ok := err.(*csv.ParseError); err != nil && !ok {
+			return err
+		}
+
+		var m telegraf.Metric
+		if err == nil {
+			m, err = p.process(s, record)
+		}
 		if err != nil {
-			return metrics, err
+			if p.SkipErrors {
+				log.Printf("W! [parsers.csv] Skipping row %d: %v", row, err)
+				continue
+			}
+			return err
 		}
+
+		if m == nil {
+			continue
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	s := p.newState()
+	metrics := make([]telegraf.Metric, 0)
+	err := p.parse(bytes.NewReader(buf), s, func(m telegraf.Metric) error {
 		metrics = append(metrics, m)
+		return nil
+	})
+
+	// The column names read from the header are used by ParseLine.
+	if len(p.ColumnNames) == 0 {
+		p.ColumnNames = s.columns
 	}
-	return metrics, nil
+	return metrics, err
+}
+
+// ParseStream parses the CSV document read from the reader, calling fn with
+// each metric as soon as it is parsed.  Unlike Parse, the column names read
+// from the header are only used for the rows of this document.
+func (p *Parser) ParseStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	return p.parse(r, p.newState(), fn)
 }
 
 // ParseLine does not use any information in header and assumes DataColumns is set
 // it will also not skip any rows
 func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
-	r := bytes.NewReader([]byte(line))
+	r := strings.NewReader(line)
 	csvReader, err := p.compile(r)
 	if err != nil {
 		return nil, err
@@ -126,21 +189,59 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	if err != nil {
 		return nil, err
 	}
-	m, err := p.parseRecord(record)
+	m, err := p.parseRecord(p.ColumnNames, record)
 	if err != nil {
 		return nil, err
 	}
 	return m, nil
 }
 
-func (p *Parser) parseRecord(record []string) (telegraf.Metric, error) {
+// LineParser parses the lines of a CSV file one at a time, such as those read
+// by the tail input.  The rows to skip and the header are read from the first
+// lines of the file, and the column names are kept for the following ones.
+type LineParser struct {
+	parser *Parser
+	state  *state
+}
+
+// NewLineParser returns a parser of the lines of a file.
+func (p *Parser) NewLineParser() *LineParser {
+	return &LineParser{
+		parser: p,
+		state:  p.newState(),
+	}
+}
+
+// ParseLine parses the next line of the file, returning no metric for the
+// skipped, header, comment and empty lines.
+func (lp *LineParser) ParseLine(line string) (telegraf.Metric, error) {
+	csvReader, err := lp.parser.compile(strings.NewReader(line))
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := csvReader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lp.parser.process(lp.state, record)
+}
+
+func (p *Parser) parseRecord(columns []string, record []string) (telegraf.Metric, error) {
 	recordFields := make(map[string]interface{})
 	tags := make(map[string]string)
 
 	// skip columns in record
-	record = record[p.SkipColumns:]
+	if len(record) < p.SkipColumns {
+		record = nil
+	} else {
+		record = record[p.SkipColumns:]
+	}
 outer:
-	for i, fieldName := range p.ColumnNames {
+	for i, fieldName := range columns {
 		if i < len(record) {
 			value := record[i]
 			if p.TrimSpace {
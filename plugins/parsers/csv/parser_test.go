@@ -1,7 +1,9 @@
 package csv
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -451,3 +453,116 @@ func TestTimestampTimezone(t *testing.T) {
 	require.Equal(t, metrics[0].Time().UnixNano(), int64(1243094706000000000))
 	require.Equal(t, metrics[1].Time().UnixNano(), int64(1257609906000000000))
 }
+
+func TestParseStreamHeaderPerDocument(t *testing.T) {
+	p := Parser{
+		MetricName:     "csv",
+		SkipRows:       1,
+		HeaderRowCount: 2,
+		TimeFunc:       DefaultTime,
+	}
+
+	parse := func(data string) []telegraf.Metric {
+		var metrics []telegraf.Metric
+		err := p.ParseStream(strings.NewReader(data), func(m telegraf.Metric) error {
+			metrics = append(metrics, m)
+			return nil
+		})
+		require.NoError(t, err)
+		return metrics
+	}
+
+	metrics := parse("report\nusage_,usage_\nuser,idle\n40,60\n30,70\n")
+	require.Len(t, metrics, 2)
+	require.Equal(t, map[string]interface{}{"usage_user": int64(40), "usage_idle": int64(60)}, metrics[0].Fields())
+	require.Equal(t, map[string]interface{}{"usage_user": int64(30), "usage_idle": int64(70)}, metrics[1].Fields())
+
+	// The header of the previous document is not used.
+	metrics = parse("report\nmem_,mem_\nused,free\n2,8\n")
+	require.Len(t, metrics, 1)
+	require.Equal(t, map[string]interface{}{"mem_used": int64(2), "mem_free": int64(8)}, metrics[0].Fields())
+	require.Empty(t, p.ColumnNames)
+}
+
+func TestParseStreamCallbackError(t *testing.T) {
+	p := Parser{
+		MetricName:  "csv",
+		ColumnNames: []string{"a"},
+		TimeFunc:    DefaultTime,
+	}
+
+	failed := errors.New("full")
+	var count int
+	err := p.ParseStream(strings.NewReader("1\n2\n3\n"), func(m telegraf.Metric) error {
+		count++
+		return failed
+	})
+	require.Equal(t, failed, err)
+	require.Equal(t, 1, count)
+}
+
+func TestSkipErrors(t *testing.T) {
+	testCSV := `a,b
+1,2
+3,"4
+5,x
+6,7`
+
+	p := Parser{
+		MetricName:     "csv",
+		HeaderRowCount: 1,
+		ColumnTypes:    []string{"int", "int"},
+		TimeFunc:       DefaultTime,
+	}
+	metrics, err := p.Parse([]byte(testCSV))
+	require.Error(t, err)
+	require.Len(t, metrics, 1)
+
+	p = Parser{
+		MetricName:     "csv",
+		HeaderRowCount: 1,
+		ColumnTypes:    []string{"int", "int"},
+		SkipErrors:     true,
+		TimeFunc:       DefaultTime,
+	}
+	metrics, err = p.Parse([]byte("a,b\n1,2\n5,x\n6,7\n"))
+	require.NoError(t, err)
+	expected := []telegraf.Metric{
+		testutil.MustMetric("csv", map[string]string{}, map[string]interface{}{"a": 1, "b": 2}, DefaultTime()),
+		testutil.MustMetric("csv", map[string]string{}, map[string]interface{}{"a": 6, "b": 7}, DefaultTime()),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+
+	// The syntax errors are skipped as well.
+	metrics, err = p.Parse([]byte("a,b\n1,2\n3,4\"x\n6,7\n"))
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestLineParser(t *testing.T) {
+	p := Parser{
+		MetricName:     "csv",
+		SkipRows:       1,
+		HeaderRowCount: 2,
+		Comment:        "#",
+		TimeFunc:       DefaultTime,
+	}
+
+	lines := p.NewLineParser()
+	for _, line := range []string{"report", "", "usage_,usage_", "# comment", "user,idle"} {
+		m, err := lines.ParseLine(line)
+		require.NoError(t, err)
+		require.Nil(t, m)
+	}
+
+	m, err := lines.ParseLine("40,60")
+	require.NoError(t, err)
+	testutil.RequireMetricEqual(t,
+		testutil.MustMetric("csv", map[string]string{}, map[string]interface{}{"usage_user": 40, "usage_idle": 60}, DefaultTime()),
+		m)
+
+	// Each file has its own header.
+	m, err = p.NewLineParser().ParseLine("40,60")
+	require.NoError(t, err)
+	require.Nil(t, m)
+}
@@ -0,0 +1,162 @@
+package grok
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// localeNames are the month and weekday names of the supported timestamp
+// locales.  Each entry holds the full name followed by the abbreviations,
+// months start with January and weekdays with Sunday.
+var localeNames = map[string]struct {
+	months   [12]string
+	weekdays [7]string
+}{
+	"de": {
+		months: [12]string{
+			"januar jan", "februar feb", "märz mär mrz", "april apr",
+			"mai", "juni jun", "juli jul", "august aug",
+			"september sep sept", "oktober okt", "november nov", "dezember dez",
+		},
+		weekdays: [7]string{
+			"sonntag so", "montag mo", "dienstag di", "mittwoch mi",
+			"donnerstag do", "freitag fr", "samstag sa sonnabend",
+		},
+	},
+	"es": {
+		months: [12]string{
+			"enero ene", "febrero feb", "marzo mar", "abril abr",
+			"mayo may", "junio jun", "julio jul", "agosto ago",
+			"septiembre sep sept setiembre set", "octubre oct", "noviembre nov", "diciembre dic",
+		},
+		weekdays: [7]string{
+			"domingo dom", "lunes lun", "martes", "miércoles mié",
+			"jueves jue", "viernes vie", "sábado sáb",
+		},
+	},
+	"fr": {
+		months: [12]string{
+			"janvier janv", "février févr fév", "mars", "avril avr",
+			"mai", "juin", "juillet juil", "août",
+			"septembre sept", "octobre oct", "novembre nov", "décembre déc",
+		},
+		weekdays: [7]string{
+			"dimanche dim", "lundi lun", "mardi mar", "mercredi mer",
+			"jeudi jeu", "vendredi ven", "samedi sam",
+		},
+	},
+	"it": {
+		months: [12]string{
+			"gennaio gen", "febbraio feb", "marzo mar", "aprile apr",
+			"maggio mag", "giugno giu", "luglio lug", "agosto ago",
+			"settembre set", "ottobre ott", "novembre nov", "dicembre dic",
+		},
+		weekdays: [7]string{
+			"domenica dom", "lunedì lun", "martedì", "mercoledì mer",
+			"giovedì gio", "venerdì ven", "sabato sab",
+		},
+	},
+	"nl": {
+		months: [12]string{
+			"januari jan", "februari feb", "maart mrt", "april apr",
+			"mei", "juni jun", "juli jul", "augustus aug",
+			"september sep", "oktober okt", "november nov", "december dec",
+		},
+		weekdays: [7]string{
+			"zondag zo", "maandag ma", "dinsdag di", "woensdag wo",
+			"donderdag do", "vrijdag vr", "zaterdag za",
+		},
+	},
+}
+
+// dateWord is a month or weekday referenced by a localized name.
+type dateWord struct {
+	month bool
+	index int
+}
+
+// locale translates localized month and weekday names to English, so that
+// they can be parsed with Go time layouts.
+type locale struct {
+	words map[string]dateWord
+}
+
+func getLocale(name string) (*locale, error) {
+	names, ok := localeNames[strings.ToLower(name)]
+	if !ok {
+		available := make([]string, 0, len(localeNames))
+		for n := range localeNames {
+			available = append(available, n)
+		}
+		sort.Strings(available)
+		return nil, fmt.Errorf("unknown timestamp locale %q; available locales are %v", name, available)
+	}
+
+	l := &locale{words: make(map[string]dateWord)}
+	// Months are added last, so that they win over weekdays sharing the same
+	// abbreviation.
+	for i, words := range names.weekdays {
+		for _, w := range strings.Fields(words) {
+			l.words[w] = dateWord{index: i}
+		}
+	}
+	for i, words := range names.months {
+		for _, w := range strings.Fields(words) {
+			l.words[w] = dateWord{month: true, index: i + 1}
+		}
+	}
+	return l, nil
+}
+
+// translate replaces the localized month and weekday names in the value by
+// their English names, using the full or abbreviated form of the layout.
+func (l *locale) translate(value, layout string) string {
+	fullMonth := strings.Contains(layout, "January")
+	fullWeekday := strings.Contains(layout, "Monday")
+
+	var b strings.Builder
+	start := -1
+	flush := func(end int) {
+		word := value[start:end]
+		w, ok := l.words[strings.ToLower(word)]
+		if !ok {
+			b.WriteString(word)
+			return
+		}
+
+		var name string
+		if w.month {
+			name = time.Month(w.index).String()
+			if !fullMonth {
+				name = name[:3]
+			}
+		} else {
+			name = time.Weekday(w.index).String()
+			if !fullWeekday {
+				name = name[:3]
+			}
+		}
+		b.WriteString(name)
+	}
+
+	for i, r := range value {
+		if unicode.IsLetter(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			flush(i)
+			start = -1
+		}
+		b.WriteRune(r)
+	}
+	if start >= 0 {
+		flush(len(value))
+	}
+	return b.String()
+}
@@ -90,6 +90,29 @@ type Parser struct {
 	// UniqueTimestamp when set to "disable", timestamp will not incremented if there is a duplicate.
 	UniqueTimestamp string
 
+	// PatternSets are the names of bundled pattern sets to load, such as
+	// "nginx" or "java".
+	PatternSets []string
+
+	// ReloadInterval is how often the CustomPatternFiles are checked for
+	// changes, they are reloaded when modified.  Zero disables reloading.
+	ReloadInterval time.Duration
+
+	// TimestampLocale is the language of month and weekday names in
+	// timestamps, such as "de" or "fr".  Default: "" for English.
+	TimestampLocale string
+	locale          *locale
+
+	// TimestampFallbacks are the timestamp layouts, or built-in ts- modifier
+	// names, tried in order when a timestamp does not match its layout.
+	TimestampFallbacks []string
+
+	// InheritTimestamp sets the timestamp of lines without a timestamp to the
+	// one of the last line with a timestamp, such as for the continuation
+	// lines of a multiline message.
+	InheritTimestamp bool
+	lastTimestamp    time.Time
+
 	// typeMap is a map of patterns -> capture name -> modifier,
 	//   ie, {
 	//          "%{TESTLOG}":
@@ -121,6 +144,11 @@ type Parser struct {
 	// layouts.
 	foundTsLayouts []string
 
+	// patternFiles holds the modification time of the CustomPatternFiles
+	// when they were loaded.
+	patternFiles    map[string]time.Time
+	lastReloadCheck time.Time
+
 	timeFunc func() time.Time
 	g        *grok.Grok
 	tsModder *tsModder
@@ -128,16 +156,6 @@ type Parser struct {
 
 // Compile is a bound method to Parser which will process the options for our parser
 func (p *Parser) Compile() error {
-	p.typeMap = make(map[string]map[string]string)
-	p.tsMap = make(map[string]map[string]string)
-	p.patterns = make(map[string]string)
-	p.tsModder = &tsModder{}
-	var err error
-	p.g, err = grok.NewWithConfig(&grok.Config{NamedCapturesOnly: true})
-	if err != nil {
-		return err
-	}
-
 	if p.UniqueTimestamp == "" {
 		p.UniqueTimestamp = "auto"
 	}
@@ -159,9 +177,54 @@ func (p *Parser) Compile() error {
 		return fmt.Errorf("pattern required")
 	}
 
-	// Combine user-supplied CustomPatterns with DEFAULT_PATTERNS and parse
-	// them together as the same type of pattern.
-	p.CustomPatterns = DEFAULT_PATTERNS + p.CustomPatterns
+	// Combine user-supplied CustomPatterns with DEFAULT_PATTERNS and the
+	// selected pattern sets, and parse them together as the same type of
+	// pattern.
+	var sets string
+	for _, name := range p.PatternSets {
+		patterns, err := patternSet(name)
+		if err != nil {
+			return err
+		}
+		sets += patterns
+	}
+	p.CustomPatterns = DEFAULT_PATTERNS + sets + p.CustomPatterns
+
+	var err error
+	p.loc, err = time.LoadLocation(p.Timezone)
+	if err != nil {
+		log.Printf("W! improper timezone supplied (%s), setting loc to UTC", p.Timezone)
+		p.loc, _ = time.LoadLocation("UTC")
+	}
+
+	if p.TimestampLocale != "" {
+		p.locale, err = getLocale(p.TimestampLocale)
+		if err != nil {
+			return err
+		}
+	}
+
+	if p.timeFunc == nil {
+		p.timeFunc = time.Now
+	}
+
+	return p.compilePatterns()
+}
+
+// compilePatterns builds the grok patterns from the custom patterns and the
+// custom pattern files.
+func (p *Parser) compilePatterns() error {
+	p.typeMap = make(map[string]map[string]string)
+	p.tsMap = make(map[string]map[string]string)
+	p.patterns = make(map[string]string)
+	p.patternFiles = make(map[string]time.Time)
+	p.tsModder = &tsModder{}
+	var err error
+	p.g, err = grok.NewWithConfig(&grok.Config{NamedCapturesOnly: true})
+	if err != nil {
+		return err
+	}
+
 	if len(p.CustomPatterns) != 0 {
 		scanner := bufio.NewScanner(strings.NewReader(p.CustomPatterns))
 		p.addCustomPatterns(scanner)
@@ -174,21 +237,58 @@ func (p *Parser) Compile() error {
 			return fileErr
 		}
 
+		if stat, err := file.Stat(); err == nil {
+			p.patternFiles[filename] = stat.ModTime()
+		}
+
 		scanner := bufio.NewScanner(bufio.NewReader(file))
 		p.addCustomPatterns(scanner)
+		file.Close()
 	}
 
-	p.loc, err = time.LoadLocation(p.Timezone)
-	if err != nil {
-		log.Printf("W! improper timezone supplied (%s), setting loc to UTC", p.Timezone)
-		p.loc, _ = time.LoadLocation("UTC")
+	return p.compileCustomPatterns()
+}
+
+// reloadPatterns recompiles the patterns if any of the custom pattern files
+// was modified since it was loaded.  The previous patterns are kept if the
+// new ones fail to compile.
+func (p *Parser) reloadPatterns() {
+	if p.ReloadInterval <= 0 || len(p.CustomPatternFiles) == 0 {
+		return
 	}
 
-	if p.timeFunc == nil {
-		p.timeFunc = time.Now
+	now := p.timeFunc()
+	if now.Sub(p.lastReloadCheck) < p.ReloadInterval {
+		return
 	}
+	p.lastReloadCheck = now
 
-	return p.compileCustomPatterns()
+	modified := false
+	for _, filename := range p.CustomPatternFiles {
+		stat, err := os.Stat(filename)
+		if err != nil {
+			log.Printf("E! Error checking grok pattern file %s: %s", filename, err)
+			return
+		}
+		if !stat.ModTime().Equal(p.patternFiles[filename]) {
+			modified = true
+		}
+	}
+	if !modified {
+		return
+	}
+
+	typeMap, tsMap, patterns, patternFiles, g := p.typeMap, p.tsMap, p.patterns, p.patternFiles, p.g
+	tsModder := p.tsModder
+	if err := p.compilePatterns(); err != nil {
+		log.Printf("E! Error reloading grok pattern files, keeping previous patterns: %s", err)
+		p.typeMap, p.tsMap, p.patterns, p.patternFiles, p.g = typeMap, tsMap, patterns, patternFiles, g
+		p.tsModder = tsModder
+		return
+	}
+	p.tsModder = tsModder
+	p.foundTsLayouts = nil
+	log.Printf("I! Reloaded grok pattern files")
 }
 
 // ParseLine is the primary function to process individual lines, returning the metrics
@@ -198,6 +298,9 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	var values map[string]string
 	// the matching pattern string
 	var patternName string
+
+	p.reloadPatterns()
+
 	for _, pattern := range p.NamedPatterns {
 		if values, err = p.g.Parse(pattern, line); err != nil {
 			return nil, err
@@ -222,6 +325,7 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	}
 
 	timestamp := time.Now()
+	foundTimestamp := false
 	for k, v := range values {
 		if k == "" || v == "" {
 			continue
@@ -274,101 +378,25 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 			tags[k] = v
 		case STRING:
 			fields[k] = v
-		case EPOCH:
-			parts := strings.SplitN(v, ".", 2)
-			if len(parts) == 0 {
-				log.Printf("E! Error parsing %s to timestamp: %s", v, err)
-				break
-			}
-
-			sec, err := strconv.ParseInt(parts[0], 10, 64)
-			if err != nil {
-				log.Printf("E! Error parsing %s to timestamp: %s", v, err)
-				break
-			}
-			ts := time.Unix(sec, 0)
-
-			if len(parts) == 2 {
-				padded := fmt.Sprintf("%-9s", parts[1])
-				nsString := strings.Replace(padded[:9], " ", "0", -1)
-				nanosec, err := strconv.ParseInt(nsString, 10, 64)
-				if err != nil {
-					log.Printf("E! Error parsing %s to timestamp: %s", v, err)
-					break
-				}
-				ts = ts.Add(time.Duration(nanosec) * time.Nanosecond)
-			}
-			timestamp = ts
-		case EPOCH_MILLI:
-			ms, err := strconv.ParseInt(v, 10, 64)
-			if err != nil {
-				log.Printf("E! Error parsing %s to int: %s", v, err)
-			} else {
-				timestamp = time.Unix(0, ms*int64(time.Millisecond))
-			}
-		case EPOCH_NANO:
-			iv, err := strconv.ParseInt(v, 10, 64)
-			if err != nil {
-				log.Printf("E! Error parsing %s to int: %s", v, err)
-			} else {
-				timestamp = time.Unix(0, iv)
-			}
-		case SYSLOG_TIMESTAMP:
-			ts, err := time.ParseInLocation(time.Stamp, v, p.loc)
-			if err == nil {
-				if ts.Year() == 0 {
-					ts = ts.AddDate(timestamp.Year(), 0, 0)
-				}
-				timestamp = ts
-			} else {
-				log.Printf("E! Error parsing %s to time layout [%s]: %s", v, t, err)
-			}
-		case GENERIC_TIMESTAMP:
-			var foundTs bool
-			// first try timestamp layouts that we've already found
-			for _, layout := range p.foundTsLayouts {
-				ts, err := time.ParseInLocation(layout, v, p.loc)
-				if err == nil {
-					timestamp = ts
-					foundTs = true
-					break
-				}
-			}
-			// if we haven't found a timestamp layout yet, try all timestamp
-			// layouts.
-			if !foundTs {
-				for _, layout := range timeLayouts {
-					ts, err := time.ParseInLocation(layout, v, p.loc)
-					if err == nil {
-						timestamp = ts
-						foundTs = true
-						p.foundTsLayouts = append(p.foundTsLayouts, layout)
-						break
-					}
-				}
-			}
-			// if we still haven't found a timestamp layout, log it and we will
-			// just use time.Now()
-			if !foundTs {
-				log.Printf("E! Error parsing timestamp [%s], could not find any "+
-					"suitable time layouts.", v)
-			}
 		case DROP:
 		// goodbye!
 		default:
-			v = strings.Replace(v, ",", ".", -1)
-			ts, err := time.ParseInLocation(t, v, p.loc)
-			if err == nil {
-				if ts.Year() == 0 {
-					ts = ts.AddDate(timestamp.Year(), 0, 0)
-				}
+			// a timestamp layout or one of the special timestamp types
+			if ts, ok := p.parseTimestampWithFallbacks(t, v, timestamp); ok {
 				timestamp = ts
-			} else {
-				log.Printf("E! Error parsing %s to time layout [%s]: %s", v, t, err)
+				foundTimestamp = true
 			}
 		}
 	}
 
+	if p.InheritTimestamp {
+		if foundTimestamp {
+			p.lastTimestamp = timestamp
+		} else if !p.lastTimestamp.IsZero() {
+			timestamp = p.lastTimestamp
+		}
+	}
+
 	if p.UniqueTimestamp != "auto" {
 		return metric.New(p.Measurement, tags, fields, timestamp)
 	}
@@ -376,6 +404,110 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	return metric.New(p.Measurement, tags, fields, p.tsModder.tsMod(timestamp))
 }
 
+// parseTimestampWithFallbacks parses the value using the layout, then each
+// of the TimestampFallbacks, logging an error if none of them match.
+func (p *Parser) parseTimestampWithFallbacks(layout, v string, now time.Time) (time.Time, bool) {
+	ts, err := p.parseTimestamp(layout, v, now)
+	if err == nil {
+		return ts, true
+	}
+
+	for _, fallback := range p.TimestampFallbacks {
+		if l, ok := timeLayouts[fallback]; ok {
+			fallback = l
+		}
+		if ts, ferr := p.parseTimestamp(fallback, v, now); ferr == nil {
+			return ts, true
+		}
+	}
+
+	log.Printf("E! Error parsing %s to time layout [%s]: %s", v, layout, err)
+	return time.Time{}, false
+}
+
+// parseTimestamp parses the value using a time layout or one of the special
+// timestamp types, such as EPOCH.
+func (p *Parser) parseTimestamp(layout, v string, now time.Time) (time.Time, error) {
+	switch layout {
+	case EPOCH:
+		parts := strings.SplitN(v, ".", 2)
+		sec, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		ts := time.Unix(sec, 0)
+
+		if len(parts) == 2 {
+			padded := fmt.Sprintf("%-9s", parts[1])
+			nsString := strings.Replace(padded[:9], " ", "0", -1)
+			nanosec, err := strconv.ParseInt(nsString, 10, 64)
+			if err != nil {
+				return time.Time{}, err
+			}
+			ts = ts.Add(time.Duration(nanosec) * time.Nanosecond)
+		}
+		return ts, nil
+	case EPOCH_MILLI:
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(0, ms*int64(time.Millisecond)), nil
+	case EPOCH_NANO:
+		iv, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(0, iv), nil
+	case SYSLOG_TIMESTAMP:
+		ts, err := p.parseInLocation(time.Stamp, v)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if ts.Year() == 0 {
+			ts = ts.AddDate(now.Year(), 0, 0)
+		}
+		return ts, nil
+	case GENERIC_TIMESTAMP:
+		// first try timestamp layouts that we've already found
+		for _, layout := range p.foundTsLayouts {
+			ts, err := p.parseInLocation(layout, v)
+			if err == nil {
+				return ts, nil
+			}
+		}
+		// if we haven't found a timestamp layout yet, try all timestamp
+		// layouts.
+		for _, layout := range timeLayouts {
+			ts, err := p.parseInLocation(layout, v)
+			if err == nil {
+				p.foundTsLayouts = append(p.foundTsLayouts, layout)
+				return ts, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("could not find any suitable time layouts")
+	default:
+		v = strings.Replace(v, ",", ".", -1)
+		ts, err := p.parseInLocation(layout, v)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if ts.Year() == 0 {
+			ts = ts.AddDate(now.Year(), 0, 0)
+		}
+		return ts, nil
+	}
+}
+
+// parseInLocation parses the value in the configured timezone, translating
+// month and weekday names of the TimestampLocale.
+func (p *Parser) parseInLocation(layout, v string) (time.Time, error) {
+	if p.locale != nil {
+		v = p.locale.translate(v, layout)
+	}
+	return time.ParseInLocation(layout, v, p.loc)
+}
+
 func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
 
 	metrics := make([]telegraf.Metric, 0)
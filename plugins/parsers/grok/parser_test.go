@@ -1,10 +1,14 @@
 package grok
 
 import (
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -1115,3 +1119,265 @@ func TestTrimRegression(t *testing.T) {
 	)
 	require.Equal(t, expected, actual)
 }
+
+func TestPatternSets(t *testing.T) {
+	tests := []struct {
+		name     string
+		set      string
+		pattern  string
+		line     string
+		expected telegraf.Metric
+	}{
+		{
+			name:    "nginx error log",
+			set:     "nginx",
+			pattern: "%{NGINX_ERROR_LOG}",
+			line:    `2020/06/04 12:41:45 [error] 1234#0: *5 open() "/www/x" failed (2: No such file or directory), client: 10.0.0.1`,
+			expected: testutil.MustMetric(
+				"nginx",
+				map[string]string{"level": "error"},
+				map[string]interface{}{
+					"pid":           int64(1234),
+					"tid":           int64(0),
+					"connection_id": int64(5),
+					"message":       `open() "/www/x" failed (2: No such file or directory), client: 10.0.0.1`,
+				},
+				time.Date(2020, 6, 4, 12, 41, 45, 0, time.UTC),
+			),
+		},
+		{
+			name:    "nginx access log",
+			set:     "nginx",
+			pattern: "%{NGINX_ACCESS_LOG}",
+			line:    `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "-" "curl" 0.005 0.004`,
+			expected: testutil.MustMetric(
+				"nginx",
+				map[string]string{"verb": "GET", "resp_code": "200"},
+				map[string]interface{}{
+					"client_ip":              "127.0.0.1",
+					"ident":                  "-",
+					"auth":                   "frank",
+					"request":                "/apache_pb.gif",
+					"http_version":           float64(1.0),
+					"resp_bytes":             int64(2326),
+					"referrer":               "-",
+					"agent":                  "curl",
+					"request_time":           float64(0.005),
+					"upstream_response_time": float64(0.004),
+				},
+				time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC),
+			),
+		},
+		{
+			name:    "haproxy tcp log",
+			set:     "haproxy",
+			pattern: "%{HAPROXY_TCP_LOG}",
+			line:    `Feb  6 12:12:56 localhost haproxy[14387]: 10.0.1.2:33313 [06/Feb/2009:12:12:51.443] fnt bck/srv1 0/0/5007 212 -- 0/0/0/0/3 0/0`,
+			expected: testutil.MustMetric(
+				"haproxy",
+				map[string]string{
+					"syslog_server": "localhost",
+					"frontend_name": "fnt",
+					"backend_name":  "bck",
+					"server_name":   "srv1",
+				},
+				map[string]interface{}{
+					"program":              "haproxy",
+					"pid":                  "14387",
+					"client_ip":            "10.0.1.2",
+					"client_port":          int64(33313),
+					"time_queue":           int64(0),
+					"time_backend_connect": int64(0),
+					"time_duration":        int64(5007),
+					"bytes_read":           int64(212),
+					"termination_state":    "--",
+					"actconn":              int64(0),
+					"feconn":               int64(0),
+					"beconn":               int64(0),
+					"srvconn":              int64(0),
+					"retries":              int64(3),
+					"srv_queue":            int64(0),
+					"backend_queue":        int64(0),
+				},
+				time.Date(2009, 2, 6, 12, 12, 51, 443000000, time.UTC),
+			),
+		},
+		{
+			name:    "postgres log",
+			set:     "postgres",
+			pattern: "%{POSTGRES_LOG}",
+			line:    `2020-06-04 12:41:45.123 UTC [1234] LOG:  database system is ready to accept connections`,
+			expected: testutil.MustMetric(
+				"postgres",
+				map[string]string{"level": "LOG"},
+				map[string]interface{}{
+					"pid":     int64(1234),
+					"message": "database system is ready to accept connections",
+				},
+				time.Date(2020, 6, 4, 12, 41, 45, 123000000, time.UTC),
+			),
+		},
+		{
+			name:    "java log",
+			set:     "java",
+			pattern: "%{JAVA_LOG}",
+			line:    `2020-06-04 12:41:45,123 ERROR [main] com.example.App - Request failed`,
+			expected: testutil.MustMetric(
+				"java",
+				map[string]string{"level": "ERROR"},
+				map[string]interface{}{
+					"thread":  "main",
+					"class":   "com.example.App",
+					"message": "Request failed",
+				},
+				time.Date(2020, 6, 4, 12, 41, 45, 123000000, time.UTC),
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Parser{
+				Measurement: tt.set,
+				Patterns:    []string{tt.pattern},
+				PatternSets: []string{tt.set},
+			}
+			require.NoError(t, p.Compile())
+
+			m, err := p.ParseLine(tt.line)
+			require.NoError(t, err)
+			require.NotNil(t, m)
+			testutil.RequireMetricEqual(t, tt.expected, m)
+		})
+	}
+}
+
+func TestPatternSetsUnknown(t *testing.T) {
+	p := &Parser{
+		Patterns:    []string{"%{NGINX_ERROR_LOG}"},
+		PatternSets: []string{"unknown"},
+	}
+	require.Error(t, p.Compile())
+}
+
+func TestTimestampLocale(t *testing.T) {
+	p := &Parser{
+		Patterns:        []string{`%{MY_TIMESTAMP:ts:ts-"Mon 02 Jan 2006 15:04:05"} value=%{NUMBER:value:int}`},
+		CustomPatterns:  `MY_TIMESTAMP \S+ %{MONTHDAY} \S+ %{YEAR} %{TIME}`,
+		TimestampLocale: "fr",
+	}
+	require.NoError(t, p.Compile())
+
+	m, err := p.ParseLine("mar 02 févr 2021 13:10:34 value=42")
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	require.Equal(t, time.Date(2021, 2, 2, 13, 10, 34, 0, time.UTC), m.Time())
+
+	p = &Parser{
+		Patterns:        []string{`%{MY_TIMESTAMP:ts:ts-"02 January 2006 15:04:05"} value=%{NUMBER:value:int}`},
+		CustomPatterns:  `MY_TIMESTAMP %{MONTHDAY} \S+ %{YEAR} %{TIME}`,
+		TimestampLocale: "de",
+	}
+	require.NoError(t, p.Compile())
+
+	m, err = p.ParseLine("21 März 2017 13:10:34 value=42")
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	require.Equal(t, time.Date(2017, 3, 21, 13, 10, 34, 0, time.UTC), m.Time())
+}
+
+func TestTimestampLocaleUnknown(t *testing.T) {
+	p := &Parser{
+		Patterns:        []string{"%{NUMBER:value:int}"},
+		TimestampLocale: "xx",
+	}
+	require.Error(t, p.Compile())
+}
+
+func TestTimestampFallbacks(t *testing.T) {
+	p := &Parser{
+		Patterns:           []string{`%{NOTSPACE:ts:ts-rfc3339} value=%{NUMBER:value:int}`},
+		TimestampFallbacks: []string{"ts-epoch", "2006-01-02_15:04:05"},
+		UniqueTimestamp:    "disable",
+	}
+	require.NoError(t, p.Compile())
+
+	tests := []struct {
+		line     string
+		expected time.Time
+	}{
+		{"2017-02-21T13:10:34Z value=42", time.Date(2017, 2, 21, 13, 10, 34, 0, time.UTC)},
+		{"1487682634 value=42", time.Unix(1487682634, 0)},
+		{"2017-02-21_13:10:34 value=42", time.Date(2017, 2, 21, 13, 10, 34, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		m, err := p.ParseLine(tt.line)
+		require.NoError(t, err)
+		require.NotNil(t, m)
+		require.True(t, tt.expected.Equal(m.Time()), "expected %v, got %v", tt.expected, m.Time())
+	}
+}
+
+func TestInheritTimestamp(t *testing.T) {
+	p := &Parser{
+		Measurement:      "java",
+		Patterns:         []string{"%{JAVA_LOG}", "%{JAVA_STACKTRACE_LINE}"},
+		PatternSets:      []string{"java"},
+		InheritTimestamp: true,
+		UniqueTimestamp:  "disable",
+	}
+	require.NoError(t, p.Compile())
+
+	metrics, err := p.Parse([]byte(
+		"2020-06-04 12:41:45,123 ERROR [main] com.example.App - Request failed\n" +
+			"\tat com.example.App.main(App.java:42)\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	expected := time.Date(2020, 6, 4, 12, 41, 45, 123000000, time.UTC)
+	require.Equal(t, expected, metrics[0].Time())
+	require.Equal(t, expected, metrics[1].Time())
+	require.Equal(t, int64(42), metrics[1].Fields()["line"])
+}
+
+func TestReloadCustomPatternFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grok")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "patterns")
+	require.NoError(t, ioutil.WriteFile(filename, []byte("MYLOG %{NUMBER:value:int}\n"), 0644))
+
+	now := time.Unix(0, 0)
+	p := &Parser{
+		Patterns:           []string{"%{MYLOG}"},
+		CustomPatternFiles: []string{filename},
+		ReloadInterval:     time.Minute,
+		timeFunc:           func() time.Time { return now },
+	}
+	require.NoError(t, p.Compile())
+
+	m, err := p.ParseLine("42")
+	require.NoError(t, err)
+	require.Equal(t, int64(42), m.Fields()["value"])
+
+	require.NoError(t, ioutil.WriteFile(filename, []byte("MYLOG %{NUMBER:value:float}\n"), 0644))
+	require.NoError(t, os.Chtimes(filename, time.Now(), time.Now().Add(time.Hour)))
+
+	// Not reloaded before the interval has passed
+	m, err = p.ParseLine("42")
+	require.NoError(t, err)
+	require.Equal(t, int64(42), m.Fields()["value"])
+
+	now = now.Add(time.Minute)
+	m, err = p.ParseLine("42")
+	require.NoError(t, err)
+	require.Equal(t, float64(42), m.Fields()["value"])
+
+	// Invalid patterns keep the previous ones
+	require.NoError(t, ioutil.WriteFile(filename, []byte("MYLOG %{NUMBER:a:ts-epoch} %{NUMBER:b:ts-epoch}\n"), 0644))
+	require.NoError(t, os.Chtimes(filename, time.Now(), time.Now().Add(2*time.Hour)))
+	now = now.Add(time.Minute)
+	m, err = p.ParseLine("42")
+	require.NoError(t, err)
+	require.Equal(t, float64(42), m.Fields()["value"])
+}
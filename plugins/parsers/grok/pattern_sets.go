@@ -0,0 +1,79 @@
+package grok
+
+import (
+	"fmt"
+	"sort"
+)
+
+// patternSets are bundled patterns which can be enabled by name.
+var patternSets = map[string]string{
+	"nginx": `
+# nginx error log, example log looks like this:
+#   2020/06/04 12:41:45 [error] 1234#0: *5 open() "/www/x" failed (2: No such file or directory), client: 10.0.0.1
+NGINX_ERROR_DATE %{YEAR}/%{MONTHNUM}/%{MONTHDAY} %{TIME}
+NGINX_ERROR_LOG %{NGINX_ERROR_DATE:ts:ts-"2006/01/02 15:04:05"} \[%{LOGLEVEL:level:tag}\] %{POSINT:pid:int}#%{NUMBER:tid:int}: (?:\*%{NUMBER:connection_id:int} )?%{GREEDYDATA:message}
+
+# nginx access log using the combined format followed by the request and
+# upstream response times:
+#   log_format timed '$remote_addr - $remote_user [$time_local] "$request" '
+#                    '$status $body_bytes_sent "$http_referer" '
+#                    '"$http_user_agent" $request_time $upstream_response_time';
+NGINX_ACCESS_LOG %{COMBINED_LOG_FORMAT} %{NUMBER:request_time:float} (?:%{NUMBER:upstream_response_time:float}|-)
+`,
+
+	"haproxy": `
+# HAProxy HTTP and TCP logs as sent to syslog, example log looks like this:
+#   Feb  6 12:14:14 localhost haproxy[14389]: 10.0.1.2:33317 [06/Feb/2009:12:14:14.655] http-in static/srv1 10/0/30/69/109 200 2750 - - ---- 1/1/1/1/0 0/0 {1wt.eu} {} "GET /index.html HTTP/1.1"
+HAPROXY_DATE %{MONTHDAY}/%{MONTH}/%{YEAR}:%{TIME}
+HAPROXY_HTTP_LOG %{SYSLOGTIMESTAMP:syslog_timestamp:drop} %{IPORHOST:syslog_server:tag} %{SYSLOGPROG}: %{IP:client_ip}:%{INT:client_port:int} \[%{HAPROXY_DATE:ts:ts-"02/Jan/2006:15:04:05"}\] %{NOTSPACE:frontend_name:tag} %{NOTSPACE:backend_name:tag}/%{NOTSPACE:server_name:tag} %{INT:time_request:int}/%{INT:time_queue:int}/%{INT:time_backend_connect:int}/%{INT:time_backend_response:int}/\+?%{INT:time_duration:int} %{INT:http_status_code:tag} \+?%{INT:bytes_read:int} %{NOTSPACE:captured_request_cookie} %{NOTSPACE:captured_response_cookie} %{NOTSPACE:termination_state} %{INT:actconn:int}/%{INT:feconn:int}/%{INT:beconn:int}/%{INT:srvconn:int}/\+?%{INT:retries:int} %{INT:srv_queue:int}/%{INT:backend_queue:int}(?: \{%{DATA:captured_request_headers}\})?(?: \{%{DATA:captured_response_headers}\})? "(?:%{WORD:http_verb:tag} %{NOTSPACE:http_request}(?: HTTP/%{NUMBER:http_version:float})?|<BADREQ>)"
+HAPROXY_TCP_LOG %{SYSLOGTIMESTAMP:syslog_timestamp:drop} %{IPORHOST:syslog_server:tag} %{SYSLOGPROG}: %{IP:client_ip}:%{INT:client_port:int} \[%{HAPROXY_DATE:ts:ts-"02/Jan/2006:15:04:05"}\] %{NOTSPACE:frontend_name:tag} %{NOTSPACE:backend_name:tag}/%{NOTSPACE:server_name:tag} %{INT:time_queue:int}/%{INT:time_backend_connect:int}/\+?%{INT:time_duration:int} \+?%{INT:bytes_read:int} %{NOTSPACE:termination_state} %{INT:actconn:int}/%{INT:feconn:int}/%{INT:beconn:int}/%{INT:srvconn:int}/\+?%{INT:retries:int} %{INT:srv_queue:int}/%{INT:backend_queue:int}
+`,
+
+	"postgres": `
+# PostgreSQL log using a log_line_prefix of '%m [%p] ' or '%m [%p] %u@%d ',
+# example log looks like this:
+#   2020-06-04 12:41:45.123 UTC [1234] LOG:  database system is ready to accept connections
+POSTGRES_TIMESTAMP %{YEAR}-%{MONTHNUM}-%{MONTHDAY} %{TIME} %{WORD}
+POSTGRES_PREFIX %{POSTGRES_TIMESTAMP:ts:ts-"2006-01-02 15:04:05 MST"} \[%{POSINT:pid:int}\] (?:%{USERNAME:user:tag}@%{USERNAME:database:tag} )?
+POSTGRES_LOG %{POSTGRES_PREFIX}%{WORD:level:tag}:  %{GREEDYDATA:message}
+
+# Statement durations logged with log_min_duration_statement
+POSTGRES_DURATION_LOG %{POSTGRES_PREFIX}%{WORD:level:tag}:  duration: %{NUMBER:duration_ms:float} ms(?:  (?:statement|(?:parse|bind|execute) [^:]*): %{GREEDYDATA:statement})?
+`,
+
+	"java": `
+# Java application logs in the default log4j and logback layout, example log
+# looks like this:
+#   2020-06-04 12:41:45,123 ERROR [main] com.example.App - Request failed
+JAVA_CLASS (?:[a-zA-Z$_][a-zA-Z$_0-9]*\.)*[a-zA-Z$_][a-zA-Z$_0-9]*
+JAVA_METHOD (?:<init>|<clinit>|[a-zA-Z$_][a-zA-Z$_0-9]*)
+JAVA_FILE (?:[A-Za-z0-9_. -]+)
+JAVA_LOG_TIMESTAMP %{YEAR}-%{MONTHNUM}-%{MONTHDAY} %{TIME}
+JAVA_LOG %{JAVA_LOG_TIMESTAMP:ts:ts-"2006-01-02 15:04:05"} +%{LOGLEVEL:level:tag} +\[%{DATA:thread}\] %{JAVA_CLASS:class} +- %{GREEDYDATA:message}
+
+# Stack trace lines following a log message
+#   at com.example.App.main(App.java:42)
+#   Caused by: java.lang.IllegalStateException: closed
+JAVA_STACKTRACE_LINE \s+at %{JAVA_CLASS:class}\.%{JAVA_METHOD:method}\(%{JAVA_FILE:file}(?::%{NUMBER:line:int})?\)
+JAVA_CAUSED_BY Caused by: %{JAVA_CLASS:exception}(?:: %{GREEDYDATA:message})?
+`,
+}
+
+// patternSetNames returns the sorted names of the bundled pattern sets.
+func patternSetNames() []string {
+	names := make([]string, 0, len(patternSets))
+	for name := range patternSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// patternSet returns the patterns of the named pattern set.
+func patternSet(name string) (string, error) {
+	patterns, ok := patternSets[name]
+	if !ok {
+		return "", fmt.Errorf("unknown pattern set %q; available pattern sets are %v", name, patternSetNames())
+	}
+	return patterns, nil
+}
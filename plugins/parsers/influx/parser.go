@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"strings"
 	"sync"
 	"time"
@@ -64,6 +65,7 @@ type Parser struct {
 	sync.Mutex
 	*machine
 	handler *MetricHandler
+	series  bool
 }
 
 // NewParser returns a Parser than accepts line protocol
@@ -79,6 +81,7 @@ func NewSeriesParser(handler *MetricHandler) *Parser {
 	return &Parser{
 		machine: NewSeriesMachine(handler),
 		handler: handler,
+		series:  true,
 	}
 }
 
@@ -125,6 +128,73 @@ func (p *Parser) Parse(input []byte) ([]telegraf.Metric, error) {
 	return metrics, nil
 }
 
+// ParseStream parses the line protocol read from the reader, calling fn with
+// each metric as soon as it is parsed.  Series are not streamed.
+func (p *Parser) ParseStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	if p.series {
+		return p.parseSeriesStream(r, fn)
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	machine := NewStreamMachine(r, p.handler)
+
+	for {
+		err := machine.Next()
+		if err == EOF {
+			return nil
+		}
+
+		if e, ok := err.(*readErr); ok {
+			return e.Err
+		}
+
+		if err != nil {
+			return &ParseError{
+				Offset:     machine.Position(),
+				LineOffset: machine.LineOffset(),
+				LineNumber: machine.LineNumber(),
+				Column:     machine.Column(),
+				msg:        err.Error(),
+				buf:        machine.LineText(),
+			}
+		}
+
+		metric, err := p.handler.Metric()
+		if err != nil {
+			return err
+		}
+
+		if metric == nil {
+			continue
+		}
+
+		p.applyDefaultTagsSingle(metric)
+		if err := fn(metric); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *Parser) parseSeriesStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	metrics, err := p.Parse(buf)
+	if err != nil {
+		return err
+	}
+
+	for _, metric := range metrics {
+		if err := fn(metric); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	metrics, err := p.Parse([]byte(line))
 	if err != nil {
@@ -695,6 +695,49 @@ func TestStreamParser(t *testing.T) {
 	}
 }
 
+func TestParserParseStream(t *testing.T) {
+	for _, tt := range ptests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewMetricHandler()
+			parser := NewParser(handler)
+			parser.SetTimeFunc(DefaultTime)
+			if tt.timeFunc != nil {
+				parser.SetTimeFunc(tt.timeFunc)
+			}
+
+			var metrics []telegraf.Metric
+			err := parser.ParseStream(bytes.NewBuffer(tt.input), func(m telegraf.Metric) error {
+				metrics = append(metrics, m)
+				return nil
+			})
+			if tt.err != nil {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.metrics, metrics)
+		})
+	}
+}
+
+func TestParserParseStreamCallbackError(t *testing.T) {
+	parser := NewParser(NewMetricHandler())
+	parser.SetDefaultTags(map[string]string{"host": "localhost"})
+
+	var metrics []telegraf.Metric
+	stop := errors.New("stop")
+	err := parser.ParseStream(strings.NewReader("cpu value=1\ncpu value=2\ncpu value=3\n"), func(m telegraf.Metric) error {
+		metrics = append(metrics, m)
+		if len(metrics) == 2 {
+			return stop
+		}
+		return nil
+	})
+	require.Equal(t, stop, err)
+	require.Len(t, metrics, 2)
+	require.Equal(t, map[string]string{"host": "localhost"}, metrics[0].Tags())
+}
+
 func TestSeriesParser(t *testing.T) {
 	var tests = []struct {
 		name     string
@@ -1,10 +1,13 @@
 package json
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"strconv"
 	"time"
@@ -22,29 +25,31 @@ var (
 )
 
 type Config struct {
-	MetricName   string
-	TagKeys      []string
-	NameKey      string
-	StringFields []string
-	Query        string
-	TimeKey      string
-	TimeFormat   string
-	Timezone     string
-	DefaultTags  map[string]string
-	Strict       bool
+	MetricName       string
+	TagKeys          []string
+	NameKey          string
+	StringFields     []string
+	StructuredFields []string
+	Query            string
+	TimeKey          string
+	TimeFormat       string
+	Timezone         string
+	DefaultTags      map[string]string
+	Strict           bool
 }
 
 type Parser struct {
-	metricName   string
-	tagKeys      []string
-	stringFields filter.Filter
-	nameKey      string
-	query        string
-	timeKey      string
-	timeFormat   string
-	timezone     string
-	defaultTags  map[string]string
-	strict       bool
+	metricName       string
+	tagKeys          []string
+	stringFields     filter.Filter
+	structuredFields filter.Filter
+	nameKey          string
+	query            string
+	timeKey          string
+	timeFormat       string
+	timezone         string
+	defaultTags      map[string]string
+	strict           bool
 }
 
 func New(config *Config) (*Parser, error) {
@@ -53,17 +58,23 @@ func New(config *Config) (*Parser, error) {
 		return nil, err
 	}
 
+	structuredFilter, err := filter.Compile(config.StructuredFields)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Parser{
-		metricName:   config.MetricName,
-		tagKeys:      config.TagKeys,
-		nameKey:      config.NameKey,
-		stringFields: stringFilter,
-		query:        config.Query,
-		timeKey:      config.TimeKey,
-		timeFormat:   config.TimeFormat,
-		timezone:     config.Timezone,
-		defaultTags:  config.DefaultTags,
-		strict:       config.Strict,
+		metricName:       config.MetricName,
+		tagKeys:          config.TagKeys,
+		nameKey:          config.NameKey,
+		stringFields:     stringFilter,
+		structuredFields: structuredFilter,
+		query:            config.Query,
+		timeKey:          config.TimeKey,
+		timeFormat:       config.TimeFormat,
+		timezone:         config.Timezone,
+		defaultTags:      config.DefaultTags,
+		strict:           config.Strict,
 	}, nil
 }
 
@@ -90,12 +101,37 @@ func (p *Parser) parseArray(data []interface{}, timestamp time.Time) ([]telegraf
 	return results, nil
 }
 
+// splitStructured returns the object without the arrays and objects matching
+// json_structured_fields, and these values which are kept as is instead of
+// being flattened.
+func (p *Parser) splitStructured(data map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	if p.structuredFields == nil {
+		return data, nil
+	}
+
+	flat := make(map[string]interface{}, len(data))
+	structured := make(map[string]interface{})
+	for k, v := range data {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			if p.structuredFields.Match(k) {
+				structured[k] = v
+				continue
+			}
+		}
+		flat[k] = v
+	}
+	return flat, structured
+}
+
 func (p *Parser) parseObject(data map[string]interface{}, timestamp time.Time) ([]telegraf.Metric, error) {
 	tags := make(map[string]string)
 	for k, v := range p.defaultTags {
 		tags[k] = v
 	}
 
+	data, structured := p.splitStructured(data)
+
 	f := JSONFlattener{}
 	err := f.FullFlattenJSON("", data, true, true)
 	if err != nil {
@@ -138,6 +174,9 @@ func (p *Parser) parseObject(data map[string]interface{}, timestamp time.Time) (
 	}
 
 	tags, nFields := p.switchFieldToTag(tags, f.Fields)
+	for k, v := range structured {
+		nFields[k] = v
+	}
 	metric, err := metric.New(name, tags, nFields, timestamp)
 	if err != nil {
 		return nil, err
@@ -216,6 +255,109 @@ func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
 	}
 }
 
+// ParseStream parses the JSON read from the reader, calling fn with each
+// metric as soon as it is parsed.  The elements of a top-level array are
+// decoded one at a time.  Data selected by json_query is not streamed.
+func (p *Parser) ParseStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	if p.query != "" {
+		buf, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		metrics, err := p.Parse(buf)
+		if err != nil {
+			return err
+		}
+		return emit(metrics, fn)
+	}
+
+	br := bufio.NewReader(r)
+	if bom, err := br.Peek(len(utf8BOM)); err == nil && bytes.Equal(bom, utf8BOM) {
+		br.Discard(len(utf8BOM))
+	}
+
+	// Skip the leading whitespace to find the type of the top-level value
+	var first byte
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			first = c
+			br.UnreadByte()
+			break
+		}
+	}
+
+	decoder := json.NewDecoder(br)
+	timestamp := time.Now().UTC()
+	switch first {
+	case '{':
+		var data map[string]interface{}
+		if err := decoder.Decode(&data); err != nil {
+			return err
+		}
+		metrics, err := p.parseObject(data, timestamp)
+		if err != nil {
+			return err
+		}
+		if err := emit(metrics, fn); err != nil {
+			return err
+		}
+	case '[':
+		if _, err := decoder.Token(); err != nil {
+			return err
+		}
+		for decoder.More() {
+			var item interface{}
+			if err := decoder.Decode(&item); err != nil {
+				return err
+			}
+			v, ok := item.(map[string]interface{})
+			if !ok {
+				return ErrWrongType
+			}
+			metrics, err := p.parseObject(v, timestamp)
+			if err != nil {
+				if p.strict {
+					return err
+				}
+				continue
+			}
+			if err := emit(metrics, fn); err != nil {
+				return err
+			}
+		}
+		if _, err := decoder.Token(); err != nil {
+			return err
+		}
+	default:
+		return ErrWrongType
+	}
+
+	// Like Parse, only a single top-level value is accepted
+	if _, err := decoder.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("invalid data after top-level value")
+		}
+		return err
+	}
+	return nil
+}
+
+func emit(metrics []telegraf.Metric, fn func(telegraf.Metric) error) error {
+	for _, m := range metrics {
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	metrics, err := p.Parse([]byte(line + "\n"))
 
@@ -2,6 +2,7 @@ package json
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -948,3 +949,109 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestParseStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		strict  bool
+		timeKey string
+		query   string
+		input   string
+	}{
+		{name: "object", input: validJSON},
+		{name: "object with newlines", input: validJSONNewline},
+		{name: "array", input: validJSONArray},
+		{name: "array with multiple objects", input: validJSONArrayMultiple},
+		{name: "tags", input: validJSONTags},
+		{name: "array with tags", input: validJSONArrayTags},
+		{name: "byte order mark", input: "\xef\xbb\xbf" + validJSON},
+		{name: "empty", input: " \n"},
+		{name: "mixed validity", timeKey: "time", input: mixedValidityJSON},
+		{name: "query", query: "b", input: validJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := New(&Config{
+				MetricName: "json_test",
+				TagKeys:    []string{"mytag", "othertag"},
+				TimeKey:    tt.timeKey,
+				TimeFormat: "2006-01-02T15:04:05",
+				Query:      tt.query,
+				Strict:     tt.strict,
+			})
+			require.NoError(t, err)
+
+			expected, err := parser.Parse([]byte(tt.input))
+			require.NoError(t, err)
+
+			actual := []telegraf.Metric{}
+			err = parser.ParseStream(strings.NewReader(tt.input), func(m telegraf.Metric) error {
+				actual = append(actual, m)
+				return nil
+			})
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
+		})
+	}
+}
+
+func TestParseStreamErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "invalid", input: invalidJSON},
+		{name: "invalid object", input: invalidJSON2},
+		{name: "scalar", input: "5"},
+		{name: "array of scalars", input: "[1, 2]"},
+		{name: "trailing data", input: validJSON + validJSON},
+		{name: "strict", input: mixedValidityJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := New(&Config{
+				MetricName: "json_test",
+				TimeKey:    "time",
+				TimeFormat: "2006-01-02T15:04:05",
+				Strict:     true,
+			})
+			require.NoError(t, err)
+
+			err = parser.ParseStream(strings.NewReader(tt.input), func(m telegraf.Metric) error {
+				return nil
+			})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestParseStructuredFields(t *testing.T) {
+	parser, err := New(&Config{
+		MetricName:       "json_test",
+		TagKeys:          []string{"host"},
+		StructuredFields: []string{"labels", "owner", "value"},
+	})
+	require.NoError(t, err)
+
+	actual, err := parser.Parse([]byte(`{
+		"host": "a",
+		"value": 1,
+		"labels": ["web", "db"],
+		"owner": {"name": "ops", "id": 4},
+		"disk": {"used": 42}
+	}`))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("json_test",
+			map[string]string{"host": "a"},
+			map[string]interface{}{
+				"value":     1.0,
+				"labels":    []interface{}{"web", "db"},
+				"owner":     map[string]interface{}{"name": "ops", "id": 4.0},
+				"disk_used": 42.0,
+			},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
+}
@@ -3,6 +3,7 @@ package logfmt
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"strconv"
 	"time"
 
@@ -33,17 +34,25 @@ func NewParser(metricName string, defaultTags map[string]string) *Parser {
 
 // Parse converts a slice of bytes in logfmt format to metrics.
 func (p *Parser) Parse(b []byte) ([]telegraf.Metric, error) {
-	reader := bytes.NewReader(b)
-	decoder := logfmt.NewDecoder(reader)
 	metrics := make([]telegraf.Metric, 0)
+	err := p.ParseStream(bytes.NewReader(b), func(m telegraf.Metric) error {
+		metrics = append(metrics, m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// ParseStream converts the logfmt data read from the reader to metrics,
+// calling fn with each metric as soon as it is parsed.
+func (p *Parser) ParseStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	decoder := logfmt.NewDecoder(r)
 	for {
 		ok := decoder.ScanRecord()
 		if !ok {
-			err := decoder.Err()
-			if err != nil {
-				return nil, err
-			}
-			break
+			return decoder.Err()
 		}
 		fields := make(map[string]interface{})
 		for decoder.ScanKeyval() {
@@ -69,13 +78,14 @@ func (p *Parser) Parse(b []byte) ([]telegraf.Metric, error) {
 
 		m, err := metric.New(p.MetricName, map[string]string{}, fields, p.Now())
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		metrics = append(metrics, m)
+		p.applyDefaultTags(m)
+		if err := fn(m); err != nil {
+			return err
+		}
 	}
-	p.applyDefaultTags(metrics)
-	return metrics, nil
 }
 
 // ParseLine converts a single line of text in logfmt format to metrics.
@@ -96,16 +106,10 @@ func (p *Parser) SetDefaultTags(tags map[string]string) {
 	p.DefaultTags = tags
 }
 
-func (p *Parser) applyDefaultTags(metrics []telegraf.Metric) {
-	if len(p.DefaultTags) == 0 {
-		return
-	}
-
-	for _, m := range metrics {
-		for k, v := range p.DefaultTags {
-			if !m.HasTag(k) {
-				m.AddTag(k, v)
-			}
+func (p *Parser) applyDefaultTags(m telegraf.Metric) {
+	for k, v := range p.DefaultTags {
+		if !m.HasTag(k) {
+			m.AddTag(k, v)
 		}
 	}
 }
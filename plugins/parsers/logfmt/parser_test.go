@@ -1,12 +1,14 @@
 package logfmt
 
 import (
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
 )
 
 func MustMetric(t *testing.T, m *testutil.Metric) telegraf.Metric {
@@ -224,3 +226,40 @@ func TestParseLine(t *testing.T) {
 		})
 	}
 }
+
+func TestParseStream(t *testing.T) {
+	l := Parser{
+		MetricName:  "testlog",
+		DefaultTags: map[string]string{"host": "localhost"},
+		Now:         func() time.Time { return time.Unix(0, 0) },
+	}
+
+	var got []telegraf.Metric
+	err := l.ParseStream(strings.NewReader("lvl=5 msg=first\n\nlvl=4 msg=second\n"), func(m telegraf.Metric) error {
+		got = append(got, m)
+		return nil
+	})
+	require.NoError(t, err)
+
+	want := []telegraf.Metric{
+		testutil.MustMetric(
+			"testlog",
+			map[string]string{"host": "localhost"},
+			map[string]interface{}{
+				"lvl": int64(5),
+				"msg": "first",
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"testlog",
+			map[string]string{"host": "localhost"},
+			map[string]interface{}{
+				"lvl": int64(4),
+				"msg": "second",
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, want, got)
+}
@@ -2,9 +2,13 @@ package parsers
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/parsers/avro"
+	"github.com/influxdata/telegraf/plugins/parsers/binary"
 	"github.com/influxdata/telegraf/plugins/parsers/collectd"
 	"github.com/influxdata/telegraf/plugins/parsers/csv"
 	"github.com/influxdata/telegraf/plugins/parsers/dropwizard"
@@ -15,6 +19,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/influxdata/telegraf/plugins/parsers/logfmt"
 	"github.com/influxdata/telegraf/plugins/parsers/nagios"
+	"github.com/influxdata/telegraf/plugins/parsers/syslog"
 	"github.com/influxdata/telegraf/plugins/parsers/value"
 	"github.com/influxdata/telegraf/plugins/parsers/wavefront"
 )
@@ -57,6 +62,44 @@ type Parser interface {
 	SetDefaultTags(tags map[string]string)
 }
 
+// StreamParser is an interface for parsers which are able to parse data
+// incrementally, so that large payloads don't have to be held in memory.
+type StreamParser interface {
+	// ParseStream parses the data read from the reader and calls fn with
+	// each metric as soon as it is parsed.  Parsing stops at the first
+	// error, either a parse error or an error returned by fn; the metrics
+	// passed to fn before the error are not revoked.
+	//
+	// Must be thread-safe.
+	ParseStream(r io.Reader, fn func(telegraf.Metric) error) error
+}
+
+// ParseStream parses the data read from the reader with the given parser and
+// calls fn with each metric.  The data is streamed if the parser implements
+// StreamParser, otherwise it is read completely before being parsed.
+func ParseStream(parser Parser, r io.Reader, fn func(telegraf.Metric) error) error {
+	if sp, ok := parser.(StreamParser); ok {
+		return sp.ParseStream(r, fn)
+	}
+
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	metrics, err := parser.Parse(buf)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range metrics {
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Config is a struct that covers the data types needed for all parser types,
 // and can be used to instantiate _any_ of the parsers.
 type Config struct {
@@ -72,6 +115,9 @@ type Config struct {
 	TagKeys []string `toml:"tag_keys"`
 	// Array of glob pattern strings keys that should be added as string fields.
 	JSONStringFields []string `toml:"json_string_fields"`
+	// Array of glob pattern strings keys of arrays and objects that should be
+	// added as structured fields instead of being flattened.
+	JSONStructuredFields []string `toml:"json_structured_fields"`
 
 	JSONNameKey string `toml:"json_name_key"`
 	// MetricName applies to JSON & value. This will be the name of the measurement.
@@ -125,12 +171,17 @@ type Config struct {
 	DropwizardTagPathsMap map[string]string `toml:"dropwizard_tag_paths_map"`
 
 	//grok patterns
-	GrokPatterns           []string `toml:"grok_patterns"`
-	GrokNamedPatterns      []string `toml:"grok_named_patterns"`
-	GrokCustomPatterns     string   `toml:"grok_custom_patterns"`
-	GrokCustomPatternFiles []string `toml:"grok_custom_pattern_files"`
-	GrokTimezone           string   `toml:"grok_timezone"`
-	GrokUniqueTimestamp    string   `toml:"grok_unique_timestamp"`
+	GrokPatterns           []string      `toml:"grok_patterns"`
+	GrokNamedPatterns      []string      `toml:"grok_named_patterns"`
+	GrokCustomPatterns     string        `toml:"grok_custom_patterns"`
+	GrokCustomPatternFiles []string      `toml:"grok_custom_pattern_files"`
+	GrokTimezone           string        `toml:"grok_timezone"`
+	GrokUniqueTimestamp    string        `toml:"grok_unique_timestamp"`
+	GrokPatternSets        []string      `toml:"grok_pattern_sets"`
+	GrokReloadInterval     time.Duration `toml:"grok_reload_interval"`
+	GrokTimestampLocale    string        `toml:"grok_timestamp_locale"`
+	GrokTimestampFallbacks []string      `toml:"grok_timestamp_fallbacks"`
+	GrokInheritTimestamp   bool          `toml:"grok_inherit_timestamp"`
 
 	//csv configuration
 	CSVColumnNames       []string `toml:"csv_column_names"`
@@ -146,9 +197,29 @@ type Config struct {
 	CSVTimestampFormat   string   `toml:"csv_timestamp_format"`
 	CSVTimezone          string   `toml:"csv_timezone"`
 	CSVTrimSpace         bool     `toml:"csv_trim_space"`
+	CSVSkipErrors        bool     `toml:"csv_skip_errors"`
 
 	// FormData configuration
 	FormUrlencodedTagKeys []string `toml:"form_urlencoded_tag_keys"`
+
+	// Syslog configuration
+	SyslogRFC        string `toml:"syslog_rfc"`
+	SyslogBestEffort bool   `toml:"syslog_best_effort"`
+	SyslogSeparator  string `toml:"syslog_sdparam_separator"`
+
+	// Binary configuration
+	BinaryEndianness string         `toml:"binary_endianness"`
+	BinaryFields     []binary.Field `toml:"binary_field"`
+
+	// Avro configuration
+	AvroSchemaRegistry  string   `toml:"avro_schema_registry"`
+	AvroSchema          string   `toml:"avro_schema"`
+	AvroMeasurement     string   `toml:"avro_measurement"`
+	AvroTags            []string `toml:"avro_tags"`
+	AvroFields          []string `toml:"avro_fields"`
+	AvroTimestamp       string   `toml:"avro_timestamp"`
+	AvroTimestampFormat string   `toml:"avro_timestamp_format"`
+	AvroFieldSeparator  string   `toml:"avro_field_separator"`
 }
 
 // NewParser returns a Parser interface based on the given config.
@@ -159,16 +230,17 @@ func NewParser(config *Config) (Parser, error) {
 	case "json":
 		parser, err = json.New(
 			&json.Config{
-				MetricName:   config.MetricName,
-				TagKeys:      config.TagKeys,
-				NameKey:      config.JSONNameKey,
-				StringFields: config.JSONStringFields,
-				Query:        config.JSONQuery,
-				TimeKey:      config.JSONTimeKey,
-				TimeFormat:   config.JSONTimeFormat,
-				Timezone:     config.JSONTimezone,
-				DefaultTags:  config.DefaultTags,
-				Strict:       config.JSONStrict,
+				MetricName:       config.MetricName,
+				TagKeys:          config.TagKeys,
+				NameKey:          config.JSONNameKey,
+				StringFields:     config.JSONStringFields,
+				StructuredFields: config.JSONStructuredFields,
+				Query:            config.JSONQuery,
+				TimeKey:          config.JSONTimeKey,
+				TimeFormat:       config.JSONTimeFormat,
+				Timezone:         config.JSONTimezone,
+				DefaultTags:      config.DefaultTags,
+				Strict:           config.JSONStrict,
 			},
 		)
 	case "value":
@@ -197,14 +269,7 @@ func NewParser(config *Config) (Parser, error) {
 	case "wavefront":
 		parser, err = NewWavefrontParser(config.DefaultTags)
 	case "grok":
-		parser, err = newGrokParser(
-			config.MetricName,
-			config.GrokPatterns,
-			config.GrokNamedPatterns,
-			config.GrokCustomPatterns,
-			config.GrokCustomPatternFiles,
-			config.GrokTimezone,
-			config.GrokUniqueTimestamp)
+		parser, err = newGrokParser(config)
 	case "csv":
 		parser, err = newCSVParser(config.MetricName,
 			config.CSVHeaderRowCount,
@@ -213,6 +278,7 @@ func NewParser(config *Config) (Parser, error) {
 			config.CSVDelimiter,
 			config.CSVComment,
 			config.CSVTrimSpace,
+			config.CSVSkipErrors,
 			config.CSVColumnNames,
 			config.CSVColumnTypes,
 			config.CSVTagColumns,
@@ -229,6 +295,22 @@ func NewParser(config *Config) (Parser, error) {
 			config.DefaultTags,
 			config.FormUrlencodedTagKeys,
 		)
+	case "syslog":
+		parser, err = NewSyslogParser(
+			config.SyslogRFC,
+			config.SyslogBestEffort,
+			config.SyslogSeparator,
+			config.DefaultTags,
+		)
+	case "binary":
+		parser, err = NewBinaryParser(
+			config.MetricName,
+			config.BinaryEndianness,
+			config.BinaryFields,
+			config.DefaultTags,
+		)
+	case "avro":
+		parser, err = NewAvroParser(config)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -242,6 +324,7 @@ func newCSVParser(metricName string,
 	delimiter string,
 	comment string,
 	trimSpace bool,
+	skipErrors bool,
 	columnNames []string,
 	columnTypes []string,
 	tagColumns []string,
@@ -281,6 +364,7 @@ func newCSVParser(metricName string,
 		Delimiter:         delimiter,
 		Comment:           comment,
 		TrimSpace:         trimSpace,
+		SkipErrors:        skipErrors,
 		ColumnNames:       columnNames,
 		ColumnTypes:       columnTypes,
 		TagColumns:        tagColumns,
@@ -295,18 +379,20 @@ func newCSVParser(metricName string,
 	return parser, nil
 }
 
-func newGrokParser(metricName string,
-	patterns []string, nPatterns []string,
-	cPatterns string, cPatternFiles []string,
-	tZone string, uniqueTimestamp string) (Parser, error) {
+func newGrokParser(config *Config) (Parser, error) {
 	parser := grok.Parser{
-		Measurement:        metricName,
-		Patterns:           patterns,
-		NamedPatterns:      nPatterns,
-		CustomPatterns:     cPatterns,
-		CustomPatternFiles: cPatternFiles,
-		Timezone:           tZone,
-		UniqueTimestamp:    uniqueTimestamp,
+		Measurement:        config.MetricName,
+		Patterns:           config.GrokPatterns,
+		NamedPatterns:      config.GrokNamedPatterns,
+		CustomPatterns:     config.GrokCustomPatterns,
+		CustomPatternFiles: config.GrokCustomPatternFiles,
+		Timezone:           config.GrokTimezone,
+		UniqueTimestamp:    config.GrokUniqueTimestamp,
+		PatternSets:        config.GrokPatternSets,
+		ReloadInterval:     config.GrokReloadInterval,
+		TimestampLocale:    config.GrokTimestampLocale,
+		TimestampFallbacks: config.GrokTimestampFallbacks,
+		InheritTimestamp:   config.GrokInheritTimestamp,
 	}
 
 	err := parser.Compile()
@@ -381,6 +467,43 @@ func NewLogFmtParser(metricName string, defaultTags map[string]string) (Parser,
 	return logfmt.NewParser(metricName, defaultTags), nil
 }
 
+// NewSyslogParser returns a syslog parser.
+func NewSyslogParser(rfc string, bestEffort bool, separator string, defaultTags map[string]string) (Parser, error) {
+	parser, err := syslog.NewParser(rfc, bestEffort, separator, defaultTags)
+	if err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
+
+// NewBinaryParser returns a parser for fixed-layout binary payloads.
+func NewBinaryParser(metricName string, endianness string, fields []binary.Field, defaultTags map[string]string) (Parser, error) {
+	parser, err := binary.NewParser(metricName, endianness, fields, defaultTags)
+	if err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
+
+func NewAvroParser(config *Config) (Parser, error) {
+	parser := &avro.Parser{
+		MetricName:      config.MetricName,
+		SchemaRegistry:  config.AvroSchemaRegistry,
+		Schema:          config.AvroSchema,
+		Measurement:     config.AvroMeasurement,
+		Tags:            config.AvroTags,
+		Fields:          config.AvroFields,
+		Timestamp:       config.AvroTimestamp,
+		TimestampFormat: config.AvroTimestampFormat,
+		FieldSeparator:  config.AvroFieldSeparator,
+		DefaultTags:     config.DefaultTags,
+	}
+	if err := parser.Init(); err != nil {
+		return nil, err
+	}
+	return parser, nil
+}
+
 func NewWavefrontParser(defaultTags map[string]string) (Parser, error) {
 	return wavefront.NewWavefrontParser(defaultTags), nil
 }
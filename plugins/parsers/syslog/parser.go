@@ -0,0 +1,232 @@
+package syslog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/influxdata/go-syslog/v2"
+	"github.com/influxdata/go-syslog/v2/rfc5424"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+const (
+	// RFCAuto detects the format of each message.
+	RFCAuto = "auto"
+	// RFC5424 parses messages as described in RFC5424.
+	RFC5424 = "rfc5424"
+	// RFC3164 parses messages as described in RFC3164 (BSD syslog).
+	RFC3164 = "rfc3164"
+)
+
+// Parser decodes syslog messages into metrics with the same shape as the
+// metrics produced by the syslog input.
+type Parser struct {
+	// RFC is one of RFCAuto, RFC5424 or RFC3164.
+	RFC string
+	// BestEffort returns partial RFC5424 messages instead of an error.
+	BestEffort bool
+	// Separator is placed between the SD-ID and the SD-PARAM names of the
+	// structured data fields.
+	Separator   string
+	DefaultTags map[string]string
+	Now         func() time.Time
+
+	machine  syslog.Machine
+	lastTime time.Time
+}
+
+// NewParser creates a parser.
+func NewParser(rfc string, bestEffort bool, separator string, defaultTags map[string]string) (*Parser, error) {
+	if rfc == "" {
+		rfc = RFCAuto
+	}
+	switch rfc {
+	case RFCAuto, RFC5424, RFC3164:
+	default:
+		return nil, fmt.Errorf("unknown syslog rfc %q; must be one of %q, %q or %q", rfc, RFCAuto, RFC5424, RFC3164)
+	}
+	if separator == "" {
+		separator = "_"
+	}
+
+	var opts []syslog.MachineOption
+	if bestEffort {
+		opts = append(opts, rfc5424.WithBestEffort())
+	}
+
+	return &Parser{
+		RFC:         rfc,
+		BestEffort:  bestEffort,
+		Separator:   separator,
+		DefaultTags: defaultTags,
+		Now:         time.Now,
+		machine:     rfc5424.NewParser(opts...),
+	}, nil
+}
+
+// Parse converts newline separated syslog messages to metrics.  Lines not
+// starting with a priority are continuations of the previous message.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	var messages [][]byte
+	for _, line := range bytes.Split(buf, []byte("\n")) {
+		line = bytes.TrimRight(line, "\r")
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] != '<' && len(messages) > 0 {
+			last := len(messages) - 1
+			messages[last] = append(append(messages[last], '\n'), line...)
+			continue
+		}
+		messages = append(messages, append([]byte(nil), line...))
+	}
+
+	metrics := make([]telegraf.Metric, 0, len(messages))
+	for _, message := range messages {
+		m, err := p.parseMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// ParseLine converts a single syslog message to a metric.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	return p.parseMessage([]byte(strings.TrimRight(line, "\r\n")))
+}
+
+// SetDefaultTags sets the tags added to all parsed metrics.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) parseMessage(buf []byte) (telegraf.Metric, error) {
+	var msg syslog.Message
+	var err error
+	rfc := p.RFC
+	if rfc == RFCAuto {
+		rfc = detectRFC(buf)
+	}
+	switch rfc {
+	case RFC5424:
+		msg, err = p.machine.Parse(buf)
+		if err != nil && (!p.BestEffort || msg == nil) {
+			return nil, err
+		}
+	case RFC3164:
+		msg, err = parseRFC3164(buf, p.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tags := Tags(msg)
+	for k, v := range p.DefaultTags {
+		if _, ok := tags[k]; !ok {
+			tags[k] = v
+		}
+	}
+	return metric.New("syslog", tags, Fields(msg, p.Separator), p.time())
+}
+
+// time returns the receive time of the message, messages parsed within the
+// same nanosecond get distinct timestamps like in the syslog input.
+func (p *Parser) time() time.Time {
+	t := p.Now()
+	if !t.After(p.lastTime) {
+		t = p.lastTime.Add(time.Nanosecond)
+	}
+	p.lastTime = t
+	return t
+}
+
+// detectRFC returns RFC5424 if the priority is followed by a version and
+// RFC3164 otherwise.
+func detectRFC(buf []byte) string {
+	end := bytes.IndexByte(buf, '>')
+	if end < 0 {
+		return RFC5424
+	}
+	rest := buf[end+1:]
+	n := 0
+	for n < len(rest) && rest[n] >= '0' && rest[n] <= '9' {
+		n++
+	}
+	if n > 0 && n <= 3 && n < len(rest) && rest[n] == ' ' {
+		return RFC5424
+	}
+	return RFC3164
+}
+
+// Tags returns the tags of the metric created for a syslog message.
+func Tags(msg syslog.Message) map[string]string {
+	ts := map[string]string{}
+
+	// Not checking assuming a minimally valid message
+	ts["severity"] = *msg.SeverityShortLevel()
+	ts["facility"] = *msg.FacilityLevel()
+
+	if msg.Hostname() != nil {
+		ts["hostname"] = *msg.Hostname()
+	}
+
+	if msg.Appname() != nil {
+		ts["appname"] = *msg.Appname()
+	}
+
+	return ts
+}
+
+// Fields returns the fields of the metric created for a syslog message.  The
+// separator is placed between the SD-ID and SD-PARAM names of the structured
+// data fields.
+func Fields(msg syslog.Message, separator string) map[string]interface{} {
+	// Not checking assuming a minimally valid message
+	flds := map[string]interface{}{}
+	// RFC3164 messages have no version
+	if msg.Version() > 0 {
+		flds["version"] = msg.Version()
+	}
+	flds["severity_code"] = int(*msg.Severity())
+	flds["facility_code"] = int(*msg.Facility())
+
+	if msg.Timestamp() != nil {
+		flds["timestamp"] = (*msg.Timestamp()).UnixNano()
+	}
+
+	if msg.ProcID() != nil {
+		flds["procid"] = *msg.ProcID()
+	}
+
+	if msg.MsgID() != nil {
+		flds["msgid"] = *msg.MsgID()
+	}
+
+	if msg.Message() != nil {
+		flds["message"] = strings.TrimRightFunc(*msg.Message(), func(r rune) bool {
+			return unicode.IsSpace(r)
+		})
+	}
+
+	if msg.StructuredData() != nil {
+		for sdid, sdparams := range *msg.StructuredData() {
+			if len(sdparams) == 0 {
+				// When SD-ID does not have params we indicate its presence with a bool
+				flds[sdid] = true
+				continue
+			}
+			for name, value := range sdparams {
+				// Using whitespace as separator since it is not allowed by the grammar within SDID
+				flds[sdid+separator+name] = value
+			}
+		}
+	}
+
+	return flds
+}
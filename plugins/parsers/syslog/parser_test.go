@@ -0,0 +1,256 @@
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var defaultTime = time.Unix(1577836800, 0)
+
+func newTestParser(t *testing.T, rfc string, bestEffort bool) *Parser {
+	p, err := NewParser(rfc, bestEffort, "", nil)
+	require.NoError(t, err)
+	p.Now = func() time.Time { return defaultTime }
+	return p
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		rfc        string
+		bestEffort bool
+		input      string
+		expected   []telegraf.Metric
+	}{
+		{
+			name:  "rfc5424",
+			rfc:   RFCAuto,
+			input: `<29>1 2016-02-21T04:32:57+00:00 web1 someservice 2341 2 [origin][meta sequence="14125553" service="someservice"] "GET /v1/ok HTTP/1.1" 200 145 "-" "hacheck 0.9.0" 24306 127.0.0.1:40124 575`,
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"syslog",
+					map[string]string{
+						"severity": "notice",
+						"facility": "daemon",
+						"hostname": "web1",
+						"appname":  "someservice",
+					},
+					map[string]interface{}{
+						"version":       uint16(1),
+						"timestamp":     time.Unix(1456029177, 0).UnixNano(),
+						"procid":        "2341",
+						"msgid":         "2",
+						"message":       `"GET /v1/ok HTTP/1.1" 200 145 "-" "hacheck 0.9.0" 24306 127.0.0.1:40124 575`,
+						"origin":        true,
+						"meta_sequence": "14125553",
+						"meta_service":  "someservice",
+						"severity_code": 5,
+						"facility_code": 3,
+					},
+					defaultTime,
+				),
+			},
+		},
+		{
+			name:  "rfc3164",
+			rfc:   RFCAuto,
+			input: `<34>Oct 11 22:14:15 mymachine su[1234]: 'su root' failed for lonvick on /dev/pts/8`,
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"syslog",
+					map[string]string{
+						"severity": "crit",
+						"facility": "auth",
+						"hostname": "mymachine",
+						"appname":  "su",
+					},
+					map[string]interface{}{
+						"timestamp":     time.Date(2019, 10, 11, 22, 14, 15, 0, time.UTC).UnixNano(),
+						"procid":        "1234",
+						"message":       "'su root' failed for lonvick on /dev/pts/8",
+						"severity_code": 2,
+						"facility_code": 4,
+					},
+					defaultTime,
+				),
+			},
+		},
+		{
+			name:  "rfc3164 with rfc3339 timestamp and without procid",
+			rfc:   RFC3164,
+			input: `<13>2020-01-01T00:00:00.5Z host1 CRON: (root) CMD (run-parts /etc/cron.hourly)`,
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"syslog",
+					map[string]string{
+						"severity": "notice",
+						"facility": "user",
+						"hostname": "host1",
+						"appname":  "CRON",
+					},
+					map[string]interface{}{
+						"timestamp":     time.Date(2020, 1, 1, 0, 0, 0, 500000000, time.UTC).UnixNano(),
+						"message":       "(root) CMD (run-parts /etc/cron.hourly)",
+						"severity_code": 5,
+						"facility_code": 1,
+					},
+					defaultTime,
+				),
+			},
+		},
+		{
+			name:  "rfc3164 without timestamp",
+			rfc:   RFC3164,
+			input: `<13>just a message`,
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"syslog",
+					map[string]string{
+						"severity": "notice",
+						"facility": "user",
+					},
+					map[string]interface{}{
+						"message":       "just a message",
+						"severity_code": 5,
+						"facility_code": 1,
+					},
+					defaultTime,
+				),
+			},
+		},
+		{
+			name:       "rfc5424 best effort",
+			rfc:        RFC5424,
+			bestEffort: true,
+			input:      `<1>2 - - - - - -`,
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"syslog",
+					map[string]string{
+						"severity": "alert",
+						"facility": "kern",
+					},
+					map[string]interface{}{
+						"version":       uint16(2),
+						"severity_code": 1,
+						"facility_code": 0,
+					},
+					defaultTime,
+				),
+			},
+		},
+		{
+			name: "multiple messages with continuation lines",
+			rfc:  RFCAuto,
+			input: "<34>Oct 11 22:14:15 mymachine app: first\n  second\n" +
+				"<1>1 - - - - - - third\n",
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"syslog",
+					map[string]string{
+						"severity": "crit",
+						"facility": "auth",
+						"hostname": "mymachine",
+						"appname":  "app",
+					},
+					map[string]interface{}{
+						"timestamp":     time.Date(2019, 10, 11, 22, 14, 15, 0, time.UTC).UnixNano(),
+						"message":       "first\n  second",
+						"severity_code": 2,
+						"facility_code": 4,
+					},
+					defaultTime,
+				),
+				testutil.MustMetric(
+					"syslog",
+					map[string]string{
+						"severity": "alert",
+						"facility": "kern",
+					},
+					map[string]interface{}{
+						"version":       uint16(1),
+						"message":       "third",
+						"severity_code": 1,
+						"facility_code": 0,
+					},
+					defaultTime.Add(time.Nanosecond),
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestParser(t, tt.rfc, tt.bestEffort)
+			actual, err := p.Parse([]byte(tt.input))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		rfc   string
+		input string
+	}{
+		{
+			name:  "rfc5424 strict",
+			rfc:   RFC5424,
+			input: `<1>2 - - - - - X`,
+		},
+		{
+			name:  "rfc3164 missing priority",
+			rfc:   RFC3164,
+			input: `Oct 11 22:14:15 mymachine su: failed`,
+		},
+		{
+			name:  "rfc3164 priority out of range",
+			rfc:   RFC3164,
+			input: `<192>Oct 11 22:14:15 mymachine su: failed`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestParser(t, tt.rfc, false)
+			_, err := p.Parse([]byte(tt.input))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestParseLineDefaultTags(t *testing.T) {
+	p := newTestParser(t, RFCAuto, false)
+	p.SetDefaultTags(map[string]string{"source": "kafka", "hostname": "ignored"})
+
+	m, err := p.ParseLine("<34>Oct 11 22:14:15 mymachine su: failed\n")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"severity": "crit",
+		"facility": "auth",
+		"hostname": "mymachine",
+		"appname":  "su",
+		"source":   "kafka",
+	}, m.Tags())
+}
+
+func TestRFC3164TimestampYear(t *testing.T) {
+	// A timestamp more than a day in the future belongs to the previous year
+	now := time.Date(2020, 1, 1, 0, 10, 0, 0, time.UTC)
+	msg, err := parseRFC3164([]byte("<34>Dec 31 23:59:00 host app: msg"), now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2019, 12, 31, 23, 59, 0, 0, time.UTC), *msg.Timestamp())
+
+	msg, err = parseRFC3164([]byte("<34>Jan  1 00:09:00 host app: msg"), now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2020, 1, 1, 0, 9, 0, 0, time.UTC), *msg.Timestamp())
+}
+
+func TestInvalidRFC(t *testing.T) {
+	_, err := NewParser("rfc1234", false, "", nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,175 @@
+package syslog
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/go-syslog/v2/rfc5424"
+)
+
+// rfc3164Message is a BSD syslog message.  The priority based methods are
+// provided by the embedded RFC5424 message.
+type rfc3164Message struct {
+	*rfc5424.SyslogMessage
+
+	timestamp *time.Time
+	hostname  *string
+	appname   *string
+	procid    *string
+	message   *string
+}
+
+func (m *rfc3164Message) Valid() bool {
+	return m.Priority() != nil
+}
+
+func (m *rfc3164Message) Version() uint16 {
+	return 0
+}
+
+func (m *rfc3164Message) Timestamp() *time.Time {
+	return m.timestamp
+}
+
+func (m *rfc3164Message) Hostname() *string {
+	return m.hostname
+}
+
+func (m *rfc3164Message) Appname() *string {
+	return m.appname
+}
+
+func (m *rfc3164Message) ProcID() *string {
+	return m.procid
+}
+
+func (m *rfc3164Message) MsgID() *string {
+	return nil
+}
+
+func (m *rfc3164Message) Message() *string {
+	return m.message
+}
+
+func (m *rfc3164Message) StructuredData() *map[string]map[string]string {
+	return nil
+}
+
+// parseRFC3164 parses a message like "<34>Oct 11 22:14:15 mymachine su[42]: 'su root' failed".
+// The timestamp can also be in RFC3339 format, timestamps without a year are
+// in UTC and in the year of now unless that puts them more than a day in the
+// future.  Messages without a valid timestamp only consist of a message as
+// described in RFC3164 section 4.3.3.
+func parseRFC3164(buf []byte, now time.Time) (*rfc3164Message, error) {
+	if len(buf) == 0 || buf[0] != '<' {
+		return nil, fmt.Errorf("expecting a priority value within angle brackets")
+	}
+	end := bytes.IndexByte(buf, '>')
+	if end < 2 || end > 4 {
+		return nil, fmt.Errorf("expecting a priority value within angle brackets")
+	}
+	pri, err := strconv.ParseUint(string(buf[1:end]), 10, 8)
+	if err != nil || pri > 191 {
+		return nil, fmt.Errorf("expecting a priority value in the range 1-191 or equal to 0")
+	}
+
+	m := &rfc3164Message{
+		SyslogMessage: (&rfc5424.SyslogMessage{}).SetPriority(uint8(pri)),
+	}
+	rest := buf[end+1:]
+
+	ts, rest, ok := parseRFC3164Timestamp(rest, now)
+	if ok {
+		m.timestamp = &ts
+		if host, r := nextToken(rest); len(host) > 0 {
+			hostname := string(host)
+			m.hostname = &hostname
+			rest = r
+		}
+		rest = parseTag(m, rest)
+	}
+
+	if len(rest) > 0 {
+		message := string(rest)
+		m.message = &message
+	}
+	return m, nil
+}
+
+func parseRFC3164Timestamp(buf []byte, now time.Time) (time.Time, []byte, bool) {
+	// Stamp format, the day of month is padded with a space
+	if len(buf) >= len(time.Stamp) {
+		if ts, err := time.Parse(time.Stamp, string(buf[:len(time.Stamp)])); err == nil {
+			now = now.UTC()
+			ts = ts.AddDate(now.Year(), 0, 0)
+			if ts.Sub(now) > 24*time.Hour {
+				ts = ts.AddDate(-1, 0, 0)
+			}
+			return ts, skipSpace(buf[len(time.Stamp):]), true
+		}
+	}
+
+	token, rest := nextToken(buf)
+	if ts, err := time.Parse(time.RFC3339Nano, string(token)); err == nil {
+		return ts, rest, true
+	}
+	return time.Time{}, buf, false
+}
+
+// parseTag parses the TAG field including the optional process id enclosed in
+// square brackets and the colon following it.  The buffer is returned
+// unchanged when it does not start with a tag.
+func parseTag(m *rfc3164Message, buf []byte) []byte {
+	i := 0
+	for i < len(buf) && i < 48 && isTagChar(buf[i]) {
+		i++
+	}
+	if i == 0 || i == len(buf) {
+		return buf
+	}
+	appname := string(buf[:i])
+
+	var procid string
+	rest := buf[i:]
+	if rest[0] == '[' {
+		end := bytes.IndexByte(rest, ']')
+		if end < 0 {
+			return buf
+		}
+		procid = string(rest[1:end])
+		rest = rest[end+1:]
+	}
+	if len(rest) == 0 || rest[0] != ':' {
+		return buf
+	}
+
+	m.appname = &appname
+	if procid != "" {
+		m.procid = &procid
+	}
+	return skipSpace(rest[1:])
+}
+
+func isTagChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	case c == '-', c == '_', c == '.', c == '/':
+		return true
+	}
+	return false
+}
+
+func nextToken(buf []byte) ([]byte, []byte) {
+	end := bytes.IndexByte(buf, ' ')
+	if end < 0 {
+		return buf, nil
+	}
+	return buf[:end], skipSpace(buf[end:])
+}
+
+func skipSpace(buf []byte) []byte {
+	return bytes.TrimLeft(buf, " ")
+}
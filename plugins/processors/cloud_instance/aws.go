@@ -0,0 +1,111 @@
+package cloud_instance
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	internalaws "github.com/influxdata/telegraf/config/aws"
+)
+
+// maxFilterValues is the maximum number of values allowed in a single
+// DescribeInstances filter.
+const maxFilterValues = 200
+
+type ec2Client interface {
+	DescribeInstancesPagesWithContext(ctx aws.Context, input *ec2.DescribeInstancesInput, fn func(*ec2.DescribeInstancesOutput, bool) bool, opts ...request.Option) error
+}
+
+type ec2Lookup struct {
+	client ec2Client
+}
+
+func (c *CloudInstance) newEC2Lookup() (instanceLookup, error) {
+	credentialConfig := &internalaws.CredentialConfig{
+		Region:      c.Region,
+		AccessKey:   c.AccessKey,
+		SecretKey:   c.SecretKey,
+		RoleARN:     c.RoleARN,
+		Profile:     c.Profile,
+		Filename:    c.CredentialPath,
+		Token:       c.Token,
+		EndpointURL: c.EndpointURL,
+	}
+	configProvider := credentialConfig.Credentials()
+	return &ec2Lookup{client: ec2.New(configProvider)}, nil
+}
+
+// Lookup describes the instances using an instance-id filter, unlike the
+// InstanceIds parameter a filter does not fail the request when one of the
+// instances does not exist.
+func (l *ec2Lookup) Lookup(ctx context.Context, ids []string) (map[string]*instanceInfo, error) {
+	instances := make(map[string]*instanceInfo, len(ids))
+	for len(ids) > 0 {
+		n := len(ids)
+		if n > maxFilterValues {
+			n = maxFilterValues
+		}
+
+		input := &ec2.DescribeInstancesInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String("instance-id"),
+					Values: aws.StringSlice(ids[:n]),
+				},
+			},
+		}
+		err := l.client.DescribeInstancesPagesWithContext(ctx, input,
+			func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+				for _, reservation := range page.Reservations {
+					for _, instance := range reservation.Instances {
+						instances[aws.StringValue(instance.InstanceId)] = ec2InstanceInfo(reservation, instance)
+					}
+				}
+				return true
+			})
+		if err != nil {
+			return nil, err
+		}
+		ids = ids[n:]
+	}
+	return instances, nil
+}
+
+func ec2InstanceInfo(reservation *ec2.Reservation, instance *ec2.Instance) *instanceInfo {
+	info := &instanceInfo{
+		attributes: map[string]string{
+			"instance_type": aws.StringValue(instance.InstanceType),
+			"image_id":      aws.StringValue(instance.ImageId),
+			"vpc_id":        aws.StringValue(instance.VpcId),
+			"subnet_id":     aws.StringValue(instance.SubnetId),
+			"lifecycle":     aws.StringValue(instance.InstanceLifecycle),
+			"account_id":    aws.StringValue(reservation.OwnerId),
+		},
+		tags: make(map[string]string, len(instance.Tags)),
+	}
+
+	if info.attributes["lifecycle"] == "" {
+		info.attributes["lifecycle"] = "normal"
+	}
+
+	if instance.Placement != nil {
+		zone := aws.StringValue(instance.Placement.AvailabilityZone)
+		info.attributes["availability_zone"] = zone
+		info.attributes["region"] = strings.TrimRight(zone, "abcdefghijklmnopqrstuvwxyz")
+	}
+
+	if instance.State != nil {
+		info.attributes["state"] = aws.StringValue(instance.State.Name)
+	}
+
+	for _, tag := range instance.Tags {
+		key := aws.StringValue(tag.Key)
+		info.tags[key] = aws.StringValue(tag.Value)
+		if key == "Name" {
+			info.attributes["name"] = aws.StringValue(tag.Value)
+		}
+	}
+	return info
+}
@@ -0,0 +1,259 @@
+package cloud_instance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+)
+
+const (
+	azureResourceManager = "https://management.azure.com/"
+	azureResourceGraph   = "providers/Microsoft.ResourceGraph/resources?api-version=2021-03-01"
+
+	// azureInstanceMetadataURL is the compute metadata of the instance
+	// Telegraf is running on, used to find the default subscription.
+	azureInstanceMetadataURL = "http://169.254.169.254/metadata/instance/compute?api-version=2021-02-01"
+)
+
+// azureVMIDRe matches the vmId of an Azure virtual machine, anything else is
+// skipped so that the query can not be altered.
+var azureVMIDRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+type azureLookup struct {
+	client        *http.Client
+	authorizer    autorest.Authorizer
+	url           string
+	subscriptions []string
+}
+
+// azureInstanceMetadata contains the compute metadata of the current VM.
+type azureInstanceMetadata struct {
+	SubscriptionID string `json:"subscriptionId"`
+}
+
+type azureQueryRequest struct {
+	Subscriptions []string          `json:"subscriptions"`
+	Query         string            `json:"query"`
+	Options       map[string]string `json:"options"`
+}
+
+type azureQueryResponse struct {
+	SkipToken string                 `json:"$skipToken"`
+	Data      []*azureVirtualMachine `json:"data"`
+}
+
+type azureVirtualMachine struct {
+	VMID           string            `json:"vmId"`
+	Name           string            `json:"name"`
+	Location       string            `json:"location"`
+	ResourceGroup  string            `json:"resourceGroup"`
+	SubscriptionID string            `json:"subscriptionId"`
+	Zones          []string          `json:"zones"`
+	Tags           map[string]string `json:"tags"`
+	VMSize         string            `json:"vmSize"`
+	PowerState     string            `json:"powerState"`
+}
+
+func (c *CloudInstance) newAzureLookup() (instanceLookup, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+		},
+		Timeout: c.Timeout.Duration,
+	}
+
+	subscriptions := c.SubscriptionIDs
+	if len(subscriptions) == 0 {
+		metadata, err := azureComputeMetadata(client)
+		if err != nil {
+			return nil, fmt.Errorf("no subscription_ids configured and the instance metadata is unavailable: %v", err)
+		}
+		subscriptions = []string{metadata.SubscriptionID}
+	}
+
+	authorizer, err := auth.NewAuthorizerFromEnvironmentWithResource(azureResourceManager)
+	if err != nil {
+		return nil, err
+	}
+
+	return &azureLookup{
+		client:        client,
+		authorizer:    authorizer,
+		url:           azureResourceManager + azureResourceGraph,
+		subscriptions: subscriptions,
+	}, nil
+}
+
+// azureComputeMetadata retrieves the compute metadata of the current VM from
+// the Instance Metadata Service.
+func azureComputeMetadata(client *http.Client) (*azureInstanceMetadata, error) {
+	req, err := http.NewRequest("GET", azureInstanceMetadataURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received status code %d (%s) from %s",
+			resp.StatusCode, http.StatusText(resp.StatusCode), azureInstanceMetadataURL)
+	}
+
+	var metadata azureInstanceMetadata
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return nil, err
+	}
+	if metadata.SubscriptionID == "" {
+		return nil, fmt.Errorf("no subscription id in instance metadata")
+	}
+	return &metadata, nil
+}
+
+// Lookup queries the virtual machines by vmId using Azure Resource Graph.
+func (l *azureLookup) Lookup(ctx context.Context, ids []string) (map[string]*instanceInfo, error) {
+	instances := make(map[string]*instanceInfo, len(ids))
+	for len(ids) > 0 {
+		n := len(ids)
+		if n > maxFilterTerms {
+			n = maxFilterTerms
+		}
+
+		// The vmId is matched case insensitively, the instances are
+		// returned by the id as requested.
+		requested := make(map[string]string, n)
+		for _, id := range ids[:n] {
+			requested[strings.ToLower(id)] = id
+		}
+
+		query := azureQuery(ids[:n])
+		ids = ids[n:]
+		if query == "" {
+			continue
+		}
+
+		var skipToken string
+		for {
+			resp, err := l.query(ctx, query, skipToken)
+			if err != nil {
+				return nil, err
+			}
+			for _, vm := range resp.Data {
+				if id, ok := requested[strings.ToLower(vm.VMID)]; ok {
+					instances[id] = azureInstanceInfo(vm)
+				}
+			}
+
+			skipToken = resp.SkipToken
+			if skipToken == "" {
+				break
+			}
+		}
+	}
+
+	return instances, nil
+}
+
+func (l *azureLookup) query(ctx context.Context, query, skipToken string) (*azureQueryResponse, error) {
+	options := map[string]string{"resultFormat": "objectArray"}
+	if skipToken != "" {
+		options["$skipToken"] = skipToken
+	}
+
+	body, err := json.Marshal(&azureQueryRequest{
+		Subscriptions: l.subscriptions,
+		Query:         query,
+		Options:       options,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", l.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	req, err = autorest.CreatePreparer(l.authorizer.WithAuthorization()).Prepare(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch authentication credentials: %v", err)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("received status code %d (%s) from resource graph: %s",
+			resp.StatusCode, http.StatusText(resp.StatusCode), msg)
+	}
+
+	var result azureQueryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func azureQuery(ids []string) string {
+	terms := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !azureVMIDRe.MatchString(id) {
+			continue
+		}
+		terms = append(terms, "'"+id+"'")
+	}
+	if len(terms) == 0 {
+		return ""
+	}
+
+	return "Resources" +
+		" | where type =~ 'microsoft.compute/virtualmachines'" +
+		" | where properties.vmId in~ (" + strings.Join(terms, ", ") + ")" +
+		" | project vmId = tostring(properties.vmId), name, location, resourceGroup," +
+		" subscriptionId, zones, tags," +
+		" vmSize = tostring(properties.hardwareProfile.vmSize)," +
+		" powerState = tostring(properties.extended.instanceView.powerState.code)"
+}
+
+func azureInstanceInfo(vm *azureVirtualMachine) *instanceInfo {
+	zone := vm.Location
+	if len(vm.Zones) > 0 {
+		zone = vm.Location + "-" + vm.Zones[0]
+	}
+
+	info := &instanceInfo{
+		attributes: map[string]string{
+			"instance_type":     vm.VMSize,
+			"availability_zone": zone,
+			"region":            vm.Location,
+			"state":             strings.TrimPrefix(vm.PowerState, "PowerState/"),
+			"name":              vm.Name,
+			"resource_group":    vm.ResourceGroup,
+			"subscription_id":   vm.SubscriptionID,
+		},
+		tags: make(map[string]string, len(vm.Tags)),
+	}
+
+	for key, value := range vm.Tags {
+		info.tags[key] = value
+	}
+	return info
+}
@@ -0,0 +1,325 @@
+package cloud_instance
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Cloud provider to query, one of "aws", "azure" or "gcp".
+  provider = "aws"
+
+  ## Tag containing the instance id.
+  # instance_id_tag = "instance_id"
+
+  ## Instance attributes to add as tags.
+  ## Supported by all providers: "instance_type", "availability_zone",
+  ##   "region", "state", "name"
+  ## AWS only: "image_id", "vpc_id", "subnet_id", "lifecycle", "account_id"
+  ## Azure only: "resource_group", "subscription_id"
+  ## GCP only: "project"
+  # attributes = ["instance_type", "availability_zone"]
+
+  ## Instance tags (AWS, Azure) or labels (GCP) to add as metric tags.  Nothing is
+  ## added unless it is listed in instance_tag_include, use "*" for all.
+  # instance_tag_include = []
+  # instance_tag_exclude = []
+
+  ## Amount of time instance attributes are cached for.  Unknown instances
+  ## are cached as well to avoid repeated lookups.
+  # cache_ttl = "1h"
+
+  ## Metrics with instance ids not found in the cache are held back until
+  ## batch_size unknown ids have been seen, or batch_timeout has elapsed,
+  ## and then looked up using a single request.
+  # batch_size = 100
+  # batch_timeout = "1s"
+
+  ## Timeout for lookup requests.
+  # timeout = "10s"
+
+  ## AWS region and credentials, credentials are loaded in the following order
+  ## 1) Assumed credentials via STS if role_arn is specified
+  ## 2) explicit credentials from 'access_key' and 'secret_key'
+  ## 3) shared profile from 'profile'
+  ## 4) environment variables
+  ## 5) shared credentials file
+  ## 6) EC2 Instance Profile
+  # region = "us-east-1"
+  # access_key = ""
+  # secret_key = ""
+  # token = ""
+  # role_arn = ""
+  # profile = ""
+  # shared_credential_file = ""
+  # endpoint_url = ""
+
+  ## GCP project and credentials, if credentials_file is not set the
+  ## Application Default Credentials are used.
+  # project = "my-project"
+  # credentials_file = "path/to/my/creds.json"
+
+  ## Azure subscriptions to query, by default the subscription of the VM
+  ## Telegraf is running on is retrieved from the Instance Metadata Service.
+  ## The credentials are read from the environment, see the README.
+  # subscription_ids = []
+`
+
+// instanceInfo is the description of an instance returned by a provider.
+type instanceInfo struct {
+	attributes map[string]string
+	tags       map[string]string
+}
+
+// instanceLookup retrieves the instances with the given ids.  Ids that are
+// not found are omitted from the result.
+type instanceLookup interface {
+	Lookup(ctx context.Context, ids []string) (map[string]*instanceInfo, error)
+}
+
+type cacheEntry struct {
+	tags    map[string]string
+	expires time.Time
+}
+
+type CloudInstance struct {
+	Provider           string            `toml:"provider"`
+	InstanceIDTag      string            `toml:"instance_id_tag"`
+	Attributes         []string          `toml:"attributes"`
+	InstanceTagInclude []string          `toml:"instance_tag_include"`
+	InstanceTagExclude []string          `toml:"instance_tag_exclude"`
+	CacheTTL           internal.Duration `toml:"cache_ttl"`
+	BatchSize          int               `toml:"batch_size"`
+	BatchTimeout       internal.Duration `toml:"batch_timeout"`
+	Timeout            internal.Duration `toml:"timeout"`
+
+	Region         string `toml:"region"`
+	AccessKey      string `toml:"access_key"`
+	SecretKey      string `toml:"secret_key"`
+	RoleARN        string `toml:"role_arn"`
+	Profile        string `toml:"profile"`
+	CredentialPath string `toml:"shared_credential_file"`
+	Token          string `toml:"token"`
+	EndpointURL    string `toml:"endpoint_url"`
+
+	Project         string `toml:"project"`
+	CredentialsFile string `toml:"credentials_file"`
+
+	SubscriptionIDs []string `toml:"subscription_ids"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	lookup    instanceLookup
+	tagFilter filter.Filter
+	cache     map[string]*cacheEntry
+
+	in         chan telegraf.Metric
+	pending    []telegraf.Metric
+	pendingIDs map[string]bool
+	wg         sync.WaitGroup
+}
+
+func (c *CloudInstance) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *CloudInstance) Description() string {
+	return "Add cloud instance attributes as tags using the instance id"
+}
+
+func (c *CloudInstance) Init() error {
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("batch_size must be greater than zero")
+	}
+
+	if len(c.InstanceTagInclude) > 0 {
+		var err error
+		c.tagFilter, err = filter.NewIncludeExcludeFilter(c.InstanceTagInclude, c.InstanceTagExclude)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.cache = make(map[string]*cacheEntry)
+	c.pendingIDs = make(map[string]bool)
+
+	var err error
+	switch c.Provider {
+	case "aws":
+		c.lookup, err = c.newEC2Lookup()
+	case "azure":
+		c.lookup, err = c.newAzureLookup()
+	case "gcp":
+		c.lookup, err = c.newGCELookup()
+	default:
+		err = fmt.Errorf("unknown provider %q", c.Provider)
+	}
+	return err
+}
+
+func (c *CloudInstance) Start(acc telegraf.Accumulator) error {
+	c.in = make(chan telegraf.Metric, c.BatchSize)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.run(acc)
+	}()
+	return nil
+}
+
+func (c *CloudInstance) Add(metric telegraf.Metric, acc telegraf.Accumulator) {
+	c.in <- metric
+}
+
+func (c *CloudInstance) Stop() error {
+	close(c.in)
+	c.wg.Wait()
+	return nil
+}
+
+func (c *CloudInstance) run(acc telegraf.Accumulator) {
+	var timeout <-chan time.Time
+	for {
+		select {
+		case metric, ok := <-c.in:
+			if !ok {
+				c.flush(acc)
+				return
+			}
+
+			if id, ok := metric.GetTag(c.InstanceIDTag); ok {
+				if _, ok := c.cached(id, time.Now()); !ok {
+					c.pendingIDs[id] = true
+				}
+			}
+
+			// Metrics are held back as long as any lookup is pending so that
+			// their order is preserved.
+			if len(c.pending) == 0 && len(c.pendingIDs) == 0 {
+				c.decorate(metric, time.Now())
+				acc.AddMetric(metric)
+				continue
+			}
+
+			if len(c.pending) == 0 {
+				timeout = time.After(c.BatchTimeout.Duration)
+			}
+			c.pending = append(c.pending, metric)
+
+			if len(c.pendingIDs) >= c.BatchSize {
+				c.flush(acc)
+				timeout = nil
+			}
+		case <-timeout:
+			c.flush(acc)
+			timeout = nil
+		}
+	}
+}
+
+// flush looks up all pending instance ids and emits the held back metrics.
+func (c *CloudInstance) flush(acc telegraf.Accumulator) {
+	if len(c.pendingIDs) > 0 {
+		ids := make([]string, 0, len(c.pendingIDs))
+		for id := range c.pendingIDs {
+			ids = append(ids, id)
+		}
+		c.update(ids, time.Now())
+		c.pendingIDs = make(map[string]bool)
+	}
+
+	now := time.Now()
+	for _, metric := range c.pending {
+		c.decorate(metric, now)
+		acc.AddMetric(metric)
+	}
+	c.pending = nil
+}
+
+func (c *CloudInstance) update(ids []string, now time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout.Duration)
+	defer cancel()
+
+	instances, err := c.lookup.Lookup(ctx, ids)
+	if err != nil {
+		c.Log.Errorf("Error looking up instances: %v", err)
+		return
+	}
+
+	expires := now.Add(c.CacheTTL.Duration)
+	for _, id := range ids {
+		c.cache[id] = &cacheEntry{
+			tags:    c.instanceTags(instances[id]),
+			expires: expires,
+		}
+	}
+}
+
+// instanceTags selects the attributes and instance tags to add to metrics.
+func (c *CloudInstance) instanceTags(info *instanceInfo) map[string]string {
+	tags := make(map[string]string)
+	if info == nil {
+		return tags
+	}
+
+	for _, attr := range c.Attributes {
+		if value, ok := info.attributes[attr]; ok && value != "" {
+			tags[attr] = value
+		}
+	}
+
+	if c.tagFilter != nil {
+		for key, value := range info.tags {
+			if c.tagFilter.Match(key) {
+				tags[key] = value
+			}
+		}
+	}
+	return tags
+}
+
+func (c *CloudInstance) cached(id string, now time.Time) (map[string]string, bool) {
+	entry, ok := c.cache[id]
+	if !ok {
+		return nil, false
+	}
+	if now.After(entry.expires) {
+		delete(c.cache, id)
+		return nil, false
+	}
+	return entry.tags, true
+}
+
+func (c *CloudInstance) decorate(metric telegraf.Metric, now time.Time) {
+	id, ok := metric.GetTag(c.InstanceIDTag)
+	if !ok {
+		return
+	}
+
+	tags, _ := c.cached(id, now)
+	for key, value := range tags {
+		if !metric.HasTag(key) {
+			metric.AddTag(key, value)
+		}
+	}
+}
+
+func init() {
+	processors.AddStreaming("cloud_instance", func() telegraf.StreamingProcessor {
+		return &CloudInstance{
+			InstanceIDTag: "instance_id",
+			Attributes:    []string{"instance_type", "availability_zone"},
+			CacheTTL:      internal.Duration{Duration: time.Hour},
+			BatchSize:     100,
+			BatchTimeout:  internal.Duration{Duration: time.Second},
+			Timeout:       internal.Duration{Duration: 10 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,280 @@
+package cloud_instance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type mockLookup struct {
+	instances map[string]*instanceInfo
+	calls     [][]string
+}
+
+func (m *mockLookup) Lookup(ctx context.Context, ids []string) (map[string]*instanceInfo, error) {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	m.calls = append(m.calls, sorted)
+
+	result := make(map[string]*instanceInfo)
+	for _, id := range ids {
+		if info, ok := m.instances[id]; ok {
+			result[id] = info
+		}
+	}
+	return result, nil
+}
+
+func newTestProcessor(lookup instanceLookup) *CloudInstance {
+	return &CloudInstance{
+		InstanceIDTag:      "instance_id",
+		Attributes:         []string{"instance_type", "availability_zone"},
+		InstanceTagInclude: []string{"team"},
+		CacheTTL:           internal.Duration{Duration: time.Hour},
+		BatchSize:          2,
+		BatchTimeout:       internal.Duration{Duration: time.Hour},
+		Timeout:            internal.Duration{Duration: time.Second},
+		Log:                testutil.Logger{},
+		lookup:             lookup,
+	}
+}
+
+func initTestProcessor(t *testing.T, c *CloudInstance) {
+	lookup := c.lookup
+	c.Provider = "aws"
+	c.Region = "us-east-1"
+	require.NoError(t, c.Init())
+	c.lookup = lookup
+}
+
+func testLookup() *mockLookup {
+	return &mockLookup{
+		instances: map[string]*instanceInfo{
+			"i-1": {
+				attributes: map[string]string{
+					"instance_type":     "m5.large",
+					"availability_zone": "us-east-1a",
+					"vpc_id":            "vpc-1",
+				},
+				tags: map[string]string{"team": "web", "Name": "web-1"},
+			},
+			"i-2": {
+				attributes: map[string]string{
+					"instance_type":     "c5.xlarge",
+					"availability_zone": "us-east-1b",
+				},
+				tags: map[string]string{},
+			},
+		},
+	}
+}
+
+func TestBatchedLookup(t *testing.T) {
+	lookup := testLookup()
+	c := newTestProcessor(lookup)
+	initTestProcessor(t, c)
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, c.Start(acc))
+
+	now := time.Unix(0, 0)
+	c.Add(testutil.MustMetric("cpu", map[string]string{"instance_id": "i-1"}, map[string]interface{}{"value": 1}, now), acc)
+	c.Add(testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 2}, now), acc)
+	c.Add(testutil.MustMetric("cpu", map[string]string{"instance_id": "i-1"}, map[string]interface{}{"value": 3}, now), acc)
+	c.Add(testutil.MustMetric("cpu", map[string]string{"instance_id": "i-2"}, map[string]interface{}{"value": 4}, now), acc)
+	acc.Wait(4)
+
+	// Cached instances are not looked up again, unknown instances are
+	// cached as well.
+	c.Add(testutil.MustMetric("cpu", map[string]string{"instance_id": "i-2"}, map[string]interface{}{"value": 5}, now), acc)
+	require.NoError(t, c.Stop())
+
+	require.Equal(t, [][]string{{"i-1", "i-2"}}, lookup.calls)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"instance_id": "i-1", "instance_type": "m5.large", "availability_zone": "us-east-1a", "team": "web"},
+			map[string]interface{}{"value": 1}, now),
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"value": 2}, now),
+		testutil.MustMetric("cpu",
+			map[string]string{"instance_id": "i-1", "instance_type": "m5.large", "availability_zone": "us-east-1a", "team": "web"},
+			map[string]interface{}{"value": 3}, now),
+		testutil.MustMetric("cpu",
+			map[string]string{"instance_id": "i-2", "instance_type": "c5.xlarge", "availability_zone": "us-east-1b"},
+			map[string]interface{}{"value": 4}, now),
+		testutil.MustMetric("cpu",
+			map[string]string{"instance_id": "i-2", "instance_type": "c5.xlarge", "availability_zone": "us-east-1b"},
+			map[string]interface{}{"value": 5}, now),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestUnknownInstance(t *testing.T) {
+	lookup := testLookup()
+	c := newTestProcessor(lookup)
+	c.BatchTimeout = internal.Duration{Duration: time.Millisecond}
+	initTestProcessor(t, c)
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, c.Start(acc))
+
+	now := time.Unix(0, 0)
+	c.Add(testutil.MustMetric("cpu", map[string]string{"instance_id": "i-3"}, map[string]interface{}{"value": 1}, now), acc)
+	acc.Wait(1)
+	c.Add(testutil.MustMetric("cpu", map[string]string{"instance_id": "i-3"}, map[string]interface{}{"value": 2}, now), acc)
+	require.NoError(t, c.Stop())
+
+	require.Equal(t, [][]string{{"i-3"}}, lookup.calls)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"instance_id": "i-3"}, map[string]interface{}{"value": 1}, now),
+		testutil.MustMetric("cpu", map[string]string{"instance_id": "i-3"}, map[string]interface{}{"value": 2}, now),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestCacheExpiry(t *testing.T) {
+	c := newTestProcessor(testLookup())
+	initTestProcessor(t, c)
+
+	now := time.Now()
+	c.update([]string{"i-1"}, now)
+
+	tags, ok := c.cached("i-1", now.Add(time.Minute))
+	require.True(t, ok)
+	require.Equal(t, "m5.large", tags["instance_type"])
+
+	_, ok = c.cached("i-1", now.Add(2*time.Hour))
+	require.False(t, ok)
+}
+
+type mockEC2 struct {
+	inputs []*ec2.DescribeInstancesInput
+}
+
+func (m *mockEC2) DescribeInstancesPagesWithContext(ctx aws.Context, input *ec2.DescribeInstancesInput, fn func(*ec2.DescribeInstancesOutput, bool) bool, opts ...request.Option) error {
+	m.inputs = append(m.inputs, input)
+	fn(&ec2.DescribeInstancesOutput{
+		Reservations: []*ec2.Reservation{
+			{
+				OwnerId: aws.String("123456789012"),
+				Instances: []*ec2.Instance{
+					{
+						InstanceId:   aws.String("i-1"),
+						InstanceType: aws.String("m5.large"),
+						Placement:    &ec2.Placement{AvailabilityZone: aws.String("us-east-1a")},
+						State:        &ec2.InstanceState{Name: aws.String("running")},
+						Tags: []*ec2.Tag{
+							{Key: aws.String("Name"), Value: aws.String("web-1")},
+						},
+					},
+				},
+			},
+		},
+	}, true)
+	return nil
+}
+
+func TestEC2Lookup(t *testing.T) {
+	client := &mockEC2{}
+	l := &ec2Lookup{client: client}
+
+	ids := make([]string, 250)
+	for i := range ids {
+		ids[i] = "i-1"
+	}
+	instances, err := l.Lookup(context.Background(), ids)
+	require.NoError(t, err)
+
+	require.Len(t, client.inputs, 2)
+	require.Len(t, client.inputs[0].Filters[0].Values, 200)
+	require.Len(t, client.inputs[1].Filters[0].Values, 50)
+
+	require.Equal(t, map[string]string{
+		"instance_type":     "m5.large",
+		"availability_zone": "us-east-1a",
+		"region":            "us-east-1",
+		"state":             "running",
+		"name":              "web-1",
+		"lifecycle":         "normal",
+		"account_id":        "123456789012",
+		"image_id":          "",
+		"vpc_id":            "",
+		"subnet_id":         "",
+	}, instances["i-1"].attributes)
+	require.Equal(t, map[string]string{"Name": "web-1"}, instances["i-1"].tags)
+}
+
+func TestGCEFilter(t *testing.T) {
+	require.Equal(t, "(id = 1) OR (id = 2)", gceFilter([]string{"1", "2"}))
+	require.Equal(t, "", gceFilter([]string{"i-1 OR true"}))
+}
+
+func TestAzureLookup(t *testing.T) {
+	var requests []*azureQueryRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req azureQueryRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, &req)
+
+		if req.Options["$skipToken"] == "" {
+			fmt.Fprint(w, `{"$skipToken": "page2", "data": [{
+				"vmId": "0A6B6C22-4B4E-4A0C-9C0A-1B2C3D4E5F60",
+				"name": "web-1",
+				"location": "westeurope",
+				"resourceGroup": "web",
+				"subscriptionId": "sub-1",
+				"zones": ["1"],
+				"tags": {"team": "web"},
+				"vmSize": "Standard_D2s_v3",
+				"powerState": "PowerState/running"
+			}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"data": []}`)
+	}))
+	defer ts.Close()
+
+	l := &azureLookup{
+		client:        ts.Client(),
+		authorizer:    autorest.NullAuthorizer{},
+		url:           ts.URL,
+		subscriptions: []string{"sub-1"},
+	}
+
+	id := "0a6b6c22-4b4e-4a0c-9c0a-1b2c3d4e5f60"
+	instances, err := l.Lookup(context.Background(), []string{id, "web-1' or true"})
+	require.NoError(t, err)
+
+	require.Len(t, requests, 2)
+	require.Equal(t, []string{"sub-1"}, requests[0].Subscriptions)
+	require.Contains(t, requests[0].Query, "in~ ('"+id+"')")
+	require.Equal(t, "page2", requests[1].Options["$skipToken"])
+
+	require.Equal(t, map[string]string{
+		"instance_type":     "Standard_D2s_v3",
+		"availability_zone": "westeurope-1",
+		"region":            "westeurope",
+		"state":             "running",
+		"name":              "web-1",
+		"resource_group":    "web",
+		"subscription_id":   "sub-1",
+	}, instances[id].attributes)
+	require.Equal(t, map[string]string{"team": "web"}, instances[id].tags)
+}
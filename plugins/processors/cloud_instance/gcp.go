@@ -0,0 +1,112 @@
+package cloud_instance
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf/internal"
+	compute "google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+)
+
+// maxFilterTerms limits the number of ids in a single list filter to keep
+// the request URL short.
+const maxFilterTerms = 50
+
+type gceLookup struct {
+	project string
+	service *compute.Service
+}
+
+func (c *CloudInstance) newGCELookup() (instanceLookup, error) {
+	if c.Project == "" {
+		return nil, fmt.Errorf("project is required for the gcp provider")
+	}
+
+	opts := []option.ClientOption{
+		option.WithScopes(compute.ComputeReadonlyScope),
+		option.WithUserAgent(internal.ProductToken()),
+	}
+	if c.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(c.CredentialsFile))
+	}
+
+	service, err := compute.NewService(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &gceLookup{project: c.Project, service: service}, nil
+}
+
+func (l *gceLookup) Lookup(ctx context.Context, ids []string) (map[string]*instanceInfo, error) {
+	instances := make(map[string]*instanceInfo, len(ids))
+	for len(ids) > 0 {
+		n := len(ids)
+		if n > maxFilterTerms {
+			n = maxFilterTerms
+		}
+
+		filter := gceFilter(ids[:n])
+		ids = ids[n:]
+		if filter == "" {
+			continue
+		}
+
+		err := l.service.Instances.AggregatedList(l.project).
+			Filter(filter).
+			Pages(ctx, func(page *compute.InstanceAggregatedList) error {
+				for _, scope := range page.Items {
+					for _, instance := range scope.Instances {
+						id := strconv.FormatUint(instance.Id, 10)
+						instances[id] = gceInstanceInfo(l.project, instance)
+					}
+				}
+				return nil
+			})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return instances, nil
+}
+
+func gceFilter(ids []string) string {
+	terms := make([]string, 0, len(ids))
+	for _, id := range ids {
+		// Instance ids are numeric, skip anything else so that the filter
+		// expression can not be altered.
+		if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+			continue
+		}
+		terms = append(terms, "(id = "+id+")")
+	}
+	return strings.Join(terms, " OR ")
+}
+
+func gceInstanceInfo(project string, instance *compute.Instance) *instanceInfo {
+	zone := path.Base(instance.Zone)
+	region := zone
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		region = zone[:i]
+	}
+
+	info := &instanceInfo{
+		attributes: map[string]string{
+			"instance_type":     path.Base(instance.MachineType),
+			"availability_zone": zone,
+			"region":            region,
+			"state":             instance.Status,
+			"name":              instance.Name,
+			"project":           project,
+		},
+		tags: make(map[string]string, len(instance.Labels)),
+	}
+
+	for key, value := range instance.Labels {
+		info.tags[key] = value
+	}
+	return info
+}
@@ -1,9 +1,12 @@
 package defaults
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/conditional"
 	"github.com/influxdata/telegraf/plugins/processors"
-	"strings"
 )
 
 const sampleConfig = `
@@ -20,12 +23,36 @@ const sampleConfig = `
   #   field_1 = "bar"
   #   time_idle = 0
   #   is_error = true
+
+  ## Rules set defaults only on metrics matching all of their conditions.
+  ## They are applied in order after the fields above.  String values may
+  ## be Go templates using the metric, such as '{{ .Tag "host" }}'.
+  # [[processors.defaults.rule]]
+  #   ## Apply only if the tags have a value matching one of the glob
+  #   ## patterns.
+  #   when_tag = { env = ["staging", "dev*"] }
+  #   ## Apply only if none of these fields are set.
+  #   # when_field_missing = []
+  #
+  #   [processors.defaults.rule.fields]
+  #     owner = '{{ .Tag "team" }}@example.com'
 `
 
 // Defaults is a processor for ensuring certain fields always exist
 // on your Metrics with at least a default value.
 type Defaults struct {
 	DefaultFieldsSets map[string]interface{} `toml:"fields"`
+	Rules             []rule                 `toml:"rule"`
+	Log               telegraf.Logger        `toml:"-"`
+}
+
+type rule struct {
+	WhenTag          map[string][]string    `toml:"when_tag"`
+	WhenFieldMissing []string               `toml:"when_field_missing"`
+	Fields           map[string]interface{} `toml:"fields"`
+
+	condition *conditional.Condition
+	fields    map[string]*conditional.Value
 }
 
 // SampleConfig represents a sample toml config for this plugin.
@@ -38,25 +65,78 @@ func (def *Defaults) Description() string {
 	return "Defaults sets default value(s) for specified fields that are not set on incoming metrics."
 }
 
+// Init compiles the conditions and templates of the rules.
+func (def *Defaults) Init() error {
+	for i := range def.Rules {
+		r := &def.Rules[i]
+
+		var err error
+		r.condition, err = conditional.NewCondition(r.WhenTag, r.WhenFieldMissing)
+		if err != nil {
+			return fmt.Errorf("rule %d: %v", i+1, err)
+		}
+
+		r.fields = make(map[string]*conditional.Value, len(r.Fields))
+		for key, value := range r.Fields {
+			if r.fields[key], err = conditional.NewValue(value); err != nil {
+				return fmt.Errorf("rule %d: invalid value for field %q: %v", i+1, key, err)
+			}
+		}
+	}
+	return nil
+}
+
 // Apply contains the main implementation of this processor.
 // For each metric in 'inputMetrics', it goes over each default pair.
 // If the field in the pair does not exist on the metric, the associated default is added.
 // If the field was found, then, if its value is the empty string or one or more spaces, it is replaced
 // by the associated default.
+// Afterwards the same is done for the fields of each rule whose conditions match.
 func (def *Defaults) Apply(inputMetrics ...telegraf.Metric) []telegraf.Metric {
 	for _, metric := range inputMetrics {
 		for defField, defValue := range def.DefaultFieldsSets {
-			if maybeCurrent, isSet := metric.GetField(defField); !isSet {
-				metric.AddField(defField, defValue)
-			} else if trimmed, isStr := maybeTrimmedString(maybeCurrent); isStr && trimmed == "" {
-				metric.RemoveField(defField)
-				metric.AddField(defField, defValue)
+			setDefault(metric, defField, defValue)
+		}
+
+		for i := range def.Rules {
+			r := &def.Rules[i]
+			if !r.condition.Match(metric) {
+				continue
+			}
+			for defField, value := range r.fields {
+				if !isUnset(metric, defField) {
+					continue
+				}
+				defValue, err := value.Eval(metric)
+				if err != nil {
+					def.Log.Errorf("Failed to execute template for field %q: %v", defField, err)
+					continue
+				}
+				setDefault(metric, defField, defValue)
 			}
 		}
 	}
 	return inputMetrics
 }
 
+func setDefault(metric telegraf.Metric, defField string, defValue interface{}) {
+	if !metric.HasField(defField) {
+		metric.AddField(defField, defValue)
+	} else if isUnset(metric, defField) {
+		metric.RemoveField(defField)
+		metric.AddField(defField, defValue)
+	}
+}
+
+func isUnset(metric telegraf.Metric, field string) bool {
+	maybeCurrent, isSet := metric.GetField(field)
+	if !isSet {
+		return true
+	}
+	trimmed, isStr := maybeTrimmedString(maybeCurrent)
+	return isStr && trimmed == ""
+}
+
 func maybeTrimmedString(v interface{}) (string, bool) {
 	switch value := v.(type) {
 	case string:
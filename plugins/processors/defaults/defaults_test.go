@@ -7,6 +7,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDefaults(t *testing.T) {
@@ -129,3 +130,65 @@ func TestDefaults(t *testing.T) {
 		})
 	}
 }
+
+func TestDefaultsRules(t *testing.T) {
+	defaults := &Defaults{
+		DefaultFieldsSets: map[string]interface{}{
+			"status": "N/A",
+		},
+		Rules: []rule{
+			{
+				WhenTag: map[string][]string{"env": {"staging", "dev*"}},
+				Fields: map[string]interface{}{
+					"owner":   `{{ .Tag "team" }}@example.com`,
+					"retries": 3,
+				},
+			},
+			{
+				WhenFieldMissing: []string{"latency"},
+				Fields: map[string]interface{}{
+					"latency": 0,
+				},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, defaults.Init())
+
+	input := []telegraf.Metric{
+		testutil.MustMetric("lb",
+			map[string]string{"env": "development", "team": "web"},
+			map[string]interface{}{"owner": " ", "latency": 230},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("lb",
+			map[string]string{"env": "production", "team": "web"},
+			map[string]interface{}{"retries": 1},
+			time.Unix(0, 0),
+		),
+	}
+	expected := []telegraf.Metric{
+		testutil.MustMetric("lb",
+			map[string]string{"env": "development", "team": "web"},
+			map[string]interface{}{
+				"owner":   "web@example.com",
+				"retries": 3,
+				"latency": 230,
+				"status":  "N/A",
+			},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("lb",
+			map[string]string{"env": "production", "team": "web"},
+			map[string]interface{}{
+				"retries": 1,
+				"latency": 0,
+				"status":  "N/A",
+			},
+			time.Unix(0, 0),
+		),
+	}
+
+	actual := defaults.Apply(input...)
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.SortMetrics())
+}
@@ -0,0 +1,154 @@
+package device_inventory
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const page1 = `
+{
+  "count": 2,
+  "next": "%s/api/dcim/devices/?limit=1&offset=1",
+  "results": [
+    {
+      "name": "core-sw1",
+      "primary_ip": {"address": "10.0.0.1/24"},
+      "site": {"slug": "ams1"},
+      "rack": {"name": "R101"},
+      "device_role": {"slug": "core-switch"},
+      "tenant": null
+    }
+  ]
+}
+`
+
+const page2 = `
+{
+  "count": 2,
+  "next": null,
+  "results": [
+    {
+      "name": "edge-rtr1",
+      "primary_ip": null,
+      "site": {"slug": "fra1"},
+      "rack": null,
+      "device_role": {"slug": "edge-router"},
+      "tenant": {"slug": "acme"}
+    }
+  ]
+}
+`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Token abc", r.Header.Get("Authorization"))
+		switch r.URL.Query().Get("offset") {
+		case "":
+			fmt.Fprintf(w, page1, ts.URL)
+		case "1":
+			fmt.Fprint(w, page2)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return ts
+}
+
+func newTestProcessor(url string) *DeviceInventory {
+	return &DeviceInventory{
+		URL:             url,
+		RefreshInterval: internal.Duration{Duration: time.Hour},
+		HostTag:         "agent_host",
+		Headers:         map[string]string{"Authorization": "Token abc"},
+		Timeout:         internal.Duration{Duration: 5 * time.Second},
+		ResultsPath:     "results",
+		NextPath:        "next",
+		KeyPaths:        []string{"name", "primary_ip.address"},
+		Tags: map[string]string{
+			"site":   "site.slug",
+			"rack":   "rack.name",
+			"role":   "device_role.slug",
+			"tenant": "tenant.slug",
+		},
+		Log: testutil.Logger{},
+	}
+}
+
+func TestDecorate(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	d := newTestProcessor(ts.URL + "/api/dcim/devices/?limit=1")
+	require.NoError(t, d.Init())
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, d.Start(acc))
+
+	now := time.Unix(0, 0)
+	d.Add(testutil.MustMetric("snmp", map[string]string{"agent_host": "10.0.0.1"}, map[string]interface{}{"uptime": 1}, now), acc)
+	d.Add(testutil.MustMetric("snmp", map[string]string{"agent_host": "edge-rtr1", "site": "override"}, map[string]interface{}{"uptime": 2}, now), acc)
+	d.Add(testutil.MustMetric("snmp", map[string]string{"agent_host": "10.0.0.2"}, map[string]interface{}{"uptime": 3}, now), acc)
+	require.NoError(t, d.Stop())
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("snmp",
+			map[string]string{"agent_host": "10.0.0.1", "site": "ams1", "rack": "R101", "role": "core-switch"},
+			map[string]interface{}{"uptime": 1}, now),
+		testutil.MustMetric("snmp",
+			map[string]string{"agent_host": "edge-rtr1", "site": "override", "role": "edge-router", "tenant": "acme"},
+			map[string]interface{}{"uptime": 2}, now),
+		testutil.MustMetric("snmp",
+			map[string]string{"agent_host": "10.0.0.2"},
+			map[string]interface{}{"uptime": 3}, now),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestRefreshErrorKeepsInventory(t *testing.T) {
+	ts := newTestServer(t)
+	defer ts.Close()
+
+	d := newTestProcessor(ts.URL + "/api/dcim/devices/?limit=1")
+	require.NoError(t, d.Init())
+	require.NoError(t, d.refresh(context.Background()))
+	require.Len(t, d.devices, 3)
+
+	d.URL = ts.URL + "/api/dcim/devices/?offset=2"
+	require.Error(t, d.refresh(context.Background()))
+	require.Len(t, d.devices, 3)
+}
+
+func TestArrayResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"hostname": "sw1", "location": "lab"}]`)
+	}))
+	defer ts.Close()
+
+	d := newTestProcessor(ts.URL)
+	d.ResultsPath = ""
+	d.NextPath = ""
+	d.KeyPaths = []string{"hostname"}
+	d.Tags = map[string]string{"location": "location"}
+	require.NoError(t, d.Init())
+	require.NoError(t, d.refresh(context.Background()))
+
+	require.Equal(t, map[string]map[string]string{
+		"sw1": {"location": "lab"},
+	}, d.devices)
+}
+
+func TestInitInvalidRefreshInterval(t *testing.T) {
+	d := newTestProcessor("http://localhost")
+	d.RefreshInterval = internal.Duration{}
+	require.Error(t, d.Init())
+}
@@ -0,0 +1,345 @@
+package execd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+// frameEnd is written by the shim after the metrics returned for each metric
+// it processed.
+const frameEnd = "# end"
+
+// stopTimeout is the time given to the process to exit once its stdin is
+// closed, before it is killed.
+const stopTimeout = 5 * time.Second
+
+const sampleConfig = `
+  ## Program to run as daemon.  The metrics are written to its STDIN in
+  ## influx line protocol, and the metrics it writes to STDOUT in line
+  ## protocol replace them.
+  command = ["telegraf-processor", "-config", "/etc/telegraf/processor.conf"]
+
+  ## Delay before the process is restarted after an unexpected termination.
+  ## The delay doubles on each consecutive restart up to restart_delay_max,
+  ## it is reset once the process ran for at least restart_delay_max.
+  # restart_delay = "10s"
+  # restart_delay_max = "5m"
+
+  ## Maximum number of consecutive restarts before giving up, 0 is unlimited.
+  # max_restarts = 0
+
+  ## Ping the process on STDIN every health_check_interval and restart it
+  ## when it does not answer within health_check_timeout.  The process must
+  ## answer a "# ping" line with a "# pong" line on STDOUT, after the metrics
+  ## written before the ping.  Disabled if 0.
+  # health_check_interval = "0s"
+  # health_check_timeout = "5s"
+
+  ## The process ends the metrics returned for each metric with a "# end"
+  ## line, as done by the execd shim.  Limits the number of metrics written to
+  ## the process that are not answered yet to max_in_flight.
+  # framed = false
+  # max_in_flight = 1000
+`
+
+type Execd struct {
+	Command             []string        `toml:"command"`
+	RestartDelay        config.Duration `toml:"restart_delay"`
+	RestartDelayMax     config.Duration `toml:"restart_delay_max"`
+	MaxRestarts         int             `toml:"max_restarts"`
+	HealthCheckInterval config.Duration `toml:"health_check_interval"`
+	HealthCheckTimeout  config.Duration `toml:"health_check_timeout"`
+	Framed              bool            `toml:"framed"`
+	MaxInFlight         int             `toml:"max_in_flight"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	acc        telegraf.Accumulator
+	serializer *influx.Serializer
+	parser     parsers.Parser
+
+	mu       sync.Mutex
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	stdout   io.ReadCloser
+	stderr   io.ReadCloser
+	inFlight chan struct{}
+	health   process.HealthCheck
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (e *Execd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Execd) Description() string {
+	return "Run executable as long-running processor plugin"
+}
+
+func (e *Execd) Init() error {
+	if len(e.Command) == 0 {
+		return errors.New("no command specified")
+	}
+	if e.Framed && e.MaxInFlight < 1 {
+		return errors.New("max_in_flight must be positive")
+	}
+
+	var err error
+	e.parser, err = parsers.NewInfluxParser()
+	if err != nil {
+		return err
+	}
+	e.serializer = influx.NewSerializer()
+	return nil
+}
+
+func (e *Execd) Start(acc telegraf.Accumulator) error {
+	e.acc = acc
+	if e.Framed {
+		e.inFlight = make(chan struct{}, e.MaxInFlight)
+	}
+
+	if err := e.cmdStart(); err != nil {
+		return err
+	}
+
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+	e.done = make(chan struct{})
+	go func() {
+		if err := e.cmdLoop(); err != nil {
+			e.Log.Error(err)
+		}
+		close(e.done)
+	}()
+	return nil
+}
+
+func (e *Execd) Add(m telegraf.Metric, acc telegraf.Accumulator) {
+	b, err := e.serializer.Serialize(m)
+	if err != nil {
+		e.Log.Errorf("Could not serialize metric: %v", err)
+		m.Drop()
+		return
+	}
+
+	if e.Framed {
+		select {
+		case e.inFlight <- struct{}{}:
+		case <-e.ctx.Done():
+			m.Drop()
+			return
+		}
+	}
+
+	e.mu.Lock()
+	_, err = e.stdin.Write(b)
+	e.mu.Unlock()
+	if err != nil {
+		e.Log.Errorf("Error writing to process %s: %v", e.Command, err)
+		e.release()
+		m.Drop()
+		return
+	}
+
+	// The metric is replaced by the metrics returned by the process.
+	m.Accept()
+}
+
+func (e *Execd) Stop() error {
+	e.cancel()
+
+	e.mu.Lock()
+	e.stdin.Close()
+	cmd := e.cmd
+	e.mu.Unlock()
+
+	select {
+	case <-e.done:
+	case <-time.After(stopTimeout):
+		e.Log.Errorf("Process %s did not exit within %s, killing it", e.Command, stopTimeout)
+		cmd.Process.Kill()
+		<-e.done
+	}
+	return nil
+}
+
+// cmdLoop waits for the running process, restarting it until the processor
+// is stopped.
+func (e *Execd) cmdLoop() error {
+	s := &process.Supervisor{
+		Command:             e.Command,
+		RestartDelay:        time.Duration(e.RestartDelay),
+		RestartDelayMax:     time.Duration(e.RestartDelayMax),
+		MaxRestarts:         e.MaxRestarts,
+		HealthCheckInterval: time.Duration(e.HealthCheckInterval),
+		HealthCheckTimeout:  time.Duration(e.HealthCheckTimeout),
+		Log:                 e.Log,
+		Start:               e.cmdStart,
+		Wait:                e.cmdWait,
+		Terminated: func(error) {
+			// The metrics written to the process are not answered
+			// anymore.
+			e.releaseAll()
+		},
+		Write:  e.writeStdin,
+		Kill:   e.kill,
+		Health: &e.health,
+	}
+	return s.Run(e.ctx)
+}
+
+// writeStdin writes to the stdin of the running process, without
+// interleaving with a metric.
+func (e *Execd) writeStdin(b []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err := e.stdin.Write(b)
+	return err
+}
+
+// kill kills the running process.
+func (e *Execd) kill() {
+	e.mu.Lock()
+	cmd := e.cmd
+	e.mu.Unlock()
+	cmd.Process.Kill()
+}
+
+func (e *Execd) cmdStart() error {
+	var cmd *exec.Cmd
+	if len(e.Command) > 1 {
+		cmd = exec.Command(e.Command[0], e.Command[1:]...)
+	} else {
+		cmd = exec.Command(e.Command[0])
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("error opening stdin pipe: %v", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("error opening stdout pipe: %v", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("error opening stderr pipe: %v", err)
+	}
+
+	e.Log.Infof("Starting process: %s", e.Command)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("error starting process: %v", err)
+	}
+
+	e.mu.Lock()
+	e.cmd, e.stdin, e.stdout, e.stderr = cmd, stdin, stdout, stderr
+	e.mu.Unlock()
+	return nil
+}
+
+func (e *Execd) cmdWait() error {
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		e.cmdReadOut(e.stdout)
+		wg.Done()
+	}()
+
+	go func() {
+		e.cmdReadErr(e.stderr)
+		wg.Done()
+	}()
+
+	wg.Wait()
+	return e.cmd.Wait()
+}
+
+func (e *Execd) cmdReadOut(out io.Reader) {
+	if e.HealthCheckInterval > 0 {
+		out = process.NewPongReader(out, e.health.Pong)
+	}
+
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == frameEnd {
+			e.release()
+			continue
+		}
+		// Other comments, such as health check answers, are ignored.
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		metrics, err := e.parser.Parse(scanner.Bytes())
+		if err != nil {
+			e.Log.Errorf("Parse error: %v", err)
+		}
+		for _, metric := range metrics {
+			e.acc.AddMetric(metric)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stdout: %v", err)
+	}
+}
+
+func (e *Execd) cmdReadErr(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		e.Log.Errorf("stderr: %q", scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stderr: %v", err)
+	}
+}
+
+// release frees the slot of a metric answered by the process.
+func (e *Execd) release() {
+	select {
+	case <-e.inFlight:
+	default:
+	}
+}
+
+// releaseAll frees the slots of all the metrics written to the process.
+func (e *Execd) releaseAll() {
+	for {
+		select {
+		case <-e.inFlight:
+		default:
+			return
+		}
+	}
+}
+
+func init() {
+	processors.AddStreaming("execd", func() telegraf.StreamingProcessor {
+		return &Execd{
+			RestartDelay:       config.Duration(10 * time.Second),
+			RestartDelayMax:    config.Duration(5 * time.Minute),
+			HealthCheckTimeout: config.Duration(5 * time.Second),
+			MaxInFlight:        1000,
+		}
+	})
+}
@@ -0,0 +1,113 @@
+// +build !windows
+
+package execd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalProcessorWorks(t *testing.T) {
+	e := &Execd{
+		Command:      []string{"sed", "-u", "s/ value=/,processed=yes value=/"},
+		RestartDelay: config.Duration(5 * time.Second),
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, e.Init())
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, e.Start(acc))
+
+	now := time.Unix(1600000000, 0)
+	for i := 0; i < 3; i++ {
+		e.Add(newMetric(int64(i), now), acc)
+	}
+
+	acc.Wait(3)
+	require.NoError(t, e.Stop())
+
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, 3)
+	for i, m := range metrics {
+		require.Equal(t, map[string]string{"processed": "yes"}, m.Tags())
+		require.Equal(t, map[string]interface{}{"value": int64(i)}, m.Fields())
+		require.Equal(t, now, m.Time())
+	}
+}
+
+func TestFramedProcessor(t *testing.T) {
+	// Returns each metric twice, ending the frame
+	script := `while read line; do echo "$line"; echo "$line"; echo "# end"; done`
+	e := &Execd{
+		Command:      []string{"sh", "-c", script},
+		RestartDelay: config.Duration(5 * time.Second),
+		Framed:       true,
+		MaxInFlight:  1,
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, e.Init())
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, e.Start(acc))
+
+	// Each metric waits for the answer of the previous one
+	added := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			e.Add(newMetric(int64(i), time.Unix(0, 0)), acc)
+		}
+		close(added)
+	}()
+
+	select {
+	case <-added:
+	case <-time.After(10 * time.Second):
+		t.Fatal("metrics not answered")
+	}
+
+	acc.Wait(6)
+	require.NoError(t, e.Stop())
+	require.Len(t, acc.GetTelegrafMetrics(), 6)
+}
+
+func TestRestartsProcess(t *testing.T) {
+	// Exits after the first metric
+	e := &Execd{
+		Command:      []string{"sh", "-c", `read line; echo "$line"`},
+		RestartDelay: config.Duration(10 * time.Millisecond),
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, e.Init())
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, e.Start(acc))
+
+	e.mu.Lock()
+	first := e.cmd
+	e.mu.Unlock()
+
+	e.Add(newMetric(1, time.Unix(0, 0)), acc)
+	acc.Wait(1)
+
+	// Wait for the process to be restarted
+	require.Eventually(t, func() bool {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		return e.cmd != first
+	}, 10*time.Second, 10*time.Millisecond)
+
+	e.Add(newMetric(2, time.Unix(0, 0)), acc)
+	acc.Wait(2)
+	require.NoError(t, e.Stop())
+}
+
+func newMetric(value int64, tm time.Time) telegraf.Metric {
+	m, _ := metric.New("test", map[string]string{}, map[string]interface{}{"value": value}, tm)
+	return m
+}
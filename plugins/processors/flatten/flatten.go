@@ -0,0 +1,312 @@
+package flatten
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Operation to apply to the fields:
+  ##   flatten: the fields holding JSON objects or arrays are replaced by one
+  ##            field per value, named after the path of the value.
+  ##   nest:    the fields named after a path are replaced by one field
+  ##            holding the JSON object of the values, the inverse operation.
+  # mode = "flatten"
+
+  ## Fields to operate on, globs are supported.  When empty, all the fields
+  ## are considered.  In nest mode, the fields are matched by their full name.
+  # fields = []
+
+  ## Separator between the keys of the path in the field names.
+  # separator = "."
+
+  ## Maximum number of levels to flatten or nest, 0 for no limit.  Deeper
+  ## values are kept as JSON when flattening, and their path is kept in the
+  ## key when nesting.
+  # max_depth = 0
+
+  ## Maximum number of fields a field is flattened to, 0 for no limit.  A
+  ## field flattening to more fields is kept as is.
+  # max_fields = 0
+
+  ## Keep the original fields next to the flattened or nested fields.
+  # keep_original = false
+`
+
+const (
+	modeFlatten = "flatten"
+	modeNest    = "nest"
+)
+
+type Flatten struct {
+	Mode         string   `toml:"mode"`
+	Fields       []string `toml:"fields"`
+	Separator    string   `toml:"separator"`
+	MaxDepth     int      `toml:"max_depth"`
+	MaxFields    int      `toml:"max_fields"`
+	KeepOriginal bool     `toml:"keep_original"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	fieldFilter filter.Filter
+}
+
+func (f *Flatten) SampleConfig() string {
+	return sampleConfig
+}
+
+func (f *Flatten) Description() string {
+	return "Flatten fields holding JSON objects into a field per value, or nest them back"
+}
+
+func (f *Flatten) Init() error {
+	switch f.Mode {
+	case "":
+		f.Mode = modeFlatten
+	case modeFlatten, modeNest:
+	default:
+		return fmt.Errorf("invalid mode %q", f.Mode)
+	}
+	if f.Separator == "" {
+		return fmt.Errorf("separator must not be empty")
+	}
+
+	var err error
+	f.fieldFilter, err = filter.Compile(f.Fields)
+	return err
+}
+
+func (f *Flatten) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		if f.Mode == modeNest {
+			f.nest(m)
+		} else {
+			f.flatten(m)
+		}
+	}
+	return in
+}
+
+func (f *Flatten) match(key string) bool {
+	return f.fieldFilter == nil || f.fieldFilter.Match(key)
+}
+
+// flatten replaces the structured fields of the metric by their values.
+func (f *Flatten) flatten(m telegraf.Metric) {
+	// The field list is modified while flattening
+	fields := make([]*telegraf.Field, len(m.FieldList()))
+	copy(fields, m.FieldList())
+
+	for _, field := range fields {
+		if !f.match(field.Key) {
+			continue
+		}
+
+		value, ok := structured(field.Value)
+		if !ok {
+			continue
+		}
+
+		flat := make(map[string]interface{})
+		if err := f.flattenValue(field.Key, value, 1, flat); err != nil {
+			f.Log.Debugf("Not flattening field %q of %q: %v", field.Key, m.Name(), err)
+			continue
+		}
+
+		if !f.KeepOriginal {
+			m.RemoveField(field.Key)
+		}
+		for key, v := range flat {
+			m.AddField(key, v)
+		}
+	}
+}
+
+// structured returns the object or array held by the field value.
+func structured(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}, []interface{}:
+		return v, true
+	case string:
+		s := strings.TrimSpace(v)
+		if !strings.HasPrefix(s, "{") && !strings.HasPrefix(s, "[") {
+			return nil, false
+		}
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(s), &decoded); err != nil {
+			return nil, false
+		}
+		return decoded, true
+	default:
+		return nil, false
+	}
+}
+
+func (f *Flatten) flattenValue(key string, value interface{}, depth int, flat map[string]interface{}) error {
+	if f.MaxFields > 0 && len(flat) > f.MaxFields {
+		return fmt.Errorf("more than %d fields", f.MaxFields)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if f.MaxDepth > 0 && depth > f.MaxDepth {
+			return f.addJSON(key, v, flat)
+		}
+		for k, item := range v {
+			if err := f.flattenValue(key+f.Separator+k, item, depth+1, flat); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if f.MaxDepth > 0 && depth > f.MaxDepth {
+			return f.addJSON(key, v, flat)
+		}
+		for i, item := range v {
+			if err := f.flattenValue(key+f.Separator+strconv.Itoa(i), item, depth+1, flat); err != nil {
+				return err
+			}
+		}
+	case nil:
+		// null values are not fields
+	default:
+		flat[key] = v
+	}
+
+	if f.MaxFields > 0 && len(flat) > f.MaxFields {
+		return fmt.Errorf("more than %d fields", f.MaxFields)
+	}
+	return nil
+}
+
+func (f *Flatten) addJSON(key string, value interface{}, flat map[string]interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	flat[key] = string(b)
+	return nil
+}
+
+// nest replaces the fields named after a path by a field holding the JSON
+// object of their values, named after the first key of the path.
+func (f *Flatten) nest(m telegraf.Metric) {
+	groups := make(map[string][]*telegraf.Field)
+	var roots []string
+	for _, field := range m.FieldList() {
+		if !f.match(field.Key) {
+			continue
+		}
+		parts := strings.SplitN(field.Key, f.Separator, 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			continue
+		}
+		if _, ok := groups[parts[0]]; !ok {
+			roots = append(roots, parts[0])
+		}
+		groups[parts[0]] = append(groups[parts[0]], field)
+	}
+	sort.Strings(roots)
+
+	for _, root := range roots {
+		// The nested field would replace an unrelated field
+		if m.HasField(root) {
+			f.Log.Debugf("Not nesting fields of %q into existing field %q", m.Name(), root)
+			continue
+		}
+
+		tree := make(map[string]interface{})
+		conflict := false
+		for _, field := range groups[root] {
+			path := strings.Split(strings.TrimPrefix(field.Key, root+f.Separator), f.Separator)
+			if f.MaxDepth > 0 && len(path) > f.MaxDepth {
+				path = append(path[:f.MaxDepth-1], strings.Join(path[f.MaxDepth-1:], f.Separator))
+			}
+			if !insert(tree, path, field.Value) {
+				conflict = true
+				break
+			}
+		}
+		if conflict {
+			f.Log.Debugf("Not nesting fields %q of %q, a value and an object share a path", root, m.Name())
+			continue
+		}
+
+		b, err := json.Marshal(arrays(tree))
+		if err != nil {
+			f.Log.Debugf("Not nesting fields %q of %q: %v", root, m.Name(), err)
+			continue
+		}
+
+		if !f.KeepOriginal {
+			for _, field := range groups[root] {
+				m.RemoveField(field.Key)
+			}
+		}
+		m.AddField(root, string(b))
+	}
+}
+
+// insert sets the value at the path of the tree, returning false if the path
+// conflicts with a value already set.
+func insert(tree map[string]interface{}, path []string, value interface{}) bool {
+	for _, key := range path[:len(path)-1] {
+		child, ok := tree[key]
+		if !ok {
+			node := make(map[string]interface{})
+			tree[key] = node
+			tree = node
+			continue
+		}
+		node, ok := child.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		tree = node
+	}
+
+	last := path[len(path)-1]
+	if _, ok := tree[last]; ok {
+		return false
+	}
+	tree[last] = value
+	return true
+}
+
+// arrays converts the objects of the tree keyed by the indexes 0 to n-1 into
+// arrays, the inverse of flattening the arrays.
+func arrays(value interface{}) interface{} {
+	node, ok := value.(map[string]interface{})
+	if !ok || len(node) == 0 {
+		return value
+	}
+
+	for k, v := range node {
+		node[k] = arrays(v)
+	}
+
+	list := make([]interface{}, len(node))
+	for k, v := range node {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 || i >= len(node) || strconv.Itoa(i) != k {
+			return node
+		}
+		list[i] = v
+	}
+	return list
+}
+
+func init() {
+	processors.Add("flatten", func() telegraf.Processor {
+		return &Flatten{
+			Mode:      modeFlatten,
+			Separator: ".",
+		}
+	})
+}
@@ -0,0 +1,213 @@
+package flatten
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetric(fields map[string]interface{}) telegraf.Metric {
+	return testutil.MustMetric("test",
+		map[string]string{"host": "localhost"},
+		fields,
+		time.Unix(0, 0))
+}
+
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   *Flatten
+		fields   map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name:   "object and array",
+			plugin: &Flatten{},
+			fields: map[string]interface{}{
+				"value":   int64(42),
+				"payload": `{"request": {"method": "GET", "size": 12}, "ids": [1, 2], "ok": true, "none": null}`,
+			},
+			expected: map[string]interface{}{
+				"value":                  int64(42),
+				"payload.request.method": "GET",
+				"payload.request.size":   float64(12),
+				"payload.ids.0":          float64(1),
+				"payload.ids.1":          float64(2),
+				"payload.ok":             true,
+			},
+		},
+		{
+			name:   "not json",
+			plugin: &Flatten{},
+			fields: map[string]interface{}{
+				"message": "{not json",
+				"status":  "ok",
+			},
+			expected: map[string]interface{}{
+				"message": "{not json",
+				"status":  "ok",
+			},
+		},
+		{
+			name:   "selected fields and separator",
+			plugin: &Flatten{Fields: []string{"a*"}, Separator: "_", KeepOriginal: true},
+			fields: map[string]interface{}{
+				"a": `{"x": 1}`,
+				"b": `{"y": 2}`,
+			},
+			expected: map[string]interface{}{
+				"a":   `{"x": 1}`,
+				"a_x": float64(1),
+				"b":   `{"y": 2}`,
+			},
+		},
+		{
+			name:   "max depth",
+			plugin: &Flatten{MaxDepth: 1},
+			fields: map[string]interface{}{
+				"a": `{"x": {"y": {"z": 1}}, "list": [1, [2, 3]], "v": "s"}`,
+			},
+			expected: map[string]interface{}{
+				"a.x":    `{"y":{"z":1}}`,
+				"a.list": `[1,[2,3]]`,
+				"a.v":    "s",
+			},
+		},
+		{
+			name:   "max fields",
+			plugin: &Flatten{MaxFields: 2},
+			fields: map[string]interface{}{
+				"small": `{"x": 1, "y": 2}`,
+				"large": `[1, 2, 3]`,
+			},
+			expected: map[string]interface{}{
+				"small.x": float64(1),
+				"small.y": float64(2),
+				"large":   `[1, 2, 3]`,
+			},
+		},
+		{
+			name:   "structured value",
+			plugin: &Flatten{},
+			fields: map[string]interface{}{
+				"a": `[{"x": 1}, {"x": 2}]`,
+			},
+			expected: map[string]interface{}{
+				"a.0.x": float64(1),
+				"a.1.x": float64(2),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.plugin.Separator == "" {
+				tt.plugin.Separator = "."
+			}
+			tt.plugin.Log = testutil.Logger{}
+			require.NoError(t, tt.plugin.Init())
+
+			actual := tt.plugin.Apply(newMetric(tt.fields))
+			testutil.RequireMetricsEqual(t,
+				[]telegraf.Metric{newMetric(tt.expected)}, actual)
+		})
+	}
+}
+
+func TestNest(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   *Flatten
+		fields   map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name:   "object and array",
+			plugin: &Flatten{},
+			fields: map[string]interface{}{
+				"value":                  int64(42),
+				"payload.request.method": "GET",
+				"payload.request.size":   int64(12),
+				"payload.ids.0":          int64(1),
+				"payload.ids.1":          int64(2),
+			},
+			expected: map[string]interface{}{
+				"value":   int64(42),
+				"payload": `{"ids":[1,2],"request":{"method":"GET","size":12}}`,
+			},
+		},
+		{
+			name:   "not an array",
+			plugin: &Flatten{},
+			fields: map[string]interface{}{
+				"a.0": int64(1),
+				"a.2": int64(2),
+			},
+			expected: map[string]interface{}{
+				"a": `{"0":1,"2":2}`,
+			},
+		},
+		{
+			name:   "existing field",
+			plugin: &Flatten{},
+			fields: map[string]interface{}{
+				"a":   int64(1),
+				"a.x": int64(2),
+			},
+			expected: map[string]interface{}{
+				"a":   int64(1),
+				"a.x": int64(2),
+			},
+		},
+		{
+			name:   "max depth",
+			plugin: &Flatten{MaxDepth: 1, Fields: []string{"a.*"}},
+			fields: map[string]interface{}{
+				"a.x.y": int64(1),
+				"a.z":   int64(2),
+				"b.x":   int64(3),
+			},
+			expected: map[string]interface{}{
+				"a":   `{"x.y":1,"z":2}`,
+				"b.x": int64(3),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.plugin.Mode = modeNest
+			if tt.plugin.Separator == "" {
+				tt.plugin.Separator = "."
+			}
+			tt.plugin.Log = testutil.Logger{}
+			require.NoError(t, tt.plugin.Init())
+
+			actual := tt.plugin.Apply(newMetric(tt.fields))
+			testutil.RequireMetricsEqual(t,
+				[]telegraf.Metric{newMetric(tt.expected)}, actual)
+		})
+	}
+}
+
+func TestNestInverse(t *testing.T) {
+	flatten := &Flatten{Separator: "_", Log: testutil.Logger{}}
+	require.NoError(t, flatten.Init())
+	nest := &Flatten{Mode: modeNest, Separator: "_", Log: testutil.Logger{}}
+	require.NoError(t, nest.Init())
+
+	input := `{"a":[{"b":1},{"c":[true,"x"]}],"d":2.5}`
+	m := newMetric(map[string]interface{}{"doc": input})
+	actual := nest.Apply(flatten.Apply(m)...)
+
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{newMetric(map[string]interface{}{"doc": input})}, actual)
+}
+
+func TestInitInvalid(t *testing.T) {
+	require.Error(t, (&Flatten{Mode: "unknown", Separator: "."}).Init())
+	require.Error(t, (&Flatten{}).Init())
+}
@@ -0,0 +1,64 @@
+package kube_metadata
+
+import (
+	"context"
+	"time"
+
+	"github.com/ericchiang/k8s"
+	v1 "github.com/ericchiang/k8s/apis/core/v1"
+
+	"github.com/influxdata/telegraf/internal/tls"
+)
+
+type client struct {
+	namespace string
+	timeout   time.Duration
+	*k8s.Client
+}
+
+func newClient(baseURL, namespace, bearerToken string, timeout time.Duration, tlsConfig tls.ClientConfig) (*client, error) {
+	c, err := k8s.NewClient(&k8s.Config{
+		Clusters: []k8s.NamedCluster{{Name: "cluster", Cluster: k8s.Cluster{
+			Server:                baseURL,
+			InsecureSkipTLSVerify: tlsConfig.InsecureSkipVerify,
+			CertificateAuthority:  tlsConfig.TLSCA,
+		}}},
+		Contexts: []k8s.NamedContext{{Name: "context", Context: k8s.Context{
+			Cluster:   "cluster",
+			AuthInfo:  "auth",
+			Namespace: namespace,
+		}}},
+		AuthInfos: []k8s.NamedAuthInfo{{Name: "auth", AuthInfo: k8s.AuthInfo{
+			Token:             bearerToken,
+			ClientCertificate: tlsConfig.TLSCert,
+			ClientKey:         tlsConfig.TLSKey,
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &client{
+		Client:    c,
+		timeout:   timeout,
+		namespace: namespace,
+	}, nil
+}
+
+func (c *client) getNodes(ctx context.Context) (*v1.NodeList, error) {
+	list := new(v1.NodeList)
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return list, c.List(ctx, "", list)
+}
+
+func (c *client) getPods(ctx context.Context) (*v1.PodList, error) {
+	list := new(v1.PodList)
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return list, c.List(ctx, c.namespace, list)
+}
+
+func (c *client) watchPods(ctx context.Context, resourceVersion string) (*k8s.Watcher, error) {
+	return c.Watch(ctx, c.namespace, new(v1.Pod), k8s.ResourceVersion(resourceVersion))
+}
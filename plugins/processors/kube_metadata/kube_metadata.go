@@ -0,0 +1,464 @@
+package kube_metadata
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/ericchiang/k8s/apis/core/v1"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const (
+	defaultServiceAccountPath = "/run/secrets/kubernetes.io/serviceaccount/token"
+	retryInterval             = 5 * time.Second
+)
+
+var sampleConfig = `
+  ## URL for the Kubernetes API
+  url = "https://kubernetes.default.svc"
+
+  ## Namespace to watch. Set to "" to use all namespaces.
+  # namespace = ""
+
+  ## Use bearer token for authorization. ('bearer_token' takes priority)
+  ## If both of these are empty, we'll use the default serviceaccount:
+  ## at: /run/secrets/kubernetes.io/serviceaccount/token
+  # bearer_token = "/path/to/bearer/token"
+  ## OR
+  # bearer_token_string = "abc_123"
+
+  ## Set response_timeout (default 5 seconds)
+  # response_timeout = "5s"
+
+  ## Interval at which the full pod and node lists are fetched again.  Between
+  ## resyncs pod changes are received using a watch.
+  # resync_interval = "5m"
+
+  ## Tags used to find the pod a metric belongs to.  The pod is looked up
+  ## using the namespace and pod name tags, or by the container id tag.
+  # pod_tag = "pod_name"
+  # namespace_tag = "namespace"
+  # container_id_tag = "container_id"
+
+  ## Pod labels and annotations to add as tags.  Nothing is added unless it
+  ## is listed in an include option, use "*" to add everything.
+  # label_include = []
+  # label_exclude = []
+  # annotation_include = []
+  # annotation_exclude = []
+
+  ## Labels of the node the pod is scheduled on to add as tags.
+  # node_label_include = []
+  # node_label_exclude = []
+
+  ## Optional TLS Config
+  # tls_ca = "/path/to/cafile"
+  # tls_cert = "/path/to/certfile"
+  # tls_key = "/path/to/keyfile"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+type KubeMetadata struct {
+	URL               string            `toml:"url"`
+	BearerToken       string            `toml:"bearer_token"`
+	BearerTokenString string            `toml:"bearer_token_string"`
+	Namespace         string            `toml:"namespace"`
+	ResponseTimeout   internal.Duration `toml:"response_timeout"`
+	ResyncInterval    internal.Duration `toml:"resync_interval"`
+
+	PodTag         string `toml:"pod_tag"`
+	NamespaceTag   string `toml:"namespace_tag"`
+	ContainerIDTag string `toml:"container_id_tag"`
+
+	LabelInclude      []string `toml:"label_include"`
+	LabelExclude      []string `toml:"label_exclude"`
+	AnnotationInclude []string `toml:"annotation_include"`
+	AnnotationExclude []string `toml:"annotation_exclude"`
+	NodeLabelInclude  []string `toml:"node_label_include"`
+	NodeLabelExclude  []string `toml:"node_label_exclude"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	tls.ClientConfig
+
+	client           *client
+	labelFilter      filter.Filter
+	annotationFilter filter.Filter
+	nodeLabelFilter  filter.Filter
+
+	sync.RWMutex
+	pods       map[string]*podInfo
+	containers map[string]*podInfo
+	nodes      map[string]map[string]string
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// podInfo holds the tags to add for a single pod.
+type podInfo struct {
+	namespace    string
+	name         string
+	node         string
+	containerIDs []string
+	tags         map[string]string
+}
+
+func (k *KubeMetadata) SampleConfig() string {
+	return sampleConfig
+}
+
+func (k *KubeMetadata) Description() string {
+	return "Add Kubernetes pod labels, annotations, owner and node as tags"
+}
+
+func (k *KubeMetadata) Init() error {
+	var err error
+	k.labelFilter, err = newAllowFilter(k.LabelInclude, k.LabelExclude)
+	if err != nil {
+		return err
+	}
+	k.annotationFilter, err = newAllowFilter(k.AnnotationInclude, k.AnnotationExclude)
+	if err != nil {
+		return err
+	}
+	k.nodeLabelFilter, err = newAllowFilter(k.NodeLabelInclude, k.NodeLabelExclude)
+	if err != nil {
+		return err
+	}
+
+	k.pods = make(map[string]*podInfo)
+	k.containers = make(map[string]*podInfo)
+	k.nodes = make(map[string]map[string]string)
+
+	// If neither are provided, use the default service account.
+	if k.BearerToken == "" && k.BearerTokenString == "" {
+		k.BearerToken = defaultServiceAccountPath
+	}
+
+	if k.BearerToken != "" {
+		token, err := ioutil.ReadFile(k.BearerToken)
+		if err != nil {
+			return err
+		}
+		k.BearerTokenString = strings.TrimSpace(string(token))
+	}
+
+	k.client, err = newClient(k.URL, k.Namespace, k.BearerTokenString, k.ResponseTimeout.Duration, k.ClientConfig)
+	return err
+}
+
+func (k *KubeMetadata) Start(acc telegraf.Accumulator) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	k.cancel = cancel
+
+	// Load the initial state before processing any metrics, failures are
+	// retried in the background.
+	resourceVersion, err := k.sync(ctx)
+	if err != nil {
+		k.Log.Errorf("Error loading pods: %v", err)
+	}
+
+	k.wg.Add(1)
+	go func() {
+		defer k.wg.Done()
+		k.run(ctx, resourceVersion, err == nil)
+	}()
+	return nil
+}
+
+func (k *KubeMetadata) Add(metric telegraf.Metric, acc telegraf.Accumulator) {
+	k.decorate(metric)
+	acc.AddMetric(metric)
+}
+
+func (k *KubeMetadata) Stop() error {
+	if k.cancel != nil {
+		k.cancel()
+	}
+	k.wg.Wait()
+	return nil
+}
+
+func (k *KubeMetadata) run(ctx context.Context, resourceVersion string, synced bool) {
+	for {
+		var err error
+		if !synced {
+			resourceVersion, err = k.sync(ctx)
+		}
+		if err == nil {
+			err = k.watch(ctx, resourceVersion)
+		}
+		synced = false
+
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			k.Log.Errorf("Error watching pods: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryInterval):
+			}
+		}
+	}
+}
+
+// sync replaces the cached nodes and pods with the current state and returns
+// the resource version of the pod list.
+func (k *KubeMetadata) sync(ctx context.Context) (string, error) {
+	if k.nodeLabelFilter != nil {
+		nodes, err := k.client.getNodes(ctx)
+		if err != nil {
+			return "", err
+		}
+		k.updateNodes(nodes)
+	}
+
+	pods, err := k.client.getPods(ctx)
+	if err != nil {
+		return "", err
+	}
+	k.updatePods(pods)
+	return pods.GetMetadata().GetResourceVersion(), nil
+}
+
+// watch applies pod changes until the resync interval has elapsed.
+func (k *KubeMetadata) watch(ctx context.Context, resourceVersion string) error {
+	ctx, cancel := context.WithTimeout(ctx, k.ResyncInterval.Duration)
+	defer cancel()
+
+	watcher, err := k.client.watchPods(ctx, resourceVersion)
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	for {
+		pod := new(v1.Pod)
+		eventType, err := watcher.Next(pod)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		switch eventType {
+		case "ADDED", "MODIFIED":
+			k.setPod(pod)
+		case "DELETED":
+			k.deletePod(pod)
+		}
+	}
+}
+
+func (k *KubeMetadata) updateNodes(list *v1.NodeList) {
+	nodes := make(map[string]map[string]string, len(list.GetItems()))
+	for _, node := range list.GetItems() {
+		tags := make(map[string]string)
+		for key, value := range node.GetMetadata().GetLabels() {
+			if k.nodeLabelFilter.Match(key) {
+				tags[key] = value
+			}
+		}
+		nodes[node.GetMetadata().GetName()] = tags
+	}
+
+	k.Lock()
+	k.nodes = nodes
+	k.Unlock()
+}
+
+func (k *KubeMetadata) updatePods(list *v1.PodList) {
+	k.Lock()
+	defer k.Unlock()
+
+	k.pods = make(map[string]*podInfo, len(list.GetItems()))
+	k.containers = make(map[string]*podInfo)
+	for _, pod := range list.GetItems() {
+		k.addPodLocked(k.newPodInfo(pod))
+	}
+}
+
+func (k *KubeMetadata) setPod(pod *v1.Pod) {
+	info := k.newPodInfo(pod)
+
+	k.Lock()
+	defer k.Unlock()
+	k.removePodLocked(info.namespace, info.name)
+	k.addPodLocked(info)
+}
+
+func (k *KubeMetadata) deletePod(pod *v1.Pod) {
+	k.Lock()
+	defer k.Unlock()
+	k.removePodLocked(pod.GetMetadata().GetNamespace(), pod.GetMetadata().GetName())
+}
+
+func (k *KubeMetadata) addPodLocked(info *podInfo) {
+	k.pods[podKey(info.namespace, info.name)] = info
+	for _, id := range info.containerIDs {
+		k.containers[id] = info
+	}
+}
+
+func (k *KubeMetadata) removePodLocked(namespace, name string) {
+	key := podKey(namespace, name)
+	if old, ok := k.pods[key]; ok {
+		for _, id := range old.containerIDs {
+			delete(k.containers, id)
+		}
+		delete(k.pods, key)
+	}
+}
+
+func (k *KubeMetadata) newPodInfo(pod *v1.Pod) *podInfo {
+	meta := pod.GetMetadata()
+	info := &podInfo{
+		namespace: meta.GetNamespace(),
+		name:      meta.GetName(),
+		node:      pod.GetSpec().GetNodeName(),
+		tags:      make(map[string]string),
+	}
+
+	if k.labelFilter != nil {
+		for key, value := range meta.GetLabels() {
+			if k.labelFilter.Match(key) {
+				info.tags[key] = value
+			}
+		}
+	}
+
+	if k.annotationFilter != nil {
+		for key, value := range meta.GetAnnotations() {
+			if k.annotationFilter.Match(key) {
+				info.tags[key] = value
+			}
+		}
+	}
+
+	if kind, name := workload(pod); kind != "" {
+		info.tags["workload_kind"] = kind
+		info.tags["workload_name"] = name
+	}
+
+	if info.node != "" {
+		info.tags["node_name"] = info.node
+	}
+
+	for _, status := range pod.GetStatus().GetContainerStatuses() {
+		info.addContainerID(status.GetContainerID())
+	}
+	for _, status := range pod.GetStatus().GetInitContainerStatuses() {
+		info.addContainerID(status.GetContainerID())
+	}
+
+	return info
+}
+
+// addContainerID records the container id without the runtime prefix, such
+// as "docker://", along with the short id used by the docker cli.
+func (p *podInfo) addContainerID(id string) {
+	if i := strings.Index(id, "://"); i >= 0 {
+		id = id[i+3:]
+	}
+	if id == "" {
+		return
+	}
+	p.containerIDs = append(p.containerIDs, id)
+	if len(id) > 12 {
+		p.containerIDs = append(p.containerIDs, id[:12])
+	}
+}
+
+func (k *KubeMetadata) decorate(metric telegraf.Metric) {
+	k.RLock()
+	defer k.RUnlock()
+
+	var info *podInfo
+	if name, ok := metric.GetTag(k.PodTag); ok {
+		namespace, _ := metric.GetTag(k.NamespaceTag)
+		info = k.pods[podKey(namespace, name)]
+	}
+	if info == nil {
+		if id, ok := metric.GetTag(k.ContainerIDTag); ok {
+			info = k.containers[id]
+		}
+	}
+	if info == nil {
+		return
+	}
+
+	addTagIfMissing(metric, k.NamespaceTag, info.namespace)
+	addTagIfMissing(metric, k.PodTag, info.name)
+	for key, value := range info.tags {
+		addTagIfMissing(metric, key, value)
+	}
+	for key, value := range k.nodes[info.node] {
+		addTagIfMissing(metric, key, value)
+	}
+}
+
+// workload returns the kind and name of the controller managing the pod.
+// Pods created by a Deployment are owned by a ReplicaSet, in this case the
+// Deployment is reported instead.
+func workload(pod *v1.Pod) (string, string) {
+	for _, ref := range pod.GetMetadata().GetOwnerReferences() {
+		if !ref.GetController() {
+			continue
+		}
+
+		kind, name := ref.GetKind(), ref.GetName()
+		if kind == "ReplicaSet" {
+			hash := pod.GetMetadata().GetLabels()["pod-template-hash"]
+			if hash != "" && strings.HasSuffix(name, "-"+hash) {
+				return "Deployment", strings.TrimSuffix(name, "-"+hash)
+			}
+		}
+		return kind, name
+	}
+	return "", ""
+}
+
+// newAllowFilter returns a filter that only matches when include is set, nil
+// is returned when nothing should be matched.
+func newAllowFilter(include, exclude []string) (filter.Filter, error) {
+	if len(include) == 0 {
+		return nil, nil
+	}
+	return filter.NewIncludeExcludeFilter(include, exclude)
+}
+
+func addTagIfMissing(metric telegraf.Metric, key, value string) {
+	if key == "" || value == "" || metric.HasTag(key) {
+		return
+	}
+	metric.AddTag(key, value)
+}
+
+func podKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+func init() {
+	processors.AddStreaming("kube_metadata", func() telegraf.StreamingProcessor {
+		return &KubeMetadata{
+			ResponseTimeout: internal.Duration{Duration: 5 * time.Second},
+			ResyncInterval:  internal.Duration{Duration: 5 * time.Minute},
+			PodTag:          "pod_name",
+			NamespaceTag:    "namespace",
+			ContainerIDTag:  "container_id",
+		}
+	})
+}
@@ -0,0 +1,268 @@
+package kube_metadata
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/ericchiang/k8s/apis/core/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func toStrPtr(s string) *string {
+	return &s
+}
+
+func toBoolPtr(b bool) *bool {
+	return &b
+}
+
+func newTestProcessor(t *testing.T, k *KubeMetadata) *KubeMetadata {
+	k.PodTag = "pod_name"
+	k.NamespaceTag = "namespace"
+	k.ContainerIDTag = "container_id"
+	k.BearerTokenString = "abc123"
+	k.URL = "https://127.0.0.1:443/"
+	k.Log = testutil.Logger{}
+	require.NoError(t, k.Init())
+	return k
+}
+
+func testPod() *v1.Pod {
+	return &v1.Pod{
+		Metadata: &metav1.ObjectMeta{
+			Name:      toStrPtr("web-5d8b9c7f4d-x2x9z"),
+			Namespace: toStrPtr("default"),
+			Labels: map[string]string{
+				"app":               "web",
+				"tier":              "frontend",
+				"pod-template-hash": "5d8b9c7f4d",
+			},
+			Annotations: map[string]string{
+				"team": "payments",
+			},
+			OwnerReferences: []*metav1.OwnerReference{
+				{
+					Kind:       toStrPtr("ReplicaSet"),
+					Name:       toStrPtr("web-5d8b9c7f4d"),
+					Controller: toBoolPtr(true),
+				},
+			},
+		},
+		Spec: &v1.PodSpec{
+			NodeName: toStrPtr("node1"),
+		},
+		Status: &v1.PodStatus{
+			ContainerStatuses: []*v1.ContainerStatus{
+				{
+					Name:        toStrPtr("web"),
+					ContainerID: toStrPtr("docker://0123456789abcdef0123456789abcdef"),
+				},
+			},
+		},
+	}
+}
+
+func apply(k *KubeMetadata, metrics ...telegraf.Metric) []telegraf.Metric {
+	acc := &testutil.Accumulator{}
+	for _, m := range metrics {
+		k.Add(m, acc)
+	}
+	return acc.GetTelegrafMetrics()
+}
+
+func TestDecorate(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		processor *KubeMetadata
+		input     telegraf.Metric
+		expected  telegraf.Metric
+	}{
+		{
+			name:      "owner and node by pod name",
+			processor: &KubeMetadata{},
+			input: testutil.MustMetric("cpu",
+				map[string]string{"namespace": "default", "pod_name": "web-5d8b9c7f4d-x2x9z"},
+				map[string]interface{}{"value": 42},
+				now),
+			expected: testutil.MustMetric("cpu",
+				map[string]string{
+					"namespace":     "default",
+					"pod_name":      "web-5d8b9c7f4d-x2x9z",
+					"workload_kind": "Deployment",
+					"workload_name": "web",
+					"node_name":     "node1",
+				},
+				map[string]interface{}{"value": 42},
+				now),
+		},
+		{
+			name: "labels and annotations allowlist",
+			processor: &KubeMetadata{
+				LabelInclude:      []string{"app", "tier"},
+				LabelExclude:      []string{"tier"},
+				AnnotationInclude: []string{"team"},
+			},
+			input: testutil.MustMetric("cpu",
+				map[string]string{"namespace": "default", "pod_name": "web-5d8b9c7f4d-x2x9z"},
+				map[string]interface{}{"value": 42},
+				now),
+			expected: testutil.MustMetric("cpu",
+				map[string]string{
+					"namespace":     "default",
+					"pod_name":      "web-5d8b9c7f4d-x2x9z",
+					"workload_kind": "Deployment",
+					"workload_name": "web",
+					"node_name":     "node1",
+					"app":           "web",
+					"team":          "payments",
+				},
+				map[string]interface{}{"value": 42},
+				now),
+		},
+		{
+			name:      "lookup by short container id",
+			processor: &KubeMetadata{},
+			input: testutil.MustMetric("docker_container_cpu",
+				map[string]string{"container_id": "0123456789ab"},
+				map[string]interface{}{"value": 42},
+				now),
+			expected: testutil.MustMetric("docker_container_cpu",
+				map[string]string{
+					"container_id":  "0123456789ab",
+					"namespace":     "default",
+					"pod_name":      "web-5d8b9c7f4d-x2x9z",
+					"workload_kind": "Deployment",
+					"workload_name": "web",
+					"node_name":     "node1",
+				},
+				map[string]interface{}{"value": 42},
+				now),
+		},
+		{
+			name: "existing tags are kept",
+			processor: &KubeMetadata{
+				LabelInclude: []string{"app"},
+			},
+			input: testutil.MustMetric("cpu",
+				map[string]string{"namespace": "default", "pod_name": "web-5d8b9c7f4d-x2x9z", "app": "other"},
+				map[string]interface{}{"value": 42},
+				now),
+			expected: testutil.MustMetric("cpu",
+				map[string]string{
+					"namespace":     "default",
+					"pod_name":      "web-5d8b9c7f4d-x2x9z",
+					"workload_kind": "Deployment",
+					"workload_name": "web",
+					"node_name":     "node1",
+					"app":           "other",
+				},
+				map[string]interface{}{"value": 42},
+				now),
+		},
+		{
+			name:      "unknown pod",
+			processor: &KubeMetadata{},
+			input: testutil.MustMetric("cpu",
+				map[string]string{"namespace": "kube-system", "pod_name": "web-5d8b9c7f4d-x2x9z"},
+				map[string]interface{}{"value": 42},
+				now),
+			expected: testutil.MustMetric("cpu",
+				map[string]string{"namespace": "kube-system", "pod_name": "web-5d8b9c7f4d-x2x9z"},
+				map[string]interface{}{"value": 42},
+				now),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := newTestProcessor(t, tt.processor)
+			k.updatePods(&v1.PodList{Items: []*v1.Pod{testPod()}})
+
+			actual := apply(k, tt.input)
+			testutil.RequireMetricsEqual(t, []telegraf.Metric{tt.expected}, actual)
+		})
+	}
+}
+
+func TestNodeLabels(t *testing.T) {
+	k := newTestProcessor(t, &KubeMetadata{
+		NodeLabelInclude: []string{"topology.kubernetes.io/*"},
+	})
+	k.updateNodes(&v1.NodeList{
+		Items: []*v1.Node{
+			{
+				Metadata: &metav1.ObjectMeta{
+					Name: toStrPtr("node1"),
+					Labels: map[string]string{
+						"topology.kubernetes.io/zone": "us-east-1a",
+						"kubernetes.io/hostname":      "node1",
+					},
+				},
+			},
+		},
+	})
+	k.updatePods(&v1.PodList{Items: []*v1.Pod{testPod()}})
+
+	actual := apply(k, testutil.MustMetric("cpu",
+		map[string]string{"namespace": "default", "pod_name": "web-5d8b9c7f4d-x2x9z"},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0)))
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{
+				"namespace":                   "default",
+				"pod_name":                    "web-5d8b9c7f4d-x2x9z",
+				"workload_kind":               "Deployment",
+				"workload_name":               "web",
+				"node_name":                   "node1",
+				"topology.kubernetes.io/zone": "us-east-1a",
+			},
+			map[string]interface{}{"value": 42},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestWatchEvents(t *testing.T) {
+	k := newTestProcessor(t, &KubeMetadata{})
+	k.updatePods(&v1.PodList{})
+
+	pod := testPod()
+	k.setPod(pod)
+	require.Len(t, k.pods, 1)
+	require.Len(t, k.containers, 2)
+
+	pod.Spec.NodeName = toStrPtr("node2")
+	k.setPod(pod)
+	require.Len(t, k.pods, 1)
+	require.Equal(t, "node2", k.pods["default/web-5d8b9c7f4d-x2x9z"].node)
+
+	k.deletePod(pod)
+	require.Len(t, k.pods, 0)
+	require.Len(t, k.containers, 0)
+}
+
+func TestWorkload(t *testing.T) {
+	pod := testPod()
+	pod.Metadata.OwnerReferences = []*metav1.OwnerReference{
+		{
+			Kind:       toStrPtr("StatefulSet"),
+			Name:       toStrPtr("db"),
+			Controller: toBoolPtr(true),
+		},
+	}
+	kind, name := workload(pod)
+	require.Equal(t, "StatefulSet", kind)
+	require.Equal(t, "db", name)
+
+	pod.Metadata.OwnerReferences = nil
+	kind, name = workload(pod)
+	require.Equal(t, "", kind)
+	require.Equal(t, "", name)
+}
@@ -1,7 +1,11 @@
 package override
 
 import (
+	"fmt"
+
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/common/conditional"
 	"github.com/influxdata/telegraf/plugins/processors"
 )
 
@@ -11,16 +15,81 @@ var sampleConfig = `
   # name_prefix = "new_name_prefix"
   # name_suffix = "new_name_suffix"
 
+  ## Priority of the metrics in the output buffers, when a buffer is full the
+  ## metrics with the lowest priority are dropped first.  The default
+  ## priority of metrics is 0.
+  # priority = 0
+
   ## Tags to be added (all values must be strings)
   # [processors.override.tags]
   #   additional_tag = "tag_value"
+
+  ## Rules are applied in order after the modifications above, only to
+  ## metrics matching all of their conditions.  Values of rules may be Go
+  ## templates using the metric, such as '{{ .Tag "host" }}'.
+  # [[processors.override.rule]]
+  #   ## Apply only if the tags have a value matching one of the glob
+  #   ## patterns.
+  #   when_tag = { env = ["prod*"] }
+  #   ## Apply only if none of these fields are set.
+  #   # when_field_missing = []
+  #
+  #   # name_override = ""
+  #   # name_prefix = ""
+  #   # name_suffix = ""
+  #   [processors.override.rule.tags]
+  #     alert_route = '{{ .Tag "team" }}-pager'
 `
 
 type Override struct {
 	NameOverride string
 	NamePrefix   string
 	NameSuffix   string
+	Priority     *int
 	Tags         map[string]string
+	Rules        []rule          `toml:"rule"`
+	Log          telegraf.Logger `toml:"-"`
+}
+
+type rule struct {
+	WhenTag          map[string][]string `toml:"when_tag"`
+	WhenFieldMissing []string            `toml:"when_field_missing"`
+	NameOverride     string              `toml:"name_override"`
+	NamePrefix       string              `toml:"name_prefix"`
+	NameSuffix       string              `toml:"name_suffix"`
+	Tags             map[string]string   `toml:"tags"`
+
+	condition    *conditional.Condition
+	nameOverride *conditional.Value
+	namePrefix   *conditional.Value
+	nameSuffix   *conditional.Value
+	tags         map[string]*conditional.Value
+}
+
+func (r *rule) init() error {
+	var err error
+	r.condition, err = conditional.NewCondition(r.WhenTag, r.WhenFieldMissing)
+	if err != nil {
+		return err
+	}
+
+	if r.nameOverride, err = conditional.NewValue(r.NameOverride); err != nil {
+		return fmt.Errorf("invalid name_override: %v", err)
+	}
+	if r.namePrefix, err = conditional.NewValue(r.NamePrefix); err != nil {
+		return fmt.Errorf("invalid name_prefix: %v", err)
+	}
+	if r.nameSuffix, err = conditional.NewValue(r.NameSuffix); err != nil {
+		return fmt.Errorf("invalid name_suffix: %v", err)
+	}
+
+	r.tags = make(map[string]*conditional.Value, len(r.Tags))
+	for key, value := range r.Tags {
+		if r.tags[key], err = conditional.NewValue(value); err != nil {
+			return fmt.Errorf("invalid value for tag %q: %v", key, err)
+		}
+	}
+	return nil
 }
 
 func (p *Override) SampleConfig() string {
@@ -31,8 +100,17 @@ func (p *Override) Description() string {
 	return "Apply metric modifications using override semantics."
 }
 
+func (p *Override) Init() error {
+	for i := range p.Rules {
+		if err := p.Rules[i].init(); err != nil {
+			return fmt.Errorf("rule %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
 func (p *Override) Apply(in ...telegraf.Metric) []telegraf.Metric {
-	for _, metric := range in {
+	for i, metric := range in {
 		if len(p.NameOverride) > 0 {
 			metric.SetName(p.NameOverride)
 		}
@@ -45,10 +123,63 @@ func (p *Override) Apply(in ...telegraf.Metric) []telegraf.Metric {
 		for key, value := range p.Tags {
 			metric.AddTag(key, value)
 		}
+
+		for j := range p.Rules {
+			p.applyRule(&p.Rules[j], metric)
+		}
+
+		if p.Priority != nil {
+			in[i] = models.WithPriority(metric, *p.Priority)
+		}
 	}
 	return in
 }
 
+func (p *Override) applyRule(r *rule, metric telegraf.Metric) {
+	if !r.condition.Match(metric) {
+		return
+	}
+
+	// Evaluate all values before modifying the metric, so that templates
+	// refer to the metric as it was when the condition matched.
+	name, err := r.nameOverride.String(metric)
+	if err != nil {
+		p.Log.Errorf("Failed to execute name_override template: %v", err)
+		return
+	}
+	prefix, err := r.namePrefix.String(metric)
+	if err != nil {
+		p.Log.Errorf("Failed to execute name_prefix template: %v", err)
+		return
+	}
+	suffix, err := r.nameSuffix.String(metric)
+	if err != nil {
+		p.Log.Errorf("Failed to execute name_suffix template: %v", err)
+		return
+	}
+	tags := make(map[string]string, len(r.tags))
+	for key, value := range r.tags {
+		tags[key], err = value.String(metric)
+		if err != nil {
+			p.Log.Errorf("Failed to execute template for tag %q: %v", key, err)
+			return
+		}
+	}
+
+	if len(name) > 0 {
+		metric.SetName(name)
+	}
+	if len(prefix) > 0 {
+		metric.AddPrefix(prefix)
+	}
+	if len(suffix) > 0 {
+		metric.AddSuffix(suffix)
+	}
+	for key, value := range tags {
+		metric.AddTag(key, value)
+	}
+}
+
 func init() {
 	processors.Add("override", func() telegraf.Processor {
 		return &Override{}
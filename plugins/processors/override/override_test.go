@@ -6,7 +6,10 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func createTestMetric() telegraf.Metric {
@@ -78,3 +81,72 @@ func TestNameSuffix(t *testing.T) {
 
 	assert.Equal(t, "m1-suff", processed[0].Name(), "Suffix was not applied")
 }
+
+func TestPriority(t *testing.T) {
+	priority := 10
+	processor := Override{Priority: &priority}
+
+	processed := processor.Apply(createTestMetric())
+
+	assert.Equal(t, 10, models.Priority(processed[0]), "Priority was not set")
+}
+
+func TestRules(t *testing.T) {
+	processor := &Override{
+		Rules: []rule{
+			{
+				WhenTag:    map[string][]string{"env": {"prod*"}},
+				NamePrefix: "prod_",
+				Tags: map[string]string{
+					"alert_route": `{{ .Tag "team" }}-pager`,
+				},
+			},
+			{
+				WhenTag:          map[string][]string{"env": {"dev"}},
+				WhenFieldMissing: []string{"value"},
+				NameOverride:     "ignored",
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, processor.Init())
+
+	input := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"env": "production", "team": "ops"},
+			map[string]interface{}{"value": int64(1)},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("cpu",
+			map[string]string{"env": "dev", "team": "ops"},
+			map[string]interface{}{"value": int64(1)},
+			time.Unix(0, 0),
+		),
+	}
+	expected := []telegraf.Metric{
+		testutil.MustMetric("prod_cpu",
+			map[string]string{"env": "production", "team": "ops", "alert_route": "ops-pager"},
+			map[string]interface{}{"value": int64(1)},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("cpu",
+			map[string]string{"env": "dev", "team": "ops"},
+			map[string]interface{}{"value": int64(1)},
+			time.Unix(0, 0),
+		),
+	}
+
+	actual := processor.Apply(input...)
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestRuleInvalidTemplate(t *testing.T) {
+	processor := &Override{
+		Rules: []rule{
+			{
+				Tags: map[string]string{"route": `{{ .Tag "team" `},
+			},
+		},
+	}
+	require.Error(t, processor.Init())
+}
@@ -1,16 +1,25 @@
 package regex
 
 import (
+	"fmt"
 	"regexp"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/processors"
 )
 
+// maxCacheSize limits the number of cached tag conversion results, the cache
+// is cleared when it is full.
+const maxCacheSize = 10000
+
 type Regex struct {
-	Tags       []converter
-	Fields     []converter
-	regexCache map[string]*regexp.Regexp
+	Tags         []converter
+	Fields       []converter
+	TagRename    []renamer
+	FieldRename  []renamer
+	MetricRename []renamer
+	regexCache   map[string]*regexp.Regexp
+	resultCache  map[cacheKey]cacheResult
 }
 
 type converter struct {
@@ -19,6 +28,25 @@ type converter struct {
 	Replacement string
 	ResultKey   string
 	Append      bool
+	NamedGroups bool
+}
+
+type renamer struct {
+	Pattern     string
+	Replacement string
+	ResultKey   string
+}
+
+// cacheKey identifies the result of a conversion of a tag value, tag values
+// are repeated for every metric of a series.
+type cacheKey struct {
+	converter converter
+	value     string
+}
+
+type cacheResult struct {
+	key   string
+	value string
 }
 
 const sampleConfig = `
@@ -50,11 +78,35 @@ const sampleConfig = `
   #   pattern = ".*category=(\\w+).*"
   #   replacement = "${1}"
   #   result_key = "search_category"
+
+  ## With named_groups, each named subgroup of a matching pattern is added as
+  ## a tag, or a field for field conversions, named after the subgroup.
+  # [[processors.regex.tags]]
+  #   key = "path"
+  #   pattern = "^/(?P<service>\\w+)/(?P<version>v\\d+)/"
+  #   named_groups = true
+
+  ## Rename tag keys, field keys or the metric name matching the pattern.
+  ## If result_key is "keep", an existing tag or field with the new key is
+  ## not overwritten and the original key is left unchanged.
+  # [[processors.regex.tag_rename]]
+  #   pattern = "^host_(\\w+)$"
+  #   replacement = "${1}"
+  #   # result_key = "overwrite"
+
+  # [[processors.regex.field_rename]]
+  #   pattern = "^search_(\\w+)$"
+  #   replacement = "${1}"
+
+  # [[processors.regex.metric_rename]]
+  #   pattern = "^nginx_(\\w+)$"
+  #   replacement = "web_${1}"
 `
 
 func NewRegex() *Regex {
 	return &Regex{
-		regexCache: make(map[string]*regexp.Regexp),
+		regexCache:  make(map[string]*regexp.Regexp),
+		resultCache: make(map[cacheKey]cacheResult),
 	}
 }
 
@@ -66,17 +118,50 @@ func (r *Regex) Description() string {
 	return "Transforms tag and field values with regex pattern"
 }
 
+// Init compiles all patterns, so that invalid patterns are reported on
+// startup.
+func (r *Regex) Init() error {
+	var patterns []string
+	for _, c := range r.Tags {
+		patterns = append(patterns, c.Pattern)
+	}
+	for _, c := range r.Fields {
+		patterns = append(patterns, c.Pattern)
+	}
+	for _, renamers := range [][]renamer{r.TagRename, r.FieldRename, r.MetricRename} {
+		for _, c := range renamers {
+			switch c.ResultKey {
+			case "", "overwrite", "keep":
+			default:
+				return fmt.Errorf("invalid result_key %q for rename pattern %q", c.ResultKey, c.Pattern)
+			}
+			patterns = append(patterns, c.Pattern)
+		}
+	}
+
+	for _, pattern := range patterns {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+		r.regexCache[pattern] = regex
+	}
+	return nil
+}
+
 func (r *Regex) Apply(in ...telegraf.Metric) []telegraf.Metric {
 	for _, metric := range in {
 		for _, converter := range r.Tags {
 			if value, ok := metric.GetTag(converter.Key); ok {
-				if key, newValue := r.convert(converter, value); newValue != "" {
-					if converter.Append {
-						if v, ok := metric.GetTag(key); ok {
-							newValue = v + newValue
-						}
+				if converter.NamedGroups {
+					for key, newValue := range r.groups(converter, value) {
+						r.addTag(metric, converter, key, newValue)
 					}
-					metric.AddTag(key, newValue)
+					continue
+				}
+
+				if key, newValue := r.convertTag(converter, value); newValue != "" {
+					r.addTag(metric, converter, key, newValue)
 				}
 			}
 		}
@@ -85,23 +170,58 @@ func (r *Regex) Apply(in ...telegraf.Metric) []telegraf.Metric {
 			if value, ok := metric.GetField(converter.Key); ok {
 				switch value := value.(type) {
 				case string:
+					if converter.NamedGroups {
+						for key, newValue := range r.groups(converter, value) {
+							metric.AddField(key, newValue)
+						}
+						continue
+					}
+
 					if key, newValue := r.convert(converter, value); newValue != "" {
 						metric.AddField(key, newValue)
 					}
 				}
 			}
 		}
+
+		r.renameTags(metric)
+		r.renameFields(metric)
+		r.renameMetric(metric)
 	}
 
 	return in
 }
 
-func (r *Regex) convert(c converter, src string) (string, string) {
-	regex, compiled := r.regexCache[c.Pattern]
-	if !compiled {
-		regex = regexp.MustCompile(c.Pattern)
-		r.regexCache[c.Pattern] = regex
+func (r *Regex) addTag(metric telegraf.Metric, c converter, key string, value string) {
+	if c.Append {
+		if v, ok := metric.GetTag(key); ok {
+			value = v + value
+		}
+	}
+	metric.AddTag(key, value)
+}
+
+// convertTag converts the tag value, caching the result.
+func (r *Regex) convertTag(c converter, src string) (string, string) {
+	if r.resultCache == nil {
+		r.resultCache = make(map[cacheKey]cacheResult)
+	}
+
+	key := cacheKey{converter: c, value: src}
+	if result, ok := r.resultCache[key]; ok {
+		return result.key, result.value
+	}
+
+	resultKey, value := r.convert(c, src)
+	if len(r.resultCache) >= maxCacheSize {
+		r.resultCache = make(map[cacheKey]cacheResult)
 	}
+	r.resultCache[key] = cacheResult{key: resultKey, value: value}
+	return resultKey, value
+}
+
+func (r *Regex) convert(c converter, src string) (string, string) {
+	regex := r.regex(c.Pattern)
 
 	value := ""
 	if c.ResultKey == "" || regex.MatchString(src) {
@@ -115,6 +235,105 @@ func (r *Regex) convert(c converter, src string) (string, string) {
 	return c.Key, value
 }
 
+// groups returns the non-empty named subgroups of the first match of the
+// pattern.
+func (r *Regex) groups(c converter, src string) map[string]string {
+	regex := r.regex(c.Pattern)
+
+	match := regex.FindStringSubmatch(src)
+	if match == nil {
+		return nil
+	}
+
+	result := make(map[string]string)
+	for i, name := range regex.SubexpNames() {
+		if name != "" && match[i] != "" {
+			result[name] = match[i]
+		}
+	}
+	return result
+}
+
+func (r *Regex) renameTags(metric telegraf.Metric) {
+	for _, c := range r.TagRename {
+		regex := r.regex(c.Pattern)
+
+		replacements := make(map[string]string)
+		for _, tag := range metric.TagList() {
+			if !regex.MatchString(tag.Key) {
+				continue
+			}
+			newKey := regex.ReplaceAllString(tag.Key, c.Replacement)
+			if newKey == "" || newKey == tag.Key {
+				continue
+			}
+			if c.ResultKey == "keep" && metric.HasTag(newKey) {
+				continue
+			}
+			replacements[tag.Key] = newKey
+		}
+
+		for oldKey, newKey := range replacements {
+			value, _ := metric.GetTag(oldKey)
+			metric.RemoveTag(oldKey)
+			metric.AddTag(newKey, value)
+		}
+	}
+}
+
+func (r *Regex) renameFields(metric telegraf.Metric) {
+	for _, c := range r.FieldRename {
+		regex := r.regex(c.Pattern)
+
+		replacements := make(map[string]string)
+		for _, field := range metric.FieldList() {
+			if !regex.MatchString(field.Key) {
+				continue
+			}
+			newKey := regex.ReplaceAllString(field.Key, c.Replacement)
+			if newKey == "" || newKey == field.Key {
+				continue
+			}
+			if c.ResultKey == "keep" && metric.HasField(newKey) {
+				continue
+			}
+			replacements[field.Key] = newKey
+		}
+
+		for oldKey, newKey := range replacements {
+			value, _ := metric.GetField(oldKey)
+			metric.RemoveField(oldKey)
+			metric.AddField(newKey, value)
+		}
+	}
+}
+
+func (r *Regex) renameMetric(metric telegraf.Metric) {
+	for _, c := range r.MetricRename {
+		regex := r.regex(c.Pattern)
+
+		if !regex.MatchString(metric.Name()) {
+			continue
+		}
+		if name := regex.ReplaceAllString(metric.Name(), c.Replacement); name != "" {
+			metric.SetName(name)
+		}
+	}
+}
+
+func (r *Regex) regex(pattern string) *regexp.Regexp {
+	if r.regexCache == nil {
+		r.regexCache = make(map[string]*regexp.Regexp)
+	}
+
+	regex, compiled := r.regexCache[pattern]
+	if !compiled {
+		regex = regexp.MustCompile(pattern)
+		r.regexCache[pattern] = regex
+	}
+	return regex
+}
+
 func init() {
 	processors.Add("regex", func() telegraf.Processor {
 		return NewRegex()
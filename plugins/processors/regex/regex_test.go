@@ -7,6 +7,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func newM1() telegraf.Metric {
@@ -261,6 +262,97 @@ func TestNoMatches(t *testing.T) {
 	}
 }
 
+func TestNamedGroups(t *testing.T) {
+	regex := NewRegex()
+	regex.Tags = []converter{
+		{
+			Key:         "resp_code",
+			Pattern:     "^(?P<resp_class>\\d)(?P<resp_detail>\\d\\d)$",
+			NamedGroups: true,
+		},
+	}
+	regex.Fields = []converter{
+		{
+			Key:         "request",
+			Pattern:     "^/api/(?P<endpoint>\\w+)/\\?category=(?P<category>\\w+)(?P<missing>&x=\\w+)?",
+			NamedGroups: true,
+		},
+	}
+	require.NoError(t, regex.Init())
+
+	processed := regex.Apply(newM2())
+
+	assert.Equal(t, map[string]string{
+		"verb":        "GET",
+		"resp_code":   "200",
+		"resp_class":  "2",
+		"resp_detail": "00",
+	}, processed[0].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"request":       "/api/search/?category=plugins&q=regex&sort=asc",
+		"ignore_number": int64(200),
+		"ignore_bool":   true,
+		"endpoint":      "search",
+		"category":      "plugins",
+	}, processed[0].Fields())
+}
+
+func TestRename(t *testing.T) {
+	regex := NewRegex()
+	regex.TagRename = []renamer{
+		{Pattern: "^resp_(\\w+)$", Replacement: "response_${1}"},
+		{Pattern: "^verb$", Replacement: "response_code", ResultKey: "keep"},
+	}
+	regex.FieldRename = []renamer{
+		{Pattern: "^ignore_", Replacement: ""},
+	}
+	regex.MetricRename = []renamer{
+		{Pattern: "^access_(\\w+)$", Replacement: "http_${1}"},
+	}
+	require.NoError(t, regex.Init())
+
+	processed := regex.Apply(newM2())
+
+	assert.Equal(t, "http_log", processed[0].Name())
+	assert.Equal(t, map[string]string{
+		"verb":          "GET",
+		"response_code": "200",
+	}, processed[0].Tags())
+	assert.Equal(t, map[string]interface{}{
+		"request": "/api/search/?category=plugins&q=regex&sort=asc",
+		"number":  int64(200),
+		"bool":    true,
+	}, processed[0].Fields())
+}
+
+func TestInitInvalidPattern(t *testing.T) {
+	regex := NewRegex()
+	regex.Tags = []converter{{Key: "resp_code", Pattern: "^(\\d"}}
+	require.Error(t, regex.Init())
+
+	regex = NewRegex()
+	regex.MetricRename = []renamer{{Pattern: "a", ResultKey: "replace"}}
+	require.Error(t, regex.Init())
+}
+
+func TestTagConversionCache(t *testing.T) {
+	regex := NewRegex()
+	regex.Tags = []converter{
+		{
+			Key:         "resp_code",
+			Pattern:     "^(\\d)\\d\\d$",
+			Replacement: "${1}xx",
+		},
+	}
+	require.NoError(t, regex.Init())
+
+	for i := 0; i < 2; i++ {
+		processed := regex.Apply(newM1())
+		assert.Equal(t, "2xx", processed[0].Tags()["resp_code"])
+	}
+	assert.Len(t, regex.resultCache, 1)
+}
+
 func BenchmarkConversions(b *testing.B) {
 	regex := NewRegex()
 	regex.Tags = []converter{
@@ -0,0 +1,303 @@
+package starlark
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/models"
+	common "github.com/influxdata/telegraf/plugins/common/starlark"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/selfstat"
+	"go.starlark.net/starlark"
+)
+
+const (
+	description  = "Process metrics using a Starlark script"
+	sampleConfig = `
+  ## The Starlark source can be set as a string in this configuration file, or
+  ## by referencing a file containing the script.  Only one source or script
+  ## should be set at once.
+  ##
+  ## Source of the Starlark script.
+  source = '''
+def apply(metric):
+	return metric
+'''
+
+  ## File containing a Starlark script.  Modules loaded with load() are read
+  ## relative to the directory of the file loading them.
+  # script = "/usr/local/bin/myscript.star"
+
+  ## Allow the script to read environment variables using os.getenv.
+  # allow_env = false
+
+  ## What to do with a metric when the script fails on it:
+  ##   drop: drop the metric, the default.
+  ##   pass: pass the metric on as it was when the error occurred.
+  ##   tag:  pass the metric on with the starlark_error tag set to true and
+  ##         the error in the starlark_error field.
+  # on_error = "drop"
+
+  ## Interval at which the flush function of the script, if it defines one,
+  ## is called to add the metrics it buffered.
+  # flush_interval = "10s"
+
+  ## Constants exposed to the script in the read-only constants dict.
+  # [processors.starlark.constants]
+  #   threshold = 10
+  #   units = ["kB", "MB"]
+`
+)
+
+type Starlark struct {
+	common.Common
+	OnError       string            `toml:"on_error"`
+	FlushInterval internal.Duration `toml:"flush_interval"`
+
+	// mu serializes the calls of the script, the flush function is called
+	// from its own goroutine.
+	mu        sync.Mutex
+	applyFunc *starlark.Function
+	flushFunc *starlark.Function
+	results   []telegraf.Metric
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+
+	applyTime   selfstat.Stat
+	applyCalls  selfstat.Stat
+	applyErrors selfstat.Stat
+}
+
+func (s *Starlark) Init() error {
+	switch s.OnError {
+	case "":
+		s.OnError = "drop"
+	case "drop", "pass", "tag":
+	default:
+		return fmt.Errorf("invalid on_error %q, must be drop, pass or tag", s.OnError)
+	}
+
+	if err := s.Common.Init("processor.starlark", nil); err != nil {
+		return err
+	}
+
+	// The source should define an apply function.
+	fn, err := s.Function("apply", 1)
+	if err != nil {
+		return err
+	}
+	s.applyFunc = fn
+
+	// The flush function is optional.
+	if s.IsDefined("flush") {
+		fn, err := s.Function("flush", 0)
+		if err != nil {
+			return err
+		}
+		if s.FlushInterval.Duration <= 0 {
+			return fmt.Errorf("flush_interval must be positive")
+		}
+		s.flushFunc = fn
+	}
+
+	// Processors with a script are told apart by its path, processors with
+	// an inline source share their stats.
+	tags := map[string]string{}
+	if s.Script != "" {
+		tags["script"] = s.Script
+	}
+	s.applyTime = selfstat.RegisterTiming("starlark", "apply_time_ns", tags)
+	s.applyCalls = selfstat.Register("starlark", "apply_calls", tags)
+	s.applyErrors = selfstat.Register("starlark", "errors", tags)
+	return nil
+}
+
+func (s *Starlark) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Starlark) Description() string {
+	return description
+}
+
+func (s *Starlark) Start(acc telegraf.Accumulator) error {
+	if s.flushFunc == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.FlushInterval.Duration)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				// Flush the metrics still buffered when stopping
+				s.flush(acc)
+				return
+			case <-ticker.C:
+				s.flush(acc)
+			}
+		}
+	}()
+	return nil
+}
+
+// flush calls the flush function of the script and adds the metrics it
+// returns.  The metrics are copied, since they are usually kept in the state.
+func (s *Starlark) flush(acc telegraf.Accumulator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rv, err := s.Call(s.flushFunc)
+	if err != nil {
+		s.applyErrors.Incr(1)
+		s.LogError(err)
+		return
+	}
+
+	switch rv := rv.(type) {
+	case *starlark.List:
+		iter := rv.Iterate()
+		defer iter.Done()
+		var v starlark.Value
+		for iter.Next(&v) {
+			m, ok := v.(*common.Metric)
+			if !ok {
+				s.applyErrors.Incr(1)
+				s.Log.Errorf("Invalid type returned by flush in list: %s", v.Type())
+				continue
+			}
+			acc.AddMetric(m.Unwrap().Copy())
+		}
+	case *common.Metric:
+		acc.AddMetric(rv.Unwrap().Copy())
+	case starlark.NoneType:
+	default:
+		s.applyErrors.Incr(1)
+		s.Log.Errorf("Invalid type returned by flush: %s", rv.Type())
+	}
+}
+
+func (s *Starlark) Add(metric telegraf.Metric, acc telegraf.Accumulator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A new wrapper is used for every call so that metrics kept in the state
+	// by the script are not replaced by the next metric.
+	start := time.Now()
+	rv, err := s.Call(s.applyFunc, common.NewMetric(metric))
+	s.applyTime.Incr(time.Since(start).Nanoseconds())
+	s.applyCalls.Incr(1)
+	if err != nil {
+		s.applyErrors.Incr(1)
+		s.LogError(err)
+		s.handleError(metric, err, acc)
+		return
+	}
+
+	switch rv := rv.(type) {
+	case *starlark.List:
+		iter := rv.Iterate()
+		defer iter.Done()
+		var v starlark.Value
+		for iter.Next(&v) {
+			switch v := v.(type) {
+			case *common.Metric:
+				m := v.Unwrap()
+				if containsMetric(s.results, m) {
+					s.applyErrors.Incr(1)
+					s.Log.Errorf("Duplicate metric reference detected")
+					continue
+				}
+				s.results = append(s.results, m)
+				acc.AddMetric(m)
+			default:
+				s.applyErrors.Incr(1)
+				s.Log.Errorf("Invalid type returned in list: %s", v.Type())
+			}
+		}
+
+		// If the script didn't return the original metrics, mark it as
+		// successfully handled.
+		if !containsMetric(s.results, metric) {
+			metric.Drop()
+		}
+
+		// clear results
+		for i := range s.results {
+			s.results[i] = nil
+		}
+		s.results = s.results[:0]
+	case *common.Metric:
+		m := rv.Unwrap()
+
+		// If we got the original metric back, possibly with a new priority,
+		// use that and drop the new one.
+		// Otherwise mark the original as accepted and use the new metric.
+		if models.UnwrapPriority(metric) != models.UnwrapPriority(m) {
+			metric.Accept()
+		}
+		acc.AddMetric(m)
+	case starlark.NoneType:
+		metric.Drop()
+	default:
+		s.applyErrors.Incr(1)
+		s.Log.Errorf("Invalid type returned: %T", rv)
+		metric.Reject()
+	}
+}
+
+// handleError passes on or drops a metric the script failed on, according to
+// on_error.
+func (s *Starlark) handleError(metric telegraf.Metric, err error, acc telegraf.Accumulator) {
+	switch s.OnError {
+	case "pass":
+		acc.AddMetric(metric)
+	case "tag":
+		msg := err.Error()
+		if err, ok := err.(*starlark.EvalError); ok {
+			msg = err.Msg
+		}
+		metric.AddTag("starlark_error", "true")
+		metric.AddField("starlark_error", msg)
+		acc.AddMetric(metric)
+	default:
+		metric.Reject()
+	}
+}
+
+func (s *Starlark) Stop() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+	return nil
+}
+
+// containsMetric reports whether the metric is in the list, a metric given a
+// priority by the script is still the same metric.
+func containsMetric(metrics []telegraf.Metric, metric telegraf.Metric) bool {
+	for _, m := range metrics {
+		if models.UnwrapPriority(m) == models.UnwrapPriority(metric) {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	processors.AddStreaming("starlark", func() telegraf.StreamingProcessor {
+		return &Starlark{
+			FlushInterval: internal.Duration{Duration: 10 * time.Second},
+		}
+	})
+}
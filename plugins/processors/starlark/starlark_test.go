@@ -0,0 +1,1410 @@
+package starlark
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/models"
+	common "github.com/influxdata/telegraf/plugins/common/starlark"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name   string
+		plugin *Starlark
+	}{
+		{
+			name: "source must define apply",
+			plugin: &Starlark{
+				Common: common.Common{
+					Source: "",
+					Log:    testutil.Logger{},
+				},
+			},
+		},
+		{
+			name: "apply not defined",
+			plugin: &Starlark{
+				Common: common.Common{
+					Source: `
+def process(metric):
+	return metric
+`,
+					Log: testutil.Logger{},
+				},
+			},
+		},
+		{
+			name: "apply is not a function",
+			plugin: &Starlark{
+				Common: common.Common{
+					Source: `
+apply = 42
+`,
+					Log: testutil.Logger{},
+				},
+			},
+		},
+		{
+			name: "apply takes too many arguments",
+			plugin: &Starlark{
+				Common: common.Common{
+					Source: `
+def apply(a, b):
+	return a
+`,
+					Log: testutil.Logger{},
+				},
+			},
+		},
+		{
+			name: "source and script",
+			plugin: &Starlark{
+				Common: common.Common{
+					Source: `
+def apply(metric):
+	return metric
+`,
+					Script: "testdata/ratio.star",
+					Log:    testutil.Logger{},
+				},
+			},
+		},
+		{
+			name: "syntax error",
+			plugin: &Starlark{
+				Common: common.Common{
+					Source: `
+def apply(metric):
+	return metric[
+`,
+					Log: testutil.Logger{},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.plugin.Init()
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		input    []telegraf.Metric
+		expected []telegraf.Metric
+	}{
+		{
+			name: "pass through",
+			source: `
+def apply(metric):
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"time_idle": 42,
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"time_idle": 42,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "read value from global scope",
+			source: `
+names = {
+	'cpu': 'cpu2',
+	'mem': 'mem2',
+}
+
+def apply(metric):
+	metric.name = names[metric.name]
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"time_idle": 42,
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu2",
+					map[string]string{},
+					map[string]interface{}{
+						"time_idle": 42,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "set time",
+			source: `
+def apply(metric):
+	metric.time = 1000000000
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"time_idle": 42,
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"time_idle": 42,
+					},
+					time.Unix(1, 0),
+				),
+			},
+		},
+		{
+			name: "tag operations",
+			source: `
+def apply(metric):
+	metric.tags['region'] = metric.tags.pop('host') + '-region'
+	metric.tags.setdefault('env', 'prod')
+	metric.tags.update(dc='east')
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{
+						"host": "example",
+					},
+					map[string]interface{}{
+						"time_idle": 42,
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{
+						"region": "example-region",
+						"env":    "prod",
+						"dc":     "east",
+					},
+					map[string]interface{}{
+						"time_idle": 42,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "field operations",
+			source: `
+def apply(metric):
+	metric.fields['time_busy'] = 100 - metric.fields['time_idle']
+	metric.fields['ratio'] = metric.fields['time_idle'] / 100.0
+	metric.fields['ok'] = True
+	metric.fields['big'] = 18446744073709551615
+	metric.fields.pop('unused')
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"time_idle": 42,
+						"unused":    "x",
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"time_idle": 42,
+						"time_busy": 58,
+						"ratio":     0.42,
+						"ok":        true,
+						"big":       uint64(18446744073709551615),
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "iterate fields",
+			source: `
+def apply(metric):
+	for k, v in metric.fields.items():
+		metric.tags[k] = str(v)
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"time_idle": 42,
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{
+						"time_idle": "42",
+					},
+					map[string]interface{}{
+						"time_idle": 42,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "return none drops metric",
+			source: `
+def apply(metric):
+	return None
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"time_idle": 42,
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{},
+		},
+		{
+			name: "return list of metrics",
+			source: `
+def apply(metric):
+	m = Metric('mem')
+	m.fields['used'] = 1
+	m.time = metric.time
+	dup = deepcopy(metric)
+	dup.name = 'cpu2'
+	return [metric, m, dup]
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"time_idle": 42,
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"time_idle": 42,
+					},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("mem",
+					map[string]string{},
+					map[string]interface{}{
+						"used": 1,
+					},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("cpu2",
+					map[string]string{},
+					map[string]interface{}{
+						"time_idle": 42,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "modify global scope is an error",
+			source: `
+names = []
+
+def apply(metric):
+	names.append(metric.name)
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"time_idle": 42,
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{},
+		},
+		{
+			name: "count metrics using state",
+			source: `
+def apply(metric):
+	count = state.get(metric.name, 0) + 1
+	state[metric.name] = count
+	metric.fields['count'] = count
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("mem",
+					map[string]string{},
+					map[string]interface{}{},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{},
+					time.Unix(10, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"count": 1,
+					},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("mem",
+					map[string]string{},
+					map[string]interface{}{
+						"count": 1,
+					},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"count": 2,
+					},
+					time.Unix(10, 0),
+				),
+			},
+		},
+		{
+			name: "compute delta using previous metric",
+			source: `
+def apply(metric):
+	last = state.get('last')
+	state['last'] = deepcopy(metric)
+	if last == None:
+		return None
+	metric.fields['delta'] = metric.fields['value'] - last.fields['value']
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("counter",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 10,
+					},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("counter",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 15,
+					},
+					time.Unix(10, 0),
+				),
+				testutil.MustMetric("counter",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 22,
+					},
+					time.Unix(20, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("counter",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 15,
+						"delta": 5,
+					},
+					time.Unix(10, 0),
+				),
+				testutil.MustMetric("counter",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 22,
+						"delta": 7,
+					},
+					time.Unix(20, 0),
+				),
+			},
+		},
+		{
+			name: "json decode and encode",
+			source: `
+def apply(metric):
+	payload = json.decode(metric.fields.pop('message'))
+	metric.fields['status'] = payload['status']
+	metric.fields['latency'] = payload['timing']['latency']
+	metric.fields['paths'] = json.encode(payload['paths'])
+	metric.fields['invalid'] = json.decode('{', 'default')
+	metric.tags['region'] = 'us-east'
+	metric.fields['tags'] = json.encode(metric.tags)
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("log",
+					map[string]string{
+						"host": "example.org",
+					},
+					map[string]interface{}{
+						"message": `{"status": 200, "timing": {"latency": 0.25}, "paths": ["/a", "/b", null]}`,
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("log",
+					map[string]string{
+						"host":   "example.org",
+						"region": "us-east",
+					},
+					map[string]interface{}{
+						"status":  200,
+						"latency": 0.25,
+						"paths":   `["/a","/b",null]`,
+						"invalid": "default",
+						"tags":    `{"host":"example.org","region":"us-east"}`,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "json decode error",
+			source: `
+def apply(metric):
+	metric.fields['value'] = json.decode(metric.fields['message'])
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("log",
+					map[string]string{},
+					map[string]interface{}{
+						"message": `{"status": 200} trailing`,
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{},
+		},
+		{
+			name: "parse time from field",
+			source: `
+def apply(metric):
+	t = time.parse_time(metric.fields.pop('timestamp'))
+	metric.time = t
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("event",
+					map[string]string{},
+					map[string]interface{}{
+						"timestamp": "2023-01-02T15:04:05Z",
+						"value":     42,
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("event",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 42,
+					},
+					time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC),
+				),
+			},
+		},
+		{
+			name: "parse time with format and location",
+			source: `
+def apply(metric):
+	t = time.parse_time(metric.fields['local'], format='2006-01-02 15:04:05', location='America/New_York')
+	metric.time = t.unix_nano
+	metric.fields['utc'] = t.in_location('UTC').format('2006-01-02T15:04:05Z07:00')
+	metric.fields['hour'] = t.hour
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("event",
+					map[string]string{},
+					map[string]interface{}{
+						"local": "2023-01-02 10:04:05",
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("event",
+					map[string]string{},
+					map[string]interface{}{
+						"local": "2023-01-02 10:04:05",
+						"utc":   "2023-01-02T15:04:05Z",
+						"hour":  10,
+					},
+					time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC),
+				),
+			},
+		},
+		{
+			name: "duration arithmetic",
+			source: `
+def apply(metric):
+	t = time.from_timestamp(0, metric.time)
+	start = time.parse_time(metric.fields['start'])
+	elapsed = t - start
+	metric.fields['elapsed_seconds'] = elapsed.seconds
+	metric.fields['elapsed_minutes'] = elapsed // time.minute
+	metric.fields['deadline'] = (start + 2 * time.parse_duration('1h30m')).unix
+	metric.fields['late'] = elapsed > time.hour
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("job",
+					map[string]string{},
+					map[string]interface{}{
+						"start": "1970-01-01T00:00:00Z",
+					},
+					time.Unix(90, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("job",
+					map[string]string{},
+					map[string]interface{}{
+						"start":           "1970-01-01T00:00:00Z",
+						"elapsed_seconds": 90.0,
+						"elapsed_minutes": 1,
+						"deadline":        10800,
+						"late":            false,
+					},
+					time.Unix(90, 0),
+				),
+			},
+		},
+		{
+			name: "invalid time is an error",
+			source: `
+def apply(metric):
+	metric.time = time.parse_time(metric.fields['timestamp'])
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("event",
+					map[string]string{},
+					map[string]interface{}{
+						"timestamp": "yesterday",
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{},
+		},
+		{
+			name: "math functions",
+			source: `
+def apply(metric):
+	v = metric.fields['value']
+	metric.fields['sqrt'] = math.sqrt(v)
+	metric.fields['log10'] = math.log(v, 10)
+	metric.fields['ln'] = math.log(math.e)
+	metric.fields['pow'] = math.pow(v, 0.5)
+	metric.fields['floor'] = math.floor(2.7)
+	metric.fields['ceil'] = math.ceil(2.1)
+	metric.fields['isnan'] = math.isnan(math.nan)
+	metric.fields['isinf'] = math.isinf(v)
+	metric.fields['circle'] = math.floor(math.pi * 100)
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 100,
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value":  100,
+						"sqrt":   10.0,
+						"log10":  2.0,
+						"ln":     1.0,
+						"pow":    10.0,
+						"floor":  2,
+						"ceil":   3,
+						"isnan":  true,
+						"isinf":  false,
+						"circle": 314,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "math domain error",
+			source: `
+def apply(metric):
+	metric.fields['log'] = math.log(metric.fields['value'])
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 0,
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{},
+		},
+		{
+			name: "regular expressions",
+			source: `
+def apply(metric):
+	request = metric.fields['request']
+	m = re.match(r'(?P<method>[A-Z]+) (?P<path>\S+)', request)
+	metric.tags['method'] = m.group('method')
+	metric.tags['path'] = re.sub(r'/(\d+)', r'/:id', m.group('path'))
+	metric.fields['groups'] = ','.join(m.groups())
+	metric.fields['end'] = m.end('method')
+	metric.fields['ids'] = ','.join(re.findall(r'/(\d+)', request))
+	metric.fields['swapped'] = re.sub(r'(\w+)=(\w+)', r'\2=\g<1>', 'a=b c=d', 1)
+	metric.fields['parts'] = len(re.split(r'/+', m.group('path')))
+	metric.fields['no_match'] = re.match(r'\d+', request) == None
+	metric.fields['search'] = re.search(r'\d+', request).group()
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("http",
+					map[string]string{},
+					map[string]interface{}{
+						"request": "GET /users/42/orders/7 HTTP/1.1",
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("http",
+					map[string]string{
+						"method": "GET",
+						"path":   "/users/:id/orders/:id",
+					},
+					map[string]interface{}{
+						"request":  "GET /users/42/orders/7 HTTP/1.1",
+						"groups":   "GET,/users/42/orders/7",
+						"end":      3,
+						"ids":      "42,7,1",
+						"swapped":  "b=a c=d",
+						"parts":    5,
+						"no_match": true,
+						"search":   "42",
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "invalid regular expression",
+			source: `
+def apply(metric):
+	metric.fields['value'] = re.findall('(', metric.fields['value'])
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": "42",
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{},
+		},
+		{
+			name: "field type conversions",
+			source: `
+def apply(metric):
+	metric.fields['count'] = uint64(metric.fields['count'] + 1)
+	metric.fields['large'] = uint64(18446744073709551615)
+	metric.fields['signed'] = int64(metric.fields['ratio'])
+	metric.fields['parsed'] = uint64("42")
+	metric.fields['ratio'] = float64(metric.fields['total'])
+	metric.fields['types'] = " ".join([
+		field_type(metric, 'count'),
+		field_type(metric, 'signed'),
+		field_type(metric, 'ratio'),
+		field_type(metric, 'status'),
+		field_type(metric, 'ok'),
+		str(field_type(metric, 'missing')),
+	])
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"count":  uint64(41),
+						"ratio":  -2.5,
+						"total":  10,
+						"status": "up",
+						"ok":     true,
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"count":  uint64(42),
+						"large":  uint64(18446744073709551615),
+						"signed": int64(-2),
+						"parsed": uint64(42),
+						"ratio":  10.0,
+						"total":  10,
+						"status": "up",
+						"ok":     true,
+						"types":  "uint64 int64 float64 string bool None",
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "negative uint64 is an error",
+			source: `
+def apply(metric):
+	metric.fields['value'] = uint64(metric.fields['value'])
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": -1,
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{},
+		},
+		{
+			name: "int64 out of range is an error",
+			source: `
+def apply(metric):
+	metric.fields['value'] = int64(uint64(metric.fields['value']))
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": uint64(18446744073709551615),
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Starlark{
+				Common: common.Common{
+					Source: tt.source,
+					Log:    testutil.Logger{},
+				},
+			}
+			err := plugin.Init()
+			require.NoError(t, err)
+
+			var acc testutil.Accumulator
+
+			err = plugin.Start(&acc)
+			require.NoError(t, err)
+
+			for _, m := range tt.input {
+				plugin.Add(m, &acc)
+			}
+
+			err = plugin.Stop()
+			require.NoError(t, err)
+
+			testutil.RequireMetricsEqual(t, tt.expected, acc.GetTelegrafMetrics())
+		})
+	}
+}
+
+func TestScript(t *testing.T) {
+	plugin := &Starlark{
+		Common: common.Common{
+			Script: "testdata/ratio.star",
+			Log:    testutil.Logger{},
+		},
+	}
+	err := plugin.Init()
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+	plugin.Add(testutil.MustMetric("mem",
+		map[string]string{},
+		map[string]interface{}{
+			"used":  2,
+			"total": 10,
+		},
+		time.Unix(0, 0),
+	), &acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("mem",
+			map[string]string{},
+			map[string]interface{}{
+				"used":  2,
+				"total": 10,
+				"usage": 20.0,
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestScriptLoad(t *testing.T) {
+	plugin := &Starlark{
+		Common: common.Common{
+			Script: "testdata/load.star",
+			Log:    testutil.Logger{},
+		},
+	}
+	err := plugin.Init()
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+	plugin.Add(testutil.MustMetric("disk",
+		map[string]string{},
+		map[string]interface{}{
+			"size_kb": 2,
+		},
+		time.Unix(0, 0),
+	), &acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("disk",
+			map[string]string{},
+			map[string]interface{}{
+				"size": 2000,
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestScriptLoadError(t *testing.T) {
+	plugin := &Starlark{
+		Common: common.Common{
+			Script: "testdata/cycle.star",
+			Log:    testutil.Logger{},
+		},
+	}
+	require.Error(t, plugin.Init())
+
+	// Inline sources have no directory to load modules from
+	plugin = &Starlark{
+		Common: common.Common{
+			Source: `
+load("testdata/lib/factors.star", "factors")
+
+def apply(metric):
+	return metric
+`,
+			Log: testutil.Logger{},
+		},
+	}
+	require.Error(t, plugin.Init())
+}
+
+func TestConstants(t *testing.T) {
+	plugin := &Starlark{
+		Common: common.Common{
+			Source: `
+def apply(metric):
+	metric.fields["over"] = metric.fields["value"] > constants["threshold"]
+	metric.tags["unit"] = constants["units"][0]
+	metric.tags["site"] = constants["location"]["site"]
+	metric.fields["since"] = constants["since"].unix
+	return metric
+`,
+			Constants: map[string]interface{}{
+				"threshold": int64(10),
+				"units":     []interface{}{"kB", "MB"},
+				"location":  map[string]interface{}{"site": "north"},
+				"since":     time.Unix(42, 0),
+			},
+			Log: testutil.Logger{},
+		},
+	}
+	err := plugin.Init()
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+	plugin.Add(testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"value": 42,
+		},
+		time.Unix(0, 0),
+	), &acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{
+				"unit": "kB",
+				"site": "north",
+			},
+			map[string]interface{}{
+				"value": 42,
+				"over":  true,
+				"since": 42,
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+
+	// The constants are frozen
+	plugin = &Starlark{
+		Common: common.Common{
+			Source: `
+def apply(metric):
+	constants["location"]["site"] = "south"
+	return metric
+`,
+			Constants: map[string]interface{}{
+				"location": map[string]interface{}{"site": "north"},
+			},
+			Log: testutil.Logger{},
+		},
+	}
+	err = plugin.Init()
+	require.NoError(t, err)
+
+	acc = testutil.Accumulator{}
+	plugin.Add(testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"value": 42,
+		},
+		time.Unix(0, 0),
+	), &acc)
+	require.Empty(t, acc.GetTelegrafMetrics())
+
+	// Unsupported values are an error
+	plugin = &Starlark{
+		Common: common.Common{
+			Source:    "def apply(metric):\n\treturn metric\n",
+			Constants: map[string]interface{}{"value": struct{}{}},
+			Log:       testutil.Logger{},
+		},
+	}
+	require.Error(t, plugin.Init())
+}
+
+func TestLog(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	plugin := &Starlark{
+		Common: common.Common{
+			Script: "testdata/log.star",
+			Log:    testutil.Logger{Name: "processors.starlark"},
+		},
+	}
+	err := plugin.Init()
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+	plugin.Add(testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"value": 42,
+		},
+		time.Unix(0, 0),
+	), &acc)
+	require.Len(t, acc.GetTelegrafMetrics(), 1)
+
+	logs := buf.String()
+	require.Contains(t, logs, "D! [processors.starlark] [log.star] processing cpu\n")
+	require.Contains(t, logs, "I! [processors.starlark] [log.star] value is 42\n")
+	require.Contains(t, logs, "W! [processors.starlark] [log.star] {\"value\": 42}\n")
+	require.Contains(t, logs, "E! [processors.starlark] [log.star] loaded\n")
+}
+
+func TestOnError(t *testing.T) {
+	source := `
+def apply(metric):
+	metric.fields["checked"] = True
+	metric.fields["ratio"] = metric.fields["used"] / metric.fields["total"]
+	return metric
+`
+	input := func() telegraf.Metric {
+		return testutil.MustMetric("mem",
+			map[string]string{},
+			map[string]interface{}{
+				"used":  2,
+				"total": 0,
+			},
+			time.Unix(0, 0),
+		)
+	}
+
+	tests := []struct {
+		onError  string
+		expected []telegraf.Metric
+	}{
+		{
+			onError:  "drop",
+			expected: []telegraf.Metric{},
+		},
+		{
+			onError: "pass",
+			expected: []telegraf.Metric{
+				testutil.MustMetric("mem",
+					map[string]string{},
+					map[string]interface{}{
+						"used":    2,
+						"total":   0,
+						"checked": true,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			onError: "tag",
+			expected: []telegraf.Metric{
+				testutil.MustMetric("mem",
+					map[string]string{
+						"starlark_error": "true",
+					},
+					map[string]interface{}{
+						"used":           2,
+						"total":          0,
+						"checked":        true,
+						"starlark_error": "real division by zero",
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.onError, func(t *testing.T) {
+			plugin := &Starlark{
+				Common: common.Common{
+					Source: source,
+					Log:    testutil.Logger{},
+				},
+				OnError: tt.onError,
+			}
+			err := plugin.Init()
+			require.NoError(t, err)
+
+			var acc testutil.Accumulator
+			plugin.Add(input(), &acc)
+			testutil.RequireMetricsEqual(t, tt.expected, acc.GetTelegrafMetrics())
+		})
+	}
+
+	plugin := &Starlark{
+		Common: common.Common{
+			Source: source,
+			Log:    testutil.Logger{},
+		},
+		OnError: "retry",
+	}
+	require.Error(t, plugin.Init())
+}
+
+func TestFlush(t *testing.T) {
+	plugin := &Starlark{
+		Common: common.Common{
+			Source: `
+def apply(metric):
+	id = metric.tags["request_id"]
+	event = state.setdefault(id, {})
+	event[metric.name] = deepcopy(metric)
+	return None
+
+def flush():
+	joined = []
+	for id, event in state.items():
+		if "request" in event and "response" in event:
+			m = Metric("http_exchange")
+			m.tags["request_id"] = id
+			m.fields["latency_ns"] = event["response"].time - event["request"].time
+			m.time = event["request"].time
+			joined.append(m)
+	for m in joined:
+		state.pop(m.tags["request_id"])
+	return joined
+`,
+			Log: testutil.Logger{},
+		},
+		FlushInterval: internal.Duration{Duration: time.Hour},
+	}
+	err := plugin.Init()
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+
+	event := func(name, id string, tm time.Time) telegraf.Metric {
+		return testutil.MustMetric(name,
+			map[string]string{"request_id": id},
+			map[string]interface{}{"size": 10},
+			tm,
+		)
+	}
+	plugin.Add(event("request", "a", time.Unix(0, 100)), &acc)
+	plugin.Add(event("request", "b", time.Unix(0, 200)), &acc)
+	plugin.Add(event("response", "a", time.Unix(0, 150)), &acc)
+	require.Empty(t, acc.GetTelegrafMetrics())
+
+	// The buffered metrics are flushed when stopping
+	require.NoError(t, plugin.Stop())
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("http_exchange",
+			map[string]string{"request_id": "a"},
+			map[string]interface{}{"latency_ns": 50},
+			time.Unix(0, 100),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestFlushInterval(t *testing.T) {
+	plugin := &Starlark{
+		Common: common.Common{
+			Source: `
+def apply(metric):
+	state["count"] = state.get("count", 0) + 1
+	return None
+
+def flush():
+	m = Metric("count")
+	m.fields["count"] = state.get("count", 0)
+	return m
+`,
+			Log: testutil.Logger{},
+		},
+		FlushInterval: internal.Duration{Duration: 10 * time.Millisecond},
+	}
+	err := plugin.Init()
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	plugin.Add(testutil.TestMetric(1), &acc)
+	acc.Wait(2)
+	require.NoError(t, plugin.Stop())
+
+	// flush must take no parameter
+	plugin = &Starlark{
+		Common: common.Common{
+			Source: `
+def apply(metric):
+	return metric
+
+def flush(metric):
+	return metric
+`,
+			Log: testutil.Logger{},
+		},
+		FlushInterval: internal.Duration{Duration: time.Second},
+	}
+	require.Error(t, plugin.Init())
+}
+
+func TestPriority(t *testing.T) {
+	plugin := &Starlark{
+		Common: common.Common{
+			Source: `
+def apply(metric):
+	metric.priority = metric.priority + 10
+	return metric
+`,
+			Log: testutil.Logger{},
+		},
+	}
+	err := plugin.Init()
+	require.NoError(t, err)
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"time_idle": 42,
+		},
+		time.Unix(0, 0),
+	)
+	m = models.WithPriority(m, 5)
+
+	var acc metricAccumulator
+	plugin.Add(m, &acc)
+	require.Len(t, acc.added, 1)
+	require.Equal(t, 15, models.Priority(acc.added[0]))
+}
+
+// metricAccumulator keeps the metrics added as they are, the priority of a
+// metric is lost when the testutil.Accumulator copies it.
+type metricAccumulator struct {
+	testutil.Accumulator
+	added []telegraf.Metric
+}
+
+func (a *metricAccumulator) AddMetric(m telegraf.Metric) {
+	a.added = append(a.added, m)
+}
+
+func TestGetenv(t *testing.T) {
+	os.Setenv("TELEGRAF_STARLARK_TEST_DC", "us-east-1")
+	defer os.Unsetenv("TELEGRAF_STARLARK_TEST_DC")
+
+	source := `
+datacenter = os.getenv("TELEGRAF_STARLARK_TEST_DC")
+
+def apply(metric):
+	metric.tags["datacenter"] = datacenter
+	metric.tags["region"] = os.getenv("TELEGRAF_STARLARK_TEST_MISSING", "unknown")
+	return metric
+`
+
+	plugin := &Starlark{
+		Common: common.Common{
+			Source:   source,
+			AllowEnv: true,
+			Log:      testutil.Logger{},
+		},
+	}
+	err := plugin.Init()
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+	plugin.Add(testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"time_idle": 42,
+		},
+		time.Unix(0, 0),
+	), &acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{
+				"datacenter": "us-east-1",
+				"region":     "unknown",
+			},
+			map[string]interface{}{
+				"time_idle": 42,
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+
+	// Reading the environment is an error unless it is allowed
+	plugin = &Starlark{
+		Common: common.Common{
+			Source: source,
+			Log:    testutil.Logger{},
+		},
+	}
+	err = plugin.Init()
+	require.Error(t, err)
+}
+
+func TestApplyStats(t *testing.T) {
+	plugin := &Starlark{
+		Common: common.Common{
+			Script: "testdata/ratio.star",
+			Log:    testutil.Logger{},
+		},
+	}
+	err := plugin.Init()
+	require.NoError(t, err)
+
+	// Stats are shared by processors using the same script
+	calls := plugin.applyCalls.Get()
+	errors := plugin.applyErrors.Get()
+
+	var acc testutil.Accumulator
+	plugin.Add(testutil.MustMetric("mem",
+		map[string]string{},
+		map[string]interface{}{
+			"used":  2,
+			"total": 10,
+		},
+		time.Unix(0, 0),
+	), &acc)
+	plugin.Add(testutil.MustMetric("mem",
+		map[string]string{},
+		map[string]interface{}{
+			"used": 2,
+		},
+		time.Unix(0, 0),
+	), &acc)
+
+	require.Equal(t, calls+2, plugin.applyCalls.Get())
+	require.Equal(t, errors+1, plugin.applyErrors.Get())
+	require.Equal(t, map[string]string{"script": "testdata/ratio.star"}, plugin.applyTime.Tags())
+	require.True(t, plugin.applyTime.Get() > 0)
+}
@@ -2,26 +2,34 @@ package strings
 
 import (
 	"encoding/base64"
+	"fmt"
 	"strings"
 	"unicode"
 	"unicode/utf8"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/processors"
+	"golang.org/x/text/unicode/norm"
 )
 
 type Strings struct {
-	Lowercase    []converter `toml:"lowercase"`
-	Uppercase    []converter `toml:"uppercase"`
-	Titlecase    []converter `toml:"titlecase"`
-	Trim         []converter `toml:"trim"`
-	TrimLeft     []converter `toml:"trim_left"`
-	TrimRight    []converter `toml:"trim_right"`
-	TrimPrefix   []converter `toml:"trim_prefix"`
-	TrimSuffix   []converter `toml:"trim_suffix"`
-	Replace      []converter `toml:"replace"`
-	Left         []converter `toml:"left"`
-	Base64Decode []converter `toml:"base64decode"`
+	Lowercase     []converter `toml:"lowercase"`
+	Uppercase     []converter `toml:"uppercase"`
+	Titlecase     []converter `toml:"titlecase"`
+	Trim          []converter `toml:"trim"`
+	TrimLeft      []converter `toml:"trim_left"`
+	TrimRight     []converter `toml:"trim_right"`
+	TrimPrefix    []converter `toml:"trim_prefix"`
+	TrimSuffix    []converter `toml:"trim_suffix"`
+	Replace       []converter `toml:"replace"`
+	Left          []converter `toml:"left"`
+	Base64Decode  []converter `toml:"base64decode"`
+	Base64Encode  []converter `toml:"base64encode"`
+	Normalize     []converter `toml:"normalize"`
+	Transliterate []converter `toml:"transliterate"`
+	SnakeCase     []converter `toml:"snakecase"`
+	CamelCase     []converter `toml:"camelcase"`
+	TruncateBytes []converter `toml:"truncate_bytes"`
 
 	converters []converter
 	init       bool
@@ -42,6 +50,7 @@ type converter struct {
 	Old         string
 	New         string
 	Width       int
+	Form        string
 
 	fn ConvertFunc
 }
@@ -98,6 +107,32 @@ const sampleConfig = `
   ## Decode a base64 encoded utf-8 string
   # [[processors.strings.base64decode]]
   #   field = "message"
+
+  ## Encode a string as base64
+  # [[processors.strings.base64encode]]
+  #   field = "payload"
+
+  ## Apply a unicode normalization form, one of "NFC", "NFD", "NFKC" or
+  ## "NFKD"
+  # [[processors.strings.normalize]]
+  #   tag = "*"
+  #   form = "NFC"
+
+  ## Transliterate to ASCII, removing accents and other characters without
+  ## an ASCII equivalent
+  # [[processors.strings.transliterate]]
+  #   tag = "location"
+
+  ## Convert to snake_case or camelCase
+  # [[processors.strings.snakecase]]
+  #   field_key = "*"
+  # [[processors.strings.camelcase]]
+  #   tag_key = "*"
+
+  ## Truncate to at most width bytes without splitting characters
+  # [[processors.strings.truncate_bytes]]
+  #   tag = "*"
+  #   width = 255
 `
 
 func (s *Strings) SampleConfig() string {
@@ -226,6 +261,16 @@ func (c *converter) convert(metric telegraf.Metric) {
 	}
 }
 
+// Init validates the normalization forms.
+func (s *Strings) Init() error {
+	for _, c := range s.Normalize {
+		if _, err := normForm(c.Form); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *Strings) initOnce() {
 	if s.init {
 		return
@@ -318,10 +363,160 @@ func (s *Strings) initOnce() {
 		}
 		s.converters = append(s.converters, c)
 	}
+	for _, c := range s.Base64Encode {
+		c.fn = func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		}
+		s.converters = append(s.converters, c)
+	}
+	for _, c := range s.Normalize {
+		form, err := normForm(c.Form)
+		if err != nil {
+			continue
+		}
+		c.fn = form.String
+		s.converters = append(s.converters, c)
+	}
+	for _, c := range s.Transliterate {
+		c.fn = transliterate
+		s.converters = append(s.converters, c)
+	}
+	for _, c := range s.SnakeCase {
+		c.fn = snakeCase
+		s.converters = append(s.converters, c)
+	}
+	for _, c := range s.CamelCase {
+		c.fn = camelCase
+		s.converters = append(s.converters, c)
+	}
+	for _, c := range s.TruncateBytes {
+		c := c
+		c.fn = func(s string) string {
+			return truncateBytes(s, c.Width)
+		}
+		s.converters = append(s.converters, c)
+	}
 
 	s.init = true
 }
 
+func normForm(form string) (norm.Form, error) {
+	switch strings.ToUpper(form) {
+	case "", "NFC":
+		return norm.NFC, nil
+	case "NFD":
+		return norm.NFD, nil
+	case "NFKC":
+		return norm.NFKC, nil
+	case "NFKD":
+		return norm.NFKD, nil
+	default:
+		return norm.NFC, fmt.Errorf("invalid normalization form %q", form)
+	}
+}
+
+// transliterations contains the ASCII equivalents of letters which are not
+// decomposed into a base letter and marks.
+var transliterations = map[rune]string{
+	'Æ': "AE", 'æ': "ae",
+	'Ø': "O", 'ø': "o",
+	'Œ': "OE", 'œ': "oe",
+	'Đ': "D", 'đ': "d",
+	'Ł': "L", 'ł': "l",
+	'Þ': "TH", 'þ': "th",
+	'ß': "ss",
+	'ı': "i",
+}
+
+// transliterate converts the string to ASCII, by removing the marks of
+// decomposed characters and replacing known letters.  Other characters
+// without an ASCII equivalent are removed.
+func transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(s) {
+		switch {
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		case unicode.Is(unicode.Mn, r):
+		default:
+			b.WriteString(transliterations[r])
+		}
+	}
+	return b.String()
+}
+
+// words splits the string into words at characters other than letters and
+// digits, and at changes from lower to upper case.  An upper case letter
+// followed by a lower case letter starts a new word, so that "HTTPRequest"
+// is split into "HTTP" and "Request".
+func words(s string) []string {
+	runes := []rune(s)
+
+	var result []string
+	var word []rune
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				result = append(result, string(word))
+				word = nil
+			}
+			continue
+		}
+
+		if len(word) > 0 && unicode.IsUpper(r) {
+			prev := word[len(word)-1]
+			next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next) {
+				result = append(result, string(word))
+				word = nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		result = append(result, string(word))
+	}
+	return result
+}
+
+// snakeCase converts the string to lower case words separated by
+// underscores.
+func snakeCase(s string) string {
+	w := words(s)
+	for i := range w {
+		w[i] = strings.ToLower(w[i])
+	}
+	return strings.Join(w, "_")
+}
+
+// camelCase converts the string to words starting with an upper case letter
+// except for the first word, without separators.
+func camelCase(s string) string {
+	w := words(s)
+	for i := range w {
+		w[i] = strings.ToLower(w[i])
+		if i > 0 {
+			r, size := utf8.DecodeRuneInString(w[i])
+			w[i] = string(unicode.ToUpper(r)) + w[i][size:]
+		}
+	}
+	return strings.Join(w, "")
+}
+
+// truncateBytes truncates the string to at most width bytes, without
+// splitting a multi-byte character.
+func truncateBytes(s string, width int) string {
+	if width < 0 || len(s) <= width {
+		return s
+	}
+
+	end := width
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end]
+}
+
 func (s *Strings) Apply(in ...telegraf.Metric) []telegraf.Metric {
 	s.initOnce()
 
@@ -1047,3 +1047,147 @@ func TestBase64Decode(t *testing.T) {
 		})
 	}
 }
+
+func TestBase64Encode(t *testing.T) {
+	plugin := &Strings{
+		Base64Encode: []converter{
+			{
+				Field: "message",
+			},
+		},
+	}
+	m := testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"message": "howdy",
+		},
+		time.Unix(0, 0),
+	)
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{
+				"message": "aG93ZHk=",
+			},
+			time.Unix(0, 0),
+		),
+	}
+
+	actual := plugin.Apply(m)
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestNormalize(t *testing.T) {
+	decomposed := "Cafe\u0301"
+	composed := "Caf\u00e9"
+
+	plugin := &Strings{
+		Normalize: []converter{
+			{
+				Tag:  "location",
+				Form: "NFC",
+			},
+		},
+	}
+	require.NoError(t, plugin.Init())
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{"location": decomposed},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0),
+	)
+	actual := plugin.Apply(m)
+	value, ok := actual[0].GetTag("location")
+	require.True(t, ok)
+	require.Equal(t, composed, value)
+}
+
+func TestNormalizeInvalidForm(t *testing.T) {
+	plugin := &Strings{
+		Normalize: []converter{
+			{
+				Tag:  "location",
+				Form: "NFX",
+			},
+		},
+	}
+	require.Error(t, plugin.Init())
+}
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Zürich", "Zurich"},
+		{"São Paulo", "Sao Paulo"},
+		{"Straße", "Strasse"},
+		{"Łódź", "Lodz"},
+		{"København", "Kobenhavn"},
+		{"東京", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			require.Equal(t, tt.expected, transliterate(tt.input))
+		})
+	}
+}
+
+func TestCaseConversions(t *testing.T) {
+	tests := []struct {
+		input string
+		snake string
+		camel string
+	}{
+		{"requestCount", "request_count", "requestCount"},
+		{"HTTPRequestCount", "http_request_count", "httpRequestCount"},
+		{"http_request_count", "http_request_count", "httpRequestCount"},
+		{"bytes-sent total", "bytes_sent_total", "bytesSentTotal"},
+		{"ipv4Address", "ipv4_address", "ipv4Address"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			require.Equal(t, tt.snake, snakeCase(tt.input))
+			require.Equal(t, tt.camel, camelCase(tt.input))
+		})
+	}
+}
+
+func TestTruncateBytes(t *testing.T) {
+	plugin := &Strings{
+		TruncateBytes: []converter{
+			{
+				Tag:   "*",
+				Width: 5,
+			},
+		},
+	}
+	m := testutil.MustMetric("cpu",
+		map[string]string{
+			"ascii":   "abcdefgh",
+			"unicode": "aaaé",
+			"short":   "abc",
+		},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0),
+	)
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{
+				"ascii":   "abcde",
+				"unicode": "aaaé",
+				"short":   "abc",
+			},
+			map[string]interface{}{"value": 42},
+			time.Unix(0, 0),
+		),
+	}
+
+	actual := plugin.Apply(m)
+	testutil.RequireMetricsEqual(t, expected, actual)
+
+	// a multi-byte character crossing the limit is removed entirely
+	require.Equal(t, "aaaa", truncateBytes("aaaaé", 5))
+	require.Equal(t, "", truncateBytes("é", 1))
+}
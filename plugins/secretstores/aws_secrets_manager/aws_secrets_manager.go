@@ -0,0 +1,159 @@
+package aws_secrets_manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+
+	"github.com/influxdata/telegraf"
+	internalaws "github.com/influxdata/telegraf/config/aws"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+const sampleConfig = `
+  ## Identifier of the store, the secrets are referenced as
+  ## "@{<id>:<secret>}" in the config, or "@{<id>:<secret>:<key>}" to use
+  ## the key of a secret holding a JSON object.  The secret is its name or
+  ## ARN, such as "@{aws:telegraf/db:password}".
+  id = "aws"
+
+  ## Amazon REGION
+  region = "us-east-1"
+
+  ## Amazon Credentials
+  ## Credentials are loaded in the following order
+  ## 1) Assumed credentials via STS if role_arn is specified
+  ## 2) explicit credentials from 'access_key' and 'secret_key'
+  ## 3) shared profile from 'profile'
+  ## 4) environment variables
+  ## 5) shared credentials file
+  ## 6) EC2 Instance Profile
+  #access_key = ""
+  #secret_key = ""
+  #token = ""
+  #role_arn = ""
+  #profile = ""
+  #shared_credential_file = ""
+
+  ## Endpoint to make request against, the correct endpoint is automatically
+  ## determined and this option should only be set if you wish to override the
+  ## default.
+  ##   ex: endpoint_url = "http://localhost:8000"
+  # endpoint_url = ""
+
+  ## Staging label of the version of the secrets, by default the current
+  ## version is used.
+  # version_stage = "AWSCURRENT"
+`
+
+// secretsClient is the part of the Secrets Manager API used by the store.
+type secretsClient interface {
+	GetSecretValue(*secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// SecretsManager is a secret store reading the secrets from AWS Secrets
+// Manager.
+type SecretsManager struct {
+	Region      string `toml:"region"`
+	AccessKey   string `toml:"access_key"`
+	SecretKey   string `toml:"secret_key"`
+	RoleARN     string `toml:"role_arn"`
+	Profile     string `toml:"profile"`
+	Filename    string `toml:"shared_credential_file"`
+	Token       string `toml:"token"`
+	EndpointURL string `toml:"endpoint_url"`
+
+	VersionStage string `toml:"version_stage"`
+
+	client secretsClient
+}
+
+func (s *SecretsManager) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *SecretsManager) Description() string {
+	return "Read secrets from AWS Secrets Manager"
+}
+
+func (s *SecretsManager) Init() error {
+	credentialConfig := &internalaws.CredentialConfig{
+		Region:      s.Region,
+		AccessKey:   s.AccessKey,
+		SecretKey:   s.SecretKey,
+		RoleARN:     s.RoleARN,
+		Profile:     s.Profile,
+		Filename:    s.Filename,
+		Token:       s.Token,
+		EndpointURL: s.EndpointURL,
+	}
+	s.client = secretsmanager.New(credentialConfig.Credentials())
+	return nil
+}
+
+// Get returns the secret identified by the key "<secret>" or
+// "<secret>:<key>", the latter being the key of a secret holding a JSON
+// object.
+func (s *SecretsManager) Get(key string) (string, error) {
+	id, name := splitKey(key)
+
+	input := &secretsmanager.GetSecretValueInput{SecretId: aws.String(id)}
+	if s.VersionStage != "" {
+		input.VersionStage = aws.String(s.VersionStage)
+	}
+	output, err := s.client.GetSecretValue(input)
+	if err != nil {
+		return "", err
+	}
+
+	var value string
+	if output.SecretString != nil {
+		value = *output.SecretString
+	} else {
+		value = string(output.SecretBinary)
+	}
+	if name == "" {
+		return value, nil
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &object); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object: %v", id, err)
+	}
+	v, ok := object[name]
+	if !ok {
+		return "", fmt.Errorf("key %q not found in secret %s", name, id)
+	}
+	if str, ok := v.(string); ok {
+		return str, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// splitKey splits the key into the id of the secret and the key within the
+// secret.  The ARNs of the secrets are made of 7 parts separated by colons,
+// while the names of the secrets cannot contain any.
+func splitKey(key string) (string, string) {
+	n := 2
+	if strings.HasPrefix(key, "arn:") {
+		n = 8
+	}
+	parts := strings.SplitN(key, ":", n)
+	if len(parts) < n {
+		return key, ""
+	}
+	return strings.Join(parts[:n-1], ":"), parts[n-1]
+}
+
+func init() {
+	secretstores.Add("aws_secrets_manager", func() telegraf.SecretStore {
+		return &SecretsManager{}
+	})
+}
@@ -0,0 +1,78 @@
+package aws_secrets_manager
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/stretchr/testify/require"
+)
+
+type mockClient struct {
+	secrets map[string]*secretsmanager.GetSecretValueOutput
+	stage   string
+}
+
+func (m *mockClient) GetSecretValue(input *secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+	if input.VersionStage != nil {
+		m.stage = *input.VersionStage
+	}
+	output, ok := m.secrets[*input.SecretId]
+	if !ok {
+		return nil, errors.New("ResourceNotFoundException: Secrets Manager can't find the specified secret.")
+	}
+	return output, nil
+}
+
+func TestGet(t *testing.T) {
+	arn := "arn:aws:secretsmanager:us-east-1:123456789012:secret:telegraf/db-AbCdEf"
+	client := &mockClient{
+		secrets: map[string]*secretsmanager.GetSecretValueOutput{
+			"telegraf/token": {SecretString: aws.String("secret")},
+			"telegraf/db":    {SecretString: aws.String(`{"username":"telegraf","password":"secret","port":5432}`)},
+			arn:              {SecretString: aws.String(`{"password":"arn_secret"}`)},
+			"telegraf/bin":   {SecretBinary: []byte("binary")},
+		},
+	}
+	s := &SecretsManager{client: client}
+
+	tests := []struct {
+		key      string
+		expected string
+		err      bool
+	}{
+		{key: "telegraf/token", expected: "secret"},
+		{key: "telegraf/db:password", expected: "secret"},
+		{key: "telegraf/db:port", expected: "5432"},
+		{key: arn + ":password", expected: "arn_secret"},
+		{key: arn, expected: `{"password":"arn_secret"}`},
+		{key: "telegraf/bin", expected: "binary"},
+		{key: "telegraf/db:missing", err: true},
+		{key: "telegraf/token:password", err: true},
+		{key: "telegraf/missing", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			value, err := s.Get(tt.key)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func TestVersionStage(t *testing.T) {
+	client := &mockClient{
+		secrets: map[string]*secretsmanager.GetSecretValueOutput{
+			"telegraf/token": {SecretString: aws.String("secret")},
+		},
+	}
+	s := &SecretsManager{VersionStage: "AWSPREVIOUS", client: client}
+	_, err := s.Get("telegraf/token")
+	require.NoError(t, err)
+	require.Equal(t, "AWSPREVIOUS", client.stage)
+}
@@ -0,0 +1,152 @@
+package file
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+const sampleConfig = `
+  ## Identifier of the store, the secrets are referenced as "@{<id>:<name>}"
+  ## in the config.
+  id = "file"
+
+  ## Path of the encrypted file holding the secrets, created with
+  ## "telegraf secrets encrypt".
+  path = "/etc/telegraf/secrets.enc"
+
+  ## Path of the file holding the 256-bit encryption key as 64 hexadecimal
+  ## characters, such as created with "openssl rand -hex 32".
+  key_file = "/etc/telegraf/secrets.key"
+`
+
+// File is a secret store reading the secrets from a local file encrypted
+// with AES-256-GCM.  The file is read on each Get, so the secrets can be
+// rotated by replacing it.
+type File struct {
+	Path    string `toml:"path"`
+	KeyFile string `toml:"key_file"`
+
+	key []byte
+}
+
+func (f *File) SampleConfig() string {
+	return sampleConfig
+}
+
+func (f *File) Description() string {
+	return "Read secrets from a local encrypted file"
+}
+
+func (f *File) Init() error {
+	if f.Path == "" {
+		return errors.New("path is required")
+	}
+	if f.KeyFile == "" {
+		return errors.New("key_file is required")
+	}
+
+	key, err := ReadKey(f.KeyFile)
+	if err != nil {
+		return err
+	}
+	f.key = key
+
+	_, err = f.read()
+	return err
+}
+
+func (f *File) Get(key string) (string, error) {
+	secrets, err := f.read()
+	if err != nil {
+		return "", err
+	}
+	value, ok := secrets[key]
+	if !ok {
+		return "", fmt.Errorf("secret %q not found in %s", key, f.Path)
+	}
+	return value, nil
+}
+
+// read decrypts the file and returns the secrets it holds.
+func (f *File) read() (map[string]string, error) {
+	data, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := Decrypt(f.key, data)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting %s: %v", f.Path, err)
+	}
+
+	var secrets map[string]string
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("error decoding %s: %v", f.Path, err)
+	}
+	return secrets, nil
+}
+
+// ReadKey reads the encryption key from the file at path, holding the key as
+// 64 hexadecimal characters.
+func ReadKey(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("%s must hold a 256-bit key as 64 hexadecimal characters", path)
+	}
+	return key, nil
+}
+
+// Encrypt encrypts the plaintext with AES-256-GCM, the random nonce is
+// prepended to the returned ciphertext.
+func Encrypt(key, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt decrypts the ciphertext returned by Encrypt.
+func Decrypt(key, ciphertext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func init() {
+	secretstores.Add("file", func() telegraf.SecretStore {
+		return &File{}
+	})
+}
@@ -0,0 +1,82 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+func writeSecrets(t *testing.T, path string, key string, plaintext string) {
+	k, err := ReadKey(key)
+	require.NoError(t, err)
+	data, err := Encrypt(k, []byte(plaintext))
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+}
+
+func TestGet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyFile := filepath.Join(dir, "secrets.key")
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte(testKey+"\n"), 0600))
+	path := filepath.Join(dir, "secrets.enc")
+	writeSecrets(t, path, keyFile, `{"db_password": "secret"}`)
+
+	f := &File{Path: path, KeyFile: keyFile}
+	require.NoError(t, f.Init())
+
+	value, err := f.Get("db_password")
+	require.NoError(t, err)
+	require.Equal(t, "secret", value)
+
+	_, err = f.Get("missing")
+	require.Error(t, err)
+
+	// The file is read again on each Get.
+	writeSecrets(t, path, keyFile, `{"db_password": "rotated"}`)
+	value, err = f.Get("db_password")
+	require.NoError(t, err)
+	require.Equal(t, "rotated", value)
+}
+
+func TestInitErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	keyFile := filepath.Join(dir, "secrets.key")
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte(testKey), 0600))
+	otherKeyFile := filepath.Join(dir, "other.key")
+	require.NoError(t, ioutil.WriteFile(otherKeyFile, []byte(testKey[2:]+"ff"), 0600))
+	shortKeyFile := filepath.Join(dir, "short.key")
+	require.NoError(t, ioutil.WriteFile(shortKeyFile, []byte(testKey[:32]), 0600))
+
+	path := filepath.Join(dir, "secrets.enc")
+	writeSecrets(t, path, keyFile, `{"db_password": "secret"}`)
+	invalid := filepath.Join(dir, "invalid.enc")
+	writeSecrets(t, invalid, keyFile, `["secret"]`)
+
+	tests := []struct {
+		name string
+		file *File
+	}{
+		{"missing path", &File{KeyFile: keyFile}},
+		{"missing key file", &File{Path: path}},
+		{"short key", &File{Path: path, KeyFile: shortKeyFile}},
+		{"wrong key", &File{Path: path, KeyFile: otherKeyFile}},
+		{"invalid content", &File{Path: invalid, KeyFile: keyFile}},
+		{"missing file", &File{Path: filepath.Join(dir, "missing"), KeyFile: keyFile}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, tt.file.Init())
+		})
+	}
+}
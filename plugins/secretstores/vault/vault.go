@@ -0,0 +1,206 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+const sampleConfig = `
+  ## Identifier of the store, the secrets are referenced as
+  ## "@{<id>:<path>:<key>}" in the config, such as
+  ## "@{vault:kv/telegraf:db_password}".
+  id = "vault"
+
+  ## Address of the Vault server.
+  address = "https://127.0.0.1:8200"
+
+  ## Token used to authenticate, or file holding the token, which is read
+  ## again on each request so it can be renewed by the Vault agent.
+  # token = ""
+  # token_file = "/etc/telegraf/vault-token"
+
+  ## Vault Enterprise namespace.
+  # namespace = ""
+
+  ## Version of the KV secrets engine, 1 or 2.  With version 2 the first
+  ## element of the path is the mount of the engine.
+  # kv_version = 2
+
+  ## Timeout of the requests.
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+const defaultTimeout = 5 * time.Second
+
+// Vault is a secret store reading the secrets from the KV secrets engine of
+// HashiCorp Vault.
+type Vault struct {
+	Address   string            `toml:"address"`
+	Token     string            `toml:"token"`
+	TokenFile string            `toml:"token_file"`
+	Namespace string            `toml:"namespace"`
+	KVVersion int               `toml:"kv_version"`
+	Timeout   internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	client *http.Client
+}
+
+func (v *Vault) SampleConfig() string {
+	return sampleConfig
+}
+
+func (v *Vault) Description() string {
+	return "Read secrets from the KV secrets engine of HashiCorp Vault"
+}
+
+func (v *Vault) Init() error {
+	if v.Address == "" {
+		return errors.New("address is required")
+	}
+	if v.Token == "" && v.TokenFile == "" {
+		return errors.New("token or token_file is required")
+	}
+	switch v.KVVersion {
+	case 0:
+		v.KVVersion = 2
+	case 1, 2:
+	default:
+		return fmt.Errorf("invalid kv_version %d, must be 1 or 2", v.KVVersion)
+	}
+	if v.Timeout.Duration == 0 {
+		v.Timeout.Duration = defaultTimeout
+	}
+
+	tlsCfg, err := v.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	v.client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+			Proxy:           http.ProxyFromEnvironment,
+		},
+		Timeout: v.Timeout.Duration,
+	}
+	return nil
+}
+
+// Get returns the secret identified by the key "<path>:<key>", the key being
+// the key of the secret at path.
+func (v *Vault) Get(key string) (string, error) {
+	i := strings.LastIndex(key, ":")
+	if i <= 0 || i == len(key)-1 {
+		return "", fmt.Errorf("invalid key %q, must be <path>:<key>", key)
+	}
+	path, name := strings.Trim(key[:i], "/"), key[i+1:]
+
+	data, err := v.read(path)
+	if err != nil {
+		return "", err
+	}
+	value, ok := data[name]
+	if !ok {
+		return "", fmt.Errorf("key %q not found at %s", name, path)
+	}
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return fmt.Sprint(value), nil
+	default:
+		return "", fmt.Errorf("key %q at %s is not a string", name, path)
+	}
+}
+
+// read returns the data of the secret at path.
+func (v *Vault) read(path string) (map[string]interface{}, error) {
+	apiPath := path
+	if v.KVVersion == 2 {
+		parts := strings.SplitN(path, "/", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid path %q, must be <mount>/<path>", path)
+		}
+		apiPath = parts[0] + "/data/" + parts[1]
+	}
+
+	token, err := v.token()
+	if err != nil {
+		return nil, err
+	}
+
+	u := strings.TrimRight(v.Address, "/") + "/v1/" + apiPath
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("reading %s failed: %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&secret); err != nil {
+		return nil, fmt.Errorf("error decoding the secret at %s: %v", path, err)
+	}
+
+	if v.KVVersion == 2 {
+		data, ok := secret.Data["data"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("no data at %s", path)
+		}
+		return data, nil
+	}
+	return secret.Data, nil
+}
+
+func (v *Vault) token() (string, error) {
+	if v.TokenFile == "" {
+		return v.Token, nil
+	}
+	token, err := ioutil.ReadFile(v.TokenFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+func init() {
+	secretstores.Add("vault", func() telegraf.SecretStore {
+		return &Vault{}
+	})
+}
@@ -0,0 +1,93 @@
+package vault
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	var namespace string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		namespace = r.Header.Get("X-Vault-Namespace")
+		switch r.URL.Path {
+		case "/v1/kv/data/telegraf":
+			w.Write([]byte(`{"data":{"data":{"db_password":"secret","port":5432},"metadata":{"version":3}}}`))
+		case "/v1/secret/telegraf":
+			w.Write([]byte(`{"data":{"db_password":"secret_v1"}}`))
+		default:
+			http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	v := &Vault{Address: ts.URL, Token: "s.token", Namespace: "team"}
+	require.NoError(t, v.Init())
+
+	value, err := v.Get("kv/telegraf:db_password")
+	require.NoError(t, err)
+	require.Equal(t, "secret", value)
+	require.Equal(t, "team", namespace)
+
+	value, err = v.Get("kv/telegraf:port")
+	require.NoError(t, err)
+	require.Equal(t, "5432", value)
+
+	_, err = v.Get("kv/telegraf:missing")
+	require.Error(t, err)
+	_, err = v.Get("kv/missing:db_password")
+	require.Error(t, err)
+	_, err = v.Get("kv/telegraf")
+	require.Error(t, err)
+	_, err = v.Get("kv:db_password")
+	require.Error(t, err)
+
+	v1 := &Vault{Address: ts.URL, Token: "s.token", KVVersion: 1}
+	require.NoError(t, v1.Init())
+	value, err = v1.Get("secret/telegraf:db_password")
+	require.NoError(t, err)
+	require.Equal(t, "secret_v1", value)
+
+	denied := &Vault{Address: ts.URL, Token: "s.other"}
+	require.NoError(t, denied.Init())
+	_, err = denied.Get("kv/telegraf:db_password")
+	require.Error(t, err)
+}
+
+func TestTokenFile(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"data":{"token":"` + r.Header.Get("X-Vault-Token") + `"}}}`))
+	}))
+	defer ts.Close()
+
+	f, err := ioutil.TempFile("", "vault-token")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte("s.first\n"), 0600))
+
+	v := &Vault{Address: ts.URL, TokenFile: f.Name()}
+	require.NoError(t, v.Init())
+	value, err := v.Get("kv/telegraf:token")
+	require.NoError(t, err)
+	require.Equal(t, "s.first", value)
+
+	// The token file is read again on each request.
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte("s.renewed\n"), 0600))
+	value, err = v.Get("kv/telegraf:token")
+	require.NoError(t, err)
+	require.Equal(t, "s.renewed", value)
+}
+
+func TestInitErrors(t *testing.T) {
+	require.Error(t, (&Vault{Token: "s.token"}).Init())
+	require.Error(t, (&Vault{Address: "http://127.0.0.1:8200"}).Init())
+	require.Error(t, (&Vault{Address: "http://127.0.0.1:8200", Token: "s.token", KVVersion: 3}).Init())
+}
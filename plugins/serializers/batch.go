@@ -0,0 +1,44 @@
+package serializers
+
+import (
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+)
+
+// SerializeBatchPartial serializes the metrics as a batch.  If the batch can
+// not be serialized, each metric is serialized on its own to find the
+// metrics causing the failure, and the batch is serialized again without
+// them.
+//
+// When metrics are left out a *internal.PartialWriteError describing them is
+// returned along with the serialized batch, an output can return this error
+// from Write after sending the batch.  Any other error means that the batch
+// could not be serialized at all.
+func SerializeBatchPartial(s Serializer, metrics []telegraf.Metric) ([]byte, error) {
+	octets, err := s.SerializeBatch(metrics)
+	if err == nil {
+		return octets, nil
+	}
+
+	partial := &internal.PartialWriteError{}
+	valid := make([]telegraf.Metric, 0, len(metrics))
+	for i, m := range metrics {
+		if _, err := s.Serialize(m); err != nil {
+			partial.MetricsReject = append(partial.MetricsReject, i)
+			partial.MetricsRejectErrors = append(partial.MetricsRejectErrors, err)
+			continue
+		}
+		valid = append(valid, m)
+	}
+
+	// The batch could not be serialized, but no single metric is at fault.
+	if len(partial.MetricsReject) == 0 {
+		return nil, err
+	}
+
+	octets, err = s.SerializeBatch(valid)
+	if err != nil {
+		return nil, err
+	}
+	return octets, partial
+}
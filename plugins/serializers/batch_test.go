@@ -0,0 +1,55 @@
+package serializers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// stringSerializer fails to serialize metrics with a "bad" tag.
+type stringSerializer struct{}
+
+func (s *stringSerializer) Serialize(m telegraf.Metric) ([]byte, error) {
+	if m.HasTag("bad") {
+		return nil, errors.New("bad metric")
+	}
+	return []byte(m.Name() + "\n"), nil
+}
+
+func (s *stringSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var out []byte
+	for _, m := range metrics {
+		b, err := s.Serialize(m)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b...)
+	}
+	return out, nil
+}
+
+func TestSerializeBatchPartial(t *testing.T) {
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("a", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		testutil.MustMetric("b", map[string]string{"bad": "true"}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		testutil.MustMetric("c", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+	}
+
+	octets, err := SerializeBatchPartial(&stringSerializer{}, metrics)
+	require.Equal(t, "a\nc\n", string(octets))
+
+	partial, ok := err.(*internal.PartialWriteError)
+	require.True(t, ok)
+	require.Equal(t, []int{1}, partial.MetricsReject)
+	require.Len(t, partial.MetricsRejectErrors, 1)
+	require.NoError(t, partial.Err)
+
+	octets, err = SerializeBatchPartial(&stringSerializer{}, []telegraf.Metric{metrics[0]})
+	require.NoError(t, err)
+	require.Equal(t, "a\n", string(octets))
+}
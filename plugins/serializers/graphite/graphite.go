@@ -1,10 +1,8 @@
 package graphite
 
 import (
-	"bytes"
 	"fmt"
 	"math"
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -16,23 +14,14 @@ import (
 const DEFAULT_TEMPLATE = "host.tags.measurement.field"
 
 var (
-	allowedChars = regexp.MustCompile(`[^a-zA-Z0-9-:._=\p{L}]`)
-	hyphenChars  = strings.NewReplacer(
-		"/", "-",
-		"@", "-",
-		"*", "-",
-	)
-	dropChars = strings.NewReplacer(
-		`\`, "",
-		"..", ".",
-	)
-
 	fieldDeleter = strings.NewReplacer(".FIELDNAME", "", "FIELDNAME.", "")
 )
 
 type GraphiteTemplate struct {
 	Filter filter.Filter
 	Value  string
+
+	template *template
 }
 
 type GraphiteSerializer struct {
@@ -41,103 +30,163 @@ type GraphiteSerializer struct {
 	TagSupport bool
 	Separator  string
 	Templates  []*GraphiteTemplate
+
+	// StrictSanitize replaces all characters not allowed by the Graphite
+	// metric path and tag rules, instead of the historical replacement rules.
+	StrictSanitize bool
+
+	template *template
+}
+
+// Init precompiles the templates of the serializer.  Calling it is optional,
+// templates which are not compiled are parsed for each serialized metric.
+func (s *GraphiteSerializer) Init() error {
+	s.template = compileTemplate(s.Template)
+	for _, t := range s.Templates {
+		if t.template == nil {
+			t.template = compileTemplate(t.Value)
+		}
+	}
+	return nil
 }
 
 func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
-	out := []byte{}
+	var e encoder
+	e.serialize(s, metric)
+	return e.out, nil
+}
+
+func (s *GraphiteSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var e encoder
+	for _, m := range metrics {
+		e.serialize(s, m)
+	}
+	return e.out, nil
+}
 
+// metricTemplate returns the compiled template used for the given metric.
+func (s *GraphiteSerializer) metricTemplate(metric telegraf.Metric) *template {
+	for _, t := range s.Templates {
+		if t.Filter.Match(metric.Name()) {
+			if t.template != nil {
+				return t.template
+			}
+			return compileTemplate(t.Value)
+		}
+	}
+	if s.template != nil {
+		return s.template
+	}
+	return compileTemplate(s.Template)
+}
+
+// encoder holds the output and scratch buffers while serializing, so that
+// they are reused between the metrics of a batch.
+type encoder struct {
+	out    []byte
+	raw    []byte
+	bucket []byte
+	tags   tagList
+}
+
+func (e *encoder) serialize(s *GraphiteSerializer, metric telegraf.Metric) {
 	// Convert UnixNano to Unix timestamps
 	timestamp := metric.Time().UnixNano() / 1000000000
 
-	switch s.TagSupport {
-	case true:
-		for fieldName, value := range metric.Fields() {
-			fieldValue := formatValue(value)
-			if fieldValue == "" {
-				continue
-			}
-			bucket := SerializeBucketNameWithTags(metric.Name(), metric.Tags(), s.Prefix, s.Separator, fieldName)
-			metricString := fmt.Sprintf("%s %s %d\n",
-				// insert "field" section of template
-				bucket,
-				//bucket,
-				fieldValue,
-				timestamp)
-			point := []byte(metricString)
-			out = append(out, point...)
+	if s.TagSupport {
+		e.serializeWithTags(s, metric, timestamp)
+		return
+	}
+
+	var fieldPos int
+	var ok bool
+	e.raw, fieldPos, ok = s.metricTemplate(metric).appendBucket(e.raw[:0], metric, s.Prefix)
+	if !ok {
+		return
+	}
+
+	for _, field := range metric.FieldList() {
+		if !isValidValue(field.Value) {
+			continue
 		}
-	default:
-		template := s.Template
-		for _, graphiteTemplate := range s.Templates {
-			if graphiteTemplate.Filter.Match(metric.Name()) {
-				template = graphiteTemplate.Value
-				break
-			}
+		e.bucket = insertField(e.bucket[:0], e.raw, fieldPos, field.Key)
+		if s.StrictSanitize {
+			e.out = appendStrictPath(e.out, e.bucket)
+		} else {
+			e.out = appendSanitized(e.out, e.bucket)
 		}
+		e.out = appendPoint(e.out, field.Value, timestamp)
+	}
+}
 
-		bucket := SerializeBucketName(metric.Name(), metric.Tags(), template, s.Prefix)
-		if bucket == "" {
-			return out, nil
+func (e *encoder) serializeWithTags(s *GraphiteSerializer, metric telegraf.Metric, timestamp int64) {
+	e.tags.reset()
+	for _, tag := range metric.TagList() {
+		e.tags.add(tag.Key, tag.Value, s.StrictSanitize)
+	}
+	sort.Sort(&e.tags)
+
+	for _, field := range metric.FieldList() {
+		if !isValidValue(field.Value) {
+			continue
 		}
 
-		for fieldName, value := range metric.Fields() {
-			fieldValue := formatValue(value)
-			if fieldValue == "" {
-				continue
-			}
-			metricString := fmt.Sprintf("%s %s %d\n",
-				// insert "field" section of template
-				sanitize(InsertField(bucket, fieldName)),
-				fieldValue,
-				timestamp)
-			point := []byte(metricString)
-			out = append(out, point...)
+		e.raw = e.raw[:0]
+		if s.Prefix != "" {
+			e.raw = append(e.raw, s.Prefix...)
+			e.raw = append(e.raw, s.Separator...)
+		}
+		e.raw = append(e.raw, metric.Name()...)
+		if field.Key != "value" {
+			e.raw = append(e.raw, s.Separator...)
+			e.raw = append(e.raw, field.Key...)
 		}
+
+		if s.StrictSanitize {
+			e.out = appendStrictPath(e.out, e.raw)
+		} else {
+			e.out = appendSanitized(e.out, e.raw)
+		}
+		e.out = e.tags.appendTo(e.out)
+		e.out = appendPoint(e.out, field.Value, timestamp)
 	}
-	return out, nil
 }
 
-func (s *GraphiteSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
-	var batch bytes.Buffer
-	for _, m := range metrics {
-		buf, err := s.Serialize(m)
-		if err != nil {
-			return nil, err
-		}
-		_, err = batch.Write(buf)
-		if err != nil {
-			return nil, err
-		}
+// appendPoint appends the value and timestamp, completing the line.
+func appendPoint(dst []byte, value interface{}, timestamp int64) []byte {
+	dst = append(dst, ' ')
+	dst = appendValue(dst, value)
+	dst = append(dst, ' ')
+	dst = strconv.AppendInt(dst, timestamp, 10)
+	return append(dst, '\n')
+}
+
+func isValidValue(value interface{}) bool {
+	switch v := value.(type) {
+	case bool, uint64, int64:
+		return true
+	case float64:
+		return !math.IsNaN(v) && !math.IsInf(v, 0)
 	}
-	return batch.Bytes(), nil
+	return false
 }
 
-func formatValue(value interface{}) string {
+// appendValue appends a value accepted by isValidValue.
+func appendValue(dst []byte, value interface{}) []byte {
 	switch v := value.(type) {
-	case string:
-		return ""
 	case bool:
 		if v {
-			return "1"
-		} else {
-			return "0"
+			return append(dst, '1')
 		}
+		return append(dst, '0')
 	case uint64:
-		return strconv.FormatUint(v, 10)
+		return strconv.AppendUint(dst, v, 10)
 	case int64:
-		return strconv.FormatInt(v, 10)
+		return strconv.AppendInt(dst, v, 10)
 	case float64:
-		if math.IsNaN(v) {
-			return ""
-		}
-
-		if math.IsInf(v, 0) {
-			return ""
-		}
-		return strconv.FormatFloat(v, 'f', -1, 64)
+		return strconv.AppendFloat(dst, v, 'f', -1, 64)
 	}
-
-	return ""
+	return dst
 }
 
 // SerializeBucketName will take the given measurement name and tags and
@@ -232,8 +281,9 @@ func InitGraphiteTemplates(templates []string) ([]*GraphiteTemplate, string, err
 		}
 
 		graphiteTemplates = append(graphiteTemplates, &GraphiteTemplate{
-			Filter: tFilter,
-			Value:  parts[1],
+			Filter:   tFilter,
+			Value:    parts[1],
+			template: compileTemplate(parts[1]),
 		})
 	}
 
@@ -310,10 +360,5 @@ func buildTags(tags map[string]string) string {
 }
 
 func sanitize(value string) string {
-	// Apply special hyphenation rules to preserve backwards compatibility
-	value = hyphenChars.Replace(value)
-	// Apply rule to drop some chars to preserve backwards compatibility
-	value = dropChars.Replace(value)
-	// Replace any remaining illegal chars
-	return allowedChars.ReplaceAllLiteralString(value, "_")
+	return string(appendSanitized(nil, []byte(value)))
 }
@@ -1053,3 +1053,202 @@ func TestSerializeBatchWithTagsSupport(t *testing.T) {
 		})
 	}
 }
+
+func TestCleanWithStrictSanitize(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	tests := []struct {
+		name        string
+		metric_name string
+		tags        map[string]string
+		fields      map[string]interface{}
+		expected    string
+	}{
+		{
+			"Base metric",
+			"cpu",
+			map[string]string{"host": "localhost"},
+			map[string]interface{}{"usage_busy": float64(8.5)},
+			"localhost.cpu.usage_busy 8.5 1234567890\n",
+		},
+		{
+			"Special characters",
+			"cpu",
+			map[string]string{"host": "local/host", "label": "a@b*c=d\\e"},
+			map[string]interface{}{"usage_busy": float64(8.5)},
+			"local_host.a_b_c_d_e.cpu.usage_busy 8.5 1234567890\n",
+		},
+		{
+			"Unicode",
+			"cpu",
+			map[string]string{"host": "localhost", "label": "ünicode"},
+			map[string]interface{}{"usage_busy": float64(8.5)},
+			"localhost._nicode.cpu.usage_busy 8.5 1234567890\n",
+		},
+		{
+			"Empty nodes",
+			"cpu",
+			map[string]string{"host": "localhost"},
+			map[string]interface{}{"value": float64(8.5)},
+			"localhost.cpu 8.5 1234567890\n",
+		},
+	}
+
+	s := GraphiteSerializer{
+		StrictSanitize: true,
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := metric.New(tt.metric_name, tt.tags, tt.fields, now)
+			assert.NoError(t, err)
+			actual, _ := s.Serialize(m)
+			require.Equal(t, tt.expected, string(actual))
+		})
+	}
+}
+
+func TestCleanWithTagsSupportStrictSanitize(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	tests := []struct {
+		name        string
+		metric_name string
+		tags        map[string]string
+		fields      map[string]interface{}
+		expected    string
+	}{
+		{
+			"Base metric",
+			"cpu",
+			map[string]string{"host": "localhost"},
+			map[string]interface{}{"usage_busy": float64(8.5)},
+			"cpu.usage_busy;host=localhost 8.5 1234567890\n",
+		},
+		{
+			"Allowed punct in tags",
+			"cpu",
+			map[string]string{"host": "localhost", "path": "/var/log@*(x)"},
+			map[string]interface{}{"usage_busy": float64(8.5)},
+			"cpu.usage_busy;host=localhost;path=/var/log@*(x) 8.5 1234567890\n",
+		},
+		{
+			"Reserved characters in tags",
+			"cpu",
+			map[string]string{"host": "localhost", "a;b!c^d=e": "f;g h"},
+			map[string]interface{}{"usage_busy": float64(8.5)},
+			"cpu.usage_busy;a_b_c_d_e=f_g_h;host=localhost 8.5 1234567890\n",
+		},
+		{
+			"Tilde prefixed tag value",
+			"cpu",
+			map[string]string{"host": "localhost", "label": "~~value~"},
+			map[string]interface{}{"usage_busy": float64(8.5)},
+			"cpu.usage_busy;host=localhost;label=value~ 8.5 1234567890\n",
+		},
+		{
+			"Empty tag value",
+			"cpu",
+			map[string]string{"host": "localhost", "label": "~"},
+			map[string]interface{}{"usage_busy": float64(8.5)},
+			"cpu.usage_busy;host=localhost 8.5 1234567890\n",
+		},
+		{
+			"Name tag",
+			"cpu",
+			map[string]string{"host": "localhost", "name": "total"},
+			map[string]interface{}{"usage_busy": float64(8.5)},
+			"cpu.usage_busy;_name=total;host=localhost 8.5 1234567890\n",
+		},
+		{
+			"Special characters in path",
+			"cpu/total",
+			map[string]string{"host": "localhost"},
+			map[string]interface{}{"usage busy": float64(8.5)},
+			"cpu_total.usage_busy;host=localhost 8.5 1234567890\n",
+		},
+	}
+
+	s := GraphiteSerializer{
+		TagSupport:     true,
+		Separator:      ".",
+		StrictSanitize: true,
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := metric.New(tt.metric_name, tt.tags, tt.fields, now)
+			assert.NoError(t, err)
+			actual, _ := s.Serialize(m)
+			require.Equal(t, tt.expected, string(actual))
+		})
+	}
+}
+
+func TestSerializeCompiledTemplates(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	m, err := metric.New("cpu", defaultTags, map[string]interface{}{
+		"usage_idle": float64(91.5),
+		"value":      int64(1),
+	}, now)
+	require.NoError(t, err)
+
+	for _, template := range []string{"", template1, template2, template3, template4, template5, template6, "field.measurement", "field"} {
+		t.Run(template, func(t *testing.T) {
+			s := GraphiteSerializer{
+				Prefix:   "prefix",
+				Template: template,
+			}
+			expected, err := s.Serialize(m)
+			require.NoError(t, err)
+
+			require.NoError(t, s.Init())
+			actual, err := s.Serialize(m)
+			require.NoError(t, err)
+			require.Equal(t, string(expected), string(actual))
+
+			bucket := SerializeBucketName(m.Name(), m.Tags(), template, s.Prefix)
+			var lines []string
+			for _, field := range m.FieldList() {
+				lines = append(lines, fmt.Sprintf("%s %v %d\n", sanitize(InsertField(bucket, field.Key)), field.Value, now.Unix()))
+			}
+			require.Equal(t, strings.Join(lines, ""), string(actual))
+		})
+	}
+}
+
+func BenchmarkSerializeBatch(b *testing.B) {
+	now := time.Unix(1234567890, 0)
+	m, _ := metric.New("cpu", defaultTags, map[string]interface{}{
+		"usage_idle": float64(91.5),
+		"usage_busy": float64(8.5),
+		"count":      int64(42),
+	}, now)
+	metrics := make([]telegraf.Metric, 100)
+	for i := range metrics {
+		metrics[i] = m
+	}
+
+	s := GraphiteSerializer{}
+	s.Init()
+	for i := 0; i < b.N; i++ {
+		s.SerializeBatch(metrics)
+	}
+}
+
+func BenchmarkSerializeBatchWithTagsSupport(b *testing.B) {
+	now := time.Unix(1234567890, 0)
+	m, _ := metric.New("cpu", defaultTags, map[string]interface{}{
+		"usage_idle": float64(91.5),
+		"usage_busy": float64(8.5),
+		"count":      int64(42),
+	}, now)
+	metrics := make([]telegraf.Metric, 100)
+	for i := range metrics {
+		metrics[i] = m
+	}
+
+	s := GraphiteSerializer{
+		TagSupport: true,
+		Separator:  ".",
+	}
+	for i := 0; i < b.N; i++ {
+		s.SerializeBatch(metrics)
+	}
+}
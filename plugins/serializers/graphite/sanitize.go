@@ -0,0 +1,130 @@
+package graphite
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Character tables indexed by byte value, a true entry marks a character
+// which is copied to the output unchanged.
+var (
+	// allowedChars are the characters kept by the historical sanitization,
+	// in addition to unicode letters.
+	allowedChars = charTable("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-:._=")
+
+	// strictPathChars are the characters allowed in a Graphite metric path.
+	strictPathChars = charTable("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-:._")
+
+	// strictTagNameChars are the characters allowed in a Graphite tag name,
+	// printable ASCII except ";!^=".
+	strictTagNameChars = printableTable(";!^=")
+
+	// strictTagValueChars are the characters allowed in a Graphite tag value,
+	// printable ASCII except ";".
+	strictTagValueChars = printableTable(";")
+)
+
+func charTable(chars string) *[256]bool {
+	var t [256]bool
+	for i := 0; i < len(chars); i++ {
+		t[chars[i]] = true
+	}
+	return &t
+}
+
+func printableTable(except string) *[256]bool {
+	var t [256]bool
+	for c := '!'; c <= '~'; c++ {
+		t[c] = true
+	}
+	for i := 0; i < len(except); i++ {
+		t[except[i]] = false
+	}
+	return &t
+}
+
+// appendSanitized appends the value to dst using the historical rules: "/",
+// "@" and "*" are replaced by a hyphen, backslashes are dropped, ".." is
+// shortened to "." and any remaining character which is neither allowed nor
+// a unicode letter is replaced by an underscore.
+func appendSanitized(dst []byte, value []byte) []byte {
+	for i := 0; i < len(value); {
+		c := value[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRune(value[i:])
+			if r != utf8.RuneError && unicode.IsLetter(r) {
+				dst = append(dst, value[i:i+size]...)
+			} else {
+				dst = append(dst, '_')
+			}
+			i += size
+			continue
+		}
+
+		switch {
+		case c == '\\':
+		case c == '.' && i+1 < len(value) && value[i+1] == '.':
+			dst = append(dst, '.')
+			i++
+		case c == '/' || c == '@' || c == '*':
+			dst = append(dst, '-')
+		case allowedChars[c]:
+			dst = append(dst, c)
+		default:
+			dst = append(dst, '_')
+		}
+		i++
+	}
+	return dst
+}
+
+// appendStrict appends the value to dst, replacing each character, or each
+// multi-byte rune, missing from the table by an underscore.
+func appendStrict(dst []byte, value []byte, table *[256]bool) []byte {
+	for i := 0; i < len(value); {
+		c := value[i]
+		if c >= utf8.RuneSelf {
+			_, size := utf8.DecodeRune(value[i:])
+			dst = append(dst, '_')
+			i += size
+			continue
+		}
+
+		if table[c] {
+			dst = append(dst, c)
+		} else {
+			dst = append(dst, '_')
+		}
+		i++
+	}
+	return dst
+}
+
+// appendStrictPath appends a metric path to dst, dropping the empty nodes
+// which are not allowed by Graphite.
+func appendStrictPath(dst []byte, path []byte) []byte {
+	start := len(dst)
+	for i := 0; i < len(path); {
+		c := path[i]
+		switch {
+		case c >= utf8.RuneSelf:
+			_, size := utf8.DecodeRune(path[i:])
+			dst = append(dst, '_')
+			i += size
+			continue
+		case c == '.':
+			if len(dst) > start && dst[len(dst)-1] != '.' {
+				dst = append(dst, '.')
+			}
+		case strictPathChars[c]:
+			dst = append(dst, c)
+		default:
+			dst = append(dst, '_')
+		}
+		i++
+	}
+	if len(dst) > start && dst[len(dst)-1] == '.' {
+		dst = dst[:len(dst)-1]
+	}
+	return dst
+}
@@ -0,0 +1,228 @@
+package graphite
+
+import (
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+type partKind int
+
+const (
+	partMeasurement partKind = iota
+	partTags
+	partField
+	partTag
+	partLiteral
+)
+
+type templatePart struct {
+	kind partKind
+	// value is the tag key of a partTag or the text of a partLiteral.
+	value string
+}
+
+// template is a parsed template pattern, such as "host.tags.measurement.field".
+type template struct {
+	parts []templatePart
+	// tagKeys are the tags used by partTag parts, they are excluded from
+	// the partTags part.
+	tagKeys []string
+}
+
+func compileTemplate(pattern string) *template {
+	if pattern == "" {
+		pattern = DEFAULT_TEMPLATE
+	}
+
+	t := &template{}
+	var hasTags, hasField bool
+	for _, part := range strings.Split(pattern, ".") {
+		switch part {
+		case "measurement":
+			t.parts = append(t.parts, templatePart{kind: partMeasurement})
+		case "tags":
+			// Only the first tags part is replaced
+			if hasTags {
+				t.parts = append(t.parts, templatePart{kind: partLiteral, value: "TAGS"})
+				continue
+			}
+			hasTags = true
+			t.parts = append(t.parts, templatePart{kind: partTags})
+		case "field":
+			// Only the first field part is replaced
+			if hasField {
+				t.parts = append(t.parts, templatePart{kind: partLiteral, value: "FIELDNAME"})
+				continue
+			}
+			hasField = true
+			t.parts = append(t.parts, templatePart{kind: partField})
+		default:
+			// A tag can only be used once
+			if containsString(t.tagKeys, part) {
+				continue
+			}
+			t.tagKeys = append(t.tagKeys, part)
+			t.parts = append(t.parts, templatePart{kind: partTag, value: part})
+		}
+	}
+	return t
+}
+
+// appendBucket appends the bucket of the metric to dst, leaving out the field
+// name.  It returns the position in dst at which the field name belongs, or
+// -1 if the template has no field part, and false if the bucket is empty.
+func (t *template) appendBucket(dst []byte, metric telegraf.Metric, prefix string) ([]byte, int, bool) {
+	if prefix != "" {
+		dst = append(dst, prefix...)
+	}
+
+	fieldPos := -1
+	n := 0
+	for _, part := range t.parts {
+		var value string
+		if part.kind == partTag {
+			var ok bool
+			if value, ok = metric.GetTag(part.value); !ok {
+				continue
+			}
+		}
+
+		if n > 0 || prefix != "" {
+			dst = append(dst, '.')
+		}
+		n++
+
+		switch part.kind {
+		case partMeasurement:
+			dst = append(dst, metric.Name()...)
+		case partTags:
+			first := true
+			for _, tag := range metric.TagList() {
+				if containsString(t.tagKeys, tag.Key) {
+					continue
+				}
+				if !first {
+					dst = append(dst, '.')
+				}
+				first = false
+				dst = appendReplaceDots(dst, tag.Value)
+			}
+		case partField:
+			fieldPos = len(dst)
+		case partTag:
+			dst = appendReplaceDots(dst, value)
+		case partLiteral:
+			dst = append(dst, part.value...)
+		}
+	}
+	return dst, fieldPos, n > 0
+}
+
+// insertField appends the bucket with the field name inserted at fieldPos.
+// The field name "value" is left out together with its separator.
+func insertField(dst []byte, bucket []byte, fieldPos int, fieldName string) []byte {
+	if fieldPos < 0 {
+		return append(dst, bucket...)
+	}
+
+	before, after := bucket[:fieldPos], bucket[fieldPos:]
+	if fieldName != "value" {
+		dst = append(dst, before...)
+		dst = append(dst, fieldName...)
+		return append(dst, after...)
+	}
+
+	switch {
+	case len(before) > 0:
+		dst = append(dst, before[:len(before)-1]...)
+		return append(dst, after...)
+	case len(after) > 0:
+		return append(dst, after[1:]...)
+	default:
+		return append(dst, "FIELDNAME"...)
+	}
+}
+
+func appendReplaceDots(dst []byte, value string) []byte {
+	for i := 0; i < len(value); i++ {
+		if value[i] == '.' {
+			dst = append(dst, '_')
+		} else {
+			dst = append(dst, value[i])
+		}
+	}
+	return dst
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// tagList holds the sanitized "key=value" pairs of a metric in tag support
+// mode.
+type tagList struct {
+	buf     []byte
+	scratch []byte
+	spans   [][2]int
+}
+
+func (l *tagList) reset() {
+	l.buf = l.buf[:0]
+	l.spans = l.spans[:0]
+}
+
+func (l *tagList) add(key, value string, strict bool) {
+	// The tag name is reserved by Graphite for the metric path
+	if key == "name" {
+		key = "_name"
+	}
+
+	start := len(l.buf)
+	if !strict {
+		l.scratch = append(l.scratch[:0], key...)
+		l.buf = appendSanitized(l.buf, l.scratch)
+		l.buf = append(l.buf, '=')
+		l.scratch = append(l.scratch[:0], value...)
+		l.buf = appendSanitized(l.buf, l.scratch)
+		l.spans = append(l.spans, [2]int{start, len(l.buf)})
+		return
+	}
+
+	// Tag values may not start with a tilde
+	value = strings.TrimLeft(value, "~")
+	if key == "" || value == "" {
+		return
+	}
+	l.scratch = append(l.scratch[:0], key...)
+	l.buf = appendStrict(l.buf, l.scratch, strictTagNameChars)
+	l.buf = append(l.buf, '=')
+	l.scratch = append(l.scratch[:0], value...)
+	l.buf = appendStrict(l.buf, l.scratch, strictTagValueChars)
+	l.spans = append(l.spans, [2]int{start, len(l.buf)})
+}
+
+func (l *tagList) appendTo(dst []byte) []byte {
+	for _, s := range l.spans {
+		dst = append(dst, ';')
+		dst = append(dst, l.buf[s[0]:s[1]]...)
+	}
+	return dst
+}
+
+func (l *tagList) Len() int {
+	return len(l.spans)
+}
+
+func (l *tagList) Less(i, j int) bool {
+	return string(l.buf[l.spans[i][0]:l.spans[i][1]]) < string(l.buf[l.spans[j][0]:l.spans[j][1]])
+}
+
+func (l *tagList) Swap(i, j int) {
+	l.spans[i], l.spans[j] = l.spans[j], l.spans[i]
+}
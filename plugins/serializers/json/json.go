@@ -2,19 +2,94 @@ package json
 
 import (
 	"encoding/json"
+	"fmt"
 	"math"
 	"time"
 
 	"github.com/influxdata/telegraf"
 )
 
+const (
+	// BatchFormatObject wraps the metrics of a batch in an object.
+	BatchFormatObject = "object"
+	// BatchFormatArray writes the metrics of a batch as a bare array.
+	BatchFormatArray = "array"
+)
+
+type FormatConfig struct {
+	// TimestampUnits is the resolution of numeric timestamps.
+	TimestampUnits time.Duration
+
+	// TimestampFormat is either empty for a numeric timestamp in
+	// TimestampUnits, one of "unix", "unix_ms", "unix_us" or "unix_ns", or a
+	// Go reference time layout used to write the timestamp as a string.
+	TimestampFormat string
+
+	// FlattenTags and FlattenFields move the tags and fields into the metric
+	// object instead of nesting them in the "tags" and "fields" objects.
+	FlattenTags   bool
+	FlattenFields bool
+
+	// Rename maps the keys of the metric object, including tag and field
+	// keys, to the names written.
+	Rename map[string]string
+
+	// BatchFormat is either BatchFormatObject or BatchFormatArray.
+	BatchFormat string
+
+	// BatchKey is the key holding the metrics in an object batch.
+	BatchKey string
+
+	// BatchEnvelope are additional static members of an object batch.
+	BatchEnvelope map[string]string
+}
+
 type serializer struct {
 	TimestampUnits time.Duration
+
+	config FormatConfig
 }
 
 func NewSerializer(timestampUnits time.Duration) (*serializer, error) {
+	return NewSerializerWithConfig(FormatConfig{TimestampUnits: timestampUnits})
+}
+
+func NewSerializerWithConfig(config FormatConfig) (*serializer, error) {
+	switch config.TimestampFormat {
+	case "":
+	case "unix":
+		config.TimestampUnits = time.Second
+	case "unix_ms":
+		config.TimestampUnits = time.Millisecond
+	case "unix_us":
+		config.TimestampUnits = time.Microsecond
+	case "unix_ns":
+		config.TimestampUnits = time.Nanosecond
+	}
+
+	if config.BatchFormat == "" {
+		config.BatchFormat = BatchFormatObject
+	}
+	if config.BatchKey == "" {
+		config.BatchKey = "metrics"
+	}
+
+	switch config.BatchFormat {
+	case BatchFormatObject:
+		if _, ok := config.BatchEnvelope[config.BatchKey]; ok {
+			return nil, fmt.Errorf("batch envelope cannot contain the batch key %q", config.BatchKey)
+		}
+	case BatchFormatArray:
+		if len(config.BatchEnvelope) > 0 {
+			return nil, fmt.Errorf("batch envelope requires the %q batch format", BatchFormatObject)
+		}
+	default:
+		return nil, fmt.Errorf("unknown batch format %q", config.BatchFormat)
+	}
+
 	s := &serializer{
-		TimestampUnits: truncateDuration(timestampUnits),
+		TimestampUnits: truncateDuration(config.TimestampUnits),
+		config:         config,
 	}
 	return s, nil
 }
@@ -37,8 +112,14 @@ func (s *serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 		objects = append(objects, m)
 	}
 
-	obj := map[string]interface{}{
-		"metrics": objects,
+	var obj interface{} = objects
+	if s.config.BatchFormat == BatchFormatObject {
+		envelope := make(map[string]interface{}, len(s.config.BatchEnvelope)+1)
+		for k, v := range s.config.BatchEnvelope {
+			envelope[k] = v
+		}
+		envelope[s.config.BatchKey] = objects
+		obj = envelope
 	}
 
 	serialized, err := json.Marshal(obj)
@@ -51,13 +132,23 @@ func (s *serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 func (s *serializer) createObject(metric telegraf.Metric) map[string]interface{} {
 	m := make(map[string]interface{}, 4)
 
-	tags := make(map[string]string, len(metric.TagList()))
-	for _, tag := range metric.TagList() {
-		tags[tag.Key] = tag.Value
+	if s.config.FlattenTags {
+		for _, tag := range metric.TagList() {
+			m[s.key(tag.Key)] = tag.Value
+		}
+	} else {
+		tags := make(map[string]string, len(metric.TagList()))
+		for _, tag := range metric.TagList() {
+			tags[s.key(tag.Key)] = tag.Value
+		}
+		m[s.key("tags")] = tags
 	}
-	m["tags"] = tags
 
-	fields := make(map[string]interface{}, len(metric.FieldList()))
+	fields := m
+	if !s.config.FlattenFields {
+		fields = make(map[string]interface{}, len(metric.FieldList()))
+		m[s.key("fields")] = fields
+	}
 	for _, field := range metric.FieldList() {
 		switch fv := field.Value.(type) {
 		case float64:
@@ -66,15 +157,37 @@ func (s *serializer) createObject(metric telegraf.Metric) map[string]interface{}
 				continue
 			}
 		}
-		fields[field.Key] = field.Value
+		fields[s.key(field.Key)] = field.Value
 	}
-	m["fields"] = fields
 
-	m["name"] = metric.Name()
-	m["timestamp"] = metric.Time().UnixNano() / int64(s.TimestampUnits)
+	m[s.key("name")] = metric.Name()
+	m[s.key("timestamp")] = s.timestamp(metric.Time())
 	return m
 }
 
+// StructuredFields returns true, the arrays and maps of the fields are written
+// as JSON arrays and objects.
+func (s *serializer) StructuredFields() bool {
+	return true
+}
+
+// key returns the name written for the key of the metric object.
+func (s *serializer) key(k string) string {
+	if name, ok := s.config.Rename[k]; ok {
+		return name
+	}
+	return k
+}
+
+func (s *serializer) timestamp(t time.Time) interface{} {
+	switch s.config.TimestampFormat {
+	case "", "unix", "unix_ms", "unix_us", "unix_ns":
+		return t.UnixNano() / int64(s.TimestampUnits)
+	default:
+		return t.UTC().Format(s.config.TimestampFormat)
+	}
+}
+
 func truncateDuration(units time.Duration) time.Duration {
 	// Default precision is 1s
 	if units <= 0 {
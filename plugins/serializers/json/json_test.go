@@ -157,6 +157,20 @@ func TestSerializeMultiFields(t *testing.T) {
 	assert.Equal(t, string(expS), string(buf))
 }
 
+func TestSerializeStructuredFields(t *testing.T) {
+	m, err := metric.New("deploy", nil, map[string]interface{}{
+		"labels": []interface{}{"web", int64(2)},
+		"owner":  map[string]interface{}{"name": "ops"},
+	}, time.Unix(0, 0))
+	require.NoError(t, err)
+
+	s, _ := NewSerializer(0)
+	require.True(t, s.StructuredFields())
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, `{"fields":{"labels":["web",2],"owner":{"name":"ops"}},"name":"deploy","tags":{},"timestamp":0}`+"\n", string(buf))
+}
+
 func TestSerializeMetricWithEscapes(t *testing.T) {
 	now := time.Now()
 	tags := map[string]string{
@@ -233,3 +247,135 @@ func TestSerializeBatchSkipInfAllFields(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []byte(`{"metrics":[{"fields":{},"name":"cpu","tags":{},"timestamp":0}]}`), buf)
 }
+
+func TestSerializeWithConfig(t *testing.T) {
+	m := testutil.MustMetric(
+		"cpu",
+		map[string]string{
+			"host": "localhost",
+		},
+		map[string]interface{}{
+			"value": 42.0,
+		},
+		time.Unix(1525478795, 123456789),
+	)
+
+	tests := []struct {
+		name     string
+		config   FormatConfig
+		expected string
+	}{
+		{
+			name:     "flatten tags",
+			config:   FormatConfig{FlattenTags: true},
+			expected: `{"fields":{"value":42},"host":"localhost","name":"cpu","timestamp":1525478795}`,
+		},
+		{
+			name:     "flatten tags and fields",
+			config:   FormatConfig{FlattenTags: true, FlattenFields: true},
+			expected: `{"host":"localhost","name":"cpu","timestamp":1525478795,"value":42}`,
+		},
+		{
+			name: "rename keys",
+			config: FormatConfig{
+				FlattenFields: true,
+				Rename: map[string]string{
+					"name":      "measurement",
+					"tags":      "labels",
+					"timestamp": "time",
+					"value":     "usage",
+				},
+			},
+			expected: `{"labels":{"host":"localhost"},"measurement":"cpu","time":1525478795,"usage":42}`,
+		},
+		{
+			name:     "unix_ms timestamp",
+			config:   FormatConfig{TimestampFormat: "unix_ms"},
+			expected: `{"fields":{"value":42},"name":"cpu","tags":{"host":"localhost"},"timestamp":1525478795123}`,
+		},
+		{
+			name:     "layout timestamp",
+			config:   FormatConfig{TimestampFormat: time.RFC3339Nano},
+			expected: `{"fields":{"value":42},"name":"cpu","tags":{"host":"localhost"},"timestamp":"2018-05-05T00:06:35.123456789Z"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSerializerWithConfig(tt.config)
+			require.NoError(t, err)
+			buf, err := s.Serialize(m)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected+"\n", string(buf))
+		})
+	}
+}
+
+func TestSerializeBatchWithConfig(t *testing.T) {
+	m := testutil.MustMetric(
+		"cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"value": 42.0,
+		},
+		time.Unix(0, 0),
+	)
+	metrics := []telegraf.Metric{m, m}
+
+	tests := []struct {
+		name     string
+		config   FormatConfig
+		expected string
+	}{
+		{
+			name:     "array",
+			config:   FormatConfig{BatchFormat: BatchFormatArray},
+			expected: `[{"fields":{"value":42},"name":"cpu","tags":{},"timestamp":0},{"fields":{"value":42},"name":"cpu","tags":{},"timestamp":0}]`,
+		},
+		{
+			name: "envelope",
+			config: FormatConfig{
+				BatchKey:      "data",
+				BatchEnvelope: map[string]string{"source": "telegraf"},
+			},
+			expected: `{"data":[{"fields":{"value":42},"name":"cpu","tags":{},"timestamp":0},{"fields":{"value":42},"name":"cpu","tags":{},"timestamp":0}],"source":"telegraf"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSerializerWithConfig(tt.config)
+			require.NoError(t, err)
+			buf, err := s.SerializeBatch(metrics)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(buf))
+		})
+	}
+}
+
+func TestNewSerializerWithConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config FormatConfig
+	}{
+		{
+			name:   "unknown batch format",
+			config: FormatConfig{BatchFormat: "lines"},
+		},
+		{
+			name: "envelope with array",
+			config: FormatConfig{
+				BatchFormat:   BatchFormatArray,
+				BatchEnvelope: map[string]string{"source": "telegraf"},
+			},
+		},
+		{
+			name:   "envelope contains batch key",
+			config: FormatConfig{BatchEnvelope: map[string]string{"metrics": "x"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSerializerWithConfig(tt.config)
+			require.Error(t, err)
+		})
+	}
+}
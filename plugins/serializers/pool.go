@@ -0,0 +1,129 @@
+package serializers
+
+import (
+	"sync"
+
+	"github.com/influxdata/telegraf"
+)
+
+// minChunkSize is the minimum number of metrics serialized by a worker, the
+// batches smaller than two chunks are serialized without starting workers.
+const minChunkSize = 64
+
+// Pool serializes the metrics of a batch concurrently, each worker using its
+// own serializer.  The batch is split in contiguous chunks and the results
+// are returned in the order of the metrics, preserving the ordering of each
+// series.
+//
+// A Pool is not safe for concurrent use, an output calls it from Write.
+type Pool struct {
+	serializers []Serializer
+}
+
+// NewPool returns a Pool of the given number of workers, creating the
+// serializer of each worker with fn.  There is always at least one worker.
+func NewPool(workers int, fn SerializerFunc) (*Pool, error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	p := &Pool{serializers: make([]Serializer, 0, workers)}
+	for i := 0; i < workers; i++ {
+		serializer, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		p.serializers = append(p.serializers, serializer)
+	}
+	return p, nil
+}
+
+// Workers returns the number of workers of the pool.
+func (p *Pool) Workers() int {
+	return len(p.serializers)
+}
+
+// Serialize serializes each metric on its own.  The serialized metric and the
+// error of each metric are at its index in the returned slices.
+func (p *Pool) Serialize(metrics []telegraf.Metric) ([][]byte, []error) {
+	octets := make([][]byte, len(metrics))
+	errs := make([]error, len(metrics))
+	p.run(len(metrics), func(_ int, s Serializer, start, end int) {
+		for i := start; i < end; i++ {
+			octets[i], errs[i] = s.Serialize(metrics[i])
+		}
+	})
+	return octets, errs
+}
+
+// SerializeBatch serializes a chunk of the metrics per worker as a batch,
+// applying encode, such as a compression, to each serialized chunk if it is
+// not nil.  The chunks are returned in the order of the metrics, so writing
+// them one after the other is the same as writing the whole batch.
+func (p *Pool) SerializeBatch(metrics []telegraf.Metric, encode func([]byte) ([]byte, error)) ([][]byte, error) {
+	count := len(p.split(len(metrics)))
+	octets := make([][]byte, count)
+	errs := make([]error, count)
+	p.run(len(metrics), func(i int, s Serializer, start, end int) {
+		buf, err := s.SerializeBatch(metrics[start:end])
+		if err == nil && encode != nil {
+			buf, err = encode(buf)
+		}
+		octets[i], errs[i] = buf, err
+	})
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return octets, nil
+}
+
+// run calls fn with the index, the serializer of its worker and the bounds of
+// each chunk of the n metrics, and waits for the workers to finish.
+func (p *Pool) run(n int, fn func(i int, s Serializer, start, end int)) {
+	chunks := p.split(n)
+	if len(chunks) == 1 {
+		fn(0, p.serializers[0], 0, n)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i, start := range chunks {
+		end := n
+		if i+1 < len(chunks) {
+			end = chunks[i+1]
+		}
+
+		wg.Add(1)
+		go func(i, start, end int) {
+			defer wg.Done()
+			fn(i, p.serializers[i], start, end)
+		}(i, start, end)
+	}
+	wg.Wait()
+}
+
+// split returns the start of each chunk of the n metrics, there is at most a
+// chunk per worker and every chunk but the last holds the same number of
+// metrics.
+func (p *Pool) split(n int) []int {
+	count := n / minChunkSize
+	if count > len(p.serializers) {
+		count = len(p.serializers)
+	}
+	if count < 1 {
+		count = 1
+	}
+
+	size := (n + count - 1) / count
+	if size < 1 {
+		size = 1
+	}
+	chunks := make([]int, 0, count)
+	for start := 0; start < n || len(chunks) == 0; start += size {
+		chunks = append(chunks, start)
+	}
+	return chunks
+}
@@ -0,0 +1,112 @@
+package serializers
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newStringSerializer() (Serializer, error) {
+	return &stringSerializer{}, nil
+}
+
+func poolMetrics(n int) []telegraf.Metric {
+	metrics := make([]telegraf.Metric, 0, n)
+	for i := 0; i < n; i++ {
+		metrics = append(metrics, testutil.MustMetric(strconv.Itoa(i),
+			map[string]string{}, map[string]interface{}{"value": i}, time.Unix(0, 0)))
+	}
+	return metrics
+}
+
+func TestNewPool(t *testing.T) {
+	pool, err := NewPool(0, newStringSerializer)
+	require.NoError(t, err)
+	require.Equal(t, 1, pool.Workers())
+
+	pool, err = NewPool(4, newStringSerializer)
+	require.NoError(t, err)
+	require.Equal(t, 4, pool.Workers())
+
+	_, err = NewPool(4, func() (Serializer, error) {
+		return nil, errors.New("invalid config")
+	})
+	require.Error(t, err)
+}
+
+func TestPoolSplit(t *testing.T) {
+	pool, err := NewPool(4, newStringSerializer)
+	require.NoError(t, err)
+
+	require.Equal(t, []int{0}, pool.split(0))
+	require.Equal(t, []int{0}, pool.split(minChunkSize*2-1))
+	require.Equal(t, []int{0, minChunkSize}, pool.split(minChunkSize*2))
+	require.Equal(t, []int{0, 250, 500, 750}, pool.split(1000))
+	require.Equal(t, []int{0, 251, 502, 753}, pool.split(1001))
+}
+
+func TestPoolSerialize(t *testing.T) {
+	pool, err := NewPool(4, newStringSerializer)
+	require.NoError(t, err)
+
+	metrics := poolMetrics(1000)
+	metrics[500].AddTag("bad", "true")
+
+	octets, errs := pool.Serialize(metrics)
+	require.Len(t, octets, len(metrics))
+	require.Len(t, errs, len(metrics))
+	for i := range metrics {
+		if i == 500 {
+			require.Error(t, errs[i])
+			continue
+		}
+		require.NoError(t, errs[i])
+		require.Equal(t, strconv.Itoa(i)+"\n", string(octets[i]))
+	}
+}
+
+func TestPoolSerializeBatch(t *testing.T) {
+	pool, err := NewPool(4, newStringSerializer)
+	require.NoError(t, err)
+
+	metrics := poolMetrics(1000)
+	expected, err := (&stringSerializer{}).SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	chunks, err := pool.SerializeBatch(metrics, nil)
+	require.NoError(t, err)
+	require.Len(t, chunks, 4)
+	require.Equal(t, string(expected), string(bytes.Join(chunks, nil)))
+
+	chunks, err = pool.SerializeBatch(metrics, func(data []byte) ([]byte, error) {
+		return append([]byte("|"), data...), nil
+	})
+	require.NoError(t, err)
+	require.Len(t, chunks, 4)
+	for _, chunk := range chunks {
+		require.Equal(t, byte('|'), chunk[0])
+	}
+
+	metrics[999].AddTag("bad", "true")
+	_, err = pool.SerializeBatch(metrics, nil)
+	require.Error(t, err)
+}
+
+func BenchmarkPoolSerializeBatch(b *testing.B) {
+	pool, err := NewPool(4, func() (Serializer, error) {
+		return NewInfluxSerializer()
+	})
+	require.NoError(b, err)
+
+	metrics := poolMetrics(5000)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		pool.SerializeBatch(metrics, nil)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/gogo/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/influxdata/telegraf"
 	dto "github.com/prometheus/client_model/go"
 )
@@ -25,9 +26,11 @@ type Metric struct {
 	Labels    []LabelPair
 	Time      time.Time
 	AddTime   time.Time
+	Created   time.Time
 	Scaler    *Scaler
 	Histogram *Histogram
 	Summary   *Summary
+	Exemplar  *Exemplar
 }
 
 type LabelPair struct {
@@ -39,6 +42,13 @@ type Scaler struct {
 	Value float64
 }
 
+// Exemplar references a trace that contributed to a counter value.
+type Exemplar struct {
+	TraceID string
+	Value   float64
+	Time    time.Time
+}
+
 type Bucket struct {
 	Bound float64
 	Count uint64
@@ -124,6 +134,11 @@ func hasLabel(name string, labels []LabelPair) bool {
 func (c *Collection) createLabels(metric telegraf.Metric) []LabelPair {
 	labels := make([]LabelPair, 0, len(metric.TagList()))
 	for _, tag := range metric.TagList() {
+		// The trace id is reported in the exemplar instead of a label.
+		if c.config.ExemplarTraceIDTag != "" && tag.Key == c.config.ExemplarTraceIDTag {
+			continue
+		}
+
 		// Ignore special tags for histogram and summary types.
 		switch metric.Type() {
 		case telegraf.Histogram:
@@ -218,7 +233,39 @@ func (c *Collection) Add(metric telegraf.Metric, now time.Time) {
 
 		switch metric.Type() {
 		case telegraf.Counter:
-			fallthrough
+			value, ok := SampleValue(field.Value)
+			if !ok {
+				continue
+			}
+
+			// The series is created again when the counter is reset.
+			created := now
+			var exemplar *Exemplar
+			if m != nil {
+				if value >= m.Scaler.Value {
+					created = m.Created
+				}
+				exemplar = m.Exemplar
+			}
+
+			if traceID, ok := c.traceID(metric); ok {
+				exemplar = &Exemplar{
+					TraceID: traceID,
+					Value:   value,
+					Time:    metric.Time(),
+				}
+			}
+
+			m = &Metric{
+				Labels:   labels,
+				Time:     metric.Time(),
+				AddTime:  now,
+				Created:  created,
+				Scaler:   &Scaler{Value: value},
+				Exemplar: exemplar,
+			}
+
+			entry.Metrics[metricKey] = m
 		case telegraf.Gauge:
 			fallthrough
 		case telegraf.Untyped:
@@ -241,9 +288,12 @@ func (c *Collection) Add(metric telegraf.Metric, now time.Time) {
 					Labels:    labels,
 					Time:      metric.Time(),
 					AddTime:   now,
+					Created:   now,
 					Histogram: &Histogram{},
 				}
 			}
+			m.Time = metric.Time()
+			m.AddTime = now
 			switch {
 			case strings.HasSuffix(field.Key, "_bucket"):
 				le, ok := metric.GetTag("le")
@@ -277,6 +327,10 @@ func (c *Collection) Add(metric telegraf.Metric, now time.Time) {
 					continue
 				}
 
+				// The series is created again when the histogram is reset.
+				if count < m.Histogram.Count {
+					m.Created = now
+				}
 				m.Histogram.Count = count
 			default:
 				continue
@@ -289,9 +343,12 @@ func (c *Collection) Add(metric telegraf.Metric, now time.Time) {
 					Labels:  labels,
 					Time:    metric.Time(),
 					AddTime: now,
+					Created: now,
 					Summary: &Summary{},
 				}
 			}
+			m.Time = metric.Time()
+			m.AddTime = now
 			switch {
 			case strings.HasSuffix(field.Key, "_sum"):
 				sum, ok := SampleSum(field.Value)
@@ -306,6 +363,10 @@ func (c *Collection) Add(metric telegraf.Metric, now time.Time) {
 					continue
 				}
 
+				// The series is created again when the summary is reset.
+				if count < m.Summary.Count {
+					m.Created = now
+				}
 				m.Summary.Count = count
 			default:
 				quantileTag, ok := metric.GetTag("quantile")
@@ -333,6 +394,38 @@ func (c *Collection) Add(metric telegraf.Metric, now time.Time) {
 	}
 }
 
+func (c *Collection) traceID(metric telegraf.Metric) (string, bool) {
+	if c.config.ExemplarTraceIDTag == "" {
+		return "", false
+	}
+	traceID, ok := metric.GetTag(c.config.ExemplarTraceIDTag)
+	return traceID, ok && traceID != ""
+}
+
+// Created returns the time the series of the metric in the family returned
+// by GetProto was created.  The time is reset when a counter, histogram or
+// summary decreases, and is forgotten when the series expires.
+func (c *Collection) Created(family *dto.MetricFamily, metric *dto.Metric) (time.Time, bool) {
+	entry, ok := c.Entries[MetricFamily{
+		Name: family.GetName(),
+		Type: valueType(family.GetType()),
+	}]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	labels := make([]LabelPair, 0, len(metric.Label))
+	for _, label := range metric.Label {
+		labels = append(labels, LabelPair{Name: label.GetName(), Value: label.GetValue()})
+	}
+
+	m, ok := entry.Metrics[MakeMetricKey(labels)]
+	if !ok || m.Created.IsZero() {
+		return time.Time{}, false
+	}
+	return m.Created, true
+}
+
 func (c *Collection) Expire(now time.Time, age time.Duration) {
 	expireTime := now.Add(-age)
 	for _, entry := range c.Entries {
@@ -435,6 +528,21 @@ func (c *Collection) GetProto() []*dto.MetricFamily {
 				m.Gauge = &dto.Gauge{Value: proto.Float64(metric.Scaler.Value)}
 			case telegraf.Counter:
 				m.Counter = &dto.Counter{Value: proto.Float64(metric.Scaler.Value)}
+				if metric.Exemplar != nil {
+					m.Counter.Exemplar = &dto.Exemplar{
+						Label: []*dto.LabelPair{
+							{
+								Name:  proto.String("trace_id"),
+								Value: proto.String(metric.Exemplar.TraceID),
+							},
+						},
+						Value: proto.Float64(metric.Exemplar.Value),
+						Timestamp: &timestamp.Timestamp{
+							Seconds: metric.Exemplar.Time.Unix(),
+							Nanos:   int32(metric.Exemplar.Time.Nanosecond()),
+						},
+					}
+				}
 			case telegraf.Untyped:
 				m.Untyped = &dto.Untyped{Value: proto.Float64(metric.Scaler.Value)}
 			case telegraf.Histogram:
@@ -425,3 +425,100 @@ func TestCollectionExpire(t *testing.T) {
 		})
 	}
 }
+
+func TestCollectionHistogramUpdateNotExpired(t *testing.T) {
+	c := NewCollection(FormatConfig{})
+	c.Add(testutil.MustMetric(
+		"prometheus",
+		map[string]string{"le": "+Inf"},
+		map[string]interface{}{"http_request_duration_seconds_bucket": 10.0},
+		time.Unix(0, 0),
+		telegraf.Histogram,
+	), time.Unix(0, 0))
+	c.Add(testutil.MustMetric(
+		"prometheus",
+		map[string]string{"le": "+Inf"},
+		map[string]interface{}{"http_request_duration_seconds_bucket": 20.0},
+		time.Unix(15, 0),
+		telegraf.Histogram,
+	), time.Unix(15, 0))
+	c.Expire(time.Unix(20, 0), 10*time.Second)
+
+	actual := c.GetProto()
+	require.Len(t, actual, 1)
+	require.Equal(t, uint64(20), actual[0].Metric[0].Histogram.Bucket[0].GetCumulativeCount())
+}
+
+func TestCollectionSummaryUpdateNotExpired(t *testing.T) {
+	c := NewCollection(FormatConfig{})
+	c.Add(testutil.MustMetric(
+		"prometheus",
+		map[string]string{"quantile": "0.5"},
+		map[string]interface{}{"rpc_duration_seconds": 10.0},
+		time.Unix(0, 0),
+		telegraf.Summary,
+	), time.Unix(0, 0))
+	c.Add(testutil.MustMetric(
+		"prometheus",
+		map[string]string{"quantile": "0.5"},
+		map[string]interface{}{"rpc_duration_seconds": 20.0},
+		time.Unix(15, 0),
+		telegraf.Summary,
+	), time.Unix(15, 0))
+	c.Expire(time.Unix(20, 0), 10*time.Second)
+
+	actual := c.GetProto()
+	require.Len(t, actual, 1)
+	require.Equal(t, 20.0, actual[0].Metric[0].Summary.Quantile[0].GetValue())
+}
+
+func TestCollectionCounterCreated(t *testing.T) {
+	c := NewCollection(FormatConfig{ExemplarTraceIDTag: "trace_id"})
+
+	add := func(value float64, traceID string, now time.Time) {
+		tags := map[string]string{"host": "example.org"}
+		if traceID != "" {
+			tags["trace_id"] = traceID
+		}
+		c.Add(testutil.MustMetric(
+			"http",
+			tags,
+			map[string]interface{}{"requests_total": value},
+			now,
+			telegraf.Counter,
+		), now)
+	}
+
+	created := func() time.Time {
+		mfs := c.GetProto()
+		require.Len(t, mfs, 1)
+		require.Len(t, mfs[0].Metric, 1)
+		ts, ok := c.Created(mfs[0], mfs[0].Metric[0])
+		require.True(t, ok)
+		return ts
+	}
+
+	add(1, "abc", time.Unix(10, 0))
+	require.Equal(t, time.Unix(10, 0), created())
+
+	// The exemplar is kept until a new trace id is seen.
+	add(2, "", time.Unix(20, 0))
+	require.Equal(t, time.Unix(10, 0), created())
+	exemplar := c.GetProto()[0].Metric[0].Counter.Exemplar
+	require.Equal(t, "abc", exemplar.Label[0].GetValue())
+	require.Equal(t, 1.0, exemplar.GetValue())
+	require.Equal(t, []*dto.LabelPair{
+		{Name: proto.String("host"), Value: proto.String("example.org")},
+	}, c.GetProto()[0].Metric[0].Label)
+
+	// A counter reset creates the series again.
+	add(0, "def", time.Unix(30, 0))
+	require.Equal(t, time.Unix(30, 0), created())
+	require.Equal(t, "def", c.GetProto()[0].Metric[0].Counter.Exemplar.Label[0].GetValue())
+
+	// An expired series is created again when it reappears.
+	c.Expire(time.Unix(100, 0), 10*time.Second)
+	require.Len(t, c.GetProto(), 0)
+	add(5, "", time.Unix(110, 0))
+	require.Equal(t, time.Unix(110, 0), created())
+}
@@ -159,6 +159,21 @@ func MetricType(valueType telegraf.ValueType) *dto.MetricType {
 	}
 }
 
+func valueType(metricType dto.MetricType) telegraf.ValueType {
+	switch metricType {
+	case dto.MetricType_COUNTER:
+		return telegraf.Counter
+	case dto.MetricType_GAUGE:
+		return telegraf.Gauge
+	case dto.MetricType_SUMMARY:
+		return telegraf.Summary
+	case dto.MetricType_HISTOGRAM:
+		return telegraf.Histogram
+	default:
+		return telegraf.Untyped
+	}
+}
+
 // SampleValue converts a field value into a value suitable for a simple sample value.
 func SampleValue(value interface{}) (float64, bool) {
 	switch v := value.(type) {
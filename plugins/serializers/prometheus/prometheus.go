@@ -36,6 +36,10 @@ type FormatConfig struct {
 	TimestampExport TimestampExport
 	MetricSortOrder MetricSortOrder
 	StringHandling  StringHandling
+
+	// ExemplarTraceIDTag is the tag holding the trace id reported in the
+	// exemplar of counters.  The tag is not added as a label.
+	ExemplarTraceIDTag string
 }
 
 type Serializer struct {
@@ -22,6 +22,16 @@ type SerializerOutput interface {
 	SetSerializer(serializer Serializer)
 }
 
+// SerializerFunc returns a new serializer.
+type SerializerFunc func() (Serializer, error)
+
+// SerializerFuncOutput is an interface for output plugins that need several
+// serializers, such as to serialize metrics concurrently.
+type SerializerFuncOutput interface {
+	// SetSerializerFunc sets the function creating the serializers.
+	SetSerializerFunc(fn SerializerFunc)
+}
+
 // Serializer is an interface defining functions that a serializer plugin must
 // satisfy.
 //
@@ -42,6 +52,22 @@ type Serializer interface {
 	SerializeBatch(metrics []telegraf.Metric) ([]byte, error)
 }
 
+// StructuredSerializer is implemented by the serializers writing documents,
+// which can write arrays and maps as field values.
+type StructuredSerializer interface {
+	// StructuredFields returns true if the serializer writes the arrays and
+	// maps of the fields as such.
+	StructuredFields() bool
+}
+
+// StructuredFields returns true if the serializer writes the arrays and maps
+// of the fields as such, outputs using it can implement the
+// telegraf.StructuredOutput interface with it.
+func StructuredFields(serializer Serializer) bool {
+	s, ok := serializer.(StructuredSerializer)
+	return ok && s.StructuredFields()
+}
+
 // Config is a struct that covers the data types needed for all serializer types,
 // and can be used to instantiate _any_ of the serializers.
 type Config struct {
@@ -54,6 +80,9 @@ type Config struct {
 	// Character for separating metric name and field for Graphite tags
 	GraphiteSeparator string `toml:"graphite_separator"`
 
+	// Replace all characters not allowed by Graphite in paths and tags
+	GraphiteStrictSanitize bool `toml:"graphite_strict_sanitize"`
+
 	// Maximum line length in bytes; influx format only
 	InfluxMaxLineBytes int `toml:"influx_max_line_bytes"`
 
@@ -77,6 +106,26 @@ type Config struct {
 	// Timestamp units to use for JSON formatted output
 	TimestampUnits time.Duration `toml:"timestamp_units"`
 
+	// Timestamp format for JSON formatted output; unix, unix_ms, unix_us,
+	// unix_ns or a Go reference time layout
+	JSONTimestampFormat string `toml:"json_timestamp_format"`
+
+	// Move tags and fields into the JSON metric object
+	JSONFlattenTags   bool `toml:"json_flatten_tags"`
+	JSONFlattenFields bool `toml:"json_flatten_fields"`
+
+	// Rename keys of the JSON metric object
+	JSONRename map[string]string `toml:"json_rename"`
+
+	// Format of JSON batches; object or array
+	JSONBatchFormat string `toml:"json_batch_format"`
+
+	// Key holding the metrics in JSON object batches
+	JSONBatchKey string `toml:"json_batch_key"`
+
+	// Additional members of JSON object batches
+	JSONBatchEnvelope map[string]string `toml:"json_batch_envelope"`
+
 	// Include HEC routing fields for splunkmetric output
 	HecRouting bool `toml:"hec_routing"`
 
@@ -110,9 +159,9 @@ func NewSerializer(config *Config) (Serializer, error) {
 	case "influx":
 		serializer, err = NewInfluxSerializerConfig(config)
 	case "graphite":
-		serializer, err = NewGraphiteSerializer(config.Prefix, config.Template, config.GraphiteTagSupport, config.GraphiteSeparator, config.Templates)
+		serializer, err = NewGraphiteSerializer(config.Prefix, config.Template, config.GraphiteTagSupport, config.GraphiteStrictSanitize, config.GraphiteSeparator, config.Templates)
 	case "json":
-		serializer, err = NewJsonSerializer(config.TimestampUnits)
+		serializer, err = NewJsonSerializer(config)
 	case "splunkmetric":
 		serializer, err = NewSplunkmetricSerializer(config.HecRouting, config.SplunkmetricMultiMetric)
 	case "nowmetric":
@@ -156,8 +205,17 @@ func NewWavefrontSerializer(prefix string, useStrict bool, sourceOverride []stri
 	return wavefront.NewSerializer(prefix, useStrict, sourceOverride)
 }
 
-func NewJsonSerializer(timestampUnits time.Duration) (Serializer, error) {
-	return json.NewSerializer(timestampUnits)
+func NewJsonSerializer(config *Config) (Serializer, error) {
+	return json.NewSerializerWithConfig(json.FormatConfig{
+		TimestampUnits:  config.TimestampUnits,
+		TimestampFormat: config.JSONTimestampFormat,
+		FlattenTags:     config.JSONFlattenTags,
+		FlattenFields:   config.JSONFlattenFields,
+		Rename:          config.JSONRename,
+		BatchFormat:     config.JSONBatchFormat,
+		BatchKey:        config.JSONBatchKey,
+		BatchEnvelope:   config.JSONBatchEnvelope,
+	})
 }
 
 func NewCarbon2Serializer() (Serializer, error) {
@@ -194,7 +252,7 @@ func NewInfluxSerializer() (Serializer, error) {
 	return influx.NewSerializer(), nil
 }
 
-func NewGraphiteSerializer(prefix, template string, tag_support bool, separator string, templates []string) (Serializer, error) {
+func NewGraphiteSerializer(prefix, template string, tag_support bool, strict_sanitize bool, separator string, templates []string) (Serializer, error) {
 	graphiteTemplates, defaultTemplate, err := graphite.InitGraphiteTemplates(templates)
 
 	if err != nil {
@@ -209,11 +267,16 @@ func NewGraphiteSerializer(prefix, template string, tag_support bool, separator
 		separator = "."
 	}
 
-	return &graphite.GraphiteSerializer{
-		Prefix:     prefix,
-		Template:   template,
-		TagSupport: tag_support,
-		Separator:  separator,
-		Templates:  graphiteTemplates,
-	}, nil
+	s := &graphite.GraphiteSerializer{
+		Prefix:         prefix,
+		Template:       template,
+		TagSupport:     tag_support,
+		Separator:      separator,
+		Templates:      graphiteTemplates,
+		StrictSanitize: strict_sanitize,
+	}
+	if err := s.Init(); err != nil {
+		return nil, err
+	}
+	return s, nil
 }
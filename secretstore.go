@@ -0,0 +1,11 @@
+package telegraf
+
+// SecretStore is a secret store plugin interface for resolving the secrets
+// referenced in the configuration, such as passwords and tokens.
+type SecretStore interface {
+	PluginDescriber
+
+	// Get returns the value of the secret identified by key.  The format of
+	// the key depends on the store.
+	Get(key string) (string, error)
+}
@@ -0,0 +1,285 @@
+// +build integration
+
+package testutil
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Container describes a docker container started for an integration test.
+// The tests using it are built with the integration build tag and need the
+// docker command.
+type Container struct {
+	Image string
+	// Ports exposed by the container, such as "5432/tcp".  Each is mapped to
+	// a random port of the host unless it is mapped in HostPorts.
+	Ports []string
+	// HostPorts maps exposed ports to fixed ports of the host, for services
+	// advertising their address to clients.
+	HostPorts map[string]string
+	Env       map[string]string
+	Cmd       []string
+	// WaitFor is the readiness probe of the container.  Start returns once it
+	// succeeds.
+	WaitFor WaitStrategy
+	// StartupTimeout is the time to wait for the container to be ready,
+	// 1 minute if it is not set.
+	StartupTimeout time.Duration
+
+	id    string
+	host  string
+	ports map[string]string
+}
+
+// WaitStrategy probes whether a started container is ready.
+type WaitStrategy func(c *Container) error
+
+// Start runs the container and waits for it to be ready.  The caller must
+// remove the container with Terminate, the container is removed by Start if
+// it fails.
+func (c *Container) Start(t *testing.T) {
+	t.Helper()
+
+	args := []string{"run", "--detach"}
+	for _, port := range c.Ports {
+		if hostPort, ok := c.HostPorts[port]; ok {
+			args = append(args, "--publish", hostPort+":"+port)
+		} else {
+			args = append(args, "--publish", port)
+		}
+	}
+	for k, v := range c.Env {
+		args = append(args, "--env", k+"="+v)
+	}
+	args = append(args, c.Image)
+	args = append(args, c.Cmd...)
+
+	id, err := docker(args...)
+	if err != nil {
+		t.Fatalf("starting container %s: %v", c.Image, err)
+	}
+	c.id = id
+
+	ready := false
+	defer func() {
+		if !ready {
+			c.Terminate(t)
+		}
+	}()
+
+	c.host = GetLocalHost()
+	c.ports = make(map[string]string, len(c.Ports))
+	for _, port := range c.Ports {
+		out, err := docker("port", c.id, port)
+		if err != nil {
+			t.Fatalf("port %s of container %s: %v", port, c.Image, err)
+		}
+		// One line per address family, such as "0.0.0.0:32768"
+		line := strings.SplitN(out, "\n", 2)[0]
+		c.ports[port] = line[strings.LastIndex(line, ":")+1:]
+	}
+
+	if c.WaitFor == nil {
+		ready = true
+		return
+	}
+	timeout := c.StartupTimeout
+	if timeout == 0 {
+		timeout = time.Minute
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		err := c.WaitFor(c)
+		if err == nil {
+			ready = true
+			return
+		}
+		if time.Now().After(deadline) {
+			logs, _ := c.Logs()
+			t.Fatalf("container %s not ready after %s: %v\n%s", c.Image, timeout, err, logs)
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// Terminate removes the container and its volumes.
+func (c *Container) Terminate(t *testing.T) {
+	t.Helper()
+
+	if c.id == "" {
+		return
+	}
+	if _, err := docker("rm", "--force", "--volumes", c.id); err != nil {
+		t.Logf("removing container %s: %v", c.Image, err)
+	}
+	c.id = ""
+}
+
+// Host returns the address of the host running the containers.
+func (c *Container) Host() string {
+	return c.host
+}
+
+// Port returns the port of the host mapped to an exposed port.
+func (c *Container) Port(port string) string {
+	return c.ports[port]
+}
+
+// Address returns the host and port mapped to an exposed port.
+func (c *Container) Address(port string) string {
+	return net.JoinHostPort(c.host, c.ports[port])
+}
+
+// Logs returns the output of the container, both stdout and stderr.
+func (c *Container) Logs() (string, error) {
+	out, err := exec.Command("docker", "logs", c.id).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("docker logs: %v: %s", err, out)
+	}
+	return string(out), nil
+}
+
+// WaitForListeningPort waits until the exposed port accepts connections.
+func WaitForListeningPort(port string) WaitStrategy {
+	return func(c *Container) error {
+		conn, err := net.DialTimeout("tcp", c.Address(port), time.Second)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// WaitForLog waits until the output of the container contains the message
+// the number of times.
+func WaitForLog(message string, occurrences int) WaitStrategy {
+	return func(c *Container) error {
+		logs, err := c.Logs()
+		if err != nil {
+			return err
+		}
+		if n := strings.Count(logs, message); n < occurrences {
+			return fmt.Errorf("log message %q seen %d of %d times", message, n, occurrences)
+		}
+		return nil
+	}
+}
+
+// WaitForHTTP waits until a GET of the path on the exposed port returns a
+// 2xx status.
+func WaitForHTTP(port, path string) WaitStrategy {
+	client := &http.Client{Timeout: time.Second}
+	return func(c *Container) error {
+		resp, err := client.Get("http://" + c.Address(port) + path)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("%s returned HTTP status %s", path, resp.Status)
+		}
+		return nil
+	}
+}
+
+// WaitForAll waits until all the strategies succeed.
+func WaitForAll(strategies ...WaitStrategy) WaitStrategy {
+	return func(c *Container) error {
+		for _, wait := range strategies {
+			if err := wait(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// PostgresContainer returns a PostgreSQL server accepting the postgres user
+// without password on port 5432/tcp.
+func PostgresContainer() *Container {
+	return &Container{
+		Image: "postgres:alpine",
+		Ports: []string{"5432/tcp"},
+		Env:   map[string]string{"POSTGRES_HOST_AUTH_METHOD": "trust"},
+		// The server is restarted once after the initialization
+		WaitFor: WaitForAll(
+			WaitForLog("database system is ready to accept connections", 2),
+			WaitForListeningPort("5432/tcp"),
+		),
+	}
+}
+
+// RedisContainer returns a Redis server on port 6379/tcp.
+func RedisContainer() *Container {
+	return &Container{
+		Image: "redis:alpine",
+		Ports: []string{"6379/tcp"},
+		WaitFor: WaitForAll(
+			WaitForLog("Ready to accept connections", 1),
+			WaitForListeningPort("6379/tcp"),
+		),
+	}
+}
+
+// KafkaContainer returns a single node Kafka broker on port 9092/tcp.  The
+// broker advertises its address to the clients, so the port is mapped to
+// the same port of the host, picked among the free ports.
+func KafkaContainer(t *testing.T) *Container {
+	t.Helper()
+
+	port, err := freePort()
+	if err != nil {
+		t.Fatalf("finding a free port: %v", err)
+	}
+	host := GetLocalHost()
+	return &Container{
+		Image:     "bitnami/kafka:3.4",
+		Ports:     []string{"9092/tcp"},
+		HostPorts: map[string]string{"9092/tcp": port},
+		Env: map[string]string{
+			"KAFKA_ENABLE_KRAFT":                       "yes",
+			"KAFKA_CFG_NODE_ID":                        "1",
+			"KAFKA_CFG_PROCESS_ROLES":                  "broker,controller",
+			"KAFKA_CFG_CONTROLLER_LISTENER_NAMES":      "CONTROLLER",
+			"KAFKA_CFG_CONTROLLER_QUORUM_VOTERS":       "1@127.0.0.1:9093",
+			"KAFKA_CFG_LISTENERS":                      "PLAINTEXT://:9092,CONTROLLER://:9093",
+			"KAFKA_CFG_LISTENER_SECURITY_PROTOCOL_MAP": "CONTROLLER:PLAINTEXT,PLAINTEXT:PLAINTEXT",
+			"KAFKA_CFG_ADVERTISED_LISTENERS":           "PLAINTEXT://" + net.JoinHostPort(host, port),
+			"KAFKA_CFG_AUTO_CREATE_TOPICS_ENABLE":      "true",
+			"ALLOW_PLAINTEXT_LISTENER":                 "yes",
+		},
+		WaitFor: WaitForAll(
+			WaitForLog("Kafka Server started", 1),
+			WaitForListeningPort("9092/tcp"),
+		),
+		StartupTimeout: 2 * time.Minute,
+	}
+}
+
+func freePort() (string, error) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+	return strconv.Itoa(l.Addr().(*net.TCPAddr).Port), nil
+}
+
+func docker(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("docker %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
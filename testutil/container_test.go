@@ -0,0 +1,30 @@
+// +build integration
+
+package testutil
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisContainer(t *testing.T) {
+	c := RedisContainer()
+	c.Start(t)
+	defer c.Terminate(t)
+
+	require.NotEmpty(t, c.Port("6379/tcp"))
+
+	conn, err := net.DialTimeout("tcp", c.Address("6379/tcp"), 5*time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("PING\r\n"))
+	require.NoError(t, err)
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "+PONG\r\n", reply)
+}
@@ -0,0 +1,301 @@
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/stretchr/testify/require"
+)
+
+// UpdateGoldenEnv is the environment variable that, when set to true,
+// rewrites the golden files with the metrics produced by the tests instead of
+// comparing them.
+const UpdateGoldenEnv = "TELEGRAF_UPDATE_GOLDEN"
+
+// Cassette is a recording of HTTP interactions, replayed by the server
+// returned by NewCassetteServer.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.  Requests match when
+// the method and path are equal, and the query if it is recorded.
+type Interaction struct {
+	Request struct {
+		Method string `json:"method"`
+		Path   string `json:"path"`
+		Query  string `json:"query"`
+	} `json:"request"`
+	Response struct {
+		Status  int               `json:"status"`
+		Headers map[string]string `json:"headers"`
+		Body    string            `json:"body"`
+		// BodyFile is read for the body, relative to the cassette
+		BodyFile string `json:"body_file"`
+	} `json:"response"`
+}
+
+// LoadCassette reads a cassette from a JSON file.
+func LoadCassette(path string) (*Cassette, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Cassette
+	if err := json.Unmarshal(buf, &c); err != nil {
+		return nil, fmt.Errorf("parsing cassette %s: %v", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	for i := range c.Interactions {
+		resp := &c.Interactions[i].Response
+		if resp.BodyFile != "" {
+			body, err := ioutil.ReadFile(filepath.Join(dir, resp.BodyFile))
+			if err != nil {
+				return nil, err
+			}
+			resp.Body = string(body)
+		}
+		if resp.Status == 0 {
+			resp.Status = http.StatusOK
+		}
+	}
+	return &c, nil
+}
+
+// NewCassetteServer starts a server replaying the interactions of the
+// cassette.  Requests without a recorded interaction fail the test with a
+// 404 response.  The caller must close the server.
+func NewCassetteServer(t *testing.T, path string) *httptest.Server {
+	t.Helper()
+
+	c, err := LoadCassette(path)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, i := range c.Interactions {
+			method := i.Request.Method
+			if method == "" {
+				method = "GET"
+			}
+			if method != r.Method || i.Request.Path != r.URL.Path {
+				continue
+			}
+			if i.Request.Query != "" && i.Request.Query != r.URL.RawQuery {
+				continue
+			}
+
+			for k, v := range i.Response.Headers {
+				w.Header().Set(k, v)
+			}
+			w.WriteHeader(i.Response.Status)
+			w.Write([]byte(i.Response.Body))
+			return
+		}
+
+		t.Errorf("no interaction recorded in %s for %s %s", path, r.Method, r.URL.RequestURI())
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	return ts
+}
+
+// Normalizer modifies the metrics before they are compared with a golden
+// file, to remove values that change between runs.
+type Normalizer func(m telegraf.Metric)
+
+// NormalizeTime sets the time of the metrics.
+func NormalizeTime(tm time.Time) Normalizer {
+	return func(m telegraf.Metric) {
+		m.SetTime(tm)
+	}
+}
+
+// NormalizeFields replaces the values of the fields matching the glob
+// patterns with their zero value, keeping the type of the field.
+func NormalizeFields(patterns ...string) Normalizer {
+	f := mustCompile(patterns)
+	return func(m telegraf.Metric) {
+		for _, field := range m.FieldList() {
+			if f.Match(field.Key) {
+				m.AddField(field.Key, zeroValue(field.Value))
+			}
+		}
+	}
+}
+
+// DropFields removes the fields matching the glob patterns.
+func DropFields(patterns ...string) Normalizer {
+	f := mustCompile(patterns)
+	return func(m telegraf.Metric) {
+		for _, field := range append([]*telegraf.Field(nil), m.FieldList()...) {
+			if f.Match(field.Key) {
+				m.RemoveField(field.Key)
+			}
+		}
+	}
+}
+
+// DropTags removes the tags matching the glob patterns.
+func DropTags(patterns ...string) Normalizer {
+	f := mustCompile(patterns)
+	return func(m telegraf.Metric) {
+		for _, tag := range append([]*telegraf.Tag(nil), m.TagList()...) {
+			if f.Match(tag.Key) {
+				m.RemoveTag(tag.Key)
+			}
+		}
+	}
+}
+
+// RoundFloats rounds the float fields to the number of decimal places.
+func RoundFloats(places int) Normalizer {
+	scale := math.Pow10(places)
+	return func(m telegraf.Metric) {
+		for _, field := range m.FieldList() {
+			if v, ok := field.Value.(float64); ok {
+				m.AddField(field.Key, math.Round(v*scale)/scale)
+			}
+		}
+	}
+}
+
+func mustCompile(patterns []string) filter.Filter {
+	f, err := filter.Compile(patterns)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func zeroValue(v interface{}) interface{} {
+	switch v.(type) {
+	case int64:
+		return int64(0)
+	case uint64:
+		return uint64(0)
+	case float64:
+		return float64(0)
+	case string:
+		return ""
+	case bool:
+		return false
+	}
+	return v
+}
+
+// GatherGolden calls Init if the input has one, gathers the input once and
+// compares the metrics with the golden file.  Errors of the gather fail the
+// test.
+func GatherGolden(t *testing.T, input telegraf.Input, path string, normalizers ...Normalizer) {
+	t.Helper()
+
+	if i, ok := input.(telegraf.Initializer); ok {
+		require.NoError(t, i.Init())
+	}
+
+	var acc Accumulator
+	require.NoError(t, input.Gather(&acc))
+	require.Empty(t, acc.Errors)
+	RequireGolden(t, path, acc.GetTelegrafMetrics(), normalizers...)
+}
+
+// RequireGolden compares the metrics, in line protocol sorted by line, with
+// the content of the golden file.  The metrics are normalized first, the
+// metrics passed in are not modified.  When the TELEGRAF_UPDATE_GOLDEN
+// environment variable is set to true the file is written instead.
+func RequireGolden(t *testing.T, path string, metrics []telegraf.Metric, normalizers ...Normalizer) {
+	t.Helper()
+
+	lines := make([]string, 0, len(metrics))
+	for _, m := range metrics {
+		m = m.Copy()
+		for _, normalize := range normalizers {
+			normalize(m)
+		}
+		lines = append(lines, FormatMetric(m))
+	}
+	sort.Strings(lines)
+	actual := strings.Join(lines, "")
+
+	if update, _ := strconv.ParseBool(os.Getenv(UpdateGoldenEnv)); update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(actual), 0644))
+		return
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	require.NoError(t, err, "set %s=true to create the golden file", UpdateGoldenEnv)
+	require.Equal(t, string(expected), actual, "metrics differ from golden file %s", path)
+}
+
+var (
+	nameEscaper  = strings.NewReplacer(`,`, `\,`, ` `, `\ `, "\n", `\n`)
+	keyEscaper   = strings.NewReplacer(`,`, `\,`, ` `, `\ `, `=`, `\=`, "\n", `\n`)
+	valueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+)
+
+// FormatMetric returns the metric as a line of line protocol with sorted
+// tags and fields, so that equal metrics are always formatted the same.
+func FormatMetric(m telegraf.Metric) string {
+	var b strings.Builder
+	b.WriteString(nameEscaper.Replace(m.Name()))
+
+	tags := append([]*telegraf.Tag(nil), m.TagList()...)
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+	for _, tag := range tags {
+		b.WriteString(",")
+		b.WriteString(keyEscaper.Replace(tag.Key))
+		b.WriteString("=")
+		b.WriteString(keyEscaper.Replace(tag.Value))
+	}
+
+	fields := append([]*telegraf.Field(nil), m.FieldList()...)
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+	for i, field := range fields {
+		if i == 0 {
+			b.WriteString(" ")
+		} else {
+			b.WriteString(",")
+		}
+		b.WriteString(keyEscaper.Replace(field.Key))
+		b.WriteString("=")
+		switch v := field.Value.(type) {
+		case int64:
+			b.WriteString(strconv.FormatInt(v, 10) + "i")
+		case uint64:
+			b.WriteString(strconv.FormatUint(v, 10) + "u")
+		case float64:
+			b.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+		case string:
+			b.WriteString(`"` + valueEscaper.Replace(v) + `"`)
+		case bool:
+			b.WriteString(strconv.FormatBool(v))
+		default:
+			fmt.Fprintf(&b, "%v", v)
+		}
+	}
+
+	b.WriteString(" ")
+	b.WriteString(strconv.FormatInt(m.Time().UnixNano(), 10))
+	b.WriteString("\n")
+	return b.String()
+}
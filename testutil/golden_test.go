@@ -0,0 +1,86 @@
+package testutil
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/stretchr/testify/require"
+)
+
+// statsInput reads the stats and health of a server recorded in a cassette.
+type statsInput struct {
+	URL string
+}
+
+func (s *statsInput) Description() string  { return "" }
+func (s *statsInput) SampleConfig() string { return "" }
+
+func (s *statsInput) Gather(acc telegraf.Accumulator) error {
+	resp, err := http.Get(s.URL + "/stats?format=json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var stats struct {
+		Server   string  `json:"server"`
+		Requests int64   `json:"requests"`
+		Latency  float64 `json:"latency"`
+		Uptime   int64   `json:"uptime"`
+		Version  string  `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return err
+	}
+	tags := map[string]string{"server": stats.Server}
+	acc.AddFields("stats", map[string]interface{}{
+		"requests": stats.Requests,
+		"latency":  stats.Latency,
+		"uptime":   stats.Uptime,
+		"version":  stats.Version,
+	}, tags)
+
+	health, err := http.Get(s.URL + "/health")
+	if err != nil {
+		return err
+	}
+	health.Body.Close()
+	acc.AddFields("status", map[string]interface{}{"code": health.StatusCode}, tags)
+	return nil
+}
+
+func TestGatherGolden(t *testing.T) {
+	ts := NewCassetteServer(t, "testdata/cassette.json")
+	defer ts.Close()
+
+	GatherGolden(t, &statsInput{URL: ts.URL}, "testdata/stats.golden",
+		NormalizeTime(time.Unix(0, 0)),
+		NormalizeFields("uptime"),
+		RoundFloats(3),
+	)
+}
+
+func TestFormatMetric(t *testing.T) {
+	m := MustMetric("cpu",
+		map[string]string{"host": "a", "cpu": "cpu0"},
+		map[string]interface{}{"idle": 42.0, "user": 1.0},
+		time.Unix(42, 0),
+	)
+	require.Equal(t, "cpu,cpu=cpu0,host=a idle=42,user=1 42000000000\n", FormatMetric(m))
+
+	DropTags("host")(m.Copy())
+	DropFields("u*")(m.Copy())
+	require.Len(t, m.TagList(), 2)
+	require.Len(t, m.FieldList(), 2)
+}
+
+func TestLoadCassette(t *testing.T) {
+	c, err := LoadCassette("testdata/cassette.json")
+	require.NoError(t, err)
+	require.Len(t, c.Interactions, 2)
+	require.Equal(t, http.StatusOK, c.Interactions[0].Response.Status)
+	require.Contains(t, c.Interactions[0].Response.Body, `"server": "web 01"`)
+}